@@ -9,7 +9,7 @@
 # The update is skipped (with a warning, not an error) when the API is
 # unreachable or returns an unexpected response.
 # ---------------------------------------------------------------------------
-$dbPath = "internal\gamepad\gamecontrollerdb.txt"
+$dbPath = "pkg\gamepad\gamecontrollerdb.txt"
 $apiUrl = "https://api.github.com/repos/mdqinc/SDL_GameControllerDB/contents/gamecontrollerdb.txt"
 $rawUrl = "https://raw.githubusercontent.com/mdqinc/SDL_GameControllerDB/master/gamecontrollerdb.txt"
 
@@ -11,7 +11,7 @@ set -e
 # The update is skipped (with a warning, not an error) when curl/sha1sum/
 # shasum is unavailable or the API is unreachable.
 # ---------------------------------------------------------------------------
-DB_PATH="internal/gamepad/gamecontrollerdb.txt"
+DB_PATH="pkg/gamepad/gamecontrollerdb.txt"
 API_URL="https://api.github.com/repos/mdqinc/SDL_GameControllerDB/contents/gamecontrollerdb.txt"
 RAW_URL="https://raw.githubusercontent.com/mdqinc/SDL_GameControllerDB/master/gamecontrollerdb.txt"
 
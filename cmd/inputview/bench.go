@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lxzan/gws"
+	"github.com/soar/inputview/internal/config"
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/server"
+	"github.com/soar/inputview/internal/web"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+const (
+	// benchLatencySamples caps the number of lockstep round-trips used for the
+	// per-stage latency measurement (the throughput phase uses all events).
+	benchLatencySamples = 1000
+
+	// benchRecvTimeout is how long the bench waits for a message before
+	// declaring it lost.
+	benchRecvTimeout = 2 * time.Second
+
+	// benchIdleTimeout ends the throughput drain once no message has arrived
+	// for this long.
+	benchIdleTimeout = 250 * time.Millisecond
+)
+
+// benchMessage is a WebSocket message received by the loopback client.
+type benchMessage struct {
+	at time.Time
+}
+
+// benchClient is the gws event handler for the loopback WebSocket client.
+// Every state message is timestamped on arrival and forwarded to msgs.
+type benchClient struct {
+	gws.BuiltinEventHandler
+	msgs chan benchMessage
+}
+
+func (c *benchClient) OnMessage(socket *gws.Conn, message *gws.Message) {
+	defer message.Close()
+	at := time.Now()
+	var hdr struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(message.Bytes(), &hdr); err != nil {
+		return
+	}
+	if hdr.Type != "full" && hdr.Type != "delta" {
+		return
+	}
+	select {
+	case c.msgs <- benchMessage{at: at}:
+	default:
+	}
+}
+
+// benchState returns the i-th synthetic state. Button A toggles on every step
+// so each injected state yields exactly one non-empty delta; the left stick
+// sweeps a circle to exercise the analog fields as well.
+func benchState(i int) gamepad.GamepadState {
+	angle := float64(i) * 0.1
+	s := gamepad.GamepadState{
+		Connected:      true,
+		ControllerType: "xbox",
+		Name:           "Benchmark",
+		PlayerIndex:    1,
+	}
+	s.Buttons.A = i%2 == 0
+	s.Sticks.Left.Position.X = math.Cos(angle)
+	s.Sticks.Left.Position.Y = math.Sin(angle)
+	return s
+}
+
+// runBench drives synthetic state changes through the full pipeline
+// (Reader → Broadcaster → Hub → loopback WebSocket client) and writes a report
+// with throughput, per-stage latency, and allocation counts to w.
+//
+// Stages:
+//   - emit:    Reader.Inject → state leaves the reader's changes channel
+//   - deliver: changes channel → message received by the WebSocket client
+//     (delta computation, JSON encoding, hub fan-out, socket write and read)
+func runBench(cfg config.Config, w io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := gamepad.NewReader()
+	reader.SetChangesBuffer(cfg.ChangesBuffer)
+
+	h := hub.NewHub()
+	h.SetBroadcastBuffer(cfg.HubBuffer)
+	h.SetClientBuffer(cfg.ClientBuffer)
+	go h.Run(ctx)
+
+	// Tap between the reader and the broadcaster: timestamps each state as it
+	// leaves the changes channel so the emit and deliver stages can be split.
+	tapped := make(chan gamepad.GamepadState, 64)
+	tapTimes := make(chan time.Time, 1)
+	var lockstep atomic.Bool
+	var tapCount atomic.Int64
+	go func() {
+		defer close(tapped)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s := <-reader.Changes():
+				if lockstep.Load() {
+					tapTimes <- time.Now()
+				}
+				tapCount.Add(1)
+				tapped <- s
+			}
+		}
+	}()
+
+	broadcaster := hub.NewBroadcaster(h, tapped, nil)
+	go broadcaster.Run()
+
+	srv := server.New(h, broadcaster, reader, nil, web.FrontendFS(), web.GzipCache(), ".", cfg.OverlayDir, cfg.KeyboardDir, "")
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	client := &benchClient{msgs: make(chan benchMessage, cfg.BenchEvents+benchLatencySamples+16)}
+	conn, _, err := gws.NewClient(client, &gws.ClientOption{
+		Addr: "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws",
+	})
+	if err != nil {
+		return fmt.Errorf("connect loopback client: %w", err)
+	}
+	go conn.ReadLoop()
+	defer conn.WriteClose(1000, nil)
+
+	// The server sends the current full state on connect.
+	if _, ok := benchRecv(client.msgs, benchRecvTimeout); !ok {
+		return fmt.Errorf("no initial state received from server")
+	}
+
+	// --- Phase 1: lockstep latency ---
+	samples := min(cfg.BenchEvents, benchLatencySamples)
+	emitLat := make([]time.Duration, 0, samples)
+	deliverLat := make([]time.Duration, 0, samples)
+	totalLat := make([]time.Duration, 0, samples)
+	lockstep.Store(true)
+	for i := range samples {
+		start := time.Now()
+		reader.Inject(benchState(i))
+		var tapAt time.Time
+		select {
+		case tapAt = <-tapTimes:
+		case <-time.After(benchRecvTimeout):
+			return fmt.Errorf("latency phase: state %d never left the reader", i)
+		}
+		msg, ok := benchRecv(client.msgs, benchRecvTimeout)
+		if !ok {
+			return fmt.Errorf("latency phase: state %d never reached the client", i)
+		}
+		emitLat = append(emitLat, tapAt.Sub(start))
+		deliverLat = append(deliverLat, msg.at.Sub(tapAt))
+		totalLat = append(totalLat, msg.at.Sub(start))
+	}
+	lockstep.Store(false)
+
+	// --- Phase 2: burst throughput ---
+	tapBase := tapCount.Load()
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := range cfg.BenchEvents {
+		reader.Inject(benchState(samples + i))
+	}
+	injectDur := time.Since(start)
+
+	// Drain until the stream goes idle; anything still missing by then is lost.
+	received := 0
+	var lastAt time.Time
+	for {
+		msg, ok := benchRecv(client.msgs, benchIdleTimeout)
+		if !ok {
+			break
+		}
+		received++
+		lastAt = msg.at
+	}
+	runtime.ReadMemStats(&after)
+	passed := tapCount.Load() - tapBase
+
+	// --- Report ---
+	fmt.Fprintf(w, "InputView pipeline benchmark (%s/%s, %s)\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+	fmt.Fprintf(w, "\nLatency (%d lockstep round-trips)\n", samples)
+	fmt.Fprintf(w, "  %-8s %10s %10s %10s %10s\n", "stage", "p50", "p95", "p99", "max")
+	benchPrintLatency(w, "emit", emitLat)
+	benchPrintLatency(w, "deliver", deliverLat)
+	benchPrintLatency(w, "total", totalLat)
+
+	fmt.Fprintf(w, "\nThroughput (%d injected back-to-back)\n", cfg.BenchEvents)
+	fmt.Fprintf(w, "  inject rate:      %.0f states/s\n", float64(cfg.BenchEvents)/injectDur.Seconds())
+	if received > 0 {
+		fmt.Fprintf(w, "  delivery rate:    %.0f msgs/s\n", float64(received)/lastAt.Sub(start).Seconds())
+	}
+	fmt.Fprintf(w, "  passed reader:    %d (%d coalesced/dropped in changes mailbox)\n", passed, int64(cfg.BenchEvents)-passed)
+	fmt.Fprintf(w, "  received:         %d (%d lost after reader)\n", received, passed-int64(received))
+
+	fmt.Fprintf(w, "\nAllocations (throughput phase, whole process)\n")
+	fmt.Fprintf(w, "  allocs/state:     %.1f\n", float64(after.Mallocs-before.Mallocs)/float64(cfg.BenchEvents))
+	fmt.Fprintf(w, "  bytes/state:      %.0f\n", float64(after.TotalAlloc-before.TotalAlloc)/float64(cfg.BenchEvents))
+	if received > 0 {
+		fmt.Fprintf(w, "  allocs/message:   %.1f\n", float64(after.Mallocs-before.Mallocs)/float64(received))
+	}
+	fmt.Fprintf(w, "  GC cycles:        %d\n", after.NumGC-before.NumGC)
+	return nil
+}
+
+// benchRecv waits up to timeout for the next client message.
+func benchRecv(msgs <-chan benchMessage, timeout time.Duration) (benchMessage, bool) {
+	select {
+	case m := <-msgs:
+		return m, true
+	case <-time.After(timeout):
+		return benchMessage{}, false
+	}
+}
+
+// benchPrintLatency writes one latency table row with p50/p95/p99/max of d.
+func benchPrintLatency(w io.Writer, stage string, d []time.Duration) {
+	if len(d) == 0 {
+		return
+	}
+	slices.Sort(d)
+	pct := func(p float64) time.Duration {
+		return d[min(len(d)-1, int(float64(len(d))*p))]
+	}
+	fmt.Fprintf(w, "  %-8s %10s %10s %10s %10s\n", stage,
+		pct(0.50).Round(time.Microsecond), pct(0.95).Round(time.Microsecond),
+		pct(0.99).Round(time.Microsecond), d[len(d)-1].Round(time.Microsecond))
+}
+
+// runBenchAndExit runs the benchmark with the given config and exits the process.
+func runBenchAndExit(cfg config.Config) {
+	if err := runBench(cfg, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "bench error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -6,17 +6,19 @@ import (
 	"log/slog"
 	"runtime"
 
+	"github.com/soar/inputview/internal/audit"
 	"github.com/soar/inputview/internal/console"
+	"github.com/soar/inputview/internal/server"
 )
 
 // guiMode is false in dev/console builds (default).
 const guiMode = false
 
 // setupShutdown sets up console-mode shutdown handling.
-// exeDir is passed for API symmetry with the release build; it is not used in
-// dev/console mode.
+// exeDir, addr, noTray, srv, and auditLog are passed for API symmetry with the release build;
+// they are not used in dev/console mode (which never has a tray).
 // Returns a channel that is closed on Ctrl+C / Ctrl+Break (Windows).
-func setupShutdown(exeDir string) <-chan struct{} {
+func setupShutdown(exeDir, addr string, noTray bool, srv *server.Server, auditLog *audit.Log) <-chan struct{} {
 	ch := make(chan struct{}, 1)
 	console.SetupConsoleHandler(ch)
 
@@ -3,10 +3,13 @@
 package main
 
 import (
+	"net"
 	"path/filepath"
 	"runtime"
 
+	"github.com/soar/inputview/internal/audit"
 	"github.com/soar/inputview/internal/overlay"
+	"github.com/soar/inputview/internal/server"
 	"github.com/soar/inputview/internal/tray"
 )
 
@@ -15,15 +18,23 @@ const guiMode = true
 
 // setupShutdown sets up GUI-mode shutdown handling via system tray (Windows).
 // Returns a channel closed when the user requests exit from the tray menu.
-// Returns nil on non-Windows platforms (only OS signals are used).
-func setupShutdown(exeDir string) <-chan struct{} {
-	if runtime.GOOS == "windows" {
+// Returns nil on non-Windows platforms and with noTray (only OS signals are
+// used). The tray's URLs use the port of addr, the main listen address; its
+// "Allow Remote Connections" item toggles srv's remote access and records the
+// change in auditLog.
+func setupShutdown(exeDir, addr string, noTray bool, srv *server.Server, auditLog *audit.Log) <-chan struct{} {
+	if runtime.GOOS == "windows" && !noTray {
+		_, port, _ := net.SplitHostPort(addr)
 		overlays := overlay.ScanDir(filepath.Join(exeDir, "overlays"))
 		ch := make(chan struct{})
 		go func() {
 			t := tray.New(func() {
 				close(ch)
-			}, overlays, ":8080")
+			}, overlays, ":"+port)
+			t.SetRemoteAccess(srv.RemoteAllowed(), func(allowed bool) {
+				auditLog.Record(audit.Entry{Action: audit.ActionRemoteAccess, Source: audit.SourceTray, Details: map[string]any{"allowed": allowed}})
+				srv.SetRemoteAllowed(allowed)
+			})
 			t.Run(tray.GetIcon())
 		}()
 		return ch
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/soar/inputview/internal/config"
+	"github.com/soar/inputview/internal/rawinput"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// runDevices starts the input readers for selftestDetectWait, then writes
+// what InputView knows about every detected controller to w: identity (name,
+// GUID, VID/PID, serial, product version), raw input counts, and the mapping
+// path that was chosen.
+// No server, hub, or tray is started.
+func runDevices(cfg config.Config, exeDir string, w io.Writer) {
+	fmt.Fprintf(w, "InputView devices (%s/%s)\n\n", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS != "windows" {
+		fmt.Fprintln(w, "controller input is only implemented on Windows; no devices to list")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gamepad.LoadSDLDB(filepath.Join(exeDir, cfg.SDLDBPath))
+	if err := gamepad.LoadMappingDir(filepath.Join(exeDir, cfg.MappingsDir)); err != nil {
+		fmt.Fprintf(w, "warning: %v\n", err)
+	}
+	reader := gamepad.NewReader()
+	reader.SetPollDelay(time.Duration(cfg.PollRate) * time.Millisecond)
+	kmReader := rawinput.New()
+	reader.SetRawInputReader(kmReader)
+	go reader.Run(ctx)
+	go kmReader.Run(ctx)
+	time.Sleep(selftestDetectWait)
+
+	controllers := reader.Controllers()
+	if len(controllers) == 0 {
+		fmt.Fprintln(w, "no controllers detected (plug one in and press a button, then run again)")
+		return
+	}
+	for i, c := range controllers {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		active := ""
+		if c.Active {
+			active = "  [active]"
+		}
+		fmt.Fprintf(w, "P%d %s%s\n", c.PlayerIndex, c.Name, active)
+		fmt.Fprintf(w, "    source:   %s\n", c.Source)
+		if c.GUID != "" {
+			fmt.Fprintf(w, "    GUID:     %s\n", c.GUID)
+			fmt.Fprintf(w, "    VID/PID:  %04X:%04X\n", c.VendorID, c.ProductID)
+		} else {
+			fmt.Fprintf(w, "    VID/PID:  unknown\n")
+		}
+		if c.Serial != "" {
+			fmt.Fprintf(w, "    serial:   %s\n", c.Serial)
+		}
+		if c.ProductVersion != 0 {
+			fmt.Fprintf(w, "    version:  0x%04X\n", c.ProductVersion)
+		}
+		fmt.Fprintf(w, "    inputs:   %d axes, %d buttons, %d hats\n", c.Axes, c.Buttons, c.Hats)
+		fmt.Fprintf(w, "    mapping:  %s → controller type %q\n", devicesMappingLabel(c), c.ControllerType)
+	}
+}
+
+// devicesMappingLabel describes ControllerInfo.Mapping for humans.
+func devicesMappingLabel(c gamepad.ControllerInfo) string {
+	switch c.Mapping {
+	case "xinput":
+		return "xinput (fixed XInput layout)"
+	case "sdl_db":
+		return fmt.Sprintf("sdl_db (GameControllerDB entry %q)", c.SDLName)
+	case "builtin":
+		return "builtin (VID/PID table or generic HID defaults; no GameControllerDB entry for this GUID)"
+	case "custom":
+		return "custom (mapping file in --mappings-dir)"
+	case "nintendo":
+		return "nintendo (custom report parser)"
+	default:
+		return c.Mapping
+	}
+}
+
+// runDevicesAndExit lists the detected controllers and exits the process.
+func runDevicesAndExit(cfg config.Config, exeDir string) {
+	runDevices(cfg, exeDir, os.Stdout)
+	os.Exit(0)
+}
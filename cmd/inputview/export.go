@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/soar/inputview/internal/config"
+	"github.com/soar/inputview/internal/export"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// runExport converts the --export-input recording into --export-format and
+// writes it to --export-out (by default next to the recording), timed by
+// --export-sync. A summary line goes to w.
+func runExport(cfg config.Config, w io.Writer) error {
+	f, err := os.Open(cfg.ExportInput)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entries, err := export.Entries(f, cfg.Deadzone)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cfg.ExportInput, err)
+	}
+
+	var offset time.Duration // output time of the capture start
+	switch cfg.ExportSync {
+	case "":
+	case "wall":
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		start, err := gamepad.CaptureStart(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cfg.ExportInput, err)
+		}
+		local := start.Local()
+		offset = local.Sub(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location()))
+	default:
+		sync, _ := time.ParseDuration(cfg.ExportSync) // validated by config.Load
+		offset = sync - entries[0].At
+	}
+
+	out := cfg.ExportOut
+	if out == "" {
+		out = strings.TrimSuffix(cfg.ExportInput, filepath.Ext(cfg.ExportInput)) + "." + cfg.ExportFormat
+	}
+	if out == cfg.ExportInput {
+		return fmt.Errorf("--export-out would overwrite the recording %s", out)
+	}
+	o, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSuffix(filepath.Base(cfg.ExportInput), filepath.Ext(cfg.ExportInput))
+	if err := export.Write(o, cfg.ExportFormat, entries, offset, cfg.ExportFPS, title); err != nil {
+		o.Close()
+		return err
+	}
+	if err := o.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "wrote %d %s entries to %s\n", len(entries), cfg.ExportFormat, out)
+	return nil
+}
+
+// runExportAndExit runs the export command and exits the process.
+func runExportAndExit(cfg config.Config) {
+	if err := runExport(cfg, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "export error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
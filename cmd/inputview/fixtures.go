@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/soar/inputview/internal/config"
+	"github.com/soar/inputview/internal/hub"
+)
+
+// fixturesManifest is manifest.json in a protocol version directory.
+type fixturesManifest struct {
+	ProtocolVersion int                    `json:"protocolVersion"`
+	Fixtures        []fixturesManifestItem `json:"fixtures"`
+}
+
+type fixturesManifestItem struct {
+	hub.Fixture
+	File       string `json:"file"`
+	BinaryFile string `json:"binaryFile,omitempty"` // server fixtures only
+	Messages   int    `json:"messages"`
+}
+
+// runFixtures writes the canonical protocol example messages to
+// <dir>/v<ProtocolVersion>/: one <name>.jsonl per fixture (one message per
+// line, encoded exactly as sent on the wire), for server fixtures also a
+// <name>.msgpack with the same messages as MessagePack clients get them (one
+// value per frame, back to back), plus a manifest.json describing them.
+// Existing files with the same names are overwritten.
+func runFixtures(dir string, w io.Writer) error {
+	outDir := filepath.Join(dir, fmt.Sprintf("v%d", hub.ProtocolVersion))
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	manifest := fixturesManifest{ProtocolVersion: hub.ProtocolVersion}
+	for _, f := range hub.ProtocolFixtures() {
+		var buf, bin []byte
+		for _, m := range f.Messages {
+			data, err := json.Marshal(m)
+			if err != nil {
+				return fmt.Errorf("fixture %s: %w", f.Name, err)
+			}
+			buf = append(append(buf, data...), '\n')
+			if f.Direction == hub.FixtureServer {
+				frame, err := hub.EncodeMsgpack(data)
+				if err != nil {
+					return fmt.Errorf("fixture %s: %w", f.Name, err)
+				}
+				bin = append(bin, frame...)
+			}
+		}
+		item := fixturesManifestItem{Fixture: f, File: f.Name + ".jsonl", Messages: len(f.Messages)}
+		if err := os.WriteFile(filepath.Join(outDir, item.File), buf, 0o644); err != nil {
+			return err
+		}
+		if bin != nil {
+			item.BinaryFile = f.Name + ".msgpack"
+			if err := os.WriteFile(filepath.Join(outDir, item.BinaryFile), bin, 0o644); err != nil {
+				return err
+			}
+		}
+		manifest.Fixtures = append(manifest.Fixtures, item)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "manifest.json"), append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "wrote %d protocol v%d fixtures to %s\n", len(manifest.Fixtures), hub.ProtocolVersion, outDir)
+	return nil
+}
+
+// runFixturesAndExit writes the fixtures to cfg.FixturesDir and exits the process.
+func runFixturesAndExit(cfg config.Config) {
+	if err := runFixtures(cfg.FixturesDir, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "fixtures error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
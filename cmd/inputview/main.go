@@ -1,22 +1,34 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/soar/inputview/internal/audit"
 	"github.com/soar/inputview/internal/config"
-	"github.com/soar/inputview/internal/gamepad"
 	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/marker"
+	"github.com/soar/inputview/internal/plugin"
 	"github.com/soar/inputview/internal/rawinput"
+	"github.com/soar/inputview/internal/relay"
 	"github.com/soar/inputview/internal/server"
+	"github.com/soar/inputview/internal/udpout"
+	"github.com/soar/inputview/internal/vigem"
 	"github.com/soar/inputview/internal/web"
+	"github.com/soar/inputview/pkg/gamepad"
 )
 
 func main() {
@@ -42,23 +54,96 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Container mode: log JSON lines to stdout for the container runtime's
+	// log collector.
+	if cfg.Container {
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slogLevel})))
+	}
+
+	// Benchmark mode: run synthetic input through the pipeline and exit before
+	// any real device, tray, or listener is set up. Quiet the per-client logs.
+	if cfg.Bench {
+		slogLevel.Set(slog.LevelWarn)
+		runBenchAndExit(cfg)
+	}
+
+	// Subcommands run their own short-lived pipeline and exit.
+	switch cfg.Command {
+	case "selftest":
+		slogLevel.Set(slog.LevelWarn)
+		runSelftestAndExit(cfg, appExeDir)
+	case "fixtures":
+		runFixturesAndExit(cfg)
+	case "export":
+		runExportAndExit(cfg)
+	case "devices":
+		slogLevel.Set(slog.LevelWarn)
+		runDevicesAndExit(cfg, appExeDir)
+	}
+
+	// Audit log of control actions (memory only unless --audit-log is set).
+	auditLog, err := audit.New(cfg.AuditLog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit log error: %v\n", err)
+		os.Exit(1)
+	}
+	defer auditLog.Close()
+
 	// Create cancellable context
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Create gamepad reader and apply config settings.
 	reader := gamepad.NewReader()
-	reader.SetDeadzone(cfg.Deadzone)
+	if err := setDeadzones(reader, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "deadzones error: %v\n", err)
+		os.Exit(1)
+	}
+	calibPath := cfg.CalibrationFile
+	if calibPath != "" {
+		if !filepath.IsAbs(calibPath) {
+			calibPath = filepath.Join(appExeDir, calibPath)
+		}
+		reader.SetTriggerCalibration(true)
+		if err := loadTriggerCalibration(reader, calibPath); err != nil {
+			fmt.Fprintf(os.Stderr, "trigger calibration error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	reader.SetPollDelay(time.Duration(cfg.PollRate) * time.Millisecond)
+	reader.SetPollSpin(cfg.PollSpin)
+	reader.SetChangesBuffer(cfg.ChangesBuffer)
+	reader.SetAllPlayers(cfg.AllPlayers)
+	reader.SetMotion(cfg.Motion)
+	reader.SetJoyConSideways(cfg.JoyConSideways)
+	// With a record binding, --capture-raw names the recordings the binding
+	// starts instead of one recording from launch.
+	var rec *recorder
+	if slices.ContainsFunc(slices.Collect(maps.Values(cfg.Bindings)), func(bc config.BindingConfig) bool { return bc.Action == "record" }) {
+		rec = &recorder{reader: reader, auditLog: auditLog, path: cfg.CaptureRaw}
+		defer rec.stop()
+	} else if cfg.CaptureRaw != "" {
+		f, err := os.Create(cfg.CaptureRaw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "capture error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		reader.SetCaptureWriter(f)
+		slog.Info("recording raw gamepad input", "file", cfg.CaptureRaw)
+		auditLog.Record(audit.Entry{Action: audit.ActionCaptureStart, Source: audit.SourceConfig, Details: map[string]any{"file": cfg.CaptureRaw}})
+		defer auditLog.Record(audit.Entry{Action: audit.ActionCaptureStop, Source: audit.SourceServer, Details: map[string]any{"file": cfg.CaptureRaw}})
+	}
 
 	// Load SDL GameControllerDB. The embedded database is always used as a base;
 	// an external gamecontrollerdb.txt next to the executable (if present) is
 	// merged on top so users can update mappings without recompiling.
 	sdlDBPath := filepath.Join(appExeDir, cfg.SDLDBPath)
 	gamepad.LoadSDLDB(sdlDBPath)
-
-	// Set up shutdown handling (console Ctrl+C or system tray, depending on build mode).
-	extraShutdownCh := setupShutdown(appExeDir)
+	// Custom device mappings (mappings/*.json) win over both for their devices.
+	if err := gamepad.LoadMappingDir(filepath.Join(appExeDir, cfg.MappingsDir)); err != nil {
+		slog.Warn("skipped invalid custom mapping files", "error", err)
+	}
 
 	// Handle OS signals (Ctrl+C on Unix, SIGTERM everywhere)
 	sigCh := make(chan os.Signal, 1)
@@ -66,6 +151,11 @@ func main() {
 
 	// Create and start hub
 	h := hub.NewHub()
+	h.SetBroadcastBuffer(cfg.HubBuffer)
+	h.SetClientBuffer(cfg.ClientBuffer)
+	h.SetUpdateRate(cfg.UpdateRate)
+	h.SetKeepalive(time.Duration(cfg.PingInterval)*time.Second, time.Duration(cfg.PingTimeout)*time.Second)
+	h.SetAuditLog(auditLog)
 	hubDone := make(chan struct{})
 	go func() {
 		h.Run(ctx)
@@ -84,6 +174,112 @@ func main() {
 
 	// Create broadcaster (listens to both gamepad and keyboard/mouse channels)
 	broadcaster := hub.NewBroadcaster(h, reader.Changes(), kmReader.Changes())
+	broadcaster.SetBatteryThresholds(cfg.BatteryThresholds)
+	broadcaster.SetProfiles(profileTransforms(cfg.Profiles))
+	broadcaster.SetControllerEvents(reader.Events())
+	broadcaster.SetAllPlayers(cfg.AllPlayers)
+	if cfg.CompareReplay != "" {
+		track, err := loadPressTrack(cfg.CompareReplay, cfg.Deadzone)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "compare replay error: %v\n", err)
+			os.Exit(1)
+		}
+		broadcaster.SetComparison(hub.NewComparison(track,
+			time.Duration(cfg.CompareWindow)*time.Millisecond, time.Duration(cfg.CompareTolerance)*time.Millisecond))
+		slog.Info("comparing input against recording", "file", cfg.CompareReplay, "presses", len(track))
+	}
+	if cfg.GhostReplay != "" {
+		frames, err := loadRecording(cfg.GhostReplay, cfg.Deadzone)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ghost replay error: %v\n", err)
+			os.Exit(1)
+		}
+		broadcaster.SetGhost(hub.NewGhost(filepath.Base(cfg.GhostReplay), frames))
+		slog.Info("playing recording as ghost", "file", cfg.GhostReplay, "frames", len(frames))
+	}
+	var relays []*relay.Relay
+	for i, url := range cfg.RelayFrom {
+		r, err := relay.New(url, cfg.RelayPlayer, i+1)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "relay error: %v\n", err)
+			os.Exit(1)
+		}
+		relays = append(relays, r)
+	}
+	var replayFrames []hub.Frame
+	if cfg.Replay != "" {
+		if replayFrames, err = loadRecording(cfg.Replay, cfg.Deadzone); err != nil {
+			fmt.Fprintf(os.Stderr, "replay error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.Scenario != "" {
+		if replayFrames, err = loadScenario(cfg.Scenario); err != nil {
+			fmt.Fprintf(os.Stderr, "scenario error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	markersFile := ""
+	if cfg.CaptureRaw != "" {
+		markersFile = marker.FileFor(cfg.CaptureRaw)
+	}
+	markers, err := marker.New(markersFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "marker error: %v\n", err)
+		os.Exit(1)
+	}
+	defer markers.Close()
+	combos, err := markerCombos(cfg.MarkerCombos)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "marker combo error: %v\n", err)
+		os.Exit(1)
+	}
+	broadcaster.SetMarkers(markers, combos)
+	if cfg.FreezeCombo != "" {
+		controls, err := hub.ParseCombo(cfg.FreezeCombo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "freeze combo error: %v\n", err)
+			os.Exit(1)
+		}
+		broadcaster.SetFreezeCombo(controls, time.Duration(cfg.FreezeTimeout)*time.Second)
+	}
+	bindings, err := comboBindings(cfg, h, broadcaster, reader, rec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "binding error: %v\n", err)
+		os.Exit(1)
+	}
+	broadcaster.SetBindings(bindings)
+	sequences, err := inputSequences(cfg.Sequences)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sequence error: %v\n", err)
+		os.Exit(1)
+	}
+	broadcaster.SetSequences(sequences)
+	broadcaster.SetHistory(time.Duration(cfg.History) * time.Second)
+	broadcaster.SetStatsInterval(time.Duration(cfg.StatsInterval) * time.Second)
+	plugins, err := startPlugins(ctx, cfg.Plugins, h, broadcaster)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "plugin error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.UDPOut != "" {
+		sender, err := udpout.New(cfg.UDPOut, cfg.UDPRate, broadcaster.ShownStates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "udp output error: %v\n", err)
+			os.Exit(1)
+		}
+		slog.Info("sending state over UDP", "addr", cfg.UDPOut, "rate", cfg.UDPRate)
+		go sender.Run(ctx)
+	}
+	if cfg.ViGEm {
+		pad, err := vigem.Connect()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "vigem error: %v\n", err)
+			os.Exit(1)
+		}
+		slog.Info("mirroring the active controller to a virtual Xbox 360 controller")
+		go vigem.Mirror(ctx, pad, time.Duration(cfg.PollRate)*time.Millisecond, reader.State, reader.SetIgnoredXInputSlot)
+	}
 	broadcasterDone := make(chan struct{})
 	go func() {
 		broadcaster.Run()
@@ -92,6 +288,27 @@ func main() {
 
 	// Create and start HTTP server
 	srv := server.New(h, broadcaster, reader, kmReader, web.FrontendFS(), web.GzipCache(), appExeDir, cfg.OverlayDir, cfg.KeyboardDir, cfg.Addr)
+	srv.SetInjectEnabled(cfg.EnableInject)
+	srv.SetDebug(cfg.Debug)
+	srv.SetHeadless(cfg.Headless)
+	srv.SetBasicAuth(cfg.AuthUser, cfg.AuthPassword)
+	srv.SetAllowedNets(parsePrefixes(cfg.AllowCIDR))
+	srv.SetTrustedProxies(parsePrefixes(cfg.TrustedProxies))
+	srv.SetRemoteAllowed(cfg.AllowRemote)
+	srv.SetTLS(cfg.TLSCert, cfg.TLSKey)
+	for _, name := range slices.Sorted(maps.Keys(cfg.Listeners)) {
+		l := cfg.Listeners[name]
+		srv.AddListener(server.Listener{
+			Name: name, Addr: l.Addr, TLSCert: l.TLSCert, TLSKey: l.TLSKey,
+			AuthUser: l.AuthUser, AuthPassword: l.AuthPassword,
+		})
+	}
+	srv.SetAuditLog(auditLog)
+
+	// Set up shutdown handling (console Ctrl+C or system tray, depending on build
+	// mode). Headless and container modes and --no-tray never create a tray.
+	// The tray can toggle remote access on srv, recorded in auditLog.
+	extraShutdownCh := setupShutdown(appExeDir, cfg.Addr, cfg.Headless || cfg.Container || cfg.NoTray, srv, auditLog)
 	serverErrCh := make(chan error, 1)
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -99,7 +316,11 @@ func main() {
 		}
 	}()
 
-	slog.Info("InputView started", "addr", "http://localhost"+cfg.Addr)
+	scheme := "http"
+	if cfg.TLSCert != "" {
+		scheme = "https"
+	}
+	slog.Info("InputView started", "addr", scheme+"://localhost"+cfg.Addr, "listeners", 1+len(cfg.Listeners), "headless", cfg.Headless, "container", cfg.Container)
 
 	// Run gamepad reader (XInput polling loop, ~60 Hz)
 	readerDone := make(chan struct{})
@@ -108,6 +329,27 @@ func main() {
 		close(readerDone)
 	}()
 
+	// Play a recording or scenario as the live input (--replay, --scenario)
+	if replayFrames != nil {
+		slog.Info("replaying recording as live input", "file", cmp.Or(cfg.Replay, cfg.Scenario), "frames", len(replayFrames), "speed", cfg.ReplaySpeed, "loop", cfg.ReplayLoop)
+		go hub.PlayRecording(ctx, replayFrames, cfg.ReplaySpeed, cfg.ReplayLoop, reader.Inject)
+	}
+
+	// Show remote instances' controllers as the live input (--relay-from),
+	// one player each
+	if len(relays) > 0 {
+		slog.Info("relaying input from remote instances", "instances", len(relays), "player", cfg.RelayPlayer)
+	}
+	for _, r := range relays {
+		go r.Run(ctx, reader.Inject)
+	}
+
+	// Play a generated demo controller as the live input (--demo)
+	if cfg.Demo {
+		slog.Info("playing demo controller as live input")
+		go hub.PlayDemo(ctx, reader.Inject)
+	}
+
 	// Run keyboard/mouse Raw Input reader in a separate goroutine
 	// (also uses LockOSThread internally on Windows for the message loop)
 	kmReaderDone := make(chan struct{})
@@ -116,6 +358,9 @@ func main() {
 		close(kmReaderDone)
 	}()
 
+	// Stop polling at full rate, or exit, while nobody uses the server
+	idleExitCh := watchIdle(ctx, cfg, h, broadcaster, reader)
+
 	// Wait for any shutdown trigger
 	if extraShutdownCh != nil {
 		select {
@@ -123,6 +368,8 @@ func main() {
 			slog.Info("shutting down")
 		case <-extraShutdownCh:
 			slog.Info("shutdown requested")
+		case <-idleExitCh:
+			slog.Info("idle timeout reached, shutting down", "minutes", cfg.IdleTimeout)
 		case err := <-serverErrCh:
 			slog.Error("HTTP server error", "error", err)
 		}
@@ -130,36 +377,317 @@ func main() {
 		select {
 		case <-sigCh:
 			slog.Info("shutting down")
+		case <-idleExitCh:
+			slog.Info("idle timeout reached, shutting down", "minutes", cfg.IdleTimeout)
 		case err := <-serverErrCh:
 			slog.Error("HTTP server error", "error", err)
 		}
 	}
+	shutdownTimeout := time.Duration(cfg.ShutdownTimeout) * time.Second
+
+	// Graceful HTTP server shutdown: stop accepting connections, then tell the
+	// WebSocket clients (which http.Server.Shutdown does not track) and wait
+	// for them to disconnect. The hub must still run to unregister them.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP server shutdown error", "error", err)
+	}
+	if n := h.Drain(shutdownCtx); n > 0 {
+		slog.Warn("timed out waiting for WebSocket clients to disconnect", "remaining", n)
+	}
 	cancel()
 
 	// Wait for reader to finish
 	<-readerDone
+	if calibPath != "" {
+		if err := saveTriggerCalibration(reader, calibPath); err != nil {
+			slog.Error("saving trigger calibration", "file", calibPath, "error", err)
+		}
+	}
 	select {
 	case <-kmReaderDone:
-	case <-time.After(5 * time.Second):
+	case <-time.After(shutdownTimeout):
 		slog.Warn("timed out waiting for keyboard/mouse reader shutdown")
 	}
 	select {
 	case <-broadcasterDone:
-	case <-time.After(5 * time.Second):
+	case <-time.After(shutdownTimeout):
 		slog.Warn("timed out waiting for broadcaster shutdown")
 	}
 	select {
 	case <-hubDone:
-	case <-time.After(5 * time.Second):
+	case <-time.After(shutdownTimeout):
 		slog.Warn("timed out waiting for hub shutdown")
 	}
-
-	// Graceful HTTP server shutdown
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer shutdownCancel()
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		slog.Error("HTTP server shutdown error", "error", err)
+	for _, p := range plugins {
+		<-p.Done() // killed after plugin.stopTimeout at the latest
 	}
 
 	slog.Info("InputView stopped")
 }
+
+// profileTransforms converts the configured output profiles to hub transforms.
+func profileTransforms(profiles map[string]config.ProfileConfig) map[string]hub.Transform {
+	m := make(map[string]hub.Transform, len(profiles))
+	for name, p := range profiles {
+		m[name] = hub.Transform{
+			Mirror: p.Mirror, Rotate: p.Rotate, SwapShoulders: p.SwapShoulders,
+			SwapSticks: p.SwapSticks, SwapTriggers: p.SwapTriggers,
+		}
+	}
+	return m
+}
+
+// setDeadzones applies deadzone, trigger-deadzone, deadzone-mode, and the
+// [deadzones] tables of cfg to reader.
+func setDeadzones(reader *gamepad.Reader, cfg config.Config) error {
+	global := gamepad.Deadzones{Stick: cfg.Deadzone, Trigger: cfg.TriggerDeadzone, Mode: cfg.DeadzoneMode}
+	reader.SetDeadzones(global)
+	for _, guid := range slices.Sorted(maps.Keys(cfg.Deadzones)) {
+		d, dz := cfg.Deadzones[guid], global
+		if d.Stick != nil {
+			dz.Stick = *d.Stick
+		}
+		if d.Trigger != nil {
+			dz.Trigger = *d.Trigger
+		}
+		if d.Mode != "" {
+			dz.Mode = d.Mode
+		}
+		if err := reader.SetDeviceDeadzones(guid, dz); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTriggerCalibration loads the trigger ranges saved in path, if it exists.
+func loadTriggerCalibration(reader *gamepad.Reader, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := reader.LoadTriggerCalibration(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	slog.Info("loaded trigger calibration", "file", path)
+	return nil
+}
+
+// saveTriggerCalibration writes the learned trigger ranges to path, through a
+// temporary file so that a failed write keeps the previous ones.
+func saveTriggerCalibration(reader *gamepad.Reader, path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := reader.SaveTriggerCalibration(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// watchIdle starts hub.WatchIdle for --idle-timeout. With --idle-action=sleep
+// the reader polls slowly while idle; with exit the returned channel becomes
+// ready at the first idle period. It returns nil (never ready) when disabled
+// or sleeping.
+func watchIdle(ctx context.Context, cfg config.Config, h *hub.Hub, b *hub.Broadcaster, reader *gamepad.Reader) <-chan struct{} {
+	if cfg.IdleTimeout == 0 {
+		return nil
+	}
+	timeout := time.Duration(cfg.IdleTimeout) * time.Minute
+	if cfg.IdleAction == "exit" {
+		exitCh := make(chan struct{}, 1)
+		go hub.WatchIdle(ctx, h, b, timeout, func() {
+			select {
+			case exitCh <- struct{}{}:
+			default:
+			}
+		}, func() {})
+		return exitCh
+	}
+	go hub.WatchIdle(ctx, h, b, timeout, func() {
+		slog.Info("idle: no input and no clients, sleeping", "minutes", cfg.IdleTimeout)
+		reader.SetSleeping(true)
+	}, func() {
+		slog.Info("idle: woke up")
+		reader.SetSleeping(false)
+	})
+	return nil
+}
+
+// markerCombos parses the [marker-combos] table, sorted by name.
+func markerCombos(cfg map[string]string) ([]hub.MarkerCombo, error) {
+	var combos []hub.MarkerCombo
+	for _, name := range slices.Sorted(maps.Keys(cfg)) {
+		controls, err := hub.ParseCombo(cfg[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		combos = append(combos, hub.MarkerCombo{Name: name, Controls: controls})
+	}
+	return combos, nil
+}
+
+// inputSequences parses the [sequences] table, sorted by name.
+func inputSequences(cfg map[string]config.SequenceConfig) ([]hub.Sequence, error) {
+	var seqs []hub.Sequence
+	for _, name := range slices.Sorted(maps.Keys(cfg)) {
+		sc := cfg[name]
+		sq := hub.Sequence{Name: name, Window: hub.DefaultSequenceWindow}
+		if sc.Window > 0 {
+			sq.Window = time.Duration(sc.Window * float64(time.Second))
+		}
+		for i, step := range sc.Steps {
+			controls, err := hub.ParseCombo(step)
+			if err != nil {
+				return nil, fmt.Errorf("%s step %d: %w", name, i+1, err)
+			}
+			sq.Steps = append(sq.Steps, controls)
+		}
+		seqs = append(seqs, sq)
+	}
+	return seqs, nil
+}
+
+// comboBindings builds the [bindings] of cfg, with their actions. rec runs
+// the record bindings (nil when there are none).
+func comboBindings(cfg config.Config, h *hub.Hub, b *hub.Broadcaster, reader *gamepad.Reader, rec *recorder) ([]hub.Binding, error) {
+	freezeTimeout := time.Duration(cfg.FreezeTimeout) * time.Second
+	var bindings []hub.Binding
+	for _, name := range slices.Sorted(maps.Keys(cfg.Bindings)) {
+		bc := cfg.Bindings[name]
+		controls, err := hub.ParseCombo(bc.Combo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		var action func(playerIndex int)
+		switch bc.Action {
+		case "freeze":
+			action = func(int) {
+				if !b.Unfreeze() {
+					b.Freeze(freezeTimeout)
+				}
+			}
+		case "marker":
+			action = func(playerIndex int) { b.AddMarker(name, marker.SourceCombo, playerIndex) }
+		case "next-player":
+			action = func(playerIndex int) {
+				n := len(reader.Controllers())
+				if n < 2 {
+					return
+				}
+				next := playerIndex%n + 1
+				h.MovePlayer(playerIndex, next)
+				if cfg.AllPlayers {
+					// Every player is streamed; only the viewers move.
+					b.SyncPlayer(next)
+				} else {
+					reader.SetActiveByPlayerIndex(next)
+				}
+				slog.Info("binding switched player", "binding", name, "player", next)
+			}
+		case "record":
+			action = func(int) { rec.toggle(name) }
+		case "webhook":
+			action = hub.Webhook(name, bc.URL, bc.Payload)
+		}
+		bindings = append(bindings, hub.Binding{
+			Name:     name,
+			Controls: controls,
+			Hold:     time.Duration(bc.Hold * float64(time.Second)),
+			Action:   action,
+		})
+	}
+	return bindings, nil
+}
+
+// startPlugins starts the [plugins] (sorted by name) and lets them observe
+// the hub's broadcasts.
+func startPlugins(ctx context.Context, cfg map[string]config.PluginConfig, h *hub.Hub, b *hub.Broadcaster) ([]*plugin.Process, error) {
+	var plugins []*plugin.Process
+	for _, name := range slices.Sorted(maps.Keys(cfg)) {
+		p, err := plugin.Start(ctx, name, cfg[name].Command, b)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("plugin started", "plugin", name, "command", cfg[name].Command)
+		plugins = append(plugins, p)
+	}
+	if len(plugins) > 0 {
+		h.SetTap(func(msg []byte) {
+			for _, p := range plugins {
+				p.Observe(msg)
+			}
+		})
+	}
+	return plugins, nil
+}
+
+// loadPressTrack reads the reference presses for --compare-replay.
+func loadPressTrack(path string, dz float64) ([]hub.Press, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	track, err := hub.LoadPressTrack(f, dz)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return track, nil
+}
+
+// loadRecording reads the states of a --ghost-replay or --replay recording.
+func loadRecording(path string, dz float64) ([]hub.Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	frames, err := hub.LoadRecording(f, dz)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return frames, nil
+}
+
+// loadScenario reads the --scenario file at path, as YAML if its extension is
+// .yaml or .yml.
+func loadScenario(path string) ([]hub.Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ext := strings.ToLower(filepath.Ext(path))
+	frames, err := hub.LoadScenario(f, ext == ".yaml" || ext == ".yml")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return frames, nil
+}
+
+// parsePrefixes parses allow-cidr / trusted-proxies entries (already validated
+// by config.Load).
+func parsePrefixes(cidrs []string) []netip.Prefix {
+	nets := make([]netip.Prefix, 0, len(cidrs))
+	for _, c := range cidrs {
+		if p, err := config.ParsePrefix(c); err == nil {
+			nets = append(nets, p)
+		}
+	}
+	return nets
+}
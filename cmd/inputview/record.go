@@ -0,0 +1,81 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soar/inputview/internal/audit"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// recorder starts and stops --capture-raw recordings for the record combo
+// binding. Each recording gets its own file, named after --capture-raw with
+// its start time (see recordingFile), so toggling never overwrites a run.
+type recorder struct {
+	reader   *gamepad.Reader
+	auditLog *audit.Log
+	path     string // --capture-raw
+
+	mu   sync.Mutex
+	file *os.File // the recording in progress; nil when stopped
+}
+
+// recordingFile names a recording of path started at t:
+// "run.jsonl" → "run-20260114-153000.jsonl".
+func recordingFile(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + t.Format("-20060102-150405") + ext
+}
+
+// toggle starts a recording, or stops the one in progress. binding names the
+// binding in the logs and the audit log.
+func (rc *recorder) toggle(binding string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.file != nil {
+		rc.stopLocked(audit.SourceBinding, binding)
+		return
+	}
+	name := recordingFile(rc.path, time.Now())
+	// O_EXCL: a second start within the same second must not truncate the
+	// recording that just stopped.
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		slog.Warn("binding could not start recording", "binding", binding, "error", err)
+		return
+	}
+	rc.file = f
+	rc.reader.StartCapture(f)
+	slog.Info("recording raw gamepad input", "file", name, "binding", binding)
+	rc.auditLog.Record(audit.Entry{Action: audit.ActionCaptureStart, Source: audit.SourceBinding, Details: map[string]any{"file": name, "binding": binding}})
+}
+
+// stop stops the recording in progress, if any, at shutdown.
+func (rc *recorder) stop() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.file != nil {
+		rc.stopLocked(audit.SourceServer, "")
+	}
+}
+
+// stopLocked stops the recording in progress and closes its file. Caller must
+// hold rc.mu.
+func (rc *recorder) stopLocked(source, binding string) {
+	rc.reader.StopCapture()
+	name := rc.file.Name()
+	if err := rc.file.Close(); err != nil {
+		slog.Warn("recording could not be closed", "file", name, "error", err)
+	}
+	rc.file = nil
+	details := map[string]any{"file": name}
+	if binding != "" {
+		details["binding"] = binding
+	}
+	slog.Info("stopped recording raw gamepad input", "file", name)
+	rc.auditLog.Record(audit.Entry{Action: audit.ActionCaptureStop, Source: source, Details: details})
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/lxzan/gws"
+	"github.com/soar/inputview/internal/config"
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/rawinput"
+	"github.com/soar/inputview/internal/server"
+	"github.com/soar/inputview/internal/web"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+const (
+	// selftestDetectWait is how long the input readers run before controllers
+	// are listed. HID devices register on their first WM_INPUT or arrival
+	// notification, which can take a few hundred milliseconds after start.
+	selftestDetectWait = 1500 * time.Millisecond
+
+	// selftestTimeout bounds each network check.
+	selftestTimeout = 3 * time.Second
+)
+
+// selftestReport writes one line per check and tallies the results.
+type selftestReport struct {
+	w      io.Writer
+	failed int
+}
+
+func (r *selftestReport) pass(check, format string, args ...any) {
+	fmt.Fprintf(r.w, "  [PASS] %-12s %s\n", check, fmt.Sprintf(format, args...))
+}
+
+func (r *selftestReport) fail(check, format string, args ...any) {
+	r.failed++
+	fmt.Fprintf(r.w, "  [FAIL] %-12s %s\n", check, fmt.Sprintf(format, args...))
+}
+
+func (r *selftestReport) skip(check, format string, args ...any) {
+	fmt.Fprintf(r.w, "  [SKIP] %-12s %s\n", check, fmt.Sprintf(format, args...))
+}
+
+// detail writes an indented line under the previous check.
+func (r *selftestReport) detail(format string, args ...any) {
+	fmt.Fprintf(r.w, "                      %s\n", fmt.Sprintf(format, args...))
+}
+
+// selftestClient is the gws event handler for the loopback handshake check.
+// It forwards the type of every received message.
+type selftestClient struct {
+	gws.BuiltinEventHandler
+	types chan string
+}
+
+func (c *selftestClient) OnMessage(socket *gws.Conn, message *gws.Message) {
+	defer message.Close()
+	var hdr struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(message.Bytes(), &hdr); err != nil {
+		return
+	}
+	select {
+	case c.types <- hdr.Type:
+	default:
+	}
+}
+
+// runSelftest checks the pieces a support request usually hinges on — SDL
+// mapping database, controller detection, the configured listen address, and
+// a loopback HTTP + WebSocket round-trip — and writes a pass/fail report to w.
+// Returns false if any check failed.
+func runSelftest(cfg config.Config, exeDir string, w io.Writer) bool {
+	rep := &selftestReport{w: w}
+	fmt.Fprintf(w, "InputView self-test (%s/%s, %s)\n\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// --- SDL GameControllerDB ---
+	sdlDBPath := filepath.Join(exeDir, cfg.SDLDBPath)
+	gamepad.LoadSDLDB(sdlDBPath)
+	if n := gamepad.SDLMappingCount(); n > 0 {
+		rep.pass("sdl-db", "%d mappings loaded for platform %q", n, gamepad.SDLPlatform())
+	} else {
+		rep.fail("sdl-db", "no mappings loaded for platform %q", gamepad.SDLPlatform())
+	}
+	if _, err := os.Stat(sdlDBPath); err == nil {
+		if ext, err := gamepad.LoadSDLMappingsFromFile(sdlDBPath, gamepad.SDLPlatform()); err != nil {
+			rep.fail("sdl-db", "external %s: %v", sdlDBPath, err)
+		} else {
+			rep.detail("external %s: %d entries merged", sdlDBPath, len(ext))
+		}
+	} else {
+		rep.detail("external %s: not present (embedded DB only)", sdlDBPath)
+	}
+
+	// --- Custom device mappings ---
+	mappingsDir := filepath.Join(exeDir, cfg.MappingsDir)
+	if err := gamepad.LoadMappingDir(mappingsDir); err != nil {
+		rep.fail("mappings", "%v", err)
+	} else {
+		rep.pass("mappings", "%d devices with a custom mapping in %s", gamepad.CustomMappingCount(), mappingsDir)
+	}
+
+	// --- Input readers and controller detection ---
+	reader := gamepad.NewReader()
+	if err := setDeadzones(reader, cfg); err != nil {
+		rep.fail("deadzones", "%v", err)
+	}
+	reader.SetPollDelay(time.Duration(cfg.PollRate) * time.Millisecond)
+	kmReader := rawinput.New()
+	reader.SetRawInputReader(kmReader)
+	go reader.Run(ctx)
+	go kmReader.Run(ctx)
+
+	h := hub.NewHub()
+	go h.Run(ctx)
+	broadcaster := hub.NewBroadcaster(h, reader.Changes(), kmReader.Changes())
+	go broadcaster.Run()
+
+	if runtime.GOOS != "windows" {
+		rep.skip("controllers", "controller input is only implemented on Windows")
+	} else {
+		time.Sleep(selftestDetectWait)
+		controllers := reader.Controllers()
+		rep.pass("controllers", "%d detected", len(controllers))
+		if len(controllers) == 0 {
+			rep.detail("plug a controller in (and press a button) to check its mapping")
+		}
+		for _, c := range controllers {
+			active := ""
+			if c.Active {
+				active = ", active"
+			}
+			rep.detail("P%d %s [%s, guid=%s, type=%s, mapping=%s, axes=%d buttons=%d hats=%d%s]",
+				c.PlayerIndex, c.Name, c.Source, c.GUID, c.ControllerType, c.Mapping, c.Axes, c.Buttons, c.Hats, active)
+		}
+	}
+
+	// --- Configured listen address ---
+	if ln, err := net.Listen("tcp", cfg.Addr); err != nil {
+		rep.fail("listen", "configured address %s unavailable: %v (is another instance running?)", cfg.Addr, err)
+	} else {
+		ln.Close()
+		rep.pass("listen", "configured address %s is free", cfg.Addr)
+	}
+
+	// --- Loopback HTTP + WebSocket on a temporary port ---
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		rep.fail("loopback", "bind temporary port: %v", err)
+		return selftestSummary(rep)
+	}
+	srv := server.New(h, broadcaster, reader, kmReader, web.FrontendFS(), web.GzipCache(), exeDir, cfg.OverlayDir, cfg.KeyboardDir, ln.Addr().String())
+	httpSrv := &http.Server{Handler: srv.Handler()}
+	go httpSrv.Serve(ln)
+	defer httpSrv.Close()
+	rep.pass("loopback", "bound temporary port %s", ln.Addr())
+
+	httpClient := &http.Client{Timeout: selftestTimeout}
+	if resp, err := httpClient.Get("http://" + ln.Addr().String() + "/health"); err != nil {
+		rep.fail("health", "GET /health: %v", err)
+	} else {
+		var health struct {
+			Status string `json:"status"`
+		}
+		err := json.NewDecoder(resp.Body).Decode(&health)
+		resp.Body.Close()
+		switch {
+		case err != nil:
+			rep.fail("health", "GET /health: decode response: %v", err)
+		case resp.StatusCode != http.StatusOK || health.Status != "ok":
+			rep.fail("health", "GET /health: HTTP %d, status %q", resp.StatusCode, health.Status)
+		default:
+			rep.pass("health", "GET /health returned status ok")
+		}
+	}
+
+	client := &selftestClient{types: make(chan string, 4)}
+	conn, _, err := gws.NewClient(client, &gws.ClientOption{
+		Addr:             "ws://" + ln.Addr().String() + "/ws",
+		HandshakeTimeout: selftestTimeout,
+	})
+	if err != nil {
+		rep.fail("websocket", "handshake: %v", err)
+		return selftestSummary(rep)
+	}
+	go conn.ReadLoop()
+	defer conn.WriteClose(1000, nil)
+	select {
+	case typ := <-client.types:
+		if typ == "full" {
+			rep.pass("websocket", "handshake ok, initial full state received")
+		} else {
+			rep.fail("websocket", "first message has type %q, want \"full\"", typ)
+		}
+	case <-time.After(selftestTimeout):
+		rep.fail("websocket", "no initial state within %s", selftestTimeout)
+	}
+
+	return selftestSummary(rep)
+}
+
+// selftestSummary writes the final verdict line and reports whether all
+// checks passed.
+func selftestSummary(rep *selftestReport) bool {
+	if rep.failed > 0 {
+		fmt.Fprintf(rep.w, "\nFAIL: %d check(s) failed\n", rep.failed)
+		return false
+	}
+	fmt.Fprintf(rep.w, "\nPASS\n")
+	return true
+}
+
+// runSelftestAndExit runs the self-test and exits the process with status 0
+// if every check passed, 1 otherwise.
+func runSelftestAndExit(cfg config.Config, exeDir string) {
+	if !runSelftest(cfg, exeDir, os.Stdout) {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
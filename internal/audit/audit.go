@@ -0,0 +1,198 @@
+// Package audit records control actions — player switches, settings changed
+// by clients or the tray, clients disconnected by the server, raw capture
+// start/stop, viewer tokens issued and revoked — with time, client, and
+// source, in memory and optionally in a JSON Lines file that survives
+// restarts.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions (Entry.Action).
+const (
+	ActionSelectPlayer  = "select_player"  // a client switched the active controller
+	ActionSelectProfile = "select_profile" // a client switched its output profile
+	ActionSetMouseSens  = "set_mouse_sens" // a client changed the mouse sensitivity
+	ActionRumble        = "rumble"         // a client made a controller vibrate
+	ActionSetLED        = "set_led"        // a controller's lightbar or player LEDs were set
+	ActionSetRawMode    = "set_raw_mode"   // a controller was switched to raw mode or back
+	ActionSetDeadzone   = "set_deadzone"   // a controller's deadzones were set or reset
+	ActionRemoteAccess  = "remote_access"  // remote connections were allowed or disallowed
+	ActionClientsClosed = "clients_closed" // the server closed client connections
+	ActionCaptureStart  = "capture_start"  // raw input capture started
+	ActionCaptureStop   = "capture_stop"   // raw input capture stopped
+	ActionTokenCreated  = "token_created"  // a viewer token was issued
+	ActionTokenRevoked  = "token_revoked"  // a viewer token was revoked before expiring
+)
+
+// Sources (Entry.Source): where the action came from.
+const (
+	SourceWebSocket = "ws"      // a WebSocket client command; Entry.Client is set
+	SourceTray      = "tray"    // the system tray menu
+	SourceConfig    = "config"  // a startup setting (flag, environment, or inputview.toml)
+	SourceServer    = "server"  // the server itself, as a consequence of another action
+	SourceAPI       = "api"     // an /api endpoint; Entry.Client is set
+	SourceBinding   = "binding" // a [bindings] combo; Details has the binding name
+)
+
+// maxEntries is the number of entries kept in memory (and so queryable).
+// The file keeps everything.
+const maxEntries = 1000
+
+// Entry is one recorded action.
+type Entry struct {
+	Time    int64          `json:"time"`              // Unix milliseconds
+	Action  string         `json:"action"`            // Action* constant
+	Source  string         `json:"source"`            // Source* constant
+	Client  string         `json:"client,omitempty"`  // "ip:port" of the client, for SourceWebSocket and SourceAPI
+	Details map[string]any `json:"details,omitempty"` // action-specific values, e.g. {"playerIndex": 2}
+}
+
+// Query filters Log.Query. Zero fields match everything.
+type Query struct {
+	Since  int64  // only entries at or after this Unix millisecond time
+	Action string // only this action
+	Client string // only this client address (exact match)
+	Limit  int    // at most this many of the newest matches; 0 = all
+}
+
+// Log is an audit log. A nil *Log records nothing and has no entries, so
+// callers need not check whether auditing is set up. Safe for concurrent use.
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry  // newest last, at most maxEntries
+	file    *os.File // nil = memory only
+}
+
+// New returns a log kept in memory only when path is empty. Otherwise the
+// entries already in the file are loaded (the newest maxEntries of them;
+// unreadable lines are skipped) and new entries are appended to it.
+func New(path string) (*Log, error) {
+	l := &Log{}
+	if path == "" {
+		return l, nil
+	}
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// load reads the existing entries of path, if it exists.
+func (l *Log) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read audit log: %w", err)
+	}
+	defer f.Close()
+
+	skipped := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			skipped++
+			continue
+		}
+		l.append(e)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read audit log: %w", err)
+	}
+	if skipped > 0 {
+		slog.Warn("skipped unreadable audit log lines", "file", path, "lines", skipped)
+	}
+	return nil
+}
+
+// append adds e to the in-memory entries, dropping the oldest beyond
+// maxEntries. Caller must hold l.mu (or own l).
+func (l *Log) append(e Entry) {
+	if len(l.entries) == maxEntries {
+		copy(l.entries, l.entries[1:])
+		l.entries = l.entries[:maxEntries-1]
+	}
+	l.entries = append(l.entries, e)
+}
+
+// Record adds e, stamping Time if it is zero, and appends it to the file.
+// A failed file write is logged; the entry is still kept in memory.
+func (l *Log) Record(e Entry) {
+	if l == nil {
+		return
+	}
+	if e.Time == 0 {
+		e.Time = time.Now().UnixMilli()
+	}
+	slog.Debug("audit", "action", e.Action, "source", e.Source, "client", e.Client, "details", e.Details)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.append(e)
+	if l.file == nil {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err == nil {
+		_, err = l.file.Write(append(data, '\n'))
+	}
+	if err != nil {
+		slog.Error("error writing audit log", "error", err)
+	}
+}
+
+// Query returns the matching in-memory entries, oldest first.
+func (l *Log) Query(q Query) []Entry {
+	if l == nil {
+		return []Entry{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := []Entry{}
+	for _, e := range l.entries {
+		if e.Time < q.Since || (q.Action != "" && e.Action != q.Action) || (q.Client != "" && e.Client != q.Client) {
+			continue
+		}
+		out = append(out, e)
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out
+}
+
+// Close closes the file, if any. Later entries are kept in memory only.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLogPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(Entry{Time: 1, Action: ActionSelectPlayer, Source: SourceWebSocket, Client: "192.0.2.1:5000", Details: map[string]any{"playerIndex": 2}})
+	l.Record(Entry{Time: 2, Action: ActionRemoteAccess, Source: SourceTray, Details: map[string]any{"allowed": false}})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A torn last line (crash mid-write) is skipped, not fatal.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":3,"act`)
+	f.Close()
+
+	l, err = New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	want := []Entry{
+		{Time: 1, Action: ActionSelectPlayer, Source: SourceWebSocket, Client: "192.0.2.1:5000", Details: map[string]any{"playerIndex": float64(2)}},
+		{Time: 2, Action: ActionRemoteAccess, Source: SourceTray, Details: map[string]any{"allowed": false}},
+	}
+	if got := l.Query(Query{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded entries =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestLogQuery(t *testing.T) {
+	l, _ := New("")
+	for i := range maxEntries + 5 {
+		action := ActionSelectPlayer
+		if i%2 == 1 {
+			action = ActionSetMouseSens
+		}
+		l.Record(Entry{Time: int64(i + 1), Action: action, Source: SourceWebSocket})
+	}
+
+	if got := l.Query(Query{}); len(got) != maxEntries || got[0].Time != 6 {
+		t.Errorf("all entries: got %d starting at time %d, want %d starting at 6", len(got), got[0].Time, maxEntries)
+	}
+	got := l.Query(Query{Action: ActionSetMouseSens, Since: 900, Limit: 3})
+	var times []int64
+	for _, e := range got {
+		times = append(times, e.Time)
+	}
+	if want := []int64{1000, 1002, 1004}; !reflect.DeepEqual(times, want) {
+		t.Errorf("filtered times = %v, want %v (newest matches, oldest first)", times, want)
+	}
+
+	var nilLog *Log
+	nilLog.Record(Entry{Action: ActionSelectPlayer})
+	if got := nilLog.Query(Query{}); got == nil || len(got) != 0 {
+		t.Errorf("nil log Query() = %#v, want empty non-nil", got)
+	}
+}
@@ -1,9 +1,17 @@
 package config
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -11,18 +19,190 @@ import (
 
 // Config holds all application configuration.
 type Config struct {
-	Addr             string  `mapstructure:"addr"`
-	PollRate         int     `mapstructure:"poll-rate"`
-	Deadzone         float64 `mapstructure:"deadzone"`
-	MouseSensitivity float64 `mapstructure:"mouse-sens"`
-	OverlayDir       string  `mapstructure:"overlay-dir"`
-	KeyboardDir      string  `mapstructure:"keyboard-dir"`
-	SDLDBPath        string  `mapstructure:"sdl-db"`
-	LogLevel         string  `mapstructure:"log-level"`
+	Addr              string   `mapstructure:"addr"`
+	Port              int      `mapstructure:"port"`
+	NoTray            bool     `mapstructure:"no-tray"`
+	PollRate          int      `mapstructure:"poll-rate"`
+	PollSpin          bool     `mapstructure:"poll-spin"`
+	UpdateRate        float64  `mapstructure:"update-rate"`
+	Deadzone          float64  `mapstructure:"deadzone"`
+	TriggerDeadzone   float64  `mapstructure:"trigger-deadzone"`
+	DeadzoneMode      string   `mapstructure:"deadzone-mode"`
+	CalibrationFile   string   `mapstructure:"trigger-calibration"`
+	MouseSensitivity  float64  `mapstructure:"mouse-sens"`
+	OverlayDir        string   `mapstructure:"overlay-dir"`
+	KeyboardDir       string   `mapstructure:"keyboard-dir"`
+	SDLDBPath         string   `mapstructure:"sdl-db"`
+	MappingsDir       string   `mapstructure:"mappings-dir"`
+	LogLevel          string   `mapstructure:"log-level"`
+	Headless          bool     `mapstructure:"headless"`
+	EnableInject      bool     `mapstructure:"enable-inject"`
+	Debug             bool     `mapstructure:"debug"`
+	CaptureRaw        string   `mapstructure:"capture-raw"`
+	ChangesBuffer     int      `mapstructure:"changes-buffer"`
+	HubBuffer         int      `mapstructure:"hub-buffer"`
+	ClientBuffer      int      `mapstructure:"client-buffer"`
+	PingInterval      int      `mapstructure:"ping-interval"`
+	PingTimeout       int      `mapstructure:"ping-timeout"`
+	Bench             bool     `mapstructure:"bench"`
+	BenchEvents       int      `mapstructure:"bench-events"`
+	FixturesDir       string   `mapstructure:"fixtures-dir"`
+	ExportInput       string   `mapstructure:"export-input"`
+	ExportFormat      string   `mapstructure:"export-format"`
+	ExportOut         string   `mapstructure:"export-out"`
+	ExportSync        string   `mapstructure:"export-sync"`
+	ExportFPS         int      `mapstructure:"export-fps"`
+	BatteryThresholds []int    `mapstructure:"battery-thresholds"`
+	AuthUser          string   `mapstructure:"auth-user"`
+	AuthPassword      string   `mapstructure:"auth-password"`
+	AllowRemote       bool     `mapstructure:"allow-remote"`
+	AllowCIDR         []string `mapstructure:"allow-cidr"`
+	TrustedProxies    []string `mapstructure:"trusted-proxies"`
+	Container         bool     `mapstructure:"container"`
+	ShutdownTimeout   int      `mapstructure:"shutdown-timeout"`
+	TLSCert           string   `mapstructure:"tls-cert"`
+	TLSKey            string   `mapstructure:"tls-key"`
+	AuditLog          string   `mapstructure:"audit-log"`
+	CompareReplay     string   `mapstructure:"compare-replay"`
+	CompareWindow     int      `mapstructure:"compare-window"`
+	CompareTolerance  int      `mapstructure:"compare-tolerance"`
+	GhostReplay       string   `mapstructure:"ghost-replay"`
+	Replay            string   `mapstructure:"replay"`
+	ReplaySpeed       float64  `mapstructure:"replay-speed"`
+	ReplayLoop        bool     `mapstructure:"replay-loop"`
+	Scenario          string   `mapstructure:"scenario"`
+	Demo              bool     `mapstructure:"demo"`
+	History           int      `mapstructure:"history"`
+	StatsInterval     int      `mapstructure:"stats-interval"`
+	FreezeCombo       string   `mapstructure:"freeze-combo"`
+	FreezeTimeout     int      `mapstructure:"freeze-timeout"`
+	IdleTimeout       int      `mapstructure:"idle-timeout"`
+	IdleAction        string   `mapstructure:"idle-action"`
+	AllPlayers        bool     `mapstructure:"all-players"`
+	Motion            bool     `mapstructure:"motion"`
+	JoyConSideways    bool     `mapstructure:"joycon-sideways"`
+	UDPOut            string   `mapstructure:"udp-out"`
+	UDPRate           int      `mapstructure:"udp-rate"`
+	ViGEm             bool     `mapstructure:"vigem"`
+	RelayFrom         []string `mapstructure:"relay-from"`
+	RelayPlayer       int      `mapstructure:"relay-player"`
+
+	// Profiles are the named output profiles (TOML [profiles.<name>]
+	// tables only; no CLI flag). Viper lowercases the names.
+	Profiles map[string]ProfileConfig `mapstructure:"profiles"`
+
+	// Deadzones override deadzone and trigger-deadzone for the controllers
+	// with an SDL GUID (TOML [deadzones.<guid>] tables only; no CLI flag;
+	// see gamepad.Reader.SetDeviceDeadzones).
+	Deadzones map[string]DeadzoneConfig `mapstructure:"deadzones"`
+
+	// Listeners are additional addresses served alongside Addr, each with
+	// its own TLS and basic auth settings (TOML [listeners.<name>] tables
+	// only; no CLI flag). Viper lowercases the names.
+	Listeners map[string]ListenerConfig `mapstructure:"listeners"`
+
+	// MarkerCombos maps marker names to the button combos that drop them,
+	// e.g. highlight = "back+rb" (TOML [marker-combos] table only; no CLI
+	// flag; see hub.ParseCombo). Viper lowercases the names.
+	MarkerCombos map[string]string `mapstructure:"marker-combos"`
+
+	// Bindings map names to button combos that run a server action (TOML
+	// [bindings.<name>] tables only; no CLI flag). Viper lowercases the
+	// names.
+	Bindings map[string]BindingConfig `mapstructure:"bindings"`
+
+	// Sequences map names to input sequences announced to clients as
+	// "combo" messages (TOML [sequences.<name>] tables only; no CLI flag).
+	// Viper lowercases the names.
+	Sequences map[string]SequenceConfig `mapstructure:"sequences"`
+
+	// Plugins are extension processes run next to the server (TOML
+	// [plugins.<name>] tables only; no CLI flag; see internal/plugin). Viper
+	// lowercases the names.
+	Plugins map[string]PluginConfig `mapstructure:"plugins"`
+
+	// Command is the optional subcommand given as the first positional
+	// argument (e.g. "selftest"); empty runs the server.
+	Command string `mapstructure:"-"`
+}
+
+// ProfileConfig is one output profile: the transforms applied to the states
+// sent to clients that select it.
+type ProfileConfig struct {
+	Mirror        bool `mapstructure:"mirror"`         // flip the layout horizontally
+	Rotate        int  `mapstructure:"rotate"`         // clockwise degrees: 0, 90, 180, or 270
+	SwapShoulders bool `mapstructure:"swap-shoulders"` // trade LB/RB and LT/RT
+	SwapSticks    bool `mapstructure:"swap-sticks"`    // trade the left and right sticks (southpaw)
+	SwapTriggers  bool `mapstructure:"swap-triggers"`  // trade LT/RT only
+}
+
+// DeadzoneConfig is the deadzones of one controller. Unset thresholds are
+// deadzone's, trigger-deadzone's, and deadzone-mode's.
+type DeadzoneConfig struct {
+	Stick   *float64 `mapstructure:"stick"`   // sticks and flight stick axes, 0.0-1.0
+	Trigger *float64 `mapstructure:"trigger"` // triggers, pedals, and thrust, 0.0-1.0
+	Mode    string   `mapstructure:"mode"`    // one of DeadzoneModes; empty = deadzone-mode
+}
+
+// DeadzoneModes lists the accepted deadzone-mode values (see
+// gamepad.DeadzoneModes).
+var DeadzoneModes = []string{"axial", "radial", "scaled"}
+
+// ListenerConfig is one additional listener. Nothing is inherited from the
+// top-level tls-* and auth-* settings.
+type ListenerConfig struct {
+	Addr         string `mapstructure:"addr"`          // "host:port" or "unix:<path>"
+	TLSCert      string `mapstructure:"tls-cert"`      // PEM certificate file; empty = plain HTTP
+	TLSKey       string `mapstructure:"tls-key"`       // PEM key file for tls-cert
+	AuthUser     string `mapstructure:"auth-user"`     // basic auth user name; empty = any
+	AuthPassword string `mapstructure:"auth-password"` // basic auth password; empty = no auth
 }
 
+// BindingActions lists the accepted BindingConfig actions.
+var BindingActions = []string{"freeze", "marker", "next-player", "record", "webhook"}
+
+// BindingConfig is one combo binding: holding Combo for Hold seconds runs
+// Action (one of BindingActions).
+type BindingConfig struct {
+	Combo   string  `mapstructure:"combo"`   // e.g. "guide+back"; see hub.ParseCombo
+	Hold    float64 `mapstructure:"hold"`    // seconds; 0 = as soon as the combo is completed
+	Action  string  `mapstructure:"action"`  // freeze (toggle), marker (named after the binding), next-player, record (toggle), webhook
+	URL     string  `mapstructure:"url"`     // webhook only: the http(s) URL to POST to
+	Payload string  `mapstructure:"payload"` // webhook only: the request body (empty = hub.WebhookPayload)
+}
+
+// SequenceConfig is one input sequence: Steps entered in order, each within
+// Window seconds of the one before.
+type SequenceConfig struct {
+	Steps  []string `mapstructure:"steps"`  // one combo per step, e.g. ["down", "down+right", "right", "x"]; see hub.ParseCombo
+	Window float64  `mapstructure:"window"` // seconds from one step to the next; 0 = hub.DefaultSequenceWindow
+}
+
+// PluginConfig is one plugin: an external command speaking JSON Lines over
+// stdio.
+type PluginConfig struct {
+	Command []string `mapstructure:"command"` // program and arguments, e.g. ["python", "detector.py"]
+}
+
+// ParsePrefix parses an allow-cidr or trusted-proxies entry: a CIDR prefix ("192.168.1.0/24",
+// "fd00::/8") or a single address ("10.0.0.5"), which allows only itself.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		return p.Masked(), nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is neither a CIDR prefix nor an IP address", s)
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// Commands lists the accepted subcommands.
+var Commands = []string{"selftest", "fixtures", "devices", "export"}
+
 // Load parses CLI flags, reads an optional TOML config file (inputview.toml
-// next to the executable), and returns a validated Config.
+// next to the executable) and INPUTVIEW_* environment variables, and returns
+// a validated Config. Precedence: flags > environment > config file > defaults.
 //
 // exeDir is the directory containing the executable; used to locate
 // inputview.toml. Pass "." when running from a source tree.
@@ -30,13 +210,72 @@ func Load(exeDir string) (Config, error) {
 	// --- 1. Define flags ---
 	flags := pflag.NewFlagSet("inputview", pflag.ContinueOnError)
 	flags.String("addr", ":8080", "HTTP listen address")
+	flags.Int("port", 0, "Listen on this port instead of --addr's, keeping its host (0 = --addr as given)")
 	flags.Int("poll-rate", 16, "Gamepad/keyboard poll rate in milliseconds (~60 Hz)")
+	flags.Bool("poll-spin", false, "Hybrid sleep/spin poll pacing for sub-millisecond accuracy at 500-1000 Hz (uses more CPU)")
+	flags.Float64("update-rate", 0, "Default WebSocket update rate in Hz, 0-1000: state changes in between are coalesced (0 = every change)")
 	flags.Float64("deadzone", 0.05, "Analog stick deadzone, range 0.0-1.0")
+	flags.Float64("trigger-deadzone", 0.05, "Trigger and pedal deadzone, range 0.0-1.0 (default: --deadzone)")
+	flags.String("deadzone-mode", "axial", "Stick deadzone shape: axial (per axis), radial (stick distance), scaled (radial, rescaled)")
+	flags.String("trigger-calibration", "", "Learn the range HID triggers and pedals really report and keep it in this file (relative to executable; empty = off)")
 	flags.Float64("mouse-sens", 500.0, "Mouse movement sensitivity divisor (lower = more sensitive)")
 	flags.String("overlay-dir", "overlays", "Directory containing Input Overlay presets (relative to executable)")
 	flags.String("keyboard-dir", "keyboards", "Directory containing keyboard layout files (relative to executable)")
 	flags.String("sdl-db", "gamecontrollerdb.txt", "SDL GameControllerDB filename (relative to executable)")
+	flags.String("mappings-dir", "mappings", "Directory of custom device mapping JSON files, merged over the built-in table (relative to executable)")
 	flags.String("log-level", "info", "Log level: debug, info, warn, error")
+	flags.Bool("headless", false, "Data-server mode: no tray, no embedded frontend; only /ws, /health, /metrics, and /api/*")
+	flags.Bool("no-tray", false, "No system tray icon in release builds; stop with Ctrl+C or SIGTERM (the frontend is still served)")
+	flags.Bool("enable-inject", false, "Enable the debug-only POST /api/inject endpoint for scripted input")
+	flags.Bool("debug", false, "Serve net/http/pprof CPU/heap profiles at /debug/pprof/ to clients on this machine")
+	flags.String("capture-raw", "", "Record raw gamepad input (before mapping) to this file for bug reports and replay")
+	flags.Int("changes-buffer", 16, "Pending gamepad button edges held when the broadcaster falls behind")
+	flags.Int("hub-buffer", 0, "Hub broadcast queue length (0 = broadcaster fans out directly)")
+	flags.Int("client-buffer", 256, "Max unsent messages per WebSocket client before dropping (0 = unbounded)")
+	flags.Int("ping-interval", 30, "Seconds between WebSocket pings to each client (0 = no pings; dead clients then linger until the OS drops them)")
+	flags.Int("ping-timeout", 10, "Seconds a client may take to answer a ping, on top of --ping-interval, before it is disconnected")
+	flags.Bool("bench", false, "Run the pipeline benchmark (synthetic input over a loopback WebSocket) and exit")
+	flags.Int("bench-events", 10000, "Number of synthetic state changes injected by --bench")
+	flags.String("fixtures-dir", "fixtures", "Output directory for the fixtures command")
+	flags.String("export-input", "", "--capture-raw recording to convert with the export command")
+	flags.String("export-format", "srt", "Output format of the export command: srt, ass (subtitles), or edl (timeline markers)")
+	flags.String("export-out", "", "Output file of the export command (empty = --export-input with the format's extension)")
+	flags.String("export-sync", "", "Export timing: empty = from the capture start, \"wall\" = time of day, or a duration such as 1m2.5s = the first press's position in the video")
+	flags.Int("export-fps", 30, "Timecode frame rate of --export-format=edl")
+	flags.IntSlice("battery-thresholds", []int{20, 10, 5}, "Battery levels in percent that trigger a power_changed event when crossed while discharging")
+	flags.String("auth-user", "", "HTTP basic auth user name (empty = any user name; only used with --auth-password)")
+	flags.String("auth-password", "", "Require this HTTP basic auth password for the frontend, /ws, and /api/* (empty = no auth)")
+	flags.Bool("allow-remote", false, "Serve clients on other machines; by default only loopback (this PC) is served, whatever --addr binds to")
+	flags.StringSlice("trusted-proxies", nil, "Reverse proxies (CIDR or IP) whose X-Forwarded-For/-Proto/-Host headers are honored (empty = ignore the headers)")
+	flags.StringSlice("allow-cidr", nil, "With --allow-remote, only accept remote clients from these networks, e.g. 192.168.1.0/24 (bare IPs allowed; empty = any)")
+	flags.Int("shutdown-timeout", 5, "Seconds to wait on shutdown for WebSocket clients to disconnect, and for each subsystem to stop")
+	flags.String("tls-cert", "", "Serve HTTPS with this PEM certificate (chain) file; reloaded when it changes (empty = plain HTTP)")
+	flags.String("tls-key", "", "PEM private key file for --tls-cert; reloaded when it changes")
+	flags.String("audit-log", "", "Append control actions (player switches, setting changes, disconnects) to this JSON Lines file (empty = memory only)")
+	flags.String("compare-replay", "", "Compare the live player's presses against this --capture-raw recording and send input_diff events (empty = off)")
+	flags.Int("compare-window", 250, "Milliseconds within which a live press is matched with the same press of the --compare-replay recording")
+	flags.Int("compare-tolerance", 40, "Milliseconds a matched press may be off before it is reported early or late")
+	flags.String("ghost-replay", "", "Play this --capture-raw recording as a ghost_state stream next to the live input, from each first press (empty = off)")
+	flags.String("replay", "", "Play this --capture-raw recording as the live input with its original timing, from its first press, for designing overlays without a controller (empty = off)")
+	flags.Float64("replay-speed", 1.0, "Playback speed of --replay and --scenario (0.1-16, 1 = original timing)")
+	flags.Bool("replay-loop", false, "Restart the --replay recording or --scenario after its last state instead of stopping")
+	flags.String("scenario", "", "Play this JSON or YAML (.yaml/.yml) timeline of full and delta states as the live input, for reproducible frontend tests and demo videos (empty = off)")
+	flags.Bool("demo", false, "Play a generated demo controller (circling sticks, sweeping triggers, buttons in turn) as the live input, for developing overlays and themes without a controller")
+	flags.Int("history", 0, "Seconds of gamepad state changes kept for GET /api/history, so late-joining clients and analysis tools can catch up (0 = off)")
+	flags.Int("stats-interval", 0, "Seconds between \"stats\" WebSocket messages with each player's press counts, actions per minute, and stick travel (0 = off; GET /api/stats always works)")
+	flags.String("freeze-combo", "", "Button combo that freezes the input display and releases it again, e.g. back+start (empty = off)")
+	flags.Int("freeze-timeout", 0, "Seconds a --freeze-combo freeze lasts before it releases itself (0 = until the combo is pressed again)")
+	flags.Int("idle-timeout", 0, "Minutes without controller or keyboard/mouse input and without connected clients before --idle-action (0 = never)")
+	flags.String("idle-action", "sleep", "What to do when idle: sleep (poll slowly until input or a client arrives) or exit")
+	flags.Bool("all-players", false, "Stream every connected controller at once, each to the clients following its player (?p=N), for local multiplayer")
+	flags.Bool("motion", false, "Stream gyro and accelerometer readings of DualShock 4, DualSense, and Switch Pro controllers (sends a delta per HID report)")
+	flags.Bool("joycon-sideways", false, "Show a Joy-Con without a partner as a controller of its own held sideways (left and right Joy-Cons are always paired)")
+	flags.String("udp-out", "", "Send the shown state as compact binary UDP packets to this host:port, for tools without a WebSocket stack (empty = off)")
+	flags.Int("udp-rate", 60, "Packets per second and stream sent to --udp-out (1-1000)")
+	flags.Bool("vigem", false, "Mirror the active controller to a virtual Xbox 360 controller for XInput-only games (Windows; needs ViGEmBus and ViGEmClient.dll)")
+	flags.StringSlice("relay-from", nil, "Show the controller of the InputView instance at this WebSocket URL as the live input, e.g. ws://gaming-pc:8080/ws, for dual-PC streaming; several URLs are shown as players 1, 2, ... (empty = off)")
+	flags.Int("relay-player", 1, "Player of each --relay-from instance to relay (1-16)")
+	flags.Bool("container", false, "Container mode: no tray or console integration, JSON logs on stdout, remote clients allowed by default (auto-detected in Docker/Podman)")
 
 	// --- 2. Parse flags ---
 	if err := flags.Parse(os.Args[1:]); err != nil {
@@ -49,13 +288,72 @@ func Load(exeDir string) (Config, error) {
 	// --- 3. Set viper defaults ---
 	v := viper.New()
 	v.SetDefault("addr", ":8080")
+	v.SetDefault("port", 0)
 	v.SetDefault("poll-rate", 16)
+	v.SetDefault("poll-spin", false)
+	v.SetDefault("update-rate", 0)
 	v.SetDefault("deadzone", 0.05)
+	v.SetDefault("trigger-deadzone", 0.05)
+	v.SetDefault("deadzone-mode", "axial")
+	v.SetDefault("trigger-calibration", "")
 	v.SetDefault("mouse-sens", 500.0)
 	v.SetDefault("overlay-dir", "overlays")
 	v.SetDefault("keyboard-dir", "keyboards")
 	v.SetDefault("sdl-db", "gamecontrollerdb.txt")
+	v.SetDefault("mappings-dir", "mappings")
 	v.SetDefault("log-level", "info")
+	v.SetDefault("headless", false)
+	v.SetDefault("no-tray", false)
+	v.SetDefault("enable-inject", false)
+	v.SetDefault("debug", false)
+	v.SetDefault("capture-raw", "")
+	v.SetDefault("changes-buffer", 16)
+	v.SetDefault("hub-buffer", 0)
+	v.SetDefault("client-buffer", 256)
+	v.SetDefault("ping-interval", 30)
+	v.SetDefault("ping-timeout", 10)
+	v.SetDefault("bench", false)
+	v.SetDefault("bench-events", 10000)
+	v.SetDefault("fixtures-dir", "fixtures")
+	v.SetDefault("export-input", "")
+	v.SetDefault("export-format", "srt")
+	v.SetDefault("export-out", "")
+	v.SetDefault("export-sync", "")
+	v.SetDefault("export-fps", 30)
+	v.SetDefault("battery-thresholds", []int{20, 10, 5})
+	v.SetDefault("auth-user", "")
+	v.SetDefault("auth-password", "")
+	v.SetDefault("allow-remote", false)
+	v.SetDefault("allow-cidr", []string{})
+	v.SetDefault("trusted-proxies", []string{})
+	v.SetDefault("container", false)
+	v.SetDefault("shutdown-timeout", 5)
+	v.SetDefault("tls-cert", "")
+	v.SetDefault("tls-key", "")
+	v.SetDefault("audit-log", "")
+	v.SetDefault("compare-replay", "")
+	v.SetDefault("compare-window", 250)
+	v.SetDefault("compare-tolerance", 40)
+	v.SetDefault("ghost-replay", "")
+	v.SetDefault("replay", "")
+	v.SetDefault("replay-speed", 1.0)
+	v.SetDefault("replay-loop", false)
+	v.SetDefault("scenario", "")
+	v.SetDefault("demo", false)
+	v.SetDefault("history", 0)
+	v.SetDefault("stats-interval", 0)
+	v.SetDefault("freeze-combo", "")
+	v.SetDefault("freeze-timeout", 0)
+	v.SetDefault("idle-timeout", 0)
+	v.SetDefault("idle-action", "sleep")
+	v.SetDefault("all-players", false)
+	v.SetDefault("motion", false)
+	v.SetDefault("joycon-sideways", false)
+	v.SetDefault("udp-out", "")
+	v.SetDefault("udp-rate", 60)
+	v.SetDefault("vigem", false)
+	v.SetDefault("relay-from", []string{})
+	v.SetDefault("relay-player", 1)
 
 	// --- 4. Configure TOML config file location ---
 	v.SetConfigName("inputview")
@@ -75,22 +373,289 @@ func Load(exeDir string) (Config, error) {
 		return Config{}, err
 	}
 
-	// --- 7. Unmarshal into struct ---
+	// --- 7. Environment variables (override config file, not flags) ---
+	// INPUTVIEW_<KEY> with dashes as underscores, e.g. INPUTVIEW_AUTH_PASSWORD.
+	// Slices are comma-separated. Profiles, deadzones, listeners, marker
+	// combos, bindings, sequences, and plugins can only be set in the TOML
+	// file.
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+
+	// --- 8. Unmarshal into struct ---
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return Config{}, err
 	}
 
-	// --- 8. Validate ---
+	// --- 9. Container mode and settings that follow others ---
+	// Auto-detected unless set explicitly. Inside a container, port-forwarded
+	// connections arrive from the bridge network rather than loopback, so
+	// remote clients are served unless allow-remote is set explicitly.
+	explicit := func(key string) bool {
+		return flags.Changed(key) || v.InConfig(key) || os.Getenv(envName(key)) != ""
+	}
+	if !explicit("container") {
+		cfg.Container = inContainer()
+	}
+	if cfg.Container && !explicit("allow-remote") {
+		cfg.AllowRemote = true
+	}
+	// Several relay sources are shown side by side, one player each.
+	if len(cfg.RelayFrom) > 1 && !explicit("all-players") {
+		cfg.AllPlayers = true
+	}
+	// The trigger deadzone follows the stick one unless set on its own.
+	if !explicit("trigger-deadzone") {
+		cfg.TriggerDeadzone = cfg.Deadzone
+	}
+
+	// --- 10. Validate ---
+	if args := flags.Args(); len(args) > 0 {
+		if len(args) > 1 {
+			return Config{}, fmt.Errorf("unexpected arguments after %q: %v", args[0], args[1:])
+		}
+		if !slices.Contains(Commands, args[0]) {
+			return Config{}, fmt.Errorf("unknown command %q (available: %v)", args[0], Commands)
+		}
+		cfg.Command = args[0]
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return Config{}, fmt.Errorf("port must be in [0, 65535], got %d", cfg.Port)
+	}
+	if cfg.Port != 0 {
+		host, _, err := net.SplitHostPort(cfg.Addr)
+		if err != nil {
+			return Config{}, fmt.Errorf("addr %q: %w", cfg.Addr, err)
+		}
+		cfg.Addr = net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+	}
 	if cfg.Deadzone < 0.0 || cfg.Deadzone > 1.0 {
 		return Config{}, fmt.Errorf("deadzone must be in [0.0, 1.0], got %f", cfg.Deadzone)
 	}
+	if cfg.TriggerDeadzone < 0.0 || cfg.TriggerDeadzone > 1.0 {
+		return Config{}, fmt.Errorf("trigger-deadzone must be in [0.0, 1.0], got %f", cfg.TriggerDeadzone)
+	}
+	if !slices.Contains(DeadzoneModes, cfg.DeadzoneMode) {
+		return Config{}, fmt.Errorf("deadzone-mode must be one of %v, got %q", DeadzoneModes, cfg.DeadzoneMode)
+	}
+	for guid, d := range cfg.Deadzones {
+		if b, err := hex.DecodeString(guid); err != nil || len(b) != 16 {
+			return Config{}, fmt.Errorf("deadzones: %q is not an SDL GUID (32 hex characters)", guid)
+		}
+		if d.Mode != "" && !slices.Contains(DeadzoneModes, d.Mode) {
+			return Config{}, fmt.Errorf("deadzones.%s.mode must be one of %v, got %q", guid, DeadzoneModes, d.Mode)
+		}
+		for name, dz := range map[string]*float64{"stick": d.Stick, "trigger": d.Trigger} {
+			if dz != nil && (*dz < 0.0 || *dz > 1.0) {
+				return Config{}, fmt.Errorf("deadzones.%s.%s must be in [0.0, 1.0], got %f", guid, name, *dz)
+			}
+		}
+	}
 	if cfg.PollRate < 1 {
 		return Config{}, fmt.Errorf("poll-rate must be >= 1, got %d", cfg.PollRate)
 	}
+	if cfg.UpdateRate < 0 || cfg.UpdateRate > 1000 {
+		return Config{}, fmt.Errorf("update-rate must be in [0, 1000], got %f", cfg.UpdateRate)
+	}
 	if cfg.MouseSensitivity <= 0 {
 		return Config{}, fmt.Errorf("mouse-sens must be > 0, got %f", cfg.MouseSensitivity)
 	}
+	if cfg.ChangesBuffer < 1 {
+		return Config{}, fmt.Errorf("changes-buffer must be >= 1, got %d", cfg.ChangesBuffer)
+	}
+	if cfg.HubBuffer < 0 {
+		return Config{}, fmt.Errorf("hub-buffer must be >= 0, got %d", cfg.HubBuffer)
+	}
+	if cfg.ClientBuffer < 0 {
+		return Config{}, fmt.Errorf("client-buffer must be >= 0, got %d", cfg.ClientBuffer)
+	}
+	if cfg.PingInterval < 0 {
+		return Config{}, fmt.Errorf("ping-interval must be >= 0, got %d", cfg.PingInterval)
+	}
+	if cfg.PingTimeout < 1 {
+		return Config{}, fmt.Errorf("ping-timeout must be >= 1, got %d", cfg.PingTimeout)
+	}
+	if cfg.BenchEvents < 1 {
+		return Config{}, fmt.Errorf("bench-events must be >= 1, got %d", cfg.BenchEvents)
+	}
+	if cfg.ShutdownTimeout < 1 {
+		return Config{}, fmt.Errorf("shutdown-timeout must be >= 1, got %d", cfg.ShutdownTimeout)
+	}
+	if cfg.CompareReplay != "" && cfg.CompareReplay == cfg.CaptureRaw {
+		return Config{}, errors.New("compare-replay and capture-raw must be different files")
+	}
+	if cfg.GhostReplay != "" && cfg.GhostReplay == cfg.CaptureRaw {
+		return Config{}, errors.New("ghost-replay and capture-raw must be different files")
+	}
+	if cfg.Replay != "" && cfg.Replay == cfg.CaptureRaw {
+		return Config{}, errors.New("replay and capture-raw must be different files")
+	}
+	if cfg.Replay != "" && len(cfg.RelayFrom) > 0 {
+		return Config{}, errors.New("replay and relay-from cannot be used together")
+	}
+	if cfg.Scenario != "" && (cfg.Replay != "" || len(cfg.RelayFrom) > 0) {
+		return Config{}, errors.New("scenario cannot be used with replay or relay-from")
+	}
+	if cfg.Demo && (cfg.Replay != "" || cfg.Scenario != "" || len(cfg.RelayFrom) > 0) {
+		return Config{}, errors.New("demo cannot be used with replay, scenario, or relay-from")
+	}
+	if len(cfg.RelayFrom) > 16 {
+		return Config{}, fmt.Errorf("relay-from takes at most 16 URLs, got %d", len(cfg.RelayFrom))
+	}
+	if len(cfg.RelayFrom) > 1 && !cfg.AllPlayers {
+		return Config{}, errors.New("several relay-from URLs need all-players")
+	}
+	if cfg.RelayPlayer < 1 || cfg.RelayPlayer > 16 {
+		return Config{}, fmt.Errorf("relay-player must be in [1, 16], got %d", cfg.RelayPlayer)
+	}
+	if cfg.ReplaySpeed < 0.1 || cfg.ReplaySpeed > 16 {
+		return Config{}, fmt.Errorf("replay-speed must be in [0.1, 16], got %g", cfg.ReplaySpeed)
+	}
+	if cfg.History < 0 || cfg.History > 600 {
+		return Config{}, fmt.Errorf("history must be in [0, 600], got %d", cfg.History)
+	}
+	if cfg.StatsInterval < 0 || cfg.StatsInterval > 3600 {
+		return Config{}, fmt.Errorf("stats-interval must be in [0, 3600], got %d", cfg.StatsInterval)
+	}
+	if cfg.FreezeTimeout < 0 || cfg.FreezeTimeout > 3600 {
+		return Config{}, fmt.Errorf("freeze-timeout must be in [0, 3600], got %d", cfg.FreezeTimeout)
+	}
+	if cfg.IdleTimeout < 0 {
+		return Config{}, fmt.Errorf("idle-timeout must be >= 0, got %d", cfg.IdleTimeout)
+	}
+	if cfg.IdleAction != "sleep" && cfg.IdleAction != "exit" {
+		return Config{}, fmt.Errorf("idle-action must be sleep or exit, got %q", cfg.IdleAction)
+	}
+	if cfg.UDPRate < 1 || cfg.UDPRate > 1000 {
+		return Config{}, fmt.Errorf("udp-rate must be in [1, 1000], got %d", cfg.UDPRate)
+	}
+	if cfg.CompareWindow < 1 {
+		return Config{}, fmt.Errorf("compare-window must be >= 1, got %d", cfg.CompareWindow)
+	}
+	if cfg.CompareTolerance < 0 || cfg.CompareTolerance > cfg.CompareWindow {
+		return Config{}, fmt.Errorf("compare-tolerance must be in [0, compare-window], got %d", cfg.CompareTolerance)
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return Config{}, errors.New("tls-cert and tls-key must be set together")
+	}
+	if cfg.FixturesDir == "" {
+		return Config{}, errors.New("fixtures-dir must not be empty")
+	}
+	if cfg.Command == "export" && cfg.ExportInput == "" {
+		return Config{}, errors.New("the export command needs --export-input")
+	}
+	switch cfg.ExportFormat {
+	case "srt", "ass", "edl":
+	default:
+		return Config{}, fmt.Errorf("export-format must be one of srt/ass/edl, got %q", cfg.ExportFormat)
+	}
+	if cfg.ExportSync != "" && cfg.ExportSync != "wall" {
+		if d, err := time.ParseDuration(cfg.ExportSync); err != nil || d < 0 {
+			return Config{}, fmt.Errorf("export-sync must be empty, \"wall\", or a duration >= 0, got %q", cfg.ExportSync)
+		}
+	}
+	if cfg.ExportFPS < 1 || cfg.ExportFPS > 240 {
+		return Config{}, fmt.Errorf("export-fps must be in [1, 240], got %d", cfg.ExportFPS)
+	}
+	for _, t := range cfg.BatteryThresholds {
+		if t < 1 || t > 100 {
+			return Config{}, fmt.Errorf("battery-thresholds must be in [1, 100], got %d", t)
+		}
+	}
+	for name, p := range cfg.Profiles {
+		if len(name) > 64 {
+			return Config{}, fmt.Errorf("profiles: name %q too long (max 64 bytes)", name)
+		}
+		switch p.Rotate {
+		case 0, 90, 180, 270:
+		default:
+			return Config{}, fmt.Errorf("profiles.%s.rotate must be one of 0/90/180/270, got %d", name, p.Rotate)
+		}
+	}
+	addrs := map[string]string{cfg.Addr: "addr"}
+	for name, l := range cfg.Listeners {
+		if name == "addr" || name == "main" {
+			return Config{}, fmt.Errorf("listeners: name %q is reserved for the main listener", name)
+		}
+		if len(name) > 64 {
+			return Config{}, fmt.Errorf("listeners: name %q too long (max 64 bytes)", name)
+		}
+		if l.Addr == "" || l.Addr == "unix:" {
+			return Config{}, fmt.Errorf("listeners.%s.addr must be set", name)
+		}
+		if other, ok := addrs[l.Addr]; ok {
+			return Config{}, fmt.Errorf("listeners.%s.addr %q is already used by %s", name, l.Addr, other)
+		}
+		addrs[l.Addr] = "listeners." + name
+		if (l.TLSCert == "") != (l.TLSKey == "") {
+			return Config{}, fmt.Errorf("listeners.%s: tls-cert and tls-key must be set together", name)
+		}
+	}
+	for name, combo := range cfg.MarkerCombos {
+		if len(name) > 64 {
+			return Config{}, fmt.Errorf("marker-combos: name %q too long (max 64 bytes)", name)
+		}
+		if strings.TrimSpace(combo) == "" {
+			return Config{}, fmt.Errorf("marker-combos.%s must not be empty", name)
+		}
+	}
+	for name, bd := range cfg.Bindings {
+		if len(name) > 64 {
+			return Config{}, fmt.Errorf("bindings: name %q too long (max 64 bytes)", name)
+		}
+		if strings.TrimSpace(bd.Combo) == "" {
+			return Config{}, fmt.Errorf("bindings.%s.combo must be set", name)
+		}
+		if bd.Hold < 0 || bd.Hold > 60 {
+			return Config{}, fmt.Errorf("bindings.%s.hold must be in [0, 60], got %g", name, bd.Hold)
+		}
+		if !slices.Contains(BindingActions, bd.Action) {
+			return Config{}, fmt.Errorf("bindings.%s.action must be one of %v, got %q", name, BindingActions, bd.Action)
+		}
+		if bd.Action == "record" && cfg.CaptureRaw == "" {
+			return Config{}, fmt.Errorf("bindings.%s: the record action needs capture-raw to name the recordings", name)
+		}
+		if bd.Action == "webhook" {
+			if u, err := url.Parse(bd.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return Config{}, fmt.Errorf("bindings.%s.url must be an http or https URL, got %q", name, bd.URL)
+			}
+		}
+	}
+	for name, sq := range cfg.Sequences {
+		if len(name) > 64 {
+			return Config{}, fmt.Errorf("sequences: name %q too long (max 64 bytes)", name)
+		}
+		if len(sq.Steps) < 2 || len(sq.Steps) > 32 {
+			return Config{}, fmt.Errorf("sequences.%s.steps must have 2 to 32 steps, got %d", name, len(sq.Steps))
+		}
+		for i, step := range sq.Steps {
+			if strings.TrimSpace(step) == "" {
+				return Config{}, fmt.Errorf("sequences.%s.steps[%d] must not be empty", name, i)
+			}
+		}
+		if sq.Window < 0 || sq.Window > 10 {
+			return Config{}, fmt.Errorf("sequences.%s.window must be in [0, 10], got %g", name, sq.Window)
+		}
+	}
+	for name, p := range cfg.Plugins {
+		if len(name) > 64 {
+			return Config{}, fmt.Errorf("plugins: name %q too long (max 64 bytes)", name)
+		}
+		if len(p.Command) == 0 || p.Command[0] == "" {
+			return Config{}, fmt.Errorf("plugins.%s.command must be set", name)
+		}
+	}
+	for _, s := range cfg.AllowCIDR {
+		if _, err := ParsePrefix(s); err != nil {
+			return Config{}, fmt.Errorf("allow-cidr: %w", err)
+		}
+	}
+	for _, s := range cfg.TrustedProxies {
+		if _, err := ParsePrefix(s); err != nil {
+			return Config{}, fmt.Errorf("trusted-proxies: %w", err)
+		}
+	}
 	switch cfg.LogLevel {
 	case "debug", "info", "warn", "error":
 	default:
@@ -99,3 +664,23 @@ func Load(exeDir string) (Config, error) {
 
 	return cfg, nil
 }
+
+// envPrefix is the prefix of the environment variables read by Load.
+const envPrefix = "INPUTVIEW"
+
+// envName returns the environment variable for a config key
+// ("auth-password" → "INPUTVIEW_AUTH_PASSWORD").
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// inContainer reports whether the process runs in a Docker (/.dockerenv) or
+// Podman (/run/.containerenv) container.
+func inContainer() bool {
+	for _, f := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(f); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+// Package export converts a raw input capture (see gamepad.ReplayCapture)
+// into timed annotations for video editors: SRT or ASS subtitle tracks and
+// EDL timeline markers, one entry per press — or per marker of a session's
+// marker list.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/marker"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Formats lists the accepted output formats (Write's format argument).
+var Formats = []string{"srt", "ass", "edl"}
+
+// minEntryDuration is how long an entry stays up at least, so taps remain
+// readable; it is cut short by the next entry.
+const minEntryDuration = 200 * time.Millisecond
+
+// labels are the names shown for hub.PressEdges controls.
+var labels = map[string]string{
+	"buttons.a": "A", "buttons.b": "B", "buttons.x": "X", "buttons.y": "Y",
+	"buttons.lb": "LB", "buttons.rb": "RB",
+	"buttons.back": "Back", "buttons.start": "Start", "buttons.guide": "Guide",
+	"buttons.touchpad": "Touchpad", "buttons.capture": "Capture",
+	"extra.p1": "P1", "extra.p2": "P2", "extra.p3": "P3", "extra.p4": "P4",
+	"extra.fn1": "Fn1", "extra.fn2": "Fn2",
+	"dpad.up": "Up", "dpad.down": "Down", "dpad.left": "Left", "dpad.right": "Right",
+	"sticks.left": "LS", "sticks.right": "RS",
+}
+
+// markerEntryDuration is how long a marker entry stays up, unless cut short
+// by the next marker.
+const markerEntryDuration = 2 * time.Second
+
+// Entry is one press: the controls pressed together, shown from the press
+// until they are all released (at least minEntryDuration, at most until the
+// next entry). For a marker (see Markers), Name is shown instead.
+type Entry struct {
+	At       time.Duration // since capture start (time of day for markers)
+	End      time.Duration // since capture start (time of day for markers)
+	Controls []string      // hub.PressEdges naming, e.g. "buttons.a", "dpad.up"
+	Name     string        // shown instead of Controls when set
+}
+
+// Text returns what the output shows for the entry: its Name, or its
+// controls, e.g. "A + Up".
+func (e Entry) Text() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	names := make([]string, len(e.Controls))
+	for i, c := range e.Controls {
+		names[i] = labels[c]
+	}
+	return strings.Join(names, " + ")
+}
+
+// Entries reads a raw input capture of one controller and returns its
+// presses, with times relative to the capture start. dz is the deadzone to
+// replay with. Like hub.LoadRecording, it does not tell devices apart.
+func Entries(rd io.Reader, dz float64) ([]Entry, error) {
+	var (
+		entries []Entry
+		prev    gamepad.GamepadState
+		last    time.Duration
+		held    = make(map[string]int) // control → index of the entry it was pressed in
+	)
+	err := gamepad.ReplayCapture(rd, dz, func(at time.Duration, s gamepad.GamepadState) {
+		for _, c := range hub.PressEdges(s, prev) {
+			if i, ok := held[c]; ok {
+				entries[i].End = max(entries[i].End, at)
+				delete(held, c)
+			}
+		}
+		if pressed := hub.PressEdges(prev, s); len(pressed) > 0 {
+			entries = append(entries, Entry{At: at, End: at, Controls: pressed})
+			for _, c := range pressed {
+				held[c] = len(entries) - 1
+			}
+		}
+		prev, last = s, at
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("capture contains no presses")
+	}
+	for _, i := range held { // still held when the capture ended
+		entries[i].End = max(entries[i].End, last)
+	}
+	for i := range entries {
+		e := &entries[i]
+		e.End = max(e.End, e.At+minEntryDuration)
+		if i+1 < len(entries) {
+			e.End = min(e.End, entries[i+1].At)
+		}
+	}
+	return entries, nil
+}
+
+// Markers returns an entry per marker, timed by its local time of day (like
+// --export-sync=wall), for Write with offset 0.
+func Markers(markers []marker.Marker) []Entry {
+	entries := make([]Entry, len(markers))
+	for i, m := range markers {
+		t := time.UnixMilli(m.Time)
+		at := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()))
+		entries[i] = Entry{At: at, End: at + markerEntryDuration, Name: m.Name}
+		if i > 0 && entries[i-1].End > at {
+			entries[i-1].End = max(at, entries[i-1].At)
+		}
+	}
+	return entries
+}
+
+// Write writes entries to w in format (one of Formats), with offset added to
+// every time: the output time of the capture start. fps is the timecode frame
+// rate of EDL output; title names the track.
+func Write(w io.Writer, format string, entries []Entry, offset time.Duration, fps int, title string) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case "srt":
+		writeSRT(bw, entries, offset)
+	case "ass":
+		writeASS(bw, entries, offset, title)
+	case "edl":
+		writeEDL(bw, entries, offset, fps, title)
+	default:
+		return fmt.Errorf("unknown export format %q (available: %v)", format, Formats)
+	}
+	return bw.Flush()
+}
+
+// writeSRT writes a SubRip subtitle track.
+func writeSRT(w io.Writer, entries []Entry, offset time.Duration) {
+	for i, e := range entries {
+		fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, srtTime(e.At+offset), srtTime(e.End+offset), e.Text())
+	}
+}
+
+// srtTime formats d as an SRT timestamp, "hh:mm:ss,mmm".
+func srtTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// assHeader is the script header of ASS output: a 1080p script with one
+// bottom-centred style for the entries.
+const assHeader = `[Script Info]
+Title: %s
+ScriptType: v4.00+
+PlayResX: 1920
+PlayResY: 1080
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Input,Arial,48,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,-1,0,0,0,100,100,0,0,1,3,0,2,40,40,60,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`
+
+// writeASS writes an Advanced SubStation Alpha subtitle track.
+func writeASS(w io.Writer, entries []Entry, offset time.Duration, title string) {
+	fmt.Fprintf(w, assHeader, title)
+	for _, e := range entries {
+		fmt.Fprintf(w, "Dialogue: 0,%s,%s,Input,,0,0,0,,%s\n", assTime(e.At+offset), assTime(e.End+offset), e.Text())
+	}
+}
+
+// assTime formats d as an ASS timestamp, "h:mm:ss.cc".
+func assTime(d time.Duration) string {
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+// writeEDL writes a CMX 3600 EDL with one marker per entry, in the form DaVinci
+// Resolve imports as timeline markers (Timeline → Import → Timeline Markers
+// from EDL): a one-frame event followed by a "|C: |M: |D:" marker line.
+func writeEDL(w io.Writer, entries []Entry, offset time.Duration, fps int, title string) {
+	fmt.Fprintf(w, "TITLE: %s\nFCM: NON-DROP FRAME\n\n", title)
+	for i, e := range entries {
+		in, dur := frames(e.At+offset, fps), frames(e.End-e.At, fps)
+		tcIn, tcOut := timecode(in, fps), timecode(in+1, fps)
+		fmt.Fprintf(w, "%03d  001      V     C        %s %s %s %s  \n", i+1, tcIn, tcOut, tcIn, tcOut)
+		fmt.Fprintf(w, " |C:ResolveColorBlue |M:%s |D:%d\n\n", e.Text(), max(dur, 1))
+	}
+}
+
+// frames converts d to a frame count at fps, rounding to the nearest frame.
+func frames(d time.Duration, fps int) int64 {
+	return (d.Milliseconds()*int64(fps) + 500) / 1000
+}
+
+// timecode formats a frame count as "hh:mm:ss:ff", wrapping after 24 hours.
+func timecode(n int64, fps int) string {
+	f := int64(fps)
+	s := n / f
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", s/3600%24, s/60%60, s%60, n%f)
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEntries verifies that presses become entries from press to release,
+// with simultaneous presses merged, short taps extended, and holds cut at the
+// next entry.
+func TestEntries(t *testing.T) {
+	capture := strings.Join([]string{
+		`{"t":0,"event":"connect","source":"xinput","device":0,"vid":1118,"pid":654}`,
+		`{"t":1000000,"event":"input","source":"xinput","device":0,"data":"011000000000000000000000"}`, // A + dpad up
+		`{"t":1500000,"event":"input","source":"xinput","device":0,"data":"001000000000000000000000"}`, // up released
+		`{"t":1600000,"event":"input","source":"xinput","device":0,"data":"000000000000000000000000"}`, // A released
+		`{"t":2000000,"event":"input","source":"xinput","device":0,"data":"002000000000000000000000"}`, // B tap
+		`{"t":2050000,"event":"input","source":"xinput","device":0,"data":"000000000000000000000000"}`,
+		`{"t":3000000,"event":"input","source":"xinput","device":0,"data":"004000000000000000000000"}`, // X held...
+		`{"t":3100000,"event":"input","source":"xinput","device":0,"data":"00c000000000000000000000"}`, // ...into Y
+	}, "\n")
+	got, err := Entries(strings.NewReader(capture), 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := time.Millisecond
+	want := []Entry{
+		{At: 1000 * s, End: 1600 * s, Controls: []string{"buttons.a", "dpad.up"}},
+		{At: 2000 * s, End: 2200 * s, Controls: []string{"buttons.b"}},
+		{At: 3000 * s, End: 3100 * s, Controls: []string{"buttons.x"}},
+		{At: 3100 * s, End: 3300 * s, Controls: []string{"buttons.y"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() =\n%+v\nwant\n%+v", got, want)
+	}
+	if text := got[0].Text(); text != "A + Up" {
+		t.Errorf("Text() = %q, want %q", text, "A + Up")
+	}
+}
+
+// TestWrite verifies the timestamps and layout of each format, with an
+// offset applied.
+func TestWrite(t *testing.T) {
+	entries := []Entry{{At: 1500 * time.Millisecond, End: 1750 * time.Millisecond, Controls: []string{"buttons.a"}}}
+	offset := time.Hour + 2*time.Second
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"srt", []string{"1\n01:00:03,500 --> 01:00:03,750\nA\n\n"}},
+		{"ass", []string{"Title: run\n", "Style: Input,", "Dialogue: 0,1:00:03.50,1:00:03.75,Input,,0,0,0,,A\n"}},
+		{"edl", []string{"TITLE: run\n", "001  001      V     C        01:00:03:15 01:00:03:16 01:00:03:15 01:00:03:16  \n |C:ResolveColorBlue |M:A |D:8\n"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, tt.format, entries, offset, 30, "run"); err != nil {
+			t.Fatalf("Write(%s): %v", tt.format, err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("Write(%s) = %q, want containing %q", tt.format, buf.String(), w)
+			}
+		}
+	}
+	if err := Write(&bytes.Buffer{}, "vtt", entries, 0, 30, "run"); err == nil {
+		t.Error("Write(vtt): got nil error")
+	}
+}
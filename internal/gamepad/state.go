@@ -1,135 +0,0 @@
-package gamepad
-
-import "math"
-
-// Vector represents a 2D coordinate.
-type Vector struct {
-	X float64 `json:"x"`
-	Y float64 `json:"y"`
-}
-
-// StickState represents the state of an analog stick (position and pressed state).
-type StickState struct {
-	Position Vector `json:"position"`
-	Pressed  bool   `json:"pressed"`
-}
-
-// TriggerState represents the state of a trigger (0.0 to 1.0).
-type TriggerState struct {
-	Value float64 `json:"value"`
-}
-
-// ButtonState represents the state of all face and shoulder buttons.
-type ButtonState struct {
-	A        bool `json:"a"`
-	B        bool `json:"b"`
-	X        bool `json:"x"`
-	Y        bool `json:"y"`
-	LB       bool `json:"lb"`
-	RB       bool `json:"rb"`
-	Back     bool `json:"back"`
-	Start    bool `json:"start"`
-	Guide    bool `json:"guide"`
-	Touchpad bool `json:"touchpad"`
-	Capture  bool `json:"capture"`
-}
-
-// DpadState represents the state of the directional pad.
-type DpadState struct {
-	Up    bool `json:"up"`
-	Down  bool `json:"down"`
-	Left  bool `json:"left"`
-	Right bool `json:"right"`
-}
-
-// SticksState represents the state of both analog sticks.
-type SticksState struct {
-	Left  StickState `json:"left"`
-	Right StickState `json:"right"`
-}
-
-// TriggersState represents the state of both triggers.
-type TriggersState struct {
-	LT TriggerState `json:"lt"`
-	RT TriggerState `json:"rt"`
-}
-
-// GamepadState represents the complete state of a connected gamepad.
-type GamepadState struct {
-	Connected      bool          `json:"connected"`
-	ControllerType string        `json:"controllerType"`
-	Name           string        `json:"name"`
-	PlayerIndex    int           `json:"playerIndex"`
-	Buttons        ButtonState   `json:"buttons"`
-	Dpad           DpadState     `json:"dpad"`
-	Sticks         SticksState   `json:"sticks"`
-	Triggers       TriggersState `json:"triggers"`
-}
-
-// DeltaChanges represents incremental changes to gamepad state for efficient updates.
-// Pointer fields indicate which values have changed (nil = unchanged).
-type DeltaChanges struct {
-	Connected      *bool          `json:"connected,omitempty"`
-	ControllerType *string        `json:"controllerType,omitempty"`
-	Name           *string        `json:"name,omitempty"`
-	Buttons        *ButtonState   `json:"buttons,omitempty"`
-	Dpad           *DpadState     `json:"dpad,omitempty"`
-	Sticks         *SticksState   `json:"sticks,omitempty"`
-	Triggers       *TriggersState `json:"triggers,omitempty"`
-}
-
-// IsEmpty returns true if no changes are present.
-func (d *DeltaChanges) IsEmpty() bool {
-	return d.Connected == nil &&
-		d.ControllerType == nil &&
-		d.Name == nil &&
-		d.Buttons == nil &&
-		d.Dpad == nil &&
-		d.Sticks == nil &&
-		d.Triggers == nil
-}
-
-// analogThreshold is the minimum difference between two analog values to be considered different.
-const analogThreshold = 0.01
-
-// floatEqual returns true if two floats are approximately equal within analogThreshold.
-func floatEqual(a, b float64) bool {
-	return math.Abs(a-b) < analogThreshold
-}
-
-// ComputeDelta calculates the incremental changes between two gamepad states.
-func ComputeDelta(old, new_ GamepadState) *DeltaChanges {
-	d := &DeltaChanges{}
-
-	if old.Connected != new_.Connected {
-		d.Connected = &new_.Connected
-	}
-	if old.ControllerType != new_.ControllerType {
-		d.ControllerType = &new_.ControllerType
-	}
-	if old.Name != new_.Name {
-		d.Name = &new_.Name
-	}
-	if old.Buttons != new_.Buttons {
-		d.Buttons = &new_.Buttons
-	}
-	if old.Dpad != new_.Dpad {
-		d.Dpad = &new_.Dpad
-	}
-
-	if !floatEqual(old.Sticks.Left.Position.X, new_.Sticks.Left.Position.X) ||
-		!floatEqual(old.Sticks.Left.Position.Y, new_.Sticks.Left.Position.Y) ||
-		old.Sticks.Left.Pressed != new_.Sticks.Left.Pressed ||
-		!floatEqual(old.Sticks.Right.Position.X, new_.Sticks.Right.Position.X) ||
-		!floatEqual(old.Sticks.Right.Position.Y, new_.Sticks.Right.Position.Y) ||
-		old.Sticks.Right.Pressed != new_.Sticks.Right.Pressed {
-		d.Sticks = &new_.Sticks
-	}
-
-	if !floatEqual(old.Triggers.LT.Value, new_.Triggers.LT.Value) ||
-		!floatEqual(old.Triggers.RT.Value, new_.Triggers.RT.Value) {
-		d.Triggers = &new_.Triggers
-	}
-
-	return d
-}
@@ -0,0 +1,107 @@
+package hub
+
+import (
+	"sync"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Analog modes a client can receive the state stream in (see
+// Client.SetAnalogMode).
+const (
+	AnalogInline = "inline" // analog changes travel in the deltas like any other (the default)
+	AnalogSplit  = "split"  // deltas leave analog-only changes out; every analog change comes as an "analog" message
+	AnalogNone   = "none"   // deltas leave analog-only changes out; no analog messages
+)
+
+// AnalogModes lists the accepted analog mode names.
+var AnalogModes = []string{AnalogInline, AnalogSplit, AnalogNone}
+
+// AnalogFrame is the payload of an "analog" message: the analog inputs of a
+// player's state after one of them changed. Stick clicks are buttons and stay
+// in the deltas; wheels and flight sticks are not split.
+type AnalogFrame struct {
+	Sticks   AnalogSticks          `json:"sticks"`
+	Triggers gamepad.TriggersState `json:"triggers"`
+	Motion   *gamepad.MotionState  `json:"motion,omitempty"` // with --motion, for controllers that report it
+}
+
+// AnalogSticks are the positions of both sticks.
+type AnalogSticks struct {
+	Left  gamepad.Vector `json:"left"`
+	Right gamepad.Vector `json:"right"`
+}
+
+// newAnalogFrame returns the analog inputs of s.
+func newAnalogFrame(s gamepad.GamepadState) *AnalogFrame {
+	f := &AnalogFrame{
+		Sticks:   AnalogSticks{Left: s.Sticks.Left.Position, Right: s.Sticks.Right.Position},
+		Triggers: s.Triggers,
+	}
+	if s.Motion != (gamepad.MotionState{}) {
+		m := s.Motion
+		f.Motion = &m
+	}
+	return f
+}
+
+// hasAnalog reports whether the delta d may change an analog input.
+func hasAnalog(d *gamepad.DeltaChanges) bool {
+	return d != nil && (d.Sticks != nil || d.Triggers != nil || d.Motion != nil)
+}
+
+// eventChanges returns the changes from prev to next that an AnalogSplit or
+// AnalogNone client gets in its deltas: all but the triggers and motion, and
+// the sticks only when a stick click changed (with their current positions).
+// nil if only analog inputs changed.
+func eventChanges(prev, next gamepad.GamepadState) *gamepad.DeltaChanges {
+	d := gamepad.ComputeDelta(prev, next)
+	d.Triggers, d.Motion = nil, nil
+	if !clicked(prev, next) {
+		d.Sticks = nil
+	}
+	if d.IsEmpty() {
+		return nil
+	}
+	return d
+}
+
+// analogMessage is the "analog" message of a delta, marshalled the first time
+// a split client needs it, once for all of them.
+type analogMessage struct {
+	once  sync.Once
+	seq   int64
+	state gamepad.GamepadState
+	m     *outgoing // nil if marshalling failed
+}
+
+// outgoing returns the marshalled message, or nil if it cannot be marshalled.
+func (a *analogMessage) outgoing() *outgoing {
+	a.once.Do(func() {
+		if data, ok := marshalOrLog("analog message", NewAnalogMessage(a.seq, newAnalogFrame(a.state))); ok {
+			a.m = &outgoing{text: data}
+		}
+	})
+	return a.m
+}
+
+// SetAnalogMode sets how the client receives analog inputs (one of
+// AnalogModes; unknown names are AnalogInline): in its deltas, as separate
+// "analog" messages next to deltas that only carry the rest (AnalogSplit), or
+// only in its fulls (AnalogNone). The update rate (see SetUpdateRate) limits
+// the deltas; analog messages are sent for every change. Safe to call from
+// any goroutine.
+func (c *Client) SetAnalogMode(mode string) {
+	if mode != AnalogSplit && mode != AnalogNone {
+		mode = AnalogInline
+	}
+	c.analog.Store(mode)
+}
+
+// AnalogMode returns the client's analog mode (see SetAnalogMode).
+func (c *Client) AnalogMode() string {
+	if mode, ok := c.analog.Load().(string); ok {
+		return mode
+	}
+	return AnalogInline
+}
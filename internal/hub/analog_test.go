@@ -0,0 +1,79 @@
+package hub
+
+import (
+	"testing"
+	"time"
+)
+
+// TestEventChanges verifies that analog-only changes leave no event changes,
+// and that a stick click keeps the sticks.
+func TestEventChanges(t *testing.T) {
+	prev := fixtureXboxState()
+	next := prev
+	next.Sticks.Left.Position.X = 0.5
+	next.Triggers.LT.Value = 0.25
+	if d := eventChanges(prev, next); d != nil {
+		t.Errorf("eventChanges(analog only) = %+v, want nil", d)
+	}
+	next.Buttons.A = true
+	if d := eventChanges(prev, next); d == nil || d.Buttons == nil || d.Sticks != nil || d.Triggers != nil {
+		t.Errorf("eventChanges(A and analog) = %+v, want only the buttons", d)
+	}
+	next.Sticks.Left.Pressed = true
+	if d := eventChanges(prev, next); d == nil || d.Sticks == nil || d.Sticks.Left.Position.X != 0.5 {
+		t.Errorf("eventChanges(stick click) = %+v, want the sticks", d)
+	}
+}
+
+// TestAnalogSplit verifies that a split client gets analog changes as analog
+// messages and deltas only for the rest, and a none client only the rest.
+func TestAnalogSplit(t *testing.T) {
+	h := NewHub()
+	go h.Run(t.Context())
+	c, mc, recv := loopbackClient(t, h)
+	b := NewBroadcaster(h, nil, nil)
+
+	b.SendInitialState(c)
+	if full := recv(); full.Type != "full" {
+		t.Fatalf("first message = %q, want full", full.Type)
+	}
+	state := fixtureXboxState()
+	pushState(b, state)
+	if msg := recv(); msg.Type != "delta" {
+		t.Fatalf("after connecting got %q, want delta", msg.Type)
+	}
+
+	c.SetAnalogMode(AnalogSplit)
+	state.Sticks.Left.Position.X = 0.5
+	pushState(b, state)
+	if msg := recv(); msg.Type != "analog" || msg.Analog == nil || msg.Analog.Sticks.Left.X != 0.5 {
+		t.Fatalf("after a stick move got %+v, want an analog message", msg)
+	}
+	state.Buttons.A = true
+	state.Triggers.RT.Value = 0.75
+	pushState(b, state)
+	if msg := recv(); msg.Type != "analog" || msg.Analog.Triggers.RT.Value != 0.75 {
+		t.Fatalf("got %+v, want the analog message first", msg)
+	}
+	if msg := recv(); msg.Type != "delta" || msg.Changes.Buttons == nil || !msg.Changes.Buttons.A ||
+		msg.Changes.Sticks != nil || msg.Changes.Triggers != nil {
+		t.Fatalf("got %+v, want a delta with only the buttons", msg)
+	}
+
+	c.SetAnalogMode(AnalogNone)
+	state.Sticks.Right.Position.Y = -0.25
+	pushState(b, state)
+	state.Buttons.B = true
+	pushState(b, state)
+	if msg := recv(); msg.Type != "delta" || msg.Changes.Buttons == nil || !msg.Changes.Buttons.B || msg.Changes.Sticks != nil {
+		t.Fatalf("got %+v, want only the delta pressing B", msg)
+	}
+	select {
+	case extra := <-mc.msgs:
+		t.Errorf("unexpected %q in none mode", extra.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if c.AnalogMode() != AnalogNone {
+		t.Errorf("AnalogMode() = %q, want none", c.AnalogMode())
+	}
+}
@@ -0,0 +1,207 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lxzan/gws"
+	"github.com/soar/inputview/internal/input"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Benchmarks for message encoding and hub fan-out. Run with:
+//
+//	go test -run '^$' -bench . -benchmem ./pkg/gamepad/ ./internal/hub/
+//
+// and compare runs with benchstat to catch regressions as the protocol grows.
+
+// benchSink keeps results alive so the compiler cannot elide benchmarked work.
+var benchSink any
+
+// benchGamepadState returns a representative mid-game state.
+func benchGamepadState() gamepad.GamepadState {
+	s := gamepad.GamepadState{Connected: true, ControllerType: "xbox", Name: "Xbox Controller (VID_045E&PID_028E)", PlayerIndex: 1}
+	s.Buttons.A = true
+	s.Sticks.Left.Position = gamepad.Vector{X: 0.5, Y: -0.25}
+	s.Triggers.RT.Value = 0.75
+	return s
+}
+
+// BenchmarkEncode measures JSON encoding of each server→client message shape,
+// and the MessagePack transcode binary clients get on top of it.
+func BenchmarkEncode(b *testing.B) {
+	state := benchGamepadState()
+	delta := gamepad.ComputeDelta(gamepad.GamepadState{Connected: true, ControllerType: "xbox", Name: state.Name, PlayerIndex: 1}, state)
+	km := input.KeyMouseState{
+		Keys:         map[uint16]bool{17: true, 30: true, 31: true, 32: true},
+		MouseButtons: map[uint16]bool{1: true},
+	}
+	kmDelta := input.ComputeKeyMouseDelta(input.KeyMouseState{Keys: map[uint16]bool{}, MouseButtons: map[uint16]bool{}}, km)
+
+	cases := []struct {
+		name string
+		msg  *WSMessage
+	}{
+		{"full", NewFullMessage(1, &state)},
+		{"delta", NewDeltaMessage(2, delta)},
+		{"km_full", NewKMFullMessage(3, &km)},
+		{"km_delta", NewKMDeltaMessage(4, kmDelta)},
+	}
+	for _, tc := range cases {
+		b.Run("json/"+tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				data, err := json.Marshal(tc.msg)
+				if err != nil {
+					b.Fatal(err)
+				}
+				benchSink = data
+			}
+		})
+	}
+	for _, tc := range cases {
+		data, err := json.Marshal(tc.msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run("msgpack/"+tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				bin, err := jsonToMsgpack(data, wsMessageType)
+				if err != nil {
+					b.Fatal(err)
+				}
+				benchSink = bin
+			}
+		})
+	}
+}
+
+// benchServerHandler registers every accepted connection with the hub, in
+// encoding if set.
+type benchServerHandler struct {
+	gws.BuiltinEventHandler
+	hub      *Hub
+	encoding string
+}
+
+func (h *benchServerHandler) OnOpen(socket *gws.Conn) {
+	c := NewClient(h.hub, socket)
+	if h.encoding != "" {
+		c.SetEncoding(h.encoding)
+	}
+	socket.Session().Store("client", c)
+	h.hub.Register(c)
+}
+
+func (h *benchServerHandler) OnPong(socket *gws.Conn, payload []byte) {
+	if v, ok := socket.Session().Load("client"); ok {
+		v.(*Client).Seen()
+	}
+}
+
+func (h *benchServerHandler) OnClose(socket *gws.Conn, err error) {
+	if v, ok := socket.Session().Load("client"); ok {
+		h.hub.Unregister(v.(*Client))
+	}
+}
+
+// benchCountingClient counts messages received by a loopback client.
+type benchCountingClient struct {
+	gws.BuiltinEventHandler
+	received *atomic.Int64
+}
+
+func (c *benchCountingClient) OnMessage(socket *gws.Conn, message *gws.Message) {
+	message.Close()
+	c.received.Add(1)
+}
+
+// BenchmarkHubFanOut measures BroadcastToPlayer with N real loopback
+// WebSocket clients, JSON or MessagePack, including the time for every client
+// to receive each message (so write-queue cost is not hidden by WriteAsync).
+// Binary clients share one transcode per message.
+func BenchmarkHubFanOut(b *testing.B) {
+	state := benchGamepadState()
+	data, err := json.Marshal(NewFullMessage(1, &state))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Silence per-client connect/disconnect logs for the duration.
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	for _, enc := range Encodings {
+		for _, n := range []int{1, 10, 100} {
+			b.Run(fmt.Sprintf("%s/clients=%d", enc, n), func(b *testing.B) {
+				benchFanOut(b, data, enc, n)
+			})
+		}
+	}
+}
+
+// benchFanOut runs a BenchmarkHubFanOut case: n clients receiving data in
+// encoding enc.
+func benchFanOut(b *testing.B, data []byte, enc string, n int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := NewHub()
+	go h.Run(ctx)
+
+	upgrader := gws.NewUpgrader(&benchServerHandler{hub: h, encoding: enc}, nil)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		socket, err := upgrader.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		go socket.ReadLoop()
+	}))
+	defer ts.Close()
+
+	var received atomic.Int64
+	addr := "ws" + strings.TrimPrefix(ts.URL, "http")
+	for range n {
+		conn, _, err := gws.NewClient(&benchCountingClient{received: &received}, &gws.ClientOption{Addr: addr})
+		if err != nil {
+			b.Fatal(err)
+		}
+		go conn.ReadLoop()
+		defer conn.WriteClose(1000, nil)
+	}
+	waitForClients(b, h, n)
+
+	b.ReportAllocs()
+	var sent int64
+	for b.Loop() {
+		h.BroadcastToPlayer(data, 1)
+		sent += int64(n)
+		for received.Load() < sent {
+			time.Sleep(10 * time.Microsecond)
+		}
+	}
+}
+
+// waitForClients blocks until the hub has n registered clients.
+func waitForClients(b testing.TB, h *Hub, n int) {
+	b.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.RLock()
+		got := len(h.clients)
+		h.mu.RUnlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	b.Fatalf("timed out waiting for %d clients", n)
+}
@@ -0,0 +1,127 @@
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Binding runs Action when its combo is completed (see completes) and then
+// held for Hold, so a server action can be triggered from the controller.
+type Binding struct {
+	Name     string
+	Controls []string      // PressEdges naming; see ParseCombo
+	Hold     time.Duration // how long the combo must stay held; 0 = on completion
+	Action   func(playerIndex int)
+}
+
+// webhookTimeout bounds a webhook request, connection included.
+const webhookTimeout = 10 * time.Second
+
+// webhookClient sends the webhook requests.
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// WebhookPayload is the body a webhook binding posts when it has no payload
+// of its own.
+type WebhookPayload struct {
+	Binding     string `json:"binding"`
+	PlayerIndex int    `json:"playerIndex"` // the player who held the combo
+	Time        int64  `json:"time"`        // Unix milliseconds
+}
+
+// Webhook returns a binding Action that POSTs payload to url, in the
+// background so the broadcaster never waits on it: as application/json if it
+// is valid JSON, as text otherwise, and a WebhookPayload of the binding name
+// if it is empty. Failures and non-2xx responses are logged.
+func Webhook(name, url, payload string) func(playerIndex int) {
+	return func(playerIndex int) {
+		body, contentType := []byte(payload), "application/json"
+		switch {
+		case payload == "":
+			body, _ = json.Marshal(WebhookPayload{Binding: name, PlayerIndex: playerIndex, Time: time.Now().UnixMilli()})
+		case !json.Valid(body):
+			contentType = "text/plain; charset=utf-8"
+		}
+		go func() {
+			resp, err := webhookClient.Post(url, contentType, bytes.NewReader(body))
+			if err != nil {
+				slog.Warn("binding webhook failed", "binding", name, "error", err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode/100 != 2 {
+				slog.Warn("binding webhook failed", "binding", name, "status", resp.Status)
+				return
+			}
+			slog.Info("binding webhook sent", "binding", name, "player", playerIndex)
+		}()
+	}
+}
+
+// armedKey is a Binding whose combo a player holds, waiting out its Hold.
+// Each player arms a binding on their own.
+type armedKey struct {
+	binding     int // index into bindings
+	playerIndex int
+}
+
+// SetBindings runs each binding's Action (outside the broadcaster's lock, on
+// the broadcaster goroutine or a timer's) when its combo is completed and
+// held. nil (the default) disables bindings. Call before Run.
+func (b *Broadcaster) SetBindings(bindings []Binding) {
+	b.mu.Lock()
+	b.bindings = bindings
+	b.armed = make(map[armedKey]int64)
+	b.mu.Unlock()
+}
+
+// bindingsLocked returns the bindings state fires at once (Hold 0), arms the
+// timers of the held ones it completes, and disarms those it releases. b.mu
+// must be held, and state not yet tracked.
+func (b *Broadcaster) bindingsLocked(state gamepad.GamepadState) []Binding {
+	if len(b.bindings) == 0 {
+		return nil
+	}
+	var fired []Binding
+	if len(b.armed) > 0 {
+		held := PressEdges(gamepad.GamepadState{}, state)
+		for k := range b.armed {
+			if k.playerIndex == state.PlayerIndex && !holds(b.bindings[k.binding].Controls, held) {
+				delete(b.armed, k)
+			}
+		}
+	}
+	pressed, held := b.comboEdgesLocked(state)
+	for i, bd := range b.bindings {
+		if !completes(bd.Controls, pressed, held) {
+			continue
+		}
+		if bd.Hold == 0 {
+			fired = append(fired, bd)
+			continue
+		}
+		b.bindingGen++
+		k, gen := armedKey{binding: i, playerIndex: state.PlayerIndex}, b.bindingGen
+		b.armed[k] = gen
+		time.AfterFunc(bd.Hold, func() { b.holdElapsed(k, gen) })
+	}
+	return fired
+}
+
+// holdElapsed runs the binding of k for its player when its Hold passes,
+// unless the player released the combo or completed it again since.
+func (b *Broadcaster) holdElapsed(k armedKey, gen int64) {
+	b.mu.Lock()
+	if armed, ok := b.armed[k]; !ok || armed != gen {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.armed, k)
+	bd := b.bindings[k.binding]
+	b.mu.Unlock()
+	bd.Action(k.playerIndex)
+}
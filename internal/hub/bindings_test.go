@@ -0,0 +1,138 @@
+package hub
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestBindings verifies that a binding without Hold runs when its combo is
+// completed, and that a held binding runs only if the combo stays held for
+// its Hold.
+func TestBindings(t *testing.T) {
+	controls, _ := ParseCombo("guide+back")
+	ran := make(chan string, 4)
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetBindings([]Binding{
+		{Name: "now", Controls: controls, Action: func(int) { ran <- "now" }},
+		{Name: "held", Controls: controls, Hold: 50 * time.Millisecond, Action: func(p int) {
+			if p != 1 {
+				t.Errorf("held binding ran for player %d, want 1", p)
+			}
+			ran <- "held"
+		}},
+	})
+	idle := fixtureXboxState()
+	both := idle
+	both.Buttons.Guide, both.Buttons.Back = true, true
+
+	apply := func(state gamepad.GamepadState) []Binding {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		fired := b.bindingsLocked(state)
+		b.stateMessageLocked(state, 1000)
+		return fired
+	}
+
+	if fired := apply(both); len(fired) != 1 || fired[0].Name != "now" {
+		t.Fatalf("completing the combo fired %v, want only the binding without hold", fired)
+	}
+	apply(idle) // released before the hold passed
+	select {
+	case name := <-ran:
+		t.Fatalf("%s ran after an early release", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	apply(both)
+	select {
+	case name := <-ran:
+		if name != "held" {
+			t.Errorf("ran %q, want held", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("held binding did not run")
+	}
+}
+
+// TestBindingsPerPlayer verifies that two players holding the same held combo
+// each arm it: both run, once each, with their own player index.
+func TestBindingsPerPlayer(t *testing.T) {
+	controls, _ := ParseCombo("guide+back")
+	ran := make(chan int, 4)
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetBindings([]Binding{{Name: "held", Controls: controls, Hold: 50 * time.Millisecond, Action: func(p int) { ran <- p }}})
+	p1 := fixtureXboxState()
+	p1.Buttons.Guide, p1.Buttons.Back = true, true
+	p2 := p1
+	p2.PlayerIndex = 2
+
+	b.mu.Lock()
+	for _, s := range []gamepad.GamepadState{p1, p2} {
+		b.bindingsLocked(s)
+		b.stateMessageLocked(s, 1000)
+	}
+	b.mu.Unlock()
+
+	got := map[int]int{}
+	for range 2 {
+		select {
+		case p := <-ran:
+			got[p]++
+		case <-time.After(time.Second):
+			t.Fatalf("held binding ran for %v, want players 1 and 2", got)
+		}
+	}
+	select {
+	case p := <-ran:
+		got[p]++
+	case <-time.After(100 * time.Millisecond):
+	}
+	if got[1] != 1 || got[2] != 1 {
+		t.Errorf("held binding ran %v times per player, want once for players 1 and 2", got)
+	}
+}
+
+// TestWebhook verifies the requests of a webhook action: the default payload
+// names the binding and player, and a custom one is posted as given.
+func TestWebhook(t *testing.T) {
+	type request struct{ contentType, body string }
+	got := make(chan request, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		got <- request{r.Header.Get("Content-Type"), string(body)}
+	}))
+	defer ts.Close()
+	receive := func() request {
+		select {
+		case r := <-got:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("no webhook request")
+			return request{}
+		}
+	}
+
+	Webhook("highlight", ts.URL, "")(2)
+	r := receive()
+	var p WebhookPayload
+	if err := json.Unmarshal([]byte(r.body), &p); err != nil || p.Binding != "highlight" || p.PlayerIndex != 2 || p.Time == 0 {
+		t.Errorf("default payload = %s (%v), want binding highlight of player 2", r.body, err)
+	}
+	if r.contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", r.contentType)
+	}
+
+	Webhook("scene", ts.URL, "scene=brb")(1)
+	if r := receive(); r.body != "scene=brb" || r.contentType != "text/plain; charset=utf-8" {
+		t.Errorf("request = %+v, want the text payload as given", r)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/soar/inputview/internal/gamepad"
 	"github.com/soar/inputview/internal/input"
+	"github.com/soar/inputview/internal/marker"
+	"github.com/soar/inputview/pkg/gamepad"
 )
 
 const (
@@ -20,15 +22,44 @@ type Broadcaster struct {
 	hub         *Hub
 	changes     <-chan gamepad.GamepadState
 	kmChanges   <-chan input.KeyMouseState
-	mu          sync.Mutex // protects lastState, lastKMState, seq, kmSeq
+	mu          sync.Mutex // protects lastState, lastKMState, seq, kmSeq, deltaCount, players, batteryThresholds, the freeze, binding, sequence, notation, history, and statsInterval fields
 	lastState   gamepad.GamepadState
 	lastKMState input.KeyMouseState
 	seq         int64
 	kmSeq       int64
+	deltaCount  int64                // deltas since the last state-triggered full
+	started     int64                // Unix ms; PressCounters.Since
+	players     map[int]*playerStats // keyed by PlayerIndex
+	lastInput   atomic.Int64         // Unix ms of the last gamepad or keyboard/mouse change; see LastInput
+
+	batteryThresholds []int                          // percent; see SetBatteryThresholds
+	profiles          map[string]Transform           // lowercase name → transform; see SetProfiles
+	controllerEvents  <-chan gamepad.ControllerEvent // see SetControllerEvents; nil = none
+	comparison        *Comparison                    // see SetComparison; nil = none
+	ghost             *Ghost                         // see SetGhost; nil = none
+	markers           *marker.List                   // see SetMarkers; nil = none
+	markerCombos      []MarkerCombo                  // see SetMarkers
+	freezeCombo       []string                       // see SetFreezeCombo; nil = none
+	freezeTimeout     time.Duration                  // see SetFreezeCombo
+	bindings          []Binding                      // see SetBindings; nil = none
+	sequences         []Sequence                     // see SetSequences; nil = none
+	history           *history                       // see SetHistory; nil = none
+	statsInterval     time.Duration                  // see SetStatsInterval; 0 = none
+	allPlayers        bool                           // see SetAllPlayers
+
+	frozen    FreezeInfo             // see Freeze
+	held      []gamepad.GamepadState // the streams shown while frozen (see streamsLocked)
+	freezeGen int64                  // bumped by every freeze and release; guards expireFreeze
+
+	armed      map[armedKey]int64 // held bindings waiting out their Hold → bindingGen when armed
+	bindingGen int64              // bumped by every arming; guards holdElapsed
+
+	seqProgress map[int][]sequenceProgress // keyed by PlayerIndex, one per sequence
+	notation    map[int]notationMark       // keyed by PlayerIndex; the last notation entry
 }
 
 func NewBroadcaster(h *Hub, changes <-chan gamepad.GamepadState, kmChanges <-chan input.KeyMouseState) *Broadcaster {
-	return &Broadcaster{
+	b := &Broadcaster{
 		hub:       h,
 		changes:   changes,
 		kmChanges: kmChanges,
@@ -36,15 +67,45 @@ func NewBroadcaster(h *Hub, changes <-chan gamepad.GamepadState, kmChanges <-cha
 			Keys:         make(map[uint16]bool),
 			MouseButtons: make(map[uint16]bool),
 		},
+		started:  time.Now().UnixMilli(),
+		players:  make(map[int]*playerStats),
+		notation: make(map[int]notationMark),
 	}
+	b.lastInput.Store(b.started)
+	return b
 }
 
 // Run starts the broadcaster loop. Should be run in a goroutine.
 func (b *Broadcaster) Run() {
 	ticker := time.NewTicker(fullSyncInterval)
 	defer ticker.Stop()
-
-	var deltaCount int64
+	var compareTick <-chan time.Time // nil (never ready) without a comparison
+	if b.comparison != nil {
+		t := time.NewTicker(compareTickInterval)
+		defer t.Stop()
+		compareTick = t.C
+	}
+	var statsTick <-chan time.Time // nil (never ready) without a stats interval
+	if b.statsInterval > 0 {
+		t := time.NewTicker(b.statsInterval)
+		defer t.Stop()
+		statsTick = t.C
+	}
+	var ghostTick <-chan time.Time // nil (never ready) without a ghost
+	scheduleGhost := func(int64) {}
+	if b.ghost != nil {
+		t := time.NewTimer(0)
+		t.Stop()
+		defer t.Stop()
+		ghostTick = t.C
+		scheduleGhost = func(next int64) {
+			if next == 0 {
+				t.Stop()
+				return
+			}
+			t.Reset(time.Until(time.UnixMilli(next)))
+		}
+	}
 
 	for {
 		select {
@@ -53,29 +114,74 @@ func (b *Broadcaster) Run() {
 				return
 			}
 
+			now := time.Now().UnixMilli()
+			b.lastInput.Store(now)
 			b.mu.Lock()
-			delta := gamepad.ComputeDelta(b.lastState, state)
-			b.lastState = state
+			b.historyLocked(state, now)
+			diffs := b.compareLocked(state, now)
+			ghost, next := b.ghostLocked(&state, now)
+			combos := b.combosLocked(state)
+			bindings := b.bindingsLocked(state)
+			entered := b.sequencesLocked(state, now)
+			entry := b.notationLocked(state, now)
+			toggle := b.freezeComboLocked(state)
+			var freeze *FreezeInfo
+			if toggle && !b.frozen.Frozen {
+				// Freeze before this state is tracked, so the combo press is not shown.
+				info := b.freezeLocked(b.freezeTimeout)
+				freeze = &info
+			}
+			msg, power := b.stateMessageLocked(state, now)
+			var release []*WSMessage
+			if b.frozen.Frozen {
+				msg = nil
+				if toggle && freeze == nil {
+					release = b.unfreezeLocked()
+					freeze = &FreezeInfo{}
+				}
+			}
+			b.mu.Unlock()
 
-			if delta.IsEmpty() {
-				b.mu.Unlock()
-				continue
+			if msg != nil {
+				b.broadcastState(msg, state, state.PlayerIndex)
+			}
+			b.broadcastFulls(release)
+			b.broadcastPower(power, state.PlayerIndex)
+			b.broadcastDiffs(diffs)
+			b.broadcastGhost(ghost)
+			scheduleGhost(next)
+			b.broadcastNotation(state.PlayerIndex, entry)
+			for _, c := range combos {
+				b.AddMarker(c.Name, marker.SourceCombo, state.PlayerIndex)
+			}
+			for _, ev := range entered {
+				b.broadcastCombo(state.PlayerIndex, ev)
+			}
+			if freeze != nil {
+				b.broadcastFreeze(*freeze)
+			}
+			for _, bd := range bindings {
+				bd.Action(state.PlayerIndex)
 			}
 
-			b.seq++
-			seq := b.seq
-			playerIndex := state.PlayerIndex
+		case <-compareTick:
+			b.mu.Lock()
+			diffs := b.comparison.tick(time.Now().UnixMilli())
 			b.mu.Unlock()
+			b.broadcastDiffs(diffs)
 
-			deltaCount++
+		case <-statsTick:
+			b.mu.Lock()
+			stats := b.allSessionStatsLocked(time.Now().UnixMilli())
+			b.mu.Unlock()
+			b.broadcastStats(stats)
 
-			// Send full sync periodically
-			if deltaCount >= deltaCountSync {
-				b.broadcastFull(seq, state, playerIndex)
-				deltaCount = 0
-			} else {
-				b.broadcastDelta(seq, delta, playerIndex)
-			}
+		case <-ghostTick:
+			b.mu.Lock()
+			ghost, next := b.ghostLocked(nil, time.Now().UnixMilli())
+			b.mu.Unlock()
+			b.broadcastGhost(ghost)
+			scheduleGhost(next)
 
 		case kmState, ok := <-b.kmChanges:
 			if !ok {
@@ -85,19 +191,125 @@ func (b *Broadcaster) Run() {
 			}
 			b.handleKMState(kmState)
 
+		case ev, ok := <-b.controllerEvents:
+			if !ok {
+				b.controllerEvents = nil
+				continue
+			}
+			b.broadcastController(ev)
+
 		case <-ticker.C:
 			b.mu.Lock()
-			if b.lastState.Connected {
-				b.seq++
-				seq := b.seq
-				stateCopy := b.lastState
-				b.mu.Unlock()
-				b.broadcastFull(seq, stateCopy, stateCopy.PlayerIndex)
-			} else {
-				b.mu.Unlock()
+			var fulls []*WSMessage
+			// While frozen every client already has the held state.
+			if !b.frozen.Frozen {
+				for _, s := range b.streamsLocked() {
+					if s.Connected {
+						b.seq++
+						fulls = append(fulls, b.fullMessageLocked(b.seq, s))
+					}
+				}
 			}
+			b.mu.Unlock()
+			b.broadcastFulls(fulls)
+		}
+	}
+}
+
+// stateMessageLocked records state as the latest and returns the message for
+// its player's viewers (nil if nothing changed) and its power events.
+// Normally that is a delta, with a full every deltaCountSync deltas. When the
+// player index differs from the previous state's (player switched or
+// renumbered), a full is sent instead: the delta would be relative to another
+// player's state, which the new player's viewers have not been following.
+// Index 0 is the initial empty state every client got from SendInitialState,
+// so the first real state is still a delta. With SetAllPlayers each player is
+// a stream of its own: the delta is against that player's previous state, and
+// a full is sent when another device has the index (first state, renumbered,
+// disconnected). b.mu must be held.
+func (b *Broadcaster) stateMessageLocked(state gamepad.GamepadState, now int64) (*WSMessage, []PowerEvent) {
+	prev := b.lastState
+	switched := b.lastState.PlayerIndex != 0 && state.PlayerIndex != b.lastState.PlayerIndex
+	if b.allPlayers {
+		prev = gamepad.GamepadState{}
+		if ps, ok := b.players[state.PlayerIndex]; ok {
+			prev = ps.last
 		}
+		switched = prev.DeviceID != state.DeviceID
+	}
+	delta := gamepad.ComputeDelta(prev, state)
+	b.lastState = state
+	power := b.trackLocked(state, now)
+
+	if delta.IsEmpty() && !switched {
+		return nil, power
+	}
+	b.seq++
+	b.deltaCount++
+	if switched || b.deltaCount >= deltaCountSync {
+		b.deltaCount = 0
+		return b.fullMessageLocked(b.seq, state), power
+	}
+	return NewDeltaMessage(b.seq, delta), power
+}
+
+// trackLocked updates the press counters, session statistics, heatmaps, and
+// last-change timestamps of state's player, comparing against that player's previous state so that
+// switching the active player is not seen as presses, and returns the power
+// events between the two states. b.mu must be held.
+func (b *Broadcaster) trackLocked(state gamepad.GamepadState, now int64) []PowerEvent {
+	ps, ok := b.players[state.PlayerIndex]
+	if !ok {
+		ps = &playerStats{counts: PressCounters{Since: b.started}}
+		b.players[state.PlayerIndex] = ps
+	}
+	presses := ps.counts.total()
+	ps.counts.count(ps.last, state)
+	ps.session(ps.last, state, ps.counts.total()-presses, now)
+	ps.changed.update(ps.last, state, now)
+	ps.heat(ps.last, now)
+	power := powerEvents(ps.last.Battery, state.Battery, b.batteryThresholds)
+	ps.last, ps.lastAt = state, now
+	return power
+}
+
+// fullMessageLocked builds a "full" message for state with its player's
+// counters and last-change timestamps (copies). b.mu must be held.
+func (b *Broadcaster) fullMessageLocked(seq int64, state gamepad.GamepadState) *WSMessage {
+	msg := NewFullMessage(seq, &state)
+	if ps, ok := b.players[state.PlayerIndex]; ok {
+		counts, changed := ps.counts, ps.changed
+		msg.Counters = &counts
+		msg.LastChanged = &changed
 	}
+	return msg
+}
+
+// Counters returns a copy of the press counters of the given player, or nil
+// if no state has been seen for it yet. Safe to call from any goroutine.
+func (b *Broadcaster) Counters(playerIndex int) *PressCounters {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ps, ok := b.players[playerIndex]
+	if !ok {
+		return nil
+	}
+	counts := ps.counts
+	return &counts
+}
+
+// LastChanged returns a copy of the last-change timestamps of the given
+// player, or nil if no state has been seen for it yet. Safe to call from any
+// goroutine.
+func (b *Broadcaster) LastChanged(playerIndex int) *LastChanged {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ps, ok := b.players[playerIndex]
+	if !ok {
+		return nil
+	}
+	changed := ps.changed
+	return &changed
 }
 
 // handleKMState computes the delta from the previous keyboard/mouse state and broadcasts it.
@@ -110,6 +322,7 @@ func (b *Broadcaster) handleKMState(curr input.KeyMouseState) {
 		b.mu.Unlock()
 		return
 	}
+	b.lastInput.Store(time.Now().UnixMilli())
 
 	b.kmSeq++
 	seq := b.kmSeq
@@ -117,22 +330,33 @@ func (b *Broadcaster) handleKMState(curr input.KeyMouseState) {
 	b.broadcastKMDelta(seq, delta)
 }
 
-// SendInitialState sends the current full state to a newly connected client.
-// Safe to call from any goroutine (e.g. gws OnOpen handler).
+// LastInput returns when the last gamepad state change or keyboard/mouse
+// change arrived (the start time before any). Safe to call from any
+// goroutine.
+func (b *Broadcaster) LastInput() time.Time {
+	return time.UnixMilli(b.lastInput.Load())
+}
+
+// SendInitialState sends the current full state (the held one while frozen;
+// with SetAllPlayers, the one of the client's player) to a newly connected
+// client, or one that sent "request_full" to resync, in the client's output
+// profile. Safe to call from any goroutine (e.g. gws OnOpen handler).
 func (b *Broadcaster) SendInitialState(c *Client) {
 	b.mu.Lock()
 	b.seq++
-	stateCopy := b.lastState
 	seq := b.seq
+	msg := b.fullMessageLocked(seq, b.shownLocked(int(c.playerIndex.Load())))
 	b.mu.Unlock()
+	if t, ok := b.profiles[c.Profile()]; ok {
+		msg = transformMessage(msg, t)
+	}
 
-	msg := NewFullMessage(seq, &stateCopy)
 	data, err := json.Marshal(msg)
 	if err != nil {
 		slog.Error("error marshaling initial state", "error", err)
 		return
 	}
-	c.Send(data)
+	c.sendState(&outgoing{text: data}, &stateUpdate{seq: seq, state: *msg.Data, full: true})
 }
 
 // SendInitialKMState sends the current full keyboard/mouse state to a newly subscribed client.
@@ -172,21 +396,6 @@ func marshalOrLog(label string, v any) ([]byte, bool) {
 	return data, true
 }
 
-// broadcastFull marshals and broadcasts a full state message.
-// All state is passed by value — no lock needed.
-func (b *Broadcaster) broadcastFull(seq int64, state gamepad.GamepadState, playerIndex int) {
-	if data, ok := marshalOrLog("full message", NewFullMessage(seq, &state)); ok {
-		b.hub.BroadcastToPlayer(data, playerIndex)
-	}
-}
-
-// broadcastDelta marshals and broadcasts a delta message.
-func (b *Broadcaster) broadcastDelta(seq int64, delta *gamepad.DeltaChanges, playerIndex int) {
-	if data, ok := marshalOrLog("delta message", NewDeltaMessage(seq, delta)); ok {
-		b.hub.BroadcastToPlayer(data, playerIndex)
-	}
-}
-
 // broadcastKMDelta marshals and broadcasts a keyboard/mouse delta message.
 func (b *Broadcaster) broadcastKMDelta(seq int64, delta *input.KeyMouseDelta) {
 	if data, ok := marshalOrLog("km delta message", NewKMDeltaMessage(seq, delta)); ok {
@@ -0,0 +1,168 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestStateMessageRouting verifies which message each state produces: a delta
+// for changes within one player (including the first state after start and a
+// disconnect that keeps the index), nothing for an unchanged state, and a
+// full whenever the player index changes.
+func TestStateMessageRouting(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+
+	p1 := fixtureXboxState()
+	p1a := p1
+	p1a.Buttons.A = true
+	p2 := gamepad.GamepadState{Connected: true, ControllerType: "playstation", PlayerIndex: 2, DeviceID: "hid-10"}
+	p2renumbered := p2
+	p2renumbered.PlayerIndex = 1
+
+	steps := []struct {
+		name  string
+		state gamepad.GamepadState
+		want  string // message type; "" = no message
+	}{
+		{"first state", p1, "delta"},
+		{"press", p1a, "delta"},
+		{"unchanged", p1a, ""},
+		{"switch to player 2", p2, "full"},
+		{"renumbered to player 1", p2renumbered, "full"},
+		{"disconnect", gamepad.GamepadState{PlayerIndex: 1}, "delta"},
+	}
+	var lastSeq int64
+	for _, step := range steps {
+		b.mu.Lock()
+		msg, _ := b.stateMessageLocked(step.state, 1000)
+		b.mu.Unlock()
+		got := ""
+		if msg != nil {
+			got = msg.Type
+			if msg.Seq <= lastSeq {
+				t.Errorf("%s: seq %d not after %d", step.name, msg.Seq, lastSeq)
+			}
+			lastSeq = msg.Seq
+		}
+		if got != step.want {
+			t.Errorf("%s: message type %q, want %q", step.name, got, step.want)
+		}
+		if got == "full" && msg.Data.PlayerIndex != step.state.PlayerIndex {
+			t.Errorf("%s: full for player %d, want %d", step.name, msg.Data.PlayerIndex, step.state.PlayerIndex)
+		}
+	}
+}
+
+// TestStateMessageAllPlayers verifies that with SetAllPlayers each player is
+// a stream of its own: interleaved states of two players are deltas against
+// the same player's previous state, another device on an index gets a full,
+// and each player's viewers are shown that player's state.
+func TestStateMessageAllPlayers(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetAllPlayers(true)
+
+	p1 := fixtureXboxState()
+	p1a := p1
+	p1a.Buttons.A = true
+	p2 := gamepad.GamepadState{Connected: true, ControllerType: "playstation", PlayerIndex: 2, DeviceID: "hid-10"}
+	p2b := p2
+	p2b.Buttons.B = true
+	p2renumbered := p2b
+	p2renumbered.PlayerIndex = 1
+
+	steps := []struct {
+		name  string
+		state gamepad.GamepadState
+		want  string // message type; "" = no message
+	}{
+		{"first state of player 1", p1, "full"},
+		{"first state of player 2", p2, "full"},
+		{"player 1 press", p1a, "delta"},
+		{"player 2 press", p2b, "delta"},
+		{"player 1 unchanged", p1a, ""},
+		{"player 2 renumbered to player 1", p2renumbered, "full"},
+		{"player 2 gone", gamepad.GamepadState{PlayerIndex: 2}, "full"},
+	}
+	for _, step := range steps {
+		b.mu.Lock()
+		msg, _ := b.stateMessageLocked(step.state, 1000)
+		b.mu.Unlock()
+		got := ""
+		if msg != nil {
+			got = msg.Type
+		}
+		if got != step.want {
+			t.Errorf("%s: message type %q, want %q", step.name, got, step.want)
+		}
+		if got == "delta" && (msg.Changes.Buttons == nil || msg.Changes.ControllerType != nil) {
+			t.Errorf("%s: delta %+v, want the button press only", step.name, msg.Changes)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s := b.shownLocked(1); s.DeviceID != p2.DeviceID || !s.Buttons.B {
+		t.Errorf("shown to player 1 = %+v, want the renumbered controller", s)
+	}
+	if s := b.shownLocked(2); s.Connected {
+		t.Errorf("shown to player 2 = %+v, want disconnected", s)
+	}
+	if s := b.shownLocked(5); s.PlayerIndex != 5 || s.Connected {
+		t.Errorf("shown to player 5 = %+v, want an empty state of player 5", s)
+	}
+	if n := len(b.streamsLocked()); n != 2 {
+		t.Errorf("streamsLocked() = %d streams, want 2", n)
+	}
+}
+
+// TestRequestFull verifies that request_full resyncs the client with a full
+// of the current state, and that a second request within
+// minFullRequestInterval is ignored.
+func TestRequestFull(t *testing.T) {
+	h := NewHub()
+	go h.Run(t.Context())
+	c, mc, recv := loopbackClient(t, h)
+	b := NewBroadcaster(h, nil, nil)
+	c.SetCommandTargets(CommandTargets{States: b})
+	b.SendInitialState(c)
+	recv()
+	state := fixtureXboxState()
+	state.Buttons.A = true
+	pushState(b, state)
+	recv()
+
+	c.HandleMessage([]byte(`{"type":"request_full"}`))
+	msg := recv()
+	if msg.Type != "full" || msg.Data == nil || !msg.Data.Buttons.A {
+		t.Fatalf("after request_full got %+v, want a full with A pressed", msg)
+	}
+	c.HandleMessage([]byte(`{"type":"request_full"}`))
+	select {
+	case msg := <-mc.msgs:
+		t.Errorf("second request_full got %+v, want it ignored", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// stateRecorder is a StateProvider that records the requests instead of
+// sending a full.
+type stateRecorder struct{ requests int }
+
+func (r *stateRecorder) SendInitialState(*Client) { r.requests++ }
+
+// TestRequestFullResetsRate verifies that request_full makes the rate limiter
+// forget the state the client has, so a full that does not reach it is caught
+// up from scratch rather than with a delta from what it was last sent.
+func TestRequestFullResetsRate(t *testing.T) {
+	c := NewClient(NewHub(), nil)
+	var states stateRecorder
+	c.SetCommandTargets(CommandTargets{States: &states})
+	c.rate.sent, c.rate.synced = fixtureXboxState(), true
+
+	c.HandleMessage([]byte(`{"type":"request_full"}`))
+	if states.requests != 1 || c.rate.synced || c.rate.sent != (gamepad.GamepadState{}) {
+		t.Errorf("after request_full: %d requests, synced %v, sent %+v; want 1, false, the zero state", states.requests, c.rate.synced, c.rate.sent)
+	}
+}
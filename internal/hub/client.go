@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"log/slog"
 	"sync/atomic"
+	"time"
 
 	"github.com/lxzan/gws"
+	"github.com/soar/inputview/internal/audit"
+	"github.com/soar/inputview/pkg/gamepad"
 )
 
 // PlayerSwitcher defines the interface for switching active player index.
@@ -18,54 +21,251 @@ type KMStateProvider interface {
 	SendInitialKMState(c *Client)
 }
 
+// StateProvider can send the current gamepad state to a client on demand.
+type StateProvider interface {
+	SendInitialState(c *Client)
+}
+
+// ControllerOutput drives the motors and lights of the controller of a player
+// (0 = the active one) and switches it to raw mode.
+type ControllerOutput interface {
+	Rumble(playerIndex int, low, high float64, d time.Duration) error
+	SetLEDs(playerIndex int, leds gamepad.LEDs) error
+	SetRawMode(playerIndex int, enabled bool) error
+}
+
 // MouseSensitivitySetter can update the mouse movement sensitivity divisor.
 type MouseSensitivitySetter interface {
 	SetMouseSensitivity(float32)
 }
 
+// CommandTargets are what the commands of a client act on (see
+// Client.SetCommandTargets). A nil field disables the commands that need it.
+type CommandTargets struct {
+	Players     PlayerSwitcher         // select_player
+	Viewers     PlayerSwitcher         // select_player from a viewer token client: routes it only
+	KeyMouse    KMStateProvider        // subscribe_km
+	Sensitivity MouseSensitivitySetter // set_mouse_sens
+	Profiles    ProfileSelector        // select_profile
+	Ghosts      GhostSubscriber        // subscribe_ghost
+	Outputs     ControllerOutput       // rumble, set_led, set_raw_mode
+	States      StateProvider          // request_full
+}
+
+// minFullRequestInterval is how often a client may resync with request_full;
+// requests sooner after the last one are ignored.
+const minFullRequestInterval = time.Second
+
 // Client represents a connected WebSocket client.
 type Client struct {
 	hub           *Hub
 	conn          *gws.Conn
-	playerIndex   atomic.Int32 // 1-based player index this client is listening to
-	wantsKeyMouse atomic.Int32 // 1 when client has subscribed to keyboard/mouse events; 0 otherwise
+	playerIndex   atomic.Int32   // 1-based player index this client is listening to
+	wantsKeyMouse atomic.Int32   // 1 when client has subscribed to keyboard/mouse events; 0 otherwise
+	wantsGhost    atomic.Int32   // 1 when client has subscribed to the ghost stream (see Broadcaster.SetGhost); 0 otherwise
+	wantsNotation atomic.Int32   // 1 when client has subscribed to the notation stream (see NotationEntry); 0 otherwise
+	profile       atomic.Value   // string: output profile name; "" = untransformed (see Broadcaster.SelectProfile)
+	analog        atomic.Value   // string: analog mode (see SetAnalogMode); unset = AnalogInline
+	fields        atomic.Uint32  // fieldSet of the delta groups the client gets (see SetFields); 0 = all
+	remoteAddr    string         // client "ip:port" if resolved by the server (reverse proxy); "" = the socket peer
+	viewerToken   string         // ID of the viewer token the client connected with; "" = none
+	binary        bool           // receives MessagePack binary frames instead of JSON text (see SetEncoding)
+	targets       CommandTargets // see SetCommandTargets
+	lastFull      time.Time      // when request_full was last answered; only used by HandleMessage
+
+	sendLimit int32        // max queued, unwritten messages; 0 = unbounded (from Hub.SetClientBuffer)
+	inFlight  atomic.Int32 // messages handed to gws but not yet written
+	dropping  atomic.Bool  // true while messages are being dropped (for edge-triggered logging)
+	behind    atomic.Bool  // a state message was dropped; catch up once there is room (see catchUp)
+
+	rate     rateLimiter  // see SetUpdateRate
+	lastSeen atomic.Int64 // Unix ns of the last frame from the client (see Seen)
 }
 
 // NewClient creates a new Client attached to the hub.
 func NewClient(hub *Hub, conn *gws.Conn) *Client {
 	c := &Client{
-		hub:  hub,
-		conn: conn,
+		hub:       hub,
+		conn:      conn,
+		sendLimit: int32(hub.clientBuffer),
 	}
 	c.playerIndex.Store(1) // Default to player 1
+	c.profile.Store("")
+	c.SetUpdateRate(hub.updateRate)
+	c.Seen()
 	return c
 }
 
-// SetPlayerIndex sets the player index for this client.
-// Safe to call from any goroutine.
+// SetPlayerIndex sets the player index for this client, moving it to that
+// player's topic in the hub. Safe to call from any goroutine.
 func (c *Client) SetPlayerIndex(index int) {
-	c.playerIndex.Store(int32(index))
+	h := c.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.setPlayerLocked(c, int32(index))
+}
+
+// SetRemoteAddr records the client's address when it differs from the
+// socket peer (behind a reverse proxy). Must be called before Register.
+func (c *Client) SetRemoteAddr(addr string) {
+	c.remoteAddr = addr
+}
+
+// SetViewerToken records the ID of the viewer token the client was admitted
+// with, so the connection can be closed when the token expires or is
+// revoked. Must be called before Register.
+func (c *Client) SetViewerToken(id string) {
+	c.viewerToken = id
+}
+
+// SetEncoding sets the encoding the client receives messages in:
+// EncodingJSON (text frames, the default) or EncodingMsgpack (binary frames
+// with the same structure). Client commands are JSON either way. Must be
+// called before Register.
+func (c *Client) SetEncoding(enc string) {
+	c.binary = enc == EncodingMsgpack
+}
+
+// SetCommandTargets sets what the client's commands act on. Must be called
+// before Register.
+func (c *Client) SetCommandTargets(t CommandTargets) {
+	c.targets = t
+}
+
+// ViewerToken returns the ID given to SetViewerToken, or "".
+func (c *Client) ViewerToken() string { return c.viewerToken }
+
+// RemoteAddr returns the client's "ip:port": the address given to
+// SetRemoteAddr, or else the socket's peer.
+func (c *Client) RemoteAddr() string {
+	if c.remoteAddr != "" {
+		return c.remoteAddr
+	}
+	return c.conn.RemoteAddr().String()
+}
+
+// Profile returns the output profile this client receives states in, or ""
+// for untransformed states. Safe to call from any goroutine.
+func (c *Client) Profile() string {
+	return c.profile.Load().(string)
 }
 
-// Send queues a text message for asynchronous delivery to the client.
-// It is goroutine-safe and non-blocking; gws manages the internal write queue.
+// Send queues a JSON message for asynchronous delivery to the client, in
+// its encoding (see SetEncoding). It is goroutine-safe and non-blocking; gws
+// manages the internal write queue.
+//
+// gws's queue is unbounded, so with a send buffer configured (sendLimit > 0)
+// at most sendLimit messages may be waiting to be written; further messages
+// are dropped until the client catches up. A slow client then costs bounded
+// memory instead of growing a backlog. Its state stream is kept whole: the
+// fulls and deltas it missed are replaced by one delta from the state it has
+// to the latest, sent as soon as a write completes (see sendState).
 func (c *Client) Send(data []byte) {
-	c.conn.WriteAsync(gws.OpcodeText, data, nil)
+	c.send(&outgoing{text: data})
+}
+
+// send queues m in the client's encoding (see Send). It returns false if the
+// send buffer was full and m was dropped.
+func (c *Client) send(m *outgoing) bool {
+	opcode, data, ok := m.frame(c.binary)
+	if !ok {
+		return true
+	}
+	stats := &c.hub.stats
+	if c.sendLimit <= 0 {
+		stats.sent.Add(1)
+		c.conn.WriteAsync(opcode, data, stats.written)
+		return true
+	}
+	if c.inFlight.Add(1) > c.sendLimit {
+		c.inFlight.Add(-1)
+		stats.clientDrops.Add(1)
+		if c.dropping.CompareAndSwap(false, true) {
+			slog.Warn("client send buffer full, dropping messages", "limit", c.sendLimit, "remote", c.RemoteAddr())
+		}
+		return false
+	}
+	if c.dropping.CompareAndSwap(true, false) {
+		slog.Info("client send buffer drained, resuming", "remote", c.RemoteAddr())
+	}
+	stats.sent.Add(1)
+	c.conn.WriteAsync(opcode, data, func(err error) {
+		c.inFlight.Add(-1)
+		stats.written(err)
+		if c.behind.CompareAndSwap(true, false) {
+			go c.catchUp()
+		}
+	})
+	return true
 }
 
-// HandleMessage parses and dispatches a client command message.
-// Called from the gws OnMessage event handler.
-func (c *Client) HandleMessage(reader PlayerSwitcher, kmProvider KMStateProvider, sensSetter MouseSensitivitySetter, message []byte) {
-	var clientMsg ClientMessage
-	if err := json.Unmarshal(message, &clientMsg); err != nil {
-		slog.Error("error parsing client message", "error", err)
+// outgoing is a message on its way to one or more clients. Its MessagePack
+// form is transcoded once, for the first binary client, and reused for the
+// others. Not safe for concurrent use: each fan-out has its own.
+type outgoing struct {
+	text   []byte
+	binary []byte
+	failed bool // transcoding failed; binary clients do not get the message
+}
+
+// frame returns the WebSocket opcode and payload of m for a binary or text
+// client, and false if it cannot be sent.
+func (m *outgoing) frame(binary bool) (gws.Opcode, []byte, bool) {
+	if !binary {
+		return gws.OpcodeText, m.text, true
+	}
+	if m.binary == nil && !m.failed {
+		data, err := EncodeMsgpack(m.text)
+		if err != nil {
+			slog.Error("error encoding message as MessagePack", "error", err)
+			m.failed = true
+		}
+		m.binary = data
+	}
+	return gws.OpcodeBinary, m.binary, !m.failed
+}
+
+// controlCommands are the client commands that act on the controllers or the
+// server rather than on what the client is shown. Clients admitted with a
+// viewer token may not send them; their select_player goes to
+// CommandTargets.Viewers instead.
+var controlCommands = map[string]bool{
+	"rumble":         true,
+	"set_led":        true,
+	"set_raw_mode":   true,
+	"set_mouse_sens": true,
+}
+
+// HandleMessage parses and dispatches a client command message to the
+// client's CommandTargets. Called from the gws OnMessage event handler, one
+// message at a time.
+func (c *Client) HandleMessage(message []byte) {
+	received := monoNow()
+	t := c.targets
+	clientMsg, err := ParseClientMessage(message)
+	if err != nil {
+		slog.Warn("rejected client message", "error", err, "remote", c.RemoteAddr())
+		return
+	}
+	if controlCommands[clientMsg.Type] && c.ViewerToken() != "" {
+		slog.Warn("ignored control command from a viewer token client", "type", clientMsg.Type, "remote", c.RemoteAddr())
 		return
 	}
 
 	switch clientMsg.Type {
 	case "select_player":
-		if reader.SetActiveByPlayerIndex(clientMsg.PlayerIndex) {
-			c.SetPlayerIndex(clientMsg.PlayerIndex)
+		players := t.Players
+		if c.ViewerToken() != "" {
+			players = t.Viewers
+		}
+		if players == nil {
+			return
+		}
+		// Route to the new player before switching, so the full the switch
+		// triggers (see Broadcaster.stateMessageLocked) reaches this client.
+		prev := int(c.playerIndex.Load())
+		c.SetPlayerIndex(clientMsg.PlayerIndex)
+		if players.SetActiveByPlayerIndex(clientMsg.PlayerIndex) {
 			msg := NewPlayerSelectedMessage(clientMsg.PlayerIndex)
 			data, err := json.Marshal(msg)
 			if err != nil {
@@ -74,19 +274,109 @@ func (c *Client) HandleMessage(reader PlayerSwitcher, kmProvider KMStateProvider
 			}
 			c.Send(data)
 			slog.Info("client switched player", "player", clientMsg.PlayerIndex)
+			c.audit(audit.ActionSelectPlayer, map[string]any{"playerIndex": clientMsg.PlayerIndex})
 		} else {
+			c.SetPlayerIndex(prev)
 			slog.Warn("failed to switch player: invalid index", "player", clientMsg.PlayerIndex)
 		}
+	case "set_rate":
+		c.SetUpdateRate(clientMsg.Value)
+		slog.Info("client set update rate", "hz", clientMsg.Value)
+	case "set_analog":
+		c.SetAnalogMode(clientMsg.Analog)
+		slog.Info("client set analog mode", "mode", clientMsg.Analog)
+	case "set_fields":
+		names, _ := ParseFields(clientMsg.Fields) // validated by ParseClientMessage
+		c.SetFields(names)
+		slog.Info("client set fields", "fields", names)
+	case "select_profile":
+		if t.Profiles != nil && t.Profiles.SelectProfile(c, clientMsg.Profile) {
+			slog.Info("client selected output profile", "profile", clientMsg.Profile)
+			c.audit(audit.ActionSelectProfile, map[string]any{"profile": clientMsg.Profile})
+		} else {
+			slog.Warn("failed to select output profile: not configured", "profile", clientMsg.Profile)
+		}
 	case "subscribe_km":
 		c.wantsKeyMouse.Store(1)
 		slog.Info("client subscribed to keyboard/mouse events")
-		if kmProvider != nil {
-			kmProvider.SendInitialKMState(c)
+		if t.KeyMouse != nil {
+			t.KeyMouse.SendInitialKMState(c)
 		}
+	case "request_full":
+		if t.States == nil {
+			return
+		}
+		if now := time.Now(); now.Sub(c.lastFull) >= minFullRequestInterval {
+			c.lastFull = now
+			c.resetRate()
+			t.States.SendInitialState(c)
+			slog.Debug("client requested a full state")
+		} else {
+			slog.Debug("ignored request_full: too soon after the last one", "remote", c.RemoteAddr())
+		}
+	case "subscribe_ghost":
+		if t.Ghosts != nil && t.Ghosts.SubscribeGhost(c) {
+			slog.Info("client subscribed to the ghost stream")
+		} else {
+			slog.Warn("failed to subscribe to the ghost stream: no ghost replay configured")
+		}
+	case "subscribe_notation":
+		c.wantsNotation.Store(1)
+		slog.Info("client subscribed to the notation stream")
+	case "time_sync":
+		if data, ok := marshalOrLog("time_sync message", NewTimeSyncMessage(clientMsg.ClientTime, received)); ok {
+			c.Send(data)
+		}
+	case "rumble":
+		if t.Outputs == nil {
+			return
+		}
+		d := time.Duration(clientMsg.Duration) * time.Millisecond
+		if err := t.Outputs.Rumble(clientMsg.PlayerIndex, clientMsg.Low, clientMsg.High, d); err != nil {
+			slog.Warn("failed to rumble controller", "player", clientMsg.PlayerIndex, "error", err)
+			return
+		}
+		slog.Info("client rumbled controller", "player", clientMsg.PlayerIndex, "low", clientMsg.Low, "high", clientMsg.High, "duration", d)
+		c.audit(audit.ActionRumble, map[string]any{"playerIndex": clientMsg.PlayerIndex, "low": clientMsg.Low, "high": clientMsg.High, "duration": clientMsg.Duration})
+	case "set_led":
+		if t.Outputs == nil {
+			return
+		}
+		leds, _ := clientMsg.LEDs() // validated by ParseClientMessage
+		if err := t.Outputs.SetLEDs(clientMsg.PlayerIndex, leds); err != nil {
+			slog.Warn("failed to set controller LEDs", "player", clientMsg.PlayerIndex, "error", err)
+			return
+		}
+		details := map[string]any{"playerIndex": clientMsg.PlayerIndex}
+		if clientMsg.Color != "" {
+			details["color"] = clientMsg.Color
+		}
+		if clientMsg.PlayerLEDs != nil {
+			details["playerLeds"] = *clientMsg.PlayerLEDs
+		}
+		slog.Info("client set controller LEDs", "player", clientMsg.PlayerIndex, "color", clientMsg.Color)
+		c.audit(audit.ActionSetLED, details)
+	case "set_raw_mode":
+		if t.Outputs == nil {
+			return
+		}
+		enabled := *clientMsg.Enabled // validated by ParseClientMessage
+		if err := t.Outputs.SetRawMode(clientMsg.PlayerIndex, enabled); err != nil {
+			slog.Warn("failed to set controller raw mode", "player", clientMsg.PlayerIndex, "error", err)
+			return
+		}
+		slog.Info("client set controller raw mode", "player", clientMsg.PlayerIndex, "enabled", enabled)
+		c.audit(audit.ActionSetRawMode, map[string]any{"playerIndex": clientMsg.PlayerIndex, "enabled": enabled})
 	case "set_mouse_sens":
-		if sensSetter != nil && clientMsg.Value > 0 {
-			sensSetter.SetMouseSensitivity(float32(clientMsg.Value))
+		if t.Sensitivity != nil {
+			t.Sensitivity.SetMouseSensitivity(float32(clientMsg.Value))
 			slog.Info("mouse sensitivity set", "value", clientMsg.Value)
+			c.audit(audit.ActionSetMouseSens, map[string]any{"value": clientMsg.Value})
 		}
 	}
 }
+
+// audit records a control command of this client in the hub's audit log.
+func (c *Client) audit(action string, details map[string]any) {
+	c.hub.audit.Record(audit.Entry{Action: action, Source: audit.SourceWebSocket, Client: c.RemoteAddr(), Details: details})
+}
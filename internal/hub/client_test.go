@@ -0,0 +1,126 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// controlRecorder records the control commands that reach it.
+type controlRecorder struct {
+	calls []string
+}
+
+func (r *controlRecorder) SetActiveByPlayerIndex(int) bool {
+	r.calls = append(r.calls, "select_player")
+	return true
+}
+
+func (r *controlRecorder) SetMouseSensitivity(float32) {
+	r.calls = append(r.calls, "set_mouse_sens")
+}
+
+func (r *controlRecorder) Rumble(int, float64, float64, time.Duration) error {
+	r.calls = append(r.calls, "rumble")
+	return nil
+}
+
+func (r *controlRecorder) SetLEDs(int, gamepad.LEDs) error {
+	r.calls = append(r.calls, "set_led")
+	return nil
+}
+
+func (r *controlRecorder) SetRawMode(int, bool) error {
+	r.calls = append(r.calls, "set_raw_mode")
+	return nil
+}
+
+// TestViewerTokenControlCommands verifies that the control commands of a
+// client admitted with a viewer token are ignored (its select_player only
+// goes to the route-only Viewers target), while the same commands from any
+// other client are applied.
+func TestViewerTokenControlCommands(t *testing.T) {
+	commands := []string{
+		`{"type":"select_player","playerIndex":1}`,
+		`{"type":"rumble","low":1,"duration":500}`,
+		`{"type":"set_led","color":"#FF8000"}`,
+		`{"type":"set_raw_mode","enabled":true}`,
+		`{"type":"set_mouse_sens","value":1.5}`,
+	}
+	h := NewHub()
+	go h.Run(t.Context())
+	c, _, _ := loopbackClient(t, h)
+	var route controlRecorder
+	send := func(rec *controlRecorder) {
+		c.SetCommandTargets(CommandTargets{Players: rec, Viewers: &route, Sensitivity: rec, Outputs: rec})
+		for _, cmd := range commands {
+			c.HandleMessage([]byte(cmd))
+		}
+	}
+
+	c.SetViewerToken("guest")
+	var viewer controlRecorder
+	send(&viewer)
+	if len(viewer.calls) != 0 {
+		t.Errorf("viewer token client reached %v, want nothing", viewer.calls)
+	}
+	if len(route.calls) != 1 {
+		t.Errorf("viewer token client reached %v on the Viewers target, want select_player", route.calls)
+	}
+
+	c.SetViewerToken("")
+	var owner controlRecorder
+	send(&owner)
+	if len(owner.calls) != len(commands) {
+		t.Errorf("client without a token reached %v, want all %d commands", owner.calls, len(commands))
+	}
+	if len(route.calls) != 1 {
+		t.Errorf("client without a token reached %v on the Viewers target, want nothing more", route.calls)
+	}
+}
+
+// TestViewerTokenSelectPlayer verifies that a viewer token client can follow
+// another player through StreamSelector: it gets that player's states and
+// not the others', and the active controller is not switched.
+func TestViewerTokenSelectPlayer(t *testing.T) {
+	for _, allPlayers := range []bool{false, true} {
+		h := NewHub()
+		go h.Run(t.Context())
+		c, _, recv := loopbackClient(t, h)
+		b := NewBroadcaster(h, nil, nil)
+		b.SetAllPlayers(allPlayers)
+		var active controlRecorder
+		c.SetCommandTargets(CommandTargets{Players: &active, Viewers: b.StreamSelector()})
+		c.SetViewerToken("guest")
+
+		c.HandleMessage([]byte(`{"type":"select_player","playerIndex":2}`))
+		if msg := recv(); msg.Type != "full" {
+			t.Fatalf("allPlayers=%v: after select_player got %q, want the full", allPlayers, msg.Type)
+		}
+		if msg := recv(); msg.Type != "player_selected" || msg.PlayerIndex != 2 {
+			t.Fatalf("allPlayers=%v: got %q for player %d, want player_selected for player 2", allPlayers, msg.Type, msg.PlayerIndex)
+		}
+		if len(active.calls) != 0 {
+			t.Errorf("allPlayers=%v: the active controller was switched (%v)", allPlayers, active.calls)
+		}
+
+		p1 := gamepad.GamepadState{Connected: true, PlayerIndex: 1, DeviceID: "hid-1"}
+		p1.Buttons.A = true
+		p2 := gamepad.GamepadState{Connected: true, PlayerIndex: 2, DeviceID: "hid-2"}
+		p2.Buttons.B = true
+		pushState(b, p1)
+		pushState(b, p2)
+		msg := recv()
+		got := msg.Data
+		if msg.Changes != nil {
+			got = &gamepad.GamepadState{}
+			if msg.Changes.Buttons != nil {
+				got.Buttons = *msg.Changes.Buttons
+			}
+		}
+		if got == nil || !got.Buttons.B || got.Buttons.A {
+			t.Errorf("allPlayers=%v: player 2 viewer got %+v, want player 2's state", allPlayers, msg)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package hub
+
+import "time"
+
+// clockStart is the origin of the monotonic server clock carried in every
+// message (WSMessage.Mono).
+var clockStart = time.Now()
+
+// monoNow returns the monotonic server clock: microseconds since the process
+// started. Unlike Unix timestamps it never jumps when the wall clock is
+// adjusted, so clients can align messages with their own timelines after a
+// "time_sync" exchange.
+func monoNow() int64 {
+	return time.Since(clockStart).Microseconds()
+}
+
+// TimeSync is the server side of an NTP-style clock offset exchange, for
+// type "time_sync". With t0 = ClientTime, t1 = ServerReceive, t2 = ServerSend
+// and t3 the client's clock when the reply arrived (t0 and t3 in
+// microseconds), the server clock is ahead of the client's by
+// ((t1 - t0) + (t2 - t3)) / 2, within half the round trip
+// (t3 - t0) - (t2 - t1).
+type TimeSync struct {
+	ClientTime    float64 `json:"clientTime"`    // echoed from the client's time_sync command
+	ServerReceive int64   `json:"serverReceive"` // monotonic server clock when the command was read (µs)
+	ServerSend    int64   `json:"serverSend"`    // monotonic server clock when the reply was built (µs)
+}
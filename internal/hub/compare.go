@@ -0,0 +1,240 @@
+package hub
+
+import (
+	"io"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Input diff kinds (InputDiff.Kind).
+const (
+	DiffEarly  = "early"  // pressed before the reference by more than the tolerance
+	DiffLate   = "late"   // pressed after the reference by more than the tolerance
+	DiffMissed = "missed" // the reference pressed; the live player did not within the window
+	DiffExtra  = "extra"  // the live player pressed; the reference did not within the window
+)
+
+// compareTickInterval is how often the Broadcaster checks for missed
+// reference presses while a comparison is set.
+const compareTickInterval = 50 * time.Millisecond
+
+// Press is one press (released → pressed edge) of a digital control at a
+// time on a reference track.
+type Press struct {
+	At      int64  // milliseconds since the track's first press
+	Control string // "buttons.<name>", "extra.<name>", "dpad.<direction>", or "sticks.<left|right>" (click)
+}
+
+// InputDiff describes one divergence between the live player and the
+// reference track, sent as an "input_diff" message.
+type InputDiff struct {
+	Attempt  int    `json:"attempt"`            // 1-based attempt number since the server started
+	Control  string `json:"control"`            // Press.Control naming, e.g. "buttons.a", "dpad.up"
+	Kind     string `json:"kind"`               // DiffEarly, DiffLate, DiffMissed, or DiffExtra
+	AtMs     int64  `json:"atMs"`               // position on the reference track; the live press time for "extra"
+	OffsetMs int64  `json:"offsetMs,omitempty"` // live minus reference press time, for "early" / "late"
+
+	playerIndex int // the attempt's player, whose viewers get the diff
+}
+
+// PressEdges returns the controls pressed between two consecutive states of
+// the same player, in Press.Control naming. Triggers are analog and not
+// included, as in PressCounters. With the states swapped it returns the
+// controls released.
+func PressEdges(old, new_ gamepad.GamepadState) []string {
+	var out []string
+	add := func(name string, o, n bool) {
+		if !o && n {
+			out = append(out, name)
+		}
+	}
+	ob, nb := old.Buttons, new_.Buttons
+	add("buttons.a", ob.A, nb.A)
+	add("buttons.b", ob.B, nb.B)
+	add("buttons.x", ob.X, nb.X)
+	add("buttons.y", ob.Y, nb.Y)
+	add("buttons.lb", ob.LB, nb.LB)
+	add("buttons.rb", ob.RB, nb.RB)
+	add("buttons.back", ob.Back, nb.Back)
+	add("buttons.start", ob.Start, nb.Start)
+	add("buttons.guide", ob.Guide, nb.Guide)
+	add("buttons.touchpad", ob.Touchpad, nb.Touchpad)
+	add("buttons.capture", ob.Capture, nb.Capture)
+	oe, ne := old.Extra, new_.Extra
+	add("extra.p1", oe.P1, ne.P1)
+	add("extra.p2", oe.P2, ne.P2)
+	add("extra.p3", oe.P3, ne.P3)
+	add("extra.p4", oe.P4, ne.P4)
+	add("extra.fn1", oe.Fn1, ne.Fn1)
+	add("extra.fn2", oe.Fn2, ne.Fn2)
+	od, nd := old.Dpad, new_.Dpad
+	add("dpad.up", od.Up, nd.Up)
+	add("dpad.down", od.Down, nd.Down)
+	add("dpad.left", od.Left, nd.Left)
+	add("dpad.right", od.Right, nd.Right)
+	add("sticks.left", old.Sticks.Left.Pressed, new_.Sticks.Left.Pressed)
+	add("sticks.right", old.Sticks.Right.Pressed, new_.Sticks.Right.Pressed)
+	return out
+}
+
+// LoadPressTrack reads a raw input capture (see gamepad.ReplayCapture) of one
+// controller and returns its presses, relative to the first press. dz is the
+// deadzone to replay with. Replay does not tell devices apart, so a capture
+// of several controllers yields their presses merged.
+func LoadPressTrack(rd io.Reader, dz float64) ([]Press, error) {
+	frames, err := LoadRecording(rd, dz)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		track []Press
+		prev  gamepad.GamepadState
+	)
+	for _, f := range frames {
+		for _, c := range PressEdges(prev, f.State) {
+			track = append(track, Press{At: f.At, Control: c})
+		}
+		prev = f.State
+	}
+	return track, nil
+}
+
+// Comparison matches the live player's presses against a reference track.
+// An attempt starts at the first live press, which is aligned with the
+// track's first press, and ends once the whole track has been played back
+// (plus the window); the next press starts a new attempt. A live press is
+// matched with the closest unmatched reference press of the same control
+// within the window; if they lie further apart than the tolerance, it is
+// early or late. During an attempt only the player who started it is
+// compared. Not safe for concurrent use: the Broadcaster serializes it.
+type Comparison struct {
+	track     []Press
+	window    int64 // ms
+	tolerance int64 // ms
+
+	attempt     int
+	active      bool
+	playerIndex int    // the attempt's player
+	start       int64  // Unix ms of track position 0
+	matched     []bool // per track press, this attempt
+	checked     int    // track presses before this index are matched or reported missed
+}
+
+// NewComparison returns a comparison against track (as returned by
+// LoadPressTrack) with the given matching window and tolerance.
+func NewComparison(track []Press, window, tolerance time.Duration) *Comparison {
+	return &Comparison{
+		track:     track,
+		window:    window.Milliseconds(),
+		tolerance: tolerance.Milliseconds(),
+		matched:   make([]bool, len(track)),
+	}
+}
+
+// live processes a state change of a player at now (Unix ms) and returns the
+// resulting diffs.
+func (c *Comparison) live(old, new_ gamepad.GamepadState, now int64) []InputDiff {
+	diffs := c.tick(now)
+	presses := PressEdges(old, new_)
+	if len(presses) == 0 || (c.active && new_.PlayerIndex != c.playerIndex) {
+		return diffs
+	}
+	if !c.active {
+		c.attempt++
+		c.active = true
+		c.playerIndex = new_.PlayerIndex
+		c.start = now
+		c.checked = 0
+		clear(c.matched)
+	}
+	pos := now - c.start
+	for _, control := range presses {
+		best := -1
+		for i := c.checked; i < len(c.track) && c.track[i].At <= pos+c.window; i++ {
+			p := c.track[i]
+			if c.matched[i] || p.Control != control || abs(pos-p.At) > c.window {
+				continue
+			}
+			if best < 0 || abs(pos-p.At) < abs(pos-c.track[best].At) {
+				best = i
+			}
+		}
+		if best < 0 {
+			diffs = append(diffs, c.diff(control, DiffExtra, pos, 0))
+			continue
+		}
+		c.matched[best] = true
+		offset := pos - c.track[best].At
+		switch {
+		case offset < -c.tolerance:
+			diffs = append(diffs, c.diff(control, DiffEarly, c.track[best].At, offset))
+		case offset > c.tolerance:
+			diffs = append(diffs, c.diff(control, DiffLate, c.track[best].At, offset))
+		}
+	}
+	return diffs
+}
+
+// tick reports the reference presses that can no longer be matched at now
+// (Unix ms) as missed, and ends the attempt after the last one.
+func (c *Comparison) tick(now int64) []InputDiff {
+	if !c.active {
+		return nil
+	}
+	var diffs []InputDiff
+	pos := now - c.start
+	for ; c.checked < len(c.track) && c.track[c.checked].At+c.window < pos; c.checked++ {
+		if !c.matched[c.checked] {
+			p := c.track[c.checked]
+			diffs = append(diffs, c.diff(p.Control, DiffMissed, p.At, 0))
+		}
+	}
+	if c.checked == len(c.track) {
+		c.active = false
+	}
+	return diffs
+}
+
+// diff builds an InputDiff of the current attempt.
+func (c *Comparison) diff(control, kind string, at, offset int64) InputDiff {
+	return InputDiff{Attempt: c.attempt, Control: control, Kind: kind, AtMs: at, OffsetMs: offset, playerIndex: c.playerIndex}
+}
+
+// SetComparison compares the player's presses against c's reference track and
+// sends the differences to the player's viewers as "input_diff" messages.
+// nil (the default) disables it. Call before Run.
+func (b *Broadcaster) SetComparison(c *Comparison) {
+	b.mu.Lock()
+	b.comparison = c
+	b.mu.Unlock()
+}
+
+// compareLocked feeds state to the comparison, against its player's previous
+// state. b.mu must be held, and state not yet tracked.
+func (b *Broadcaster) compareLocked(state gamepad.GamepadState, now int64) []InputDiff {
+	if b.comparison == nil {
+		return nil
+	}
+	var old gamepad.GamepadState
+	if ps, ok := b.players[state.PlayerIndex]; ok {
+		old = ps.last
+	}
+	return b.comparison.live(old, state, now)
+}
+
+// broadcastDiffs marshals and broadcasts input diffs to their player's viewers.
+func (b *Broadcaster) broadcastDiffs(diffs []InputDiff) {
+	for i := range diffs {
+		if data, ok := marshalOrLog("input diff message", NewInputDiffMessage(diffs[i].playerIndex, &diffs[i])); ok {
+			b.hub.BroadcastToPlayer(data, diffs[i].playerIndex)
+		}
+	}
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,77 @@
+package hub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestLoadPressTrack verifies that a capture becomes its presses, relative to
+// the first press.
+func TestLoadPressTrack(t *testing.T) {
+	capture := strings.Join([]string{
+		`{"t":0,"event":"connect","source":"xinput","device":0,"vid":1118,"pid":654}`,
+		`{"t":100000,"event":"input","source":"xinput","device":0,"data":"001000000000000000000000"}`, // A
+		`{"t":150000,"event":"input","source":"xinput","device":0,"data":"000000000000000000000000"}`,
+		`{"t":400000,"event":"input","source":"xinput","device":0,"data":"002000000000000000000000"}`, // B
+		`{"t":700000,"event":"input","source":"xinput","device":0,"data":"011000000000000000000000"}`, // A + dpad up, B released
+	}, "\n")
+	got, err := LoadPressTrack(strings.NewReader(capture), 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Press{{0, "buttons.a"}, {300, "buttons.b"}, {600, "buttons.a"}, {600, "dpad.up"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadPressTrack() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadPressTrack(strings.NewReader(`{"t":0,"event":"connect","source":"xinput","device":0}`), 0.05); err == nil {
+		t.Error("LoadPressTrack() of a capture without presses: got nil error")
+	}
+}
+
+// TestComparison verifies matching within the window, early/late beyond the
+// tolerance, extra and missed presses, and the start of the next attempt.
+func TestComparison(t *testing.T) {
+	track := []Press{{0, "buttons.a"}, {300, "buttons.b"}, {600, "dpad.up"}, {900, "buttons.a"}}
+	c := NewComparison(track, 100*time.Millisecond, 20*time.Millisecond)
+
+	var idle, a, b, x gamepad.GamepadState
+	idle.PlayerIndex = 1
+	a, b, x = idle, idle, idle
+	a.Buttons.A, b.Buttons.B, x.Buttons.X = true, true, true
+
+	const t0 = 10000
+	var got []InputDiff
+	got = append(got, c.live(idle, a, t0)...)      // starts attempt 1, on time
+	got = append(got, c.live(a, b, t0+350)...)     // B 50 ms late
+	got = append(got, c.live(b, x, t0+450)...)     // X: no reference press
+	got = append(got, c.tick(t0+701)...)           // dpad up missed
+	got = append(got, c.live(x, a, t0+885)...)     // A 15 ms early: within the tolerance
+	got = append(got, c.tick(t0+1001)...)          // track done
+	got = append(got, c.live(idle, a, t0+5000)...) // attempt 2
+	want := []InputDiff{
+		{Attempt: 1, Control: "buttons.b", Kind: DiffLate, AtMs: 300, OffsetMs: 50, playerIndex: 1},
+		{Attempt: 1, Control: "buttons.x", Kind: DiffExtra, AtMs: 450, playerIndex: 1},
+		{Attempt: 1, Control: "dpad.up", Kind: DiffMissed, AtMs: 600, playerIndex: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffs =\n%+v\nwant\n%+v", got, want)
+	}
+	if c.attempt != 2 || !c.active {
+		t.Errorf("after the next press: attempt %d active %v, want attempt 2 active", c.attempt, c.active)
+	}
+
+	// Another player's presses are ignored during the attempt.
+	other := a
+	other.PlayerIndex = 2
+	if diffs := c.live(idle, other, t0+5300); len(diffs) != 0 {
+		t.Errorf("player 2 during player 1's attempt: diffs %+v, want none", diffs)
+	}
+	if diffs := c.live(idle, b, t0+5290); len(diffs) != 0 {
+		t.Errorf("B 10 ms early in attempt 2: diffs %+v, want none", diffs)
+	}
+}
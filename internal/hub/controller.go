@@ -0,0 +1,30 @@
+package hub
+
+import "github.com/soar/inputview/pkg/gamepad"
+
+// DeviceEvent is the payload of the "controller_connected",
+// "controller_disconnected", "controller_switched", and "controller_drift"
+// messages.
+type DeviceEvent struct {
+	Reason     string                  `json:"reason,omitempty"`   // gamepad.Disconnect* / gamepad.Switch*
+	Controller gamepad.ControllerInfo  `json:"controller"`         // for "controller_switched", the new active controller
+	Previous   *gamepad.ControllerInfo `json:"previous,omitempty"` // "controller_switched" only: the formerly active controller
+	Drift      *gamepad.DriftReport    `json:"drift,omitempty"`    // "controller_drift" only: the controller's new drift report
+}
+
+// SetControllerEvents sets the channel of controller events
+// (gamepad.Reader.Events) to forward as "controller_connected",
+// "controller_disconnected", "controller_switched", and "controller_drift"
+// messages. Without it no such messages are sent.
+// Call before Run.
+func (b *Broadcaster) SetControllerEvents(events <-chan gamepad.ControllerEvent) {
+	b.controllerEvents = events
+}
+
+// broadcastController marshals and broadcasts a controller event to every
+// client, whichever player it streams, so any overlay can show it.
+func (b *Broadcaster) broadcastController(ev gamepad.ControllerEvent) {
+	if data, ok := marshalOrLog("controller message", NewControllerMessage(ev)); ok {
+		b.hub.BroadcastAll(data)
+	}
+}
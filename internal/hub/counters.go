@@ -0,0 +1,117 @@
+package hub
+
+import "github.com/soar/inputview/pkg/gamepad"
+
+// ButtonCounts holds press counts for the buttons in gamepad.ButtonState.
+type ButtonCounts struct {
+	A        int64 `json:"a"`
+	B        int64 `json:"b"`
+	X        int64 `json:"x"`
+	Y        int64 `json:"y"`
+	LB       int64 `json:"lb"`
+	RB       int64 `json:"rb"`
+	Back     int64 `json:"back"`
+	Start    int64 `json:"start"`
+	Guide    int64 `json:"guide"`
+	Touchpad int64 `json:"touchpad"`
+	Capture  int64 `json:"capture"`
+}
+
+// ExtraCounts holds press counts for the buttons in gamepad.ExtraButtonState.
+type ExtraCounts struct {
+	P1  int64 `json:"p1"`
+	P2  int64 `json:"p2"`
+	P3  int64 `json:"p3"`
+	P4  int64 `json:"p4"`
+	Fn1 int64 `json:"fn1"`
+	Fn2 int64 `json:"fn2"`
+}
+
+// DpadCounts holds press counts for the directions in gamepad.DpadState.
+type DpadCounts struct {
+	Up    int64 `json:"up"`
+	Down  int64 `json:"down"`
+	Left  int64 `json:"left"`
+	Right int64 `json:"right"`
+}
+
+// StickCounts holds stick click (L3/R3) counts.
+type StickCounts struct {
+	Left  int64 `json:"left"`
+	Right int64 `json:"right"`
+}
+
+// PressCounters counts presses (released → pressed transitions) of every
+// digital control of one player since the server started. Triggers are
+// analog and not counted.
+type PressCounters struct {
+	Since   int64        `json:"since"` // Unix timestamp in milliseconds when counting started
+	Buttons ButtonCounts `json:"buttons"`
+	Extra   ExtraCounts  `json:"extra"`
+	Dpad    DpadCounts   `json:"dpad"`
+	Sticks  StickCounts  `json:"sticks"`
+}
+
+// pressed reports a released → pressed edge as 1.
+func pressed(old, new_ bool) int64 {
+	if !old && new_ {
+		return 1
+	}
+	return 0
+}
+
+// count adds the presses between two consecutive states of the same player.
+func (p *PressCounters) count(old, new_ gamepad.GamepadState) {
+	ob, nb := old.Buttons, new_.Buttons
+	p.Buttons.A += pressed(ob.A, nb.A)
+	p.Buttons.B += pressed(ob.B, nb.B)
+	p.Buttons.X += pressed(ob.X, nb.X)
+	p.Buttons.Y += pressed(ob.Y, nb.Y)
+	p.Buttons.LB += pressed(ob.LB, nb.LB)
+	p.Buttons.RB += pressed(ob.RB, nb.RB)
+	p.Buttons.Back += pressed(ob.Back, nb.Back)
+	p.Buttons.Start += pressed(ob.Start, nb.Start)
+	p.Buttons.Guide += pressed(ob.Guide, nb.Guide)
+	p.Buttons.Touchpad += pressed(ob.Touchpad, nb.Touchpad)
+	p.Buttons.Capture += pressed(ob.Capture, nb.Capture)
+
+	oe, ne := old.Extra, new_.Extra
+	p.Extra.P1 += pressed(oe.P1, ne.P1)
+	p.Extra.P2 += pressed(oe.P2, ne.P2)
+	p.Extra.P3 += pressed(oe.P3, ne.P3)
+	p.Extra.P4 += pressed(oe.P4, ne.P4)
+	p.Extra.Fn1 += pressed(oe.Fn1, ne.Fn1)
+	p.Extra.Fn2 += pressed(oe.Fn2, ne.Fn2)
+
+	od, nd := old.Dpad, new_.Dpad
+	p.Dpad.Up += pressed(od.Up, nd.Up)
+	p.Dpad.Down += pressed(od.Down, nd.Down)
+	p.Dpad.Left += pressed(od.Left, nd.Left)
+	p.Dpad.Right += pressed(od.Right, nd.Right)
+
+	p.Sticks.Left += pressed(old.Sticks.Left.Pressed, new_.Sticks.Left.Pressed)
+	p.Sticks.Right += pressed(old.Sticks.Right.Pressed, new_.Sticks.Right.Pressed)
+}
+
+// total returns the number of presses of all controls.
+func (p *PressCounters) total() int64 {
+	b, e, d, s := p.Buttons, p.Extra, p.Dpad, p.Sticks
+	return b.A + b.B + b.X + b.Y + b.LB + b.RB + b.Back + b.Start + b.Guide + b.Touchpad + b.Capture +
+		e.P1 + e.P2 + e.P3 + e.P4 + e.Fn1 + e.Fn2 +
+		d.Up + d.Down + d.Left + d.Right +
+		s.Left + s.Right
+}
+
+// playerStats is the Broadcaster's per-player tracking state. last is the
+// player's previous state, so switching the active player between two
+// states does not count the other player's buttons as presses or changes.
+// travel and recent feed SessionStats, heatmaps Heatmaps.
+type playerStats struct {
+	counts   PressCounters
+	changed  LastChanged
+	last     gamepad.GamepadState
+	lastAt   int64 // Unix ms of last
+	travel   StickTravel
+	recent   []int64      // Unix ms of each press in the last apmWindow, oldest first
+	heatmaps *[2]heatGrid // left, right; nil until the first connected state has been held
+}
@@ -0,0 +1,59 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+func TestPressCounters(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	if got := b.Counters(1); got != nil {
+		t.Fatalf("Counters(1) before any state = %+v, want nil", got)
+	}
+
+	p1 := fixtureXboxState()
+	p2 := gamepad.GamepadState{Connected: true, ControllerType: "playstation", PlayerIndex: 2}
+	press := func(s gamepad.GamepadState, a, up, l3 bool) gamepad.GamepadState {
+		s.Buttons.A, s.Dpad.Up, s.Sticks.Left.Pressed = a, up, l3
+		return s
+	}
+	paddle := func(s gamepad.GamepadState) gamepad.GamepadState {
+		s.Extra.P1 = true
+		return s
+	}
+	steps := []gamepad.GamepadState{
+		press(p1, true, false, false),        // A down: 1
+		press(p1, true, true, false),         // A held, up down: 1
+		press(p1, false, false, false),       // release both
+		press(p2, true, false, false),        // player 2 A down: counted for player 2 only
+		press(p1, true, false, true),         // player 1 A down again: 2, L3: 1
+		press(p1, true, false, true),         // held: no change
+		paddle(press(p1, true, false, true)), // P1 paddle down: 1
+	}
+	for _, s := range steps {
+		b.mu.Lock()
+		b.trackLocked(s, 1000)
+		b.mu.Unlock()
+	}
+
+	c1 := b.Counters(1)
+	if c1 == nil {
+		t.Fatal("Counters(1) = nil")
+	}
+	if c1.Buttons.A != 2 || c1.Dpad.Up != 1 || c1.Sticks.Left != 1 || c1.Extra.P1 != 1 || c1.Buttons.B != 0 {
+		t.Errorf("player 1 counters = %+v, want A=2 up=1 leftStick=1 p1=1", *c1)
+	}
+	if c1.Since != b.started {
+		t.Errorf("Since = %d, want %d", c1.Since, b.started)
+	}
+	if c2 := b.Counters(2); c2 == nil || c2.Buttons.A != 1 {
+		t.Errorf("player 2 counters = %+v, want A=1", c2)
+	}
+
+	// Counters returns a copy.
+	c1.Buttons.A = 100
+	if got := b.Counters(1).Buttons.A; got != 2 {
+		t.Errorf("Counters(1).Buttons.A after mutating a copy = %d, want 2", got)
+	}
+}
@@ -0,0 +1,99 @@
+package hub
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+const (
+	// demoInterval is how often PlayDemo injects a state (about 60 Hz).
+	demoInterval = 16 * time.Millisecond
+	// demoStep is how long each step of the button sequence lasts; the
+	// button is held for the first demoHold of it.
+	demoStep = 400 * time.Millisecond
+	demoHold = 300 * time.Millisecond
+	// demoLeftPeriod, demoRightPeriod, and demoTriggerPeriod are the times
+	// the left stick, the right stick, and the triggers take for one lap.
+	demoLeftPeriod    = 4 * time.Second
+	demoRightPeriod   = 3 * time.Second
+	demoTriggerPeriod = 2 * time.Second
+	// demoRadius is how far the sticks circle from the centre.
+	demoRadius = 0.9
+)
+
+// demoSequence presses the demo controller's buttons one after another, a
+// demoStep each, then starts over.
+var demoSequence = []func(s *gamepad.GamepadState){
+	func(s *gamepad.GamepadState) { s.Buttons.A = true },
+	func(s *gamepad.GamepadState) { s.Buttons.B = true },
+	func(s *gamepad.GamepadState) { s.Buttons.X = true },
+	func(s *gamepad.GamepadState) { s.Buttons.Y = true },
+	func(s *gamepad.GamepadState) { s.Dpad.Up = true },
+	func(s *gamepad.GamepadState) { s.Dpad.Right = true },
+	func(s *gamepad.GamepadState) { s.Dpad.Down = true },
+	func(s *gamepad.GamepadState) { s.Dpad.Left = true },
+	func(s *gamepad.GamepadState) { s.Buttons.LB = true },
+	func(s *gamepad.GamepadState) { s.Buttons.RB = true },
+	func(s *gamepad.GamepadState) { s.Buttons.Back = true },
+	func(s *gamepad.GamepadState) { s.Buttons.Start = true },
+	func(s *gamepad.GamepadState) { s.Buttons.Guide = true },
+	func(s *gamepad.GamepadState) { s.Sticks.Left.Pressed = true },
+	func(s *gamepad.GamepadState) { s.Sticks.Right.Pressed = true },
+}
+
+// DemoState returns the state of the demo controller (see PlayDemo) at t
+// after it started: an Xbox controller as player 1 whose left stick circles
+// clockwise and right stick counter-clockwise from the top (Y is up), whose
+// triggers sweep in turn, and whose buttons are pressed one at a time. The
+// same t always gives the same state.
+func DemoState(t time.Duration) gamepad.GamepadState {
+	s := gamepad.GamepadState{
+		Connected:      true,
+		ControllerType: "xbox",
+		Name:           "Demo Controller",
+		PlayerIndex:    1,
+		DeviceID:       "demo",
+		Capabilities:   gamepad.Capabilities{NumButtons: 11, NumAxes: 6},
+	}
+	left := lap(t, demoLeftPeriod)
+	s.Sticks.Left.Position = gamepad.Vector{X: round3(demoRadius * math.Sin(left)), Y: round3(demoRadius * math.Cos(left))}
+	right := lap(t, demoRightPeriod)
+	s.Sticks.Right.Position = gamepad.Vector{X: round3(-demoRadius * math.Sin(right)), Y: round3(demoRadius * math.Cos(right))}
+	trigger := lap(t, demoTriggerPeriod)
+	s.Triggers.LT.Value = round3((1 - math.Cos(trigger)) / 2)
+	s.Triggers.RT.Value = round3((1 + math.Cos(trigger)) / 2)
+	if t%demoStep < demoHold {
+		demoSequence[int(t/demoStep)%len(demoSequence)](&s)
+	}
+	return s
+}
+
+// lap returns the angle, in radians, reached at t by a lap of period.
+func lap(t, period time.Duration) float64 {
+	return 2 * math.Pi * float64(t%period) / float64(period)
+}
+
+// round3 rounds v to three decimals, so the demo sends no sub-noise changes.
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// PlayDemo feeds the demo controller's state (see DemoState) to inject every
+// demoInterval until ctx is done, so overlays and themes can be developed and
+// screenshotted without a controller attached.
+func PlayDemo(ctx context.Context, inject func(gamepad.GamepadState)) {
+	ticker := time.NewTicker(demoInterval)
+	defer ticker.Stop()
+	start := time.Now()
+	for {
+		inject(DemoState(time.Since(start)))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
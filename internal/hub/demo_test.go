@@ -0,0 +1,81 @@
+package hub
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestDemoState verifies the demo sticks circle at demoRadius from the top,
+// the triggers sweep in opposite phase, and the buttons are pressed one at a
+// time in sequence, released between steps.
+func TestDemoState(t *testing.T) {
+	s := DemoState(0)
+	if !s.Connected || s.PlayerIndex != 1 || s.ControllerType != "xbox" {
+		t.Fatalf("DemoState(0) = %+v, want a connected xbox as player 1", s)
+	}
+	if s.Sticks.Left.Position != (gamepad.Vector{Y: demoRadius}) || s.Triggers.LT.Value != 0 || s.Triggers.RT.Value != 1 {
+		t.Errorf("DemoState(0) sticks %+v, triggers %+v; want the left stick up, LT released, RT pulled", s.Sticks, s.Triggers)
+	}
+	if !s.Buttons.A || s.Buttons.B {
+		t.Errorf("DemoState(0) buttons = %+v, want only A", s.Buttons)
+	}
+
+	quarter := DemoState(demoLeftPeriod / 4)
+	if p := quarter.Sticks.Left.Position; p.X != demoRadius || p.Y != 0 {
+		t.Errorf("left stick after a quarter lap = %+v, want {%v 0}", p, demoRadius)
+	}
+	half := DemoState(demoTriggerPeriod / 2)
+	if half.Triggers.LT.Value != 1 || half.Triggers.RT.Value != 0 {
+		t.Errorf("triggers after half a sweep = %+v, want LT pulled, RT released", half.Triggers)
+	}
+	for _, d := range []time.Duration{time.Second / 3, 1234 * time.Millisecond} {
+		p := DemoState(d).Sticks.Right.Position
+		if r := math.Hypot(p.X, p.Y); math.Abs(r-demoRadius) > 0.002 {
+			t.Errorf("right stick at %v = %+v, radius %v; want %v", d, p, r, demoRadius)
+		}
+	}
+
+	if s := DemoState(demoHold); s.Buttons.A {
+		t.Error("A still held after demoHold")
+	}
+	if s := DemoState(demoStep); !s.Buttons.B || s.Buttons.A {
+		t.Errorf("DemoState(demoStep) buttons = %+v, want only B", s.Buttons)
+	}
+	if s := DemoState(4 * demoStep); !s.Dpad.Up {
+		t.Errorf("DemoState(4 steps) dpad = %+v, want up", s.Dpad)
+	}
+	if s := DemoState(time.Duration(len(demoSequence)) * demoStep); !s.Buttons.A {
+		t.Error("sequence did not start over with A")
+	}
+}
+
+// TestPlayDemo verifies the demo injects states until its context is
+// cancelled.
+func TestPlayDemo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	done := make(chan struct{})
+	go func() {
+		PlayDemo(ctx, func(s gamepad.GamepadState) {
+			if !s.Connected {
+				t.Error("injected a disconnected state")
+			}
+			if n++; n == 3 {
+				cancel()
+			}
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("demo did not stop when its context was cancelled")
+	}
+	if n < 3 {
+		t.Errorf("demo injected %d states, want at least 3", n)
+	}
+}
@@ -0,0 +1,129 @@
+package hub
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Fields a client can subscribe to (see Client.SetFields). Each is a group of
+// the deltas; the other groups (connection, device info, battery, wheel,
+// flight stick, raw) always reach every client.
+const (
+	FieldButtons  = "buttons"  // buttons, paddles (extra), and stick clicks
+	FieldSticks   = "sticks"   // stick positions and clicks
+	FieldTriggers = "triggers" // trigger values
+	FieldDpad     = "dpad"     // the directional pad
+	FieldMotion   = "motion"   // gyro and accelerometer (with --motion)
+)
+
+// Fields lists the field names, in fieldSet bit order.
+var Fields = []string{FieldButtons, FieldSticks, FieldTriggers, FieldDpad, FieldMotion}
+
+// fieldSet is a set of Fields, bit i for Fields[i]. 0 = all.
+type fieldSet uint32
+
+// has reports whether f includes the field name (one of Fields).
+func (f fieldSet) has(name string) bool {
+	return f == 0 || f&(1<<slices.Index(Fields, name)) != 0
+}
+
+// ParseFields parses a comma-separated list of field names (see Fields), as
+// sent with set_fields or /ws?fields=. Duplicates are kept once; "" = all.
+func ParseFields(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var names []string
+	for name := range strings.SplitSeq(list, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(Fields, name) {
+			return nil, fmt.Errorf("unknown field %q (available: %v)", name, Fields)
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// SetFields limits the deltas the client gets to the groups it renders (see
+// Fields; none = all, the default): the changes of other groups are left out,
+// and a delta left with nothing is not sent. Fulls are unchanged. Unknown names
+// are ignored. Safe to call from any goroutine.
+func (c *Client) SetFields(names []string) {
+	var f fieldSet
+	for _, name := range names {
+		if i := slices.Index(Fields, name); i >= 0 {
+			f |= 1 << i
+		}
+	}
+	c.fields.Store(uint32(f))
+}
+
+// fieldSet returns the fields the client subscribed to (see SetFields).
+func (c *Client) fieldSet() fieldSet { return fieldSet(c.fields.Load()) }
+
+// filter removes from d, the changes from prev to next, the groups not in f.
+// The sticks stay for a button subscriber when a stick click changed.
+func (f fieldSet) filter(d *gamepad.DeltaChanges, prev, next gamepad.GamepadState) {
+	if !f.has(FieldButtons) {
+		d.Buttons, d.Extra = nil, nil
+	}
+	if !f.has(FieldSticks) && !(f.has(FieldButtons) && clicked(prev, next)) {
+		d.Sticks = nil
+	}
+	if !f.has(FieldTriggers) {
+		d.Triggers = nil
+	}
+	if !f.has(FieldDpad) {
+		d.Dpad = nil
+	}
+	if !f.has(FieldMotion) {
+		d.Motion = nil
+	}
+}
+
+// covers reports whether every group of d is in f.
+func (f fieldSet) covers(d *gamepad.DeltaChanges) bool {
+	return d == nil ||
+		(f.has(FieldButtons) || d.Buttons == nil && d.Extra == nil) &&
+			(f.has(FieldSticks) || d.Sticks == nil) &&
+			(f.has(FieldTriggers) || d.Triggers == nil) &&
+			(f.has(FieldDpad) || d.Dpad == nil) &&
+			(f.has(FieldMotion) || d.Motion == nil)
+}
+
+// clicked reports whether a stick click changed from prev to next.
+func clicked(prev, next gamepad.GamepadState) bool {
+	return prev.Sticks.Left.Pressed != next.Sticks.Left.Pressed || prev.Sticks.Right.Pressed != next.Sticks.Right.Pressed
+}
+
+// takesAsIs reports whether the client gets the broadcast delta changes d
+// unchanged, given its analog mode and fields.
+func (c *Client) takesAsIs(d *gamepad.DeltaChanges) bool {
+	if c.AnalogMode() != AnalogInline && hasAnalog(d) {
+		return false
+	}
+	return c.fieldSet().covers(d)
+}
+
+// changes returns the changes from prev to next that the client gets in its
+// deltas (see SetAnalogMode and SetFields), nil if none.
+func (c *Client) changes(prev, next gamepad.GamepadState) *gamepad.DeltaChanges {
+	d := gamepad.ComputeDelta(prev, next)
+	if c.AnalogMode() != AnalogInline {
+		if d = eventChanges(prev, next); d == nil {
+			return nil
+		}
+	}
+	if f := c.fieldSet(); f != 0 {
+		f.filter(d, prev, next)
+	}
+	if d.IsEmpty() {
+		return nil
+	}
+	return d
+}
@@ -0,0 +1,76 @@
+package hub
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestParseFields verifies field lists, duplicates, and unknown names.
+func TestParseFields(t *testing.T) {
+	for _, tt := range []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"buttons", []string{"buttons"}, false},
+		{"dpad, buttons,dpad", []string{"dpad", "buttons"}, false},
+		{"buttons,", nil, true},
+		{"keys", nil, true},
+	} {
+		got, err := ParseFields(tt.list)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseFields(%q) = %v, %v; want %v, error %v", tt.list, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// TestFieldSubscription verifies that a button-only client gets no deltas for
+// stick moves, deltas with only the buttons otherwise, and the sticks for a
+// stick click.
+func TestFieldSubscription(t *testing.T) {
+	h := NewHub()
+	go h.Run(t.Context())
+	c, mc, recv := loopbackClient(t, h)
+	b := NewBroadcaster(h, nil, nil)
+
+	b.SendInitialState(c)
+	if full := recv(); full.Type != "full" {
+		t.Fatalf("first message = %q, want full", full.Type)
+	}
+	state := fixtureXboxState()
+	pushState(b, state)
+	if msg := recv(); msg.Type != "delta" {
+		t.Fatalf("after connecting got %q, want delta", msg.Type)
+	}
+
+	c.SetFields([]string{FieldButtons})
+	state.Sticks.Left.Position.X = 0.5
+	state.Dpad.Up = true
+	pushState(b, state)
+	state.Buttons.A = true
+	state.Triggers.LT.Value = 1
+	pushState(b, state)
+	if msg := recv(); msg.Type != "delta" || msg.Changes.Buttons == nil || !msg.Changes.Buttons.A ||
+		msg.Changes.Sticks != nil || msg.Changes.Triggers != nil || msg.Changes.Dpad != nil {
+		t.Fatalf("got %+v, want a delta with only the buttons", msg)
+	}
+	state.Sticks.Left.Pressed = true
+	pushState(b, state)
+	if msg := recv(); msg.Changes.Sticks == nil || !msg.Changes.Sticks.Left.Pressed {
+		t.Fatalf("got %+v, want the sticks for a stick click", msg)
+	}
+	select {
+	case extra := <-mc.msgs:
+		t.Errorf("unexpected %q", extra.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.SetFields(nil)
+	state.Dpad.Up = false
+	pushState(b, state)
+	if msg := recv(); msg.Changes.Dpad == nil {
+		t.Errorf("with all fields got %+v, want the dpad", msg)
+	}
+}
@@ -0,0 +1,556 @@
+package hub
+
+import (
+	"encoding/json"
+
+	"github.com/soar/inputview/internal/input"
+	"github.com/soar/inputview/internal/marker"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// ProtocolVersion is the version of the WebSocket message protocol described
+// by WSMessage and ClientMessage. It is bumped on incompatible changes; new
+// optional fields and message types do not change it.
+const ProtocolVersion = 1
+
+// fixtureTimestamp replaces time.Now() in fixtures so the output is stable.
+const fixtureTimestamp = 1767225600000 // 2026-01-01T00:00:00Z
+
+// fixtureMono replaces the monotonic server clock in fixtures: 10 minutes
+// after start, in microseconds.
+const fixtureMono = 600_000_000
+
+// Fixture directions.
+const (
+	FixtureServer = "server" // server → client (WSMessage)
+	FixtureClient = "client" // client → server (ClientMessage)
+)
+
+// Fixture is a canonical example of a protocol exchange: one or more messages
+// in the order they appear on the wire, all in the same direction.
+type Fixture struct {
+	Name        string `json:"name"`
+	Direction   string `json:"direction"` // FixtureServer or FixtureClient
+	Description string `json:"description"`
+	Messages    []any  `json:"-"`
+}
+
+// ProtocolFixtures returns the canonical example messages for the current
+// protocol version. They are built with the same constructors the server
+// uses (timestamps fixed), so encoding them yields exactly what a client sees.
+func ProtocolFixtures() []Fixture {
+	xbox := fixtureXboxState()
+	pressed := xbox
+	pressed.Buttons.A = true
+	pressed.Dpad.Up = true
+	moved := pressed
+	moved.Sticks.Left.Position = gamepad.Vector{X: 0.5, Y: -0.25}
+	moved.Triggers.RT.Value = 0.75
+	unplugged := gamepad.GamepadState{PlayerIndex: 1}
+	paddled := xbox
+	paddled.Extra.P1 = true
+	stats := NewFullMessage(8, &pressed)
+	stats.Counters = &PressCounters{
+		Since:   fixtureTimestamp - 600000,
+		Buttons: ButtonCounts{A: 1432, B: 87, Start: 3},
+		Dpad:    DpadCounts{Up: 250},
+		Sticks:  StickCounts{Left: 12},
+	}
+	stats.LastChanged = &LastChanged{
+		Buttons:  ButtonTimes{A: fixtureTimestamp - 120, B: fixtureTimestamp - 4500, Start: fixtureTimestamp - 300000},
+		Dpad:     DpadTimes{Up: fixtureTimestamp - 80},
+		Sticks:   SticksTimes{Left: StickTimes{Position: fixtureTimestamp - 2000, Pressed: fixtureTimestamp - 9000}},
+		Triggers: TriggersTimes{RT: fixtureTimestamp - 1500},
+	}
+	// A reply to a time_sync read 0.15 ms before it was built.
+	sync := NewTimeSyncMessage(8_123_456, fixtureMono-150)
+	sync.Sync.ServerSend = fixtureMono
+
+	// Player 2, as seen by a client that selected it.
+	ps := gamepad.GamepadState{
+		Connected:      true,
+		ControllerType: "playstation",
+		Name:           "DualSense Wireless Controller (VID_054C&PID_0CE6)",
+		PlayerIndex:    2,
+		DeviceID:       "hid-1a03f7",
+		Serial:         "a0ab51c0ffee",
+		ProductVersion: 0x0100,
+		Capabilities: gamepad.Capabilities{
+			HasRumble: true, HasGyro: true, HasTouchpad: true, BatteryReadable: true,
+			NumButtons: 15, NumAxes: 6,
+		},
+	}
+	psPressed := ps
+	psPressed.Buttons.Touchpad = true
+	// With --motion: lying flat, then tilted toward the player while turning.
+	psFlat := ps
+	psFlat.Motion = gamepad.MotionState{Accel: gamepad.Vector3{Y: 9.806}}
+	psTilted := psFlat
+	psTilted.Motion = gamepad.MotionState{Gyro: gamepad.Vector3{X: 0.75, Y: -0.125}, Accel: gamepad.Vector3{Y: 8.5, Z: 4.875}}
+	// Player 3: a Logitech G29 turned a quarter left on the throttle, then
+	// braking into second gear.
+	wheel := gamepad.GamepadState{
+		Connected:      true,
+		ControllerType: "wheel",
+		Name:           "Logitech G29 Driving Force Racing Wheel (VID_046D&PID_C24F)",
+		PlayerIndex:    3,
+		DeviceID:       "hid-2b61c0",
+		Capabilities:   gamepad.Capabilities{NumButtons: 25, NumAxes: 4},
+		Wheel:          gamepad.WheelState{Angle: -0.25, Throttle: 0.875, Gear: 3},
+	}
+	braking := wheel
+	braking.Wheel = gamepad.WheelState{Angle: -0.125, Brake: 0.625, Clutch: 1, Gear: 2}
+	// Player 4: a Logitech Extreme 3D Pro rolling right at half thrust, then
+	// pulled back with the trigger (button 1) and the hat up.
+	stick := gamepad.GamepadState{
+		Connected:      true,
+		ControllerType: "flightstick",
+		Name:           "Logitech Extreme 3D Pro (VID_046D&PID_C215)",
+		PlayerIndex:    4,
+		DeviceID:       "hid-3c9e14",
+		Capabilities:   gamepad.Capabilities{NumButtons: 12, NumAxes: 4},
+		Flight:         gamepad.FlightState{Roll: 0.375, Thrust: 0.5},
+	}
+	firing := stick
+	firing.Flight.Pitch = -0.625
+	firing.Flight.Buttons[0] = 1
+	firing.Flight.Hats[0].Up = true
+	// Player 5: an unmapped DragonRise pad in raw mode at rest, then with
+	// button 3 (bit 2 of buttons[0]) held, the second axis up, and the hat
+	// right.
+	raw := gamepad.GamepadState{
+		Connected:      true,
+		ControllerType: "raw",
+		Name:           "USB Gamepad (VID_0079&PID_0006)",
+		PlayerIndex:    5,
+		DeviceID:       "hid-4d27a8",
+		Capabilities:   gamepad.Capabilities{NumButtons: 12, NumAxes: 5},
+		Raw:            gamepad.RawState{NumAxes: 5, NumButtons: 12, NumHats: 1},
+	}
+	rawPressed := raw
+	rawPressed.Raw.Axes[1] = -1
+	rawPressed.Raw.Buttons[0] = 1 << 2
+	rawPressed.Raw.Hats[0] = gamepad.RawHatRight
+	psInfo := gamepad.ControllerInfo{
+		PlayerIndex: 2, DeviceID: ps.DeviceID, Name: ps.Name, ControllerType: ps.ControllerType, Source: "hid",
+		VendorID: 0x054c, ProductID: 0x0ce6, Serial: ps.Serial, ProductVersion: ps.ProductVersion,
+		GUID: "030000004c050000e60c000000000000", Mapping: "builtin", Axes: 6, Buttons: 15, Hats: 1,
+		Deadzones: gamepad.Deadzones{Stick: 0.12, Trigger: gamepad.DefaultDeadzone, Mode: gamepad.DeadzoneScaled},
+	}
+	psActive := psInfo
+	psActive.Active = true
+	xboxInfo := gamepad.ControllerInfo{
+		PlayerIndex: 1, DeviceID: xbox.DeviceID, Name: xbox.Name, ControllerType: xbox.ControllerType, Source: "xinput",
+		VendorID: 0x045e, ProductID: 0x028e, Active: true, GUID: "030000005e0400008e02000000000000",
+		Mapping: "xinput", Axes: 6, Buttons: 11, Hats: 1,
+		Deadzones: gamepad.Deadzones{Stick: gamepad.DefaultDeadzone, Trigger: gamepad.DefaultDeadzone, Mode: gamepad.DeadzoneAxial},
+	}
+
+	km := input.KeyMouseState{
+		Keys:         map[uint16]bool{17: true, 30: true}, // W, A
+		MouseButtons: map[uint16]bool{1: true},            // left
+		MouseDX:      0.125,
+		MouseDY:      -0.5,
+	}
+	kmDelta := &input.KeyMouseDelta{
+		KeysDown:    []uint16{31}, // S
+		KeysUp:      []uint16{17}, // W
+		ButtonsDown: []uint16{2},  // right
+		MouseMove:   &input.MouseMoveData{X: 0.25, Y: 0},
+		WheelUp:     true,
+	}
+
+	return []Fixture{
+		{
+			Name:        "full",
+			Direction:   FixtureServer,
+			Description: "Complete state snapshot of a connected controller (sent on connect, every 5s, and after every 100 deltas).",
+			Messages:    []any{fixtured(NewFullMessage(1, &xbox))},
+		},
+		{
+			Name:        "full_stats",
+			Direction:   FixtureServer,
+			Description: "Snapshot with the player's press counters (released → pressed transitions since the server started) and per-control last-change timestamps (0 = unchanged); present in every full the server sends once the player produced a state.",
+			Messages:    []any{fixtured(stats)},
+		},
+		{
+			Name:        "full_disconnected",
+			Direction:   FixtureServer,
+			Description: "Snapshot while no controller is connected; all inputs are zero.",
+			Messages:    []any{fixtured(NewFullMessage(1, &unplugged))},
+		},
+		{
+			Name:        "delta_buttons",
+			Direction:   FixtureServer,
+			Description: "Delta after pressing A and dpad up: only the changed groups are present, each group in full.",
+			Messages:    []any{fixtured(NewDeltaMessage(2, gamepad.ComputeDelta(xbox, pressed)))},
+		},
+		{
+			Name:        "delta_analog",
+			Direction:   FixtureServer,
+			Description: "Delta after moving the left stick and pulling the right trigger.",
+			Messages:    []any{fixtured(NewDeltaMessage(3, gamepad.ComputeDelta(pressed, moved)))},
+		},
+		{
+			Name:        "analog_split",
+			Direction:   FixtureServer,
+			Description: "The delta_analog change as a client with ?analog=split sees it: no delta, since only analog inputs changed, and an analog message with both sticks and triggers (sent for every analog change, not limited by the update rate). A DualSense tilted with --motion adds motion.",
+			Messages: []any{
+				fixtured(NewAnalogMessage(3, newAnalogFrame(moved))),
+				fixtured(NewAnalogMessage(12, newAnalogFrame(psTilted))),
+			},
+		},
+		{
+			Name:        "session",
+			Direction:   FixtureServer,
+			Description: "A short stream: initial full, two deltas, a disconnect delta, and a periodic full. Applying each delta to the previous state yields the state in the next full.",
+			Messages: []any{
+				fixtured(NewFullMessage(1, &xbox)),
+				fixtured(NewDeltaMessage(2, gamepad.ComputeDelta(xbox, pressed))),
+				fixtured(NewDeltaMessage(3, gamepad.ComputeDelta(pressed, moved))),
+				fixtured(NewDeltaMessage(4, gamepad.ComputeDelta(moved, unplugged))),
+				fixtured(NewFullMessage(5, &unplugged)),
+			},
+		},
+		{
+			Name:        "multi_player",
+			Direction:   FixtureServer,
+			Description: "Server side of switching to player 2: confirmation, then player 2's full state and deltas (seq continues the shared counter).",
+			Messages: []any{
+				fixtured(NewPlayerSelectedMessage(2)),
+				fixtured(NewFullMessage(6, &ps)),
+				fixtured(NewDeltaMessage(7, gamepad.ComputeDelta(ps, psPressed))),
+			},
+		},
+		{
+			Name:        "motion",
+			Direction:   FixtureServer,
+			Description: "Motion sensors of player 2 with --motion: a full while the DualSense lies flat (gravity on +Y, in m/s²), then a delta as it is tilted toward the player (gyro in rad/s). Absent when --motion is off or the controller's sensors are not read.",
+			Messages: []any{
+				fixtured(NewFullMessage(8, &psFlat)),
+				fixtured(NewDeltaMessage(9, gamepad.ComputeDelta(psFlat, psTilted))),
+			},
+		},
+		{
+			Name:        "paddles",
+			Direction:   FixtureServer,
+			Description: "Delta after pressing the upper right back paddle (P1) of an Xbox Elite, then after releasing it: extra buttons are their own group, left out of fulls while none is pressed.",
+			Messages: []any{
+				fixtured(NewDeltaMessage(10, gamepad.ComputeDelta(xbox, paddled))),
+				fixtured(NewDeltaMessage(11, gamepad.ComputeDelta(paddled, xbox))),
+			},
+		},
+		{
+			Name:        "wheel",
+			Direction:   FixtureServer,
+			Description: "A steering wheel (player 3): a full while turning left on the throttle in third gear, then a delta as the player brakes and shifts down with the clutch in. Gamepads leave the wheel group out.",
+			Messages: []any{
+				fixtured(NewFullMessage(12, &wheel)),
+				fixtured(NewDeltaMessage(13, gamepad.ComputeDelta(wheel, braking))),
+			},
+		},
+		{
+			Name:        "flight_stick",
+			Direction:   FixtureServer,
+			Description: "A flight stick (player 4): a full while rolling right at half thrust, then a delta as it is pulled back with button 1 (bit 0 of buttons[0]) and the first hat up. Gamepads leave the flight group out.",
+			Messages: []any{
+				fixtured(NewFullMessage(14, &stick)),
+				fixtured(NewDeltaMessage(15, gamepad.ComputeDelta(stick, firing))),
+			},
+		},
+		{
+			Name:        "raw",
+			Direction:   FixtureServer,
+			Description: "An unmapped controller in raw mode (player 5): a full at rest, then a delta with button 3 (bit 2 of buttons[0]) held, axis 1 at -1, and hat 0 right (2). The gamepad groups stay zero; other controllers leave the raw group out.",
+			Messages: []any{
+				fixtured(NewFullMessage(16, &raw)),
+				fixtured(NewDeltaMessage(17, gamepad.ComputeDelta(raw, rawPressed))),
+			},
+		},
+		{
+			Name:        "player_selected",
+			Direction:   FixtureServer,
+			Description: "Confirmation of a select_player request.",
+			Messages:    []any{fixtured(NewPlayerSelectedMessage(2))},
+		},
+		{
+			Name:        "power_changed",
+			Direction:   FixtureServer,
+			Description: "Power events of player 2 (seq 0, outside the state stream): the level fell to the 20% threshold while discharging, then the cable was plugged in.",
+			Messages: []any{
+				fixtured(NewPowerMessage(2, &PowerEvent{
+					Reason:    PowerReasonThreshold,
+					Threshold: 20,
+					Previous:  gamepad.BatteryState{Status: gamepad.BatteryDischarging, Level: 30},
+					Current:   gamepad.BatteryState{Status: gamepad.BatteryDischarging, Level: 20},
+				})),
+				fixtured(NewPowerMessage(2, &PowerEvent{
+					Reason:   PowerReasonStatus,
+					Previous: gamepad.BatteryState{Status: gamepad.BatteryDischarging, Level: 20},
+					Current:  gamepad.BatteryState{Status: gamepad.BatteryCharging, Level: 20},
+				})),
+			},
+		},
+		{
+			Name:        "input_diff",
+			Direction:   FixtureServer,
+			Description: "Comparison against a reference recording (--compare-replay; seq 0, outside the state stream), for player 1's viewers: in attempt 3, A came 80 ms late, dpad up was never pressed, and X was pressed where the reference had no press. Controls are named by their physical position, whatever the client's profile.",
+			Messages: []any{
+				fixtured(NewInputDiffMessage(1, &InputDiff{Attempt: 3, Control: "buttons.a", Kind: DiffLate, AtMs: 1200, OffsetMs: 80})),
+				fixtured(NewInputDiffMessage(1, &InputDiff{Attempt: 3, Control: "dpad.up", Kind: DiffMissed, AtMs: 1650})),
+				fixtured(NewInputDiffMessage(1, &InputDiff{Attempt: 3, Control: "buttons.x", Kind: DiffExtra, AtMs: 1910})),
+			},
+		},
+		{
+			Name:        "ghost_state",
+			Direction:   FixtureServer,
+			Description: "Ghost replay (--ghost-replay) for a client that sent subscribe_ghost: the recording's first state, before any run, then a state of run 2, 1.2 s into the recording. Ghost states are always full and numbered separately from the live stream; they follow the client's output profile.",
+			Messages: []any{
+				fixtured(NewGhostStateMessage(1, &pressed, &GhostInfo{Label: "pb.jsonl"})),
+				fixtured(NewGhostStateMessage(37, &moved, &GhostInfo{Label: "pb.jsonl", Run: 2, AtMs: 1200, Playing: true})),
+			},
+		},
+		{
+			Name:        "marker_added",
+			Direction:   FixtureServer,
+			Description: "A marker was dropped, with the [marker-combos] combo \"highlight\" by player 1 or with POST /api/markers. Sent to every client (seq 0, outside the state stream).",
+			Messages: []any{
+				fixtured(NewMarkerMessage(&marker.Marker{ID: 4, Name: "highlight", Time: fixtureTimestamp - 250, Source: marker.SourceCombo, PlayerIndex: 1})),
+				fixtured(NewMarkerMessage(&marker.Marker{ID: 5, Name: "marker 5", Time: fixtureTimestamp, Source: marker.SourceAPI})),
+			},
+		},
+		{
+			Name:        "freeze_changed",
+			Direction:   FixtureServer,
+			Description: "The input display was frozen for 30 s (POST /api/freeze or --freeze-combo), then released. Sent to every client (seq 0, outside the state stream); while frozen, no states are sent, and the release is followed by a full of the current state.",
+			Messages: []any{
+				fixtured(NewFreezeMessage(&FreezeInfo{Frozen: true, Until: fixtureTimestamp + 30000})),
+				fixtured(NewFreezeMessage(&FreezeInfo{})),
+			},
+		},
+		{
+			Name:        "plugin_event",
+			Direction:   FixtureServer,
+			Description: "An event the [plugins.detector] process wrote to its stdout as {\"type\":\"event\",\"name\":\"combo_detected\",\"data\":{...}}; data is passed on as the plugin wrote it. Sent to every client (seq 0, outside the state stream).",
+			Messages: []any{
+				fixtured(NewPluginEventMessage(&PluginEvent{Plugin: "detector", Event: "combo_detected", Data: json.RawMessage(`{"name":"hadouken","ms":180}`)})),
+			},
+		},
+		{
+			Name:        "combo",
+			Direction:   FixtureServer,
+			Description: "Player 1 entered the [sequences.hadouken] input sequence (down, down+right, right+x), its first to last step 180 ms apart. Sent to every client (seq 0, outside the state stream).",
+			Messages: []any{
+				fixtured(NewComboMessage(1, &ComboEvent{Name: "hadouken", DurationMs: 180})),
+			},
+		},
+		{
+			Name:        "notation",
+			Direction:   FixtureServer,
+			Description: "Player 1's quarter-circle-forward + X (236X) in numpad notation, each entry sent when the direction or buttons change, with the frames (at 60 a second) the entry before lasted. Only sent to the player's viewers that sent subscribe_notation (seq 0).",
+			Messages: []any{
+				fixtured(NewNotationMessage(1, &NotationEntry{Input: "2", Direction: 2, PrevFrames: 42})),
+				fixtured(NewNotationMessage(1, &NotationEntry{Input: "3", Direction: 3, PrevFrames: 4})),
+				fixtured(NewNotationMessage(1, &NotationEntry{Input: "6X", Direction: 6, Buttons: []string{"X"}, PrevFrames: 3})),
+			},
+		},
+		{
+			Name:        "stats",
+			Direction:   FixtureServer,
+			Description: "Player 1's session statistics, sent to the player's viewers every --stats-interval seconds (seq 0): the press counts since presses.since, their total, the presses of the last minute (apm) and the average per minute, and how far each stick moved in stick radii.",
+			Messages: []any{
+				fixtured(NewStatsMessage(&SessionStats{
+					PlayerIndex: 1,
+					Presses: PressCounters{
+						Since:   fixtureTimestamp - 600000,
+						Buttons: ButtonCounts{A: 412, B: 198, X: 305, Y: 87, LB: 40, RB: 52, Start: 3},
+						Dpad:    DpadCounts{Up: 61, Down: 140, Left: 75, Right: 133},
+						Sticks:  StickCounts{Left: 6},
+					},
+					TotalPresses: 1512,
+					APM:          164,
+					AverageAPM:   151.2,
+					Travel:       StickTravel{Left: 842.317, Right: 203.06},
+				})),
+			},
+		},
+		{
+			Name:        "time_sync_reply",
+			Direction:   FixtureServer,
+			Description: "Reply to a time_sync command: clientTime echoed, serverReceive and serverSend on the monotonic server clock (µs, like every message's mono). Only sent to the asking client (seq 0).",
+			Messages:    []any{fixtured(sync)},
+		},
+		{
+			Name:        "controller_events",
+			Direction:   FixtureServer,
+			Description: "A second controller is plugged in (not made active) and later unplugged. Sent to every client (seq 0, outside the state stream); reason is unplugged or replaced.",
+			Messages: []any{
+				fixtured(NewControllerMessage(gamepad.ControllerEvent{Type: gamepad.ControllerConnected, Controller: psInfo})),
+				fixtured(NewControllerMessage(gamepad.ControllerEvent{
+					Type: gamepad.ControllerDisconnected, Reason: gamepad.DisconnectUnplugged, Controller: psInfo,
+				})),
+			},
+		},
+		{
+			Name:        "controller_switched",
+			Direction:   FixtureServer,
+			Description: "The active controller changed from player 1 to player 2 (reason selected, or promoted when the active one disconnected), with both devices, sent to every client (seq 0). The new player's viewers also get a full.",
+			Messages: []any{
+				fixtured(NewControllerMessage(gamepad.ControllerEvent{
+					Type: gamepad.ControllerSwitched, Reason: gamepad.SwitchSelected, Controller: psActive, Previous: &xboxInfo,
+				})),
+			},
+		},
+		{
+			Name:        "controller_drift",
+			Direction:   FixtureServer,
+			Description: "The left stick of the active controller started drifting: at rest it sits off centre (bias) and jitters (noise, half the spread) far enough to reach its deadzone. Measured on the raw stick before the deadzone, and sent again with drifting false once it settles. Sent to every client (seq 0); GET /api/controllers/{deviceId}/drift returns the same report.",
+			Messages: []any{
+				fixtured(NewControllerMessage(gamepad.ControllerEvent{
+					Type: gamepad.ControllerDrift, Controller: psActive, Drift: &gamepad.DriftReport{
+						Left:  gamepad.StickDrift{Bias: gamepad.Vector{X: 0.09, Y: -0.03}, Noise: gamepad.Vector{X: 0.04, Y: 0.02}, Drifting: true, Measured: true},
+						Right: gamepad.StickDrift{Bias: gamepad.Vector{X: 0.01}, Noise: gamepad.Vector{X: 0.01, Y: 0.01}, Measured: true},
+					},
+				})),
+			},
+		},
+		{
+			Name:        "profile_selected",
+			Direction:   FixtureServer,
+			Description: "Server side of select_profile for a profile with rotate = 90: the confirmation, then a full in the new profile (stick vectors, dpad, and face buttons turned clockwise; counters stay keyed by the physical control). The profile's later fulls and deltas are transformed the same way.",
+			Messages: []any{
+				fixtured(NewProfileSelectedMessage("vertical")),
+				fixtured(transformMessage(NewFullMessage(9, &moved), Transform{Rotate: 90})),
+			},
+		},
+		{
+			Name:        "server_shutdown",
+			Direction:   FixtureServer,
+			Description: "The server is stopping (seq 0): the last message before a close frame with code 1001 (going away). Show a stopped state and reconnect with backoff.",
+			Messages:    []any{fixtured(NewServerShutdownMessage())},
+		},
+		{
+			Name:        "km_full",
+			Direction:   FixtureServer,
+			Description: "Keyboard/mouse snapshot sent after subscribe_km. Keys are uiohook scancodes, mouse buttons 1-5.",
+			Messages:    []any{fixtured(NewKMFullMessage(1, &km))},
+		},
+		{
+			Name:        "km_delta",
+			Direction:   FixtureServer,
+			Description: "Keyboard/mouse delta: key and button edges, normalized mouse movement, and wheel.",
+			Messages:    []any{fixtured(NewKMDeltaMessage(2, kmDelta))},
+		},
+		{
+			Name:        "select_player",
+			Direction:   FixtureClient,
+			Description: "Ask the server to stream player 2.",
+			Messages:    []any{ClientMessage{Type: "select_player", PlayerIndex: 2}},
+		},
+		{
+			Name:        "select_profile",
+			Direction:   FixtureClient,
+			Description: "Receive states in the configured output profile \"vertical\" (empty profile = untransformed).",
+			Messages:    []any{ClientMessage{Type: "select_profile", Profile: "vertical"}},
+		},
+		{
+			Name:        "subscribe_km",
+			Direction:   FixtureClient,
+			Description: "Subscribe to the keyboard/mouse stream.",
+			Messages:    []any{ClientMessage{Type: "subscribe_km"}},
+		},
+		{
+			Name:        "subscribe_ghost",
+			Direction:   FixtureClient,
+			Description: "Subscribe to the ghost replay stream (ghost_state messages); ignored unless the server has --ghost-replay.",
+			Messages:    []any{ClientMessage{Type: "subscribe_ghost"}},
+		},
+		{
+			Name:        "subscribe_notation",
+			Direction:   FixtureClient,
+			Description: "Subscribe to the numpad notation stream (notation messages) of the followed player.",
+			Messages:    []any{ClientMessage{Type: "subscribe_notation"}},
+		},
+		{
+			Name:        "time_sync",
+			Direction:   FixtureClient,
+			Description: "Start a clock offset exchange; clientTime is the client's clock when sent (microseconds recommended), echoed in the reply.",
+			Messages:    []any{ClientMessage{Type: "time_sync", ClientTime: 8_123_456}},
+		},
+		{
+			Name:        "request_full",
+			Direction:   FixtureClient,
+			Description: "Ask for a full of the current state now, e.g. after a gap in the stream, instead of waiting for the periodic full (up to 5 s).",
+			Messages:    []any{ClientMessage{Type: "request_full"}},
+		},
+		{
+			Name:        "set_mouse_sens",
+			Direction:   FixtureClient,
+			Description: "Set the mouse movement sensitivity divisor.",
+			Messages:    []any{ClientMessage{Type: "set_mouse_sens", Value: 300}},
+		},
+		{
+			Name:        "set_rate",
+			Direction:   FixtureClient,
+			Description: "Receive at most 30 state messages per second: deltas in between are coalesced (value 0 = every change).",
+			Messages:    []any{ClientMessage{Type: "set_rate", Value: 30}},
+		},
+		{
+			Name:        "set_analog",
+			Direction:   FixtureClient,
+			Description: "Receive stick, trigger, and motion changes as analog messages, and deltas only for the rest (none = no analog messages, inline = in the deltas, the default).",
+			Messages:    []any{ClientMessage{Type: "set_analog", Analog: AnalogSplit}},
+		},
+		{
+			Name:        "set_fields",
+			Direction:   FixtureClient,
+			Description: "Receive deltas only for buttons (with paddles and stick clicks) and the dpad, for a button-only overlay; other changes of the input groups are left out, and fulls are unchanged (fields empty = all).",
+			Messages:    []any{ClientMessage{Type: "set_fields", Fields: "buttons,dpad"}},
+		},
+		{
+			Name:        "rumble",
+			Direction:   FixtureClient,
+			Description: "Vibrate the active controller (playerIndex 0; N = player N) for 500 ms, low-frequency motor at full strength, high-frequency at half, to check which controller is selected. Only XInput controllers rumble.",
+			Messages:    []any{ClientMessage{Type: "rumble", Low: 1, High: 0.5, Duration: 500}},
+		},
+		{
+			Name:        "set_led",
+			Direction:   FixtureClient,
+			Description: "Light player 2's controller orange and show player 2 on its player LEDs (playerIndex 0 = the active controller; playerLeds 0 = off). Either field may be left out to keep it. Only DualSense controllers have settable lights.",
+			Messages:    []any{ClientMessage{Type: "set_led", PlayerIndex: 2, Color: "#ff8000", PlayerLEDs: fixtureInt(2)}},
+		},
+		{
+			Name:        "set_raw_mode",
+			Direction:   FixtureClient,
+			Description: "Read player 5's controller without a mapping: its states carry the raw group from the next report on (enabled false goes back to the mapping). Only generic HID controllers have a raw mode.",
+			Messages:    []any{ClientMessage{Type: "set_raw_mode", PlayerIndex: 5, Enabled: fixtureBool(true)}},
+		},
+	}
+}
+
+// fixtureXboxState is the connected player 1 controller most fixtures start from.
+func fixtureXboxState() gamepad.GamepadState {
+	return gamepad.GamepadState{
+		Connected:      true,
+		ControllerType: "xbox",
+		Name:           "Xbox Controller",
+		PlayerIndex:    1,
+		DeviceID:       "xinput-0",
+		Capabilities: gamepad.Capabilities{
+			HasRumble: true, BatteryReadable: true,
+			NumButtons: 11, NumAxes: 6,
+		},
+	}
+}
+
+// fixtured pins a constructed message's timestamp and monotonic clock.
+func fixtured(m *WSMessage) *WSMessage {
+	m.Timestamp = fixtureTimestamp
+	m.Mono = fixtureMono
+	return m
+}
+
+// fixtureInt and fixtureBool return a pointer to v, for optional fields.
+func fixtureInt(v int) *int { return &v }
+
+func fixtureBool(v bool) *bool { return &v }
@@ -0,0 +1,77 @@
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestProtocolFixtures verifies that every fixture decodes strictly into the
+// protocol structs and re-encodes byte-for-byte, that server fixtures have a
+// MessagePack form, that client fixtures pass ParseClientMessage, and that the
+// session fixture's deltas are consistent with its full snapshots.
+func TestProtocolFixtures(t *testing.T) {
+	names := make(map[string]bool)
+	for _, f := range ProtocolFixtures() {
+		t.Run(f.Name, func(t *testing.T) {
+			if names[f.Name] {
+				t.Fatalf("duplicate fixture name %q", f.Name)
+			}
+			names[f.Name] = true
+			if len(f.Messages) == 0 || f.Description == "" {
+				t.Fatalf("fixture %q has no messages or no description", f.Name)
+			}
+			for i, m := range f.Messages {
+				data, err := json.Marshal(m)
+				if err != nil {
+					t.Fatalf("message %d: %v", i, err)
+				}
+				switch f.Direction {
+				case FixtureServer:
+					var got WSMessage
+					dec := json.NewDecoder(bytes.NewReader(data))
+					dec.DisallowUnknownFields()
+					if err := dec.Decode(&got); err != nil {
+						t.Fatalf("message %d: decode WSMessage: %v", i, err)
+					}
+					again, _ := json.Marshal(&got)
+					if !bytes.Equal(again, data) {
+						t.Errorf("message %d does not round-trip:\n got %s\nwant %s", i, again, data)
+					}
+					if bin, err := EncodeMsgpack(data); err != nil || !bytes.Contains(bin[:min(len(bin), 6)], []byte("\xa4type")) {
+						t.Errorf("message %d: EncodeMsgpack = % x, %v; want a map starting with type", i, bin[:min(len(bin), 8)], err)
+					}
+				case FixtureClient:
+					if _, err := ParseClientMessage(data); err != nil {
+						t.Errorf("message %d: ParseClientMessage: %v", i, err)
+					}
+				default:
+					t.Fatalf("unknown direction %q", f.Direction)
+				}
+			}
+		})
+	}
+
+	// In the session fixture, applying the deltas in order must reproduce each
+	// following full snapshot, as a client merging deltas would.
+	for _, f := range ProtocolFixtures() {
+		if f.Name != "session" {
+			continue
+		}
+		var state gamepad.GamepadState
+		for i, m := range f.Messages {
+			msg := m.(*WSMessage)
+			switch msg.Type {
+			case "full":
+				if i > 0 && !gamepad.ComputeDelta(state, *msg.Data).IsEmpty() {
+					t.Errorf("session message %d: merged state %+v != full %+v", i, state, *msg.Data)
+				}
+				state = *msg.Data
+			case "delta":
+				state = gamepad.ApplyDelta(state, msg.Changes)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package hub
+
+import (
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// FreezeInfo is the freeze state of the input display, for type
+// "freeze_changed" and /api/freeze.
+type FreezeInfo struct {
+	Frozen bool  `json:"frozen"`
+	Until  int64 `json:"until,omitempty"` // Unix ms when the freeze releases itself; omitted = until released
+}
+
+// SetFreezeCombo makes completing the combo controls (see ParseCombo) toggle
+// a freeze: the first time it freezes the display for timeout (0 = until
+// released), the next time it releases it. nil (the default) disables the
+// combo. Call before Run.
+func (b *Broadcaster) SetFreezeCombo(controls []string, timeout time.Duration) {
+	b.mu.Lock()
+	b.freezeCombo = controls
+	b.freezeTimeout = timeout
+	b.mu.Unlock()
+}
+
+// Freeze holds the state clients are shown, so a streamer can pause the
+// input display while explaining a technique. New states are still tracked
+// (counters, comparison, ghost, markers) but not sent, and clients that
+// connect get the held state. d > 0 releases the freeze after d; 0 holds it
+// until Unfreeze. Freezing again while frozen keeps the held state and only
+// replaces the deadline. Every client is told with a "freeze_changed"
+// message. Safe to call from any goroutine.
+func (b *Broadcaster) Freeze(d time.Duration) FreezeInfo {
+	b.mu.Lock()
+	info := b.freezeLocked(d)
+	b.mu.Unlock()
+	b.broadcastFreeze(info)
+	return info
+}
+
+// Unfreeze releases a freeze: the player's viewers get a full of the current
+// state. It returns false if the display was not frozen. Safe to call from
+// any goroutine.
+func (b *Broadcaster) Unfreeze() bool {
+	b.mu.Lock()
+	if !b.frozen.Frozen {
+		b.mu.Unlock()
+		return false
+	}
+	fulls := b.unfreezeLocked()
+	b.mu.Unlock()
+	b.broadcastFulls(fulls)
+	b.broadcastFreeze(FreezeInfo{})
+	return true
+}
+
+// Frozen returns the current freeze state. Safe to call from any goroutine.
+func (b *Broadcaster) Frozen() FreezeInfo {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.frozen
+}
+
+// freezeLocked freezes the display on the last states sent (see Freeze).
+// b.mu must be held.
+func (b *Broadcaster) freezeLocked(d time.Duration) FreezeInfo {
+	if !b.frozen.Frozen {
+		b.held = b.streamsLocked()
+	}
+	b.freezeGen++ // a pending release of an earlier freeze no longer applies
+	b.frozen = FreezeInfo{Frozen: true}
+	if d > 0 {
+		b.frozen.Until = time.Now().Add(d).UnixMilli()
+		gen := b.freezeGen
+		time.AfterFunc(d, func() { b.expireFreeze(gen) })
+	}
+	return b.frozen
+}
+
+// unfreezeLocked releases the freeze and returns the fulls that bring the
+// viewers of each stream back to its current state. b.mu must be held.
+func (b *Broadcaster) unfreezeLocked() []*WSMessage {
+	b.frozen = FreezeInfo{}
+	b.freezeGen++
+	b.held = nil
+	b.deltaCount = 0
+	var fulls []*WSMessage
+	for _, s := range b.streamsLocked() {
+		b.seq++
+		fulls = append(fulls, b.fullMessageLocked(b.seq, s))
+	}
+	return fulls
+}
+
+// expireFreeze releases the freeze of generation gen when its deadline
+// passes, unless it was released or replaced since.
+func (b *Broadcaster) expireFreeze(gen int64) {
+	b.mu.Lock()
+	if !b.frozen.Frozen || b.freezeGen != gen {
+		b.mu.Unlock()
+		return
+	}
+	fulls := b.unfreezeLocked()
+	b.mu.Unlock()
+	b.broadcastFulls(fulls)
+	b.broadcastFreeze(FreezeInfo{})
+}
+
+// shownLocked returns the state the clients following playerIndex are
+// shown: the held state while frozen, else the latest. Without
+// SetAllPlayers there is one stream, whatever the player. b.mu must be held.
+func (b *Broadcaster) shownLocked(playerIndex int) gamepad.GamepadState {
+	streams := b.held
+	if !b.frozen.Frozen {
+		streams = b.streamsLocked()
+	}
+	if !b.allPlayers {
+		return streams[0]
+	}
+	for _, s := range streams {
+		if s.PlayerIndex == playerIndex {
+			return s
+		}
+	}
+	return gamepad.GamepadState{PlayerIndex: playerIndex}
+}
+
+// freezeComboLocked reports whether state completes the freeze combo (see
+// comboEdgesLocked). b.mu must be held, and state not yet tracked.
+func (b *Broadcaster) freezeComboLocked(state gamepad.GamepadState) bool {
+	if len(b.freezeCombo) == 0 {
+		return false
+	}
+	pressed, held := b.comboEdgesLocked(state)
+	return completes(b.freezeCombo, pressed, held)
+}
+
+// broadcastFreeze announces a freeze change to every client.
+func (b *Broadcaster) broadcastFreeze(info FreezeInfo) {
+	if data, ok := marshalOrLog("freeze_changed message", NewFreezeMessage(&info)); ok {
+		b.hub.BroadcastAll(data)
+	}
+}
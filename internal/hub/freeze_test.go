@@ -0,0 +1,66 @@
+package hub
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFreeze verifies that a freeze holds the shown state while states are
+// still tracked, that the combo toggles it without showing the combo press,
+// and that a timed freeze releases itself.
+func TestFreeze(t *testing.T) {
+	controls, _ := ParseCombo("back+start")
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetFreezeCombo(controls, 0)
+	idle := fixtureXboxState()
+	a := idle
+	a.Buttons.A = true
+	combo := idle
+	combo.Buttons.Back, combo.Buttons.Start = true, true
+
+	b.mu.Lock()
+	b.stateMessageLocked(a, 1000)
+	if b.freezeComboLocked(a) {
+		t.Error("freezeComboLocked(A) = true")
+	}
+	if !b.freezeComboLocked(combo) {
+		t.Fatal("freezeComboLocked(back+start) = false")
+	}
+	b.freezeLocked(0)
+	if msg, _ := b.stateMessageLocked(combo, 1100); msg == nil {
+		t.Error("stateMessageLocked while frozen: want the state still tracked")
+	}
+	if shown := b.shownLocked(1); !shown.Buttons.A || shown.Buttons.Back {
+		t.Errorf("shown state while frozen = %+v, want the state before the combo", shown.Buttons)
+	}
+	if b.players[1].counts.Buttons.Back != 1 {
+		t.Error("press of Back while frozen not counted")
+	}
+	msg := b.unfreezeLocked()[0]
+	if msg.Type != "full" || !msg.Data.Buttons.Back || b.frozen.Frozen {
+		t.Errorf("unfreezeLocked() = %+v, want a full of the current state", msg)
+	}
+	b.mu.Unlock()
+
+	if info := b.Freeze(20 * time.Millisecond); !info.Frozen || info.Until == 0 {
+		t.Fatalf("Freeze(20ms) = %+v, want frozen with a deadline", info)
+	}
+	if info := b.Freeze(0); info.Until != 0 {
+		t.Errorf("Freeze(0) while frozen = %+v, want no deadline", info)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !b.Frozen().Frozen {
+		t.Error("the replaced 20 ms deadline released the freeze")
+	}
+	if !b.Unfreeze() || b.Unfreeze() {
+		t.Error("Unfreeze() twice: want true, then false")
+	}
+	b.Freeze(10 * time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for b.Frozen().Frozen && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if b.Frozen().Frozen {
+		t.Error("a 10 ms freeze still frozen after 1 s")
+	}
+}
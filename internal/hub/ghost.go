@@ -0,0 +1,199 @@
+package hub
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Frame is one state of a recording at a time relative to its first press.
+type Frame struct {
+	At    int64 // milliseconds since the recording's first press
+	State gamepad.GamepadState
+}
+
+// LoadRecording reads a raw input capture (see gamepad.ReplayCapture) of one
+// controller and returns its states from the first press on. dz is the
+// deadzone to replay with. Replay does not tell devices apart, so a capture
+// of several controllers yields their states interleaved.
+func LoadRecording(rd io.Reader, dz float64) ([]Frame, error) {
+	var (
+		frames []Frame
+		prev   gamepad.GamepadState
+		first  time.Duration
+	)
+	err := gamepad.ReplayCapture(rd, dz, func(at time.Duration, s gamepad.GamepadState) {
+		if len(frames) == 0 {
+			if len(PressEdges(prev, s)) == 0 {
+				prev = s
+				return
+			}
+			first = at
+		}
+		frames = append(frames, Frame{At: (at - first).Milliseconds(), State: s})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("capture contains no presses")
+	}
+	return frames, nil
+}
+
+// GhostInfo tags a "ghost_state" message with the playback it belongs to.
+type GhostInfo struct {
+	Label   string `json:"label"`   // name of the recording (its file name)
+	Run     int    `json:"run"`     // 1-based playback number since the server started; 0 = not played yet
+	AtMs    int64  `json:"atMs"`    // position of the state in the recording
+	Playing bool   `json:"playing"` // false before the first playback and once a playback has ended
+}
+
+// Ghost plays a recording back as a separate "ghost" state stream next to
+// the live input. A playback starts at the live player's first press, which
+// is aligned with the recording's first press (as in Comparison), and runs
+// through the recording once; the next press starts the next run. Between
+// runs the ghost holds its last state. Not safe for concurrent use: the
+// Broadcaster serializes it.
+type Ghost struct {
+	label  string
+	frames []Frame
+
+	run     int
+	playing bool
+	start   int64 // Unix ms of frame position 0
+	next    int   // index of the next frame to play
+	current int   // index of the last frame played (or shown before the first run)
+	seq     int64 // of the shown state; bumped on every change
+}
+
+// NewGhost returns a ghost of frames (as returned by LoadRecording), shown
+// under label. Before the first run it holds the first frame.
+func NewGhost(label string, frames []Frame) *Ghost {
+	return &Ghost{label: label, frames: frames, seq: 1}
+}
+
+// begin starts a run at now (Unix ms) unless one is playing, and reports
+// whether it did.
+func (g *Ghost) begin(now int64) bool {
+	if g.playing {
+		return false
+	}
+	g.run++
+	g.playing = true
+	g.start = now
+	g.next = 0
+	return true
+}
+
+// advance plays the frames due at now (Unix ms) and reports whether the
+// shown state changed. Frames that are due together are coalesced into the
+// last one. The run ends after the last frame.
+func (g *Ghost) advance(now int64) bool {
+	changed := false
+	for g.playing && g.next < len(g.frames) && g.start+g.frames[g.next].At <= now {
+		g.current = g.next
+		g.next++
+		changed = true
+	}
+	if g.playing && g.next == len(g.frames) {
+		g.playing = false
+		changed = true
+	}
+	return changed
+}
+
+// nextAt returns the Unix ms time the next frame is due, if a run is playing.
+func (g *Ghost) nextAt() (int64, bool) {
+	if !g.playing {
+		return 0, false
+	}
+	return g.start + g.frames[g.next].At, true
+}
+
+// message returns a "ghost_state" message with the shown state.
+func (g *Ghost) message() *WSMessage {
+	f := g.frames[g.current]
+	state := f.State
+	return NewGhostStateMessage(g.seq, &state, &GhostInfo{Label: g.label, Run: g.run, AtMs: f.At, Playing: g.playing})
+}
+
+// GhostSubscriber lets a client subscribe to the ghost stream.
+type GhostSubscriber interface {
+	SubscribeGhost(c *Client) bool
+}
+
+// SetGhost plays g next to the live input for clients that send
+// "subscribe_ghost". nil (the default) disables it. Call before Run.
+func (b *Broadcaster) SetGhost(g *Ghost) {
+	b.mu.Lock()
+	b.ghost = g
+	b.mu.Unlock()
+}
+
+// SubscribeGhost subscribes c to the ghost stream and sends it the ghost's
+// current state. It returns false, leaving c unchanged, if no ghost is set.
+// Safe to call from any goroutine (e.g. gws OnMessage handler).
+func (b *Broadcaster) SubscribeGhost(c *Client) bool {
+	b.mu.Lock()
+	if b.ghost == nil {
+		b.mu.Unlock()
+		return false
+	}
+	msg := b.ghost.message()
+	b.mu.Unlock()
+
+	c.wantsGhost.Store(1)
+	if t, ok := b.profiles[c.Profile()]; ok {
+		msg = transformMessage(msg, t)
+	}
+	if data, ok := marshalOrLog("ghost_state message", msg); ok {
+		c.Send(data)
+	}
+	return true
+}
+
+// ghostLocked starts a ghost run if state has a press, then plays the frames
+// due at now. It returns the message to broadcast (nil if the ghost did not
+// change) and when the next frame is due (0 = none). b.mu must be held, and
+// state not yet tracked.
+func (b *Broadcaster) ghostLocked(state *gamepad.GamepadState, now int64) (*WSMessage, int64) {
+	if b.ghost == nil {
+		return nil, 0
+	}
+	if state != nil {
+		var old gamepad.GamepadState
+		if ps, ok := b.players[state.PlayerIndex]; ok {
+			old = ps.last
+		}
+		if len(PressEdges(old, *state)) > 0 && b.ghost.begin(now) {
+			slog.Debug("ghost run started", "run", b.ghost.run, "recording", b.ghost.label)
+		}
+	}
+	changed := b.ghost.advance(now)
+	next, _ := b.ghost.nextAt()
+	if !changed {
+		return nil, next
+	}
+	b.ghost.seq++
+	return b.ghost.message(), next
+}
+
+// broadcastGhost marshals and broadcasts a ghost_state message to the ghost
+// subscribers, transformed for each profile's clients like broadcastState.
+func (b *Broadcaster) broadcastGhost(msg *WSMessage) {
+	if msg == nil {
+		return
+	}
+	if data, ok := marshalOrLog("ghost_state message", msg); ok {
+		b.hub.BroadcastGhost(data, "")
+	}
+	for name, t := range b.profiles {
+		if data, ok := marshalOrLog("ghost_state message", transformMessage(msg, t)); ok {
+			b.hub.BroadcastGhost(data, name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package hub
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGhost verifies that a recording is loaded from its first press and
+// played back from the live player's first press, one run at a time.
+func TestGhost(t *testing.T) {
+	capture := strings.Join([]string{
+		`{"t":0,"event":"connect","source":"xinput","device":0,"vid":1118,"pid":654}`,
+		`{"t":50000,"event":"input","source":"xinput","device":0,"data":"000000000000000000000000"}`,
+		`{"t":100000,"event":"input","source":"xinput","device":0,"data":"001000000000000000000000"}`, // A
+		`{"t":150000,"event":"input","source":"xinput","device":0,"data":"000000000000000000000000"}`,
+		`{"t":400000,"event":"input","source":"xinput","device":0,"data":"002000000000000000000000"}`, // B
+	}, "\n")
+	frames, err := LoadRecording(strings.NewReader(capture), 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 || frames[0].At != 0 || !frames[0].State.Buttons.A || frames[2].At != 300 || !frames[2].State.Buttons.B {
+		t.Fatalf("LoadRecording() = %+v, want A at 0, release at 50, B at 300", frames)
+	}
+
+	g := NewGhost("pb.jsonl", frames)
+	if _, ok := g.nextAt(); ok {
+		t.Error("nextAt() before the first run: want none")
+	}
+	if m := g.message(); m.Type != "ghost_state" || m.Ghost.Run != 0 || m.Ghost.Playing || !m.Data.Buttons.A {
+		t.Errorf("message() before the first run = %+v %+v, want run 0, idle, first frame", m, m.Ghost)
+	}
+
+	const t0 = 10000
+	if !g.begin(t0) || !g.advance(t0) {
+		t.Fatal("begin + advance at the live press: want a started run and a change")
+	}
+	if next, _ := g.nextAt(); next != t0+50 {
+		t.Errorf("nextAt() = %d, want %d", next, t0+50)
+	}
+	if g.begin(t0 + 20) {
+		t.Error("begin() during a run: want false")
+	}
+	if g.advance(t0 + 49) {
+		t.Error("advance() before the next frame: want no change")
+	}
+	if !g.advance(t0+400) || g.playing {
+		t.Error("advance() past the last frame: want a change and the run ended")
+	}
+	m := g.message()
+	if m.Ghost.Run != 1 || m.Ghost.AtMs != 300 || !m.Data.Buttons.B {
+		t.Errorf("message() after run 1 = %+v, want run 1 at 300 with B", m.Ghost)
+	}
+	if !g.begin(t0+5000) || g.run != 2 {
+		t.Error("begin() after a run: want run 2")
+	}
+
+	if _, err := LoadRecording(strings.NewReader(`{"t":0,"event":"connect","source":"xinput","device":0}`), 0.05); err == nil {
+		t.Error("LoadRecording() of a capture without presses: got nil error")
+	}
+}
@@ -0,0 +1,84 @@
+package hub
+
+import (
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// HeatmapSize is the number of cells per side of a stick heatmap.
+const HeatmapSize = 32
+
+// StickHeatmap is a 2D histogram of where a stick was held: how long it spent
+// in each cell of a HeatmapSize × HeatmapSize grid over its square range.
+type StickHeatmap struct {
+	Size    int       `json:"size"`    // cells per side
+	TotalMs int64     `json:"totalMs"` // the sum of all cells
+	Cells   [][]int64 `json:"cells"`   // ms in each cell, [row][col]: row 0 is the top (Y = +1), col 0 the left (X = -1)
+}
+
+// Heatmaps are the stick heatmaps of a player since the server started.
+type Heatmaps struct {
+	PlayerIndex int          `json:"playerIndex"`
+	Left        StickHeatmap `json:"left"`
+	Right       StickHeatmap `json:"right"`
+}
+
+// heatGrid is the ms a stick spent in each heatmap cell, row by row.
+type heatGrid [HeatmapSize * HeatmapSize]int64
+
+// heatCell returns the index in a heatGrid of the cell holding p.
+func heatCell(p gamepad.Vector) int {
+	cell := func(v float64) int {
+		return min(HeatmapSize-1, max(0, int((v+1)/2*HeatmapSize)))
+	}
+	return cell(-p.Y)*HeatmapSize + cell(p.X)
+}
+
+// heat adds the time the player's sticks spent at their positions in old,
+// from the last state to now, to its heatmaps. Time while disconnected is not
+// counted.
+func (ps *playerStats) heat(old gamepad.GamepadState, now int64) {
+	if !old.Connected || now <= ps.lastAt {
+		return
+	}
+	if ps.heatmaps == nil {
+		ps.heatmaps = new([2]heatGrid)
+	}
+	ps.heatmaps[0][heatCell(old.Sticks.Left.Position)] += now - ps.lastAt
+	ps.heatmaps[1][heatCell(old.Sticks.Right.Position)] += now - ps.lastAt
+}
+
+// stickHeatmap returns grid as a StickHeatmap.
+func stickHeatmap(grid *heatGrid) StickHeatmap {
+	h := StickHeatmap{Size: HeatmapSize, Cells: make([][]int64, HeatmapSize)}
+	for row := range h.Cells {
+		h.Cells[row] = make([]int64, HeatmapSize)
+		if grid == nil {
+			continue
+		}
+		copy(h.Cells[row], grid[row*HeatmapSize:])
+		for _, ms := range h.Cells[row] {
+			h.TotalMs += ms
+		}
+	}
+	return h
+}
+
+// Heatmaps returns the stick heatmaps of the given player, counting the
+// current positions up to now; all cells are 0 if no connected state has been
+// seen for it. Safe to call from any goroutine.
+func (b *Broadcaster) Heatmaps(playerIndex int) Heatmaps {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := Heatmaps{PlayerIndex: playerIndex, Left: stickHeatmap(nil), Right: stickHeatmap(nil)}
+	if ps, ok := b.players[playerIndex]; ok {
+		now := time.Now().UnixMilli()
+		ps.heat(ps.last, now)
+		ps.lastAt = max(ps.lastAt, now)
+		if ps.heatmaps != nil {
+			h.Left, h.Right = stickHeatmap(&ps.heatmaps[0]), stickHeatmap(&ps.heatmaps[1])
+		}
+	}
+	return h
+}
@@ -0,0 +1,56 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestHeatCell verifies stick positions map to the grid with the top row at
+// Y = +1 and the edges inside it.
+func TestHeatCell(t *testing.T) {
+	last := HeatmapSize - 1
+	for _, tt := range []struct {
+		p        gamepad.Vector
+		row, col int
+	}{
+		{gamepad.Vector{X: -1, Y: 1}, 0, 0},
+		{gamepad.Vector{X: 1, Y: -1}, last, last},
+		{gamepad.Vector{X: 0.999, Y: 0}, HeatmapSize / 2, last},
+		{gamepad.Vector{X: -0.01, Y: 0.01}, HeatmapSize/2 - 1, HeatmapSize/2 - 1},
+	} {
+		if got := heatCell(tt.p); got != tt.row*HeatmapSize+tt.col {
+			t.Errorf("heatCell(%+v) = row %d col %d, want row %d col %d", tt.p, got/HeatmapSize, got%HeatmapSize, tt.row, tt.col)
+		}
+	}
+}
+
+// TestHeatmaps verifies each position is credited with the time until the
+// next state, the current one with the time until the query, and that time
+// while disconnected is not counted.
+func TestHeatmaps(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	if h := b.Heatmaps(1); h.Left.TotalMs != 0 || len(h.Left.Cells) != HeatmapSize || len(h.Right.Cells[0]) != HeatmapSize {
+		t.Fatalf("Heatmaps(1) before any state = %+v, want an empty %d-cell grid", h.Left, HeatmapSize)
+	}
+
+	now := time.Now().UnixMilli()
+	up := fixtureXboxState()
+	up.Sticks.Left.Position = gamepad.Vector{Y: 1}
+	b.mu.Lock()
+	b.trackLocked(fixtureXboxState(), now-10_000)                 // centred for 4 s
+	b.trackLocked(up, now-6000)                                   // up for 1 s
+	b.trackLocked(gamepad.GamepadState{PlayerIndex: 1}, now-5000) // unplugged for 3 s
+	b.trackLocked(fixtureXboxState(), now-2000)                   // centred since
+	b.mu.Unlock()
+
+	h := b.Heatmaps(1)
+	centre, top := h.Left.Cells[HeatmapSize/2][HeatmapSize/2], h.Left.Cells[0][HeatmapSize/2]
+	if top != 1000 || centre < 6000 || centre > 6000+1000 || h.Left.TotalMs != centre+top {
+		t.Errorf("left stick: centre %d ms, top %d ms, total %d; want about 6000, 1000, their sum", centre, top, h.Left.TotalMs)
+	}
+	if h.Right.TotalMs != h.Left.TotalMs || h.Right.Cells[HeatmapSize/2][HeatmapSize/2] != h.Right.TotalMs {
+		t.Errorf("right stick = %d ms, %d in the centre; want all of %d in the centre", h.Right.TotalMs, h.Right.Cells[HeatmapSize/2][HeatmapSize/2], h.Left.TotalMs)
+	}
+}
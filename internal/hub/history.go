@@ -0,0 +1,99 @@
+package hub
+
+import (
+	"sort"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// maxHistoryEntries caps the input history, whatever the window: about 64 MB
+// of live states. At high poll rates with several players a long window would
+// otherwise hold millions.
+const maxHistoryEntries = 100000
+
+// HistoryEntry is a gamepad state change kept by the input history (see
+// SetHistory).
+type HistoryEntry struct {
+	Seq   uint64               `json:"seq"` // 1 for the first change, then counting up; the cursor for History
+	At    int64                `json:"at"`  // Unix ms the change reached the broadcaster
+	State gamepad.GamepadState `json:"state"`
+}
+
+// history keeps the gamepad state changes of the last window, oldest first,
+// at most size of them. Expired entries are dropped from the front in bulk
+// once they make up half the buffer, so adding stays cheap at high poll rates.
+// Entries are never written once added: compaction moves the live ones to a
+// new array, so slices handed out by after stay valid without copying them.
+type history struct {
+	window  int64 // ms
+	size    int   // most live entries kept (maxHistoryEntries)
+	entries []HistoryEntry
+	start   int    // entries[:start] have expired
+	seq     uint64 // Seq of the latest entry
+}
+
+// add keeps state, the latest change at now, and expires the entries older
+// than the window before it and those beyond size.
+func (h *history) add(state gamepad.GamepadState, now int64) {
+	h.seq++
+	h.entries = append(h.entries, HistoryEntry{Seq: h.seq, At: now, State: state})
+	h.start = max(h.start, len(h.entries)-h.size)
+	h.expire(now)
+}
+
+// expire drops the entries older than the window before now.
+func (h *history) expire(now int64) {
+	for h.start < len(h.entries) && h.entries[h.start].At < now-h.window {
+		h.start++
+	}
+	if h.start > len(h.entries)/2 {
+		h.entries, h.start = append([]HistoryEntry(nil), h.entries[h.start:]...), 0
+	}
+}
+
+// after returns the entries after seq, oldest first. The result shares the
+// buffer and must not be modified; its capacity ends at its length, so later
+// adds do not show through it.
+func (h *history) after(seq uint64) []HistoryEntry {
+	live := h.entries[h.start:]
+	i := sort.Search(len(live), func(i int) bool { return live[i].Seq > seq })
+	if i == len(live) {
+		return []HistoryEntry{}
+	}
+	return live[i:len(live):len(live)]
+}
+
+// SetHistory keeps the gamepad state changes of the last window for History,
+// every player's and also while frozen, up to the latest maxHistoryEntries. 0 (the default) disables the
+// history. Call before Run.
+func (b *Broadcaster) SetHistory(window time.Duration) {
+	b.mu.Lock()
+	b.history = nil
+	if window > 0 {
+		b.history = &history{window: window.Milliseconds(), size: maxHistoryEntries}
+	}
+	b.mu.Unlock()
+}
+
+// History returns the kept gamepad state changes after the entry with Seq seq
+// (0 for all), oldest first (never nil), and false if the history is
+// disabled. The entries are shared with the history and must not be
+// modified. Safe to call from any goroutine.
+func (b *Broadcaster) History(seq uint64) ([]HistoryEntry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.history == nil {
+		return nil, false
+	}
+	b.history.expire(time.Now().UnixMilli())
+	return b.history.after(seq), true
+}
+
+// historyLocked keeps state, received at now, in the history. b.mu must be
+// held.
+func (b *Broadcaster) historyLocked(state gamepad.GamepadState, now int64) {
+	if b.history != nil {
+		b.history.add(state, now)
+	}
+}
@@ -0,0 +1,64 @@
+package hub
+
+import (
+	"testing"
+	"time"
+)
+
+// TestHistory verifies changes are kept for the window before the latest, at
+// most size of them, returned after a given seq, oldest first, that expired
+// entries are dropped as the buffer fills, and that returned entries are not
+// overwritten by later changes.
+func TestHistory(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	if _, ok := b.History(0); ok {
+		t.Fatal("History reported enabled before SetHistory")
+	}
+	b.SetHistory(time.Second)
+	if entries, ok := b.History(0); !ok || entries == nil || len(entries) != 0 {
+		t.Fatalf("History(0) = %v, %v; want none, enabled", entries, ok)
+	}
+
+	now := time.Now().UnixMilli()
+	state := fixtureXboxState()
+	b.mu.Lock()
+	for i := range 50 {
+		state.Triggers.RT.Value = float64(i) / 50
+		b.historyLocked(state, now-25-int64(49-i)*50) // the first 30 expire
+	}
+	if n := len(b.history.entries); n >= 50 {
+		t.Errorf("buffer holds %d entries, want the expired ones dropped", n)
+	}
+	b.mu.Unlock()
+
+	entries, _ := b.History(0)
+	if len(entries) != 20 || entries[0].Seq != 31 || entries[0].At != now-975 || entries[0].State.Triggers.RT.Value != 0.6 {
+		t.Fatalf("History(0) = %d entries from seq %d at %v, want 20 from seq 31 at %d", len(entries), entries[0].Seq, entries[0].At, now-975)
+	}
+	if later, _ := b.History(46); len(later) != 4 || later[0].Seq != 47 {
+		t.Errorf("History(46) = %v, want the last 4", later)
+	}
+
+	// Changes within the same millisecond are told apart by their seq, and
+	// neither they nor the cap overwrite the entries returned before.
+	b.mu.Lock()
+	b.history.size = 5
+	for range 10 {
+		b.historyLocked(state, now)
+	}
+	b.mu.Unlock()
+	if entries[0].Seq != 31 || entries[0].State.Triggers.RT.Value != 0.6 || len(entries) != 20 || entries[19].Seq != 50 {
+		t.Errorf("earlier History(0) changed to %d entries from seq %d, want 20 from seq 31", len(entries), entries[0].Seq)
+	}
+	if capped, _ := b.History(0); len(capped) != 5 || capped[0].Seq != 56 || capped[4].Seq != 60 || capped[4].At != now {
+		t.Errorf("History(0) with size 5 = %v, want seqs 56 to 60", capped)
+	}
+	if same, _ := b.History(58); len(same) != 2 || same[0].Seq != 59 {
+		t.Errorf("History(58) = %v, want seqs 59 and 60 of the same millisecond", same)
+	}
+
+	b.SetHistory(0)
+	if _, ok := b.History(0); ok {
+		t.Error("History reported enabled after SetHistory(0)")
+	}
+}
@@ -3,25 +3,111 @@ package hub
 import (
 	"context"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
+	"time"
+
+	"github.com/lxzan/gws"
+	"github.com/soar/inputview/internal/audit"
 )
 
-// Hub manages WebSocket clients and broadcasts messages.
+// drainPollInterval is how often Drain checks whether all clients are gone.
+const drainPollInterval = 10 * time.Millisecond
+
+// broadcastMsg is a queued fan-out request (only used when the hub has a
+// broadcast buffer; see SetBroadcastBuffer).
+type broadcastMsg struct {
+	data        []byte
+	playerIndex int          // target player index; ignored when keyMouse or all is true
+	profile     string       // target output profile; only used when byProfile or ghost is true
+	update      *stateUpdate // the state a full or delta brings its stream to; nil = not a state message
+	byProfile   bool         // true: deliver only to the player's clients on profile
+	keyMouse    bool         // true: deliver to keyboard/mouse subscribers
+	ghost       bool         // true: deliver to ghost subscribers on profile
+	notation    bool         // true: deliver to the player's notation subscribers
+	all         bool         // true: deliver to every client
+}
+
+// Hub manages WebSocket clients and broadcasts messages. Clients are kept in
+// topics: every registered client is in the "all" topic (clients), and in the
+// topic of the player index it follows (players), so that the state of a
+// player only visits its own viewers.
 type Hub struct {
 	clients    map[*Client]bool
+	players    map[int32]map[*Client]bool // registered clients by Client.playerIndex (see SetPlayerIndex)
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
+
+	// broadcast queues fan-out requests for Run when non-nil. nil (the
+	// default) means BroadcastToPlayer/BroadcastKeyMouse fan out synchronously
+	// on the caller's goroutine.
+	broadcast chan broadcastMsg
+
+	// clientBuffer is the per-client send buffer given to new clients
+	// (see Client.Send). 0 = unbounded.
+	clientBuffer int
+
+	// updateRate is the update rate given to new clients (see
+	// SetUpdateRate). 0 = every change.
+	updateRate float64
+
+	// audit records the clients' control commands (see SetAuditLog).
+	audit *audit.Log
+
+	// tap observes the broadcast messages (see SetTap); nil = none.
+	tap func(msg []byte)
+
+	// pingInterval and pingTimeout configure the keepalive (see
+	// SetKeepalive); pingInterval 0 = no pings.
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+
+	// stats counts messages for monitoring (see Stats).
+	stats hubStats
 }
 
 func NewHub() *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
+		players:    make(map[int32]map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 	}
 }
 
+// SetBroadcastBuffer sets the length of the hub's broadcast queue.
+//
+// 0 (default): the broadcaster fans out each message to all clients itself.
+// Nothing is ever dropped at the hub, but a large client count directly adds
+// to the broadcaster's per-message latency.
+//
+// n > 0: messages are queued and fanned out by Run, decoupling the broadcaster
+// from fan-out cost. When the queue is full, new messages are dropped (the
+// periodic full sync repairs the affected clients). Must be called before Run.
+func (h *Hub) SetBroadcastBuffer(n int) {
+	if n > 0 {
+		h.broadcast = make(chan broadcastMsg, n)
+	} else {
+		h.broadcast = nil
+	}
+}
+
+// SetClientBuffer sets the per-client send buffer applied to clients created
+// after the call (see Client.Send). 0 = unbounded.
+func (h *Hub) SetClientBuffer(n int) {
+	h.clientBuffer = max(n, 0)
+}
+
+// SetUpdateRate sets the update rate applied to clients created after the
+// call (see Client.SetUpdateRate), so that the reader can poll far faster
+// than the network stream: deltas in between are coalesced. A client's own
+// ?rate= or set_rate replaces it. 0 (default) = every change.
+func (h *Hub) SetUpdateRate(hz float64) {
+	h.updateRate = max(hz, 0)
+}
+
 // Register adds a new client to the hub.
 func (h *Hub) Register(c *Client) {
 	h.register <- c
@@ -34,38 +120,317 @@ func (h *Hub) Unregister(c *Client) {
 
 // BroadcastToPlayer sends a message to all clients with matching player index.
 func (h *Hub) BroadcastToPlayer(msg []byte, playerIndex int) {
+	h.observe(msg)
+	if h.broadcast != nil {
+		h.enqueue(broadcastMsg{data: msg, playerIndex: playerIndex})
+		return
+	}
+	h.fanOutPlayer(msg, playerIndex)
+}
+
+// BroadcastToPlayerProfile sends a message to the clients with matching
+// player index that use the given output profile ("" = no profile).
+func (h *Hub) BroadcastToPlayerProfile(msg []byte, playerIndex int, profile string) {
+	h.broadcastStateProfile(msg, nil, playerIndex, profile)
+}
+
+// broadcastStateProfile is BroadcastToPlayerProfile for a full or delta that
+// brings the stream to u, which clients with an update rate coalesce (see
+// Client.SetUpdateRate). u nil sends msg to every client as is.
+func (h *Hub) broadcastStateProfile(msg []byte, u *stateUpdate, playerIndex int, profile string) {
+	if profile == "" {
+		h.observe(msg)
+	}
+	if h.broadcast != nil {
+		h.enqueue(broadcastMsg{data: msg, update: u, playerIndex: playerIndex, profile: profile, byProfile: true})
+		return
+	}
+	h.fanOutPlayerProfile(msg, u, playerIndex, profile)
+}
+
+// BroadcastKeyMouse sends a message to all clients that have subscribed to keyboard/mouse events.
+func (h *Hub) BroadcastKeyMouse(msg []byte) {
+	if h.broadcast != nil {
+		h.enqueue(broadcastMsg{data: msg, keyMouse: true})
+		return
+	}
+	h.fanOutKeyMouse(msg)
+}
+
+// BroadcastGhost sends a message to the clients that have subscribed to the
+// ghost stream and use the given output profile ("" = no profile).
+func (h *Hub) BroadcastGhost(msg []byte, profile string) {
+	if h.broadcast != nil {
+		h.enqueue(broadcastMsg{data: msg, profile: profile, ghost: true})
+		return
+	}
+	h.fanOutGhost(msg, profile)
+}
+
+// BroadcastNotation sends a message to the clients with matching player
+// index that have subscribed to the notation stream.
+func (h *Hub) BroadcastNotation(msg []byte, playerIndex int) {
+	if h.broadcast != nil {
+		h.enqueue(broadcastMsg{data: msg, playerIndex: playerIndex, notation: true})
+		return
+	}
+	h.fanOutNotation(msg, playerIndex)
+}
+
+// ClientCount returns the number of connected clients.
+func (h *Hub) ClientCount() int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// MovePlayer switches the clients following player from to player to, as if
+// each had sent select_player (they get a "player_selected" message), and
+// returns how many it moved. Switching the active controller is up to the
+// caller; do it afterwards, so the full it triggers reaches them (with
+// Broadcaster.SetAllPlayers, call Broadcaster.SyncPlayer instead).
+func (h *Hub) MovePlayer(from, to int) int {
+	data, ok := marshalOrLog("player_selected message", NewPlayerSelectedMessage(to))
+	if !ok {
+		return 0
+	}
+	m := &outgoing{text: data}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	moved := slices.Collect(maps.Keys(h.players[int32(from)]))
+	for _, client := range moved {
+		h.setPlayerLocked(client, int32(to))
+		client.send(m)
+	}
+	return len(moved)
+}
+
+// setPlayerLocked makes c follow player index, moving it to that player's
+// topic if it is registered. h.mu must be held for writing.
+func (h *Hub) setPlayerLocked(c *Client, index int32) {
+	if h.clients[c] {
+		h.unsubscribeLocked(c)
+		c.playerIndex.Store(index)
+		h.subscribeLocked(c)
+		return
+	}
+	c.playerIndex.Store(index)
+}
+
+// subscribeLocked adds c to the topic of the player it follows. h.mu must be
+// held for writing.
+func (h *Hub) subscribeLocked(c *Client) {
+	pi := c.playerIndex.Load()
+	t := h.players[pi]
+	if t == nil {
+		t = make(map[*Client]bool)
+		h.players[pi] = t
+	}
+	t[c] = true
+}
 
-	pi := int32(playerIndex)
+// unsubscribeLocked removes c from the topic of the player it follows,
+// dropping the topic once it is empty. h.mu must be held for writing.
+func (h *Hub) unsubscribeLocked(c *Client) {
+	pi := c.playerIndex.Load()
+	if t := h.players[pi]; t != nil {
+		delete(t, c)
+		if len(t) == 0 {
+			delete(h.players, pi)
+		}
+	}
+}
+
+// SetAuditLog records the control commands of the hub's clients
+// (select_player, select_profile, set_mouse_sens) in l. nil (the default)
+// records nothing. Must be called before clients connect.
+func (h *Hub) SetAuditLog(l *audit.Log) { h.audit = l }
+
+// BroadcastAll sends a message to every client, regardless of player index.
+func (h *Hub) BroadcastAll(msg []byte) {
+	h.observe(msg)
+	if h.broadcast != nil {
+		h.enqueue(broadcastMsg{data: msg, all: true})
+		return
+	}
+	h.fanOutAll(msg)
+}
+
+// CloseClients closes the WebSocket connections of the clients that match,
+// with close code 1008 (policy violation), and returns how many it closed.
+// It does not wait for the close frames to be written. They unregister as
+// usual once closed.
+func (h *Hub) CloseClients(match func(c *Client) bool) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	n := 0
 	for client := range h.clients {
-		if client.playerIndex.Load() == pi {
-			client.Send(msg)
+		if match(client) {
+			client.close(1008, "access revoked")
+			n++
 		}
 	}
+	return n
 }
 
-// BroadcastKeyMouse sends a message to all clients that have subscribed to keyboard/mouse events.
-func (h *Hub) BroadcastKeyMouse(msg []byte) {
+// Drain tells every client that the server is stopping: behind the messages
+// already queued for it, each client gets a "server_shutdown" message and a
+// close frame with code 1001 (going away). Drain then waits until all clients
+// have unregistered or ctx is done, and returns the number still registered.
+// Run must keep running until Drain returns, to process the unregistrations;
+// stop accepting new connections first.
+func (h *Hub) Drain(ctx context.Context) int {
+	data, ok := marshalOrLog("server_shutdown message", NewServerShutdownMessage())
+	m := &outgoing{text: data}
+	h.mu.RLock()
+	for client := range h.clients {
+		conn := client.conn
+		opcode, frame, framed := gws.OpcodeText, data, ok
+		if ok {
+			opcode, frame, framed = m.frame(client.binary)
+		}
+		conn.Async(func() {
+			if framed {
+				_ = conn.WriteMessage(opcode, frame)
+			}
+			_ = conn.WriteClose(1001, []byte("server shutting down"))
+		})
+	}
+	h.mu.RUnlock()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		h.mu.RLock()
+		n := len(h.clients)
+		h.mu.RUnlock()
+		if n == 0 || ctx.Err() != nil {
+			return n
+		}
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueue queues m for fan-out by Run, dropping it if the queue is full.
+func (h *Hub) enqueue(m broadcastMsg) {
+	select {
+	case h.broadcast <- m:
+	default:
+		h.stats.queueDrops.Add(1)
+		slog.Debug("hub broadcast queue full, dropping message", "capacity", cap(h.broadcast))
+	}
+}
+
+// fanOutPlayer delivers msg to all clients with matching player index.
+func (h *Hub) fanOutPlayer(msg []byte, playerIndex int) {
+	m := &outgoing{text: msg}
+	h.stats.broadcasts.Add(1)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.players[int32(playerIndex)] {
+		client.send(m)
+	}
+}
+
+// fanOutPlayerProfile delivers msg to the clients with matching player index
+// and output profile, through their update rate if u is set.
+func (h *Hub) fanOutPlayerProfile(msg []byte, u *stateUpdate, playerIndex int, profile string) {
+	m := &outgoing{text: msg}
+	h.stats.broadcasts.Add(1)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.players[int32(playerIndex)] {
+		if client.Profile() != profile {
+			continue
+		}
+		if u != nil {
+			client.sendState(m, u)
+		} else {
+			client.send(m)
+		}
+	}
+}
+
+// fanOutKeyMouse delivers msg to all keyboard/mouse subscribers.
+func (h *Hub) fanOutKeyMouse(msg []byte) {
+	m := &outgoing{text: msg}
+	h.stats.broadcasts.Add(1)
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
 	for client := range h.clients {
 		if client.wantsKeyMouse.Load() == 1 {
-			client.Send(msg)
+			client.send(m)
 		}
 	}
 }
 
+// fanOutGhost delivers msg to the ghost subscribers on profile.
+func (h *Hub) fanOutGhost(msg []byte, profile string) {
+	m := &outgoing{text: msg}
+	h.stats.broadcasts.Add(1)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.wantsGhost.Load() == 1 && client.Profile() == profile {
+			client.send(m)
+		}
+	}
+}
+
+// fanOutNotation delivers msg to the notation subscribers following
+// playerIndex.
+func (h *Hub) fanOutNotation(msg []byte, playerIndex int) {
+	m := &outgoing{text: msg}
+	h.stats.broadcasts.Add(1)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.players[int32(playerIndex)] {
+		if client.wantsNotation.Load() == 1 {
+			client.send(m)
+		}
+	}
+}
+
+// fanOutAll delivers msg to every client.
+func (h *Hub) fanOutAll(msg []byte) {
+	m := &outgoing{text: msg}
+	h.stats.broadcasts.Add(1)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		client.send(m)
+	}
+}
+
 // Run starts the hub's main loop. Should be run in a goroutine.
 func (h *Hub) Run(ctx context.Context) {
+	var ping <-chan time.Time // nil (no keepalive) never fires
+	if h.pingInterval > 0 {
+		ticker := time.NewTicker(h.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case now := <-ping:
+			h.keepalive(now)
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
+			h.subscribeLocked(client)
 			h.mu.Unlock()
 			slog.Info("client connected", "total", len(h.clients))
 
@@ -73,9 +438,27 @@ func (h *Hub) Run(ctx context.Context) {
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
+				h.unsubscribeLocked(client)
 			}
+			client.stopRate()
 			h.mu.Unlock()
 			slog.Info("client disconnected", "total", len(h.clients))
+
+		case m := <-h.broadcast: // nil channel (no buffer) never fires
+			switch {
+			case m.all:
+				h.fanOutAll(m.data)
+			case m.keyMouse:
+				h.fanOutKeyMouse(m.data)
+			case m.ghost:
+				h.fanOutGhost(m.data, m.profile)
+			case m.notation:
+				h.fanOutNotation(m.data, m.playerIndex)
+			case m.byProfile:
+				h.fanOutPlayerProfile(m.data, m.update, m.playerIndex, m.profile)
+			default:
+				h.fanOutPlayer(m.data, m.playerIndex)
+			}
 		}
 	}
 }
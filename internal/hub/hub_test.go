@@ -0,0 +1,192 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lxzan/gws"
+)
+
+// drainClient records the message types and the close code a client receives.
+type drainClient struct {
+	gws.BuiltinEventHandler
+	types  chan string
+	closed chan uint16
+}
+
+func (c *drainClient) OnMessage(socket *gws.Conn, message *gws.Message) {
+	defer message.Close()
+	var msg WSMessage
+	if err := json.Unmarshal(message.Bytes(), &msg); err == nil {
+		c.types <- msg.Type
+	}
+}
+
+func (c *drainClient) OnClose(socket *gws.Conn, err error) {
+	var ce *gws.CloseError
+	if errors.As(err, &ce) {
+		c.closed <- ce.Code
+	} else {
+		c.closed <- 0
+	}
+}
+
+func TestDrain(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := NewHub()
+	go h.Run(ctx)
+
+	upgrader := gws.NewUpgrader(&benchServerHandler{hub: h}, nil)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		socket, err := upgrader.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		go socket.ReadLoop()
+	}))
+	defer ts.Close()
+
+	c := &drainClient{types: make(chan string, 4), closed: make(chan uint16, 1)}
+	conn, _, err := gws.NewClient(c, &gws.ClientOption{Addr: "ws" + strings.TrimPrefix(ts.URL, "http")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go conn.ReadLoop()
+	waitForClients(t, h, 1)
+
+	// A message queued before Drain must arrive before server_shutdown.
+	h.BroadcastAll([]byte(`{"type":"full","seq":1,"timestamp":0}`))
+	drainCtx, drainCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer drainCancel()
+	if n := h.Drain(drainCtx); n != 0 {
+		t.Fatalf("Drain() = %d clients left, want 0", n)
+	}
+
+	for _, want := range []string{"full", "server_shutdown"} {
+		select {
+		case got := <-c.types:
+			if got != want {
+				t.Fatalf("message type = %q, want %q", got, want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+	select {
+	case code := <-c.closed:
+		if code != 1001 {
+			t.Errorf("close code = %d, want 1001", code)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the close frame")
+	}
+}
+
+// silentClient never answers pings, like a browser that went away without a
+// close frame.
+type silentClient struct {
+	drainClient
+}
+
+func (c *silentClient) OnPing(socket *gws.Conn, payload []byte) {}
+
+func TestKeepalive(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := NewHub()
+	h.SetKeepalive(50*time.Millisecond, 50*time.Millisecond)
+	go h.Run(ctx)
+
+	upgrader := gws.NewUpgrader(&benchServerHandler{hub: h}, nil)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		socket, err := upgrader.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		go socket.ReadLoop()
+	}))
+	defer ts.Close()
+
+	addr := "ws" + strings.TrimPrefix(ts.URL, "http")
+	alive := &drainClient{types: make(chan string, 4), closed: make(chan uint16, 1)}
+	silent := &silentClient{drainClient{types: make(chan string, 4), closed: make(chan uint16, 1)}}
+	for _, c := range []gws.Event{alive, silent} {
+		conn, _, err := gws.NewClient(c, &gws.ClientOption{Addr: addr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.NetConn().Close()
+		go conn.ReadLoop()
+	}
+	waitForClients(t, h, 2)
+
+	select {
+	case code := <-silent.closed:
+		if code != 1001 {
+			t.Errorf("close code = %d, want 1001", code)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("the client that does not answer pings was not closed")
+	}
+	waitForClients(t, h, 1)
+
+	// The client that answers pings stays connected.
+	time.Sleep(300 * time.Millisecond)
+	select {
+	case <-alive.closed:
+		t.Fatal("the client that answers pings was closed")
+	default:
+	}
+	if n := h.ClientCount(); n != 1 {
+		t.Errorf("ClientCount() = %d, want 1", n)
+	}
+}
+
+// TestPlayerTopics verifies that clients are kept in the topic of the player
+// they follow, before and after registering, and leave it on unregister.
+func TestPlayerTopics(t *testing.T) {
+	h := NewHub()
+	go h.Run(t.Context())
+	topic := func(pi int32) int {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		return len(h.players[pi])
+	}
+
+	a, b := NewClient(h, nil), NewClient(h, nil)
+	b.SetPlayerIndex(2) // before registering
+	h.Register(a)
+	h.Register(b)
+	waitForClients(t, h, 2)
+	if topic(1) != 1 || topic(2) != 1 {
+		t.Fatalf("topics = %d/%d, want 1/1", topic(1), topic(2))
+	}
+	a.SetPlayerIndex(2)
+	if topic(1) != 0 || topic(2) != 2 {
+		t.Errorf("after select_player topics = %d/%d, want 0/2", topic(1), topic(2))
+	}
+	h.Unregister(b)
+	waitForClients(t, h, 1)
+	if topic(2) != 1 {
+		t.Errorf("after unregister player 2 topic = %d, want 1", topic(2))
+	}
+	h.mu.RLock()
+	if _, ok := h.players[1]; ok {
+		t.Error("empty player 1 topic kept")
+	}
+	h.mu.RUnlock()
+}
@@ -0,0 +1,69 @@
+package hub
+
+import (
+	"context"
+	"time"
+)
+
+// idleCheckInterval is how often WatchIdle looks at the clients and input.
+// It bounds how late a sleeping server wakes up.
+const idleCheckInterval = time.Second
+
+// WatchIdle calls onIdle once the hub has had no clients and b no input for
+// timeout, and onWake as soon as either returns; then it waits for the next
+// idle period. It checks every idleCheckInterval until ctx is done.
+func WatchIdle(ctx context.Context, h *Hub, b *Broadcaster, timeout time.Duration, onIdle, onWake func()) {
+	w := idleWatch{timeout: timeout, busy: time.Now()}
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			switch w.check(now, b.LastInput(), h.ClientCount()) {
+			case idleEntered:
+				onIdle()
+			case idleLeft:
+				onWake()
+			}
+		}
+	}
+}
+
+// idleChange is the result of idleWatch.check.
+type idleChange int
+
+const (
+	idleUnchanged idleChange = iota
+	idleEntered
+	idleLeft
+)
+
+// idleWatch tracks whether the server has been unused for timeout.
+type idleWatch struct {
+	timeout time.Duration
+	busy    time.Time // last check that saw a client connected
+	idle    bool
+}
+
+// check updates the idle state at now, given the time of the last input and
+// the number of connected clients.
+func (w *idleWatch) check(now, lastInput time.Time, clients int) idleChange {
+	if clients > 0 {
+		w.busy = now
+	}
+	last := w.busy
+	if lastInput.After(last) {
+		last = lastInput
+	}
+	idle := now.Sub(last) >= w.timeout
+	if idle == w.idle {
+		return idleUnchanged
+	}
+	w.idle = idle
+	if idle {
+		return idleEntered
+	}
+	return idleLeft
+}
@@ -0,0 +1,37 @@
+package hub
+
+import (
+	"testing"
+	"time"
+)
+
+// TestIdleWatch verifies that the server goes idle only after timeout without
+// both input and clients, and wakes on either.
+func TestIdleWatch(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	w := idleWatch{timeout: 10 * time.Minute, busy: t0}
+	at := func(d time.Duration) time.Time { return t0.Add(d) }
+
+	steps := []struct {
+		name      string
+		now       time.Duration
+		lastInput time.Duration
+		clients   int
+		want      idleChange
+	}{
+		{"before the timeout", 9 * time.Minute, 0, 0, idleUnchanged},
+		{"timeout reached", 10 * time.Minute, 0, 0, idleEntered},
+		{"still idle", 11 * time.Minute, 0, 0, idleUnchanged},
+		{"client connects", 12 * time.Minute, 0, 1, idleLeft},
+		{"client leaves, timer restarts", 21 * time.Minute, 0, 0, idleUnchanged},
+		{"idle again", 22 * time.Minute, 0, 0, idleEntered},
+		{"input wakes", 23 * time.Minute, 23 * time.Minute, 0, idleLeft},
+		{"input keeps it awake", 32 * time.Minute, 23 * time.Minute, 0, idleUnchanged},
+		{"idle after the input", 33 * time.Minute, 23 * time.Minute, 0, idleEntered},
+	}
+	for _, s := range steps {
+		if got := w.check(at(s.now), at(s.lastInput), s.clients); got != s.want {
+			t.Errorf("%s: check() = %d, want %d", s.name, got, s.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package hub
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/lxzan/gws"
+)
+
+// closeTimeout bounds the write of a close frame (and any write it waits
+// behind), so a client that stopped reading cannot hold up the hub.
+const closeTimeout = time.Second
+
+// SetKeepalive makes Run ping every client each interval and close, with
+// code 1001 (going away), a client that has sent no frame, not even a pong,
+// for interval + timeout. A browser that was closed without a close frame
+// (an OBS browser source that was removed, a laptop that went to sleep)
+// would otherwise stay registered until the OS gives up on the socket.
+// interval 0 (the default) disables the pings. Must be called before Run.
+func (h *Hub) SetKeepalive(interval, timeout time.Duration) {
+	h.pingInterval = max(interval, 0)
+	h.pingTimeout = max(timeout, 0)
+}
+
+// Seen records that a frame (a message, ping, or pong) arrived from the
+// client, which keeps it from being closed by the keepalive (see
+// Hub.SetKeepalive). Safe to call from any goroutine.
+func (c *Client) Seen() {
+	c.lastSeen.Store(time.Now().UnixNano())
+}
+
+// keepalive pings every client and closes those that have not been seen for
+// pingInterval + pingTimeout.
+func (h *Hub) keepalive(now time.Time) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	limit := h.pingInterval + h.pingTimeout
+	for client := range h.clients {
+		if idle := now.Sub(time.Unix(0, client.lastSeen.Load())); idle >= limit {
+			slog.Info("closing unresponsive client", "remote", client.RemoteAddr(), "idle", idle.Round(time.Millisecond))
+			client.close(1001, "ping timeout")
+			continue
+		}
+		client.conn.WriteAsync(gws.OpcodePing, nil, nil)
+	}
+}
+
+// close sends the client a close frame with code and reason and closes the
+// connection, without blocking: writes still pending on a client that stopped
+// reading fail after closeTimeout. The client unregisters as usual once its
+// read loop ends.
+func (c *Client) close(code uint16, reason string) {
+	_ = c.conn.SetDeadline(time.Now().Add(closeTimeout))
+	go func() { _ = c.conn.WriteClose(code, []byte(reason)) }()
+}
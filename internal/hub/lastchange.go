@@ -0,0 +1,126 @@
+package hub
+
+import (
+	"math"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// analogChangeThreshold matches the threshold gamepad.ComputeDelta uses, so
+// an axis has a new timestamp exactly when a delta would report it.
+const analogChangeThreshold = 0.01
+
+// ButtonTimes holds last-change timestamps for the buttons in gamepad.ButtonState.
+type ButtonTimes struct {
+	A        int64 `json:"a"`
+	B        int64 `json:"b"`
+	X        int64 `json:"x"`
+	Y        int64 `json:"y"`
+	LB       int64 `json:"lb"`
+	RB       int64 `json:"rb"`
+	Back     int64 `json:"back"`
+	Start    int64 `json:"start"`
+	Guide    int64 `json:"guide"`
+	Touchpad int64 `json:"touchpad"`
+	Capture  int64 `json:"capture"`
+}
+
+// ExtraTimes holds last-change timestamps for the buttons in gamepad.ExtraButtonState.
+type ExtraTimes struct {
+	P1  int64 `json:"p1"`
+	P2  int64 `json:"p2"`
+	P3  int64 `json:"p3"`
+	P4  int64 `json:"p4"`
+	Fn1 int64 `json:"fn1"`
+	Fn2 int64 `json:"fn2"`
+}
+
+// DpadTimes holds last-change timestamps for the directions in gamepad.DpadState.
+type DpadTimes struct {
+	Up    int64 `json:"up"`
+	Down  int64 `json:"down"`
+	Left  int64 `json:"left"`
+	Right int64 `json:"right"`
+}
+
+// StickTimes holds last-change timestamps for one analog stick.
+type StickTimes struct {
+	Position int64 `json:"position"` // either axis moved
+	Pressed  int64 `json:"pressed"`  // click pressed or released
+}
+
+// SticksTimes holds last-change timestamps for both sticks.
+type SticksTimes struct {
+	Left  StickTimes `json:"left"`
+	Right StickTimes `json:"right"`
+}
+
+// TriggersTimes holds last-change timestamps for both triggers.
+type TriggersTimes struct {
+	LT int64 `json:"lt"`
+	RT int64 `json:"rt"`
+}
+
+// LastChanged holds, for every control of one player, the Unix timestamp in
+// milliseconds of its last change (press or release, or analog movement of at
+// least the delta threshold). 0 means unchanged since the server started.
+type LastChanged struct {
+	Buttons  ButtonTimes   `json:"buttons"`
+	Extra    ExtraTimes    `json:"extra"`
+	Dpad     DpadTimes     `json:"dpad"`
+	Sticks   SticksTimes   `json:"sticks"`
+	Triggers TriggersTimes `json:"triggers"`
+}
+
+// stamp sets *t to now if changed.
+func stamp(t *int64, changed bool, now int64) {
+	if changed {
+		*t = now
+	}
+}
+
+// analogChanged reports whether an axis moved by at least analogChangeThreshold.
+func analogChanged(old, new_ float64) bool {
+	return math.Abs(old-new_) >= analogChangeThreshold
+}
+
+// update stamps every control that differs between two consecutive states of
+// the same player with now.
+func (l *LastChanged) update(old, new_ gamepad.GamepadState, now int64) {
+	ob, nb := old.Buttons, new_.Buttons
+	stamp(&l.Buttons.A, ob.A != nb.A, now)
+	stamp(&l.Buttons.B, ob.B != nb.B, now)
+	stamp(&l.Buttons.X, ob.X != nb.X, now)
+	stamp(&l.Buttons.Y, ob.Y != nb.Y, now)
+	stamp(&l.Buttons.LB, ob.LB != nb.LB, now)
+	stamp(&l.Buttons.RB, ob.RB != nb.RB, now)
+	stamp(&l.Buttons.Back, ob.Back != nb.Back, now)
+	stamp(&l.Buttons.Start, ob.Start != nb.Start, now)
+	stamp(&l.Buttons.Guide, ob.Guide != nb.Guide, now)
+	stamp(&l.Buttons.Touchpad, ob.Touchpad != nb.Touchpad, now)
+	stamp(&l.Buttons.Capture, ob.Capture != nb.Capture, now)
+
+	oe, ne := old.Extra, new_.Extra
+	stamp(&l.Extra.P1, oe.P1 != ne.P1, now)
+	stamp(&l.Extra.P2, oe.P2 != ne.P2, now)
+	stamp(&l.Extra.P3, oe.P3 != ne.P3, now)
+	stamp(&l.Extra.P4, oe.P4 != ne.P4, now)
+	stamp(&l.Extra.Fn1, oe.Fn1 != ne.Fn1, now)
+	stamp(&l.Extra.Fn2, oe.Fn2 != ne.Fn2, now)
+
+	od, nd := old.Dpad, new_.Dpad
+	stamp(&l.Dpad.Up, od.Up != nd.Up, now)
+	stamp(&l.Dpad.Down, od.Down != nd.Down, now)
+	stamp(&l.Dpad.Left, od.Left != nd.Left, now)
+	stamp(&l.Dpad.Right, od.Right != nd.Right, now)
+
+	stick := func(t *StickTimes, o, n gamepad.StickState) {
+		stamp(&t.Position, analogChanged(o.Position.X, n.Position.X) || analogChanged(o.Position.Y, n.Position.Y), now)
+		stamp(&t.Pressed, o.Pressed != n.Pressed, now)
+	}
+	stick(&l.Sticks.Left, old.Sticks.Left, new_.Sticks.Left)
+	stick(&l.Sticks.Right, old.Sticks.Right, new_.Sticks.Right)
+
+	stamp(&l.Triggers.LT, analogChanged(old.Triggers.LT.Value, new_.Triggers.LT.Value), now)
+	stamp(&l.Triggers.RT, analogChanged(old.Triggers.RT.Value, new_.Triggers.RT.Value), now)
+}
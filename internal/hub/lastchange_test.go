@@ -0,0 +1,70 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+func TestLastChanged(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	if got := b.LastChanged(1); got != nil {
+		t.Fatalf("LastChanged(1) before any state = %+v, want nil", got)
+	}
+
+	p1 := fixtureXboxState()
+	pressedA := p1
+	pressedA.Buttons.A = true
+	moved := pressedA
+	moved.Sticks.Left.Position.X = 0.5
+	nudged := moved
+	nudged.Sticks.Left.Position.X += analogChangeThreshold / 2 // below the delta threshold
+	released := nudged
+	released.Buttons.A = false
+	released.Triggers.RT.Value = 1
+	p2 := gamepad.GamepadState{Connected: true, PlayerIndex: 2}
+	p2.Dpad.Left = true
+
+	track := func(s gamepad.GamepadState, now int64) {
+		b.mu.Lock()
+		b.trackLocked(s, now)
+		b.mu.Unlock()
+	}
+	track(p1, 100)
+	track(pressedA, 200)
+	track(moved, 300)
+	track(p2, 350) // other player: must not touch player 1
+	track(nudged, 400)
+	track(released, 500)
+
+	l1 := b.LastChanged(1)
+	if l1 == nil {
+		t.Fatal("LastChanged(1) = nil")
+	}
+	if l1.Buttons.A != 500 {
+		t.Errorf("Buttons.A = %d, want 500 (released)", l1.Buttons.A)
+	}
+	if l1.Sticks.Left.Position != 300 {
+		t.Errorf("Sticks.Left.Position = %d, want 300 (nudge below threshold ignored)", l1.Sticks.Left.Position)
+	}
+	if l1.Triggers.RT != 500 {
+		t.Errorf("Triggers.RT = %d, want 500", l1.Triggers.RT)
+	}
+	if l1.Buttons.B != 0 || l1.Dpad.Left != 0 || l1.Sticks.Left.Pressed != 0 {
+		t.Errorf("untouched controls = %+v, want 0", *l1)
+	}
+	if l2 := b.LastChanged(2); l2 == nil || l2.Dpad.Left != 350 {
+		t.Errorf("player 2 LastChanged = %+v, want dpad.left=350", l2)
+	}
+
+	// Full messages carry copies of both sections for the state's player.
+	b.mu.Lock()
+	msg := b.fullMessageLocked(7, released)
+	b.mu.Unlock()
+	if msg.Counters == nil || msg.Counters.Buttons.A != 1 || msg.LastChanged == nil || msg.LastChanged.Buttons.A != 500 {
+		t.Errorf("fullMessageLocked counters=%+v lastChanged=%+v", msg.Counters, msg.LastChanged)
+	}
+	if msg := NewFullMessage(1, &released); msg.Counters != nil || msg.LastChanged != nil {
+		t.Error("NewFullMessage must not attach counters or lastChanged")
+	}
+}
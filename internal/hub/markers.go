@@ -0,0 +1,122 @@
+package hub
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/soar/inputview/internal/marker"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// comboNames maps the control names accepted by ParseCombo to PressEdges
+// naming.
+var comboNames = map[string]string{
+	"a": "buttons.a", "b": "buttons.b", "x": "buttons.x", "y": "buttons.y",
+	"lb": "buttons.lb", "rb": "buttons.rb",
+	"back": "buttons.back", "start": "buttons.start", "guide": "buttons.guide",
+	"touchpad": "buttons.touchpad", "capture": "buttons.capture",
+	"up": "dpad.up", "down": "dpad.down", "left": "dpad.left", "right": "dpad.right",
+	"ls": "sticks.left", "rs": "sticks.right",
+}
+
+// MarkerCombo drops a marker named Name when all its Controls are held.
+type MarkerCombo struct {
+	Name     string
+	Controls []string // PressEdges naming
+}
+
+// ParseCombo parses a button combo such as "back+rb": control names joined by
+// "+", case-insensitive (a b x y lb rb back start guide touchpad capture up
+// down left right ls rs).
+func ParseCombo(s string) ([]string, error) {
+	var controls []string
+	for part := range strings.SplitSeq(s, "+") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		c, ok := comboNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown control %q in combo %q", name, s)
+		}
+		if slices.Contains(controls, c) {
+			return nil, fmt.Errorf("control %q twice in combo %q", name, s)
+		}
+		controls = append(controls, c)
+	}
+	return controls, nil
+}
+
+// SetMarkers keeps the markers dropped with combos and AddMarker in l, and
+// announces each to every client as a "marker_added" message. nil (the
+// default) disables markers. Call before Run.
+func (b *Broadcaster) SetMarkers(l *marker.List, combos []MarkerCombo) {
+	b.mu.Lock()
+	b.markers = l
+	b.markerCombos = combos
+	b.mu.Unlock()
+}
+
+// AddMarker drops a marker (see marker.List.Add) and announces it. Safe to
+// call from any goroutine.
+func (b *Broadcaster) AddMarker(name, source string, playerIndex int) marker.Marker {
+	b.mu.Lock()
+	l := b.markers
+	b.mu.Unlock()
+	m := l.Add(name, source, playerIndex)
+	if l != nil {
+		if data, ok := marshalOrLog("marker_added message", NewMarkerMessage(&m)); ok {
+			b.hub.BroadcastAll(data)
+		}
+	}
+	return m
+}
+
+// Markers returns the markers dropped so far, oldest first (never nil).
+func (b *Broadcaster) Markers() []marker.Marker {
+	b.mu.Lock()
+	l := b.markers
+	b.mu.Unlock()
+	return l.All()
+}
+
+// combosLocked returns the marker combos state completes (see
+// comboEdgesLocked). b.mu must be held, and state not yet tracked.
+func (b *Broadcaster) combosLocked(state gamepad.GamepadState) []MarkerCombo {
+	if len(b.markerCombos) == 0 {
+		return nil
+	}
+	pressed, held := b.comboEdgesLocked(state)
+	var fired []MarkerCombo
+	for _, c := range b.markerCombos {
+		if completes(c.Controls, pressed, held) {
+			fired = append(fired, c)
+		}
+	}
+	return fired
+}
+
+// comboEdgesLocked returns the controls state just pressed, against its
+// player's previous state, and all controls it holds. b.mu must be held, and
+// state not yet tracked.
+func (b *Broadcaster) comboEdgesLocked(state gamepad.GamepadState) (pressed, held []string) {
+	var old gamepad.GamepadState
+	if ps, ok := b.players[state.PlayerIndex]; ok {
+		old = ps.last
+	}
+	pressed = PressEdges(old, state)
+	if len(pressed) == 0 {
+		return nil, nil
+	}
+	return pressed, PressEdges(gamepad.GamepadState{}, state)
+}
+
+// completes reports whether a combo of controls is completed: all of them
+// are held and at least one was just pressed, so holding a combo fires it
+// once.
+func completes(controls, pressed, held []string) bool {
+	return slices.ContainsFunc(controls, func(c string) bool { return slices.Contains(pressed, c) }) && holds(controls, held)
+}
+
+// holds reports whether all controls of a combo are among held.
+func holds(controls, held []string) bool {
+	return !slices.ContainsFunc(controls, func(c string) bool { return !slices.Contains(held, c) })
+}
@@ -0,0 +1,51 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestMarkerCombos verifies combo parsing and that a combo fires once, when
+// its last control is pressed.
+func TestMarkerCombos(t *testing.T) {
+	controls, err := ParseCombo("Back + RB")
+	if err != nil || len(controls) != 2 || controls[0] != "buttons.back" || controls[1] != "buttons.rb" {
+		t.Fatalf("ParseCombo() = %v, %v", controls, err)
+	}
+	for _, bad := range []string{"back+zl", "a+a", ""} {
+		if _, err := ParseCombo(bad); err == nil {
+			t.Errorf("ParseCombo(%q): got nil error", bad)
+		}
+	}
+
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetMarkers(nil, []MarkerCombo{{Name: "highlight", Controls: controls}})
+	idle := fixtureXboxState()
+	back, both := idle, idle
+	back.Buttons.Back = true
+	both.Buttons.Back, both.Buttons.RB = true, true
+	bothA := both
+	bothA.Buttons.A = true
+
+	steps := []struct {
+		name  string
+		state gamepad.GamepadState
+		fires bool
+	}{
+		{"back", back, false},
+		{"back + rb", both, true},
+		{"still held, A pressed", bothA, false},
+		{"released", idle, false},
+		{"both at once", both, true},
+	}
+	for _, step := range steps {
+		b.mu.Lock()
+		fired := b.combosLocked(step.state)
+		b.stateMessageLocked(step.state, 1000)
+		b.mu.Unlock()
+		if got := len(fired) == 1; got != step.fires {
+			t.Errorf("%s: fired %v, want %v", step.name, fired, step.fires)
+		}
+	}
+}
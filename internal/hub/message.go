@@ -1,22 +1,45 @@
 package hub
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
 	"time"
 
-	"github.com/soar/inputview/internal/gamepad"
 	"github.com/soar/inputview/internal/input"
+	"github.com/soar/inputview/internal/marker"
+	"github.com/soar/inputview/pkg/gamepad"
 )
 
 // WSMessage represents a WebSocket message sent from server to client.
 type WSMessage struct {
-	Type        string                `json:"type"`                  // Message type: "full", "delta", "player_selected", "km_full", "km_delta"
+	Type        string                `json:"type"`                  // Message type: "full", "delta", "player_selected", "km_full", "km_delta", "power_changed", "controller_connected", "controller_disconnected", "controller_switched", "controller_drift", "profile_selected", "server_shutdown", "input_diff", "ghost_state", "marker_added", "time_sync", "freeze_changed", "plugin_event", "analog", "combo", "notation", "stats"
 	Seq         int64                 `json:"seq"`                   // Sequence number for ordering
 	Timestamp   int64                 `json:"timestamp"`             // Unix timestamp in milliseconds
-	Data        *gamepad.GamepadState `json:"data,omitempty"`        // Full gamepad state for type "full"
+	Mono        int64                 `json:"mono"`                  // Monotonic server clock in microseconds since start (see TimeSync)
+	Data        *gamepad.GamepadState `json:"data,omitempty"`        // Full gamepad state for types "full" and "ghost_state"
 	Changes     *gamepad.DeltaChanges `json:"changes,omitempty"`     // Delta changes for type "delta"
-	PlayerIndex int                   `json:"playerIndex,omitempty"` // Player index for type "player_selected"
+	PlayerIndex int                   `json:"playerIndex,omitempty"` // Player index for types "player_selected", "power_changed", "input_diff", "combo", "notation", and "stats"
 	KMState     *input.KeyMouseState  `json:"kmState,omitempty"`     // Full keyboard/mouse state for type "km_full"
 	KMDelta     *input.KeyMouseDelta  `json:"kmDelta,omitempty"`     // Keyboard/mouse delta for type "km_delta"
+	Counters    *PressCounters        `json:"counters,omitempty"`    // Press counts since server start for type "full"
+	LastChanged *LastChanged          `json:"lastChanged,omitempty"` // Per-control last-change timestamps for type "full"
+	Power       *PowerEvent           `json:"power,omitempty"`       // Power state change for type "power_changed"
+	Device      *DeviceEvent          `json:"device,omitempty"`      // Controller and reason for types "controller_connected" / "controller_disconnected" / "controller_switched" / "controller_drift"
+	Profile     string                `json:"profile,omitempty"`     // Output profile for type "profile_selected"; omitted = untransformed
+	Diff        *InputDiff            `json:"diff,omitempty"`        // Divergence from the reference track for type "input_diff"
+	Ghost       *GhostInfo            `json:"ghost,omitempty"`       // Playback position of the ghost state for type "ghost_state"
+	Marker      *marker.Marker        `json:"marker,omitempty"`      // The dropped marker for type "marker_added"
+	Sync        *TimeSync             `json:"sync,omitempty"`        // Clock offset exchange for type "time_sync"
+	Freeze      *FreezeInfo           `json:"freeze,omitempty"`      // The new freeze state for type "freeze_changed"
+	Plugin      *PluginEvent          `json:"plugin,omitempty"`      // The event a plugin injected for type "plugin_event"
+	Analog      *AnalogFrame          `json:"analog,omitempty"`      // Stick, trigger, and motion values for type "analog"
+	Combo       *ComboEvent           `json:"combo,omitempty"`       // The entered input sequence for type "combo"
+	Notation    *NotationEntry        `json:"notation,omitempty"`    // The player's new input in numpad notation for type "notation"
+	Stats       *SessionStats         `json:"stats,omitempty"`       // The player's session statistics for type "stats"
 }
 
 // NewFullMessage creates a "full" type message containing complete gamepad state.
@@ -25,6 +48,7 @@ func NewFullMessage(seq int64, state *gamepad.GamepadState) *WSMessage {
 		Type:      "full",
 		Seq:       seq,
 		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
 		Data:      state,
 	}
 }
@@ -35,26 +59,63 @@ func NewDeltaMessage(seq int64, changes *gamepad.DeltaChanges) *WSMessage {
 		Type:      "delta",
 		Seq:       seq,
 		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
 		Changes:   changes,
 	}
 }
 
+// NewAnalogMessage creates an "analog" message carrying the analog inputs of
+// the state a delta brings the stream to, for clients in AnalogSplit mode.
+func NewAnalogMessage(seq int64, f *AnalogFrame) *WSMessage {
+	return &WSMessage{
+		Type:      "analog",
+		Seq:       seq,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
+		Analog:    f,
+	}
+}
+
 // NewPlayerSelectedMessage creates a "player_selected" confirmation message.
 func NewPlayerSelectedMessage(playerIndex int) *WSMessage {
 	return &WSMessage{
 		Type:        "player_selected",
 		Seq:         0,
 		Timestamp:   time.Now().UnixMilli(),
+		Mono:        monoNow(),
 		PlayerIndex: playerIndex,
 	}
 }
 
+// NewProfileSelectedMessage creates a "profile_selected" confirmation message.
+func NewProfileSelectedMessage(profile string) *WSMessage {
+	return &WSMessage{
+		Type:      "profile_selected",
+		Seq:       0,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
+		Profile:   profile,
+	}
+}
+
+// NewServerShutdownMessage creates a "server_shutdown" message, the last
+// message before the server closes the connection on shutdown (see Hub.Drain).
+func NewServerShutdownMessage() *WSMessage {
+	return &WSMessage{
+		Type:      "server_shutdown",
+		Seq:       0,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
+	}
+}
+
 // NewKMFullMessage creates a "km_full" message with the complete keyboard/mouse state.
 func NewKMFullMessage(seq int64, state *input.KeyMouseState) *WSMessage {
 	return &WSMessage{
 		Type:      "km_full",
 		Seq:       seq,
 		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
 		KMState:   state,
 	}
 }
@@ -65,13 +126,278 @@ func NewKMDeltaMessage(seq int64, delta *input.KeyMouseDelta) *WSMessage {
 		Type:      "km_delta",
 		Seq:       seq,
 		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
 		KMDelta:   delta,
 	}
 }
 
+// NewPowerMessage creates a "power_changed" event message. Like
+// "player_selected" it is outside the state stream and has seq 0.
+func NewPowerMessage(playerIndex int, ev *PowerEvent) *WSMessage {
+	return &WSMessage{
+		Type:        "power_changed",
+		Seq:         0,
+		Timestamp:   time.Now().UnixMilli(),
+		Mono:        monoNow(),
+		PlayerIndex: playerIndex,
+		Power:       ev,
+	}
+}
+
+// NewInputDiffMessage creates an "input_diff" event message (seq 0, outside
+// the state stream) for the viewers of playerIndex.
+func NewInputDiffMessage(playerIndex int, d *InputDiff) *WSMessage {
+	return &WSMessage{
+		Type:        "input_diff",
+		Seq:         0,
+		Timestamp:   time.Now().UnixMilli(),
+		Mono:        monoNow(),
+		PlayerIndex: playerIndex,
+		Diff:        d,
+	}
+}
+
+// NewGhostStateMessage creates a "ghost_state" message with the full state of
+// the ghost replay (see Broadcaster.SetGhost). seq numbers the ghost stream,
+// separately from the live state stream.
+func NewGhostStateMessage(seq int64, state *gamepad.GamepadState, g *GhostInfo) *WSMessage {
+	return &WSMessage{
+		Type:      "ghost_state",
+		Seq:       seq,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
+		Data:      state,
+		Ghost:     g,
+	}
+}
+
+// NewMarkerMessage creates a "marker_added" event message (seq 0, outside the
+// state stream), sent to every client.
+func NewMarkerMessage(m *marker.Marker) *WSMessage {
+	return &WSMessage{
+		Type:      "marker_added",
+		Seq:       0,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
+		Marker:    m,
+	}
+}
+
+// NewFreezeMessage creates a "freeze_changed" event message (seq 0, outside
+// the state stream), sent to every client.
+func NewFreezeMessage(f *FreezeInfo) *WSMessage {
+	return &WSMessage{
+		Type:      "freeze_changed",
+		Seq:       0,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
+		Freeze:    f,
+	}
+}
+
+// NewPluginEventMessage creates a "plugin_event" event message (seq 0,
+// outside the state stream), sent to every client.
+func NewPluginEventMessage(ev *PluginEvent) *WSMessage {
+	return &WSMessage{
+		Type:      "plugin_event",
+		Seq:       0,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
+		Plugin:    ev,
+	}
+}
+
+// NewComboMessage creates a "combo" event message (seq 0, outside the state
+// stream) for the sequence playerIndex entered, sent to every client.
+func NewComboMessage(playerIndex int, ev *ComboEvent) *WSMessage {
+	return &WSMessage{
+		Type:        "combo",
+		Seq:         0,
+		Timestamp:   time.Now().UnixMilli(),
+		Mono:        monoNow(),
+		PlayerIndex: playerIndex,
+		Combo:       ev,
+	}
+}
+
+// NewNotationMessage creates a "notation" message (seq 0, outside the state
+// stream) with playerIndex's new notation entry, sent to the player's
+// viewers that sent "subscribe_notation".
+func NewNotationMessage(playerIndex int, e *NotationEntry) *WSMessage {
+	return &WSMessage{
+		Type:        "notation",
+		Seq:         0,
+		Timestamp:   time.Now().UnixMilli(),
+		Mono:        monoNow(),
+		PlayerIndex: playerIndex,
+		Notation:    e,
+	}
+}
+
+// NewStatsMessage creates a "stats" event message (seq 0, outside the state
+// stream) with the session statistics of a player, for its viewers.
+func NewStatsMessage(stats *SessionStats) *WSMessage {
+	return &WSMessage{
+		Type:        "stats",
+		Seq:         0,
+		Timestamp:   time.Now().UnixMilli(),
+		Mono:        monoNow(),
+		PlayerIndex: stats.PlayerIndex,
+		Stats:       stats,
+	}
+}
+
+// NewTimeSyncMessage creates the "time_sync" reply (seq 0, outside the state
+// stream) to a client's time_sync command read at the monotonic server clock
+// received.
+func NewTimeSyncMessage(clientTime float64, received int64) *WSMessage {
+	now := monoNow()
+	return &WSMessage{
+		Type:      "time_sync",
+		Seq:       0,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      now,
+		Sync:      &TimeSync{ClientTime: clientTime, ServerReceive: received, ServerSend: now},
+	}
+}
+
+// NewControllerMessage creates a "controller_connected",
+// "controller_disconnected", "controller_switched", or "controller_drift"
+// event message (seq 0, outside the state stream).
+func NewControllerMessage(ev gamepad.ControllerEvent) *WSMessage {
+	return &WSMessage{
+		Type:      "controller_" + ev.Type,
+		Seq:       0,
+		Timestamp: time.Now().UnixMilli(),
+		Mono:      monoNow(),
+		Device:    &DeviceEvent{Reason: ev.Reason, Controller: ev.Controller, Previous: ev.Previous, Drift: ev.Drift},
+	}
+}
+
 // ClientMessage represents a message sent from the client to the server.
 type ClientMessage struct {
 	Type        string  `json:"type"`
 	PlayerIndex int     `json:"playerIndex,omitempty"`
-	Value       float64 `json:"value,omitempty"` // Generic numeric value (e.g. mouse sensitivity)
+	Value       float64 `json:"value,omitempty"`      // Generic numeric value (e.g. mouse sensitivity, update rate)
+	Profile     string  `json:"profile,omitempty"`    // Output profile name for "select_profile"; "" = untransformed
+	ClientTime  float64 `json:"clientTime,omitempty"` // Client clock for "time_sync", echoed in the reply; microseconds recommended
+	Low         float64 `json:"low,omitempty"`        // Low-frequency motor strength (0-1) for "rumble"
+	High        float64 `json:"high,omitempty"`       // High-frequency motor strength (0-1) for "rumble"
+	Duration    int     `json:"duration,omitempty"`   // Rumble duration in milliseconds for "rumble"
+	Color       string  `json:"color,omitempty"`      // Lightbar color "#rrggbb" for "set_led"; "" = unchanged
+	PlayerLEDs  *int    `json:"playerLeds,omitempty"` // Player number (0-5, 0 = off) for the player LEDs of "set_led"; absent = unchanged
+	Enabled     *bool   `json:"enabled,omitempty"`    // Raw mode on or off for "set_raw_mode"; required
+	Analog      string  `json:"analog,omitempty"`     // Analog mode for "set_analog", one of AnalogModes; required
+	Fields      string  `json:"fields,omitempty"`     // Comma-separated Fields for "set_fields"; empty = all
+}
+
+// LEDs returns the lights a "set_led" message sets.
+func (m ClientMessage) LEDs() (gamepad.LEDs, error) {
+	return ParseLEDs(m.Color, m.PlayerLEDs)
+}
+
+// ParseLEDs validates the color ("#rrggbb", "" = unchanged) and player LEDs
+// (0-5, nil = unchanged) of a "set_led" message or LED request; at least one
+// must be set.
+func ParseLEDs(color string, playerLEDs *int) (gamepad.LEDs, error) {
+	var leds gamepad.LEDs
+	if color == "" && playerLEDs == nil {
+		return leds, errors.New(`color or playerLeds is required`)
+	}
+	if color != "" {
+		c, err := gamepad.ParseColor(color)
+		if err != nil {
+			return leds, err
+		}
+		leds.Color = &c
+	}
+	if playerLEDs != nil {
+		if *playerLEDs < 0 || *playerLEDs > gamepad.MaxPlayerLEDs {
+			return leds, fmt.Errorf("playerLeds must be in [0, %d], got %d", gamepad.MaxPlayerLEDs, *playerLEDs)
+		}
+		leds.Player = playerLEDs
+	}
+	return leds, nil
+}
+
+// maxProfileNameLen caps the profile name of "select_profile".
+const maxProfileNameLen = 64
+
+// maxClientMessageBytes caps the size of a client command message. Commands
+// are a few dozen bytes; anything this large is not a real client.
+const maxClientMessageBytes = 4 << 10
+
+// ParseClientMessage decodes and validates a client command message.
+// Oversized input, unknown fields, trailing data, unknown types, and
+// out-of-range values are rejected with a descriptive error.
+func ParseClientMessage(data []byte) (ClientMessage, error) {
+	if len(data) > maxClientMessageBytes {
+		return ClientMessage{}, fmt.Errorf("message too large: %d bytes (max %d)", len(data), maxClientMessageBytes)
+	}
+	var m ClientMessage
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return ClientMessage{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return ClientMessage{}, errors.New("invalid JSON: trailing data after message object")
+	}
+
+	switch m.Type {
+	case "":
+		return ClientMessage{}, errors.New(`missing "type"`)
+	case "select_player":
+		if m.PlayerIndex < 1 {
+			return ClientMessage{}, fmt.Errorf("select_player: playerIndex must be >= 1, got %d", m.PlayerIndex)
+		}
+	case "subscribe_km", "subscribe_ghost", "subscribe_notation", "time_sync", "request_full":
+	case "set_mouse_sens":
+		if m.Value <= 0 {
+			return ClientMessage{}, fmt.Errorf("set_mouse_sens: value must be > 0, got %g", m.Value)
+		}
+	case "set_rate":
+		if m.Value < 0 || m.Value > maxUpdateRate {
+			return ClientMessage{}, fmt.Errorf("set_rate: value must be in [0, %d], got %g", maxUpdateRate, m.Value)
+		}
+	case "set_analog":
+		if !slices.Contains(AnalogModes, m.Analog) {
+			return ClientMessage{}, fmt.Errorf("set_analog: analog must be one of %v, got %q", AnalogModes, m.Analog)
+		}
+	case "set_fields":
+		if _, err := ParseFields(m.Fields); err != nil {
+			return ClientMessage{}, fmt.Errorf("set_fields: %w", err)
+		}
+	case "rumble":
+		if m.PlayerIndex < 0 {
+			return ClientMessage{}, fmt.Errorf("rumble: playerIndex must be >= 0, got %d", m.PlayerIndex)
+		}
+		if !(m.Low >= 0 && m.Low <= 1) || !(m.High >= 0 && m.High <= 1) {
+			return ClientMessage{}, fmt.Errorf("rumble: low and high must be in [0, 1], got %g and %g", m.Low, m.High)
+		}
+		if maxMs := int(gamepad.MaxRumbleDuration / time.Millisecond); m.Duration < 1 || m.Duration > maxMs {
+			return ClientMessage{}, fmt.Errorf("rumble: duration must be in [1, %d] ms, got %d", maxMs, m.Duration)
+		}
+	case "set_led":
+		if m.PlayerIndex < 0 {
+			return ClientMessage{}, fmt.Errorf("set_led: playerIndex must be >= 0, got %d", m.PlayerIndex)
+		}
+		if _, err := m.LEDs(); err != nil {
+			return ClientMessage{}, fmt.Errorf("set_led: %w", err)
+		}
+	case "set_raw_mode":
+		if m.PlayerIndex < 0 {
+			return ClientMessage{}, fmt.Errorf("set_raw_mode: playerIndex must be >= 0, got %d", m.PlayerIndex)
+		}
+		if m.Enabled == nil {
+			return ClientMessage{}, errors.New(`set_raw_mode: missing "enabled"`)
+		}
+	case "select_profile":
+		if len(m.Profile) > maxProfileNameLen {
+			return ClientMessage{}, fmt.Errorf("select_profile: profile name too long: %d bytes (max %d)", len(m.Profile), maxProfileNameLen)
+		}
+	default:
+		return ClientMessage{}, fmt.Errorf("unknown message type %q", m.Type)
+	}
+	return m, nil
 }
@@ -0,0 +1,134 @@
+package hub
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestParseClientMessage verifies that valid commands decode and malformed or
+// out-of-range ones are rejected with a descriptive error.
+func TestParseClientMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    ClientMessage
+		wantErr string
+	}{
+		{"select player", `{"type":"select_player","playerIndex":2}`, ClientMessage{Type: "select_player", PlayerIndex: 2}, ""},
+		{"select profile", `{"type":"select_profile","profile":"vertical"}`, ClientMessage{Type: "select_profile", Profile: "vertical"}, ""},
+		{"reset profile", `{"type":"select_profile"}`, ClientMessage{Type: "select_profile"}, ""},
+		{"subscribe km", `{"type":"subscribe_km"}`, ClientMessage{Type: "subscribe_km"}, ""},
+		{"subscribe ghost", `{"type":"subscribe_ghost"}`, ClientMessage{Type: "subscribe_ghost"}, ""},
+		{"request full", `{"type":"request_full"}`, ClientMessage{Type: "request_full"}, ""},
+		{"time sync", `{"type":"time_sync","clientTime":8123456.5}`, ClientMessage{Type: "time_sync", ClientTime: 8123456.5}, ""},
+		{"mouse sens", `{"type":"set_mouse_sens","value":1.5}`, ClientMessage{Type: "set_mouse_sens", Value: 1.5}, ""},
+		{"update rate", `{"type":"set_rate","value":30}`, ClientMessage{Type: "set_rate", Value: 30}, ""},
+		{"every change", `{"type":"set_rate"}`, ClientMessage{Type: "set_rate"}, ""},
+		{"analog split", `{"type":"set_analog","analog":"split"}`, ClientMessage{Type: "set_analog", Analog: "split"}, ""},
+		{"fields", `{"type":"set_fields","fields":"buttons,dpad"}`, ClientMessage{Type: "set_fields", Fields: "buttons,dpad"}, ""},
+		{"all fields", `{"type":"set_fields"}`, ClientMessage{Type: "set_fields"}, ""},
+		{"rumble", `{"type":"rumble","playerIndex":2,"low":1,"high":0.5,"duration":500}`, ClientMessage{Type: "rumble", PlayerIndex: 2, Low: 1, High: 0.5, Duration: 500}, ""},
+		{"trailing whitespace", "{\"type\":\"subscribe_km\"}\n", ClientMessage{Type: "subscribe_km"}, ""},
+		{"malformed", `{"type":`, ClientMessage{}, "invalid JSON"},
+		{"not an object", `[1,2]`, ClientMessage{}, "invalid JSON"},
+		{"trailing data", `{"type":"subscribe_km"}{}`, ClientMessage{}, "trailing data"},
+		{"unknown field", `{"type":"subscribe_km","admin":true}`, ClientMessage{}, "unknown field"},
+		{"missing type", `{"playerIndex":1}`, ClientMessage{}, `missing "type"`},
+		{"unknown type", `{"type":"reboot"}`, ClientMessage{}, `unknown message type "reboot"`},
+		{"player zero", `{"type":"select_player"}`, ClientMessage{}, "playerIndex must be >= 1"},
+		{"player negative", `{"type":"select_player","playerIndex":-3}`, ClientMessage{}, "playerIndex must be >= 1"},
+		{"wrong value type", `{"type":"select_player","playerIndex":"1"}`, ClientMessage{}, "invalid JSON"},
+		{"sens zero", `{"type":"set_mouse_sens","value":0}`, ClientMessage{}, "value must be > 0"},
+		{"rumble too strong", `{"type":"rumble","low":1.5,"duration":500}`, ClientMessage{}, "low and high must be in [0, 1]"},
+		{"rumble no duration", `{"type":"rumble","low":1}`, ClientMessage{}, "duration must be in [1, 5000] ms"},
+		{"rumble too long", `{"type":"rumble","low":1,"duration":5001}`, ClientMessage{}, "duration must be in [1, 5000] ms"},
+		{"rumble negative player", `{"type":"rumble","playerIndex":-1,"low":1,"duration":500}`, ClientMessage{}, "playerIndex must be >= 0"},
+		{"led color only", `{"type":"set_led","color":"#FF8000"}`, ClientMessage{Type: "set_led", Color: "#FF8000"}, ""},
+		{"led nothing", `{"type":"set_led","playerIndex":1}`, ClientMessage{}, "color or playerLeds is required"},
+		{"led bad color", `{"type":"set_led","color":"orange"}`, ClientMessage{}, `color "orange" is not #rrggbb`},
+		{"led player too high", `{"type":"set_led","playerLeds":6}`, ClientMessage{}, "playerLeds must be in [0, 5]"},
+		{"raw mode no enabled", `{"type":"set_raw_mode","playerIndex":1}`, ClientMessage{}, `missing "enabled"`},
+		{"raw mode negative player", `{"type":"set_raw_mode","playerIndex":-1,"enabled":true}`, ClientMessage{}, "playerIndex must be >= 0"},
+		{"rate too high", `{"type":"set_rate","value":1001}`, ClientMessage{}, "value must be in [0, 1000]"},
+		{"analog unknown", `{"type":"set_analog","analog":"fast"}`, ClientMessage{}, `got "fast"`},
+		{"analog missing", `{"type":"set_analog"}`, ClientMessage{}, "analog must be one of"},
+		{"unknown field", `{"type":"set_fields","fields":"buttons,touchpad"}`, ClientMessage{}, `unknown field "touchpad"`},
+		{"profile too long", `{"type":"select_profile","profile":"` + strings.Repeat("p", maxProfileNameLen+1) + `"}`, ClientMessage{}, "profile name too long"},
+		{"too large", `{"type":"subscribe_km","value":` + strings.Repeat("1", maxClientMessageBytes) + `}`, ClientMessage{}, "message too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseClientMessage([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseClientMessage() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseClientMessage() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseClientMessage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// FuzzParseClientMessage checks that arbitrary client input never panics and
+// that anything accepted is a known command with in-range values.
+func FuzzParseClientMessage(f *testing.F) {
+	f.Add([]byte(`{"type":"select_player","playerIndex":1}`))
+	f.Add([]byte(`{"type":"subscribe_km"}`))
+	f.Add([]byte(`{"type":"set_mouse_sens","value":2.5}`))
+	f.Add([]byte(`{"type":""}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m, err := ParseClientMessage(data)
+		if err != nil {
+			return
+		}
+		switch m.Type {
+		case "select_player":
+			if m.PlayerIndex < 1 {
+				t.Fatalf("accepted select_player with playerIndex %d", m.PlayerIndex)
+			}
+		case "select_profile":
+			if len(m.Profile) > maxProfileNameLen {
+				t.Fatalf("accepted select_profile with a %d-byte name", len(m.Profile))
+			}
+		case "subscribe_km", "subscribe_ghost", "time_sync", "request_full":
+		case "set_mouse_sens":
+			if !(m.Value > 0) {
+				t.Fatalf("accepted set_mouse_sens with value %g", m.Value)
+			}
+		case "set_rate":
+			if !(m.Value >= 0 && m.Value <= maxUpdateRate) {
+				t.Fatalf("accepted set_rate with value %g", m.Value)
+			}
+		case "set_analog":
+			if !slices.Contains(AnalogModes, m.Analog) {
+				t.Fatalf("accepted set_analog with analog %q", m.Analog)
+			}
+		case "set_fields":
+			if _, err := ParseFields(m.Fields); err != nil {
+				t.Fatalf("accepted set_fields with fields %q", m.Fields)
+			}
+		default:
+			t.Fatalf("accepted unknown type %q", m.Type)
+		}
+	})
+}
+
+// TestTimeSyncMessage verifies the time_sync reply echoes the client clock
+// and stamps receive and send times on the message's monotonic clock.
+func TestTimeSyncMessage(t *testing.T) {
+	received := monoNow()
+	msg := NewTimeSyncMessage(42.5, received)
+	if msg.Type != "time_sync" || msg.Sync == nil || msg.Sync.ClientTime != 42.5 || msg.Sync.ServerReceive != received {
+		t.Fatalf("NewTimeSyncMessage() = %+v", msg)
+	}
+	if msg.Sync.ServerSend < received || msg.Mono != msg.Sync.ServerSend {
+		t.Errorf("serverSend = %d, mono = %d, want both = serverSend >= serverReceive %d", msg.Sync.ServerSend, msg.Mono, received)
+	}
+}
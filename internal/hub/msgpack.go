@@ -0,0 +1,495 @@
+package hub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings a client can receive messages in (see Client.SetEncoding).
+const (
+	EncodingJSON    = "json"    // text frames (the default)
+	EncodingMsgpack = "msgpack" // binary frames, MessagePack
+)
+
+// Encodings lists the accepted encoding names.
+var Encodings = []string{EncodingJSON, EncodingMsgpack}
+
+// wsMessageType is the Go type of every message sent to clients.
+var wsMessageType = reflect.TypeFor[WSMessage]()
+
+// EncodeMsgpack returns the MessagePack frame a binary client is sent for the
+// JSON message text (a marshalled WSMessage), e.g. to write binary protocol
+// fixtures.
+func EncodeMsgpack(text []byte) ([]byte, error) {
+	return jsonToMsgpack(text, wsMessageType)
+}
+
+// jsonToMsgpack transcodes a JSON message, marshalled from a value of type t,
+// to MessagePack with the same structure: objects become maps (keys in their
+// JSON order), numbers float64 where t has a float field, else integers the
+// smallest int format and other numbers float64. JSON does not keep 1.0 and 1
+// apart, so without t (nil, or below a field of interface or json.Marshaler
+// type) whole floats come out as ints. Messages are marshalled once as JSON and
+// transcoded for binary clients, so both encodings always agree.
+//
+// The transcode scans the JSON bytes in one pass against t's msgpackPlan
+// (built once per type), so it costs less than the JSON marshal it follows
+// (see BenchmarkEncode).
+func jsonToMsgpack(data []byte, t reflect.Type) ([]byte, error) {
+	// Floats grow from a few digits to 9 bytes, keys and literals shrink.
+	tc := msgpackTranscoder{data: data, out: make([]byte, 0, len(data)+len(data)/2)}
+	if err := tc.value(planFor(t)); err != nil {
+		return nil, err
+	}
+	if tc.skipSpace(); tc.pos < len(data) {
+		return nil, fmt.Errorf("msgpack: trailing data after JSON value")
+	}
+	return tc.out, nil
+}
+
+// msgpackPlan is what jsonToMsgpack needs to know of the Go type a JSON value
+// was marshalled from. A nil plan is an unknown type.
+type msgpackPlan struct {
+	float  bool                    // a float32/float64
+	fields map[string]*msgpackPlan // a struct: its fields by JSON name
+	elem   *msgpackPlan            // a slice, array, or map: its elements
+}
+
+// field returns the plan of the value under key in an object of plan p: the
+// struct field's, or the map's element plan.
+func (p *msgpackPlan) field(key []byte) *msgpackPlan {
+	switch {
+	case p == nil:
+		return nil
+	case p.fields != nil:
+		return p.fields[string(key)]
+	}
+	return p.elem
+}
+
+// jsonMarshalerType is the interface of types that marshal themselves, whose
+// JSON need not follow their Go type.
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+// msgpackPlans caches planFor.
+var msgpackPlans sync.Map // reflect.Type → *msgpackPlan
+
+// planFor returns the msgpackPlan of type t (nil for nil).
+func planFor(t reflect.Type) *msgpackPlan {
+	if t == nil {
+		return nil
+	}
+	if p, ok := msgpackPlans.Load(t); ok {
+		return p.(*msgpackPlan)
+	}
+	p := buildPlan(t, make(map[reflect.Type]*msgpackPlan))
+	msgpackPlans.Store(t, p)
+	return p
+}
+
+// buildPlan returns the msgpackPlan of t. seen holds the plans being built, so
+// recursive types end.
+func buildPlan(t reflect.Type, seen map[reflect.Type]*msgpackPlan) *msgpackPlan {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface || t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return nil
+	}
+	if p, ok := seen[t]; ok {
+		return p
+	}
+	p := &msgpackPlan{}
+	seen[t] = p
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		p.float = true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		p.elem = buildPlan(t.Elem(), seen)
+	case reflect.Struct:
+		p.fields = make(map[string]*msgpackPlan)
+		addPlanFields(p.fields, t, seen)
+	}
+	return p
+}
+
+// addPlanFields adds the fields of struct type t to fields by their JSON name,
+// as encoding/json names them, with the fields of untagged embedded structs
+// after the struct's own.
+func addPlanFields(fields map[string]*msgpackPlan, t reflect.Type, seen map[reflect.Type]*msgpackPlan) {
+	var embedded []reflect.Type
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded = append(embedded, ft)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = buildPlan(f.Type, seen)
+		}
+	}
+	for _, e := range embedded {
+		addPlanFields(fields, e, seen)
+	}
+}
+
+// msgpackTranscoder is the state of one jsonToMsgpack: the JSON being read
+// from pos and the MessagePack written so far.
+type msgpackTranscoder struct {
+	data    []byte
+	pos     int
+	out     []byte
+	scratch []byte // unescaped strings (see str)
+}
+
+// errMsgpackEOF reports JSON that ends inside a value.
+var errMsgpackEOF = fmt.Errorf("msgpack: unexpected end of JSON")
+
+// skipSpace moves past JSON whitespace.
+func (tc *msgpackTranscoder) skipSpace() {
+	for tc.pos < len(tc.data) {
+		switch tc.data[tc.pos] {
+		case ' ', '\t', '\n', '\r':
+			tc.pos++
+		default:
+			return
+		}
+	}
+}
+
+// next skips whitespace and returns the next byte without consuming it.
+func (tc *msgpackTranscoder) next() (byte, error) {
+	tc.skipSpace()
+	if tc.pos >= len(tc.data) {
+		return 0, errMsgpackEOF
+	}
+	return tc.data[tc.pos], nil
+}
+
+// value transcodes the next JSON value, marshalled from a value of plan p.
+func (tc *msgpackTranscoder) value(p *msgpackPlan) error {
+	c, err := tc.next()
+	if err != nil {
+		return err
+	}
+	switch {
+	case c == '{':
+		return tc.container(p, '}', 0x80, 0xde)
+	case c == '[':
+		return tc.container(p, ']', 0x90, 0xdc)
+	case c == '"':
+		s, err := tc.str()
+		if err != nil {
+			return err
+		}
+		tc.out = appendMsgpackString(tc.out, s)
+		return nil
+	case c == 't':
+		return tc.literal("true", 0xc3)
+	case c == 'f':
+		return tc.literal("false", 0xc2)
+	case c == 'n':
+		return tc.literal("null", 0xc0)
+	case c == '-' || c >= '0' && c <= '9':
+		return tc.number(p != nil && p.float)
+	}
+	return fmt.Errorf("msgpack: unexpected JSON byte %q at offset %d", c, tc.pos)
+}
+
+// container transcodes the object or array at pos (of plan p) up to its
+// closing byte end. Its header, fix with the count or the 16/32-bit format
+// ext16, needs the element count, so a one-byte header is reserved and
+// widened afterwards when the count turns out to be 16 or more.
+func (tc *msgpackTranscoder) container(p *msgpackPlan, end, fix, ext16 byte) error {
+	object := end == '}'
+	tc.pos++
+	start := len(tc.out)
+	tc.out = append(tc.out, fix)
+	n := 0
+	if c, err := tc.next(); err != nil {
+		return err
+	} else if c == end {
+		tc.pos++
+		return nil
+	}
+	var elem *msgpackPlan
+	if !object && p != nil {
+		elem = p.elem
+	}
+	for {
+		if object {
+			if c, err := tc.next(); err != nil {
+				return err
+			} else if c != '"' {
+				return fmt.Errorf("msgpack: expected an object key at offset %d", tc.pos)
+			}
+			key, err := tc.str()
+			if err != nil {
+				return err
+			}
+			tc.out = appendMsgpackString(tc.out, key)
+			elem = p.field(key)
+			if c, err := tc.next(); err != nil {
+				return err
+			} else if c != ':' {
+				return fmt.Errorf("msgpack: expected ':' at offset %d", tc.pos)
+			}
+			tc.pos++
+		}
+		if err := tc.value(elem); err != nil {
+			return err
+		}
+		n++
+		c, err := tc.next()
+		if err != nil {
+			return err
+		}
+		tc.pos++
+		if c == end {
+			break
+		}
+		if c != ',' {
+			return fmt.Errorf("msgpack: expected ',' or %q at offset %d", end, tc.pos-1)
+		}
+	}
+	if n < 16 {
+		tc.out[start] = fix | byte(n)
+		return nil
+	}
+	header := appendMsgpackHeader(nil, n, fix, ext16)
+	tc.out = append(tc.out, header[1:]...)
+	copy(tc.out[start+len(header):], tc.out[start+1:len(tc.out)-len(header)+1])
+	copy(tc.out[start:], header)
+	return nil
+}
+
+// str reads the JSON string at pos and returns its contents. Strings without
+// escapes (nearly all; encoding/json escapes only quotes, backslashes, control
+// characters, and <, >, &) are a subslice of the input, others are unescaped
+// into tc.scratch, valid until the next call.
+func (tc *msgpackTranscoder) str() ([]byte, error) {
+	start := tc.pos + 1
+	i := start
+	for i < len(tc.data) && tc.data[i] != '"' && tc.data[i] != '\\' {
+		i++
+	}
+	if i < len(tc.data) && tc.data[i] == '"' {
+		tc.pos = i + 1
+		return tc.data[start:i], nil
+	}
+	buf := append(tc.scratch[:0], tc.data[start:i]...)
+	for i < len(tc.data) {
+		c := tc.data[i]
+		switch {
+		case c == '"':
+			tc.pos, tc.scratch = i+1, buf
+			return buf, nil
+		case c != '\\':
+			buf = append(buf, c)
+			i++
+			continue
+		case i+1 >= len(tc.data):
+			return nil, errMsgpackEOF
+		}
+		switch e := tc.data[i+1]; e {
+		case '"', '\\', '/':
+			buf = append(buf, e)
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'u':
+			r, n := tc.unicodeEscape(i)
+			if n == 0 {
+				return nil, fmt.Errorf("msgpack: invalid JSON string escape at offset %d", i)
+			}
+			buf = utf8.AppendRune(buf, r)
+			i += n
+			continue
+		default:
+			return nil, fmt.Errorf("msgpack: invalid JSON string escape at offset %d", i)
+		}
+		i += 2
+	}
+	return nil, errMsgpackEOF
+}
+
+// unicodeEscape decodes the \uXXXX escape at i, joining a UTF-16 surrogate
+// pair into one rune (a lone surrogate becomes U+FFFD, as in encoding/json).
+// It returns the rune and the escape's length, 0 if it is malformed.
+func (tc *msgpackTranscoder) unicodeEscape(i int) (rune, int) {
+	r, ok := hex4(tc.data[i+2:])
+	if !ok {
+		return 0, 0
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, 6
+	}
+	if rest := tc.data[i+6:]; len(rest) >= 6 && rest[0] == '\\' && rest[1] == 'u' {
+		if r2, ok := hex4(rest[2:]); ok {
+			if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+				return dec, 12
+			}
+		}
+	}
+	return utf8.RuneError, 6
+}
+
+// hex4 parses the four hex digits at the start of b.
+func hex4(b []byte) (rune, bool) {
+	if len(b) < 4 {
+		return 0, false
+	}
+	var r rune
+	for _, c := range b[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c -= 'a' - 10
+		case c >= 'A' && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
+
+// literal reads the JSON literal word at pos and writes its MessagePack byte b.
+func (tc *msgpackTranscoder) literal(word string, b byte) error {
+	if !bytes.HasPrefix(tc.data[tc.pos:], []byte(word)) {
+		return fmt.Errorf("msgpack: invalid JSON literal at offset %d", tc.pos)
+	}
+	tc.pos += len(word)
+	tc.out = append(tc.out, b)
+	return nil
+}
+
+// number reads the JSON number at pos: as a float64 if float or it has a
+// fraction or exponent (or overflows int64), else as an int.
+func (tc *msgpackTranscoder) number(float bool) error {
+	start := tc.pos
+	if tc.data[tc.pos] == '-' {
+		tc.pos++
+	}
+	ok := tc.pos < len(tc.data) && tc.data[tc.pos] == '0' // no leading zeros
+	if ok {
+		tc.pos++
+	} else {
+		ok = tc.digits()
+	}
+	if ok && tc.pos < len(tc.data) && tc.data[tc.pos] == '.' {
+		tc.pos++
+		float, ok = true, tc.digits()
+	}
+	if ok && tc.pos < len(tc.data) && (tc.data[tc.pos] == 'e' || tc.data[tc.pos] == 'E') {
+		tc.pos++
+		if tc.pos < len(tc.data) && (tc.data[tc.pos] == '+' || tc.data[tc.pos] == '-') {
+			tc.pos++
+		}
+		float, ok = true, tc.digits()
+	}
+	if !ok {
+		return fmt.Errorf("msgpack: invalid JSON number at offset %d", start)
+	}
+	num := tc.data[start:tc.pos]
+	if !float {
+		if i, err := strconv.ParseInt(string(num), 10, 64); err == nil {
+			tc.out = appendMsgpackInt(tc.out, i)
+			return nil
+		}
+	}
+	f, err := strconv.ParseFloat(string(num), 64)
+	if err != nil {
+		return fmt.Errorf("msgpack: invalid JSON number at offset %d", start)
+	}
+	tc.out = binary.BigEndian.AppendUint64(append(tc.out, 0xcb), math.Float64bits(f))
+	return nil
+}
+
+// digits moves past a run of decimal digits and reports whether there was one.
+func (tc *msgpackTranscoder) digits() bool {
+	start := tc.pos
+	for tc.pos < len(tc.data) && tc.data[tc.pos] >= '0' && tc.data[tc.pos] <= '9' {
+		tc.pos++
+	}
+	return tc.pos > start
+}
+
+// appendMsgpackHeader appends a map or array header for n elements: fix is
+// the fixmap/fixarray prefix, ext16 the 16-bit format (ext16+1 is 32-bit).
+func appendMsgpackHeader(dst []byte, n int, fix, ext16 byte) []byte {
+	switch {
+	case n < 16:
+		return append(dst, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, ext16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(dst, ext16+1), uint32(n))
+	}
+}
+
+// appendMsgpackString appends s as a MessagePack str.
+func appendMsgpackString(dst, s []byte) []byte {
+	switch n := len(s); {
+	case n < 32:
+		dst = append(dst, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		dst = append(dst, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		dst = binary.BigEndian.AppendUint16(append(dst, 0xda), uint16(n))
+	default:
+		dst = binary.BigEndian.AppendUint32(append(dst, 0xdb), uint32(n))
+	}
+	return append(dst, s...)
+}
+
+// appendMsgpackInt appends i in the smallest MessagePack int format.
+func appendMsgpackInt(dst []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(dst, byte(i)) // positive fixint
+	case i < 0 && i >= -32:
+		return append(dst, byte(int8(i))) // negative fixint
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(dst, 0xd0, byte(int8(i)))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xd1), uint16(int16(i)))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xd2), uint32(int32(i)))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, 0xd3), uint64(i))
+	}
+}
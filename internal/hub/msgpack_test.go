@@ -0,0 +1,113 @@
+package hub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestJSONToMsgpack verifies the transcoding of each JSON value kind without a
+// Go type, the variable-length formats, string escapes, and that malformed
+// JSON is rejected.
+func TestJSONToMsgpack(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	wide := make(map[string]int, 16)
+	for i := range 16 {
+		wide[string(rune('a'+i))] = i
+	}
+	wideJSON, _ := json.Marshal(wide)
+
+	tests := []struct {
+		name, in string
+		want     []byte
+	}{
+		{"object in JSON order", `{"type":"delta","seq":3}`,
+			[]byte{0x82, 0xa4, 't', 'y', 'p', 'e', 0xa5, 'd', 'e', 'l', 't', 'a', 0xa3, 's', 'e', 'q', 0x03}},
+		{"array and literals", `[true,false,null]`, []byte{0x93, 0xc3, 0xc2, 0xc0}},
+		{"negative fixint", `-5`, []byte{0xfb}},
+		{"int8", `-100`, []byte{0xd0, 0x9c}},
+		{"int16", `300`, []byte{0xd1, 0x01, 0x2c}},
+		{"int64 timestamp", `1700000000000`, []byte{0xd3, 0, 0, 0x01, 0x8b, 0xcf, 0xe5, 0x68, 0x00}},
+		{"float", `0.5`, []byte{0xcb, 0x3f, 0xe0, 0, 0, 0, 0, 0, 0}},
+		{"str8", `"` + long + `"`, append([]byte{0xd9, 40}, long...)},
+		{"escaped string", `"a\u0026b\n\"\/"`, []byte{0xa6, 'a', '&', 'b', '\n', '"', '/'}},
+		{"surrogate pair", `"\ud83c\udfae"`, append([]byte{0xa4}, "🎮"...)},
+		{"whitespace", ` [ 1 , { } ] `, []byte{0x92, 0x01, 0x80}},
+		{"map16", string(wideJSON), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonToMsgpack([]byte(tt.in), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil { // only check the header
+				if len(got) < 3 || got[0] != 0xde || got[1] != 0 || got[2] != 16 {
+					t.Errorf("header = % x, want de 00 10", got[:min(3, len(got))])
+				}
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("jsonToMsgpack(%s) = % x, want % x", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{``, `{"a":`, `[1,2`, `1 2`, `[1,]`, `{"a"}`, `"ab`, `"\x"`, `"\u12"`, `1.`, `01`, `-`, `tru`} {
+		if _, err := jsonToMsgpack([]byte(bad), nil); err == nil {
+			t.Errorf("jsonToMsgpack(%q): got nil error", bad)
+		}
+	}
+}
+
+// TestJSONToMsgpackTyped verifies that numbers in float fields of the Go type
+// stay float64 when JSON wrote them as integers, through pointers, slices, and
+// maps, while int fields keep the int formats.
+func TestJSONToMsgpackTyped(t *testing.T) {
+	f64 := func(f float64) []byte { return binary.BigEndian.AppendUint64([]byte{0xcb}, math.Float64bits(f)) }
+	state := gamepad.GamepadState{PlayerIndex: 1}
+	state.Sticks.Left.Position = gamepad.Vector{X: 1, Y: 0}
+	msg := &WSMessage{Type: "full", Seq: 2, Data: &state}
+	text, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := jsonToMsgpack(text, wsMessageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range [][]byte{
+		append([]byte{0xa1, 'x'}, f64(1)...),
+		append([]byte{0xa1, 'y'}, f64(0)...),
+		{0xa3, 's', 'e', 'q', 0x02},
+		{0xab, 'p', 'l', 'a', 'y', 'e', 'r', 'I', 'n', 'd', 'e', 'x', 0x01},
+	} {
+		if !bytes.Contains(got, want) {
+			t.Errorf("msgpack of %s lacks % x", text, want)
+		}
+	}
+
+	type nested struct {
+		Levels []float64           `json:"levels"`
+		ByName map[string]*float32 `json:"byName"`
+		Count  int                 `json:"count"`
+		Any    any                 `json:"any"`
+	}
+	got, err = jsonToMsgpack([]byte(`{"levels":[0,1],"byName":{"a":2},"count":3,"any":4}`), reflect.TypeFor[nested]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x84, 0xa6, 'l', 'e', 'v', 'e', 'l', 's', 0x92}
+	want = append(append(want, f64(0)...), f64(1)...)
+	want = append(append(want, 0xa6, 'b', 'y', 'N', 'a', 'm', 'e', 0x81, 0xa1, 'a'), f64(2)...)
+	want = append(want, 0xa5, 'c', 'o', 'u', 'n', 't', 0x03, 0xa3, 'a', 'n', 'y', 0x04)
+	if !bytes.Equal(got, want) {
+		t.Errorf("typed transcode = % x, want % x", got, want)
+	}
+}
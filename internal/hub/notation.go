@@ -0,0 +1,123 @@
+package hub
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+const (
+	// notationFPS is the frame rate of notation frame counts: the 60 frames
+	// a second fighting games run at.
+	notationFPS = 60
+	// notationStick is how far the left stick must be pushed to give a
+	// direction.
+	notationStick = 0.5
+	// notationTrigger is how far a trigger must be pulled to count as a
+	// button.
+	notationTrigger = 0.5
+)
+
+// notationButtons are the buttons of notation entries, in order, with the
+// labels they are written with.
+var notationButtons = []struct {
+	label string
+	held  func(s gamepad.GamepadState) bool
+}{
+	{"A", func(s gamepad.GamepadState) bool { return s.Buttons.A }},
+	{"B", func(s gamepad.GamepadState) bool { return s.Buttons.B }},
+	{"X", func(s gamepad.GamepadState) bool { return s.Buttons.X }},
+	{"Y", func(s gamepad.GamepadState) bool { return s.Buttons.Y }},
+	{"LB", func(s gamepad.GamepadState) bool { return s.Buttons.LB }},
+	{"RB", func(s gamepad.GamepadState) bool { return s.Buttons.RB }},
+	{"LT", func(s gamepad.GamepadState) bool { return s.Triggers.LT.Value >= notationTrigger }},
+	{"RT", func(s gamepad.GamepadState) bool { return s.Triggers.RT.Value >= notationTrigger }},
+}
+
+// NotationEntry is the payload of a "notation" message: a player's input in
+// fighting-game numpad notation, sent when its direction or buttons change,
+// for the input history column of training overlays.
+type NotationEntry struct {
+	Input      string   `json:"input"`             // the direction, then the held buttons joined by "+": "2", "6X", "5A+B"
+	Direction  int      `json:"direction"`         // numpad: 1-9, 5 = neutral, 6 = right, 8 = up
+	Buttons    []string `json:"buttons,omitempty"` // the held buttons: A B X Y LB RB LT RT
+	PrevFrames int      `json:"prevFrames"`        // frames (at 60 a second) the player's entry before lasted; 0 for the first
+}
+
+// notationMark is the last notation entry of a player and when it started.
+type notationMark struct {
+	input string
+	since int64 // Unix ms
+}
+
+// newNotationEntry returns the notation of s.
+func newNotationEntry(s gamepad.GamepadState) NotationEntry {
+	e := NotationEntry{Direction: notationDirection(s)}
+	for _, nb := range notationButtons {
+		if nb.held(s) {
+			e.Buttons = append(e.Buttons, nb.label)
+		}
+	}
+	e.Input = strconv.Itoa(e.Direction) + strings.Join(e.Buttons, "+")
+	return e
+}
+
+// notationDirection returns the numpad direction of s: the D-pad's if it
+// has one pressed (opposite directions cancel), else the left stick's,
+// rounded to the nearest of eight once it is pushed past notationStick.
+func notationDirection(s gamepad.GamepadState) int {
+	var x, y int
+	if d := s.Dpad; d.Up || d.Down || d.Left || d.Right {
+		x, y = b2i(d.Right)-b2i(d.Left), b2i(d.Up)-b2i(d.Down)
+	} else if p := s.Sticks.Left.Position; math.Hypot(p.X, p.Y) >= notationStick {
+		angle := math.Round(math.Atan2(p.Y, p.X)/(math.Pi/4)) * math.Pi / 4
+		x, y = int(math.Round(math.Cos(angle))), int(math.Round(math.Sin(angle)))
+	}
+	return 5 + x + 3*y
+}
+
+// b2i returns 1 for true and 0 for false.
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// notationFrames returns how many frames at notationFPS ms spans, at least 1.
+func notationFrames(ms int64) int {
+	return max(1, int(math.Round(float64(ms)*notationFPS/1000)))
+}
+
+// notationLocked returns the notation entry state starts at now for its
+// player, nil if the player's direction and buttons did not change. A
+// disconnected state ends the player's entries. b.mu must be held.
+func (b *Broadcaster) notationLocked(state gamepad.GamepadState, now int64) *NotationEntry {
+	if !state.Connected {
+		delete(b.notation, state.PlayerIndex)
+		return nil
+	}
+	e := newNotationEntry(state)
+	last, ok := b.notation[state.PlayerIndex]
+	if ok && last.input == e.Input {
+		return nil
+	}
+	if ok {
+		e.PrevFrames = notationFrames(now - last.since)
+	}
+	b.notation[state.PlayerIndex] = notationMark{input: e.Input, since: now}
+	return &e
+}
+
+// broadcastNotation sends a notation entry of playerIndex to its viewers
+// that subscribed to the notation stream.
+func (b *Broadcaster) broadcastNotation(playerIndex int, e *NotationEntry) {
+	if e == nil {
+		return
+	}
+	if data, ok := marshalOrLog("notation message", NewNotationMessage(playerIndex, e)); ok {
+		b.hub.BroadcastNotation(data, playerIndex)
+	}
+}
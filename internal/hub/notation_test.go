@@ -0,0 +1,96 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestNotationEntry verifies numpad directions from the D-pad (opposites
+// cancelling) and the left stick, and the held buttons of the input.
+func TestNotationEntry(t *testing.T) {
+	stick := func(x, y float64) gamepad.GamepadState {
+		var s gamepad.GamepadState
+		s.Sticks.Left.Position = gamepad.Vector{X: x, Y: y}
+		return s
+	}
+	dpad := func(d gamepad.DpadState) gamepad.GamepadState {
+		var s gamepad.GamepadState
+		s.Dpad = d
+		return s
+	}
+	withButtons := func(s gamepad.GamepadState) gamepad.GamepadState {
+		s.Buttons.A, s.Buttons.RB = true, true
+		s.Triggers.LT.Value, s.Triggers.RT.Value = 0.8, 0.2
+		return s
+	}
+	for _, tt := range []struct {
+		name  string
+		state gamepad.GamepadState
+		want  string
+	}{
+		{"neutral", gamepad.GamepadState{}, "5"},
+		{"stick in the deadzone", stick(0.3, -0.3), "5"},
+		{"stick up", stick(0, 0.9), "8"},
+		{"stick down-right", stick(0.6, -0.6), "3"},
+		{"stick mostly left", stick(-0.9, 0.2), "4"},
+		{"stick down-left", stick(-0.5, -0.55), "1"},
+		{"dpad down", dpad(gamepad.DpadState{Down: true}), "2"},
+		{"dpad up-right", dpad(gamepad.DpadState{Up: true, Right: true}), "9"},
+		{"dpad over the stick", func() gamepad.GamepadState { s := stick(-1, 0); s.Dpad.Right = true; return s }(), "6"},
+		{"dpad left+right", dpad(gamepad.DpadState{Left: true, Right: true, Down: true}), "2"},
+		{"buttons and a pulled trigger", withButtons(dpad(gamepad.DpadState{Right: true})), "6A+RB+LT"},
+	} {
+		if got := newNotationEntry(tt.state).Input; got != tt.want {
+			t.Errorf("%s: Input = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if e := newNotationEntry(withButtons(gamepad.GamepadState{})); e.Direction != 5 || len(e.Buttons) != 3 || e.Buttons[2] != "LT" {
+		t.Errorf("entry = %+v, want direction 5 with A, RB, LT", e)
+	}
+}
+
+// TestNotationLocked verifies an entry is made only when the direction or
+// buttons change, with the frames the one before lasted, per player, and
+// that a disconnect ends a player's entries.
+func TestNotationLocked(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	idle := fixtureXboxState()
+	down, downRight, rightX := idle, idle, idle
+	down.Dpad.Down = true
+	downRight.Dpad.Down, downRight.Dpad.Right = true, true
+	rightX.Dpad.Right, rightX.Buttons.X = true, true
+	nudged := down
+	nudged.Sticks.Right.Position.X = 0.7 // not part of the notation
+	other := down
+	other.PlayerIndex = 2
+
+	steps := []struct {
+		name  string
+		state gamepad.GamepadState
+		at    int64
+		want  string // "" = no entry
+		prev  int
+	}{
+		{"idle", idle, 1000, "5", 0},
+		{"down", down, 1700, "2", 42},
+		{"right stick moved", nudged, 1720, "", 0},
+		{"player 2", other, 1730, "2", 0},
+		{"down-right", downRight, 1767, "3", 4},
+		{"right + X", rightX, 1817, "6X", 3},
+		{"same millisecond", idle, 1817, "5", 1},
+		{"unplugged", gamepad.GamepadState{PlayerIndex: 1}, 1900, "", 0},
+		{"back", idle, 2000, "5", 0},
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, step := range steps {
+		e := b.notationLocked(step.state, step.at)
+		switch {
+		case step.want == "" && e != nil:
+			t.Errorf("%s: entry %+v, want none", step.name, *e)
+		case step.want != "" && (e == nil || e.Input != step.want || e.PrevFrames != step.prev):
+			t.Errorf("%s: entry %+v, want %s after %d frames", step.name, e, step.want, step.prev)
+		}
+	}
+}
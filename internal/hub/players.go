@@ -0,0 +1,107 @@
+package hub
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// maxPlayerIndex is the highest player a client can follow with
+// SetAllPlayers, as in the built-in frontend (MAX_PLAYER_INDEX).
+const maxPlayerIndex = 16
+
+// SetAllPlayers streams every player at once (local multiplayer), for a
+// Reader with SetAllPlayers: each player's states go to its viewers as a
+// stream of their own, so several overlays can each show one controller.
+// Call before Run.
+func (b *Broadcaster) SetAllPlayers(enabled bool) {
+	b.mu.Lock()
+	b.allPlayers = enabled
+	b.mu.Unlock()
+}
+
+// AllPlayers reports whether every player is streamed (see SetAllPlayers).
+func (b *Broadcaster) AllPlayers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.allPlayers
+}
+
+// SyncPlayer sends the viewers of playerIndex a full of its shown state. With
+// SetAllPlayers, a client that switches player needs it: no state of the new
+// player may arrive for a while. Safe to call from any goroutine.
+func (b *Broadcaster) SyncPlayer(playerIndex int) {
+	b.mu.Lock()
+	b.seq++
+	msg := b.fullMessageLocked(b.seq, b.shownLocked(playerIndex))
+	b.mu.Unlock()
+	b.broadcastState(msg, *msg.Data, playerIndex)
+}
+
+// StreamSelector returns the PlayerSwitcher for select_player with
+// SetAllPlayers, and for viewer token clients in any mode: any player from 1
+// to maxPlayerIndex can be followed, even one not connected yet, and the
+// active controller is left alone so viewers of different players do not
+// take it from each other.
+func (b *Broadcaster) StreamSelector() PlayerSwitcher { return streamSelector{b} }
+
+// streamSelector is the PlayerSwitcher returned by StreamSelector.
+type streamSelector struct{ b *Broadcaster }
+
+// SetActiveByPlayerIndex syncs the client HandleMessage has just moved to
+// playerIndex.
+func (s streamSelector) SetActiveByPlayerIndex(playerIndex int) bool {
+	if playerIndex < 1 || playerIndex > maxPlayerIndex {
+		return false
+	}
+	s.b.SyncPlayer(playerIndex)
+	return true
+}
+
+// streamsLocked returns the latest state of each stream: the one stream
+// (lastState) by default, every player's in index order with SetAllPlayers.
+// b.mu must be held.
+func (b *Broadcaster) streamsLocked() []gamepad.GamepadState {
+	if !b.allPlayers {
+		return []gamepad.GamepadState{b.lastState}
+	}
+	var streams []gamepad.GamepadState
+	for _, pi := range slices.Sorted(maps.Keys(b.players)) {
+		if pi > 0 {
+			streams = append(streams, b.players[pi].last)
+		}
+	}
+	return streams
+}
+
+// broadcastFulls sends each full to its player's viewers.
+func (b *Broadcaster) broadcastFulls(fulls []*WSMessage) {
+	for _, msg := range fulls {
+		b.broadcastState(msg, *msg.Data, msg.Data.PlayerIndex)
+	}
+}
+
+// ShownStates returns the latest state of each stream, as clients are shown
+// it (the held states while frozen): the active controller's by default,
+// every player's with SetAllPlayers. Safe to call from any goroutine.
+func (b *Broadcaster) ShownStates() []gamepad.GamepadState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.frozen.Frozen {
+		return slices.Clone(b.held)
+	}
+	return b.streamsLocked()
+}
+
+// ShownState returns the state the clients following playerIndex were last
+// sent: its stream's state from ShownStates, or a disconnected state of that
+// player when no stream has its index. Safe to call from any goroutine.
+func (b *Broadcaster) ShownState(playerIndex int) gamepad.GamepadState {
+	for _, s := range b.ShownStates() {
+		if s.PlayerIndex == playerIndex {
+			return s
+		}
+	}
+	return gamepad.GamepadState{PlayerIndex: playerIndex}
+}
@@ -0,0 +1,34 @@
+package hub
+
+import "encoding/json"
+
+// PluginEvent is an event injected by a plugin (see internal/plugin), for
+// type "plugin_event".
+type PluginEvent struct {
+	Plugin string          `json:"plugin"`         // name of the [plugins.<name>] table
+	Event  string          `json:"event"`          // chosen by the plugin, e.g. "combo_detected"
+	Data   json.RawMessage `json:"data,omitempty"` // any JSON, passed on as the plugin wrote it
+}
+
+// SetTap makes f observe the messages broadcast to clients, as a client
+// without an output profile following every player would receive them:
+// state, power, controller, and event messages, but not keyboard/mouse or
+// ghost streams and not replies to a single client. f runs on the
+// broadcasting goroutine, so it must not block. nil (the default) observes
+// nothing. Must be called before Broadcaster.Run.
+func (h *Hub) SetTap(f func(msg []byte)) { h.tap = f }
+
+// observe passes msg to the tap, if any.
+func (h *Hub) observe(msg []byte) {
+	if h.tap != nil {
+		h.tap(msg)
+	}
+}
+
+// BroadcastPluginEvent sends ev to every client as a "plugin_event"
+// message. Safe to call from any goroutine.
+func (b *Broadcaster) BroadcastPluginEvent(ev PluginEvent) {
+	if data, ok := marshalOrLog("plugin_event message", NewPluginEventMessage(&ev)); ok {
+		b.hub.BroadcastAll(data)
+	}
+}
@@ -0,0 +1,67 @@
+package hub
+
+import (
+	"slices"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Power event reasons (PowerEvent.Reason).
+const (
+	PowerReasonStatus    = "status"    // Status changed (e.g. cable plugged in: discharging → charging)
+	PowerReasonThreshold = "threshold" // Level fell to or below a battery threshold while discharging
+)
+
+// PowerEvent describes a change of one player's controller power state,
+// sent as a "power_changed" message alongside (not instead of) the regular
+// full/delta stream, which carries the same battery values.
+type PowerEvent struct {
+	Reason    string               `json:"reason"`              // PowerReasonStatus or PowerReasonThreshold
+	Threshold int                  `json:"threshold,omitempty"` // crossed threshold in percent, for "threshold"
+	Previous  gamepad.BatteryState `json:"previous"`
+	Current   gamepad.BatteryState `json:"current"`
+}
+
+// powerEvents compares two consecutive battery states of the same player.
+// Status changes are reported only between two known states, so a controller
+// connecting or disconnecting (battery appearing or vanishing) is not an
+// event. A threshold is reported when a discharging level falls from above it
+// to at or below it; if one step crosses several, only the lowest is reported.
+func powerEvents(old, new_ gamepad.BatteryState, thresholds []int) []PowerEvent {
+	if old.Status == "" || new_.Status == "" {
+		return nil
+	}
+	var events []PowerEvent
+	if old.Status != new_.Status {
+		events = append(events, PowerEvent{Reason: PowerReasonStatus, Previous: old, Current: new_})
+	}
+	if new_.Status == gamepad.BatteryDischarging {
+		for _, t := range thresholds { // ascending: the first crossed is the lowest
+			if old.Level > t && new_.Level <= t {
+				events = append(events, PowerEvent{Reason: PowerReasonThreshold, Threshold: t, Previous: old, Current: new_})
+				break
+			}
+		}
+	}
+	return events
+}
+
+// SetBatteryThresholds sets the battery levels (percent) at which a
+// "threshold" power event is sent, in any order. nil or empty disables
+// threshold events; status events are always sent. Call before Run.
+func (b *Broadcaster) SetBatteryThresholds(thresholds []int) {
+	sorted := slices.Clone(thresholds)
+	slices.Sort(sorted)
+	b.mu.Lock()
+	b.batteryThresholds = slices.Compact(sorted)
+	b.mu.Unlock()
+}
+
+// broadcastPower marshals and broadcasts power events to the player's viewers.
+func (b *Broadcaster) broadcastPower(events []PowerEvent, playerIndex int) {
+	for i := range events {
+		if data, ok := marshalOrLog("power message", NewPowerMessage(playerIndex, &events[i])); ok {
+			b.hub.BroadcastToPlayer(data, playerIndex)
+		}
+	}
+}
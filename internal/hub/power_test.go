@@ -0,0 +1,50 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+func TestPowerEvents(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetBatteryThresholds([]int{5, 20, 10, 20})
+
+	on := func(status string, level int) gamepad.GamepadState {
+		s := fixtureXboxState()
+		s.Battery = gamepad.BatteryState{Status: status, Level: level}
+		return s
+	}
+	type want struct {
+		reason    string
+		threshold int
+	}
+	steps := []struct {
+		state gamepad.GamepadState
+		want  []want
+	}{
+		{on(gamepad.BatteryDischarging, 30), nil},                                // first reading: no event
+		{on(gamepad.BatteryDischarging, 25), nil},                                // no threshold crossed
+		{on(gamepad.BatteryDischarging, 20), []want{{PowerReasonThreshold, 20}}}, // reaching a threshold counts
+		{on(gamepad.BatteryDischarging, 15), nil},                                // already below 20
+		{on(gamepad.BatteryDischarging, 0), []want{{PowerReasonThreshold, 5}}},   // 10 and 5 crossed: lowest
+		{on(gamepad.BatteryCharging, 0), []want{{PowerReasonStatus, 0}}},
+		{on(gamepad.BatteryFull, 100), []want{{PowerReasonStatus, 0}}},
+		{gamepad.GamepadState{PlayerIndex: 1}, nil},                      // disconnected: battery vanishes
+		{on(gamepad.BatteryDischarging, 100), nil},                       // reconnected: first reading again
+		{on(gamepad.BatteryCharging, 5), []want{{PowerReasonStatus, 0}}}, // crossing while charging is not a threshold event
+	}
+	for i, step := range steps {
+		b.mu.Lock()
+		got := b.trackLocked(step.state, 1000)
+		b.mu.Unlock()
+		if len(got) != len(step.want) {
+			t.Fatalf("step %d: events = %+v, want %+v", i, got, step.want)
+		}
+		for j, ev := range got {
+			if ev.Reason != step.want[j].reason || ev.Threshold != step.want[j].threshold || ev.Current != step.state.Battery {
+				t.Errorf("step %d: event %d = %+v, want %+v with current %+v", i, j, ev, step.want[j], step.state.Battery)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package hub
+
+import (
+	"strings"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// ProfileSelector lets a client choose the output profile it receives states in.
+type ProfileSelector interface {
+	SelectProfile(c *Client, name string) bool
+}
+
+// SetProfiles sets the named output profiles. Clients on a profile (see
+// SelectProfile) receive every full and delta with its Transform applied;
+// all other clients receive the states unchanged. Names are matched
+// case-insensitively. Counters and lastChanged are never transformed: they
+// stay keyed by the physical control. Call before Run; the map is not
+// modified afterwards.
+func (b *Broadcaster) SetProfiles(profiles map[string]Transform) {
+	m := make(map[string]Transform, len(profiles))
+	for name, t := range profiles {
+		m[strings.ToLower(name)] = t
+	}
+	b.profiles = m
+}
+
+// SelectProfile switches c to the named output profile ("" = untransformed),
+// confirms it with a "profile_selected" message and sends a full in the new
+// profile, so the client never mixes orientations. It returns false, leaving
+// c unchanged, if no such profile is configured.
+// Safe to call from any goroutine (e.g. gws OnMessage handler).
+func (b *Broadcaster) SelectProfile(c *Client, name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := b.profiles[name]; !ok && name != "" {
+		return false
+	}
+	c.profile.Store(name)
+	if data, ok := marshalOrLog("profile_selected message", NewProfileSelectedMessage(name)); ok {
+		c.Send(data)
+	}
+	b.SendInitialState(c)
+	return true
+}
+
+// transformMessage returns a copy of the full or delta msg with t applied
+// to its state. Counters and LastChanged are shared, not transformed.
+func transformMessage(msg *WSMessage, t Transform) *WSMessage {
+	m := *msg
+	if msg.Data != nil {
+		s := t.State(*msg.Data)
+		m.Data = &s
+	}
+	if msg.Changes != nil {
+		m.Changes = t.Delta(msg.Changes)
+	}
+	return &m
+}
+
+// broadcastState marshals and broadcasts a full or delta message, which
+// brings the player's stream to state, to the player's viewers: unchanged to
+// clients without a profile, transformed to each profile's clients. The
+// message owns copies of all state — no lock needed.
+func (b *Broadcaster) broadcastState(msg *WSMessage, state gamepad.GamepadState, playerIndex int) {
+	if data, ok := marshalOrLog(msg.Type+" message", msg); ok {
+		b.hub.broadcastStateProfile(data, newStateUpdate(msg, state), playerIndex, "")
+	}
+	for name, t := range b.profiles {
+		tm := transformMessage(msg, t)
+		if data, ok := marshalOrLog(msg.Type+" message", tm); ok {
+			b.hub.broadcastStateProfile(data, newStateUpdate(tm, t.State(state)), playerIndex, name)
+		}
+	}
+}
+
+// newStateUpdate returns the stateUpdate of the full or delta msg, which
+// brings its stream to state.
+func newStateUpdate(msg *WSMessage, state gamepad.GamepadState) *stateUpdate {
+	u := &stateUpdate{seq: msg.Seq, state: state, full: msg.Type == "full", changes: msg.Changes}
+	if !u.full && hasAnalog(msg.Changes) {
+		u.analog = &analogMessage{seq: msg.Seq, state: state}
+	}
+	return u
+}
@@ -0,0 +1,179 @@
+package hub
+
+import (
+	"sync"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// maxUpdateRate caps the update rate a client can ask for (Hz).
+const maxUpdateRate = 1000
+
+// stateUpdate is the stream state a full or delta brings the viewers of its
+// player to, as the clients on its profile see it. Clients with an update
+// rate track it to coalesce deltas (see Client.SetUpdateRate). changes and
+// analog, for deltas only, are the delta's changes and, if they may touch an
+// analog input, its "analog" message (see Client.SetAnalogMode).
+type stateUpdate struct {
+	seq     int64
+	state   gamepad.GamepadState
+	full    bool
+	changes *gamepad.DeltaChanges
+	analog  *analogMessage
+}
+
+// rateLimiter coalesces the state stream of a client with an update rate,
+// and of a client whose send buffer is full (see Client.Send). The zero value
+// sends every change.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration        // minimum time between state messages; 0 = every change
+	last     time.Time            // when the last state message was sent
+	sent     gamepad.GamepadState // the state the client has
+	synced   bool                 // sent is the stream's latest state
+	latest   stateUpdate          // the stream's latest state, while !synced
+	timer    *time.Timer          // pending flush of latest; nil = none
+	gen      int64                // bumped by every timer; guards flushRate
+}
+
+// SetUpdateRate limits the full and delta stream of the client to hz
+// messages per second (at most 1000; 0 = every change, the default): deltas
+// that arrive sooner are coalesced into one delta from the state the client
+// has to the latest, sent when the interval is up. Fulls are never delayed.
+// Other messages are not affected. Safe to call from any goroutine.
+func (c *Client) SetUpdateRate(hz float64) {
+	r := &c.rate
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interval = 0
+	if hz > 0 {
+		r.interval = time.Duration(float64(time.Second) / min(hz, maxUpdateRate))
+	}
+}
+
+// sendState queues the full or delta m, which brings the client's stream to
+// u, subject to the update rate. A delta is only sent as is while the client
+// is in step with the stream; once it has fallen behind, the next send is a
+// delta computed from the state the client has. A message the send buffer
+// drops leaves the client behind in the same way. Clients that do not take
+// analog inputs inline or subscribed to some fields get deltas with only what
+// they take (see SetAnalogMode and SetFields).
+func (c *Client) sendState(m *outgoing, u *stateUpdate) {
+	r := &c.rate
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	due := now.Sub(r.last) >= r.interval
+	if !u.full && !c.takesAsIs(u.changes) {
+		if a := u.analog; a != nil && c.AnalogMode() == AnalogSplit {
+			if am := a.outgoing(); am != nil {
+				c.send(am)
+			}
+		}
+		switch {
+		case r.synced && c.changes(r.sent, u.state) == nil:
+			r.sent = u.state // nothing the client gets changed
+			return
+		case r.synced && due:
+			r.latest = *u
+			r.flushLocked(c, now) // the delta as the client gets it
+			return
+		}
+	}
+	switch {
+	case u.full || (r.synced && due):
+		r.stopLocked()
+		if !c.send(m) {
+			r.fallBehindLocked(c, u)
+			return
+		}
+		r.sent, r.synced, r.last = u.state, true, now
+	case due:
+		r.latest = *u
+		r.flushLocked(c, now)
+	default:
+		r.latest = *u
+		r.synced = false
+		if r.timer == nil {
+			r.gen++
+			gen := r.gen
+			r.timer = time.AfterFunc(r.interval-now.Sub(r.last), func() { c.flushRate(gen) })
+		}
+	}
+}
+
+// resetRate forgets the state the client has, for a client that asked for a
+// full to resync: until a full reaches it, a catch-up delta is computed from
+// the zero state instead of from what it was last sent.
+func (c *Client) resetRate() {
+	r := &c.rate
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent, r.synced = gamepad.GamepadState{}, false
+}
+
+// flushRate sends the coalesced delta when the interval of the timer of
+// generation gen is up, unless a message was sent since.
+func (c *Client) flushRate(gen int64) {
+	r := &c.rate
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer == nil || r.gen != gen {
+		return
+	}
+	r.flushLocked(c, time.Now())
+}
+
+// catchUp sends a client that fell behind when its send buffer was full the
+// delta to the latest state, unless it has caught up since.
+func (c *Client) catchUp() {
+	r := &c.rate
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.synced {
+		r.flushLocked(c, time.Now())
+	}
+}
+
+// fallBehindLocked records that the message bringing the client's stream to
+// u was dropped: the client keeps the state it has, and the first write to
+// complete sends it the delta to the latest (see catchUp). r.mu must be held.
+func (r *rateLimiter) fallBehindLocked(c *Client, u *stateUpdate) {
+	r.latest = *u
+	r.synced = false
+	c.behind.Store(true)
+	// The writes in flight may all have completed before behind was set.
+	if c.inFlight.Load() == 0 && c.behind.CompareAndSwap(true, false) {
+		go c.catchUp()
+	}
+}
+
+// stopRate cancels a pending flush, for a client that disconnected.
+func (c *Client) stopRate() {
+	c.rate.mu.Lock()
+	c.rate.stopLocked()
+	c.rate.mu.Unlock()
+}
+
+// flushLocked sends c a delta from the state it has to the latest one and
+// marks it in step, or behind if the delta is dropped. r.mu must be held.
+func (r *rateLimiter) flushLocked(c *Client, now time.Time) {
+	r.stopLocked()
+	if delta := c.changes(r.sent, r.latest.state); delta != nil {
+		data, ok := marshalOrLog("coalesced delta message", NewDeltaMessage(r.latest.seq, delta))
+		if ok && !c.send(&outgoing{text: data}) {
+			r.fallBehindLocked(c, &r.latest)
+			return
+		}
+	}
+	r.sent, r.synced, r.last = r.latest.state, true, now
+}
+
+// stopLocked cancels a pending flush. r.mu must be held.
+func (r *rateLimiter) stopLocked() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
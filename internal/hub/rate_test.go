@@ -0,0 +1,185 @@
+package hub
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lxzan/gws"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// messageClient forwards every message a loopback client receives.
+type messageClient struct {
+	gws.BuiltinEventHandler
+	msgs chan WSMessage
+}
+
+func (c *messageClient) OnMessage(socket *gws.Conn, message *gws.Message) {
+	defer message.Close()
+	var msg WSMessage
+	if err := json.Unmarshal(message.Bytes(), &msg); err == nil {
+		c.msgs <- msg
+	}
+}
+
+// loopbackClient connects a WebSocket client to a hub and returns the hub's
+// Client for it and a function receiving the next message it gets.
+func loopbackClient(t *testing.T, h *Hub) (*Client, *messageClient, func() WSMessage) {
+	t.Helper()
+	upgrader := gws.NewUpgrader(&benchServerHandler{hub: h}, nil)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if socket, err := upgrader.Upgrade(w, r); err == nil {
+			go socket.ReadLoop()
+		}
+	}))
+	t.Cleanup(ts.Close)
+	mc := &messageClient{msgs: make(chan WSMessage, 16)}
+	conn, _, err := gws.NewClient(mc, &gws.ClientOption{Addr: "ws" + strings.TrimPrefix(ts.URL, "http")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go conn.ReadLoop()
+	waitForClients(t, h, 1)
+	var c *Client
+	h.mu.RLock()
+	for client := range h.clients {
+		c = client
+	}
+	h.mu.RUnlock()
+	t.Cleanup(func() { conn.WriteClose(1000, nil) })
+
+	recv := func() WSMessage {
+		t.Helper()
+		select {
+		case msg := <-mc.msgs:
+			return msg
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a message")
+			return WSMessage{}
+		}
+	}
+	return c, mc, recv
+}
+
+// pushState broadcasts s as the Broadcaster would after reading it.
+func pushState(b *Broadcaster, s gamepad.GamepadState) {
+	b.mu.Lock()
+	msg, _ := b.stateMessageLocked(s, time.Now().UnixMilli())
+	b.mu.Unlock()
+	if msg != nil {
+		b.broadcastState(msg, s, s.PlayerIndex)
+	}
+}
+
+// TestUpdateRate verifies that a client with an update rate gets a burst of
+// deltas as one coalesced delta that brings it to the latest state, and the
+// stream as is again once the rate is lifted.
+func TestUpdateRate(t *testing.T) {
+	h := NewHub()
+	go h.Run(t.Context())
+	c, mc, recv := loopbackClient(t, h)
+	b := NewBroadcaster(h, nil, nil)
+	push := func(s gamepad.GamepadState) { pushState(b, s) }
+
+	c.SetUpdateRate(10)
+	b.SendInitialState(c)
+	full := recv()
+	if full.Type != "full" {
+		t.Fatalf("first message = %q, want full", full.Type)
+	}
+
+	state := fixtureXboxState()
+	for i := range 5 {
+		state.Buttons.A = i%2 == 0
+		state.Sticks.Left.Position.X = float64(i+1) / 10
+		push(state)
+	}
+	start := time.Now()
+	msg := recv()
+	if msg.Type != "delta" || time.Since(start) < 50*time.Millisecond {
+		t.Fatalf("got %q after %v, want one coalesced delta after the 100ms interval", msg.Type, time.Since(start))
+	}
+	if got := gamepad.ApplyDelta(*full.Data, msg.Changes); !gamepad.ComputeDelta(got, state).IsEmpty() {
+		t.Errorf("full + coalesced delta = %+v, want the latest state %+v", got, state)
+	}
+	select {
+	case extra := <-mc.msgs:
+		t.Errorf("unexpected %q after the coalesced delta", extra.Type)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	c.SetUpdateRate(0)
+	state.Buttons.B = true
+	push(state)
+	if msg := recv(); msg.Type != "delta" || msg.Changes == nil || msg.Changes.Buttons == nil || !msg.Changes.Buttons.B {
+		t.Errorf("without a rate got %+v, want the delta pressing B", msg)
+	}
+}
+
+// TestHubUpdateRate verifies that new clients start with the hub's update
+// rate and can lift it.
+func TestHubUpdateRate(t *testing.T) {
+	h := NewHub()
+	if c := NewClient(h, nil); c.rate.interval != 0 {
+		t.Errorf("interval without a hub rate = %v, want 0", c.rate.interval)
+	}
+	h.SetUpdateRate(50)
+	c := NewClient(h, nil)
+	if c.rate.interval != 20*time.Millisecond {
+		t.Errorf("interval = %v, want 20ms", c.rate.interval)
+	}
+	c.SetUpdateRate(0)
+	if c.rate.interval != 0 {
+		t.Errorf("interval after SetUpdateRate(0) = %v, want 0", c.rate.interval)
+	}
+}
+
+// TestSendBufferCatchUp verifies that a client whose send buffer was full
+// gets the states it missed as one delta once a write completes.
+func TestSendBufferCatchUp(t *testing.T) {
+	h := NewHub()
+	go h.Run(t.Context())
+	c, mc, recv := loopbackClient(t, h)
+	b := NewBroadcaster(h, nil, nil)
+	c.sendLimit = 4
+
+	b.SendInitialState(c)
+	full := recv()
+	state := fixtureXboxState()
+	pushState(b, state)
+	first := recv()
+	if first.Type != "delta" {
+		t.Fatalf("got %q, want delta", first.Type)
+	}
+	for c.inFlight.Load() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.inFlight.Store(c.sendLimit) // a stalled client
+	state.Buttons.A = true
+	pushState(b, state)
+	state.Buttons.B = true
+	pushState(b, state)
+	select {
+	case msg := <-mc.msgs:
+		t.Fatalf("got %q through a full send buffer", msg.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.inFlight.Store(0)
+	c.Send([]byte(`{"type":"player_selected","seq":0,"timestamp":0,"mono":0,"playerIndex":1}`))
+	if msg := recv(); msg.Type != "player_selected" {
+		t.Fatalf("got %q, want player_selected", msg.Type)
+	}
+	msg := recv()
+	if msg.Type != "delta" {
+		t.Fatalf("got %q, want the catch-up delta", msg.Type)
+	}
+	got := gamepad.ApplyDelta(gamepad.ApplyDelta(*full.Data, first.Changes), msg.Changes)
+	if !gamepad.ComputeDelta(got, state).IsEmpty() {
+		t.Errorf("after catching up = %+v, want %+v", got, state)
+	}
+}
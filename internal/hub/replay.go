@@ -0,0 +1,45 @@
+package hub
+
+import (
+	"context"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// PlayRecording feeds frames (as returned by LoadRecording) to inject as if
+// they were live input, each at its position in the recording divided by
+// speed, starting now, so overlays can be designed and the frontend debugged
+// without a controller attached. States without a player index are sent as
+// player 1, which clients follow by default. With loop the recording starts
+// over after its last frame; without, PlayRecording returns after it. It
+// also returns when ctx is done.
+func PlayRecording(ctx context.Context, frames []Frame, speed float64, loop bool, inject func(gamepad.GamepadState)) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+	for {
+		start := time.Now()
+		for _, f := range frames {
+			due := start.Add(time.Duration(float64(f.At) * float64(time.Millisecond) / speed))
+			if d := time.Until(due); d > 0 {
+				timer.Reset(d)
+				select {
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+				}
+			} else if ctx.Err() != nil {
+				return
+			}
+			s := f.State
+			if s.PlayerIndex == 0 {
+				s.PlayerIndex = 1
+			}
+			inject(s)
+		}
+		if !loop {
+			return
+		}
+	}
+}
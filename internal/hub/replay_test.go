@@ -0,0 +1,50 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestPlayRecording verifies frames are injected in order at their recorded
+// times scaled by the speed, as player 1, and that a looping playback starts
+// over until its context is cancelled.
+func TestPlayRecording(t *testing.T) {
+	a, b := fixtureXboxState(), fixtureXboxState()
+	a.Buttons.A = true
+	b.Buttons.B = true
+	a.PlayerIndex, b.PlayerIndex = 0, 0 // as replayed (see gamepad.ReplayCapture)
+	frames := []Frame{{At: 0, State: a}, {At: 200, State: b}}
+
+	var got []gamepad.GamepadState
+	start := time.Now()
+	PlayRecording(context.Background(), frames, 4, false, func(s gamepad.GamepadState) { got = append(got, s) })
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("playback at 4x took %v, want about 50ms", elapsed)
+	}
+	if len(got) != 2 || !got[0].Buttons.A || !got[1].Buttons.B || got[0].PlayerIndex != 1 {
+		t.Fatalf("injected %+v, want frame A then B as player 1", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	done := make(chan struct{})
+	go func() {
+		PlayRecording(ctx, frames, 16, true, func(gamepad.GamepadState) {
+			if n++; n == 5 {
+				cancel()
+			}
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("looping playback did not stop when its context was cancelled")
+	}
+	if n < 5 {
+		t.Errorf("looping playback injected %d states, want at least 5", n)
+	}
+}
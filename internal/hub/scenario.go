@@ -0,0 +1,95 @@
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/soar/inputview/pkg/gamepad"
+	"go.yaml.in/yaml/v3"
+)
+
+// Scenario is a hand-written input timeline (--scenario): the states a
+// controller goes through, each at its time, for reproducible frontend
+// regression tests and demo videos. Events use the shapes of POST /api/inject.
+type Scenario struct {
+	Events []ScenarioEvent `json:"events"`
+}
+
+// ScenarioEvent is one step of a Scenario: {"at":ms,"type":"full","data":{...}}
+// replaces the whole state, {"at":ms,"type":"delta","changes":{...}} is merged
+// onto the state of the event before.
+type ScenarioEvent struct {
+	At      int64                 `json:"at"` // milliseconds since the start of the scenario
+	Type    string                `json:"type"`
+	Data    *gamepad.GamepadState `json:"data,omitempty"`
+	Changes *gamepad.DeltaChanges `json:"changes,omitempty"`
+}
+
+// scenarioStart is the state the deltas before a scenario's first full event
+// are merged onto: a connected controller with nothing pressed.
+var scenarioStart = gamepad.GamepadState{Connected: true, ControllerType: "xbox", Name: "Scenario", PlayerIndex: 1}
+
+// LoadScenario reads a scenario, as JSON or (with isYAML) as the same document
+// in YAML, and returns its states as frames for PlayRecording. Unknown fields,
+// events out of time order, and states that fail GamepadState.Validate are
+// errors naming the event (1-based).
+func LoadScenario(rd io.Reader, isYAML bool) ([]Frame, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	if isYAML {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	}
+	var sc Scenario
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid scenario: trailing data after the scenario object")
+	}
+	if len(sc.Events) == 0 {
+		return nil, errors.New("scenario contains no events")
+	}
+
+	frames := make([]Frame, 0, len(sc.Events))
+	state := scenarioStart
+	for i, ev := range sc.Events {
+		switch {
+		case ev.At < 0:
+			return nil, fmt.Errorf("event %d: at must be >= 0, got %d", i+1, ev.At)
+		case i > 0 && ev.At < sc.Events[i-1].At:
+			return nil, fmt.Errorf("event %d: at %d is before the event before (%d)", i+1, ev.At, sc.Events[i-1].At)
+		}
+		switch ev.Type {
+		case "full":
+			if ev.Data == nil {
+				return nil, fmt.Errorf(`event %d: type "full" requires "data"`, i+1)
+			}
+			state = *ev.Data
+		case "delta":
+			if ev.Changes == nil {
+				return nil, fmt.Errorf(`event %d: type "delta" requires "changes"`, i+1)
+			}
+			state = gamepad.ApplyDelta(state, ev.Changes)
+		default:
+			return nil, fmt.Errorf(`event %d: type must be "full" or "delta", got %q`, i+1, ev.Type)
+		}
+		if err := state.Validate(); err != nil {
+			return nil, fmt.Errorf("event %d: invalid state: %w", i+1, err)
+		}
+		frames = append(frames, Frame{At: ev.At, State: state})
+	}
+	return frames, nil
+}
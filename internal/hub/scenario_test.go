@@ -0,0 +1,85 @@
+package hub
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLoadScenario verifies full and delta events become frames in order,
+// deltas before the first full start from a connected controller, and YAML
+// reads like the same JSON.
+func TestLoadScenario(t *testing.T) {
+	const json = `{"events":[
+		{"at":0,"type":"delta","changes":{"buttons":{"a":true}}},
+		{"at":250,"type":"delta","changes":{"triggers":{"lt":{"value":0.37}}}},
+		{"at":500,"type":"full","data":{"connected":true,"name":"Pad","playerIndex":2,"dpad":{"up":true}}}
+	]}`
+	const yaml = `
+events:
+  - at: 0
+    type: delta
+    changes: {buttons: {a: true}}
+  - at: 250
+    type: delta
+    changes:
+      triggers:
+        lt: {value: 0.37}
+  - at: 500
+    type: full
+    data: {connected: true, name: Pad, playerIndex: 2, dpad: {up: true}}
+`
+	for _, tt := range []struct {
+		name   string
+		doc    string
+		isYAML bool
+	}{{"json", json, false}, {"yaml", yaml, true}} {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, err := LoadScenario(strings.NewReader(tt.doc), tt.isYAML)
+			if err != nil {
+				t.Fatalf("LoadScenario: %v", err)
+			}
+			if len(frames) != 3 {
+				t.Fatalf("frames = %d, want 3", len(frames))
+			}
+			first, second, third := frames[0], frames[1], frames[2]
+			if !first.State.Connected || first.State.PlayerIndex != 1 || !first.State.Buttons.A {
+				t.Errorf("frame 1 = %+v, want a connected player 1 holding A", first.State)
+			}
+			if second.At != 250 || !second.State.Buttons.A || second.State.Triggers.LT.Value != 0.37 {
+				t.Errorf("frame 2 = %+v at %d, want A held and LT 0.37 at 250", second.State, second.At)
+			}
+			if third.At != 500 || third.State.Name != "Pad" || third.State.PlayerIndex != 2 || !third.State.Dpad.Up || third.State.Buttons.A {
+				t.Errorf("frame 3 = %+v at %d, want the full state at 500", third.State, third.At)
+			}
+		})
+	}
+}
+
+// TestLoadScenarioErrors verifies malformed scenarios are rejected with the
+// offending event named.
+func TestLoadScenarioErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, doc, want string
+	}{
+		{"malformed", `{"events":`, "invalid scenario"},
+		{"unknown field", `{"events":[],"loop":true}`, "invalid scenario"},
+		{"trailing data", `{"events":[]} {}`, "trailing data"},
+		{"no events", `{"events":[]}`, "no events"},
+		{"negative time", `{"events":[{"at":-1,"type":"full","data":{}}]}`, "event 1"},
+		{"out of order", `{"events":[{"at":100,"type":"full","data":{}},{"at":50,"type":"full","data":{}}]}`, "event 2"},
+		{"unknown type", `{"events":[{"at":0,"type":"km_full"}]}`, "event 1"},
+		{"full without data", `{"events":[{"at":0,"type":"full"}]}`, `requires "data"`},
+		{"delta without changes", `{"events":[{"at":0,"type":"delta"}]}`, `requires "changes"`},
+		{"invalid state", `{"events":[{"at":0,"type":"delta","changes":{"triggers":{"rt":{"value":1.5}}}}]}`, "invalid state"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadScenario(strings.NewReader(tt.doc), false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadScenario error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := LoadScenario(strings.NewReader("events: [unclosed"), true); err == nil || !strings.Contains(err.Error(), "invalid YAML") {
+		t.Errorf("LoadScenario of bad YAML error = %v, want invalid YAML", err)
+	}
+}
@@ -0,0 +1,187 @@
+package hub
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// DefaultSequenceWindow is how long a Sequence without a Window waits for its
+// next step.
+const DefaultSequenceWindow = 500 * time.Millisecond
+
+// Sequence is an input sequence, such as a fighting-game motion
+// (quarter-circle-forward + punch: "down", "down+right", "right", "x") or the
+// Konami code, announced as a "combo" message when a player enters its steps
+// in order, each within Window of the one before. A step's directions are
+// matched as held (exactly those, nothing else), on the D-pad or else the left
+// stick as in numpad notation (see notationDirection), its buttons as pressed
+// (see completes). An input that enters neither the next step nor
+// the first is ignored; one that enters only the first starts over.
+type Sequence struct {
+	Name   string
+	Steps  [][]string    // one combo per step, PressEdges naming; see ParseCombo
+	Window time.Duration // the most time from one step to the next
+}
+
+// ComboEvent is the payload of a "combo" message: a player entered a
+// Sequence.
+type ComboEvent struct {
+	Name       string `json:"name"`       // the [sequences.<name>] table
+	DurationMs int64  `json:"durationMs"` // from the first step to the last
+}
+
+// sequenceProgress is how far a player has entered a Sequence.
+type sequenceProgress struct {
+	next        int   // index of the step waited for; 0 = none entered
+	first, last int64 // Unix ms the first and the latest step were entered
+}
+
+// stepInput is the change of one state that steps are entered with.
+type stepInput struct {
+	pressed []string // controls just pressed (PressEdges naming)
+	held    []string // all controls held
+	dirs    []string // the directions held (see heldDirections)
+	moved   bool     // the held direction changed
+}
+
+// SetSequences announces each sequence to every client as a "combo" message
+// when a player enters it. nil (the default) disables sequences. Call before
+// Run.
+func (b *Broadcaster) SetSequences(seqs []Sequence) {
+	b.mu.Lock()
+	b.sequences = seqs
+	b.seqProgress = make(map[int][]sequenceProgress)
+	b.mu.Unlock()
+}
+
+// sequencesLocked advances the progress of state's player through the
+// sequences and returns those state completes at now. b.mu must be held, and
+// state not yet tracked.
+func (b *Broadcaster) sequencesLocked(state gamepad.GamepadState, now int64) []ComboEvent {
+	if len(b.sequences) == 0 {
+		return nil
+	}
+	var old gamepad.GamepadState
+	if ps, ok := b.players[state.PlayerIndex]; ok {
+		old = ps.last
+	}
+	dir := notationDirection(state)
+	in := stepInput{pressed: PressEdges(old, state), moved: notationDirection(old) != dir}
+	if len(in.pressed) == 0 && !in.moved {
+		return nil
+	}
+	in.held = PressEdges(gamepad.GamepadState{}, state)
+	in.dirs = heldDirections(dir)
+
+	progress := b.seqProgress[state.PlayerIndex]
+	if progress == nil {
+		progress = make([]sequenceProgress, len(b.sequences))
+		b.seqProgress[state.PlayerIndex] = progress
+	}
+	var fired []ComboEvent
+	for i, sq := range b.sequences {
+		p := &progress[i]
+		if p.next > 0 && now-p.last > sq.Window.Milliseconds() {
+			*p = sequenceProgress{}
+		}
+		n := advance(sq.Steps, p.next, in)
+		if n == p.next {
+			if p.next == 0 {
+				continue
+			}
+			if n = advance(sq.Steps, 0, in); n == 0 {
+				continue
+			}
+			p.next = 0 // started over
+		}
+		if p.next == 0 {
+			p.first = now
+		}
+		p.next, p.last = n, now
+		if n == len(sq.Steps) {
+			fired = append(fired, ComboEvent{Name: sq.Name, DurationMs: now - p.first})
+			*p = sequenceProgress{}
+		}
+	}
+	return fired
+}
+
+// advance returns the index of the step waited for after in, starting from
+// step i: one input change enters at most one step of directions only and
+// then one with buttons, so a punch pressed with the last direction of a
+// motion counts, but a press never enters two steps.
+func advance(steps [][]string, i int, in stepInput) int {
+	if i < len(steps) && !hasButtons(steps[i]) && in.enters(steps[i]) {
+		i++
+	}
+	if i < len(steps) && hasButtons(steps[i]) && in.enters(steps[i]) {
+		i++
+	}
+	return i
+}
+
+// enters reports whether in enters step: exactly the step's directions are
+// held, if it has any, and its buttons are completed (see completes); a step
+// of directions only needs the held direction to have moved to them.
+func (in stepInput) enters(step []string) bool {
+	dirs := directions(step)
+	sameDirs := len(dirs) == len(in.dirs) && holds(dirs, in.dirs)
+	if !hasButtons(step) {
+		return in.moved && sameDirs
+	}
+	buttons := slices.DeleteFunc(slices.Clone(step), isDirection)
+	return (len(dirs) == 0 || sameDirs) && completes(buttons, in.pressed, in.held)
+}
+
+// heldDirections returns the directions of a numpad direction (see
+// notationDirection) in PressEdges naming: "dpad.down" and "dpad.right" for
+// 3, none for 5.
+func heldDirections(numpad int) []string {
+	x, y := (numpad-1)%3-1, (numpad-1)/3-1
+	var dirs []string
+	switch {
+	case y > 0:
+		dirs = append(dirs, "dpad.up")
+	case y < 0:
+		dirs = append(dirs, "dpad.down")
+	}
+	switch {
+	case x < 0:
+		dirs = append(dirs, "dpad.left")
+	case x > 0:
+		dirs = append(dirs, "dpad.right")
+	}
+	return dirs
+}
+
+// directions returns the D-pad directions among controls.
+func directions(controls []string) []string {
+	var dirs []string
+	for _, c := range controls {
+		if isDirection(c) {
+			dirs = append(dirs, c)
+		}
+	}
+	return dirs
+}
+
+// isDirection reports whether the control (PressEdges naming) is a D-pad
+// direction.
+func isDirection(c string) bool { return strings.HasPrefix(c, "dpad.") }
+
+// hasButtons reports whether a step has controls other than D-pad directions.
+func hasButtons(step []string) bool {
+	return slices.ContainsFunc(step, func(c string) bool { return !isDirection(c) })
+}
+
+// broadcastCombo announces an entered sequence to every client.
+func (b *Broadcaster) broadcastCombo(playerIndex int, ev ComboEvent) {
+	slog.Debug("sequence entered", "sequence", ev.Name, "player", playerIndex, "ms", ev.DurationMs)
+	if data, ok := marshalOrLog("combo message", NewComboMessage(playerIndex, &ev)); ok {
+		b.hub.BroadcastAll(data)
+	}
+}
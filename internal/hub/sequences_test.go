@@ -0,0 +1,170 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// sequenceSteps parses the steps of a sequence or fails the test.
+func sequenceSteps(t *testing.T, combos ...string) [][]string {
+	t.Helper()
+	var steps [][]string
+	for _, c := range combos {
+		controls, err := ParseCombo(c)
+		if err != nil {
+			t.Fatalf("ParseCombo(%q): %v", c, err)
+		}
+		steps = append(steps, controls)
+	}
+	return steps
+}
+
+// TestSequences verifies a motion sequence is entered through D-pad changes
+// (releases included), with its button pressed on the last direction, that
+// unrelated inputs are ignored, that a late step starts over, and that a
+// repeated direction needs the D-pad to leave it in between.
+func TestSequences(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetSequences([]Sequence{
+		{Name: "hadouken", Steps: sequenceSteps(t, "down", "down+right", "right", "x"), Window: DefaultSequenceWindow},
+		{Name: "double-up", Steps: sequenceSteps(t, "up", "up"), Window: DefaultSequenceWindow},
+	})
+	idle := fixtureXboxState()
+	pad := func(up, down, left, right bool) gamepad.GamepadState {
+		s := idle
+		s.Dpad = gamepad.DpadState{Up: up, Down: down, Left: left, Right: right}
+		return s
+	}
+	down, downRight, right := pad(false, true, false, false), pad(false, true, false, true), pad(false, false, false, true)
+	rightX := right
+	rightX.Buttons.X = true
+	rightA := right
+	rightA.Buttons.A = true
+	up := pad(true, false, false, false)
+
+	steps := []struct {
+		name  string
+		state gamepad.GamepadState
+		at    int64
+		fires string
+	}{
+		{"down", down, 1000, ""},
+		{"down-right", downRight, 1050, ""},
+		{"right (down released)", right, 1100, ""},
+		{"a pressed instead", rightA, 1120, ""},
+		{"a released", right, 1130, ""},
+		{"x", rightX, 1180, "hadouken"},
+		{"released", idle, 1200, ""},
+
+		{"down again", down, 2000, ""},
+		{"down-right", downRight, 2050, ""},
+		{"right and x at once", rightX, 2100, "hadouken"},
+		{"released", idle, 2150, ""},
+
+		{"down, slowly", down, 3000, ""},
+		{"down-right too late", downRight, 3600, ""},
+		{"right", right, 3650, ""},
+		{"x", rightX, 3700, ""},
+		{"released", idle, 3750, ""},
+
+		{"up", up, 4000, ""},
+		{"up held, x", func() gamepad.GamepadState { s := up; s.Buttons.X = true; return s }(), 4050, ""},
+		{"neutral", idle, 4100, ""},
+		{"up again", up, 4150, "double-up"},
+	}
+	for _, step := range steps {
+		b.mu.Lock()
+		fired := b.sequencesLocked(step.state, step.at)
+		b.stateMessageLocked(step.state, step.at)
+		b.mu.Unlock()
+		got := ""
+		if len(fired) > 1 {
+			t.Fatalf("%s: fired %v, want at most one", step.name, fired)
+		}
+		if len(fired) == 1 {
+			got = fired[0].Name
+		}
+		if got != step.fires {
+			t.Errorf("%s: fired %q, want %q", step.name, got, step.fires)
+		}
+	}
+
+	b.SetSequences(nil)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if fired := b.sequencesLocked(down, 5000); fired != nil {
+		t.Errorf("without sequences fired %v", fired)
+	}
+}
+
+// TestSequenceDuration verifies a combo reports the time from its first step
+// to its last, and that each player has its own progress.
+func TestSequenceDuration(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetSequences([]Sequence{{Name: "ab", Steps: sequenceSteps(t, "a", "b"), Window: DefaultSequenceWindow}})
+	a1, b1 := fixtureXboxState(), fixtureXboxState()
+	a1.Buttons.A, b1.Buttons.B = true, true
+	a2 := a1
+	a2.PlayerIndex = 2
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range []struct {
+		state gamepad.GamepadState
+		at    int64
+	}{{a1, 1000}, {a2, 1100}} {
+		if fired := b.sequencesLocked(s.state, s.at); fired != nil {
+			t.Fatalf("first step fired %v", fired)
+		}
+		b.stateMessageLocked(s.state, s.at)
+	}
+	fired := b.sequencesLocked(b1, 1240)
+	if len(fired) != 1 || fired[0] != (ComboEvent{Name: "ab", DurationMs: 240}) {
+		t.Errorf("player 1 fired %v, want ab in 240 ms", fired)
+	}
+}
+
+// TestSequenceStick verifies a motion is entered with the left stick as with
+// the D-pad, its direction rounded as in numpad notation, and that stick
+// movement within the same direction does not count as a new step.
+func TestSequenceStick(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	b.SetSequences([]Sequence{
+		{Name: "hadouken", Steps: sequenceSteps(t, "down", "down+right", "right", "x"), Window: DefaultSequenceWindow},
+		{Name: "double-down", Steps: sequenceSteps(t, "down", "down"), Window: DefaultSequenceWindow},
+	})
+	idle := fixtureXboxState()
+	stick := func(x, y float64, punch bool) gamepad.GamepadState {
+		s := idle
+		s.Sticks.Left.Position = gamepad.Vector{X: x, Y: y}
+		s.Buttons.X = punch
+		return s
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, step := range []struct {
+		name  string
+		state gamepad.GamepadState
+		at    int64
+		fires string
+	}{
+		{"down", stick(0.1, -0.9, false), 1000, ""},
+		{"further down", stick(0, -1, false), 1020, ""}, // not double-down
+		{"down-right", stick(0.6, -0.6, false), 1050, ""},
+		{"right", stick(0.95, -0.1, false), 1100, ""},
+		{"x", stick(0.95, -0.1, true), 1150, "hadouken"},
+		{"back to neutral", stick(0.2, 0, false), 1200, ""},
+	} {
+		fired := b.sequencesLocked(step.state, step.at)
+		b.stateMessageLocked(step.state, step.at)
+		got := ""
+		if len(fired) == 1 {
+			got = fired[0].Name
+		}
+		if len(fired) > 1 || got != step.fires {
+			t.Errorf("%s: fired %v, want %q", step.name, fired, step.fires)
+		}
+	}
+}
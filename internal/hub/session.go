@@ -0,0 +1,125 @@
+package hub
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// apmWindow is the span SessionStats.APM counts presses over.
+const apmWindow = int64(time.Minute / time.Millisecond)
+
+// StickTravel is how far each stick has moved, in stick radii: pushing a
+// stick from the centre to the edge and back is 2.
+type StickTravel struct {
+	Left  float64 `json:"left"`
+	Right float64 `json:"right"`
+}
+
+// SessionStats are a player's input statistics since the server started, the
+// payload of a "stats" message and of GET /api/stats, for end-of-stream
+// summaries.
+type SessionStats struct {
+	PlayerIndex  int           `json:"playerIndex"`
+	Presses      PressCounters `json:"presses"`      // per control
+	TotalPresses int64         `json:"totalPresses"` // of all controls
+	APM          int           `json:"apm"`          // actions (presses) per minute: the presses of the last minute
+	AverageAPM   float64       `json:"averageApm"`   // presses per minute since presses.since (over at least a minute)
+	Travel       StickTravel   `json:"travel"`
+}
+
+// session adds the change from old to new_, which pressed presses controls
+// at now, to the player's stick travel and recent presses. Travel only
+// counts between connected states, so plugging in or unplugging a
+// controller with a pushed stick is not seen as movement.
+func (ps *playerStats) session(old, new_ gamepad.GamepadState, presses, now int64) {
+	if old.Connected && new_.Connected {
+		ps.travel.Left += distance(old.Sticks.Left.Position, new_.Sticks.Left.Position)
+		ps.travel.Right += distance(old.Sticks.Right.Position, new_.Sticks.Right.Position)
+	}
+	for range presses {
+		ps.recent = append(ps.recent, now)
+	}
+	ps.expireRecent(now)
+}
+
+// distance returns how far apart stick positions a and b are.
+func distance(a, b gamepad.Vector) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+// expireRecent drops the presses older than apmWindow before now.
+func (ps *playerStats) expireRecent(now int64) {
+	i := sort.Search(len(ps.recent), func(i int) bool { return ps.recent[i] > now-apmWindow })
+	ps.recent = ps.recent[i:]
+}
+
+// stats returns the session statistics of the player, playerIndex, at now.
+func (ps *playerStats) stats(playerIndex int, now int64) SessionStats {
+	ps.expireRecent(now)
+	total := ps.counts.total()
+	minutes := float64(max(now-ps.counts.Since, apmWindow)) / float64(apmWindow)
+	return SessionStats{
+		PlayerIndex:  playerIndex,
+		Presses:      ps.counts,
+		TotalPresses: total,
+		APM:          len(ps.recent),
+		AverageAPM:   math.Round(float64(total)/minutes*10) / 10,
+		Travel:       StickTravel{Left: round3(ps.travel.Left), Right: round3(ps.travel.Right)},
+	}
+}
+
+// allSessionStatsLocked returns the session statistics of every player seen
+// so far, by player index. b.mu must be held.
+func (b *Broadcaster) allSessionStatsLocked(now int64) []SessionStats {
+	stats := []SessionStats{}
+	for _, i := range slices.Sorted(maps.Keys(b.players)) {
+		if i > 0 {
+			stats = append(stats, b.players[i].stats(i, now))
+		}
+	}
+	return stats
+}
+
+// SessionStats returns the session statistics of the given player, or nil if
+// no state has been seen for it yet. Safe to call from any goroutine.
+func (b *Broadcaster) SessionStats(playerIndex int) *SessionStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ps, ok := b.players[playerIndex]
+	if !ok {
+		return nil
+	}
+	stats := ps.stats(playerIndex, time.Now().UnixMilli())
+	return &stats
+}
+
+// AllSessionStats returns the session statistics of every player seen so
+// far, by player index (never nil). Safe to call from any goroutine.
+func (b *Broadcaster) AllSessionStats() []SessionStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.allSessionStatsLocked(time.Now().UnixMilli())
+}
+
+// SetStatsInterval sends every player's session statistics to its viewers as
+// a "stats" message every interval. 0 (the default) sends none. Call before
+// Run.
+func (b *Broadcaster) SetStatsInterval(interval time.Duration) {
+	b.mu.Lock()
+	b.statsInterval = interval
+	b.mu.Unlock()
+}
+
+// broadcastStats sends each player's session statistics to its viewers.
+func (b *Broadcaster) broadcastStats(stats []SessionStats) {
+	for i := range stats {
+		if data, ok := marshalOrLog("stats message", NewStatsMessage(&stats[i])); ok {
+			b.hub.BroadcastToPlayer(data, stats[i].PlayerIndex)
+		}
+	}
+}
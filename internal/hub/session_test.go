@@ -0,0 +1,58 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestSessionStats verifies the stick travel between connected states, the
+// presses of the last minute (APM) and their average since counting began,
+// and that each player is reported on its own.
+func TestSessionStats(t *testing.T) {
+	b := NewBroadcaster(NewHub(), nil, nil)
+	now := time.Now().UnixMilli()
+	b.started = now - 3*apmWindow // three minutes ago
+	if got := b.SessionStats(1); got != nil {
+		t.Fatalf("SessionStats(1) before any state = %+v, want nil", got)
+	}
+
+	idle := fixtureXboxState()
+	at := func(s gamepad.GamepadState, x, y float64, a bool) gamepad.GamepadState {
+		s.Sticks.Left.Position = gamepad.Vector{X: x, Y: y}
+		s.Buttons.A = a
+		return s
+	}
+	p2 := idle
+	p2.PlayerIndex, p2.Buttons.B = 2, true
+	steps := []struct {
+		state gamepad.GamepadState
+		at    int64
+	}{
+		{at(idle, 0, 0, true), now - 2*apmWindow}, // a press too old for APM
+		{at(idle, 0, 0, false), now - 2*apmWindow + 100},
+		{at(idle, 0.6, 0.8, true), now - 500}, // travel 1
+		{at(idle, 0, 0, false), now - 400},    // travel 2
+		{gamepad.GamepadState{PlayerIndex: 1}, now - 300},
+		{at(idle, 0.9, 0, true), now - 200}, // reconnected pushed: no travel
+		{p2, now - 100},
+	}
+	b.mu.Lock()
+	for _, s := range steps {
+		b.trackLocked(s.state, s.at)
+	}
+	b.mu.Unlock()
+
+	s1 := b.SessionStats(1)
+	if s1 == nil || s1.TotalPresses != 3 || s1.Presses.Buttons.A != 3 || s1.APM != 2 || s1.AverageAPM != 1 {
+		t.Errorf("player 1 = %+v, want 3 presses, 2 in the last minute, 1 a minute", s1)
+	}
+	if s1 != nil && s1.Travel != (StickTravel{Left: 2}) {
+		t.Errorf("player 1 travel = %+v, want 2 for the left stick", s1.Travel)
+	}
+	all := b.AllSessionStats()
+	if len(all) != 2 || all[0].PlayerIndex != 1 || all[1].PlayerIndex != 2 || all[1].TotalPresses != 1 || all[1].Presses.Buttons.B != 1 {
+		t.Errorf("AllSessionStats = %+v, want player 1, then player 2 with one B", all)
+	}
+}
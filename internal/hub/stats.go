@@ -0,0 +1,40 @@
+package hub
+
+import "sync/atomic"
+
+// Stats are the hub's running totals since NewHub, for monitoring (see
+// Hub.Stats). All counts only grow.
+type Stats struct {
+	Broadcasts  uint64 // messages fanned out to the clients they target (however many)
+	Sent        uint64 // messages queued for writing to a client
+	QueueDrops  uint64 // messages dropped because the broadcast queue was full (see SetBroadcastBuffer)
+	ClientDrops uint64 // messages dropped because a client's send buffer was full (see SetClientBuffer)
+	WriteErrors uint64 // messages whose write to the client's connection failed
+}
+
+// hubStats holds the counters behind Stats.
+type hubStats struct {
+	broadcasts  atomic.Uint64
+	sent        atomic.Uint64
+	queueDrops  atomic.Uint64
+	clientDrops atomic.Uint64
+	writeErrors atomic.Uint64
+}
+
+// Stats returns the hub's running totals. Safe to call from any goroutine.
+func (h *Hub) Stats() Stats {
+	return Stats{
+		Broadcasts:  h.stats.broadcasts.Load(),
+		Sent:        h.stats.sent.Load(),
+		QueueDrops:  h.stats.queueDrops.Load(),
+		ClientDrops: h.stats.clientDrops.Load(),
+		WriteErrors: h.stats.writeErrors.Load(),
+	}
+}
+
+// written is the WriteAsync callback of a message: it counts failed writes.
+func (s *hubStats) written(err error) {
+	if err != nil {
+		s.writeErrors.Add(1)
+	}
+}
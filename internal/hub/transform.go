@@ -0,0 +1,146 @@
+package hub
+
+import "github.com/soar/inputview/pkg/gamepad"
+
+// Transform rearranges the emitted state for an overlay drawn in a different
+// orientation, so the physical control is shown where the overlay draws it.
+// The steps are applied in field order. The zero value changes nothing.
+type Transform struct {
+	// Mirror flips the layout horizontally: left and right sticks (with X
+	// negated), LB/RB, LT/RT, Back/Start, dpad left/right, and the X/B face
+	// buttons trade places, as do the left and right paddles and function
+	// buttons.
+	Mirror bool
+	// Rotate turns the layout clockwise by 0, 90, 180, or 270 degrees: stick
+	// vectors, dpad directions, and the face-button diamond (Y top, B right,
+	// A bottom, X left) rotate; shoulders and center buttons stay.
+	Rotate int
+	// SwapShoulders trades LB with RB and LT with RT.
+	SwapShoulders bool
+	// SwapSticks trades the left and right sticks, clicks included
+	// (southpaw), without mirroring their vectors.
+	SwapSticks bool
+	// SwapTriggers trades LT with RT only, leaving LB and RB in place.
+	SwapTriggers bool
+}
+
+// IsZero reports whether t leaves states unchanged.
+func (t Transform) IsZero() bool { return t == Transform{} }
+
+// State returns s transformed. Identity, capabilities, battery, motion, the
+// wheel, the flight stick, and raw readings are kept.
+func (t Transform) State(s gamepad.GamepadState) gamepad.GamepadState {
+	s.Buttons = t.buttons(s.Buttons)
+	s.Extra = t.extra(s.Extra)
+	s.Dpad = t.dpad(s.Dpad)
+	s.Sticks = t.sticks(s.Sticks)
+	s.Triggers = t.triggers(s.Triggers)
+	return s
+}
+
+// Delta returns a copy of d with every present group transformed. Each
+// transform only moves values within a group (buttons, extra buttons, dpad,
+// sticks, triggers), and a delta carries changed groups whole, so this equals the
+// delta between the transformed states.
+func (t Transform) Delta(d *gamepad.DeltaChanges) *gamepad.DeltaChanges {
+	c := *d
+	if d.Buttons != nil {
+		b := t.buttons(*d.Buttons)
+		c.Buttons = &b
+	}
+	if d.Extra != nil {
+		e := t.extra(*d.Extra)
+		c.Extra = &e
+	}
+	if d.Dpad != nil {
+		dp := t.dpad(*d.Dpad)
+		c.Dpad = &dp
+	}
+	if d.Sticks != nil {
+		s := t.sticks(*d.Sticks)
+		c.Sticks = &s
+	}
+	if d.Triggers != nil {
+		tr := t.triggers(*d.Triggers)
+		c.Triggers = &tr
+	}
+	return &c
+}
+
+// quarterTurns returns Rotate as a number of clockwise quarter turns (0-3).
+func (t Transform) quarterTurns() int {
+	return ((t.Rotate / 90 % 4) + 4) % 4
+}
+
+func (t Transform) buttons(b gamepad.ButtonState) gamepad.ButtonState {
+	if t.Mirror {
+		b.X, b.B = b.B, b.X
+		b.LB, b.RB = b.RB, b.LB
+		b.Back, b.Start = b.Start, b.Back
+	}
+	for range t.quarterTurns() {
+		// Clockwise: top → right → bottom → left → top.
+		b.Y, b.B, b.A, b.X = b.X, b.Y, b.B, b.A
+	}
+	if t.SwapShoulders {
+		b.LB, b.RB = b.RB, b.LB
+	}
+	return b
+}
+
+func (t Transform) extra(e gamepad.ExtraButtonState) gamepad.ExtraButtonState {
+	if t.Mirror {
+		e.P1, e.P2 = e.P2, e.P1
+		e.P3, e.P4 = e.P4, e.P3
+		e.Fn1, e.Fn2 = e.Fn2, e.Fn1
+	}
+	return e
+}
+
+func (t Transform) dpad(d gamepad.DpadState) gamepad.DpadState {
+	if t.Mirror {
+		d.Left, d.Right = d.Right, d.Left
+	}
+	for range t.quarterTurns() {
+		d.Up, d.Right, d.Down, d.Left = d.Left, d.Up, d.Right, d.Down
+	}
+	return d
+}
+
+func (t Transform) sticks(s gamepad.SticksState) gamepad.SticksState {
+	if t.Mirror {
+		s.Left, s.Right = s.Right, s.Left
+		s.Left.Position.X = neg(s.Left.Position.X)
+		s.Right.Position.X = neg(s.Right.Position.X)
+	}
+	for range t.quarterTurns() {
+		// Y is up-positive: rotating (x, y) clockwise gives (y, -x).
+		s.Left.Position = gamepad.Vector{X: s.Left.Position.Y, Y: neg(s.Left.Position.X)}
+		s.Right.Position = gamepad.Vector{X: s.Right.Position.Y, Y: neg(s.Right.Position.X)}
+	}
+	if t.SwapSticks {
+		s.Left, s.Right = s.Right, s.Left
+	}
+	return s
+}
+
+func (t Transform) triggers(tr gamepad.TriggersState) gamepad.TriggersState {
+	if t.Mirror {
+		tr.LT, tr.RT = tr.RT, tr.LT
+	}
+	if t.SwapShoulders {
+		tr.LT, tr.RT = tr.RT, tr.LT
+	}
+	if t.SwapTriggers {
+		tr.LT, tr.RT = tr.RT, tr.LT
+	}
+	return tr
+}
+
+// neg negates v without producing -0, which would encode as "-0".
+func neg(v float64) float64 {
+	if v == 0 {
+		return 0
+	}
+	return -v
+}
@@ -0,0 +1,98 @@
+package hub
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+func TestTransform(t *testing.T) {
+	in := fixtureXboxState()
+	in.Buttons.Y = true  // top
+	in.Buttons.LB = true // left shoulder
+	in.Buttons.Back = true
+	in.Extra.P1 = true  // right upper paddle
+	in.Extra.Fn1 = true // left function button
+	in.Dpad.Up = true
+	in.Sticks.Left.Position = gamepad.Vector{X: 0.5, Y: 0.25}
+	in.Sticks.Right.Position = gamepad.Vector{X: -1, Y: 0}
+	in.Triggers.LT.Value = 0.75
+
+	tests := []struct {
+		name string
+		t    Transform
+		want func(s *gamepad.GamepadState)
+	}{
+		{"zero", Transform{}, func(s *gamepad.GamepadState) {}},
+		{"mirror", Transform{Mirror: true}, func(s *gamepad.GamepadState) {
+			s.Buttons.LB, s.Buttons.RB = false, true
+			s.Buttons.Back, s.Buttons.Start = false, true
+			s.Extra.P1, s.Extra.P2 = false, true
+			s.Extra.Fn1, s.Extra.Fn2 = false, true
+			s.Sticks.Left.Position = gamepad.Vector{X: 1, Y: 0}
+			s.Sticks.Right.Position = gamepad.Vector{X: -0.5, Y: 0.25}
+			s.Triggers.LT.Value, s.Triggers.RT.Value = 0, 0.75
+		}},
+		{"rotate 90", Transform{Rotate: 90}, func(s *gamepad.GamepadState) {
+			s.Buttons.Y, s.Buttons.B = false, true
+			s.Dpad.Up, s.Dpad.Right = false, true
+			s.Sticks.Left.Position = gamepad.Vector{X: 0.25, Y: -0.5}
+			s.Sticks.Right.Position = gamepad.Vector{X: 0, Y: 1}
+		}},
+		{"rotate 180", Transform{Rotate: 180}, func(s *gamepad.GamepadState) {
+			s.Buttons.Y, s.Buttons.A = false, true
+			s.Dpad.Up, s.Dpad.Down = false, true
+			s.Sticks.Left.Position = gamepad.Vector{X: -0.5, Y: -0.25}
+			s.Sticks.Right.Position = gamepad.Vector{X: 1, Y: 0}
+		}},
+		{"rotate 270", Transform{Rotate: 270}, func(s *gamepad.GamepadState) {
+			s.Buttons.Y, s.Buttons.X = false, true
+			s.Dpad.Up, s.Dpad.Left = false, true
+			s.Sticks.Left.Position = gamepad.Vector{X: -0.25, Y: 0.5}
+			s.Sticks.Right.Position = gamepad.Vector{X: 0, Y: -1}
+		}},
+		{"swap shoulders", Transform{SwapShoulders: true}, func(s *gamepad.GamepadState) {
+			s.Buttons.LB, s.Buttons.RB = false, true
+			s.Triggers.LT.Value, s.Triggers.RT.Value = 0, 0.75
+		}},
+		{"swap sticks", Transform{SwapSticks: true}, func(s *gamepad.GamepadState) {
+			s.Sticks.Left, s.Sticks.Right = s.Sticks.Right, s.Sticks.Left
+		}},
+		{"swap triggers", Transform{SwapTriggers: true}, func(s *gamepad.GamepadState) {
+			s.Triggers.LT.Value, s.Triggers.RT.Value = 0, 0.75
+		}},
+		{"southpaw rotated", Transform{Rotate: 90, SwapSticks: true}, func(s *gamepad.GamepadState) {
+			s.Buttons.Y, s.Buttons.B = false, true
+			s.Dpad.Up, s.Dpad.Right = false, true
+			s.Sticks.Left.Position = gamepad.Vector{X: 0, Y: 1}
+			s.Sticks.Right.Position = gamepad.Vector{X: 0.25, Y: -0.5}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := in
+			tt.want(&want)
+			if got := tt.t.State(in); !reflect.DeepEqual(got, want) {
+				t.Errorf("State() =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTransformDelta(t *testing.T) {
+	tr := Transform{Mirror: true, Rotate: 90, SwapShoulders: true, SwapSticks: true, SwapTriggers: true}
+	old := fixtureXboxState()
+	new_ := old
+	new_.Buttons.X = true
+	new_.Extra.P3 = true
+	new_.Dpad.Left = true
+	new_.Sticks.Left.Position = gamepad.Vector{X: 0.5, Y: -0.25}
+	new_.Triggers.RT.Value = 0.5
+
+	got := tr.Delta(gamepad.ComputeDelta(old, new_))
+	want := gamepad.ComputeDelta(tr.State(old), tr.State(new_))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Delta() =\n%+v\nwant the delta of the transformed states\n%+v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+// Package marker keeps the named, timestamped markers users drop during a
+// session — with a controller button combo or POST /api/markers — so
+// highlight moments are easy to find after the stream. Markers are kept in
+// memory and, while raw input is captured, in a JSON Lines file next to the
+// recording.
+package marker
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources (Marker.Source): how the marker was dropped.
+const (
+	SourceCombo  = "combo"  // a configured controller button combo
+	SourceAPI    = "api"    // POST /api/markers
+	SourcePlugin = "plugin" // a [plugins] process (see internal/plugin)
+)
+
+// MaxNameLen caps the length of a marker name in bytes.
+const MaxNameLen = 64
+
+// maxMarkers is the number of markers kept in memory. The file keeps
+// everything.
+const maxMarkers = 10000
+
+// Marker is one dropped marker.
+type Marker struct {
+	ID          int    `json:"id"`                    // 1-based, in order of creation
+	Name        string `json:"name"`                  // e.g. "highlight"; "marker <id>" when none was given
+	Time        int64  `json:"time"`                  // Unix milliseconds
+	Source      string `json:"source"`                // Source* constant
+	PlayerIndex int    `json:"playerIndex,omitempty"` // for SourceCombo: the player who pressed the combo
+}
+
+// List is the session's markers. A nil *List keeps nothing and has no
+// markers. Safe for concurrent use.
+type List struct {
+	mu      sync.Mutex
+	markers []Marker // oldest first, at most maxMarkers
+	nextID  int
+	file    *os.File // nil = memory only
+}
+
+// New returns a list kept in memory only when path is empty. Otherwise the
+// markers are also written to path, which is truncated: like the recording it
+// belongs to, it covers one session.
+func New(path string) (*List, error) {
+	l := &List{nextID: 1}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create marker file: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// FileFor returns the marker file kept next to the raw capture at path:
+// "run.jsonl" → "run.markers.jsonl".
+func FileFor(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".markers.jsonl"
+}
+
+// CheckName reports whether name can be used for a marker: at most
+// MaxNameLen bytes and without control characters, since exports put it on a
+// single line.
+func CheckName(name string) error {
+	if len(name) > MaxNameLen {
+		return fmt.Errorf("name too long: %d bytes (max %d)", len(name), MaxNameLen)
+	}
+	if strings.ContainsFunc(name, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return fmt.Errorf("name must not contain control characters")
+	}
+	return nil
+}
+
+// Add drops a marker named name (see CheckName; empty = "marker <id>") now,
+// appends it to the file, and returns it. A failed file write is logged; the
+// marker is still kept in memory.
+func (l *List) Add(name, source string, playerIndex int) Marker {
+	if l == nil {
+		return Marker{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m := Marker{ID: l.nextID, Name: name, Time: time.Now().UnixMilli(), Source: source, PlayerIndex: playerIndex}
+	l.nextID++
+	if m.Name == "" {
+		m.Name = fmt.Sprintf("marker %d", m.ID)
+	}
+	if len(l.markers) == maxMarkers {
+		copy(l.markers, l.markers[1:])
+		l.markers = l.markers[:maxMarkers-1]
+	}
+	l.markers = append(l.markers, m)
+	slog.Info("marker added", "id", m.ID, "name", m.Name, "source", source)
+
+	if l.file != nil {
+		data, err := json.Marshal(m)
+		if err == nil {
+			_, err = l.file.Write(append(data, '\n'))
+		}
+		if err != nil {
+			slog.Error("error writing marker file", "error", err)
+		}
+	}
+	return m
+}
+
+// All returns the in-memory markers, oldest first (never nil).
+func (l *List) All() []Marker {
+	if l == nil {
+		return []Marker{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Marker{}, l.markers...)
+}
+
+// Close closes the file, if any. Later markers are kept in memory only.
+func (l *List) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,56 @@
+package marker
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.markers.jsonl")
+	os.WriteFile(path, []byte(`{"id":1,"name":"previous session"}`+"\n"), 0o600)
+	l, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Add("highlight", SourceCombo, 2)
+	l.Add("", SourceAPI, 0)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	all := l.All()
+	if len(all) != 2 || all[0].Name != "highlight" || all[0].PlayerIndex != 2 || all[1].Name != "marker 2" || all[1].ID != 2 {
+		t.Fatalf("All() = %+v, want highlight (player 2) and marker 2", all)
+	}
+
+	// The file is truncated at start and holds this session's markers.
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []Marker
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var m Marker
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, m)
+	}
+	if len(got) != 2 || got[0] != all[0] || got[1] != all[1] {
+		t.Errorf("file = %+v, want %+v", got, all)
+	}
+
+	if FileFor("captures/run.jsonl") != "captures/run.markers.jsonl" {
+		t.Errorf("FileFor() = %q", FileFor("captures/run.jsonl"))
+	}
+	for _, name := range []string{"two\nlines", string(make([]byte, MaxNameLen+1))} {
+		if CheckName(name) == nil {
+			t.Errorf("CheckName(%q) = nil, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package plugin runs extension processes next to the server, so community
+// features (custom detectors, integrations) need not land in core. A plugin
+// is an external command speaking JSON Lines over stdio: it reads the
+// messages broadcast to clients on stdin (see hub.Hub.SetTap) and writes
+// Commands to stdout to drop markers, freeze the display, or send its own
+// "plugin_event" messages to every client. Its stderr goes to the log.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/marker"
+)
+
+const (
+	// observeBuffer is how many messages wait for a plugin's stdin; when it
+	// is full, new ones are dropped so a slow plugin never stalls clients.
+	observeBuffer = 256
+
+	// maxLineSize caps a line a plugin writes (stdout and stderr).
+	maxLineSize = 64 << 10
+
+	// stopTimeout is how long a plugin has to exit after its stdin is closed
+	// at shutdown before it is killed.
+	stopTimeout = 2 * time.Second
+
+	// maxEventNameLen caps Command.Name for "event".
+	maxEventNameLen = 64
+)
+
+// Host is what plugin commands act on; *hub.Broadcaster implements it.
+type Host interface {
+	AddMarker(name, source string, playerIndex int) marker.Marker
+	Freeze(d time.Duration) hub.FreezeInfo
+	Unfreeze() bool
+	BroadcastPluginEvent(ev hub.PluginEvent)
+}
+
+// Command is one line a plugin writes to stdout.
+type Command struct {
+	Type        string          `json:"type"`                  // "marker", "freeze", "unfreeze", or "event"
+	Name        string          `json:"name,omitempty"`        // marker: its name (empty = "marker <id>"); event: the event name
+	PlayerIndex int             `json:"playerIndex,omitempty"` // marker: the player it concerns
+	Seconds     int             `json:"seconds,omitempty"`     // freeze: 0 = until released
+	Data        json.RawMessage `json:"data,omitempty"`        // event: any JSON, sent on as is
+}
+
+// Process is a running plugin.
+type Process struct {
+	name  string
+	host  Host
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	in    chan []byte
+	done  chan struct{}
+}
+
+// Start runs command (program and arguments) as the plugin name until ctx
+// is done, when its stdin is closed and, after stopTimeout, it is killed.
+func Start(ctx context.Context, name string, command []string, host Host) (*Process, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	hideWindow(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Cancel = stdin.Close
+	cmd.WaitDelay = stopTimeout
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+
+	p := &Process{name: name, host: host, cmd: cmd, stdin: stdin, in: make(chan []byte, observeBuffer), done: make(chan struct{})}
+	go p.write()
+	go p.read(stdout)
+	go p.logStderr(stderr)
+	go func() {
+		err := cmd.Wait()
+		if ctx.Err() == nil {
+			slog.Warn("plugin exited", "plugin", name, "error", err)
+		}
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Observe queues msg (one JSON message) for the plugin's stdin. It never
+// blocks: when the plugin falls behind, msg is dropped.
+func (p *Process) Observe(msg []byte) {
+	select {
+	case p.in <- msg:
+	default:
+		slog.Debug("plugin queue full, dropping message", "plugin", p.name, "capacity", cap(p.in))
+	}
+}
+
+// Done is closed once the plugin has exited.
+func (p *Process) Done() <-chan struct{} { return p.done }
+
+// write copies the queued messages to stdin, one per line, until the plugin
+// exits. msg is shared with the clients' queues, so it is never appended to.
+func (p *Process) write() {
+	w := bufio.NewWriter(p.stdin)
+	for {
+		select {
+		case msg := <-p.in:
+			w.Write(msg)
+			w.WriteByte('\n')
+			if len(p.in) > 0 {
+				continue // flush once the queue is drained
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// read runs the Commands on stdout until the plugin closes it.
+func (p *Process) read(stdout io.Reader) {
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for sc.Scan() {
+		var c Command
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			slog.Warn("rejected plugin command", "plugin", p.name, "error", err)
+			continue
+		}
+		if err := p.run(c); err != nil {
+			slog.Warn("rejected plugin command", "plugin", p.name, "type", c.Type, "error", err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		slog.Warn("plugin output error", "plugin", p.name, "error", err)
+	}
+}
+
+// run carries out one Command.
+func (p *Process) run(c Command) error {
+	switch c.Type {
+	case "marker":
+		if err := marker.CheckName(c.Name); err != nil {
+			return err
+		}
+		p.host.AddMarker(c.Name, marker.SourcePlugin, c.PlayerIndex)
+	case "freeze":
+		if c.Seconds < 0 || c.Seconds > 3600 {
+			return fmt.Errorf("seconds must be in [0, 3600], got %d", c.Seconds)
+		}
+		p.host.Freeze(time.Duration(c.Seconds) * time.Second)
+	case "unfreeze":
+		p.host.Unfreeze()
+	case "event":
+		if c.Name == "" || len(c.Name) > maxEventNameLen {
+			return fmt.Errorf("event name must be 1-%d bytes", maxEventNameLen)
+		}
+		p.host.BroadcastPluginEvent(hub.PluginEvent{Plugin: p.name, Event: c.Name, Data: c.Data})
+	default:
+		return fmt.Errorf("unknown command type %q", c.Type)
+	}
+	return nil
+}
+
+// logStderr logs each line the plugin writes to stderr.
+func (p *Process) logStderr(stderr io.Reader) {
+	sc := bufio.NewScanner(stderr)
+	sc.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for sc.Scan() {
+		slog.Info("plugin output", "plugin", p.name, "line", sc.Text())
+	}
+}
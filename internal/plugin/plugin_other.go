@@ -0,0 +1,8 @@
+//go:build !windows
+
+package plugin
+
+import "os/exec"
+
+// hideWindow does nothing outside Windows.
+func hideWindow(*exec.Cmd) {}
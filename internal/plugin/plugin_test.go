@@ -0,0 +1,98 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/marker"
+)
+
+// fakeHost records the calls a plugin's commands make.
+type fakeHost struct {
+	calls chan string
+}
+
+func (h *fakeHost) AddMarker(name, source string, playerIndex int) marker.Marker {
+	h.calls <- fmt.Sprintf("marker %s %s %d", name, source, playerIndex)
+	return marker.Marker{Name: name}
+}
+
+func (h *fakeHost) Freeze(d time.Duration) hub.FreezeInfo {
+	h.calls <- fmt.Sprintf("freeze %s", d)
+	return hub.FreezeInfo{Frozen: true}
+}
+
+func (h *fakeHost) Unfreeze() bool {
+	h.calls <- "unfreeze"
+	return true
+}
+
+func (h *fakeHost) BroadcastPluginEvent(ev hub.PluginEvent) {
+	h.calls <- fmt.Sprintf("event %s %s %s", ev.Plugin, ev.Event, ev.Data)
+}
+
+// TestHelperPlugin is not a test: run by TestPlugin as the plugin process.
+// It writes a few fixed commands, two of them invalid, then answers each
+// observed message with an event naming its type.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("INPUTVIEW_TEST_PLUGIN") != "1" {
+		t.Skip("helper process")
+	}
+	fmt.Println(`{"type":"marker","name":"start","playerIndex":2}`)
+	fmt.Println(`not json`)
+	fmt.Println(`{"type":"marker","name":"a\nb"}`)
+	fmt.Println(`{"type":"freeze","seconds":5}`)
+	fmt.Println(`{"type":"unfreeze"}`)
+	fmt.Fprintln(os.Stderr, "ready")
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		var msg struct{ Type string }
+		json.Unmarshal(sc.Bytes(), &msg)
+		fmt.Printf(`{"type":"event","name":"seen","data":{"type":%q}}`+"\n", msg.Type)
+	}
+	os.Exit(0)
+}
+
+// TestPlugin verifies that a plugin's commands reach the host, invalid ones
+// are skipped, observed messages arrive on its stdin, and it exits when the
+// context is done.
+func TestPlugin(t *testing.T) {
+	t.Setenv("INPUTVIEW_TEST_PLUGIN", "1")
+	host := &fakeHost{calls: make(chan string, 16)}
+	ctx, cancel := context.WithCancel(t.Context())
+	p, err := Start(ctx, "detector", []string{os.Args[0], "-test.run=^TestHelperPlugin$"}, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Observe([]byte(`{"type":"full","seq":1}`))
+
+	want := []string{
+		"marker start plugin 2",
+		"freeze 5s",
+		"unfreeze",
+		`event detector seen {"type":"full"}`,
+	}
+	for _, w := range want {
+		select {
+		case got := <-host.calls:
+			if got != w {
+				t.Errorf("call = %q, want %q", got, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", w)
+		}
+	}
+
+	cancel()
+	select {
+	case <-p.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("plugin did not exit after cancel")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package plugin
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// createNoWindow is CREATE_NO_WINDOW: console programs started from the tray
+// build (which has no console) would otherwise each open a console window.
+const createNoWindow = 0x08000000
+
+// hideWindow starts cmd without a console window.
+func hideWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+}
@@ -12,11 +12,11 @@ import (
 
 // HIDInputCallback is the type for raw HID input event callbacks.
 // On non-Windows platforms this type exists for API compatibility only.
-type HIDInputCallback func(hDevice uintptr, rawData []byte, reportSize uint32)
+type HIDInputCallback = func(hDevice uintptr, rawData []byte, reportSize uint32)
 
 // HIDDeviceChangeCallback is the type for HID device arrival/removal callbacks.
 // On non-Windows platforms this type exists for API compatibility only.
-type HIDDeviceChangeCallback func(added bool, hDevice uintptr)
+type HIDDeviceChangeCallback = func(added bool, hDevice uintptr)
 
 // Reader is a no-op keyboard/mouse reader on non-Windows platforms.
 type Reader struct {
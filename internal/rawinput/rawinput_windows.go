@@ -171,12 +171,12 @@ type msg struct {
 // hDevice is the raw input device handle from RAWINPUTHEADER.hDevice.
 // rawData is the raw HID report bytes (RAWHID.bRawData, dwSizeHid bytes per report).
 // reportSize is the size in bytes of a single HID report.
-type HIDInputCallback func(hDevice uintptr, rawData []byte, reportSize uint32)
+type HIDInputCallback = func(hDevice uintptr, rawData []byte, reportSize uint32)
 
 // HIDDeviceChangeCallback is called from the message loop goroutine when a HID
 // device matching a registered usage page/usage is added or removed.
 // added is true for arrival, false for removal.
-type HIDDeviceChangeCallback func(added bool, hDevice uintptr)
+type HIDDeviceChangeCallback = func(added bool, hDevice uintptr)
 
 // hidRegistration stores a single HID callback registration.
 type hidRegistration struct {
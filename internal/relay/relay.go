@@ -0,0 +1,83 @@
+// Package relay takes the input of a controller on another InputView instance
+// as live input (--relay-from), for dual-PC streaming: the instance on the
+// gaming PC reads the controller, and the one on the streaming PC serves the
+// overlays from the state it relays over the network.
+package relay
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/soar/inputview/pkg/client"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// watchInterval is how often Run checks whether the remote connection
+// dropped.
+const watchInterval = 250 * time.Millisecond
+
+// Relay follows one player of a remote instance.
+type Relay struct {
+	url         string // without credentials
+	client      *client.Client
+	localPlayer int
+}
+
+// New returns a relay of player remotePlayer of the instance whose WebSocket
+// endpoint is rawURL (ws:// or wss://, e.g. "ws://gaming-pc:8080/ws"), shown
+// here as player localPlayer. User info in the URL ("ws://user:pass@host/ws")
+// is sent as basic auth, for instances started with --auth-password.
+func New(rawURL string, remotePlayer, localPlayer int) (*Relay, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("relay url: %w", err)
+	}
+	if (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		return nil, fmt.Errorf("relay url must be ws://host/... or wss://host/..., got %q", rawURL)
+	}
+	userInfo := u.User
+	u.User = nil
+	c := client.New(u.String())
+	c.SetPlayerIndex(remotePlayer)
+	if userInfo != nil {
+		password, _ := userInfo.Password()
+		c.SetBasicAuth(userInfo.Username(), password)
+	}
+	return &Relay{url: u.String(), client: c, localPlayer: localPlayer}, nil
+}
+
+// Run connects to the remote instance, reconnecting whenever the connection
+// drops, and passes each state it streams to inject as player localPlayer;
+// while the connection is down the player is shown disconnected. It returns
+// when ctx is done.
+func (r *Relay) Run(ctx context.Context, inject func(gamepad.GamepadState)) {
+	go r.client.Run(ctx)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	updates := r.client.Updates()
+	live := false // states are being relayed over an open connection
+	for {
+		select {
+		case u, ok := <-updates:
+			if !ok {
+				return
+			}
+			if !live {
+				slog.Info("relaying remote input", "url", r.url, "player", r.localPlayer)
+				live = true
+			}
+			s := u.State
+			s.PlayerIndex = r.localPlayer
+			inject(s)
+		case <-ticker.C:
+			if live && !r.client.Connected() {
+				slog.Warn("relay connection lost, reconnecting", "url", r.url)
+				live = false
+				inject(gamepad.GamepadState{PlayerIndex: r.localPlayer})
+			}
+		}
+	}
+}
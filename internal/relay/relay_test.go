@@ -0,0 +1,134 @@
+package relay
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/server"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestNew verifies the accepted URLs.
+func TestNew(t *testing.T) {
+	for _, bad := range []string{"", "http://host/ws", "ws:///ws", "gaming-pc:8080"} {
+		if _, err := New(bad, 1, 1); err == nil {
+			t.Errorf("New(%q) error = nil, want an error", bad)
+		}
+	}
+	r, err := New("ws://streamer:secret@127.0.0.1:8080/ws", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.url != "ws://127.0.0.1:8080/ws" {
+		t.Errorf("url = %q, want the credentials removed", r.url)
+	}
+}
+
+// newRemote serves a remote instance's hub and broadcaster pipeline on a
+// loopback port and returns its WebSocket URL, the reader to inject its
+// controller's states into, its hub, and its HTTP server.
+func newRemote(t *testing.T, ctx context.Context) (string, *gamepad.Reader, *hub.Hub, *http.Server) {
+	t.Helper()
+	reader := gamepad.NewReader()
+	h := hub.NewHub()
+	go h.Run(ctx)
+	b := hub.NewBroadcaster(h, reader.Changes(), nil)
+	go b.Run()
+	srv := server.New(h, b, reader, nil, fstest.MapFS{"index.html": {Data: []byte("<html></html>")}}, nil, t.TempDir(), "overlays", "keyboards", ":0")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := &http.Server{Handler: srv.Handler()}
+	go httpSrv.Serve(ln)
+	t.Cleanup(func() { httpSrv.Close() })
+	return "ws://" + ln.Addr().String() + "/ws", reader, h, httpSrv
+}
+
+// TestRelay verifies that the remote player's states are injected as the
+// local player, and that the player shows disconnected when the remote
+// instance goes away.
+func TestRelay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url, reader, h, httpSrv := newRemote(t, ctx)
+
+	r, err := New(url, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injected := make(chan gamepad.GamepadState, 16)
+	go r.Run(ctx, func(s gamepad.GamepadState) { injected <- s })
+	next := func() gamepad.GamepadState {
+		t.Helper()
+		select {
+		case s := <-injected:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a relayed state")
+			return gamepad.GamepadState{}
+		}
+	}
+
+	next() // the initial full
+	s := gamepad.GamepadState{Connected: true, Name: "Pad", PlayerIndex: 1}
+	s.Buttons.A = true
+	reader.Inject(s)
+	for {
+		got := next()
+		if got.PlayerIndex != 3 {
+			t.Fatalf("PlayerIndex = %d, want 3", got.PlayerIndex)
+		}
+		if got.Buttons.A {
+			break
+		}
+	}
+
+	httpSrv.Close()
+	drainCtx, drainCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer drainCancel()
+	h.Drain(drainCtx)
+	if got := next(); got.Connected || got.PlayerIndex != 3 {
+		t.Errorf("state after the remote went away = %+v, want player 3 disconnected", got)
+	}
+}
+
+// TestRelayAggregate verifies that two remote instances relayed into one
+// all-players pipeline are shown as players 1 and 2.
+func TestRelayAggregate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	local := gamepad.NewReader()
+	local.SetAllPlayers(true)
+	b := hub.NewBroadcaster(hub.NewHub(), local.Changes(), nil)
+	b.SetAllPlayers(true)
+	go b.Run()
+
+	for i, name := range []string{"Alice's pad", "Bob's pad"} {
+		url, reader, _, _ := newRemote(t, ctx)
+		r, err := New(url, 1, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go r.Run(ctx, local.Inject)
+		reader.Inject(gamepad.GamepadState{Connected: true, Name: name, PlayerIndex: 1})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		shown := b.ShownStates()
+		if len(shown) == 2 && shown[0].Name == "Alice's pad" && shown[1].Name == "Bob's pad" &&
+			shown[0].PlayerIndex == 1 && shown[1].PlayerIndex == 2 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ShownStates() = %+v, want Alice's pad as player 1 and Bob's as player 2", shown)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
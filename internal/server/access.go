@@ -0,0 +1,85 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/soar/inputview/internal/audit"
+	"github.com/soar/inputview/internal/hub"
+)
+
+// SetAllowedNets restricts remote clients (see SetRemoteAllowed) to the given
+// networks; nil or empty allows any remote address. Loopback clients are
+// always allowed. Must be called before Handler or ListenAndServe.
+func (s *Server) SetAllowedNets(nets []netip.Prefix) {
+	s.allowedNets = slices.Clone(nets)
+}
+
+// SetRemoteAllowed sets whether non-loopback clients are served at all. It is
+// off by default, so binding to all interfaces does not by itself expose
+// every button press to the network. Safe to call at any time (the tray
+// toggles it): turning it off also closes the WebSocket connections of
+// clients that are no longer allowed.
+func (s *Server) SetRemoteAllowed(allowed bool) {
+	if s.remoteAllowed.Swap(allowed) == allowed {
+		return
+	}
+	if allowed {
+		slog.Warn("remote connections allowed: anyone who can reach this address sees every input", "addr", s.addr, "nets", s.allowedNets)
+		return
+	}
+	n := s.hub.CloseClients(func(c *hub.Client) bool {
+		return c.ViewerToken() == "" && !s.clientAllowed(c.RemoteAddr())
+	})
+	slog.Info("remote connections disabled", "closed", n)
+	if n > 0 {
+		s.auditLog.Record(audit.Entry{
+			Action: audit.ActionClientsClosed, Source: audit.SourceServer,
+			Details: map[string]any{"count": n, "reason": "remote access disabled"},
+		})
+	}
+}
+
+// RemoteAllowed reports whether non-loopback clients are served.
+func (s *Server) RemoteAllowed() bool { return s.remoteAllowed.Load() }
+
+// accessMiddleware answers 403 to clients that clientAllowed rejects. It
+// covers every path, /health included. A viewer token (see tokenMiddleware)
+// counts as remote access for its holder; the allowed networks still apply.
+func (s *Server) accessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote := s.remoteAllowed.Load() || viewerTokenID(r) != ""
+		if !clientAllowed(r.RemoteAddr, remote, s.allowedNets) {
+			slog.Debug("rejected remote client", "ip", r.RemoteAddr, "remote_allowed", s.remoteAllowed.Load())
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientAllowed applies the current access settings to a peer address.
+func (s *Server) clientAllowed(remoteAddr string) bool {
+	return clientAllowed(remoteAddr, s.remoteAllowed.Load(), s.allowedNets)
+}
+
+// clientAllowed reports whether the peer "ip:port" (the TCP peer, or the
+// forwarded client behind a trusted proxy; see SetTrustedProxies) may connect: loopback always; any other address only when
+// remote is true and, if nets is non-empty, it lies in one of them. An
+// unparsable address is rejected.
+func clientAllowed(remoteAddr string, remote bool, nets []netip.Prefix) bool {
+	ap, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := ap.Addr().Unmap() // IPv4 clients on a dual-stack listener arrive as ::ffff:a.b.c.d
+	if addr.IsLoopback() {
+		return true
+	}
+	if !remote {
+		return false
+	}
+	return len(nets) == 0 || inNets(addr, nets)
+}
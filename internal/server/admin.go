@@ -0,0 +1,163 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/soar/inputview/internal/audit"
+)
+
+// defaultAuditLimit is the number of entries GET /api/admin/audit returns
+// without a limit parameter.
+const defaultAuditLimit = 100
+
+// AuditResponse is the body of GET /api/admin/audit.
+type AuditResponse struct {
+	Entries []audit.Entry `json:"entries"` // oldest first; empty when nothing matches
+}
+
+// SetAuditLog serves l on GET /api/admin/audit and records in it the clients
+// the server disconnects. nil (the default) serves an empty log.
+// Must be called before Handler or ListenAndServe.
+func (s *Server) SetAuditLog(l *audit.Log) { s.auditLog = l }
+
+// adminOnly serves next to loopback clients only — the operator on this PC —
+// and answers 403 to everyone else, even with remote access allowed. Behind a
+// trusted proxy the forwarded client counts, so proxied visitors are not
+// admins.
+func adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ap, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err != nil || !ap.Addr().Unmap().IsLoopback() {
+			writeAPIError(w, http.StatusForbidden, "admin API is only available from this machine")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleAudit serves GET /api/admin/audit: the newest audit entries, oldest
+// first, filtered by the optional query parameters since (Unix ms), action,
+// client, and limit (default 100).
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	params := r.URL.Query()
+	q := audit.Query{Action: params.Get("action"), Client: params.Get("client"), Limit: defaultAuditLimit}
+	if v := params.Get("since"); v != "" {
+		since, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "since must be a Unix millisecond timestamp")
+			return
+		}
+		q.Since = since
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			writeAPIError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		q.Limit = limit
+	}
+	writeJSON(w, http.StatusOK, AuditResponse{Entries: s.auditLog.Query(q)})
+}
+
+// ListenersResponse is the body of GET /api/admin/listeners.
+type ListenersResponse struct {
+	Listeners map[string]string `json:"listeners"` // "addr" and every listener name → its configured address
+}
+
+// handleListeners serves GET /api/admin/listeners: the address of every
+// listener. It is not part of /health, which is served without basic auth.
+func (s *Server) handleListeners(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, ListenersResponse{Listeners: s.listenerAddrs()})
+}
+
+// CreateTokenRequest is the body of POST /api/admin/tokens.
+type CreateTokenRequest struct {
+	Label      string `json:"label,omitempty"`      // shown in the token list, e.g. "guest commentator"
+	TTLSeconds int64  `json:"ttlSeconds,omitempty"` // lifetime; 0 = 4 hours, at most 30 days
+}
+
+// TokensResponse is the body of GET /api/admin/tokens.
+type TokensResponse struct {
+	Tokens []ViewerToken `json:"tokens"` // oldest first, without secrets; empty when none are live
+}
+
+// maxTokenLabelLen caps CreateTokenRequest.Label.
+const maxTokenLabelLen = 64
+
+// handleTokens serves /api/admin/tokens: GET lists the live viewer tokens,
+// POST issues one and returns it with its secret (201). The secret is only
+// returned here; viewers open the page with ?token=<secret>.
+func (s *Server) handleTokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, TokensResponse{Tokens: s.tokens.list()})
+	case http.MethodPost:
+		var req CreateTokenRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		ttl := time.Duration(req.TTLSeconds) * time.Second
+		if req.TTLSeconds == 0 {
+			ttl = defaultTokenTTL
+		}
+		if ttl <= 0 || ttl > maxTokenTTL {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("ttlSeconds must be in [1, %d]", int64(maxTokenTTL/time.Second)))
+			return
+		}
+		if len(req.Label) > maxTokenLabelLen {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("label too long (max %d bytes)", maxTokenLabelLen))
+			return
+		}
+		t := s.createToken(req.Label, ttl)
+		s.auditLog.Record(audit.Entry{
+			Action: audit.ActionTokenCreated, Source: audit.SourceAPI, Client: r.RemoteAddr,
+			Details: map[string]any{"id": t.ID, "label": t.Label, "expires": t.Expires},
+		})
+		writeJSON(w, http.StatusCreated, t)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleToken serves DELETE /api/admin/tokens/{id}: it revokes the token and
+// disconnects the WebSocket clients admitted with it (204, or 404 if there is
+// no such live token).
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id := tokenIDFromPath(r.URL.Path)
+	if !s.revokeToken(id) {
+		writeAPIError(w, http.StatusNotFound, "no such token")
+		return
+	}
+	s.auditLog.Record(audit.Entry{
+		Action: audit.ActionTokenRevoked, Source: audit.SourceAPI, Client: r.RemoteAddr,
+		Details: map[string]any{"id": id},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
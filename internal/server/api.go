@@ -0,0 +1,449 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/soar/inputview/internal/audit"
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// maxAPIBodyBytes caps the request body accepted by /api endpoints.
+const maxAPIBodyBytes = 64 << 10
+
+// InjectRequest is the body of POST /api/inject. It mirrors the server→client
+// WebSocket message shapes: {"type":"full","data":{...}} replaces the whole
+// state, {"type":"delta","changes":{...}} is merged onto the current state.
+type InjectRequest struct {
+	Type    string                `json:"type"`
+	Data    *gamepad.GamepadState `json:"data,omitempty"`
+	Changes *gamepad.DeltaChanges `json:"changes,omitempty"`
+}
+
+// ControllersResponse is the body of GET /api/controllers.
+type ControllersResponse struct {
+	Controllers []gamepad.ControllerInfo `json:"controllers"` // in player-index order; empty when none are connected
+}
+
+// LEDRequest is the body of POST /api/controllers/{deviceId}/led. At least
+// one field must be set; the other light is left as it is.
+type LEDRequest struct {
+	Color      string `json:"color,omitempty"`      // lightbar color, "#rrggbb"
+	PlayerLEDs *int   `json:"playerLeds,omitempty"` // player number shown on the player LEDs, 0-5 (0 = off)
+}
+
+// RawModeRequest is the body of POST /api/controllers/{deviceId}/raw.
+type RawModeRequest struct {
+	Enabled *bool `json:"enabled"` // true: read the controller without a mapping; required
+}
+
+// DeadzoneRequest is the body of PUT /api/controllers/{deviceId}/deadzone. At
+// least one field must be set; the others are left as they are.
+type DeadzoneRequest struct {
+	Stick   *float64 `json:"stick,omitempty"`   // sticks and flight stick axes, 0.0-1.0
+	Trigger *float64 `json:"trigger,omitempty"` // triggers, pedals, and thrust, 0.0-1.0
+	Mode    string   `json:"mode,omitempty"`    // stick deadzone shape, one of gamepad.DeadzoneModes
+}
+
+// SDLMappingResponse is the body of GET /api/controllers/{deviceId}/sdl.
+type SDLMappingResponse struct {
+	DeviceID string `json:"deviceId"`
+	Mapping  string `json:"mapping"` // one gamecontrollerdb.txt line: GUID, name, bindings, platform
+}
+
+// DriftResponse is the body of GET /api/controllers/{deviceId}/drift.
+type DriftResponse struct {
+	DeviceID string              `json:"deviceId"`
+	Drift    gamepad.DriftReport `json:"drift"`
+}
+
+// APIError is the JSON error body returned by /api endpoints.
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("error encoding API response", "error", err)
+	}
+}
+
+// writeAPIError writes a JSON error body with the given status code.
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, APIError{Error: msg})
+}
+
+// handleInject serves POST /api/inject: it pushes a synthetic full state or
+// delta through Reader.Inject, so it reaches clients exactly like real input
+// (mailbox → broadcaster → hub). Responds with the resulting state.
+//
+// Debug-only: registered only when inject is enabled (--enable-inject).
+// A connected physical controller keeps emitting its own state and will
+// overwrite injected values on its next change.
+func (s *Server) handleInject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req InjectRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON: trailing data after request object")
+		return
+	}
+
+	var next gamepad.GamepadState
+	switch req.Type {
+	case "full":
+		if req.Data == nil {
+			writeAPIError(w, http.StatusBadRequest, `type "full" requires "data"`)
+			return
+		}
+		next = *req.Data
+	case "delta":
+		if req.Changes == nil {
+			writeAPIError(w, http.StatusBadRequest, `type "delta" requires "changes"`)
+			return
+		}
+		next = gamepad.ApplyDelta(s.reader.State(), req.Changes)
+	default:
+		writeAPIError(w, http.StatusBadRequest, `type must be "full" or "delta"`)
+		return
+	}
+
+	// Clients listen to player 1 by default; a zero PlayerIndex would match
+	// no client and the injected state would silently go nowhere.
+	if next.PlayerIndex == 0 {
+		next.PlayerIndex = 1
+	}
+	if err := next.Validate(); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid state: "+err.Error())
+		return
+	}
+
+	s.reader.Inject(next)
+	slog.Debug("state injected", "type", req.Type, "player", next.PlayerIndex)
+	writeJSON(w, http.StatusOK, next)
+}
+
+// handleControllers serves GET /api/controllers: the connected controllers
+// with their identity (VID/PID, GUID, serial, product version), mapping path,
+// and input counts, as reported by Reader.Controllers.
+func (s *Server) handleControllers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, ControllersResponse{Controllers: s.reader.Controllers()})
+}
+
+// handleController serves the /api/controllers/{deviceId}/<action>
+// endpoints (also mounted as /api/devices/{deviceId}/<action>): POST
+// activate, led, and raw, GET sdl and drift, and PUT/DELETE deadzone.
+func (s *Server) handleController(w http.ResponseWriter, r *http.Request) {
+	_, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/"), "/") // past controllers/ or devices/
+	id, action, _ := strings.Cut(strings.Trim(rest, "/"), "/")
+	if id == "" || (action != "activate" && action != "led" && action != "raw" && action != "sdl" && action != "drift" && action != "deadzone") {
+		writeAPIError(w, http.StatusNotFound, "not found")
+		return
+	}
+	methods := []string{http.MethodPost}
+	switch action {
+	case "sdl", "drift":
+		methods = []string{http.MethodGet}
+	case "deadzone":
+		methods = []string{http.MethodPut, http.MethodDelete}
+	}
+	if !slices.Contains(methods, r.Method) {
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	switch action {
+	case "deadzone":
+		s.handleControllerDeadzone(w, r, id)
+		return
+	case "sdl":
+		s.handleControllerSDL(w, id)
+		return
+	case "drift":
+		s.handleControllerDrift(w, id)
+		return
+	case "led":
+		s.handleControllerLED(w, r, id)
+		return
+	case "raw":
+		s.handleControllerRaw(w, r, id)
+		return
+	}
+	s.handleControllerActivate(w, r, id)
+}
+
+// handleControllerActivate serves POST /api/controllers/{deviceId}/activate:
+// it makes the controller the active one from outside the web UI, and moves
+// the viewers of the previously active player to it, like the next-player
+// binding. Responds with the controller's ControllerInfo.
+func (s *Server) handleControllerActivate(w http.ResponseWriter, r *http.Request, id string) {
+	controllers := s.reader.Controllers()
+	i := slices.IndexFunc(controllers, func(c gamepad.ControllerInfo) bool { return c.DeviceID == id })
+	if i < 0 {
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	// Route the viewers to the new player before switching, so the full the
+	// switch triggers reaches them (see Hub.MovePlayer).
+	playerIndex := i + 1
+	s.hub.MovePlayer(s.reader.GetPlayerIndex(), playerIndex)
+	if !s.reader.SetActiveByPlayerIndex(playerIndex) {
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	if s.broadcaster.AllPlayers() {
+		s.broadcaster.SyncPlayer(playerIndex)
+	}
+	slog.Info("API switched player", "device", id, "player", playerIndex)
+	s.auditLog.Record(audit.Entry{
+		Action: audit.ActionSelectPlayer, Source: audit.SourceAPI, Client: r.RemoteAddr,
+		Details: map[string]any{"playerIndex": playerIndex, "deviceId": id},
+	})
+	info := controllers[i]
+	info.Active = true
+	writeJSON(w, http.StatusOK, info)
+}
+
+// handleControllerLED serves POST /api/controllers/{deviceId}/led: it sets
+// the lightbar color and/or player LEDs of the controller (a LEDRequest), to
+// tell controllers apart in multi-player setups. Responds with the
+// controller's ControllerInfo; 409 if the controller has no settable lights
+// (only DualSense controllers do).
+func (s *Server) handleControllerLED(w http.ResponseWriter, r *http.Request, id string) {
+	var req LEDRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON: trailing data after request object")
+		return
+	}
+	leds, err := hub.ParseLEDs(req.Color, req.PlayerLEDs)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	controllers := s.reader.Controllers()
+	i := slices.IndexFunc(controllers, func(c gamepad.ControllerInfo) bool { return c.DeviceID == id })
+	if i < 0 {
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	switch err := s.reader.SetLEDs(i+1, leds); {
+	case errors.Is(err, gamepad.ErrLEDUnsupported):
+		writeAPIError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, gamepad.ErrNoController):
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	case err != nil:
+		slog.Warn("failed to set controller LEDs", "device", id, "error", err)
+		writeAPIError(w, http.StatusBadGateway, "failed to set LEDs: "+err.Error())
+		return
+	}
+	details := map[string]any{"playerIndex": i + 1, "deviceId": id}
+	if req.Color != "" {
+		details["color"] = req.Color
+	}
+	if req.PlayerLEDs != nil {
+		details["playerLeds"] = *req.PlayerLEDs
+	}
+	slog.Info("API set controller LEDs", "device", id, "color", req.Color)
+	s.auditLog.Record(audit.Entry{Action: audit.ActionSetLED, Source: audit.SourceAPI, Client: r.RemoteAddr, Details: details})
+	writeJSON(w, http.StatusOK, controllers[i])
+}
+
+// handleControllerRaw serves POST /api/controllers/{deviceId}/raw: it
+// switches the controller to raw mode or back (a RawModeRequest), so a device
+// without a fitting mapping still shows its inputs and its indices can be
+// read off for a mapping file. Responds with the controller's ControllerInfo;
+// 409 if the controller has a fixed layout.
+func (s *Server) handleControllerRaw(w http.ResponseWriter, r *http.Request, id string) {
+	var req RawModeRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON: trailing data after request object")
+		return
+	}
+	if req.Enabled == nil {
+		writeAPIError(w, http.StatusBadRequest, `missing "enabled"`)
+		return
+	}
+
+	controllers := s.reader.Controllers()
+	i := slices.IndexFunc(controllers, func(c gamepad.ControllerInfo) bool { return c.DeviceID == id })
+	if i < 0 {
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	switch err := s.reader.SetRawMode(i+1, *req.Enabled); {
+	case errors.Is(err, gamepad.ErrRawUnsupported):
+		writeAPIError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	slog.Info("API set controller raw mode", "device", id, "enabled", *req.Enabled)
+	s.auditLog.Record(audit.Entry{
+		Action: audit.ActionSetRawMode, Source: audit.SourceAPI, Client: r.RemoteAddr,
+		Details: map[string]any{"playerIndex": i + 1, "deviceId": id, "enabled": *req.Enabled},
+	})
+	info := controllers[i]
+	if refreshed := s.reader.Controllers(); i < len(refreshed) && refreshed[i].DeviceID == id {
+		info = refreshed[i]
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// handleControllerDeadzone serves /api/controllers/{deviceId}/deadzone: PUT
+// sets the deadzones of the controller's model (a DeadzoneRequest; see
+// Reader.SetDeviceDeadzones), for a worn stick that drifts, and DELETE makes
+// it use the configured ones again. The change lasts until the server
+// restarts. Responds with the controller's ControllerInfo, whose deadzones
+// field shows the result.
+func (s *Server) handleControllerDeadzone(w http.ResponseWriter, r *http.Request, id string) {
+	var req DeadzoneRequest
+	if r.Method == http.MethodPut {
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON: trailing data after request object")
+			return
+		}
+		if req.Stick == nil && req.Trigger == nil && req.Mode == "" {
+			writeAPIError(w, http.StatusBadRequest, `set at least one of "stick", "trigger", and "mode"`)
+			return
+		}
+		if req.Mode != "" && !slices.Contains(gamepad.DeadzoneModes, req.Mode) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("mode must be one of %v, got %q", gamepad.DeadzoneModes, req.Mode))
+			return
+		}
+		for name, dz := range map[string]*float64{"stick": req.Stick, "trigger": req.Trigger} {
+			if dz != nil && !(*dz >= 0 && *dz <= 1) {
+				writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("%s must be in [0.0, 1.0], got %g", name, *dz))
+				return
+			}
+		}
+	}
+
+	controllers := s.reader.Controllers()
+	i := slices.IndexFunc(controllers, func(c gamepad.ControllerInfo) bool { return c.DeviceID == id })
+	if i < 0 {
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	info := controllers[i]
+	if info.GUID == "" {
+		writeAPIError(w, http.StatusConflict, "controller has no VID/PID to key its deadzones by")
+		return
+	}
+	details := map[string]any{"playerIndex": i + 1, "deviceId": id, "guid": info.GUID}
+	var err error
+	if r.Method == http.MethodDelete {
+		err = s.reader.ClearDeviceDeadzones(info.GUID)
+		details["reset"] = true
+	} else {
+		dz := info.Deadzones
+		if req.Stick != nil {
+			dz.Stick = *req.Stick
+		}
+		if req.Trigger != nil {
+			dz.Trigger = *req.Trigger
+		}
+		if req.Mode != "" {
+			dz.Mode = req.Mode
+		}
+		err = s.reader.SetDeviceDeadzones(info.GUID, dz)
+		details["stick"], details["trigger"], details["mode"] = dz.Stick, dz.Trigger, dz.Mode
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusConflict, err.Error())
+		return
+	}
+	slog.Info("API set controller deadzones", "device", id, "guid", info.GUID)
+	s.auditLog.Record(audit.Entry{Action: audit.ActionSetDeadzone, Source: audit.SourceAPI, Client: r.RemoteAddr, Details: details})
+	if refreshed := s.reader.Controllers(); i < len(refreshed) && refreshed[i].DeviceID == id {
+		info = refreshed[i]
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// handleControllerSDL serves GET /api/controllers/{deviceId}/sdl: the mapping
+// the controller is read with as a gamecontrollerdb.txt line (see
+// Reader.ExportSDLMapping), to contribute it upstream or reuse it with other
+// SDL-based tools. 409 for layouts SDL cannot express.
+func (s *Server) handleControllerSDL(w http.ResponseWriter, id string) {
+	controllers := s.reader.Controllers()
+	i := slices.IndexFunc(controllers, func(c gamepad.ControllerInfo) bool { return c.DeviceID == id })
+	if i < 0 {
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	line, err := s.reader.ExportSDLMapping(i + 1)
+	switch {
+	case errors.Is(err, gamepad.ErrNoSDLMapping):
+		writeAPIError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	writeJSON(w, http.StatusOK, SDLMappingResponse{DeviceID: id, Mapping: line})
+}
+
+// handleControllerDrift serves GET /api/controllers/{deviceId}/drift: how far
+// the controller's sticks sit off centre and how much they jitter at rest
+// (see Reader.Drift), to tell a failing stick from a deadzone set too small.
+func (s *Server) handleControllerDrift(w http.ResponseWriter, id string) {
+	controllers := s.reader.Controllers()
+	i := slices.IndexFunc(controllers, func(c gamepad.ControllerInfo) bool { return c.DeviceID == id })
+	if i < 0 {
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	report, err := s.reader.Drift(i + 1)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "no such controller")
+		return
+	}
+	writeJSON(w, http.StatusOK, DriftResponse{DeviceID: id, Drift: report})
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// newTestServer returns a Server wired to a fresh reader, hub, and broadcaster
+// with an in-memory frontend. The hub runs until the test ends. Remote clients
+// are allowed, since httptest requests come from 192.0.2.1.
+func newTestServer(t *testing.T) (*Server, *gamepad.Reader) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	reader := gamepad.NewReader()
+	h := hub.NewHub()
+	go h.Run(ctx)
+	b := hub.NewBroadcaster(h, reader.Changes(), nil)
+	frontend := fstest.MapFS{"index.html": {Data: []byte("<html></html>")}}
+	srv := New(h, b, reader, nil, frontend, nil, t.TempDir(), "overlays", "keyboards", ":0")
+	srv.SetRemoteAllowed(true)
+	return srv, reader
+}
+
+// TestInjectDisabledByDefault verifies that /api/inject is not mounted unless enabled.
+func TestInjectDisabledByDefault(t *testing.T) {
+	srv, _ := newTestServer(t)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/inject", strings.NewReader(`{"type":"full","data":{}}`)))
+	if rec.Code == http.StatusOK {
+		t.Fatalf("POST /api/inject with inject disabled = %d, want non-200", rec.Code)
+	}
+}
+
+// TestInject verifies full and delta injection, validation, and method checks.
+func TestInject(t *testing.T) {
+	srv, reader := newTestServer(t)
+	srv.SetInjectEnabled(true)
+	handler := srv.Handler()
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		check      func(t *testing.T, s gamepad.GamepadState)
+	}{
+		{
+			name:       "full state",
+			method:     http.MethodPost,
+			body:       `{"type":"full","data":{"connected":true,"name":"Scripted","buttons":{"a":true}}}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, s gamepad.GamepadState) {
+				if !s.Connected || s.Name != "Scripted" || !s.Buttons.A {
+					t.Errorf("state after full inject = %+v", s)
+				}
+				if s.PlayerIndex != 1 {
+					t.Errorf("PlayerIndex = %d, want default 1", s.PlayerIndex)
+				}
+			},
+		},
+		{
+			name:       "delta merges onto current state",
+			method:     http.MethodPost,
+			body:       `{"type":"delta","changes":{"dpad":{"up":true}}}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, s gamepad.GamepadState) {
+				if !s.Dpad.Up || !s.Buttons.A || s.Name != "Scripted" {
+					t.Errorf("state after delta inject = %+v, want previous state plus dpad up", s)
+				}
+			},
+		},
+		{"unknown type", http.MethodPost, `{"type":"km_full"}`, http.StatusBadRequest, nil},
+		{"full without data", http.MethodPost, `{"type":"full"}`, http.StatusBadRequest, nil},
+		{"unknown field", http.MethodPost, `{"type":"full","data":{},"bogus":1}`, http.StatusBadRequest, nil},
+		{"malformed JSON", http.MethodPost, `{"type":`, http.StatusBadRequest, nil},
+		{"trailing data", http.MethodPost, `{"type":"full","data":{}} {}`, http.StatusBadRequest, nil},
+		{"stick out of range", http.MethodPost, `{"type":"full","data":{"sticks":{"left":{"position":{"x":1.5}}}}}`, http.StatusBadRequest, nil},
+		{"negative trigger", http.MethodPost, `{"type":"delta","changes":{"triggers":{"lt":{"value":-0.2}}}}`, http.StatusBadRequest, nil},
+		{"GET not allowed", http.MethodGet, ``, http.StatusMethodNotAllowed, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/api/inject", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.check == nil {
+				return
+			}
+			var resp gamepad.GamepadState
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			tt.check(t, resp)
+			if got := reader.State(); got != resp {
+				t.Errorf("Reader.State() = %+v, want response state %+v", got, resp)
+			}
+		})
+	}
+}
+
+// TestControllers verifies GET /api/controllers with no controllers attached
+// and the method check.
+func TestControllers(t *testing.T) {
+	srv, _ := newTestServer(t)
+	handler := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/controllers", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/controllers = %d, want 200", rec.Code)
+	}
+	if got, want := strings.TrimSpace(rec.Body.String()), `{"controllers":[]}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/controllers", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/controllers = %d, want 405", rec.Code)
+	}
+}
+
+// TestDevicesAlias verifies that /api/devices and /api/devices/{deviceId}/activate
+// and /led are served by the /api/controllers handlers.
+func TestDevicesAlias(t *testing.T) {
+	srv, _ := newTestServer(t)
+	handler := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/devices", nil))
+	if got, want := strings.TrimSpace(rec.Body.String()), `{"controllers":[]}`; rec.Code != http.StatusOK || got != want {
+		t.Errorf("GET /api/devices = %d %s, want 200 %s", rec.Code, got, want)
+	}
+
+	for _, tt := range []struct {
+		method, target string
+		want           int
+		body           string
+	}{
+		{http.MethodPost, "/api/devices/xinput-0/activate", http.StatusNotFound, "no such controller"},
+		{http.MethodGet, "/api/devices/xinput-0/activate", http.StatusMethodNotAllowed, "method not allowed"},
+		{http.MethodPost, "/api/devices/xinput-0/reboot", http.StatusNotFound, `"not found"`},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.want || !strings.Contains(rec.Body.String(), tt.body) {
+			t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.target, rec.Code, strings.TrimSpace(rec.Body.String()), tt.want, tt.body)
+		}
+	}
+
+	for _, tt := range []struct {
+		body string
+		want int
+	}{
+		{`{"color":"#ff8000","playerLeds":2}`, http.StatusNotFound},
+		{`{"color":"orange"}`, http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/devices/hid-1a03f7/led", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("POST /api/devices/{deviceId}/led %s = %d, want %d", tt.body, rec.Code, tt.want)
+		}
+	}
+}
+
+// TestControllerActivate verifies POST /api/controllers/{deviceId}/activate
+// and its /led, /raw, /sdl, /drift, and /deadzone endpoints reject unknown devices, paths, and
+// bodies, and the method check.
+func TestControllerActivate(t *testing.T) {
+	srv, _ := newTestServer(t)
+	handler := srv.Handler()
+
+	for _, tt := range []struct {
+		method, target string
+		want           int
+	}{
+		{http.MethodPost, "/api/controllers/xinput-0/activate", http.StatusNotFound},
+		{http.MethodPost, "/api/controllers/xinput-0", http.StatusNotFound},
+		{http.MethodGet, "/api/controllers/xinput-0/activate", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/controllers/xinput-0/reboot", http.StatusNotFound},
+		{http.MethodGet, "/api/controllers/hid-1a03f7/led", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/controllers/hid-1a03f7/raw", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/controllers/hid-1a03f7/sdl", http.StatusNotFound},
+		{http.MethodPost, "/api/controllers/hid-1a03f7/sdl", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/controllers/hid-1a03f7/drift", http.StatusNotFound},
+		{http.MethodPut, "/api/controllers/hid-1a03f7/drift", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/api/controllers/hid-1a03f7/deadzone", http.StatusNotFound},
+		{http.MethodPost, "/api/controllers/hid-1a03f7/deadzone", http.StatusMethodNotAllowed},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		body string
+		want int
+	}{
+		{`{"color":"#ff8000","playerLeds":2}`, http.StatusNotFound},
+		{`{"playerLeds":0}`, http.StatusNotFound},
+		{`{}`, http.StatusBadRequest},
+		{`{"color":"orange"}`, http.StatusBadRequest},
+		{`{"playerLeds":6}`, http.StatusBadRequest},
+		{`{"color":"#ff8000","brightness":1}`, http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/controllers/hid-1a03f7/led", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("POST led %s = %d, want %d", tt.body, rec.Code, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		body string
+		want int
+	}{
+		{`{"enabled":true}`, http.StatusNotFound},
+		{`{"enabled":false}`, http.StatusNotFound},
+		{`{}`, http.StatusBadRequest},
+		{`{"enabled":"yes"}`, http.StatusBadRequest},
+		{`{"enabled":true,"player":1}`, http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/controllers/hid-1a03f7/raw", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("POST raw %s = %d, want %d", tt.body, rec.Code, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		body string
+		want int
+	}{
+		{`{"stick":0.15}`, http.StatusNotFound},
+		{`{"stick":0.15,"trigger":0}`, http.StatusNotFound},
+		{`{"mode":"scaled"}`, http.StatusNotFound},
+		{`{"mode":"square"}`, http.StatusBadRequest},
+		{`{}`, http.StatusBadRequest},
+		{`{"trigger":1.5}`, http.StatusBadRequest},
+		{`{"stick":-0.1}`, http.StatusBadRequest},
+		{`{"stick":0.1,"player":1}`, http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/controllers/hid-1a03f7/deadzone", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("PUT deadzone %s = %d, want %d", tt.body, rec.Code, tt.want)
+		}
+	}
+}
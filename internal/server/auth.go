@@ -0,0 +1,46 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+)
+
+// authRealm is the realm of the HTTP basic auth challenge.
+const authRealm = "InputView"
+
+// SetBasicAuth protects every endpoint except /health with HTTP basic auth on
+// the main listener (AddListener sets it for the others). An empty password
+// disables it (the default); an empty user accepts any user name. Browsers ask once and reuse the credentials for /ws and the
+// overlay assets. Must be called before Handler or ListenAndServe.
+func (s *Server) SetBasicAuth(user, password string) {
+	s.authUser = user
+	s.authPassword = password
+}
+
+// basicAuthMiddleware rejects requests without the configured credentials
+// with 401 and a basic auth challenge. /health stays open for monitoring and
+// the selftest; it reveals only the version, uptime, and headless mode (the
+// listener addresses are under the admin API). Requests admitted
+// with a viewer token (see tokenMiddleware) need no credentials.
+func basicAuthMiddleware(next http.Handler, user, password string) http.Handler {
+	wantUser := sha256.Sum256([]byte(user))
+	wantPassword := sha256.Sum256([]byte(password))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" || viewerTokenID(r) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		u, p, ok := r.BasicAuth()
+		// Compare fixed-size digests in constant time, so neither the
+		// length nor the content of the secret leaks through timing.
+		gotUser, gotPassword := sha256.Sum256([]byte(u)), sha256.Sum256([]byte(p))
+		userOK := user == "" || subtle.ConstantTimeCompare(gotUser[:], wantUser[:]) == 1
+		if !ok || !userOK || subtle.ConstantTimeCompare(gotPassword[:], wantPassword[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxFreezeSeconds caps FreezeRequest.Seconds, like --freeze-timeout.
+const maxFreezeSeconds = 3600
+
+// FreezeRequest is the body of POST /api/freeze.
+type FreezeRequest struct {
+	Seconds int `json:"seconds,omitempty"` // release after this many seconds (at most 3600); 0 = until DELETE
+}
+
+// handleFreeze serves /api/freeze. GET returns the freeze state (a
+// hub.FreezeInfo), POST freezes the input display, and DELETE releases it;
+// both return the new state.
+func (s *Server) handleFreeze(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.broadcaster.Frozen())
+
+	case http.MethodPost:
+		var req FreezeRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		if req.Seconds < 0 || req.Seconds > maxFreezeSeconds {
+			writeAPIError(w, http.StatusBadRequest, "seconds must be in [0, 3600]")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.broadcaster.Freeze(time.Duration(req.Seconds)*time.Second))
+
+	case http.MethodDelete:
+		s.broadcaster.Unfreeze()
+		writeJSON(w, http.StatusOK, s.broadcaster.Frozen())
+
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
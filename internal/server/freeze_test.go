@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/soar/inputview/internal/hub"
+)
+
+// TestFreeze verifies freezing and releasing the input display with
+// /api/freeze and the validation of the freeze duration.
+func TestFreeze(t *testing.T) {
+	srv, _ := newTestServer(t)
+	handler := srv.Handler()
+
+	do := func(method, body string) (int, hub.FreezeInfo) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/api/freeze", strings.NewReader(body)))
+		var info hub.FreezeInfo
+		json.Unmarshal(rec.Body.Bytes(), &info)
+		return rec.Code, info
+	}
+
+	if code, info := do(http.MethodPost, `{"seconds":30}`); code != http.StatusOK || !info.Frozen || info.Until == 0 {
+		t.Errorf("POST {seconds: 30} = %d %+v, want 200 frozen with a deadline", code, info)
+	}
+	if code, info := do(http.MethodPost, ""); code != http.StatusOK || !info.Frozen || info.Until != 0 {
+		t.Errorf("POST without a body = %d %+v, want 200 frozen until released", code, info)
+	}
+	if code, info := do(http.MethodGet, ""); code != http.StatusOK || !info.Frozen {
+		t.Errorf("GET = %d %+v, want 200 frozen", code, info)
+	}
+	if code, info := do(http.MethodDelete, ""); code != http.StatusOK || info.Frozen {
+		t.Errorf("DELETE = %d %+v, want 200 released", code, info)
+	}
+	for _, body := range []string{`{"seconds":-1}`, `{"seconds":3601}`, `{"minutes":1}`} {
+		if code, _ := do(http.MethodPost, body); code != http.StatusBadRequest {
+			t.Errorf("POST %s = %d, want 400", body, code)
+		}
+	}
+	if code, _ := do(http.MethodPut, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT = %d, want 405", code)
+	}
+}
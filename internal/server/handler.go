@@ -1,15 +1,29 @@
 package server
 
 import (
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/lxzan/gws"
-	"github.com/soar/inputview/internal/gamepad"
 	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/pkg/gamepad"
 )
 
-const sessionKeyClient = "client"
+const (
+	sessionKeyClient     = "client"
+	sessionKeyRemoteAddr = "remoteAddr" // client address as resolved by the middlewares (see SetTrustedProxies)
+	sessionKeyToken      = "token"      // ID of the viewer token the client was admitted with (see tokenMiddleware)
+	sessionKeyEncoding   = "encoding"   // message encoding the client asked for (see wsEncoding)
+	sessionKeyRate       = "rate"       // update rate from the ?rate= query parameter (see Client.SetUpdateRate)
+	sessionKeyAnalog     = "analog"     // analog mode from the ?analog= query parameter (see Client.SetAnalogMode)
+	sessionKeyFields     = "fields"     // delta groups from the ?fields= query parameter (see Client.SetFields)
+)
 
 // wsHandler implements the gws.Event interface to handle WebSocket lifecycle events.
 type wsHandler struct {
@@ -24,6 +38,38 @@ type wsHandler struct {
 // It creates a Client, registers it with the Hub, and sends the initial gamepad state.
 func (h *wsHandler) OnOpen(socket *gws.Conn) {
 	client := hub.NewClient(h.hub, socket)
+	if v, ok := socket.Session().Load(sessionKeyRemoteAddr); ok {
+		client.SetRemoteAddr(v.(string))
+	}
+	if v, ok := socket.Session().Load(sessionKeyToken); ok {
+		client.SetViewerToken(v.(string))
+	}
+	if v, ok := socket.Session().Load(sessionKeyEncoding); ok {
+		client.SetEncoding(v.(string))
+	}
+	if v, ok := socket.Session().Load(sessionKeyRate); ok {
+		client.SetUpdateRate(v.(float64))
+	}
+	if v, ok := socket.Session().Load(sessionKeyAnalog); ok {
+		client.SetAnalogMode(v.(string))
+	}
+	if v, ok := socket.Session().Load(sessionKeyFields); ok {
+		client.SetFields(v.([]string))
+	}
+	var players hub.PlayerSwitcher = h.reader
+	if h.broadcaster.AllPlayers() {
+		players = h.broadcaster.StreamSelector()
+	}
+	client.SetCommandTargets(hub.CommandTargets{
+		Players:     players,
+		Viewers:     h.broadcaster.StreamSelector(),
+		KeyMouse:    h.broadcaster,
+		Sensitivity: h.sensSetter,
+		Profiles:    h.broadcaster,
+		Ghosts:      h.broadcaster,
+		Outputs:     h.reader,
+		States:      h.broadcaster,
+	})
 	socket.Session().Store(sessionKeyClient, client)
 	h.hub.Register(client)
 	h.broadcaster.SendInitialState(client)
@@ -40,6 +86,18 @@ func (h *wsHandler) OnClose(socket *gws.Conn, err error) {
 	h.hub.Unregister(client)
 }
 
+// OnPing answers a ping from the client and counts it as a sign of life
+// for the hub's keepalive.
+func (h *wsHandler) OnPing(socket *gws.Conn, payload []byte) {
+	_ = socket.WritePong(payload)
+	h.seen(socket)
+}
+
+// OnPong is called when the client answers the hub's keepalive ping.
+func (h *wsHandler) OnPong(socket *gws.Conn, payload []byte) {
+	h.seen(socket)
+}
+
 // OnMessage is called when a text or binary message is received from the client.
 func (h *wsHandler) OnMessage(socket *gws.Conn, message *gws.Message) {
 	defer message.Close()
@@ -48,7 +106,15 @@ func (h *wsHandler) OnMessage(socket *gws.Conn, message *gws.Message) {
 		return
 	}
 	client := v.(*hub.Client)
-	client.HandleMessage(h.reader, h.broadcaster, h.sensSetter, message.Bytes())
+	client.Seen()
+	client.HandleMessage(message.Bytes())
+}
+
+// seen marks the socket's client as alive (see hub.Hub.SetKeepalive).
+func (h *wsHandler) seen(socket *gws.Conn) {
+	if v, ok := socket.Session().Load(sessionKeyClient); ok {
+		v.(*hub.Client).Seen()
+	}
 }
 
 func handleWebSocket(h *hub.Hub, b *hub.Broadcaster, reader *gamepad.Reader, sensSetter hub.MouseSensitivitySetter) http.HandlerFunc {
@@ -58,15 +124,59 @@ func handleWebSocket(h *hub.Hub, b *hub.Broadcaster, reader *gamepad.Reader, sen
 		reader:      reader,
 		sensSetter:  sensSetter,
 	}
-	upgrader := gws.NewUpgrader(handler, &gws.ServerOption{
-		// Allow all origins for local use
+	option := &gws.ServerOption{
 		Authorize: func(r *http.Request, session gws.SessionStorage) bool {
+			session.Store(sessionKeyRemoteAddr, r.RemoteAddr)
+			if id := viewerTokenID(r); id != "" {
+				session.Store(sessionKeyToken, id)
+			}
+			session.Store(sessionKeyEncoding, wsEncoding(r))
+			if hz, ok := wsRate(r); ok {
+				session.Store(sessionKeyRate, hz)
+			}
+			if mode := r.URL.Query().Get("analog"); mode != "" {
+				session.Store(sessionKeyAnalog, mode)
+			}
+			if fields, err := hub.ParseFields(r.URL.Query().Get("fields")); err == nil && fields != nil {
+				session.Store(sessionKeyFields, fields)
+			}
 			return true
 		},
-	})
+	}
+	upgrader := gws.NewUpgrader(handler, option)
+	// gws fails the handshake of clients that offer none of SubProtocols, so
+	// only requests offering "msgpack" go through an upgrader that accepts it.
+	msgpackOption := *option
+	msgpackOption.SubProtocols = []string{hub.EncodingMsgpack}
+	msgpackUpgrader := gws.NewUpgrader(handler, &msgpackOption)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		socket, err := upgrader.Upgrade(w, r)
+		if !sameOrigin(r) {
+			slog.Warn("rejected cross-origin WebSocket request", "origin", r.Header.Get("Origin"), "host", requestHost(r), "remote", r.RemoteAddr)
+			writeAPIError(w, http.StatusForbidden, "cross-origin WebSocket requests are not allowed")
+			return
+		}
+		if enc := r.URL.Query().Get("encoding"); enc != "" && !slices.Contains(hub.Encodings, enc) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("unknown encoding %q (available: %v)", enc, hub.Encodings))
+			return
+		}
+		if _, ok := wsRate(r); !ok && r.URL.Query().Has("rate") {
+			writeAPIError(w, http.StatusBadRequest, "rate must be a number of updates per second in [0, 1000]")
+			return
+		}
+		if mode := r.URL.Query().Get("analog"); mode != "" && !slices.Contains(hub.AnalogModes, mode) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("unknown analog mode %q (available: %v)", mode, hub.AnalogModes))
+			return
+		}
+		if _, err := hub.ParseFields(r.URL.Query().Get("fields")); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		u := upgrader
+		if offersSubprotocol(r, hub.EncodingMsgpack) {
+			u = msgpackUpgrader
+		}
+		socket, err := u.Upgrade(w, r)
 		if err != nil {
 			slog.Error("WebSocket upgrade failed", "error", err)
 			return
@@ -83,3 +193,61 @@ func handleWebSocket(h *hub.Hub, b *hub.Broadcaster, reader *gamepad.Reader, sen
 		}()
 	}
 }
+
+// sameOrigin reports whether a /ws request comes from a page of this server:
+// its Origin names the host the request was sent to (requestHost, so the
+// public one behind a trusted proxy). Browsers always send Origin on a
+// WebSocket handshake, so a request without one is from a non-browser client
+// and is admitted; any other page the user opens (even with loopback always
+// admitted) is refused, so it cannot read key presses or drive the
+// controller.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Host, requestHost(r))
+}
+
+// wsEncoding returns the message encoding a /ws request asks for: the
+// ?encoding= query parameter (already validated), else msgpack if it offers
+// that subprotocol, else JSON.
+func wsEncoding(r *http.Request) string {
+	if enc := r.URL.Query().Get("encoding"); enc != "" {
+		return enc
+	}
+	if offersSubprotocol(r, hub.EncodingMsgpack) {
+		return hub.EncodingMsgpack
+	}
+	return hub.EncodingJSON
+}
+
+// wsRate returns the update rate a /ws request asks for with ?rate= (Hz, 0 =
+// every change), and false if it sets none or an invalid one.
+func wsRate(r *http.Request) (float64, bool) {
+	if !r.URL.Query().Has("rate") {
+		return 0, false
+	}
+	hz, err := strconv.ParseFloat(r.URL.Query().Get("rate"), 64)
+	if err != nil || hz < 0 || hz > 1000 || math.IsNaN(hz) {
+		return 0, false
+	}
+	return hz, true
+}
+
+// offersSubprotocol reports whether a WebSocket handshake lists name in its
+// Sec-WebSocket-Protocol header.
+func offersSubprotocol(r *http.Request, name string) bool {
+	for _, h := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for p := range strings.SplitSeq(h, ",") {
+			if strings.TrimSpace(p) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
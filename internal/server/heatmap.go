@@ -0,0 +1,115 @@
+package server
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/soar/inputview/internal/hub"
+)
+
+// heatmapRamp are the colors of a heatmap PNG from its least used cells to
+// its most used; unused cells are transparent.
+var heatmapRamp = []color.NRGBA{
+	{0, 0, 255, 255},   // blue
+	{0, 255, 255, 255}, // cyan
+	{0, 255, 0, 255},   // green
+	{255, 255, 0, 255}, // yellow
+	{255, 0, 0, 255},   // red
+}
+
+// handleHeatmap serves GET /api/heatmap: where player ?player=N's (default 1)
+// sticks were held since the server started, as time spent in each cell of a
+// grid. JSON (the default) returns both sticks' hub.Heatmaps; ?format=png
+// renders the grid of ?stick=left|right (default left) at ?scale= pixels per
+// cell (default 8), colored on a log scale.
+func (s *Server) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	player := 1
+	if v := q.Get("player"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeAPIError(w, http.StatusBadRequest, "player must be an integer >= 1")
+			return
+		}
+		player = n
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "png" {
+		writeAPIError(w, http.StatusBadRequest, "format must be json or png")
+		return
+	}
+	stick := q.Get("stick")
+	if stick != "" && stick != "left" && stick != "right" {
+		writeAPIError(w, http.StatusBadRequest, "stick must be left or right")
+		return
+	}
+	scale := 8
+	if v := q.Get("scale"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 32 {
+			writeAPIError(w, http.StatusBadRequest, "scale must be an integer in [1, 32]")
+			return
+		}
+		scale = n
+	}
+
+	h := s.broadcaster.Heatmaps(player)
+	if format != "png" {
+		writeJSON(w, http.StatusOK, h)
+		return
+	}
+	grid := h.Left
+	if stick == "right" {
+		grid = h.Right
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = png.Encode(w, renderHeatmap(grid, scale))
+}
+
+// renderHeatmap draws h with scale × scale pixels per cell: unused cells
+// transparent, the others along heatmapRamp by the log of their time against
+// the most used cell's, so short visits stay visible next to the resting
+// centre.
+func renderHeatmap(h hub.StickHeatmap, scale int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, h.Size*scale, h.Size*scale))
+	var peak int64
+	for _, row := range h.Cells {
+		for _, ms := range row {
+			peak = max(peak, ms)
+		}
+	}
+	for y, row := range h.Cells {
+		for x, ms := range row {
+			if ms == 0 {
+				continue
+			}
+			c := rampColor(math.Log1p(float64(ms)) / math.Log1p(float64(peak)))
+			for py := y * scale; py < (y+1)*scale; py++ {
+				for px := x * scale; px < (x+1)*scale; px++ {
+					img.SetNRGBA(px, py, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// rampColor returns the color at t (0-1) along heatmapRamp.
+func rampColor(t float64) color.NRGBA {
+	pos := t * float64(len(heatmapRamp)-1)
+	i := min(int(pos), len(heatmapRamp)-2)
+	f := pos - float64(i)
+	a, b := heatmapRamp[i], heatmapRamp[i+1]
+	mix := func(x, y uint8) uint8 { return uint8(math.Round(float64(x) + (float64(y)-float64(x))*f)) }
+	return color.NRGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
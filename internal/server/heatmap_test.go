@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestHeatmap verifies GET /api/heatmap: the injected stick position's cell
+// fills up as JSON, the PNG of a stick at its scale, and invalid parameters
+// and methods.
+func TestHeatmap(t *testing.T) {
+	srv, reader := newTestServer(t)
+	go srv.broadcaster.Run()
+	handler := srv.Handler()
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	state := gamepad.GamepadState{Connected: true, Name: "Scripted", PlayerIndex: 1}
+	state.Sticks.Right.Position = gamepad.Vector{X: 1, Y: 1}
+	reader.Inject(state)
+	var h hub.Heatmaps
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		rec := get("/api/heatmap")
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &h) != nil {
+			t.Fatalf("GET /api/heatmap = %d %s", rec.Code, rec.Body)
+		}
+		if h.Right.Cells[0][hub.HeatmapSize-1] > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GET /api/heatmap = %+v, want time in the top-right cell of the right stick", h.Right)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if h.PlayerIndex != 1 || h.Right.Size != hub.HeatmapSize || h.Right.TotalMs != h.Right.Cells[0][hub.HeatmapSize-1] {
+		t.Errorf("heatmaps = player %d, right stick size %d, %d ms in all; want player 1, all in one cell", h.PlayerIndex, h.Right.Size, h.Right.TotalMs)
+	}
+
+	rec := get("/api/heatmap?format=png&stick=right&scale=2")
+	img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" || err != nil {
+		t.Fatalf("GET ?format=png = %d %q, decode error %v", rec.Code, rec.Header().Get("Content-Type"), err)
+	}
+	size := 2 * hub.HeatmapSize
+	if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+		t.Errorf("PNG bounds = %v, want %d × %d", b, size, size)
+	}
+	if _, _, _, a := img.At(size-1, 0).RGBA(); a == 0 {
+		t.Error("top-right pixel transparent, want the used cell colored")
+	}
+	if _, _, _, a := img.At(0, size-1).RGBA(); a != 0 {
+		t.Error("bottom-left pixel colored, want an unused cell transparent")
+	}
+
+	for _, target := range []string{"/api/heatmap?player=0", "/api/heatmap?format=svg", "/api/heatmap?stick=middle", "/api/heatmap?format=png&scale=0"} {
+		if rec := get(target); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", target, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/heatmap", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/heatmap = %d, want 405", rec.Code)
+	}
+}
+
+// TestRenderHeatmap verifies the most used cell gets the end of the ramp, a
+// less used one a color in between, and unused cells stay transparent.
+func TestRenderHeatmap(t *testing.T) {
+	h := hub.StickHeatmap{Size: 2, Cells: [][]int64{{1_000_000, 1000}, {0, 0}}}
+	img := renderHeatmap(h, 1)
+	if got := img.NRGBAAt(0, 0); got != heatmapRamp[len(heatmapRamp)-1] {
+		t.Errorf("most used cell = %v, want %v", got, heatmapRamp[len(heatmapRamp)-1])
+	}
+	if got := img.NRGBAAt(1, 0); got == heatmapRamp[0] || got == heatmapRamp[len(heatmapRamp)-1] || got.A != 255 {
+		t.Errorf("cell at half the log scale = %v, want an opaque color inside the ramp", got)
+	}
+	if got := img.NRGBAAt(0, 1); got != (color.NRGBA{}) {
+		t.Errorf("unused cell = %v, want transparent", got)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/soar/inputview/internal/hub"
+)
+
+// HistoryResponse is the body of GET /api/history.
+type HistoryResponse struct {
+	Entries []hub.HistoryEntry `json:"entries"` // oldest first; empty when nothing changed in the window
+}
+
+// handleHistory serves GET /api/history: the gamepad state changes of the
+// last --history seconds, so late-joining clients and analysis tools can
+// reconstruct recent input. ?since= (Unix ms) returns only the later
+// changes, and ?after= (an entry's seq) only those after that entry, for
+// polling with the last entry's seq without losing changes of the same
+// millisecond; ?player=N only player N's; ?limit=N at most the first N of
+// them, for paging through a long history.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	var since int64
+	if v := q.Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			writeAPIError(w, http.StatusBadRequest, "since must be a Unix time in milliseconds")
+			return
+		}
+		since = n
+	}
+	var after uint64
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "after must be an entry seq >= 0")
+			return
+		}
+		after = n
+	}
+	player := 0
+	if v := q.Get("player"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeAPIError(w, http.StatusBadRequest, "player must be an integer >= 1")
+			return
+		}
+		player = n
+	}
+	limit := -1
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeAPIError(w, http.StatusBadRequest, "limit must be an integer >= 1")
+			return
+		}
+		limit = n
+	}
+	entries, ok := s.broadcaster.History(after)
+	if !ok {
+		writeAPIError(w, http.StatusServiceUnavailable, "history is not enabled (--history)")
+		return
+	}
+	if since > 0 {
+		entries = entries[sort.Search(len(entries), func(i int) bool { return entries[i].At > since }):]
+	}
+	if player != 0 {
+		// entries is shared with the broadcaster: filter into a new slice.
+		kept := []hub.HistoryEntry{}
+		for _, e := range entries {
+			if e.State.PlayerIndex == player {
+				kept = append(kept, e)
+				if len(kept) == limit {
+					break
+				}
+			}
+		}
+		entries = kept
+	}
+	if limit >= 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	writeJSON(w, http.StatusOK, HistoryResponse{Entries: entries})
+}
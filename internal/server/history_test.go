@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestHistory verifies GET /api/history: 503 until --history is set, the
+// injected changes after ?since= and ?after=, ?limit=, the per-player filter,
+// and invalid parameters and methods.
+func TestHistory(t *testing.T) {
+	srv, reader := newTestServer(t)
+	handler := srv.Handler()
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	if rec := get("/api/history"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /api/history without a history = %d, want 503", rec.Code)
+	}
+
+	srv.broadcaster.SetHistory(time.Minute)
+	go srv.broadcaster.Run()
+	reader.Inject(gamepad.GamepadState{Connected: true, Name: "Scripted", PlayerIndex: 1})
+	reader.Inject(gamepad.GamepadState{Connected: true, Name: "Scripted", PlayerIndex: 1, Buttons: gamepad.ButtonState{A: true}})
+	var resp HistoryResponse
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		rec := get("/api/history")
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("GET /api/history = %d %s", rec.Code, rec.Body)
+		}
+		if n := len(resp.Entries); n > 0 && resp.Entries[n-1].State.Buttons.A {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GET /api/history = %+v, want the injected press last", resp)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	first, last := resp.Entries[0].Seq, resp.Entries[len(resp.Entries)-1].Seq
+	lastAt := resp.Entries[len(resp.Entries)-1].At
+	if rec := get("/api/history?after=" + strconv.FormatUint(last, 10)); json.Unmarshal(rec.Body.Bytes(), &resp) != nil || resp.Entries == nil || len(resp.Entries) != 0 {
+		t.Errorf("GET ?after=<last seq> = %s, want no entries", rec.Body)
+	}
+	if rec := get("/api/history?since=" + strconv.FormatInt(lastAt, 10)); json.Unmarshal(rec.Body.Bytes(), &resp) != nil || resp.Entries == nil || len(resp.Entries) != 0 {
+		t.Errorf("GET ?since=<last at> = %s, want no entries", rec.Body)
+	}
+	if rec := get("/api/history?since=" + strconv.FormatInt(lastAt-1, 10) + "&after=" + strconv.FormatUint(last-1, 10)); json.Unmarshal(rec.Body.Bytes(), &resp) != nil || len(resp.Entries) != 1 || resp.Entries[0].Seq != last {
+		t.Errorf("GET ?since=<last at - 1>&after=<last seq - 1> = %s, want the last entry only", rec.Body)
+	}
+	for _, target := range []string{"/api/history?limit=1", "/api/history?player=1&limit=1"} {
+		if rec := get(target); json.Unmarshal(rec.Body.Bytes(), &resp) != nil || len(resp.Entries) != 1 || resp.Entries[0].Seq != first {
+			t.Errorf("GET %s = %s, want the first entry only", target, rec.Body)
+		}
+	}
+	if rec := get("/api/history?player=2"); json.Unmarshal(rec.Body.Bytes(), &resp) != nil || len(resp.Entries) != 0 {
+		t.Errorf("GET ?player=2 = %s, want no entries", rec.Body)
+	}
+	for _, target := range []string{"/api/history?since=soon", "/api/history?since=-1", "/api/history?after=soon", "/api/history?after=-1", "/api/history?player=0", "/api/history?limit=0"} {
+		if rec := get(target); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", target, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/history", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE /api/history = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// unixPrefix marks a listener address as a Unix domain socket path
+// ("unix:/run/inputview.sock").
+const unixPrefix = "unix:"
+
+// unixPeer is the RemoteAddr given to requests on a Unix socket, which have no
+// IP address: they come from this machine, and the socket file's permissions
+// decide who may connect, so they count as loopback.
+const unixPeer = "127.0.0.1:0"
+
+// Listener is an additional address served alongside the main one (New's
+// addr), with its own TLS and basic auth settings. Nothing is inherited from
+// SetTLS or SetBasicAuth: a listener without AuthPassword has no basic auth
+// even when the main listener does. The remote access, allowed networks,
+// trusted proxies, and viewer token settings are shared.
+type Listener struct {
+	Name         string // shown in logs and /health; must be unique
+	Addr         string // "host:port", or "unix:<path>" for a Unix domain socket
+	TLSCert      string // PEM certificate (chain) file; empty = plain HTTP
+	TLSKey       string // PEM private key file for TLSCert
+	AuthUser     string // basic auth user name; empty = any (see SetBasicAuth)
+	AuthPassword string // basic auth password; empty = no basic auth
+}
+
+// AddListener serves the handler tree on l in addition to the main address.
+// Must be called before Handler or ListenAndServe.
+func (s *Server) AddListener(l Listener) { s.extraListeners = append(s.extraListeners, l) }
+
+// listeners returns the main listener, built from New's addr, SetTLS, and
+// SetBasicAuth, followed by the ones added with AddListener.
+func (s *Server) listeners() []Listener {
+	main := Listener{
+		Name: "main", Addr: s.addr,
+		TLSCert: s.tlsCert, TLSKey: s.tlsKey,
+		AuthUser: s.authUser, AuthPassword: s.authPassword,
+	}
+	return append([]Listener{main}, s.extraListeners...)
+}
+
+// listen binds l and returns the http.Server to run on it with h wrapped in
+// l's middlewares. The caller serves it with serve.
+func (s *Server) listen(l Listener, h http.Handler) (*http.Server, net.Listener, error) {
+	network, address := "tcp", l.Addr
+	if path, ok := strings.CutPrefix(l.Addr, unixPrefix); ok {
+		network, address = "unix", path
+		removeStaleSocket(path)
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listener %s: %w", l.Name, err)
+	}
+	h = s.wrap(h, l)
+	if network == "unix" {
+		if err := os.Chmod(address, 0o600); err != nil {
+			slog.Warn("could not restrict Unix socket permissions", "listener", l.Name, "path", address, "error", err)
+		}
+		h = unixPeerMiddleware(h)
+	}
+
+	srv := &http.Server{Addr: l.Addr, Handler: h}
+	if l.TLSCert != "" {
+		certs, err := newCertReloader(l.TLSCert, l.TLSKey)
+		if err != nil {
+			ln.Close()
+			return nil, nil, fmt.Errorf("listener %s: %w", l.Name, err)
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+		slog.Info("HTTPS server listening", "listener", l.Name, "addr", l.Addr, "cert", l.TLSCert)
+	} else {
+		slog.Info("HTTP server listening", "listener", l.Name, "addr", l.Addr)
+	}
+	return srv, ln, nil
+}
+
+// serve runs srv on ln until it is shut down, over TLS if srv has a TLS
+// config.
+func serve(srv *http.Server, ln net.Listener) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(ln, "", "")
+	}
+	return srv.Serve(ln)
+}
+
+// removeStaleSocket deletes a Unix socket file left behind by a process that
+// did not shut down cleanly, so listening on path does not fail with "address
+// already in use". Anything that is not a socket is left alone.
+func removeStaleSocket(path string) {
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("could not remove stale Unix socket", "path", path, "error", err)
+	}
+}
+
+// unixPeerMiddleware gives requests on a Unix socket the loopback address
+// unixPeer, so the access check, the admin API, and logs treat them as local.
+func unixPeerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.RemoteAddr = unixPeer
+		next.ServeHTTP(w, r)
+	})
+}
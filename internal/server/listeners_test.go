@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// unixClient returns an HTTP client that sends every request to the Unix
+// socket at path.
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+// TestListeners verifies that every listener is served with its own basic
+// auth settings, that Unix socket clients count as local (admin API), that
+// their addresses are listed by the admin API and not by the open /health,
+// and that Shutdown stops them all.
+func TestListeners(t *testing.T) {
+	dir := t.TempDir()
+	mainSock, toolSock := filepath.Join(dir, "main.sock"), filepath.Join(dir, "tool.sock")
+	// A stale socket file from an unclean exit must not block listening.
+	if ln, err := net.Listen("unix", toolSock); err == nil {
+		ln.(*net.UnixListener).SetUnlinkOnClose(false)
+		ln.Close()
+	}
+
+	srv, _ := newTestServer(t)
+	srv.addr = unixPrefix + mainSock
+	srv.SetBasicAuth("", "secret")
+	srv.AddListener(Listener{Name: "tool", Addr: unixPrefix + toolSock})
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	get := func(sock, path string, auth bool) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "http://inputview"+path, nil)
+		if auth {
+			req.SetBasicAuth("", "secret")
+		}
+		var resp *http.Response
+		var err error
+		for range 100 { // until the listener is up
+			if resp, err = unixClient(sock).Do(req); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatalf("GET %s on %s: %v", path, filepath.Base(sock), err)
+		}
+		defer resp.Body.Close()
+		switch {
+		case resp.StatusCode != http.StatusOK:
+		case path == "/health":
+			var health map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := health["listeners"]; ok {
+				t.Errorf("/health lists the listeners without auth: %v", health)
+			}
+		case path == "/api/admin/listeners":
+			var body ListenersResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if got := body.Listeners["tool"]; got != unixPrefix+toolSock {
+				t.Errorf("/api/admin/listeners tool = %q, want %q", got, unixPrefix+toolSock)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	if got := get(mainSock, "/api/admin/audit", false); got != http.StatusUnauthorized {
+		t.Errorf("main listener without credentials = %d, want 401", got)
+	}
+	if got := get(mainSock, "/api/admin/audit", true); got != http.StatusOK {
+		t.Errorf("main listener with credentials = %d, want 200", got)
+	}
+	if got := get(toolSock, "/api/admin/audit", false); got != http.StatusOK {
+		t.Errorf("tool listener (no auth) = %d, want 200", got)
+	}
+	if got := get(toolSock, "/health", false); got != http.StatusOK {
+		t.Errorf("tool listener /health = %d, want 200", got)
+	}
+	if got := get(mainSock, "/health", false); got != http.StatusOK {
+		t.Errorf("main listener /health without credentials = %d, want 200", got)
+	}
+	if got := get(mainSock, "/api/admin/listeners", false); got != http.StatusUnauthorized {
+		t.Errorf("main listener /api/admin/listeners without credentials = %d, want 401", got)
+	}
+	if got := get(toolSock, "/api/admin/listeners", false); got != http.StatusOK {
+		t.Errorf("tool listener /api/admin/listeners = %d, want 200", got)
+	}
+	if fi, err := os.Stat(toolSock); err != nil {
+		t.Error(err)
+	} else if runtime.GOOS != "windows" && fi.Mode().Perm()&0o077 != 0 {
+		t.Errorf("socket permissions = %v, want no group/other access", fi.Mode().Perm())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("ListenAndServe() after Shutdown = %v, want ErrServerClosed", err)
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/soar/inputview/internal/export"
+	"github.com/soar/inputview/internal/marker"
+)
+
+// markerContentTypes are the response content types of the export formats of
+// GET /api/markers.
+var markerContentTypes = map[string]string{
+	"srt": "application/x-subrip; charset=utf-8",
+	"ass": "text/x-ssa; charset=utf-8",
+	"edl": "text/plain; charset=utf-8",
+}
+
+// CreateMarkerRequest is the body of POST /api/markers.
+type CreateMarkerRequest struct {
+	Name string `json:"name,omitempty"` // at most 64 bytes, no control characters; empty = "marker <id>"
+}
+
+// MarkersResponse is the body of GET /api/markers.
+type MarkersResponse struct {
+	Markers []marker.Marker `json:"markers"` // oldest first; empty when none were dropped
+}
+
+// handleMarkers serves /api/markers. GET lists the markers as JSON, or with
+// ?format=srt|ass|edl exports them as a file timed by time of day (EDL
+// timecodes at ?fps=, default 30). POST drops a marker now.
+func (s *Server) handleMarkers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		markers := s.broadcaster.Markers()
+		format := r.URL.Query().Get("format")
+		if format == "" || format == "json" {
+			writeJSON(w, http.StatusOK, MarkersResponse{Markers: markers})
+			return
+		}
+		if !slices.Contains(export.Formats, format) {
+			writeAPIError(w, http.StatusBadRequest, "format must be json, srt, ass, or edl")
+			return
+		}
+		fps := 30
+		if v := r.URL.Query().Get("fps"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 240 {
+				writeAPIError(w, http.StatusBadRequest, "fps must be an integer in [1, 240]")
+				return
+			}
+			fps = n
+		}
+		w.Header().Set("Content-Type", markerContentTypes[format])
+		w.Header().Set("Content-Disposition", `attachment; filename="markers.`+format+`"`)
+		_ = export.Write(w, format, export.Markers(markers), 0, fps, "InputView markers")
+
+	case http.MethodPost:
+		var req CreateMarkerRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		if err := marker.CheckName(req.Name); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid marker name: "+err.Error())
+			return
+		}
+		m := s.broadcaster.AddMarker(req.Name, marker.SourceAPI, 0)
+		if m.ID == 0 {
+			writeAPIError(w, http.StatusServiceUnavailable, "markers are not enabled")
+			return
+		}
+		writeJSON(w, http.StatusCreated, m)
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
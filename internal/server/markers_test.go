@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/soar/inputview/internal/marker"
+)
+
+// TestMarkers verifies dropping markers with POST /api/markers, name
+// validation, and listing and exporting them with GET.
+func TestMarkers(t *testing.T) {
+	srv, _ := newTestServer(t)
+	l, _ := marker.New("")
+	srv.broadcaster.SetMarkers(l, nil)
+	handler := srv.Handler()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/api/markers", `{"name":"boss down"}`)
+	var m marker.Marker
+	if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &m) != nil || m.ID != 1 || m.Name != "boss down" || m.Source != marker.SourceAPI {
+		t.Fatalf("POST /api/markers = %d %s, want 201 with marker 1", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodPost, "/api/markers", ""); rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"name":"marker 2"`) {
+		t.Errorf("POST /api/markers without a body = %d %s, want 201 with marker 2", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodPost, "/api/markers", `{"name":"a\nb"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST with a newline in the name = %d, want 400", rec.Code)
+	}
+
+	rec = do(http.MethodGet, "/api/markers", "")
+	var list MarkersResponse
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &list) != nil || len(list.Markers) != 2 {
+		t.Errorf("GET /api/markers = %d %s, want 200 with 2 markers", rec.Code, rec.Body)
+	}
+	rec = do(http.MethodGet, "/api/markers?format=edl&fps=25", "")
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, "|M:boss down |D:") || !strings.Contains(body, "|M:marker 2 |D:50") {
+		t.Errorf("GET ?format=edl = %d %s, want both markers, the last 2 s at 25 fps", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodGet, "/api/markers?format=vtt", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET ?format=vtt = %d, want 400", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/markers", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE /api/markers = %d, want 405", rec.Code)
+	}
+}
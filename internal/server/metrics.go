@@ -0,0 +1,65 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// metricSample is one line of a metric family: name+suffix{labels} value.
+type metricSample struct {
+	suffix string // appended to the family name, e.g. "_sum"; "" = none
+	labels string // `key="value",...` without braces; "" = none
+	value  float64
+}
+
+// handleMetrics serves GET /metrics in the Prometheus text exposition format,
+// so a long-running capture box can be monitored: connected clients and
+// controllers, the hub's message counts (rate() of
+// inputview_broadcast_messages_total is the broadcasts per second), drops,
+// WebSocket write errors, and the cost of the XInput polling loop.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	hs := s.hub.Stats()
+	ps := s.reader.PollStats()
+
+	var b bytes.Buffer
+	writeMetric(&b, "inputview_start_time_seconds", "gauge", "Unix time the server started.",
+		metricSample{value: float64(s.startTime.UnixMilli()) / 1000})
+	writeMetric(&b, "inputview_clients", "gauge", "Connected WebSocket clients.",
+		metricSample{value: float64(s.hub.ClientCount())})
+	writeMetric(&b, "inputview_controllers", "gauge", "Connected controllers.",
+		metricSample{value: float64(len(s.reader.Controllers()))})
+	writeMetric(&b, "inputview_broadcast_messages_total", "counter", "Messages fanned out to the clients they target.",
+		metricSample{value: float64(hs.Broadcasts)})
+	writeMetric(&b, "inputview_sent_messages_total", "counter", "Messages queued for writing to a client.",
+		metricSample{value: float64(hs.Sent)})
+	writeMetric(&b, "inputview_dropped_messages_total", "counter", "Messages dropped because a buffer was full.",
+		metricSample{labels: `buffer="hub"`, value: float64(hs.QueueDrops)},
+		metricSample{labels: `buffer="client"`, value: float64(hs.ClientDrops)})
+	writeMetric(&b, "inputview_ws_write_errors_total", "counter", "Messages whose write to a WebSocket connection failed.",
+		metricSample{value: float64(hs.WriteErrors)})
+	writeMetric(&b, "inputview_poll_duration_seconds", "summary", "Time spent in XInput polling cycles.",
+		metricSample{suffix: "_sum", value: ps.Total.Seconds()},
+		metricSample{suffix: "_count", value: float64(ps.Cycles)})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(b.Bytes())
+}
+
+// writeMetric appends a metric family with its HELP and TYPE lines.
+func writeMetric(b *bytes.Buffer, name, kind, help string, samples ...metricSample) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	for _, s := range samples {
+		b.WriteString(name + s.suffix)
+		if s.labels != "" {
+			b.WriteString("{" + s.labels + "}")
+		}
+		b.WriteString(" " + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMetrics verifies GET /metrics: the text exposition format with the
+// hub's counts, and the method check.
+func TestMetrics(t *testing.T) {
+	srv, _ := newTestServer(t)
+	srv.hub.BroadcastAll([]byte(`{"type":"full","seq":1,"timestamp":0}`))
+	handler := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE inputview_clients gauge\ninputview_clients 0\n",
+		"# TYPE inputview_controllers gauge\ninputview_controllers 0\n",
+		"# TYPE inputview_broadcast_messages_total counter\ninputview_broadcast_messages_total 1\n",
+		`inputview_dropped_messages_total{buffer="hub"} 0` + "\n",
+		`inputview_dropped_messages_total{buffer="client"} 0` + "\n",
+		"inputview_ws_write_errors_total 0\n",
+		"# TYPE inputview_poll_duration_seconds summary\ninputview_poll_duration_seconds_sum 0\ninputview_poll_duration_seconds_count 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET /metrics lacks %q:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /metrics = %d, want 405", rec.Code)
+	}
+}
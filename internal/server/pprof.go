@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// mountPprof registers the net/http/pprof handlers under /debug/pprof/ (see
+// SetDebug). They are served to loopback clients only, like the admin API:
+// profiles reveal the command line and memory contents, and a CPU profile or
+// trace keeps a request busy for its duration.
+func mountPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", adminOnly(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", adminOnly(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", adminOnly(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", adminOnly(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", adminOnly(pprof.Trace))
+}
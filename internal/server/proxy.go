@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// SetTrustedProxies sets the reverse proxies (nginx, Cloudflare tunnels, …)
+// whose X-Forwarded-For, X-Forwarded-Proto, and X-Forwarded-Host headers are
+// honored. For a request from one of them, the client address used for
+// logging, remote access checks, and the WebSocket client is taken from
+// X-Forwarded-For, and /ws checks the Origin against X-Forwarded-Host.
+// nil or empty trusts no one (the default): the headers are ignored. Must be
+// called before Handler or ListenAndServe.
+func (s *Server) SetTrustedProxies(nets []netip.Prefix) {
+	s.trustedProxies = slices.Clone(nets)
+}
+
+// protoKey is the request context key of the forwarded scheme.
+type protoKey struct{}
+
+// hostKey is the request context key of the forwarded host.
+type hostKey struct{}
+
+// requestScheme returns "https" or "http" for r: X-Forwarded-Proto from a
+// trusted proxy, otherwise whether the connection itself used TLS.
+func requestScheme(r *http.Request) string {
+	if p, ok := r.Context().Value(protoKey{}).(string); ok {
+		return p
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// requestHost returns the host the client asked for: X-Forwarded-Host from a
+// trusted proxy, otherwise r.Host.
+func requestHost(r *http.Request) string {
+	if h, ok := r.Context().Value(hostKey{}).(string); ok {
+		return h
+	}
+	return r.Host
+}
+
+// proxyMiddleware resolves the client behind a trusted proxy: it replaces
+// r.RemoteAddr with the forwarded client address (port 0) and records the
+// forwarded scheme and host for requestScheme and requestHost. Requests from other peers are passed
+// on unchanged, so their headers cannot spoof an address.
+func proxyMiddleware(next http.Handler, trusted []netip.Prefix) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err != nil || !inNets(peer.Addr().Unmap(), trusted) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r = r.Clone(r.Context())
+		if client, ok := forwardedClient(r.Header.Values("X-Forwarded-For"), trusted); ok {
+			r.RemoteAddr = netip.AddrPortFrom(client, 0).String()
+		}
+		switch p := strings.ToLower(strings.TrimSpace(r.Header.Get("X-Forwarded-Proto"))); p {
+		case "http", "https":
+			r = r.WithContext(context.WithValue(r.Context(), protoKey{}, p))
+		case "":
+		default:
+			slog.Debug("ignoring invalid X-Forwarded-Proto", "value", p)
+		}
+		// A proxy chain lists one host per hop; the first is the client's.
+		if h, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Host"), ","); strings.TrimSpace(h) != "" {
+			r = r.WithContext(context.WithValue(r.Context(), hostKey{}, strings.TrimSpace(h)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedClient returns the client address from X-Forwarded-For header
+// values: the rightmost entry that is not itself a trusted proxy, since each
+// proxy appends the peer it saw and only the trusted ones are honest. If
+// every entry is trusted, the leftmost is used. ok is false when the header
+// is missing or an entry before the client is not an IP address.
+func forwardedClient(values []string, trusted []netip.Prefix) (netip.Addr, bool) {
+	entries := strings.Split(strings.Join(values, ","), ",")
+	var last netip.Addr
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := strings.TrimSpace(entries[i])
+		if e == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(e)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		addr = addr.Unmap()
+		if !inNets(addr, trusted) {
+			return addr, true
+		}
+		last = addr
+	}
+	return last, last.IsValid()
+}
+
+// inNets reports whether addr lies in one of nets.
+func inNets(addr netip.Addr, nets []netip.Prefix) bool {
+	for _, p := range nets {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,22 +6,28 @@ import (
 	"io/fs"
 	"log/slog"
 	"mime"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/soar/inputview/internal/gamepad"
+	"github.com/soar/inputview/internal/audit"
 	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/pkg/gamepad"
 )
 
-type healthResponse struct {
-	Status        string            `json:"status"`
-	Version       string            `json:"version"`
-	UptimeSeconds int64             `json:"uptime_seconds"`
-	Listeners     map[string]string `json:"listeners"`
+// HealthResponse is the body of GET /health.
+type HealthResponse struct {
+	Status        string `json:"status"`
+	Version       string `json:"version"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	Headless      bool   `json:"headless,omitempty"`
 }
 
 type responseWriter struct {
@@ -45,8 +51,52 @@ type Server struct {
 	overlayDir  string
 	keyboardDir string
 	addr        string
-	httpServer  *http.Server
 	startTime   time.Time
+
+	// extraListeners are served alongside addr (see AddListener).
+	extraListeners []Listener
+
+	// httpServers are the running servers, one per listener, set by
+	// ListenAndServe for Shutdown.
+	serversMu   sync.Mutex
+	httpServers []*http.Server
+
+	// injectEnabled registers the debug-only POST /api/inject endpoint.
+	injectEnabled bool
+
+	// debug registers the net/http/pprof endpoints (see SetDebug).
+	debug bool
+
+	// headless skips the embedded frontend and the overlays/keyboards
+	// directories; only the data endpoints (/ws, /health, /metrics, /api/*)
+	// are served.
+	headless bool
+
+	// authUser and authPassword enable HTTP basic auth when the password
+	// is non-empty (see SetBasicAuth).
+	authUser     string
+	authPassword string
+
+	// remoteAllowed serves non-loopback clients (see SetRemoteAllowed);
+	// allowedNets further limits them to these networks when non-empty.
+	remoteAllowed atomic.Bool
+	allowedNets   []netip.Prefix
+
+	// trustedProxies are the peers whose X-Forwarded-* headers are honored
+	// (see SetTrustedProxies).
+	trustedProxies []netip.Prefix
+
+	// tlsCert and tlsKey are the PEM files served over HTTPS when set (see
+	// SetTLS).
+	tlsCert string
+	tlsKey  string
+
+	// auditLog is served by the admin API and records the clients the
+	// server disconnects (see SetAuditLog).
+	auditLog *audit.Log
+
+	// tokens are the live viewer tokens (see tokenMiddleware).
+	tokens *tokenStore
 }
 
 func New(h *hub.Hub, b *hub.Broadcaster, r *gamepad.Reader, sensSetter hub.MouseSensitivitySetter, frontendFS fs.FS, gzipCache map[string][]byte, exeDir string, overlayDir string, keyboardDir string, addr string) *Server {
@@ -62,20 +112,47 @@ func New(h *hub.Hub, b *hub.Broadcaster, r *gamepad.Reader, sensSetter hub.Mouse
 		keyboardDir: keyboardDir,
 		addr:        addr,
 		startTime:   time.Now(),
+		tokens:      newTokenStore(),
 	}
 }
 
-func (s *Server) ListenAndServe() error {
+// SetInjectEnabled enables the debug-only POST /api/inject endpoint.
+// Must be called before Handler or ListenAndServe.
+func (s *Server) SetInjectEnabled(enabled bool) { s.injectEnabled = enabled }
+
+// SetDebug mounts the net/http/pprof profiling endpoints under
+// /debug/pprof/, for loopback clients only, so CPU and heap profiles can be
+// captured from a running server. Must be called before Handler or
+// ListenAndServe.
+func (s *Server) SetDebug(enabled bool) { s.debug = enabled }
+
+// SetHeadless switches the server to data-only mode: the embedded frontend
+// and the external overlays/keyboards directories are not mounted.
+// Must be called before Handler or ListenAndServe.
+func (s *Server) SetHeadless(headless bool) { s.headless = headless }
+
+// Handler builds the HTTP handler tree (health, WebSocket, overlays, static
+// frontend) wrapped in the request logging, client access (loopback only
+// unless SetRemoteAllowed), and optional basic auth middlewares of the main
+// listener. It is usable with httptest for loopback setups; ListenAndServe
+// builds its own for every listener.
+func (s *Server) Handler() http.Handler {
+	return s.wrap(s.routes(), s.listeners()[0])
+}
+
+// routes builds the handler tree without the middlewares, shared by all
+// listeners.
+func (s *Server) routes() http.Handler {
 	mux := http.NewServeMux()
 
 	// Health check endpoint
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		resp := healthResponse{
+		resp := HealthResponse{
 			Status:        "ok",
 			Version:       "0.3.1",
 			UptimeSeconds: int64(time.Since(s.startTime).Seconds()),
-			Listeners:     map[string]string{"addr": s.addr},
+			Headless:      s.headless,
 		}
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			slog.Error("error encoding health response", "error", err)
@@ -85,6 +162,60 @@ func (s *Server) ListenAndServe() error {
 	// WebSocket endpoint
 	mux.HandleFunc("/ws", handleWebSocket(s.hub, s.broadcaster, s.reader, s.sensSetter))
 
+	// Controller metadata and switching the active controller; /api/devices
+	// is an alias
+	mux.HandleFunc("/api/controllers", s.handleControllers)
+	mux.HandleFunc("/api/controllers/", s.handleController)
+	mux.HandleFunc("/api/devices", s.handleControllers)
+	mux.HandleFunc("/api/devices/", s.handleController)
+
+	// Latest gamepad states, for polling without the WebSocket protocol
+	mux.HandleFunc("/api/state", s.handleState)
+
+	// Recent gamepad state changes, for late joiners and analysis tools
+	mux.HandleFunc("/api/history", s.handleHistory)
+
+	// Session statistics: press counts, actions per minute, stick travel
+	mux.HandleFunc("/api/stats", s.handleStats)
+
+	// Stick position heatmaps (JSON grid or PNG)
+	mux.HandleFunc("/api/heatmap", s.handleHeatmap)
+
+	// Session markers
+	mux.HandleFunc("/api/markers", s.handleMarkers)
+
+	// Freeze-frame of the input display
+	mux.HandleFunc("/api/freeze", s.handleFreeze)
+
+	// Prometheus metrics
+	mux.HandleFunc("/metrics", s.handleMetrics)
+
+	// Admin API (loopback clients only)
+	mux.HandleFunc("/api/admin/audit", adminOnly(s.handleAudit))
+	mux.HandleFunc("/api/admin/listeners", adminOnly(s.handleListeners))
+	mux.HandleFunc("/api/admin/tokens", adminOnly(s.handleTokens))
+	mux.HandleFunc("/api/admin/tokens/", adminOnly(s.handleToken))
+
+	// Debug-only state injection (overlay development, CI)
+	if s.injectEnabled {
+		slog.Warn("state injection API enabled", "endpoint", "POST /api/inject")
+		mux.HandleFunc("/api/inject", s.handleInject)
+	}
+
+	// Profiling (loopback clients only)
+	if s.debug {
+		slog.Warn("profiling endpoints enabled", "endpoint", "/debug/pprof/")
+		mountPprof(mux)
+	}
+
+	if s.headless {
+		// Data-only mode: no frontend; unknown paths get a JSON 404.
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusNotFound, "not found (headless mode: only /ws, /health, /metrics, and /api/* are served)")
+		})
+		return mux
+	}
+
 	// External overlays directory (next to the executable): /overlays/
 	// This takes priority over the embedded overlays so users can override or add configs.
 	overlaysDir := filepath.Join(s.exeDir, s.overlayDir)
@@ -103,21 +234,86 @@ func (s *Server) ListenAndServe() error {
 	// Static files (frontend) with gzip-aware serving.
 	mux.Handle("/", newGzipFileServer(s.frontendFS, s.gzipCache))
 
-	s.httpServer = &http.Server{
-		Addr:    s.addr,
-		Handler: loggingMiddleware(mux),
+	return mux
+}
+
+// listenerAddrs returns the /api/admin/listeners map: the main address under
+// "addr", the others under their names.
+func (s *Server) listenerAddrs() map[string]string {
+	m := map[string]string{"addr": s.addr}
+	for _, l := range s.extraListeners {
+		m[l.Name] = l.Addr
+	}
+	return m
+}
+
+// wrap applies the middlewares around the handler tree: trusted proxy
+// resolution outermost, so everything after it sees the real client, then
+// request logging, so rejected requests are logged too, then viewer token
+// admission, then the loopback/remote access check, then l's basic auth.
+func (s *Server) wrap(h http.Handler, l Listener) http.Handler {
+	if l.AuthPassword != "" {
+		slog.Info("HTTP basic auth enabled", "listener", l.Name, "user", l.AuthUser)
+		h = basicAuthMiddleware(h, l.AuthUser, l.AuthPassword)
+	}
+	h = loggingMiddleware(s.tokenMiddleware(s.accessMiddleware(h)))
+	if len(s.trustedProxies) > 0 {
+		slog.Info("trusting reverse proxy headers", "proxies", s.trustedProxies)
+		h = proxyMiddleware(h, s.trustedProxies)
+	}
+	return h
+}
+
+// ListenAndServe binds every listener (the main address and those added with
+// AddListener) and serves them until Shutdown. It returns the first error: a
+// listener that cannot be bound (nothing is served then), or one that fails
+// while serving (the others keep running until Shutdown).
+func (s *Server) ListenAndServe() error {
+	h := s.routes()
+	var servers []*http.Server
+	var lns []net.Listener
+	for _, l := range s.listeners() {
+		srv, ln, err := s.listen(l, h)
+		if err != nil {
+			for _, ln := range lns {
+				ln.Close()
+			}
+			return err
+		}
+		servers, lns = append(servers, srv), append(lns, ln)
 	}
+	s.serversMu.Lock()
+	s.httpServers = servers
+	s.serversMu.Unlock()
 
-	slog.Info("HTTP server listening", "addr", s.addr)
-	return s.httpServer.ListenAndServe()
+	errCh := make(chan error, len(servers))
+	for i, srv := range servers {
+		go func() { errCh <- serve(srv, lns[i]) }()
+	}
+	for range servers {
+		if err := <-errCh; err != http.ErrServerClosed {
+			return err
+		}
+	}
+	return http.ErrServerClosed
 }
 
+// Shutdown gracefully stops every listener, returning the first error.
 func (s *Server) Shutdown(ctx context.Context) error {
-	if s.httpServer != nil {
-		slog.Info("shutting down HTTP server")
-		return s.httpServer.Shutdown(ctx)
+	s.serversMu.Lock()
+	servers := s.httpServers
+	s.serversMu.Unlock()
+	if len(servers) == 0 {
+		return nil
+	}
+	slog.Info("shutting down HTTP server", "listeners", len(servers))
+	var first error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil && first == nil {
+			first = err
+		}
 	}
-	return nil
+	return first
 }
 
 // newGzipFileServer returns an http.Handler that serves files from fsys.
@@ -186,11 +382,12 @@ func acceptsGzip(r *http.Request) bool {
 	return false
 }
 
-// loggingMiddleware logs HTTP requests to stderr via slog, excluding /ws and /health paths.
+// loggingMiddleware logs HTTP requests to stderr via slog, excluding the /ws,
+// /health, and /metrics paths (polled by monitors).
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip logging for /ws and /health
-		if r.URL.Path == "/ws" || r.URL.Path == "/health" {
+		// Skip logging for /ws, /health, and /metrics
+		if r.URL.Path == "/ws" || r.URL.Path == "/health" || r.URL.Path == "/metrics" {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -203,6 +400,7 @@ func loggingMiddleware(next http.Handler) http.Handler {
 			"status", rw.status,
 			"duration", time.Since(start).String(),
 			"ip", r.RemoteAddr,
+			"proto", requestScheme(r),
 		)
 	})
 }
@@ -0,0 +1,526 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lxzan/gws"
+	"github.com/soar/inputview/internal/audit"
+)
+
+// TestHeadlessMode verifies that headless mode drops the frontend but keeps
+// the data endpoints.
+func TestHeadlessMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		headless bool
+		path     string
+		want     int
+	}{
+		{"frontend served normally", false, "/", http.StatusOK},
+		{"frontend hidden when headless", true, "/", http.StatusNotFound},
+		{"static file hidden when headless", true, "/index.html", http.StatusNotFound},
+		{"health kept when headless", true, "/health", http.StatusOK},
+		{"metrics kept when headless", true, "/metrics", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+			srv.SetHeadless(tt.headless)
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestHealthReportsHeadless verifies the /health "headless" field.
+func TestHealthReportsHeadless(t *testing.T) {
+	srv, _ := newTestServer(t)
+	srv.SetHeadless(true)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var resp HealthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode /health: %v", err)
+	}
+	if !resp.Headless {
+		t.Errorf("/health headless = false, want true")
+	}
+}
+
+// TestBasicAuth verifies that basic auth guards everything but /health.
+func TestBasicAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string // configured
+		path     string
+		authUser string // sent; "" with authPass "" = no Authorization header
+		authPass string
+		want     int
+	}{
+		{"no credentials", "viewer", "/", "", "", http.StatusUnauthorized},
+		{"wrong password", "viewer", "/", "viewer", "nope", http.StatusUnauthorized},
+		{"wrong user", "viewer", "/", "admin", "secret", http.StatusUnauthorized},
+		{"correct", "viewer", "/", "viewer", "secret", http.StatusOK},
+		{"any user accepted", "", "/", "whoever", "secret", http.StatusOK},
+		{"ws guarded", "viewer", "/ws", "", "", http.StatusUnauthorized},
+		{"api guarded", "viewer", "/api/controllers", "", "", http.StatusUnauthorized},
+		{"health open", "viewer", "/health", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+			srv.SetBasicAuth(tt.user, "secret")
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authUser != "" || tt.authPass != "" {
+				req.SetBasicAuth(tt.authUser, tt.authPass)
+			}
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.want)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("401 without a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+// TestAllowlist verifies the loopback-only default, the remote opt-in, and the
+// client IP allowlist, including IPv4-mapped peers.
+func TestAllowlist(t *testing.T) {
+	nets := []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24"), netip.MustParsePrefix("fd00::/8")}
+	tests := []struct {
+		name        string
+		allowRemote bool
+		nets        []netip.Prefix
+		remote      string
+		want        int
+	}{
+		{"remote rejected by default", false, nil, "203.0.113.9:5000", http.StatusForbidden},
+		{"listed network still needs opt-in", false, nets, "192.168.1.20:5000", http.StatusForbidden},
+		{"loopback served by default", false, nil, "127.0.0.1:5000", http.StatusOK},
+		{"no allowlist", true, nil, "203.0.113.9:5000", http.StatusOK},
+		{"in network", true, nets, "192.168.1.20:5000", http.StatusOK},
+		{"outside network", true, nets, "192.168.2.20:5000", http.StatusForbidden},
+		{"ipv6 in network", true, nets, "[fd12::1]:5000", http.StatusOK},
+		{"ipv4-mapped in network", true, nets, "[::ffff:192.168.1.20]:5000", http.StatusOK},
+		{"loopback always allowed", true, nets, "127.0.0.1:5000", http.StatusOK},
+		{"ipv6 loopback always allowed", true, nets, "[::1]:5000", http.StatusOK},
+		{"unparsable peer", true, nets, "pipe", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+			srv.SetRemoteAllowed(tt.allowRemote)
+			srv.SetAllowedNets(tt.nets)
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			req.RemoteAddr = tt.remote
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET /health from %s = %d, want %d", tt.remote, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestTrustedProxies verifies that X-Forwarded-For is honored only from a
+// trusted proxy, and that the forwarded client is then subject to the remote
+// access check.
+func TestTrustedProxies(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32"), netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name string
+		peer string
+		xff  []string
+		want int // with remote access off
+	}{
+		{"spoof from untrusted remote peer", "192.0.2.1:4000", []string{"127.0.0.1"}, http.StatusForbidden},
+		{"proxy without header", "127.0.0.1:4000", nil, http.StatusOK},
+		{"proxied loopback client", "127.0.0.1:4000", []string{"127.0.0.1"}, http.StatusOK},
+		{"proxied remote client", "127.0.0.1:4000", []string{"203.0.113.9"}, http.StatusForbidden},
+		{"proxy chain", "127.0.0.1:4000", []string{"203.0.113.9, 10.1.2.3"}, http.StatusForbidden},
+		{"spoofed leftmost entry", "127.0.0.1:4000", []string{"127.0.0.1, 203.0.113.9"}, http.StatusForbidden},
+		{"multiple header lines", "127.0.0.1:4000", []string{"203.0.113.9", "10.1.2.3"}, http.StatusForbidden},
+		{"only trusted hops", "127.0.0.1:4000", []string{"10.1.2.3, 127.0.0.1"}, http.StatusForbidden},
+		{"garbage entry", "127.0.0.1:4000", []string{"unknown"}, http.StatusOK}, // falls back to the peer
+		{"untrusted peer ignored", "[::1]:4000", []string{"203.0.113.9"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+			srv.SetRemoteAllowed(false)
+			srv.SetTrustedProxies(proxies)
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			req.RemoteAddr = tt.peer
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET /health via %s, X-Forwarded-For %q = %d, want %d", tt.peer, tt.xff, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestRequestScheme verifies X-Forwarded-Proto handling.
+func TestRequestScheme(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}
+	tests := []struct {
+		peer, proto, want string
+	}{
+		{"127.0.0.1:4000", "HTTPS", "https"},
+		{"127.0.0.1:4000", "", "http"},
+		{"127.0.0.1:4000", "gopher", "http"},
+		{"192.0.2.1:4000", "https", "http"}, // untrusted peer
+	}
+	for _, tt := range tests {
+		var got string
+		h := proxyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = requestScheme(r)
+		}), trusted)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.peer
+		if tt.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", tt.proto)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if got != tt.want {
+			t.Errorf("peer %s, X-Forwarded-Proto %q: scheme %q, want %q", tt.peer, tt.proto, got, tt.want)
+		}
+	}
+}
+
+// TestAdminAudit verifies that the admin API serves the audit log to
+// loopback clients only, and applies the query filters.
+func TestAdminAudit(t *testing.T) {
+	srv, _ := newTestServer(t)
+	l, _ := audit.New("")
+	l.Record(audit.Entry{Time: 10, Action: audit.ActionSelectPlayer, Source: audit.SourceWebSocket, Client: "192.0.2.1:5000"})
+	l.Record(audit.Entry{Time: 20, Action: audit.ActionSetMouseSens, Source: audit.SourceWebSocket, Client: "192.0.2.1:5000"})
+	l.Record(audit.Entry{Time: 30, Action: audit.ActionSelectPlayer, Source: audit.SourceWebSocket, Client: "192.0.2.2:5000"})
+	srv.SetAuditLog(l)
+	handler := srv.Handler()
+
+	tests := []struct {
+		remote, query string
+		want          int
+		times         []int64
+	}{
+		{"192.0.2.1:5000", "", http.StatusForbidden, nil}, // remote access is on, but not for the admin API
+		{"127.0.0.1:5000", "", http.StatusOK, []int64{10, 20, 30}},
+		{"[::1]:5000", "?action=select_player&limit=1", http.StatusOK, []int64{30}},
+		{"127.0.0.1:5000", "?since=15&client=192.0.2.1:5000", http.StatusOK, []int64{20}},
+		{"127.0.0.1:5000", "?since=15&action=capture_start", http.StatusOK, []int64{}},
+		{"127.0.0.1:5000", "?limit=0", http.StatusBadRequest, nil},
+		{"127.0.0.1:5000", "?since=yesterday", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit"+tt.query, nil)
+		req.RemoteAddr = tt.remote
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("GET %s from %s = %d, want %d", tt.query, tt.remote, rec.Code, tt.want)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var resp AuditResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		times := []int64{}
+		for _, e := range resp.Entries {
+			times = append(times, e.Time)
+		}
+		if !slices.Equal(times, tt.times) {
+			t.Errorf("GET %s: entry times %v, want %v", tt.query, times, tt.times)
+		}
+	}
+}
+
+// TestDebugPprof verifies that /debug/pprof/ is only mounted with SetDebug,
+// and then only for loopback clients.
+func TestDebugPprof(t *testing.T) {
+	tests := []struct {
+		name   string
+		debug  bool
+		path   string
+		remote string
+		want   int
+	}{
+		{"not mounted by default", false, "/debug/pprof/", "127.0.0.1:5000", http.StatusNotFound},
+		{"index", true, "/debug/pprof/", "127.0.0.1:5000", http.StatusOK},
+		{"heap profile", true, "/debug/pprof/heap", "[::1]:5000", http.StatusOK},
+		{"cmdline", true, "/debug/pprof/cmdline", "127.0.0.1:5000", http.StatusOK},
+		{"remote client", true, "/debug/pprof/", "192.0.2.1:5000", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+			srv.SetDebug(tt.debug)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remote
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET %s from %s = %d, want %d", tt.path, tt.remote, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestViewerTokens verifies that a viewer token admits a remote client past
+// the loopback-only default and basic auth, via ?token= and then its cookie,
+// for reading only, until it is revoked or expires.
+func TestViewerTokens(t *testing.T) {
+	srv, _ := newTestServer(t)
+	srv.SetRemoteAllowed(false)
+	srv.SetBasicAuth("", "secret")
+	handler := srv.Handler()
+	const guest, admin = "203.0.113.9:5000", "127.0.0.1:5000"
+
+	do := func(method, target, remote, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = remote
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		if remote == admin {
+			req.SetBasicAuth("", "secret") // the admin is on this PC but still needs the password
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/admin/tokens", guest, `{}`, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST /api/admin/tokens from remote = %d, want 403", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/admin/tokens", admin, `{"ttlSeconds":-1}`, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST with ttlSeconds -1 = %d, want 400", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/admin/tokens", admin, `{"label":"guest"}`, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /api/admin/tokens = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var tok ViewerToken
+	if err := json.Unmarshal(rec.Body.Bytes(), &tok); err != nil {
+		t.Fatal(err)
+	}
+	if tok.Token == "" || tok.Label != "guest" || tok.Expires-tok.Created != defaultTokenTTL.Milliseconds() {
+		t.Fatalf("created token = %+v, want a secret, label guest, and a 4h lifetime", tok)
+	}
+	if list := srv.tokens.list(); len(list) != 1 || list[0].ID != tok.ID || list[0].Token != "" {
+		t.Fatalf("token list = %+v, want the new token without its secret", list)
+	}
+
+	if rec := do(http.MethodGet, "/", guest, "", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("GET / without token = %d, want 403", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/?token=bogus", guest, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /?token=bogus = %d, want 401", rec.Code)
+	}
+	rec = do(http.MethodGet, "/?token="+tok.Token, guest, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /?token= = %d, want 200", rec.Code)
+	}
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == tokenCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != tok.Token || !cookie.HttpOnly {
+		t.Fatalf("GET /?token= set cookie %+v, want an HttpOnly %s with the secret", cookie, tokenCookie)
+	}
+	if rec := do(http.MethodGet, "/health", guest, "", cookie); rec.Code != http.StatusOK {
+		t.Errorf("GET /health with token cookie = %d, want 200", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/admin/tokens", guest, "", cookie); rec.Code != http.StatusForbidden {
+		t.Errorf("GET /api/admin/tokens with token cookie = %d, want 403 (tokens are not admin)", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/state", guest, "", cookie); rec.Code != http.StatusOK {
+		t.Errorf("GET /api/state with token cookie = %d, want 200", rec.Code)
+	}
+	for _, target := range []string{"/api/markers", "/api/freeze"} {
+		if rec := do(http.MethodPost, target, guest, `{}`, cookie); rec.Code != http.StatusForbidden {
+			t.Errorf("POST %s with token cookie = %d, want 403 (tokens are read-only)", target, rec.Code)
+		}
+	}
+
+	if rec := do(http.MethodDelete, "/api/admin/tokens/"+tok.ID, admin, "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /api/admin/tokens/{id} = %d, want 204", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/health", guest, "", cookie); rec.Code != http.StatusForbidden {
+		t.Errorf("GET /health with revoked token cookie = %d, want 403", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/admin/tokens/"+tok.ID, admin, "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE of a revoked token = %d, want 404", rec.Code)
+	}
+
+	// An expired token is rejected even before its timer has removed it.
+	exp := srv.createToken("", time.Hour)
+	srv.tokens.mu.Lock()
+	for _, st := range srv.tokens.tokens {
+		st.Expires = time.Now().Add(-time.Second).UnixMilli()
+	}
+	srv.tokens.mu.Unlock()
+	if rec := do(http.MethodGet, "/?token="+exp.Token, guest, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /?token= with expired token = %d, want 401", rec.Code)
+	}
+	srv.revokeToken(exp.ID)
+}
+
+// opcodeClient records the opcode and payload of the first message it gets.
+type opcodeClient struct {
+	gws.BuiltinEventHandler
+	first chan *gws.Message
+}
+
+func (c *opcodeClient) OnMessage(socket *gws.Conn, message *gws.Message) {
+	select {
+	case c.first <- message:
+	default:
+		message.Close()
+	}
+}
+
+// TestWebSocketEncoding verifies that ?encoding=msgpack and the msgpack
+// subprotocol switch a client to MessagePack binary frames, that JSON text
+// frames stay the default, and that unknown encodings are rejected.
+func TestWebSocketEncoding(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	for _, tt := range []struct {
+		name, query, subprotocol string
+		want                     gws.Opcode
+	}{
+		{"default", "", "", gws.OpcodeText},
+		{"query", "?encoding=msgpack", "", gws.OpcodeBinary},
+		{"explicit json", "?encoding=json", "", gws.OpcodeText},
+		{"subprotocol", "", "msgpack", gws.OpcodeBinary},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &opcodeClient{first: make(chan *gws.Message, 1)}
+			opt := &gws.ClientOption{Addr: wsURL + tt.query}
+			if tt.subprotocol != "" {
+				opt.RequestHeader = http.Header{"Sec-WebSocket-Protocol": {tt.subprotocol}}
+			}
+			conn, _, err := gws.NewClient(c, opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.WriteClose(1000, nil)
+			go conn.ReadLoop()
+
+			select {
+			case msg := <-c.first:
+				defer msg.Close()
+				if msg.Opcode != tt.want {
+					t.Fatalf("opcode = %v, want %v", msg.Opcode, tt.want)
+				}
+				// The initial full: a map whose first key is "type".
+				if tt.want == gws.OpcodeBinary && !bytes.HasPrefix(msg.Bytes()[1:], []byte("\xa4type\xa4full")) {
+					t.Errorf("binary message = % x, want a MessagePack map starting with type: full", msg.Bytes()[:min(12, len(msg.Bytes()))])
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatal("timed out waiting for the initial state")
+			}
+		})
+	}
+
+	resp, err := http.Get(ts.URL + "/ws?encoding=cbor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /ws?encoding=cbor = %d, want 400", resp.StatusCode)
+	}
+}
+
+// TestWebSocketRate verifies the ?rate=, ?analog=, and ?fields= checks of the
+// WebSocket upgrade.
+func TestWebSocketRate(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	for _, query := range []string{"rate=abc", "rate=-1", "rate=1001", "rate=NaN", "analog=fast", "fields=buttons,keys"} {
+		resp, err := http.Get(ts.URL + "/ws?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("GET /ws?%s = %d, want 400", query, resp.StatusCode)
+		}
+	}
+	conn, _, err := gws.NewClient(&opcodeClient{first: make(chan *gws.Message, 1)}, &gws.ClientOption{Addr: "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws?rate=30&analog=split&fields=buttons,sticks"})
+	if err != nil {
+		t.Fatalf("upgrade with ?rate=30&analog=split&fields=buttons,sticks: %v", err)
+	}
+	conn.WriteClose(1000, nil)
+}
+
+// TestWebSocketOrigin verifies that /ws refuses a browser handshake from a
+// page of another site, and checks the Origin against X-Forwarded-Host only
+// behind a trusted proxy.
+func TestWebSocketOrigin(t *testing.T) {
+	srv, _ := newTestServer(t)
+	srv.SetTrustedProxies([]netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	for _, tt := range []struct {
+		name    string
+		header  http.Header
+		allowed bool
+	}{
+		{"no origin", nil, true},
+		{"same origin", http.Header{"Origin": {"http://" + host}}, true},
+		{"same origin, other case", http.Header{"Origin": {"http://" + strings.ToUpper(host)}}, true},
+		{"other site", http.Header{"Origin": {"https://evil.example"}}, false},
+		{"other port", http.Header{"Origin": {"http://127.0.0.1:1"}}, false},
+		{"opaque origin", http.Header{"Origin": {"null"}}, false},
+		{"behind proxy", http.Header{"Origin": {"https://overlay.example"}, "X-Forwarded-Host": {"overlay.example"}}, true},
+		{"behind proxy, other site", http.Header{"Origin": {"http://" + host}, "X-Forwarded-Host": {"overlay.example"}}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp, err := gws.NewClient(&opcodeClient{first: make(chan *gws.Message, 1)},
+				&gws.ClientOption{Addr: "ws://" + host + "/ws", RequestHeader: tt.header})
+			if err == nil {
+				conn.WriteClose(1000, nil)
+			}
+			if tt.allowed && err != nil {
+				t.Fatalf("upgrade with %v: %v", tt.header, err)
+			}
+			if !tt.allowed && (err == nil || resp == nil || resp.StatusCode != http.StatusForbidden) {
+				t.Fatalf("upgrade with %v: err %v, want 403", tt.header, err)
+			}
+		})
+	}
+}
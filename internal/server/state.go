@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// StateResponse is the body of GET /api/state.
+type StateResponse struct {
+	States []gamepad.GamepadState `json:"states"` // one per stream: the active controller, or every player with --all-players
+}
+
+// handleState serves GET /api/state: the latest gamepad states as clients are
+// shown them (held while frozen), for scripts and tools that poll instead of
+// speaking the WebSocket protocol. ?player=N returns the one GamepadState the
+// viewers of player N were last sent; connected is false when no stream has
+// that index.
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if p := r.URL.Query().Get("player"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			writeAPIError(w, http.StatusBadRequest, "player must be an integer >= 1")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.broadcaster.ShownState(n))
+		return
+	}
+	writeJSON(w, http.StatusOK, StateResponse{States: s.broadcaster.ShownStates()})
+}
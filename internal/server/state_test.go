@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestState verifies GET /api/state: the latest state once the broadcaster
+// has it, the per-player variant, and invalid players and methods.
+func TestState(t *testing.T) {
+	srv, reader := newTestServer(t)
+	go srv.broadcaster.Run()
+	handler := srv.Handler()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	reader.Inject(gamepad.GamepadState{Connected: true, Name: "Scripted", PlayerIndex: 1, Buttons: gamepad.ButtonState{A: true}})
+	var resp StateResponse
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		rec := get("/api/state")
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("GET /api/state = %d %s", rec.Code, rec.Body)
+		}
+		if len(resp.States) == 1 && resp.States[0].Buttons.A {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GET /api/state = %+v, want the injected state", resp)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var s gamepad.GamepadState
+	if rec := get("/api/state?player=1"); json.Unmarshal(rec.Body.Bytes(), &s) != nil || s.Name != "Scripted" {
+		t.Errorf("GET ?player=1 = %s, want the injected state", rec.Body)
+	}
+	if rec := get("/api/state?player=2"); json.Unmarshal(rec.Body.Bytes(), &s) != nil || s.Connected || s.PlayerIndex != 2 {
+		t.Errorf("GET ?player=2 = %s, want a disconnected state of player 2", rec.Body)
+	}
+	if rec := get("/api/state?player=0"); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET ?player=0 = %d, want 400", rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/state", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/state = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/soar/inputview/internal/hub"
+)
+
+// StatsResponse is the body of GET /api/stats.
+type StatsResponse struct {
+	Players []hub.SessionStats `json:"players"` // every player seen so far, by player index; empty before any input
+}
+
+// handleStats serves GET /api/stats: each player's session statistics (press
+// counts, actions per minute, stick travel) since the server started, for
+// end-of-stream summaries. ?player=N returns player N's SessionStats alone,
+// all zero when no state has been seen for it.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if p := r.URL.Query().Get("player"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			writeAPIError(w, http.StatusBadRequest, "player must be an integer >= 1")
+			return
+		}
+		stats := s.broadcaster.SessionStats(n)
+		if stats == nil {
+			stats = &hub.SessionStats{PlayerIndex: n}
+		}
+		writeJSON(w, http.StatusOK, stats)
+		return
+	}
+	writeJSON(w, http.StatusOK, StatsResponse{Players: s.broadcaster.AllSessionStats()})
+}
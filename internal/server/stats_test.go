@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// TestStats verifies GET /api/stats: the injected presses once the
+// broadcaster has them, the per-player variant, and invalid players and
+// methods.
+func TestStats(t *testing.T) {
+	srv, reader := newTestServer(t)
+	go srv.broadcaster.Run()
+	handler := srv.Handler()
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	reader.Inject(gamepad.GamepadState{Connected: true, Name: "Scripted", PlayerIndex: 1, Buttons: gamepad.ButtonState{A: true}})
+	var resp StatsResponse
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		rec := get("/api/stats")
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("GET /api/stats = %d %s", rec.Code, rec.Body)
+		}
+		if len(resp.Players) == 1 && resp.Players[0].TotalPresses == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GET /api/stats = %+v, want player 1 with the injected press", resp)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var s hub.SessionStats
+	if rec := get("/api/stats?player=1"); json.Unmarshal(rec.Body.Bytes(), &s) != nil || s.Presses.Buttons.A != 1 || s.APM != 1 {
+		t.Errorf("GET ?player=1 = %s, want one A press in the last minute", rec.Body)
+	}
+	if rec := get("/api/stats?player=2"); json.Unmarshal(rec.Body.Bytes(), &s) != nil || s.PlayerIndex != 2 || s.TotalPresses != 0 {
+		t.Errorf("GET ?player=2 = %s, want empty stats of player 2", rec.Body)
+	}
+	if rec := get("/api/stats?player=x"); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET ?player=x = %d, want 400", rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/stats", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/stats = %d, want 405", rec.Code)
+	}
+}
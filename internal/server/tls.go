@@ -0,0 +1,100 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval is how often, at most, the certificate files are checked
+// for changes. The check runs during a TLS handshake, so an idle server does
+// no work.
+const certCheckInterval = 10 * time.Second
+
+// SetTLS serves HTTPS (and wss:// for /ws) with the PEM certificate and key in
+// certFile and keyFile on the main listener (AddListener sets it for the
+// others). Empty paths (the default) serve plain HTTP. The files
+// are reloaded when they change (see certReloader), so a renewed certificate
+// takes effect without a restart. Must be called before ListenAndServe.
+func (s *Server) SetTLS(certFile, keyFile string) {
+	s.tlsCert = certFile
+	s.tlsKey = keyFile
+}
+
+// certReloader serves a certificate loaded from files and reloads it when
+// either file's modification time has changed, checked at most every
+// certCheckInterval. Established connections keep running on the certificate
+// they were handshaked with.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex // protects the fields below
+	cert    *tls.Certificate
+	certMod time.Time // modification times of the loaded files
+	keyMod  time.Time
+	checked time.Time // last change check
+}
+
+// newCertReloader loads the certificate, returning an error if the files
+// cannot be read or do not form a valid pair.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, checked: time.Now()}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads the certificate pair. Caller must hold r.mu (or own r).
+func (r *certReloader) load() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+// modTimes returns the modification times of the certificate and key files.
+func (r *certReloader) modTimes() (certMod, keyMod time.Time, err error) {
+	ci, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	ki, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return ci.ModTime(), ki.ModTime(), nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. When a file changed,
+// the pair is reloaded; if that fails (e.g. the renewal has written the new
+// certificate but not yet the key), the current certificate is kept and the
+// reload is retried at the next check.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := time.Now(); now.Sub(r.checked) >= certCheckInterval {
+		r.checked = now
+		certMod, keyMod, err := r.modTimes()
+		if err == nil && (!certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod)) {
+			err = r.load()
+			if err == nil {
+				slog.Info("TLS certificate reloaded", "cert", r.certFile)
+			}
+		}
+		if err != nil {
+			slog.Warn("TLS certificate reload failed, keeping the current certificate", "cert", r.certFile, "error", err)
+		}
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soar/inputview/internal/hub"
+)
+
+// tokenCookie holds a viewer token after the first request with ?token=, so
+// the frontend's assets and /ws are admitted without changes to it.
+const tokenCookie = "inputview_token"
+
+// Viewer token lifetimes.
+const (
+	defaultTokenTTL = 4 * time.Hour
+	maxTokenTTL     = 30 * 24 * time.Hour
+)
+
+// ViewerToken describes a time-limited viewer token. Token, the secret, is
+// only set in the response that creates it.
+type ViewerToken struct {
+	ID      string `json:"id"`
+	Label   string `json:"label,omitempty"`
+	Created int64  `json:"created"` // Unix ms
+	Expires int64  `json:"expires"` // Unix ms
+	Token   string `json:"token,omitempty"`
+}
+
+// tokenKey is the request context key under which tokenMiddleware stores the
+// ID of the viewer token a request was admitted with.
+type tokenKey struct{}
+
+// tokenStore holds the live viewer tokens by the SHA-256 digest of their
+// secret, so a lookup's timing reveals nothing about the secrets. Tokens are
+// kept in memory only: a restart revokes them all.
+type tokenStore struct {
+	mu     sync.Mutex
+	tokens map[[sha256.Size]byte]*storedToken
+}
+
+type storedToken struct {
+	ViewerToken
+	timer *time.Timer // drops the token at Expires
+}
+
+func newTokenStore() *tokenStore {
+	return &tokenStore{tokens: make(map[[sha256.Size]byte]*storedToken)}
+}
+
+// add creates a token valid for ttl and calls expire with its ID when it
+// runs out (unless removed first).
+func (ts *tokenStore) add(label string, ttl time.Duration, expire func(id string)) ViewerToken {
+	var secret [32]byte
+	rand.Read(secret[:])
+	var id [6]byte
+	rand.Read(id[:])
+	now := time.Now()
+	t := ViewerToken{
+		ID:      hex.EncodeToString(id[:]),
+		Label:   label,
+		Created: now.UnixMilli(),
+		Expires: now.Add(ttl).UnixMilli(),
+		Token:   base64.RawURLEncoding.EncodeToString(secret[:]),
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	st := &storedToken{ViewerToken: t}
+	st.Token = ""
+	st.timer = time.AfterFunc(ttl, func() { expire(t.ID) })
+	ts.tokens[sha256.Sum256([]byte(t.Token))] = st
+	return t
+}
+
+// lookup returns the unexpired token with the given secret.
+func (ts *tokenStore) lookup(secret string) (ViewerToken, bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	st, ok := ts.tokens[sha256.Sum256([]byte(secret))]
+	if !ok || time.Now().UnixMilli() >= st.Expires {
+		return ViewerToken{}, false
+	}
+	return st.ViewerToken, true
+}
+
+// remove deletes the token with the given ID and reports whether it existed.
+func (ts *tokenStore) remove(id string) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for k, st := range ts.tokens {
+		if st.ID == id {
+			st.timer.Stop()
+			delete(ts.tokens, k)
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the live tokens, oldest first, without secrets.
+func (ts *tokenStore) list() []ViewerToken {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	out := make([]ViewerToken, 0, len(ts.tokens))
+	for _, st := range ts.tokens {
+		out = append(out, st.ViewerToken)
+	}
+	slices.SortFunc(out, func(a, b ViewerToken) int { return cmp.Compare(a.Created, b.Created) })
+	return out
+}
+
+// createToken issues a viewer token valid for ttl. When it expires, it is
+// dropped and the WebSocket clients admitted with it are disconnected.
+func (s *Server) createToken(label string, ttl time.Duration) ViewerToken {
+	t := s.tokens.add(label, ttl, func(id string) {
+		if s.tokens.remove(id) {
+			n := s.closeTokenClients(id)
+			slog.Info("viewer token expired", "id", id, "closed", n)
+		}
+	})
+	slog.Info("viewer token created", "id", t.ID, "label", label, "expires", time.UnixMilli(t.Expires).Format(time.RFC3339))
+	return t
+}
+
+// revokeToken drops a viewer token before it expires and disconnects the
+// WebSocket clients admitted with it. It reports whether the token existed.
+func (s *Server) revokeToken(id string) bool {
+	if !s.tokens.remove(id) {
+		return false
+	}
+	n := s.closeTokenClients(id)
+	slog.Info("viewer token revoked", "id", id, "closed", n)
+	return true
+}
+
+// closeTokenClients disconnects the WebSocket clients admitted with token id.
+func (s *Server) closeTokenClients(id string) int {
+	return s.hub.CloseClients(func(c *hub.Client) bool { return c.ViewerToken() == id })
+}
+
+// tokenMiddleware admits requests that carry a viewer token, as ?token= or in
+// the tokenCookie, by recording its ID in the request context: accessMiddleware
+// then treats the client as remote-allowed and basicAuthMiddleware lets it
+// through. Tokens are for viewing: only GET and HEAD (the frontend, /ws, and
+// the read-only API) are admitted, anything else is answered with 403. A
+// valid ?token= also sets the cookie, which expires with the token. An
+// invalid ?token= is answered with 401; a stale cookie is cleared and
+// ignored.
+func (s *Server) tokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, fromQuery := r.URL.Query().Get("token"), true
+		if secret == "" {
+			fromQuery = false
+			if c, err := r.Cookie(tokenCookie); err == nil {
+				secret = c.Value
+			}
+		}
+		if secret == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		t, ok := s.tokens.lookup(secret)
+		switch {
+		case !ok && fromQuery:
+			http.Error(w, "invalid or expired token", http.StatusUnauthorized)
+			return
+		case !ok:
+			http.SetCookie(w, &http.Cookie{Name: tokenCookie, Path: "/", MaxAge: -1})
+			next.ServeHTTP(w, r)
+			return
+		case r.Method != http.MethodGet && r.Method != http.MethodHead:
+			http.Error(w, "viewer tokens are read-only", http.StatusForbidden)
+			return
+		case fromQuery:
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    secret,
+				Path:     "/",
+				Expires:  time.UnixMilli(t.Expires),
+				HttpOnly: true,
+				Secure:   requestScheme(r) == "https",
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, t.ID)))
+	})
+}
+
+// viewerTokenID returns the ID of the viewer token r was admitted with, or "".
+func viewerTokenID(r *http.Request) string {
+	id, _ := r.Context().Value(tokenKey{}).(string)
+	return id
+}
+
+// tokenIDFromPath extracts {id} from /api/admin/tokens/{id}.
+func tokenIDFromPath(p string) string {
+	return strings.Trim(strings.TrimPrefix(p, "/api/admin/tokens"), "/")
+}
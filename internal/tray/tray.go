@@ -40,6 +40,11 @@ type Tray struct {
 	menuCopyDefault *systray.MenuItem
 	copyItems       []overlayMenuItem
 
+	// "Allow Remote Connections" checkbox; nil unless SetRemoteAccess was called
+	menuRemote    *systray.MenuItem
+	remoteAllowed bool
+	setRemote     func(bool)
+
 	menuExit *systray.MenuItem
 }
 
@@ -55,6 +60,14 @@ func New(shutdownFn ShutdownFunc, overlays []overlay.Entry, addr string) *Tray {
 	}
 }
 
+// SetRemoteAccess adds an "Allow Remote Connections" checkbox, initially
+// checked if allowed, that calls set with the new value when clicked.
+// Must be called before Run.
+func (t *Tray) SetRemoteAccess(allowed bool, set func(bool)) {
+	t.remoteAllowed = allowed
+	t.setRemote = set
+}
+
 // Run initializes and runs the system tray (blocks until Quit())
 func (t *Tray) Run(iconData []byte) {
 	runtime.LockOSThread()
@@ -91,6 +104,11 @@ func (t *Tray) onReady(iconData []byte) {
 		t.copyItems = append(t.copyItems, overlayMenuItem{item: sub, urlPath: ov.URLPath})
 	}
 
+	if t.setRemote != nil {
+		t.menuRemote = systray.AddMenuItemCheckbox("Allow Remote Connections",
+			"Serve other machines on the network; they can watch every input", t.remoteAllowed)
+	}
+
 	t.menuExit = systray.AddMenuItem("Exit", "Quit application")
 
 	// Aggregate overlay sub-item clicks into single channels so that the main
@@ -159,6 +177,10 @@ func (t *Tray) onReady(iconData []byte) {
 
 // handleMenuClicks processes menu item clicks without blocking
 func (t *Tray) handleMenuClicks(openURLCh, copyURLCh <-chan string) {
+	var remoteCh chan struct{} // nil (never fires) without the remote item
+	if t.menuRemote != nil {
+		remoteCh = t.menuRemote.ClickedCh
+	}
 	for {
 		select {
 		case <-t.stopCh:
@@ -218,6 +240,25 @@ func (t *Tray) handleMenuClicks(openURLCh, copyURLCh <-chan string) {
 				}()
 			}
 
+		// ── Allow Remote Connections ─────────────────────────────────────────
+		case <-remoteCh:
+			if !t.shuttingDown.Load() {
+				allowed := !t.menuRemote.Checked()
+				if allowed {
+					t.menuRemote.Check()
+				} else {
+					t.menuRemote.Uncheck()
+				}
+				go func() {
+					defer func() {
+						if r := recover(); r != nil {
+							slog.Error("panic in remote access toggle", "panic", r)
+						}
+					}()
+					t.setRemote(allowed)
+				}()
+			}
+
 		// ── Exit ─────────────────────────────────────────────────────────────
 		case <-t.menuExit.ClickedCh:
 			if t.shuttingDown.CompareAndSwap(false, true) {
@@ -0,0 +1,62 @@
+package tsgen
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/server"
+)
+
+// ProtocolFile is the generated declaration file, relative to internal/web.
+const ProtocolFile = "frontend/protocol.d.ts"
+
+// WriteProtocol writes the TypeScript declarations for the WebSocket protocol
+// (WSMessage, ClientMessage and the state types they carry) and the REST
+// payloads (/health, /api/*).
+func WriteProtocol(w io.Writer) error {
+	g := New()
+	g.Alias("ProtocolVersion", fmt.Sprint(hub.ProtocolVersion), "WebSocket protocol version these types describe (hub.ProtocolVersion).")
+	g.Alias("ServerMessageType", `"full" | "delta" | "player_selected" | "km_full" | "km_delta" | "power_changed" | "controller_connected" | "controller_disconnected" | "controller_switched" | "controller_drift" | "profile_selected" | "server_shutdown" | "input_diff" | "ghost_state" | "marker_added" | "time_sync" | "freeze_changed" | "plugin_event" | "analog" | "combo" | "notation" | "stats"`, "Server → client message types.")
+	g.Alias("ClientMessageType", `"select_player" | "select_profile" | "subscribe_km" | "subscribe_ghost" | "subscribe_notation" | "time_sync" | "request_full" | "set_mouse_sens" | "set_rate" | "set_analog" | "set_fields" | "rumble" | "set_led" | "set_raw_mode"`, "Client → server command types.")
+
+	g.Add(hub.WSMessage{})
+	g.Override("WSMessage", "type", "ServerMessageType")
+	g.Add(hub.ClientMessage{})
+	g.Override("ClientMessage", "type", "ClientMessageType")
+	g.Add(server.InjectRequest{})
+	g.Override("InjectRequest", "type", `"full" | "delta"`)
+	g.Add(server.HealthResponse{})
+	g.Add(server.ControllersResponse{})
+	g.Override("ControllersResponse", "controllers", "ControllerInfo[]") // never null
+	g.Add(server.StateResponse{})
+	g.Override("StateResponse", "states", "GamepadState[]") // never null
+	g.Add(server.HistoryResponse{})
+	g.Override("HistoryResponse", "entries", "HistoryEntry[]") // never null
+	g.Add(server.StatsResponse{})
+	g.Override("StatsResponse", "players", "SessionStats[]") // never null
+	g.Add(hub.Heatmaps{})
+	g.Add(server.AuditResponse{})
+	g.Override("AuditResponse", "entries", "Entry[]") // never null (audit.Entry)
+	g.Add(server.ListenersResponse{})
+	g.Add(server.CreateTokenRequest{})
+	g.Add(server.TokensResponse{})
+	g.Override("TokensResponse", "tokens", "ViewerToken[]") // never null
+	g.Add(server.CreateMarkerRequest{})
+	g.Add(server.MarkersResponse{})
+	g.Override("MarkersResponse", "markers", "Marker[]") // never null
+	g.Add(server.FreezeRequest{})
+	g.Add(server.LEDRequest{})
+	g.Add(server.RawModeRequest{})
+	g.Add(server.DeadzoneRequest{})
+	g.Add(server.SDLMappingResponse{})
+	g.Add(server.DriftResponse{})
+	g.Add(server.APIError{})
+
+	if _, err := io.WriteString(w, "// Code generated by internal/tsgen; DO NOT EDIT.\n"+
+		"// InputView WebSocket protocol and REST payload types. Regenerate with: go generate ./internal/web\n\n"); err != nil {
+		return err
+	}
+	_, err := g.WriteTo(w)
+	return err
+}
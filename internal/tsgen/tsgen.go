@@ -0,0 +1,252 @@
+// Package tsgen generates TypeScript type declarations from Go structs by
+// following encoding/json rules (field names from json tags, omitempty and
+// omitzero → optional, nil-able pointers/slices/maps → "| null"), so the .d.ts always
+// describes exactly what json.Marshal produces.
+package tsgen
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// rawMessageType is json.RawMessage: any JSON value, not base64 like []byte.
+var rawMessageType = reflect.TypeFor[json.RawMessage]()
+
+// Generator collects Go types and writes them as TypeScript interfaces.
+// Named struct types referenced by a registered type are emitted too, in
+// order of first use.
+type Generator struct {
+	order     []reflect.Type
+	names     map[reflect.Type]string
+	byName    map[string]reflect.Type
+	overrides map[string]string // "Interface.field" → TypeScript type
+	aliases   []alias
+	err       error
+}
+
+type alias struct {
+	name, tsType, doc string
+}
+
+// New returns an empty Generator.
+func New() *Generator {
+	return &Generator{
+		names:     make(map[reflect.Type]string),
+		byName:    make(map[string]reflect.Type),
+		overrides: make(map[string]string),
+	}
+}
+
+// Add registers the struct type of v (a value or pointer).
+func (g *Generator) Add(v any) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.Name() == "" {
+		g.fail(fmt.Errorf("tsgen: Add(%s): want a named struct type", t))
+		return
+	}
+	g.register(t)
+}
+
+// Override replaces the generated type of field (the JSON name) in interface
+// iface, e.g. to narrow a string discriminator to a union of literals.
+func (g *Generator) Override(iface, field, tsType string) {
+	g.overrides[iface+"."+field] = tsType
+}
+
+// Alias adds "export type name = tsType;" before the interfaces.
+func (g *Generator) Alias(name, tsType, doc string) {
+	g.aliases = append(g.aliases, alias{name, tsType, doc})
+}
+
+func (g *Generator) fail(err error) {
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// register records t and, recursively, the named structs it references.
+func (g *Generator) register(t reflect.Type) {
+	if _, ok := g.names[t]; ok {
+		return
+	}
+	if prev, ok := g.byName[t.Name()]; ok {
+		g.fail(fmt.Errorf("tsgen: %s and %s both map to interface %s", prev, t, t.Name()))
+		return
+	}
+	g.names[t] = t.Name()
+	g.byName[t.Name()] = t
+	g.order = append(g.order, t)
+	for _, f := range jsonFields(t) {
+		g.collect(f.typ)
+	}
+}
+
+// collect registers every named struct reachable from t.
+func (g *Generator) collect(t reflect.Type) {
+	switch {
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+	case t.Kind() == reflect.Pointer, t.Kind() == reflect.Slice, t.Kind() == reflect.Array:
+		g.collect(t.Elem())
+	case t.Kind() == reflect.Map:
+		g.collect(t.Elem())
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		g.register(t)
+	case t.Kind() == reflect.Struct:
+		for _, f := range jsonFields(t) {
+			g.collect(f.typ)
+		}
+	}
+}
+
+// field is one JSON object member of a struct.
+type field struct {
+	name      string
+	typ       reflect.Type
+	omitempty bool // omitempty or omitzero: the member may be absent
+}
+
+// jsonFields lists the members encoding/json emits for struct t, flattening
+// untagged embedded structs.
+func jsonFields(t reflect.Type) []field {
+	var fields []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(ft)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{name: name, typ: sf.Type, omitempty: hasOpt(opts, "omitempty") || hasOpt(opts, "omitzero")})
+	}
+	return fields
+}
+
+// hasOpt reports whether the comma-separated json tag options contain opt.
+func hasOpt(opts, opt string) bool {
+	return strings.Contains(","+opts+",", ","+opt+",")
+}
+
+// tsType returns the TypeScript type of a Go value of type t. nullable
+// reports whether the Go zero value encodes as null.
+func (g *Generator) tsType(t reflect.Type) (ts string, nullable bool) {
+	if t.Implements(textMarshalerType) || (t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(textMarshalerType)) {
+		return "string", t.Kind() == reflect.Pointer
+	}
+	if t == rawMessageType {
+		return "unknown", true
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number", false
+	case reflect.String:
+		return "string", false
+	case reflect.Pointer:
+		elem, _ := g.tsType(t.Elem())
+		return elem, true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string", true // base64
+		}
+		elem, _ := g.tsType(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]", true
+	case reflect.Array:
+		elem, _ := g.tsType(t.Elem())
+		return elem + "[]", false
+	case reflect.Map:
+		elem, _ := g.tsType(t.Elem())
+		return "Record<string, " + elem + ">", true
+	case reflect.Struct:
+		if name, ok := g.names[t]; ok {
+			return name, false
+		}
+		var b strings.Builder
+		b.WriteString("{ ")
+		for _, f := range jsonFields(t) {
+			b.WriteString(g.member(f, ""))
+			b.WriteString(" ")
+		}
+		b.WriteString("}")
+		return b.String(), false
+	case reflect.Interface:
+		return "unknown", true
+	default:
+		g.fail(fmt.Errorf("tsgen: unsupported type %s", t))
+		return "unknown", false
+	}
+}
+
+// member renders one interface member ("name?: type;").
+func (g *Generator) member(f field, iface string) string {
+	ts, nullable := g.tsType(f.typ)
+	if o, ok := g.overrides[iface+"."+f.name]; ok && iface != "" {
+		ts, nullable = o, false
+	}
+	opt := ""
+	switch {
+	case f.omitempty:
+		opt = "?" // zero values (including nil) are omitted
+	case nullable:
+		ts += " | null"
+	}
+	return fmt.Sprintf("%s%s: %s;", f.name, opt, ts)
+}
+
+// WriteTo writes the aliases and interfaces to w.
+func (g *Generator) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	for _, a := range g.aliases {
+		if a.doc != "" {
+			fmt.Fprintf(&b, "/** %s */\n", a.doc)
+		}
+		fmt.Fprintf(&b, "export type %s = %s;\n\n", a.name, a.tsType)
+	}
+	for i, t := range g.order {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "/** Go: %s */\n", t)
+		fmt.Fprintf(&b, "export interface %s {\n", g.names[t])
+		for _, f := range jsonFields(t) {
+			fmt.Fprintf(&b, "  %s\n", g.member(f, g.names[t]))
+		}
+		b.WriteString("}\n")
+	}
+	if g.err != nil {
+		return 0, g.err
+	}
+	n, err := w.Write(b.Bytes())
+	return int64(n), err
+}
@@ -0,0 +1,109 @@
+package tsgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type tsInner struct {
+	N int `json:"n"`
+}
+
+type tsEmbedded struct {
+	E string `json:"e"`
+}
+
+type tsOuter struct {
+	tsEmbedded
+	Name     string           `json:"name"`
+	Opt      *tsInner         `json:"opt,omitempty"`
+	Ptr      *tsInner         `json:"ptr"`
+	List     []tsInner        `json:"list"`
+	Tags     []string         `json:"tags,omitempty"`
+	Counts   map[uint16]bool  `json:"counts"`
+	Raw      []byte           `json:"raw"`
+	Any      json.RawMessage  `json:"any,omitempty"`
+	Inline   struct{ X bool } `json:"inline"`
+	Skipped  string           `json:"-"`
+	Untagged float32
+	hidden   int
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Zero     tsInner           `json:"zero,omitzero"`
+}
+
+// TestGeneratorJSONRules verifies that members follow encoding/json naming,
+// omitempty, embedding, and null rules.
+func TestGeneratorJSONRules(t *testing.T) {
+	g := New()
+	g.Add(&tsOuter{})
+	g.Override("tsOuter", "name", `"a" | "b"`)
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"export interface tsOuter {",
+		"  e: string;\n",
+		`  name: "a" | "b";` + "\n",
+		"  opt?: tsInner;\n",
+		"  ptr: tsInner | null;\n",
+		"  list: tsInner[] | null;\n",
+		"  tags?: string[];\n",
+		"  counts: Record<string, boolean> | null;\n",
+		"  raw: string | null;\n",
+		"  any?: unknown;\n",
+		"  inline: { X: boolean; };\n",
+		"  Untagged: number;\n",
+		"  attrs?: Record<string, string>;\n",
+		"  zero?: tsInner;\n",
+		"export interface tsInner {\n  n: number;\n}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"Skipped", "hidden", "tsEmbedded"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output contains %q:\n%s", unwanted, out)
+		}
+	}
+}
+
+// TestGeneratorNameCollision verifies that two Go types with the same name
+// are reported instead of silently merged.
+func TestGeneratorNameCollision(t *testing.T) {
+	type tsInner struct {
+		Other bool `json:"other"`
+	}
+	type wrapper struct {
+		A tsOuter `json:"a"`
+		B tsInner `json:"b"`
+	}
+	g := New()
+	g.Add(wrapper{})
+	if _, err := g.WriteTo(&bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "both map to interface tsInner") {
+		t.Errorf("WriteTo() error = %v, want name collision", err)
+	}
+}
+
+// TestProtocolUpToDate fails when the checked-in protocol.d.ts no longer
+// matches the Go structs. Fix with: go generate ./internal/web
+func TestProtocolUpToDate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteProtocol(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join("..", "web", filepath.FromSlash(ProtocolFile))
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, buf.Bytes()) {
+		t.Errorf("%s is out of date; run: go generate ./internal/web", path)
+	}
+}
@@ -0,0 +1,138 @@
+// Package udpout pushes the shown gamepad state as compact binary UDP
+// packets to one host:port at a fixed rate (--udp-out), for game engine debug
+// tools, LED rigs, and other consumers without an HTTP or WebSocket stack.
+//
+// Each tick sends one PacketSize-byte packet per shown stream (the active
+// controller's, or every player's with --all-players), little-endian:
+//
+//	offset size  field
+//	0      4     magic "IVUP"
+//	4      1     version (Version)
+//	5      1     player index (1-based; 0 = no controller)
+//	6      1     flags: bit 0 connected
+//	7      1     reserved (0)
+//	8      4     sequence number, uint32, +1 per tick (wraps)
+//	12     4     buttons, uint32, bit i = Buttons[i]
+//	16     8     left X, left Y, right X, right Y: int16, -32767..32767 (signs as in the JSON state)
+//	24     4     left, right trigger: uint16, 0..65535
+//
+// Packets are fire-and-forget: a receiver that misses one gets the whole
+// state again in the next.
+package udpout
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// Version is the packet layout version, at offset 4.
+const Version = 1
+
+// PacketSize is the length of a packet in bytes.
+const PacketSize = 28
+
+// magic starts every packet.
+const magic = "IVUP"
+
+// flagConnected is the flags bit set while the controller is connected.
+const flagConnected = 1 << 0
+
+// Buttons lists the buttons in bit order of the packet's button mask, named
+// as in button combos (see hub.ParseCombo), plus the extra buttons.
+var Buttons = []string{
+	"a", "b", "x", "y", "lb", "rb", "back", "start", "guide", "ls", "rs",
+	"up", "down", "left", "right", "touchpad", "capture",
+	"p1", "p2", "p3", "p4", "fn1", "fn2",
+}
+
+// Encode appends the packet of s with sequence number seq to buf and returns
+// the extended buffer.
+func Encode(buf []byte, seq uint32, s gamepad.GamepadState) []byte {
+	var flags byte
+	if s.Connected {
+		flags |= flagConnected
+	}
+	buf = append(buf, magic...)
+	buf = append(buf, Version, byte(min(max(s.PlayerIndex, 0), math.MaxUint8)), flags, 0)
+	buf = binary.LittleEndian.AppendUint32(buf, seq)
+	buf = binary.LittleEndian.AppendUint32(buf, buttonMask(s))
+	for _, v := range []float64{s.Sticks.Left.Position.X, s.Sticks.Left.Position.Y, s.Sticks.Right.Position.X, s.Sticks.Right.Position.Y} {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(int16(math.Round(min(max(v, -1), 1)*math.MaxInt16))))
+	}
+	for _, v := range []float64{s.Triggers.LT.Value, s.Triggers.RT.Value} {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(math.Round(min(max(v, 0), 1)*math.MaxUint16)))
+	}
+	return buf
+}
+
+// buttonMask returns the pressed buttons of s, bit i for Buttons[i].
+func buttonMask(s gamepad.GamepadState) uint32 {
+	b, e := s.Buttons, s.Extra
+	var mask uint32
+	for i, pressed := range []bool{
+		b.A, b.B, b.X, b.Y, b.LB, b.RB, b.Back, b.Start, b.Guide, s.Sticks.Left.Pressed, s.Sticks.Right.Pressed,
+		s.Dpad.Up, s.Dpad.Down, s.Dpad.Left, s.Dpad.Right, b.Touchpad, b.Capture,
+		e.P1, e.P2, e.P3, e.P4, e.Fn1, e.Fn2,
+	} {
+		if pressed {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+// Sender sends the states a function returns to a UDP address at a fixed
+// rate.
+type Sender struct {
+	conn   net.Conn
+	period time.Duration
+	states func() []gamepad.GamepadState
+	seq    uint32
+}
+
+// New returns a sender of the states returned by states (e.g.
+// hub.Broadcaster.ShownStates) to addr ("host:port"), rate times a second.
+// Resolving addr is the only thing that can fail: nothing is sent until Run.
+func New(addr string, rate int, states func() []gamepad.GamepadState) (*Sender, error) {
+	if rate < 1 {
+		return nil, fmt.Errorf("udp rate must be >= 1, got %d", rate)
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("udp output: %w", err)
+	}
+	return &Sender{conn: conn, period: time.Second / time.Duration(rate), states: states}, nil
+}
+
+// Run sends a packet per state every tick until ctx is done, then closes the
+// socket. A failed send (nobody listening, no route) is logged once until
+// sending works again.
+func (s *Sender) Run(ctx context.Context) {
+	defer s.conn.Close()
+	ticker := time.NewTicker(s.period)
+	defer ticker.Stop()
+	buf := make([]byte, 0, PacketSize)
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.seq++
+		for _, state := range s.states() {
+			_, err := s.conn.Write(Encode(buf[:0], s.seq, state))
+			if err != nil && !failing {
+				slog.Warn("udp output send failed", "addr", s.conn.RemoteAddr(), "error", err)
+			}
+			failing = err != nil
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package udpout
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+func TestEncode(t *testing.T) {
+	var s gamepad.GamepadState
+	s.Connected = true
+	s.PlayerIndex = 2
+	s.Buttons.A = true
+	s.Buttons.Start = true
+	s.Sticks.Right.Pressed = true
+	s.Dpad.Left = true
+	s.Extra.P4 = true
+	s.Sticks.Left.Position = gamepad.Vector{X: 1, Y: -0.5}
+	s.Sticks.Right.Position = gamepad.Vector{X: -2} // clamped
+	s.Triggers.RT.Value = 1
+
+	p := Encode(nil, 7, s)
+	if len(p) != PacketSize {
+		t.Fatalf("len = %d, want %d", len(p), PacketSize)
+	}
+	if !bytes.Equal(p[:8], []byte{'I', 'V', 'U', 'P', Version, 2, flagConnected, 0}) {
+		t.Errorf("header = % x", p[:8])
+	}
+	le := binary.LittleEndian
+	if seq := le.Uint32(p[8:]); seq != 7 {
+		t.Errorf("seq = %d, want 7", seq)
+	}
+	// a=0, start=7, rs=10, left=13, p4=20
+	if mask, want := le.Uint32(p[12:]), uint32(1<<0|1<<7|1<<10|1<<13|1<<20); mask != want {
+		t.Errorf("buttons = %#x, want %#x", mask, want)
+	}
+	for i, want := range []int16{32767, -16384, -32767, 0} {
+		if got := int16(le.Uint16(p[16+2*i:])); got != want {
+			t.Errorf("axis %d = %d, want %d", i, got, want)
+		}
+	}
+	if lt, rt := le.Uint16(p[24:]), le.Uint16(p[26:]); lt != 0 || rt != 65535 {
+		t.Errorf("triggers = %d, %d; want 0, 65535", lt, rt)
+	}
+	if len(Buttons) != 23 || Buttons[13] != "left" || Buttons[20] != "p4" {
+		t.Errorf("Buttons = %v, out of bit order", Buttons)
+	}
+}
+
+// TestSenderRun verifies that each tick sends a packet per state, with the
+// sequence number counting ticks.
+func TestSenderRun(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	states := func() []gamepad.GamepadState {
+		return []gamepad.GamepadState{{Connected: true, PlayerIndex: 1}, {Connected: true, PlayerIndex: 2}}
+	}
+	s, err := New(pc.LocalAddr().String(), 100, states)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 64)
+	for i, want := range []struct {
+		seq    uint32
+		player byte
+	}{{1, 1}, {1, 2}, {2, 1}, {2, 2}} {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if n != PacketSize || binary.LittleEndian.Uint32(buf[8:]) != want.seq || buf[5] != want.player {
+			t.Errorf("packet %d = % x, want seq %d of player %d", i, buf[:n], want.seq, want.player)
+		}
+	}
+
+	if _, err := New(pc.LocalAddr().String(), 0, states); err == nil {
+		t.Error("New(rate 0) error = nil, want an error")
+	}
+}
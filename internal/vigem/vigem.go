@@ -0,0 +1,141 @@
+// Package vigem mirrors the active controller to a virtual Xbox 360
+// controller on the ViGEm bus (--vigem, Windows), so games that only support
+// XInput can be played with any controller InputView reads: the DualSense,
+// Switch Pro, or generic HID pad shows up to them as an Xbox 360 controller.
+//
+// It needs the ViGEmBus driver and ViGEmClient.dll (64-bit) next to the
+// executable or on the DLL search path. Rumble sent to the virtual
+// controller is not forwarded to the real one.
+package vigem
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// ErrUnsupported is returned by Connect where ViGEm is not available (every
+// platform but 64-bit Windows).
+var ErrUnsupported = errors.New("virtual controllers need ViGEm on 64-bit Windows")
+
+// XUSB_GAMEPAD_* button bits of a report (the XInput ones).
+const (
+	buttonDpadUp     uint16 = 0x0001
+	buttonDpadDown   uint16 = 0x0002
+	buttonDpadLeft   uint16 = 0x0004
+	buttonDpadRight  uint16 = 0x0008
+	buttonStart      uint16 = 0x0010
+	buttonBack       uint16 = 0x0020
+	buttonLeftThumb  uint16 = 0x0040
+	buttonRightThumb uint16 = 0x0080
+	buttonLB         uint16 = 0x0100
+	buttonRB         uint16 = 0x0200
+	buttonGuide      uint16 = 0x0400
+	buttonA          uint16 = 0x1000
+	buttonB          uint16 = 0x2000
+	buttonX          uint16 = 0x4000
+	buttonY          uint16 = 0x8000
+)
+
+// report mirrors XUSB_REPORT, the input report of a virtual Xbox 360
+// controller (laid out like XINPUT_GAMEPAD).
+type report struct {
+	Buttons      uint16
+	LeftTrigger  uint8
+	RightTrigger uint8
+	ThumbLX      int16
+	ThumbLY      int16
+	ThumbRX      int16
+	ThumbRY      int16
+}
+
+// newReport returns the report of s: its Xbox layout buttons, sticks (Y
+// positive up, as in XInput), and triggers. Paddles, the touchpad, and the
+// capture button have no Xbox 360 counterpart; a disconnected state is a
+// released controller.
+func newReport(s gamepad.GamepadState) report {
+	if !s.Connected {
+		return report{}
+	}
+	var r report
+	for _, b := range []struct {
+		pressed bool
+		bit     uint16
+	}{
+		{s.Dpad.Up, buttonDpadUp}, {s.Dpad.Down, buttonDpadDown}, {s.Dpad.Left, buttonDpadLeft}, {s.Dpad.Right, buttonDpadRight},
+		{s.Buttons.Start, buttonStart}, {s.Buttons.Back, buttonBack},
+		{s.Sticks.Left.Pressed, buttonLeftThumb}, {s.Sticks.Right.Pressed, buttonRightThumb},
+		{s.Buttons.LB, buttonLB}, {s.Buttons.RB, buttonRB}, {s.Buttons.Guide, buttonGuide},
+		{s.Buttons.A, buttonA}, {s.Buttons.B, buttonB}, {s.Buttons.X, buttonX}, {s.Buttons.Y, buttonY},
+	} {
+		if b.pressed {
+			r.Buttons |= b.bit
+		}
+	}
+	r.LeftTrigger, r.RightTrigger = trigger(s.Triggers.LT.Value), trigger(s.Triggers.RT.Value)
+	r.ThumbLX, r.ThumbLY = axis(s.Sticks.Left.Position.X), axis(s.Sticks.Left.Position.Y)
+	r.ThumbRX, r.ThumbRY = axis(s.Sticks.Right.Position.X), axis(s.Sticks.Right.Position.Y)
+	return r
+}
+
+// axis converts a stick axis (-1.0 to 1.0) to XInput's range.
+func axis(v float64) int16 {
+	return int16(math.Round(min(max(v, -1), 1) * math.MaxInt16))
+}
+
+// trigger converts a trigger value (0.0 to 1.0) to XInput's range.
+func trigger(v float64) uint8 {
+	return uint8(math.Round(min(max(v, 0), 1) * math.MaxUint8))
+}
+
+// target is the virtual controller Mirror feeds; *Pad outside tests.
+type target interface {
+	update(r report) error
+	userIndex() (int, bool)
+}
+
+// Mirror sends the state returned by state (e.g. gamepad.Reader.State) to p
+// every interval while it changes, until ctx is done, then unplugs p. Once
+// the pad has an XInput slot, ignore is called with it (e.g.
+// gamepad.Reader.SetIgnoredXInputSlot), so InputView does not read the pad
+// back as a controller of its own.
+func Mirror(ctx context.Context, p *Pad, interval time.Duration, state func() gamepad.GamepadState, ignore func(slot int)) {
+	defer p.Close()
+	mirror(ctx, p, interval, state, ignore)
+}
+
+// mirror is Mirror without the unplugging.
+func mirror(ctx context.Context, t target, interval time.Duration, state func() gamepad.GamepadState, ignore func(slot int)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last report
+	sent, slotKnown := false, false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !slotKnown {
+			// XInput assigns the slot a moment after the pad is plugged in.
+			if slot, ok := t.userIndex(); ok {
+				slog.Info("virtual controller ready", "xinputSlot", slot)
+				ignore(slot)
+				slotKnown = true
+			}
+		}
+		r := newReport(state())
+		if sent && r == last {
+			continue
+		}
+		if err := t.update(r); err != nil {
+			slog.Warn("virtual controller update failed", "error", err)
+			continue
+		}
+		last, sent = r, true
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows || !amd64
+
+package vigem
+
+// Pad is a virtual Xbox 360 controller; there is none outside 64-bit Windows.
+type Pad struct{}
+
+// Connect always fails outside 64-bit Windows.
+func Connect() (*Pad, error) { return nil, ErrUnsupported }
+
+// update does nothing outside 64-bit Windows.
+func (p *Pad) update(r report) error { return ErrUnsupported }
+
+// userIndex never has a slot outside 64-bit Windows.
+func (p *Pad) userIndex() (int, bool) { return 0, false }
+
+// Close does nothing outside 64-bit Windows.
+func (p *Pad) Close() {}
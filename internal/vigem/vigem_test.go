@@ -0,0 +1,104 @@
+package vigem
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+func TestNewReport(t *testing.T) {
+	var s gamepad.GamepadState
+	s.Connected = true
+	s.Buttons.A, s.Buttons.Guide, s.Buttons.Touchpad = true, true, true
+	s.Dpad.Left = true
+	s.Sticks.Right.Pressed = true
+	s.Sticks.Left.Position = gamepad.Vector{X: -1, Y: 0.5}
+	s.Sticks.Right.Position = gamepad.Vector{X: 3} // clamped
+	s.Triggers.LT.Value = 1
+
+	want := report{
+		Buttons:     buttonA | buttonGuide | buttonDpadLeft | buttonRightThumb,
+		LeftTrigger: 255,
+		ThumbLX:     -32767, ThumbLY: 16384, ThumbRX: 32767,
+	}
+	if got := newReport(s); got != want {
+		t.Errorf("newReport() = %+v, want %+v", got, want)
+	}
+	if got := newReport(gamepad.GamepadState{Buttons: gamepad.ButtonState{A: true}}); got != (report{}) {
+		t.Errorf("newReport(disconnected) = %+v, want a released pad", got)
+	}
+}
+
+// fakePad records the reports it is sent and gets its slot on the second
+// lookup.
+type fakePad struct {
+	mu      sync.Mutex
+	reports []report
+	lookups int
+}
+
+func (p *fakePad) update(r report) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reports = append(p.reports, r)
+	return nil
+}
+
+func (p *fakePad) userIndex() (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lookups++
+	return 2, p.lookups > 1
+}
+
+// TestMirror verifies that the pad is sent each new state once, and that its
+// slot is ignored once known.
+func TestMirror(t *testing.T) {
+	var mu sync.Mutex
+	s := gamepad.GamepadState{Connected: true}
+	state := func() gamepad.GamepadState {
+		mu.Lock()
+		defer mu.Unlock()
+		return s
+	}
+	ignored := make(chan int, 1)
+	p := &fakePad{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		mirror(ctx, p, time.Millisecond, state, func(slot int) { ignored <- slot })
+		close(done)
+	}()
+
+	select {
+	case slot := <-ignored:
+		if slot != 2 {
+			t.Errorf("ignored slot %d, want 2", slot)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("slot never ignored")
+	}
+	mu.Lock()
+	s.Buttons.B = true
+	mu.Unlock()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.Lock()
+		n := len(p.reports)
+		p.mu.Unlock()
+		if n >= 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-done
+
+	if len(p.reports) != 2 || p.reports[0] != (report{}) || p.reports[1] != (report{Buttons: buttonB}) {
+		t.Errorf("reports = %+v, want a released pad, then B", p.reports)
+	}
+}
@@ -0,0 +1,100 @@
+//go:build amd64
+
+package vigem
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// ViGEmClient.dll exports used (ViGEmClient.h).
+var (
+	modViGEmClient          = syscall.NewLazyDLL("ViGEmClient.dll")
+	procAlloc               = modViGEmClient.NewProc("vigem_alloc")
+	procFree                = modViGEmClient.NewProc("vigem_free")
+	procConnect             = modViGEmClient.NewProc("vigem_connect")
+	procDisconnect          = modViGEmClient.NewProc("vigem_disconnect")
+	procTargetX360Alloc     = modViGEmClient.NewProc("vigem_target_x360_alloc")
+	procTargetFree          = modViGEmClient.NewProc("vigem_target_free")
+	procTargetAdd           = modViGEmClient.NewProc("vigem_target_add")
+	procTargetRemove        = modViGEmClient.NewProc("vigem_target_remove")
+	procTargetX360Update    = modViGEmClient.NewProc("vigem_target_x360_update")
+	procTargetX360UserIndex = modViGEmClient.NewProc("vigem_target_x360_get_user_index")
+)
+
+// errorNone is VIGEM_ERROR_NONE; every other VIGEM_ERROR is a failure.
+const errorNone = 0x20000000
+
+// vigemError is a VIGEM_ERROR other than errorNone.
+type vigemError uintptr
+
+func (e vigemError) Error() string { return fmt.Sprintf("ViGEm error %#x", uintptr(e)) }
+
+// call calls a ViGEmClient function returning a VIGEM_ERROR.
+func call(proc *syscall.LazyProc, args ...uintptr) error {
+	ret, _, _ := proc.Call(args...)
+	if uint32(ret) != errorNone {
+		return vigemError(uint32(ret))
+	}
+	return nil
+}
+
+// Pad is a virtual Xbox 360 controller plugged into the ViGEm bus.
+type Pad struct {
+	client uintptr // PVIGEM_CLIENT
+	target uintptr // PVIGEM_TARGET
+}
+
+// Connect connects to the ViGEm bus and plugs in a virtual Xbox 360
+// controller, released until the first update.
+func Connect() (*Pad, error) {
+	if err := modViGEmClient.Load(); err != nil {
+		return nil, fmt.Errorf("load ViGEmClient.dll: %w", err)
+	}
+	client, _, _ := procAlloc.Call()
+	if client == 0 {
+		return nil, fmt.Errorf("vigem_alloc failed")
+	}
+	if err := call(procConnect, client); err != nil {
+		procFree.Call(client)
+		return nil, fmt.Errorf("connect to ViGEmBus (is the driver installed?): %w", err)
+	}
+	target, _, _ := procTargetX360Alloc.Call()
+	if target == 0 {
+		procDisconnect.Call(client)
+		procFree.Call(client)
+		return nil, fmt.Errorf("vigem_target_x360_alloc failed")
+	}
+	if err := call(procTargetAdd, client, target); err != nil {
+		procTargetFree.Call(target)
+		procDisconnect.Call(client)
+		procFree.Call(client)
+		return nil, fmt.Errorf("plug in virtual controller: %w", err)
+	}
+	return &Pad{client: client, target: target}, nil
+}
+
+// update sends r to the pad. XUSB_REPORT is passed by value; the x64 calling
+// convention passes a 12-byte struct as a pointer to a copy.
+func (p *Pad) update(r report) error {
+	return call(procTargetX360Update, p.client, p.target, uintptr(unsafe.Pointer(&r)))
+}
+
+// userIndex returns the XInput slot of the pad, ok=false until XInput has
+// assigned one.
+func (p *Pad) userIndex() (int, bool) {
+	var index uint32
+	if call(procTargetX360UserIndex, p.client, p.target, uintptr(unsafe.Pointer(&index))) != nil {
+		return 0, false
+	}
+	return int(index), true
+}
+
+// Close unplugs the pad and disconnects from the bus.
+func (p *Pad) Close() {
+	procTargetRemove.Call(p.client, p.target)
+	procTargetFree.Call(p.target)
+	procDisconnect.Call(p.client)
+	procFree.Call(p.client)
+}
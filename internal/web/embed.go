@@ -20,6 +20,8 @@ import (
 	mjson "github.com/tdewolff/minify/v2/json"
 )
 
+//go:generate go run gen_protocol.go
+
 //go:embed all:frontend
 var frontendFiles embed.FS
 
@@ -10,6 +10,8 @@ function configNameForType(type) {
         'playstation': 'playstation',
         'playstation5': 'playstation',
         'switch_pro': 'switch_pro',
+        'arcade': 'arcade',
+        'hitbox': 'hitbox',
     };
     return configMap[type] || 'xbox';
 }
@@ -29,7 +29,10 @@ function drawController() {
     drawTriggers(cfg);
     drawBody(cfg);
     drawDpad(cfg);
+    drawLever(cfg);
+    drawHitboxDirections(cfg);
     drawFaceButtons(cfg);
+    drawArcadeButtons(cfg);
     drawShoulderButtons(cfg);
     drawSticks(cfg);
     drawTouchpad(cfg);
@@ -132,6 +135,68 @@ function drawDpad(cfg) {
     drawDpadDirection(cx, cy, size, arm, 'right', state.dpad.right);
 }
 
+// --- Arcade Lever (arcade sticks; the dpad moves the ball) ---
+function drawLever(cfg) {
+    const lever = cfg.lever;
+    if (!lever) return;
+
+    const gateR = lever.gateRadius || 48;
+    const ballR = lever.ballRadius || 22;
+    const travel = lever.travel || 30;
+
+    ctx.beginPath();
+    ctx.arc(lever.x, lever.y, gateR, 0, Math.PI * 2);
+    ctx.fillStyle = COLORS.stickBase;
+    ctx.fill();
+    ctx.strokeStyle = COLORS.outline;
+    ctx.lineWidth = 2;
+    ctx.stroke();
+
+    let dx = (state.dpad.right ? 1 : 0) - (state.dpad.left ? 1 : 0);
+    let dy = (state.dpad.down ? 1 : 0) - (state.dpad.up ? 1 : 0);
+    if (dx !== 0 && dy !== 0) {
+        dx *= Math.SQRT1_2;
+        dy *= Math.SQRT1_2;
+    }
+    const ballX = lever.x + dx * travel;
+    const ballY = lever.y + dy * travel;
+    const held = dx !== 0 || dy !== 0;
+
+    ctx.strokeStyle = COLORS.stickKnob;
+    ctx.lineWidth = 8;
+    ctx.beginPath();
+    ctx.moveTo(lever.x, lever.y);
+    ctx.lineTo(ballX, ballY);
+    ctx.stroke();
+
+    ctx.beginPath();
+    ctx.arc(ballX, ballY, ballR, 0, Math.PI * 2);
+    ctx.fillStyle = held ? COLORS.stickKnobPressed : COLORS.stickKnob;
+    ctx.fill();
+    ctx.strokeStyle = COLORS.outline;
+    ctx.lineWidth = 1.5;
+    ctx.stroke();
+}
+
+// --- Hitbox Direction Buttons (leverless; one button per dpad direction) ---
+function drawHitboxDirections(cfg) {
+    const dirs = cfg.hitboxDirections;
+    if (!dirs) return;
+
+    const r = dirs.radius || 22;
+    for (const dir of ['left', 'down', 'right', 'up']) {
+        const pos = dirs[dir];
+        if (!pos) continue;
+        ctx.beginPath();
+        ctx.arc(pos.x, pos.y, pos.radius || r, 0, Math.PI * 2);
+        ctx.fillStyle = state.dpad[dir] ? COLORS.dpadPressed : COLORS.dpadBg;
+        ctx.fill();
+        ctx.strokeStyle = COLORS.outline;
+        ctx.lineWidth = 2;
+        ctx.stroke();
+    }
+}
+
 // --- Face Buttons (A, B, X, Y) ---
 const psSymbols = {
     '\u00D7': (x, y, size) => {
@@ -180,7 +245,7 @@ function drawFaceButtons(cfg) {
 
         ctx.beginPath();
         ctx.arc(pos.x, pos.y, r, 0, Math.PI * 2);
-        ctx.fillStyle = pressed ? labelColor : COLORS.buttonDefault;
+        ctx.fillStyle = pressed ? (color || COLORS.buttonPressed) : COLORS.buttonDefault;
         ctx.fill();
         ctx.strokeStyle = labelColor;
         ctx.lineWidth = 2;
@@ -203,6 +268,50 @@ function drawFaceButtons(cfg) {
     }
 }
 
+// --- Arcade Buttons (8-button layout: face buttons, bumpers, triggers) ---
+// Triggers are read as pressed past TRIGGER_PRESS_THRESHOLD; sticks report
+// them as digital buttons anyway.
+const arcadeButtonColors = {
+    a: COLORS.faceA,
+    b: COLORS.faceB,
+    x: COLORS.faceX,
+    y: COLORS.faceY,
+};
+
+function drawArcadeButtons(cfg) {
+    const arcade = cfg.arcadeButtons;
+    if (!arcade || !arcade.buttons) return;
+
+    const r = arcade.radius || 22;
+    const defaultLabelConfig = arcade.label || { fontSize: 15, fontWeight: 'bold' };
+
+    for (const btn of arcade.buttons) {
+        const key = btn.key;
+        const pressed = (key === 'lt' || key === 'rt')
+            ? state.triggers[key].value > TRIGGER_PRESS_THRESHOLD
+            : state.buttons[key];
+        const bLabel = btn.label || {};
+        const color = bLabel.color || arcadeButtonColors[key];
+        const fontSize = bLabel.fontSize || defaultLabelConfig.fontSize || 15;
+        const fontWeight = bLabel.fontWeight || defaultLabelConfig.fontWeight || 'bold';
+
+        ctx.beginPath();
+        ctx.arc(btn.x, btn.y, btn.radius || r, 0, Math.PI * 2);
+        ctx.fillStyle = pressed ? labelColor : COLORS.buttonDefault;
+        ctx.fill();
+        ctx.strokeStyle = color || COLORS.outline;
+        ctx.lineWidth = 2;
+        ctx.stroke();
+
+        if (!bLabel.text) continue;
+        ctx.fillStyle = pressed ? COLORS.buttonLabelPressed : (color || COLORS.buttonLabel);
+        ctx.font = cachedFont(fontSize, fontWeight);
+        ctx.textAlign = 'center';
+        ctx.textBaseline = 'middle';
+        ctx.fillText(bLabel.text, btn.x, btn.y + 1);
+    }
+}
+
 // --- Shoulder Buttons (LB, RB) ---
 function drawShoulderButtons(cfg) {
     const shoulders = cfg.shoulders;
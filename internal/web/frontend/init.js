@@ -53,6 +53,15 @@ function init() {
         if (!isNaN(sens) && sens >= 1 && sens <= 10000) mouseSens = sens;
     }
 
+    const rateParam = urlParams.get('rate');
+    if (rateParam !== null) {
+        const rate = parseFloat(rateParam);
+        if (!isNaN(rate) && rate >= 0 && rate <= 1000) updateRate = rate;
+    }
+
+    const profileParam = urlParams.get('profile');
+    if (profileParam) outputProfile = profileParam;
+
     // Pre-compute body fill color after bodyAlpha is resolved
     updateBodyFillColor();
 
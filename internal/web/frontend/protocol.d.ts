@@ -0,0 +1,650 @@
+// Code generated by internal/tsgen; DO NOT EDIT.
+// InputView WebSocket protocol and REST payload types. Regenerate with: go generate ./internal/web
+
+/** WebSocket protocol version these types describe (hub.ProtocolVersion). */
+export type ProtocolVersion = 1;
+
+/** Server → client message types. */
+export type ServerMessageType = "full" | "delta" | "player_selected" | "km_full" | "km_delta" | "power_changed" | "controller_connected" | "controller_disconnected" | "controller_switched" | "controller_drift" | "profile_selected" | "server_shutdown" | "input_diff" | "ghost_state" | "marker_added" | "time_sync" | "freeze_changed" | "plugin_event" | "analog" | "combo" | "notation" | "stats";
+
+/** Client → server command types. */
+export type ClientMessageType = "select_player" | "select_profile" | "subscribe_km" | "subscribe_ghost" | "subscribe_notation" | "time_sync" | "request_full" | "set_mouse_sens" | "set_rate" | "set_analog" | "set_fields" | "rumble" | "set_led" | "set_raw_mode";
+
+/** Go: hub.WSMessage */
+export interface WSMessage {
+  type: ServerMessageType;
+  seq: number;
+  timestamp: number;
+  mono: number;
+  data?: GamepadState;
+  changes?: DeltaChanges;
+  playerIndex?: number;
+  kmState?: KeyMouseState;
+  kmDelta?: KeyMouseDelta;
+  counters?: PressCounters;
+  lastChanged?: LastChanged;
+  power?: PowerEvent;
+  device?: DeviceEvent;
+  profile?: string;
+  diff?: InputDiff;
+  ghost?: GhostInfo;
+  marker?: Marker;
+  sync?: TimeSync;
+  freeze?: FreezeInfo;
+  plugin?: PluginEvent;
+  analog?: AnalogFrame;
+  combo?: ComboEvent;
+  notation?: NotationEntry;
+  stats?: SessionStats;
+}
+
+/** Go: gamepad.GamepadState */
+export interface GamepadState {
+  connected: boolean;
+  controllerType: string;
+  name: string;
+  playerIndex: number;
+  deviceId?: string;
+  serial?: string;
+  productVersion?: number;
+  capabilities?: Capabilities;
+  battery?: BatteryState;
+  motion?: MotionState;
+  buttons: ButtonState;
+  extra?: ExtraButtonState;
+  dpad: DpadState;
+  sticks: SticksState;
+  triggers: TriggersState;
+  wheel?: WheelState;
+  flight?: FlightState;
+  raw?: RawState;
+}
+
+/** Go: gamepad.Capabilities */
+export interface Capabilities {
+  hasRumble: boolean;
+  hasGyro: boolean;
+  hasTouchpad: boolean;
+  numButtons: number;
+  numAxes: number;
+  batteryReadable: boolean;
+}
+
+/** Go: gamepad.BatteryState */
+export interface BatteryState {
+  status: string;
+  level: number;
+}
+
+/** Go: gamepad.MotionState */
+export interface MotionState {
+  gyro: Vector3;
+  accel: Vector3;
+}
+
+/** Go: gamepad.Vector3 */
+export interface Vector3 {
+  x: number;
+  y: number;
+  z: number;
+}
+
+/** Go: gamepad.ButtonState */
+export interface ButtonState {
+  a: boolean;
+  b: boolean;
+  x: boolean;
+  y: boolean;
+  lb: boolean;
+  rb: boolean;
+  back: boolean;
+  start: boolean;
+  guide: boolean;
+  touchpad: boolean;
+  capture: boolean;
+}
+
+/** Go: gamepad.ExtraButtonState */
+export interface ExtraButtonState {
+  p1: boolean;
+  p2: boolean;
+  p3: boolean;
+  p4: boolean;
+  fn1: boolean;
+  fn2: boolean;
+}
+
+/** Go: gamepad.DpadState */
+export interface DpadState {
+  up: boolean;
+  down: boolean;
+  left: boolean;
+  right: boolean;
+}
+
+/** Go: gamepad.SticksState */
+export interface SticksState {
+  left: StickState;
+  right: StickState;
+}
+
+/** Go: gamepad.StickState */
+export interface StickState {
+  position: Vector;
+  pressed: boolean;
+}
+
+/** Go: gamepad.Vector */
+export interface Vector {
+  x: number;
+  y: number;
+}
+
+/** Go: gamepad.TriggersState */
+export interface TriggersState {
+  lt: TriggerState;
+  rt: TriggerState;
+}
+
+/** Go: gamepad.TriggerState */
+export interface TriggerState {
+  value: number;
+}
+
+/** Go: gamepad.WheelState */
+export interface WheelState {
+  angle: number;
+  throttle: number;
+  brake: number;
+  clutch: number;
+  handbrake: number;
+  gear: number;
+}
+
+/** Go: gamepad.FlightState */
+export interface FlightState {
+  roll: number;
+  pitch: number;
+  yaw: number;
+  thrust: number;
+  axes: number[];
+  buttons: number[];
+  hats: DpadState[];
+}
+
+/** Go: gamepad.RawState */
+export interface RawState {
+  axes: number[];
+  buttons: number[];
+  hats: number[];
+  numAxes: number;
+  numButtons: number;
+  numHats: number;
+}
+
+/** Go: gamepad.DeltaChanges */
+export interface DeltaChanges {
+  connected?: boolean;
+  controllerType?: string;
+  name?: string;
+  deviceId?: string;
+  serial?: string;
+  productVersion?: number;
+  capabilities?: Capabilities;
+  battery?: BatteryState;
+  motion?: MotionState;
+  buttons?: ButtonState;
+  extra?: ExtraButtonState;
+  dpad?: DpadState;
+  sticks?: SticksState;
+  triggers?: TriggersState;
+  wheel?: WheelState;
+  flight?: FlightState;
+  raw?: RawState;
+}
+
+/** Go: input.KeyMouseState */
+export interface KeyMouseState {
+  keys: Record<string, boolean> | null;
+  mouseButtons: Record<string, boolean> | null;
+  mouseDX: number;
+  mouseDY: number;
+  wheelUp: boolean;
+  wheelDown: boolean;
+}
+
+/** Go: input.KeyMouseDelta */
+export interface KeyMouseDelta {
+  keysDown?: number[];
+  keysUp?: number[];
+  buttonsDown?: number[];
+  buttonsUp?: number[];
+  mouseMove?: MouseMoveData;
+  wheelUp?: boolean;
+  wheelDown?: boolean;
+}
+
+/** Go: input.MouseMoveData */
+export interface MouseMoveData {
+  x: number;
+  y: number;
+}
+
+/** Go: hub.PressCounters */
+export interface PressCounters {
+  since: number;
+  buttons: ButtonCounts;
+  extra: ExtraCounts;
+  dpad: DpadCounts;
+  sticks: StickCounts;
+}
+
+/** Go: hub.ButtonCounts */
+export interface ButtonCounts {
+  a: number;
+  b: number;
+  x: number;
+  y: number;
+  lb: number;
+  rb: number;
+  back: number;
+  start: number;
+  guide: number;
+  touchpad: number;
+  capture: number;
+}
+
+/** Go: hub.ExtraCounts */
+export interface ExtraCounts {
+  p1: number;
+  p2: number;
+  p3: number;
+  p4: number;
+  fn1: number;
+  fn2: number;
+}
+
+/** Go: hub.DpadCounts */
+export interface DpadCounts {
+  up: number;
+  down: number;
+  left: number;
+  right: number;
+}
+
+/** Go: hub.StickCounts */
+export interface StickCounts {
+  left: number;
+  right: number;
+}
+
+/** Go: hub.LastChanged */
+export interface LastChanged {
+  buttons: ButtonTimes;
+  extra: ExtraTimes;
+  dpad: DpadTimes;
+  sticks: SticksTimes;
+  triggers: TriggersTimes;
+}
+
+/** Go: hub.ButtonTimes */
+export interface ButtonTimes {
+  a: number;
+  b: number;
+  x: number;
+  y: number;
+  lb: number;
+  rb: number;
+  back: number;
+  start: number;
+  guide: number;
+  touchpad: number;
+  capture: number;
+}
+
+/** Go: hub.ExtraTimes */
+export interface ExtraTimes {
+  p1: number;
+  p2: number;
+  p3: number;
+  p4: number;
+  fn1: number;
+  fn2: number;
+}
+
+/** Go: hub.DpadTimes */
+export interface DpadTimes {
+  up: number;
+  down: number;
+  left: number;
+  right: number;
+}
+
+/** Go: hub.SticksTimes */
+export interface SticksTimes {
+  left: StickTimes;
+  right: StickTimes;
+}
+
+/** Go: hub.StickTimes */
+export interface StickTimes {
+  position: number;
+  pressed: number;
+}
+
+/** Go: hub.TriggersTimes */
+export interface TriggersTimes {
+  lt: number;
+  rt: number;
+}
+
+/** Go: hub.PowerEvent */
+export interface PowerEvent {
+  reason: string;
+  threshold?: number;
+  previous: BatteryState;
+  current: BatteryState;
+}
+
+/** Go: hub.DeviceEvent */
+export interface DeviceEvent {
+  reason?: string;
+  controller: ControllerInfo;
+  previous?: ControllerInfo;
+  drift?: DriftReport;
+}
+
+/** Go: gamepad.ControllerInfo */
+export interface ControllerInfo {
+  playerIndex: number;
+  deviceId: string;
+  name: string;
+  controllerType: string;
+  source: string;
+  vendorId?: number;
+  productId?: number;
+  active: boolean;
+  serial?: string;
+  productVersion?: number;
+  battery?: BatteryState;
+  guid?: string;
+  mapping: string;
+  sdlName?: string;
+  axes: number;
+  buttons: number;
+  hats: number;
+  rawAxes?: string[];
+  deadzones: Deadzones;
+}
+
+/** Go: gamepad.Deadzones */
+export interface Deadzones {
+  stick: number;
+  trigger: number;
+  mode: string;
+}
+
+/** Go: gamepad.DriftReport */
+export interface DriftReport {
+  left: StickDrift;
+  right: StickDrift;
+}
+
+/** Go: gamepad.StickDrift */
+export interface StickDrift {
+  bias: Vector;
+  noise: Vector;
+  drifting: boolean;
+  measured: boolean;
+}
+
+/** Go: hub.InputDiff */
+export interface InputDiff {
+  attempt: number;
+  control: string;
+  kind: string;
+  atMs: number;
+  offsetMs?: number;
+}
+
+/** Go: hub.GhostInfo */
+export interface GhostInfo {
+  label: string;
+  run: number;
+  atMs: number;
+  playing: boolean;
+}
+
+/** Go: marker.Marker */
+export interface Marker {
+  id: number;
+  name: string;
+  time: number;
+  source: string;
+  playerIndex?: number;
+}
+
+/** Go: hub.TimeSync */
+export interface TimeSync {
+  clientTime: number;
+  serverReceive: number;
+  serverSend: number;
+}
+
+/** Go: hub.FreezeInfo */
+export interface FreezeInfo {
+  frozen: boolean;
+  until?: number;
+}
+
+/** Go: hub.PluginEvent */
+export interface PluginEvent {
+  plugin: string;
+  event: string;
+  data?: unknown;
+}
+
+/** Go: hub.AnalogFrame */
+export interface AnalogFrame {
+  sticks: AnalogSticks;
+  triggers: TriggersState;
+  motion?: MotionState;
+}
+
+/** Go: hub.AnalogSticks */
+export interface AnalogSticks {
+  left: Vector;
+  right: Vector;
+}
+
+/** Go: hub.ComboEvent */
+export interface ComboEvent {
+  name: string;
+  durationMs: number;
+}
+
+/** Go: hub.NotationEntry */
+export interface NotationEntry {
+  input: string;
+  direction: number;
+  buttons?: string[];
+  prevFrames: number;
+}
+
+/** Go: hub.SessionStats */
+export interface SessionStats {
+  playerIndex: number;
+  presses: PressCounters;
+  totalPresses: number;
+  apm: number;
+  averageApm: number;
+  travel: StickTravel;
+}
+
+/** Go: hub.StickTravel */
+export interface StickTravel {
+  left: number;
+  right: number;
+}
+
+/** Go: hub.ClientMessage */
+export interface ClientMessage {
+  type: ClientMessageType;
+  playerIndex?: number;
+  value?: number;
+  profile?: string;
+  clientTime?: number;
+  low?: number;
+  high?: number;
+  duration?: number;
+  color?: string;
+  playerLeds?: number;
+  enabled?: boolean;
+  analog?: string;
+  fields?: string;
+}
+
+/** Go: server.InjectRequest */
+export interface InjectRequest {
+  type: "full" | "delta";
+  data?: GamepadState;
+  changes?: DeltaChanges;
+}
+
+/** Go: server.HealthResponse */
+export interface HealthResponse {
+  status: string;
+  version: string;
+  uptime_seconds: number;
+  headless?: boolean;
+}
+
+/** Go: server.ControllersResponse */
+export interface ControllersResponse {
+  controllers: ControllerInfo[];
+}
+
+/** Go: server.StateResponse */
+export interface StateResponse {
+  states: GamepadState[];
+}
+
+/** Go: server.HistoryResponse */
+export interface HistoryResponse {
+  entries: HistoryEntry[];
+}
+
+/** Go: hub.HistoryEntry */
+export interface HistoryEntry {
+  seq: number;
+  at: number;
+  state: GamepadState;
+}
+
+/** Go: server.StatsResponse */
+export interface StatsResponse {
+  players: SessionStats[];
+}
+
+/** Go: hub.Heatmaps */
+export interface Heatmaps {
+  playerIndex: number;
+  left: StickHeatmap;
+  right: StickHeatmap;
+}
+
+/** Go: hub.StickHeatmap */
+export interface StickHeatmap {
+  size: number;
+  totalMs: number;
+  cells: number[][] | null;
+}
+
+/** Go: server.AuditResponse */
+export interface AuditResponse {
+  entries: Entry[];
+}
+
+/** Go: audit.Entry */
+export interface Entry {
+  time: number;
+  action: string;
+  source: string;
+  client?: string;
+  details?: Record<string, unknown>;
+}
+
+/** Go: server.ListenersResponse */
+export interface ListenersResponse {
+  listeners: Record<string, string> | null;
+}
+
+/** Go: server.CreateTokenRequest */
+export interface CreateTokenRequest {
+  label?: string;
+  ttlSeconds?: number;
+}
+
+/** Go: server.TokensResponse */
+export interface TokensResponse {
+  tokens: ViewerToken[];
+}
+
+/** Go: server.ViewerToken */
+export interface ViewerToken {
+  id: string;
+  label?: string;
+  created: number;
+  expires: number;
+  token?: string;
+}
+
+/** Go: server.CreateMarkerRequest */
+export interface CreateMarkerRequest {
+  name?: string;
+}
+
+/** Go: server.MarkersResponse */
+export interface MarkersResponse {
+  markers: Marker[];
+}
+
+/** Go: server.FreezeRequest */
+export interface FreezeRequest {
+  seconds?: number;
+}
+
+/** Go: server.LEDRequest */
+export interface LEDRequest {
+  color?: string;
+  playerLeds?: number;
+}
+
+/** Go: server.RawModeRequest */
+export interface RawModeRequest {
+  enabled: boolean | null;
+}
+
+/** Go: server.DeadzoneRequest */
+export interface DeadzoneRequest {
+  stick?: number;
+  trigger?: number;
+  mode?: string;
+}
+
+/** Go: server.SDLMappingResponse */
+export interface SDLMappingResponse {
+  deviceId: string;
+  mapping: string;
+}
+
+/** Go: server.DriftResponse */
+export interface DriftResponse {
+  deviceId: string;
+  drift: DriftReport;
+}
+
+/** Go: server.APIError */
+export interface APIError {
+  error: string;
+}
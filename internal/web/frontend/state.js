@@ -39,6 +39,8 @@ let bodyAlpha = 1.0;
 let buttonAlpha = 1.0;
 let selectedPlayerIndex = 1;
 let mouseSens = 0; // 0 = not set by URL param
+let updateRate = null; // server-side update rate in Hz (?rate=); null = every change
+let outputProfile = null; // server-side output profile name (?profile=); null = none
 
 // Input Overlay state
 let overlayName = null;
@@ -53,6 +55,7 @@ let overlayHasKM = false;
 let ws = null;
 let reconnectDelay = RECONNECT_DELAY_INITIAL;
 let wsConnected = false;
+let serverStopped = false; // last connection ended with server_shutdown
 
 // Rendering state
 let kmSubscribed = false;
@@ -10,7 +10,17 @@ function connectWebSocket() {
 
     ws.onopen = () => {
         reconnectDelay = RECONNECT_DELAY_INITIAL;
+        serverStopped = false;
         setWSStatus(true);
+        // Select the output profile first, so every state after it is transformed
+        if (outputProfile !== null) {
+            ws.send(JSON.stringify({ type: 'select_profile', profile: outputProfile }));
+        }
+        // Ask the server to coalesce state updates to the requested rate
+        if (updateRate !== null) {
+            ws.send(JSON.stringify({ type: 'set_rate', value: updateRate }));
+        }
+
         // Send selected player index to backend, unless the overlay has no gamepad elements
         // (in that case we don't need gamepad data at all).
         if ((overlayName === null && (!explicitMode || hasGamepadParam)) || (overlayName !== null && overlayHasGamepad)) {
@@ -70,7 +80,7 @@ function updateStatusIndicator() {
     const text = document.getElementById('ws-text');
     if (!wsConnected) {
         dot.className = 'status-dot disconnected';
-        text.textContent = 'Server Disconnected';
+        text.textContent = serverStopped ? 'Server Stopped' : 'Server Disconnected';
     } else if (!state.connected) {
         dot.className = 'status-dot disconnected';
         text.textContent = 'No Controller';
@@ -84,6 +94,7 @@ function updateStatusIndicator() {
 // Message Dispatch & State Merging
 // ============================================================
 
+/** @param {import('./protocol').WSMessage} msg */
 function handleMessage(msg) {
     switch (msg.type) {
         case 'full':
@@ -93,8 +104,35 @@ function handleMessage(msg) {
             if (msg.changes) applyDelta(msg.changes);
             break;
         case 'player_selected':
+        case 'profile_selected':
             // Acknowledged -- nothing to do on frontend
             break;
+        case 'power_changed':
+        case 'controller_connected':
+        case 'controller_disconnected':
+        case 'controller_switched':
+        case 'controller_drift':
+        case 'input_diff':
+        case 'ghost_state':
+        case 'marker_added':
+        case 'time_sync':
+        case 'freeze_changed':
+        case 'plugin_event':
+        case 'analog':
+        case 'combo':
+        case 'notation':
+        case 'stats':
+            // Events for custom overlays; the built-in overlay follows full/delta
+            break;
+        case 'server_shutdown':
+            // The backend is stopping: drop the last state instead of freezing on it.
+            // The close follows; onclose reconnects, and the first full restores the state.
+            serverStopped = true;
+            state.connected = false;
+            updateControllerInfo();
+            dirty = true;
+            markRendererDirty(['gamepad']);
+            break;
         case 'km_full':
             if (msg.kmState) applyKMFull(msg.kmState);
             break;
@@ -0,0 +1,25 @@
+//go:build ignore
+
+// gen_protocol writes frontend/protocol.d.ts, the TypeScript declarations of
+// the WebSocket protocol and REST payloads. Run via: go generate ./internal/web
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/soar/inputview/internal/tsgen"
+)
+
+func main() {
+	var buf bytes.Buffer
+	if err := tsgen.WriteProtocol(&buf); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(tsgen.ProtocolFile, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
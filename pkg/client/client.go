@@ -0,0 +1,311 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lxzan/gws"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+const (
+	defaultReconnectInitial = 1 * time.Second
+	defaultReconnectMax     = 10 * time.Second
+	defaultUpdatesBuffer    = 64
+	handshakeTimeout        = 5 * time.Second
+	clockSyncInterval       = 30 * time.Second
+)
+
+// Update is one change of the local state.
+type Update struct {
+	State gamepad.GamepadState // complete state after the message was applied
+	Seq   int64                // server sequence number of the message
+	Full  bool                 // true if State came from a full snapshot (connect, periodic sync, resync)
+	Mono  int64                // server monotonic clock when the message was built, in µs (see LocalTime); 0 from older servers
+}
+
+// wireMessage is the subset of the server's WSMessage the client uses.
+// Unknown fields and message types are ignored for forward compatibility.
+type wireMessage struct {
+	Type        string                `json:"type"`
+	Seq         int64                 `json:"seq"`
+	Data        *gamepad.GamepadState `json:"data"`
+	Changes     *gamepad.DeltaChanges `json:"changes"`
+	PlayerIndex int                   `json:"playerIndex"`
+	Mono        int64                 `json:"mono"`
+	Sync        *wireTimeSync         `json:"sync"`
+}
+
+// wireTimeSync is the "sync" section of a time_sync reply (hub.TimeSync).
+type wireTimeSync struct {
+	ClientTime    float64 `json:"clientTime"`
+	ServerReceive int64   `json:"serverReceive"`
+	ServerSend    int64   `json:"serverSend"`
+}
+
+// clockSample is the result of one time_sync exchange, in microseconds.
+type clockSample struct {
+	offset int64 // server monotonic clock minus localMicros()
+	rtt    int64 // round trip without the server's processing time
+}
+
+// Client is a reconnecting InputView WebSocket client.
+type Client struct {
+	url              string
+	playerIndex      int
+	reconnectInitial time.Duration
+	reconnectMax     time.Duration
+	updatesBuffer    int
+	header           http.Header // extra handshake headers (SetBasicAuth)
+
+	updates   chan Update
+	initOnce  sync.Once
+	state     atomic.Pointer[gamepad.GamepadState]
+	connected atomic.Bool
+	start     time.Time // origin of localMicros
+	clock     atomic.Pointer[clockSample]
+}
+
+// New creates a client for the WebSocket endpoint at url
+// (e.g. "ws://localhost:8080/ws"). Call Run to connect.
+func New(url string) *Client {
+	c := &Client{
+		url:              url,
+		playerIndex:      1,
+		reconnectInitial: defaultReconnectInitial,
+		reconnectMax:     defaultReconnectMax,
+		updatesBuffer:    defaultUpdatesBuffer,
+		start:            time.Now(),
+	}
+	c.state.Store(&gamepad.GamepadState{})
+	return c
+}
+
+// SetPlayerIndex selects the 1-based player to stream (default 1). It is sent
+// as select_player after every connect. Must be called before Run.
+func (c *Client) SetPlayerIndex(index int) {
+	c.playerIndex = index
+}
+
+// SetReconnectDelay sets the backoff between connection attempts: the first
+// retry waits initial, each further one 1.5x longer, capped at max
+// (defaults 1s and 10s, as in the browser frontend). Must be called before Run.
+func (c *Client) SetReconnectDelay(initial, max time.Duration) {
+	c.reconnectInitial = initial
+	c.reconnectMax = max
+}
+
+// SetBasicAuth sends HTTP basic auth credentials with every handshake, for
+// servers started with --auth-password. Must be called before Run.
+func (c *Client) SetBasicAuth(user, password string) {
+	auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+	c.header = http.Header{"Authorization": {"Basic " + auth}}
+}
+
+// SetUpdatesBuffer sets the capacity of the Updates channel (default 64).
+// Must be called before Run or Updates.
+func (c *Client) SetUpdatesBuffer(n int) {
+	c.updatesBuffer = n
+}
+
+// Updates returns the channel of state changes. It is closed when Run returns.
+// The channel must be drained: while it is full the client stops reading the
+// socket, and the server drops messages for it until it catches up.
+func (c *Client) Updates() <-chan Update {
+	c.initOnce.Do(func() { c.updates = make(chan Update, c.updatesBuffer) })
+	return c.updates
+}
+
+// State returns the last known controller state. It is safe to call from any
+// goroutine and keeps its value while the client is reconnecting.
+func (c *Client) State() gamepad.GamepadState {
+	return *c.state.Load()
+}
+
+// Connected reports whether the WebSocket connection is currently open.
+func (c *Client) Connected() bool {
+	return c.connected.Load()
+}
+
+// LocalTime converts a reading of the server's monotonic clock (Update.Mono)
+// to local time, using the last time_sync exchange with the server. The
+// result is accurate to about half of ClockRoundTrip. ok is false until the
+// first exchange of the current connection has completed, or if the server
+// does not support time_sync.
+func (c *Client) LocalTime(mono int64) (t time.Time, ok bool) {
+	s := c.clock.Load()
+	if s == nil {
+		return time.Time{}, false
+	}
+	return c.start.Add(time.Duration(mono-s.offset) * time.Microsecond), true
+}
+
+// ClockRoundTrip returns the network round trip of the last time_sync
+// exchange, which bounds the error of LocalTime. ok is false as for
+// LocalTime.
+func (c *Client) ClockRoundTrip() (rtt time.Duration, ok bool) {
+	s := c.clock.Load()
+	if s == nil {
+		return 0, false
+	}
+	return time.Duration(s.rtt) * time.Microsecond, true
+}
+
+// localMicros is the client's clock for time_sync: microseconds since New.
+func (c *Client) localMicros() int64 {
+	return time.Since(c.start).Microseconds()
+}
+
+// Run connects to the server and processes messages, reconnecting with
+// backoff whenever the connection fails or drops, until ctx is cancelled.
+// It returns ctx.Err() and closes the Updates channel, so it may only be
+// called once.
+func (c *Client) Run(ctx context.Context) error {
+	c.Updates() // allocate the channel
+	defer close(c.updates)
+
+	delay := c.reconnectInitial
+	for {
+		if err := c.runConn(ctx); err != nil {
+			slog.Debug("client: connect failed", "url", c.url, "error", err, "retry", delay)
+		} else {
+			delay = c.reconnectInitial // the server was reachable; start the backoff over
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(time.Duration(float64(delay)*1.5), c.reconnectMax)
+	}
+}
+
+// runConn performs one connection: handshake, player selection, clock
+// synchronisation, and the read loop until the connection closes or ctx is
+// cancelled. It returns an error
+// only if no connection could be established.
+func (c *Client) runConn(ctx context.Context) error {
+	h := &handler{client: c, ctx: ctx, player: 1}
+	conn, _, err := gws.NewClient(h, &gws.ClientOption{
+		Addr:             c.url,
+		RequestHeader:    c.header.Clone(),
+		HandshakeTimeout: handshakeTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.NetConn().Close() })
+	defer stop()
+
+	selectMsg, _ := json.Marshal(struct {
+		Type        string `json:"type"`
+		PlayerIndex int    `json:"playerIndex"`
+	}{"select_player", c.playerIndex})
+	if err := conn.WriteMessage(gws.OpcodeText, selectMsg); err != nil {
+		conn.NetConn().Close()
+		return fmt.Errorf("send select_player: %w", err)
+	}
+
+	c.clock.Store(nil) // the server's clock may have restarted
+	syncDone := make(chan struct{})
+	defer close(syncDone)
+	go h.syncClock(conn, syncDone)
+
+	c.connected.Store(true)
+	defer c.connected.Store(false)
+	slog.Debug("client: connected", "url", c.url, "player", c.playerIndex)
+	conn.ReadLoop()
+	return nil
+}
+
+// syncClock sends a time_sync command at once and then every
+// clockSyncInterval, so LocalTime follows drift between the clocks, until
+// done is closed. Servers that never replied (older versions reject the
+// command) are only asked once.
+func (h *handler) syncClock(conn *gws.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(clockSyncInterval)
+	defer ticker.Stop()
+	for {
+		msg, _ := json.Marshal(struct {
+			Type       string  `json:"type"`
+			ClientTime float64 `json:"clientTime"`
+		}{"time_sync", float64(h.client.localMicros())})
+		if err := conn.WriteMessage(gws.OpcodeText, msg); err != nil {
+			return
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		if h.client.clock.Load() == nil {
+			return
+		}
+	}
+}
+
+// handler is the gws event handler for one connection.
+type handler struct {
+	gws.BuiltinEventHandler
+	client *Client
+	ctx    context.Context
+	synced bool // a full snapshot has been received on this connection
+	player int  // player the server streams to this connection (1 until player_selected)
+}
+
+// OnMessage applies full and delta messages to the client state. It runs on
+// the connection's read goroutine, so blocking on a full Updates channel
+// applies backpressure to the socket.
+func (h *handler) OnMessage(socket *gws.Conn, message *gws.Message) {
+	defer message.Close()
+	var msg wireMessage
+	if err := json.Unmarshal(message.Bytes(), &msg); err != nil {
+		slog.Debug("client: ignoring malformed message", "error", err)
+		return
+	}
+
+	var next gamepad.GamepadState
+	switch msg.Type {
+	case "full":
+		if msg.Data == nil {
+			return
+		}
+		next = *msg.Data
+		h.synced = true
+	case "delta":
+		if !h.synced || msg.Changes == nil {
+			return
+		}
+		// Deltas carry no player index; the server only sends this
+		// connection the player it streams, which player_selected confirmed.
+		next = gamepad.ApplyDelta(*h.client.state.Load(), msg.Changes)
+		next.PlayerIndex = h.player
+	case "player_selected":
+		h.player = msg.PlayerIndex
+		return
+	case "time_sync":
+		if s := msg.Sync; s != nil {
+			t0, t3 := int64(s.ClientTime), h.client.localMicros()
+			h.client.clock.Store(&clockSample{
+				offset: ((s.ServerReceive - t0) + (s.ServerSend - t3)) / 2,
+				rtt:    (t3 - t0) - (s.ServerSend - s.ServerReceive),
+			})
+		}
+		return
+	default:
+		return
+	}
+
+	h.client.state.Store(&next)
+	select {
+	case h.client.updates <- Update{State: next, Seq: msg.Seq, Full: msg.Type == "full", Mono: msg.Mono}:
+	case <-h.ctx.Done():
+	}
+}
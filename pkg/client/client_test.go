@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/soar/inputview/internal/hub"
+	"github.com/soar/inputview/internal/server"
+	"github.com/soar/inputview/pkg/gamepad"
+)
+
+// trackingListener records accepted connections so a test can drop them.
+type trackingListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, c)
+		l.mu.Unlock()
+	}
+	return c, err
+}
+
+// dropAll closes every accepted connection, simulating a network failure.
+func (l *trackingListener) dropAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		c.Close()
+	}
+	l.conns = nil
+}
+
+// newTestServer serves a real hub + broadcaster pipeline on a loopback port
+// and returns the WebSocket URL, the reader to inject states into, and the
+// listener.
+func newTestServer(t *testing.T) (string, *gamepad.Reader, *trackingListener) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	reader := gamepad.NewReader()
+	h := hub.NewHub()
+	go h.Run(ctx)
+	b := hub.NewBroadcaster(h, reader.Changes(), nil)
+	go b.Run()
+	frontend := fstest.MapFS{"index.html": {Data: []byte("<html></html>")}}
+	srv := server.New(h, b, reader, nil, frontend, nil, t.TempDir(), "overlays", "keyboards", ":0")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl := &trackingListener{Listener: ln}
+	httpSrv := &http.Server{Handler: srv.Handler()}
+	go httpSrv.Serve(tl)
+	t.Cleanup(func() { httpSrv.Close() })
+	return "ws://" + ln.Addr().String() + "/ws", reader, tl
+}
+
+// nextUpdate waits for the next update or fails the test.
+func nextUpdate(t *testing.T, c *Client) Update {
+	t.Helper()
+	select {
+	case u, ok := <-c.Updates():
+		if !ok {
+			t.Fatal("Updates channel closed")
+		}
+		return u
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for update")
+	}
+	return Update{}
+}
+
+// TestClientAppliesDeltas verifies that the client starts from the initial
+// full snapshot and merges subsequent deltas into its local state.
+func TestClientAppliesDeltas(t *testing.T) {
+	url, reader, _ := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := New(url)
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	if u := nextUpdate(t, c); !u.Full || u.State.Connected {
+		t.Fatalf("first update = %+v, want full disconnected snapshot", u)
+	}
+	if !c.Connected() {
+		t.Error("Connected() = false after first update")
+	}
+
+	want := gamepad.GamepadState{Connected: true, ControllerType: "xbox", Name: "Pad", PlayerIndex: 1}
+	want.Buttons.A = true
+	reader.Inject(want)
+	u := nextUpdate(t, c)
+	if u.Full || u.State != want {
+		t.Errorf("update after inject = %+v, want delta producing %+v", u, want)
+	}
+
+	want.Sticks.Left.Position = gamepad.Vector{X: 0.5, Y: -0.5}
+	reader.Inject(want)
+	if u := nextUpdate(t, c); u.State != want {
+		t.Errorf("update after stick move = %+v, want %+v", u.State, want)
+	}
+	if got := c.State(); got != want {
+		t.Errorf("State() = %+v, want %+v", got, want)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if _, ok := <-c.Updates(); ok {
+		t.Error("Updates channel not closed after Run returned")
+	}
+}
+
+// TestClientReconnect verifies that a dropped connection is re-established
+// and the state is resynchronised from the new full snapshot, including
+// changes that happened while disconnected.
+func TestClientReconnect(t *testing.T) {
+	url, reader, ln := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := New(url)
+	c.SetReconnectDelay(10*time.Millisecond, 50*time.Millisecond)
+	go c.Run(ctx)
+	nextUpdate(t, c) // initial snapshot
+
+	ln.dropAll()
+	deadline := time.Now().Add(3 * time.Second)
+	for c.Connected() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Changed while the client was offline: only a resync can deliver it.
+	offline := gamepad.GamepadState{Connected: true, ControllerType: "playstation", Name: "Offline", PlayerIndex: 1}
+	reader.Inject(offline)
+
+	for {
+		u := nextUpdate(t, c)
+		if u.Full && u.State.Name == "Offline" {
+			break
+		}
+	}
+	if got := c.State(); got != offline {
+		t.Errorf("State() after reconnect = %+v, want %+v", got, offline)
+	}
+}
+
+// TestClientRetriesUnreachableServer verifies that Run keeps retrying while
+// the server is down and still returns promptly on cancel.
+func TestClientRetriesUnreachableServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // nothing listening
+
+	c := New("ws://" + addr + "/ws")
+	c.SetReconnectDelay(5*time.Millisecond, 20*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := c.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run() = %v, want context.DeadlineExceeded", err)
+	}
+	if c.Connected() {
+		t.Error("Connected() = true for an unreachable server")
+	}
+}
+
+// TestClientClockSync verifies the time_sync exchange after connect: the
+// server's monotonic clock of an update converts to about the local time it
+// arrived.
+func TestClientClockSync(t *testing.T) {
+	url, reader, _ := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := New(url)
+	go c.Run(ctx)
+	nextUpdate(t, c)
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if _, ok := c.ClockRoundTrip(); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no time_sync reply within 3s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	reader.Inject(gamepad.GamepadState{Connected: true, PlayerIndex: 1})
+	u := nextUpdate(t, c)
+	arrived := time.Now()
+	local, ok := c.LocalTime(u.Mono)
+	if u.Mono == 0 || !ok {
+		t.Fatalf("update mono = %d, LocalTime ok = %v", u.Mono, ok)
+	}
+	if d := arrived.Sub(local); d < -100*time.Millisecond || d > time.Second {
+		t.Errorf("LocalTime(mono) is %v before the update arrived, want within [-100ms, 1s]", d)
+	}
+}
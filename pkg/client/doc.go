@@ -0,0 +1,41 @@
+// Package client connects to an InputView server's WebSocket endpoint and
+// maintains a local copy of the streamed controller state. It is meant for
+// bots, recorders, and relays written in Go that want the state without
+// re-implementing the protocol.
+//
+// # Stable API
+//
+// The following are covered by semantic versioning:
+//
+//   - New, Client, the Set* configuration methods, Run, Updates, State,
+//     Connected, LocalTime, ClockRoundTrip.
+//   - Update.
+//
+// Everything unexported may change at any time.
+//
+// # Usage
+//
+//	c := client.New("ws://localhost:8080/ws")
+//	c.SetPlayerIndex(2)
+//	go c.Run(ctx)
+//	for u := range c.Updates() {
+//		fmt.Println(u.State.Buttons.A, u.State.Sticks.Left.Position)
+//	}
+//
+// Run reconnects with exponential backoff until its context is cancelled.
+// After every (re)connect the server sends a full snapshot, which replaces
+// the local state; "delta" messages are merged onto it with
+// gamepad.ApplyDelta, exactly as the browser frontend does. Deltas received
+// before the first snapshot of a connection are ignored. Deltas carry no
+// player index; merged states get the player the server confirmed with
+// player_selected (1 if the selection failed, as on the server).
+//
+// After every connect, and every 30 seconds while the server answers, the
+// client exchanges a time_sync command with the server to estimate the offset
+// between the server's monotonic clock and its own. LocalTime converts
+// Update.Mono with it, so relays and recorders on another machine can place
+// inputs on their local (e.g. video) timeline independently of the wall
+// clocks of both machines.
+//
+// Keyboard/mouse messages are not exposed.
+package client
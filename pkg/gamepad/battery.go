@@ -0,0 +1,131 @@
+package gamepad
+
+// Battery statuses reported in BatteryState.Status.
+const (
+	BatteryUnknown     = "unknown"     // the device has a battery but does not report its state
+	BatteryWired       = "wired"       // no battery in use (wired controller)
+	BatteryDischarging = "discharging" // running on battery
+	BatteryCharging    = "charging"    // battery charging from the cable
+	BatteryFull        = "full"        // battery full, on the cable
+)
+
+// BatteryState is the power state of a controller. The zero value means the
+// device does not report its power state (omitted on the wire).
+type BatteryState struct {
+	Status string `json:"status"` // one of the Battery* constants
+	Level  int    `json:"level"`  // charge in percent (0-100); 0 when Status is "wired" or "unknown"
+}
+
+// XInput battery types and levels (XINPUT_BATTERY_INFORMATION).
+const (
+	xiBatteryTypeDisconnected = 0x00
+	xiBatteryTypeWired        = 0x01
+	xiBatteryTypeUnknown      = 0xFF
+	xiBatteryLevelFull        = 0x03
+)
+
+// xinputBattery converts an XINPUT_BATTERY_INFORMATION to a BatteryState.
+// XInput reports only four levels (empty/low/medium/full) and no charging
+// flag, so wireless pads are always "discharging".
+func xinputBattery(batteryType, level uint8) BatteryState {
+	switch batteryType {
+	case xiBatteryTypeDisconnected:
+		return BatteryState{}
+	case xiBatteryTypeWired:
+		return BatteryState{Status: BatteryWired}
+	case xiBatteryTypeUnknown:
+		return BatteryState{Status: BatteryUnknown}
+	}
+	return BatteryState{Status: BatteryDischarging, Level: int(min(level, xiBatteryLevelFull)) * 100 / xiBatteryLevelFull}
+}
+
+// switchProBattery reads the battery nibble of a Switch Pro 0x30 (full mode)
+// report, byte 2: bits 5-7 level (0 empty … 4 full), bit 4 charging.
+// Simple-mode (0x3F) reports carry no battery information.
+func switchProBattery(report []byte) (BatteryState, bool) {
+	if len(report) < 3 || report[0] != switchProReportFull {
+		return BatteryState{}, false
+	}
+	b := report[2]
+	level := int(min(b>>5, 4)) * 25
+	switch {
+	case b&0x10 == 0:
+		return BatteryState{Status: BatteryDischarging, Level: level}, true
+	case level == 100:
+		return BatteryState{Status: BatteryFull, Level: level}, true
+	default:
+		return BatteryState{Status: BatteryCharging, Level: level}, true
+	}
+}
+
+// Sony controller product IDs whose input report layout sonyBattery knows.
+var (
+	dualShock4PIDs = map[uint16]bool{0x05c4: true, 0x09cc: true, 0x0ba0: true}
+	dualSensePIDs  = map[uint16]bool{0x0ce6: true, 0x0df2: true}
+)
+
+// sonyBattery reads the battery byte of a DualShock 4 or DualSense input
+// report (USB report 0x01, or the Bluetooth full reports 0x11 / 0x31, whose
+// payload starts 2 and 1 bytes later):
+//
+//   - DualShock 4: USB byte 30, low nibble level (0-10 on the cable, 0-9 on
+//     battery), bit 4 cable connected; above 10 on the cable means full.
+//   - DualSense: USB byte 53, low nibble level (0-10, x10 %), high nibble
+//     status (0 discharging, 1 charging, 2 full).
+func sonyBattery(productID uint16, report []byte) (BatteryState, bool) {
+	if len(report) == 0 {
+		return BatteryState{}, false
+	}
+	var offset int
+	switch {
+	case dualShock4PIDs[productID] && report[0] == 0x01:
+		offset = 30
+	case dualShock4PIDs[productID] && report[0] == 0x11:
+		offset = 32
+	case dualSensePIDs[productID] && report[0] == 0x01:
+		offset = 53
+	case dualSensePIDs[productID] && report[0] == 0x31:
+		offset = 54
+	default:
+		return BatteryState{}, false
+	}
+	if len(report) <= offset {
+		return BatteryState{}, false
+	}
+	b := report[offset]
+	level := min(int(b&0x0F)*10, 100)
+
+	if dualShock4PIDs[productID] {
+		switch {
+		case b&0x10 == 0:
+			return BatteryState{Status: BatteryDischarging, Level: min(int(b&0x0F)*100/9, 100)}, true
+		case b&0x0F > 10:
+			return BatteryState{Status: BatteryFull, Level: 100}, true
+		default:
+			return BatteryState{Status: BatteryCharging, Level: level}, true
+		}
+	}
+	switch b >> 4 {
+	case 0:
+		return BatteryState{Status: BatteryDischarging, Level: level}, true
+	case 1:
+		return BatteryState{Status: BatteryCharging, Level: level}, true
+	case 2:
+		return BatteryState{Status: BatteryFull, Level: 100}, true
+	default: // 0xA/0xB: charging error, temperature out of range
+		return BatteryState{Status: BatteryUnknown}, true
+	}
+}
+
+// hidBattery reads the power state from a raw HID input report of the
+// controllers whose report layout is known (Switch Pro full mode, DualShock 4,
+// DualSense). ok is false for other devices and reports.
+func hidBattery(vendorID, productID uint16, report []byte) (BatteryState, bool) {
+	switch vendorID {
+	case nintendoVendorID:
+		return switchProBattery(report)
+	case sonyVendorID:
+		return sonyBattery(productID, report)
+	}
+	return BatteryState{}, false
+}
@@ -0,0 +1,59 @@
+package gamepad
+
+import "testing"
+
+func TestXInputBattery(t *testing.T) {
+	tests := []struct {
+		batteryType, level uint8
+		want               BatteryState
+	}{
+		{xiBatteryTypeDisconnected, 0, BatteryState{}},
+		{xiBatteryTypeWired, 0, BatteryState{Status: BatteryWired}},
+		{xiBatteryTypeUnknown, 0, BatteryState{Status: BatteryUnknown}},
+		{0x02, 0, BatteryState{Status: BatteryDischarging, Level: 0}},  // alkaline, empty
+		{0x03, 2, BatteryState{Status: BatteryDischarging, Level: 66}}, // NiMH, medium
+		{0x03, 3, BatteryState{Status: BatteryDischarging, Level: 100}},
+	}
+	for _, tt := range tests {
+		if got := xinputBattery(tt.batteryType, tt.level); got != tt.want {
+			t.Errorf("xinputBattery(%#x, %d) = %+v, want %+v", tt.batteryType, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestHIDBattery(t *testing.T) {
+	report := func(id byte, size, offset int, b byte) []byte {
+		r := make([]byte, size)
+		r[0] = id
+		r[offset] = b
+		return r
+	}
+	tests := []struct {
+		name     string
+		vid, pid uint16
+		report   []byte
+		want     BatteryState
+		wantOK   bool
+	}{
+		{"switch pro full", nintendoVendorID, 0x2009, report(0x30, 49, 2, 0x90), BatteryState{Status: BatteryFull, Level: 100}, true},
+		{"switch pro charging", nintendoVendorID, 0x2009, report(0x30, 49, 2, 0x30), BatteryState{Status: BatteryCharging, Level: 25}, true},
+		{"switch pro battery", nintendoVendorID, 0x2009, report(0x30, 49, 2, 0x60), BatteryState{Status: BatteryDischarging, Level: 75}, true},
+		{"switch pro simple mode", nintendoVendorID, 0x2009, report(0x3F, 12, 2, 0x90), BatteryState{}, false},
+		{"ds4 usb battery", sonyVendorID, 0x09cc, report(0x01, 64, 30, 0x05), BatteryState{Status: BatteryDischarging, Level: 55}, true},
+		{"ds4 usb charging", sonyVendorID, 0x09cc, report(0x01, 64, 30, 0x16), BatteryState{Status: BatteryCharging, Level: 60}, true},
+		{"ds4 usb full", sonyVendorID, 0x09cc, report(0x01, 64, 30, 0x1B), BatteryState{Status: BatteryFull, Level: 100}, true},
+		{"ds4 bluetooth", sonyVendorID, 0x05c4, report(0x11, 78, 32, 0x09), BatteryState{Status: BatteryDischarging, Level: 100}, true},
+		{"dualsense usb full", sonyVendorID, 0x0ce6, report(0x01, 64, 53, 0x2A), BatteryState{Status: BatteryFull, Level: 100}, true},
+		{"dualsense bluetooth charging", sonyVendorID, 0x0ce6, report(0x31, 78, 54, 0x18), BatteryState{Status: BatteryCharging, Level: 80}, true},
+		{"dualsense charging error", sonyVendorID, 0x0ce6, report(0x01, 64, 53, 0xA0), BatteryState{Status: BatteryUnknown}, true},
+		{"dualsense short report", sonyVendorID, 0x0ce6, report(0x01, 10, 5, 0), BatteryState{}, false},
+		{"dualshock 3", sonyVendorID, 0x0268, report(0x01, 64, 30, 0x05), BatteryState{}, false},
+		{"generic hid", 0x1234, 0x5678, report(0x01, 64, 30, 0x05), BatteryState{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := hidBattery(tt.vid, tt.pid, tt.report)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: hidBattery() = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
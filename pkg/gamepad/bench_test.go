@@ -0,0 +1,83 @@
+package gamepad
+
+import (
+	"math"
+	"testing"
+)
+
+// Benchmarks for the per-poll hot path. Run with:
+//
+//	go test -run '^$' -bench . -benchmem ./pkg/gamepad/ ./internal/hub/
+//
+// and compare runs with benchstat to catch regressions.
+
+// benchSink keeps results alive so the compiler cannot elide benchmarked work.
+var benchSink any
+
+// benchStatePair returns two states differing in sticks, triggers, and one
+// button — the typical delta produced by an active player.
+func benchStatePair() (GamepadState, GamepadState) {
+	a := GamepadState{Connected: true, ControllerType: "xbox", Name: "Xbox Controller", PlayerIndex: 1}
+	b := a
+	b.Buttons.A = true
+	b.Sticks.Left.Position = Vector{X: 0.5, Y: -0.25}
+	b.Triggers.RT.Value = 0.75
+	return a, b
+}
+
+// BenchmarkComputeDelta measures delta computation for changed and unchanged states.
+func BenchmarkComputeDelta(b *testing.B) {
+	old, changed := benchStatePair()
+	b.Run("changed", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			benchSink = ComputeDelta(old, changed)
+		}
+	})
+	b.Run("unchanged", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			benchSink = ComputeDelta(old, old)
+		}
+	})
+}
+
+// BenchmarkNormalization measures the axis normalization helpers used by the
+// XInput and HID paths.
+func BenchmarkNormalization(b *testing.B) {
+	b.Run("normalizeAxis+deadzone", func(b *testing.B) {
+		var v float64
+		for i := 0; b.Loop(); i++ {
+			v += applyDeadzone(normalizeAxis(int16(i)), 0.05)
+		}
+		benchSink = v
+	})
+	b.Run("normalizeHIDAxis", func(b *testing.B) {
+		var v float64
+		for i := 0; b.Loop(); i++ {
+			v += normalizeHIDAxis(uint32(i&0xFFFF), 0, math.MaxUint16, false)
+		}
+		benchSink = v
+	})
+	b.Run("normalize12bit", func(b *testing.B) {
+		var v float64
+		for i := 0; b.Loop(); i++ {
+			v += normalize12bit(uint16(i & 0xFFF))
+		}
+		benchSink = v
+	})
+}
+
+// BenchmarkParseSwitchProFull measures the custom Nintendo 0x30 report parser.
+func BenchmarkParseSwitchProFull(b *testing.B) {
+	report := make([]byte, 49)
+	report[0] = 0x30
+	report[3] = 0x08 // A
+	// Centered sticks (12-bit 0x800 packed little-endian).
+	report[6], report[7], report[8] = 0x00, 0x08, 0x80
+	report[9], report[10], report[11] = 0x00, 0x08, 0x80
+	b.ReportAllocs()
+	for b.Loop() {
+		benchSink, _ = parseSwitchProFull("Switch Pro Controller", report, uniformDeadzones(0.05))
+	}
+}
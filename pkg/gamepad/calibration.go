@@ -0,0 +1,119 @@
+package gamepad
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// minCalibrationSpan is the fraction of an axis's logical range a trigger
+// must have been seen to travel before its learned range replaces the logical
+// one, so that a trigger pulled halfway on its first press does not read as
+// fully pressed.
+const minCalibrationSpan = 0.25
+
+// TriggerRange is the raw HID range learned for one trigger or pedal axis:
+// the lowest and highest values it has reported.
+type TriggerRange struct {
+	Min int32 `json:"min"`
+	Max int32 `json:"max"`
+}
+
+// triggerCalibration holds the learned trigger ranges by device and HID axis
+// usage. It is shared by the input goroutine, which widens the ranges, and the
+// callers of LoadTriggerCalibration and SaveTriggerCalibration.
+type triggerCalibration struct {
+	mu     sync.Mutex
+	ranges map[deviceKey]map[uint16]TriggerRange
+}
+
+// observe widens the range of the axis usage of device k to include raw and
+// returns the range to read the axis over: the learned one once it spans
+// minCalibrationSpan of the logical range logMin to logMax, else the logical
+// one.
+func (c *triggerCalibration) observe(k deviceKey, usage uint16, raw, logMin, logMax int32) (int32, int32) {
+	c.mu.Lock()
+	axes := c.ranges[k]
+	if axes == nil {
+		if c.ranges == nil {
+			c.ranges = make(map[deviceKey]map[uint16]TriggerRange)
+		}
+		axes = make(map[uint16]TriggerRange)
+		c.ranges[k] = axes
+	}
+	r, ok := axes[usage]
+	if !ok {
+		r = TriggerRange{Min: raw, Max: raw}
+	}
+	r.Min, r.Max = min(r.Min, raw), max(r.Max, raw)
+	axes[usage] = r
+	c.mu.Unlock()
+
+	if float64(r.Max)-float64(r.Min) < minCalibrationSpan*(float64(logMax)-float64(logMin)) {
+		return logMin, logMax
+	}
+	return r.Min, r.Max
+}
+
+// SetTriggerCalibration makes the Reader learn the range each trigger and
+// pedal of a HID controller actually reports, and read it over that range
+// instead of the one its HID descriptor declares, for controllers whose
+// triggers stop short or report only part of the range (0..32767 of
+// -32768..32767). A new trigger reads over the declared range until it has
+// traveled a quarter of it. The ranges are kept by VID/PID for the Reader's
+// lifetime; SaveTriggerCalibration and LoadTriggerCalibration carry them over
+// restarts. XInput triggers have a fixed range and are not calibrated, nor
+// are replayed captures (see ReplayCapture). Off by default. Must be called
+// before Run.
+func (r *Reader) SetTriggerCalibration(enabled bool) { r.calibrate = enabled }
+
+// LoadTriggerCalibration reads trigger ranges written by
+// SaveTriggerCalibration, replacing the learned ones of the devices it lists.
+// Devices keep learning from them. Must be called before Run.
+func (r *Reader) LoadTriggerCalibration(rd io.Reader) error {
+	var file map[string]map[uint16]TriggerRange
+	if err := json.NewDecoder(rd).Decode(&file); err != nil {
+		return fmt.Errorf("trigger calibration: %w", err)
+	}
+	c := &r.calibration
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for guid, axes := range file {
+		vid, pid, ok := parseSDLGUID(guid)
+		if !ok {
+			return fmt.Errorf("trigger calibration: %q is not the SDL GUID of a VID/PID", guid)
+		}
+		for usage, tr := range axes {
+			if tr.Min > tr.Max {
+				return fmt.Errorf("trigger calibration: %s axis 0x%02x: min %d > max %d", guid, usage, tr.Min, tr.Max)
+			}
+		}
+		if c.ranges == nil {
+			c.ranges = make(map[deviceKey]map[uint16]TriggerRange)
+		}
+		c.ranges[deviceKey{VendorID: vid, ProductID: pid}] = axes
+	}
+	return nil
+}
+
+// SaveTriggerCalibration writes the learned trigger ranges as JSON: SDL GUID
+// (see ControllerInfo.GUID) → HID axis usage → TriggerRange. It may be called
+// while Run is running.
+func (r *Reader) SaveTriggerCalibration(w io.Writer) error {
+	c := &r.calibration
+	c.mu.Lock()
+	file := make(map[string]map[uint16]TriggerRange, len(c.ranges))
+	for k, axes := range c.ranges {
+		if guid := sdlGUID(k.VendorID, k.ProductID); guid != "" && len(axes) > 0 {
+			file[guid] = axes
+		}
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
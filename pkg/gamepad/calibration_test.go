@@ -0,0 +1,75 @@
+package gamepad
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestTriggerCalibrationObserve verifies that a trigger reads over its
+// logical range until it has traveled minCalibrationSpan of it, and over the
+// learned range after that.
+func TestTriggerCalibrationObserve(t *testing.T) {
+	var c triggerCalibration
+	k := deviceKey{0x1234, 0x5678}
+	// A trigger reporting only 0..32767 of a -32768..32767 field.
+	for _, tt := range []struct {
+		raw              int32
+		wantMin, wantMax int32
+	}{
+		{0, -32768, 32767},     // at rest: nothing learned yet
+		{10000, -32768, 32767}, // 15% of the range
+		{32767, 0, 32767},      // half the range: learned
+		{200, 0, 32767},
+		{-100, -100, 32767}, // widens
+	} {
+		gotMin, gotMax := c.observe(k, hidUsageZ, tt.raw, -32768, 32767)
+		if gotMin != tt.wantMin || gotMax != tt.wantMax {
+			t.Errorf("observe(%d) = %d..%d, want %d..%d", tt.raw, gotMin, gotMax, tt.wantMin, tt.wantMax)
+		}
+	}
+	if v := normalizeHIDAxis(16384, 0, 32767, true); v < 0.49 || v > 0.51 {
+		t.Errorf("half press over the learned range = %g, want 0.5", v)
+	}
+	// Other axes and devices learn on their own.
+	if gotMin, gotMax := c.observe(k, hidUsageRz, 500, 0, 1023); gotMin != 0 || gotMax != 1023 {
+		t.Errorf("observe(other axis) = %d..%d, want the logical range", gotMin, gotMax)
+	}
+}
+
+// TestReaderTriggerCalibrationRoundTrip verifies that saved ranges load back
+// under the same device, and that bad files are rejected.
+func TestReaderTriggerCalibrationRoundTrip(t *testing.T) {
+	r := NewReader()
+	k := deviceKey{0x054c, 0x0ce6}
+	r.calibration.observe(k, hidUsageZ, 12, 0, 255)
+	r.calibration.observe(k, hidUsageZ, 240, 0, 255)
+	r.calibration.observe(deviceKey{}, hidUsageZ, 5, 0, 255) // no GUID: not saved
+
+	var buf bytes.Buffer
+	if err := r.SaveTriggerCalibration(&buf); err != nil {
+		t.Fatalf("SaveTriggerCalibration() error = %v", err)
+	}
+	want := "{\n  \"030000004c050000e60c000000000000\": {\n    \"50\": {\n      \"min\": 12,\n      \"max\": 240\n    }\n  }\n}\n"
+	if buf.String() != want {
+		t.Errorf("saved =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	loaded := NewReader()
+	if err := loaded.LoadTriggerCalibration(&buf); err != nil {
+		t.Fatalf("LoadTriggerCalibration() error = %v", err)
+	}
+	if gotMin, gotMax := loaded.calibration.observe(k, hidUsageZ, 100, 0, 255); gotMin != 12 || gotMax != 240 {
+		t.Errorf("loaded range = %d..%d, want 12..240", gotMin, gotMax)
+	}
+
+	for _, bad := range []string{
+		`[]`,
+		`{"xinput":{"50":{"min":0,"max":1}}}`,
+		`{"030000004c050000e60c000000000000":{"50":{"min":9,"max":1}}}`,
+	} {
+		if err := NewReader().LoadTriggerCalibration(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadTriggerCalibration(%s) error = nil, want an error", bad)
+		}
+	}
+}
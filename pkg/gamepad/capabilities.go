@@ -0,0 +1,58 @@
+package gamepad
+
+// Vendor IDs with known controller features (Nintendo: nintendoVendorID).
+const (
+	microsoftVendorID = uint16(0x045e)
+	sonyVendorID      = uint16(0x054c)
+)
+
+// XInput exposes a fixed XINPUT_GAMEPAD layout: 2 sticks + 2 triggers, 10
+// buttons + Guide, and the d-pad as a hat.
+const (
+	xinputNumAxes    = 6
+	xinputNumButtons = 11
+	xinputNumHats    = 1
+)
+
+// knownCapabilities returns the feature flags of a controller. XInput
+// devices always support vibration and XInputGetBatteryInformation. For HID
+// devices the flags are only set when the vendor is known: Sony (DualShock 3
+// and later have motion sensors; the touchpad is detected from the built-in
+// mapping; the battery is readable on DualShock 4 / DualSense), Nintendo
+// (Switch controllers), and Microsoft (Xbox pads over Bluetooth HID, whose
+// battery report is not parsed). Unknown devices report no features. Counts
+// are left zero.
+func knownCapabilities(vendorID, productID uint16, xinput bool) Capabilities {
+	if xinput {
+		return Capabilities{HasRumble: true, BatteryReadable: true}
+	}
+	switch vendorID {
+	case sonyVendorID:
+		return Capabilities{
+			HasRumble:       true,
+			HasGyro:         true,
+			HasTouchpad:     mappingHasTouchpad(GetMapping(vendorID, productID)),
+			BatteryReadable: dualShock4PIDs[productID] || dualSensePIDs[productID],
+		}
+	case nintendoVendorID:
+		return Capabilities{HasRumble: true, HasGyro: true, BatteryReadable: true}
+	case microsoftVendorID:
+		return Capabilities{HasRumble: true}
+	}
+	return Capabilities{}
+}
+
+// mappingHasTouchpad reports whether m maps a touchpad click button.
+func mappingHasTouchpad(m *DeviceMapping) bool {
+	for _, b := range m.Buttons {
+		if b.Target == "touchpad" {
+			return true
+		}
+	}
+	for _, target := range m.HIDButtons {
+		if target == "touchpad" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package gamepad
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestKnownCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		vid, pid uint16
+		xinput   bool
+		want     Capabilities
+	}{
+		{"xinput", 0x045e, 0x028e, true, Capabilities{HasRumble: true, BatteryReadable: true}},
+		{"dualsense", 0x054c, 0x0ce6, false, Capabilities{HasRumble: true, HasGyro: true, HasTouchpad: true, BatteryReadable: true}},
+		{"dualshock 3", 0x054c, 0x0268, false, Capabilities{HasRumble: true, HasGyro: true}},
+		{"switch pro", 0x057e, 0x2009, false, Capabilities{HasRumble: true, HasGyro: true, BatteryReadable: true}},
+		{"xbox over bluetooth", 0x045e, 0x0b13, false, Capabilities{HasRumble: true}},
+		{"unknown", 0x1234, 0x5678, false, Capabilities{}},
+	}
+	for _, tt := range tests {
+		if got := knownCapabilities(tt.vid, tt.pid, tt.xinput); got != tt.want {
+			t.Errorf("%s: knownCapabilities(%04x, %04x) = %+v, want %+v", tt.name, tt.vid, tt.pid, got, tt.want)
+		}
+	}
+}
+
+// TestCapabilitiesOmitted verifies that a disconnected state carries no
+// capabilities block while a connected one does.
+func TestCapabilitiesOmitted(t *testing.T) {
+	b, err := json.Marshal(GamepadState{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "capabilities") {
+		t.Errorf("zero state encodes capabilities: %s", b)
+	}
+	b, err = json.Marshal(GamepadState{Connected: true, Capabilities: Capabilities{NumButtons: 11}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"capabilities":{"hasRumble":false,"hasGyro":false,"hasTouchpad":false,"numButtons":11,"numAxes":0,"batteryReadable":false}`) {
+		t.Errorf("connected state = %s, want a capabilities block", b)
+	}
+}
@@ -0,0 +1,358 @@
+package gamepad
+
+// capture.go — raw input capture and replay for bug reproduction.
+//
+// A capture is a JSON Lines file with one captureRecord per raw input event,
+// written before any mapping or normalisation takes place: XInput states
+// straight from XInputGetStateEx and HID reports straight from WM_INPUT, plus
+// the device identity (VID/PID, preparsed data) needed to rebuild the parser.
+// ReplayCapture feeds such a file back through the same conversion code, so a
+// "my controller maps wrong" report can be reproduced without the hardware and
+// checked in under testdata/captures as a regression test.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Capture record event kinds.
+const (
+	captureConnect    = "connect"
+	captureInput      = "input"
+	captureDisconnect = "disconnect"
+)
+
+// captureRecord is one line of a capture file.
+type captureRecord struct {
+	T         int64  `json:"t"`      // microseconds since capture start
+	Event     string `json:"event"`  // captureConnect, captureInput, captureDisconnect
+	Source    string `json:"source"` // "xinput" or "hid"
+	Device    uint64 `json:"device"` // XInput slot or HID device handle
+	VendorID  uint16 `json:"vid,omitempty"`
+	ProductID uint16 `json:"pid,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Start     int64  `json:"start,omitempty"` // connect only: Unix microseconds of capture start (t = 0)
+
+	// Data is the raw input: the 12-byte XINPUT_GAMEPAD (little-endian) for
+	// XInput, or the WM_INPUT HID report data (possibly batched) for HID.
+	Data       hexBytes `json:"data,omitempty"`
+	ReportSize uint32   `json:"report_size,omitempty"` // HID only; size of one report in Data
+
+	// Preparsed is the device's PHIDP_PREPARSED_DATA blob (HID connect only;
+	// empty for Nintendo controllers, which bypass HidP_*).
+	Preparsed hexBytes `json:"preparsed,omitempty"`
+}
+
+// hexBytes is a byte slice that encodes as a hex string in JSON, which keeps
+// captures readable and diffable (report byte offsets line up visually).
+type hexBytes []byte
+
+func (b hexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+func (b *hexBytes) UnmarshalText(text []byte) error {
+	d, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	*b = d
+	return nil
+}
+
+// captureWriter serialises capture records to a writer. A nil *captureWriter
+// is valid and discards everything, so the input paths can call it
+// unconditionally.
+type captureWriter struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	start   time.Time
+	failed  bool // a write error has been logged; stay quiet afterwards
+	stopped bool // StopCapture or StartCapture replaced this capture; write nothing more
+
+	// hidSeen records HID handles whose connect record has been written (the
+	// first WM_INPUT may arrive before, or without, a device-change event).
+	hidSeen map[uint64]struct{}
+
+	// xinputSeen records XInput slots whose connect record has been written
+	// (a capture started on a running Reader finds its controllers connected).
+	xinputSeen map[uint32]struct{}
+
+	// xinputPacket is the last recorded XInput packet number per slot. XInput
+	// only bumps it when the state changes, so unchanged polls are skipped.
+	xinputPacket map[uint32]uint32
+}
+
+// SetCaptureWriter records every raw input event (before mapping) to w as JSON
+// Lines, for replay with ReplayCapture. Writes happen synchronously on the
+// input goroutines, so w should be a plain file rather than anything slow.
+// Must be called before Run; StartCapture begins a capture on a running
+// Reader.
+func (r *Reader) SetCaptureWriter(w io.Writer) {
+	r.capture.Store(newCaptureWriter(w))
+}
+
+// StartCapture begins recording raw input events to w like SetCaptureWriter,
+// while the Reader runs. A capture in progress is stopped first. Controllers
+// that are already connected get their connect record with their next input.
+func (r *Reader) StartCapture(w io.Writer) {
+	r.capture.Swap(newCaptureWriter(w)).stop()
+}
+
+// StopCapture ends the capture in progress, if any. Nothing is written to its
+// writer once StopCapture returns, so the caller may close it.
+func (r *Reader) StopCapture() {
+	r.capture.Swap(nil).stop()
+}
+
+// Capturing reports whether raw input events are being recorded.
+func (r *Reader) Capturing() bool {
+	return r.capture.Load() != nil
+}
+
+func newCaptureWriter(w io.Writer) *captureWriter {
+	return &captureWriter{
+		enc:          json.NewEncoder(w),
+		start:        time.Now(),
+		hidSeen:      make(map[uint64]struct{}),
+		xinputSeen:   make(map[uint32]struct{}),
+		xinputPacket: make(map[uint32]uint32),
+	}
+}
+
+// stop makes c write nothing more. The input goroutines may still hold c, so
+// it waits for a write in progress.
+func (c *captureWriter) stop() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+}
+
+// writeLocked timestamps and writes rec. Caller must hold c.mu.
+func (c *captureWriter) writeLocked(rec captureRecord) {
+	if c.stopped {
+		return
+	}
+	rec.T = time.Since(c.start).Microseconds()
+	if rec.Event == captureConnect {
+		rec.Start = c.start.UnixMicro()
+	}
+	if err := c.enc.Encode(rec); err != nil && !c.failed {
+		c.failed = true
+		slog.Warn("capture: write failed; further errors suppressed", "err", err)
+	}
+}
+
+// xinputConnect records a newly detected XInput controller.
+func (c *captureWriter) xinputConnect(slot uint32, vid, pid uint16) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.xinputPacket, slot)
+	c.xinputSeen[slot] = struct{}{}
+	c.writeLocked(captureRecord{Event: captureConnect, Source: "xinput", Device: uint64(slot), VendorID: vid, ProductID: pid})
+}
+
+// xinputInput records a polled XInput state if it differs from the last one,
+// preceded by a connect record if the controller was connected before the
+// capture started.
+func (c *captureWriter) xinputInput(slot uint32, vid, pid uint16, xs *xinputState) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.xinputPacket[slot]; ok && last == xs.PacketNumber {
+		return
+	}
+	if _, ok := c.xinputSeen[slot]; !ok {
+		c.xinputSeen[slot] = struct{}{}
+		c.writeLocked(captureRecord{Event: captureConnect, Source: "xinput", Device: uint64(slot), VendorID: vid, ProductID: pid})
+	}
+	c.xinputPacket[slot] = xs.PacketNumber
+	data, _ := binary.Append(nil, binary.LittleEndian, xs.Gamepad)
+	c.writeLocked(captureRecord{Event: captureInput, Source: "xinput", Device: uint64(slot), Data: data})
+}
+
+// hidInput records a raw HID report, preceded by a connect record carrying the
+// device identity the first time hDevice is seen.
+func (c *captureWriter) hidInput(hDevice uintptr, vid, pid uint16, name string, preparsed, rawData []byte, reportSize uint32) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dev := uint64(hDevice)
+	if _, ok := c.hidSeen[dev]; !ok {
+		c.hidSeen[dev] = struct{}{}
+		c.writeLocked(captureRecord{Event: captureConnect, Source: "hid", Device: dev, VendorID: vid, ProductID: pid, Name: name, Preparsed: preparsed})
+	}
+	c.writeLocked(captureRecord{Event: captureInput, Source: "hid", Device: dev, Data: rawData, ReportSize: reportSize})
+}
+
+// disconnect records a controller removal, unless the controller has no
+// connect record in this capture.
+func (c *captureWriter) disconnect(source string, device uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seen := false
+	if source == "hid" {
+		_, seen = c.hidSeen[device]
+		delete(c.hidSeen, device)
+	} else {
+		_, seen = c.xinputSeen[uint32(device)]
+		delete(c.xinputSeen, uint32(device))
+	}
+	if !seen {
+		return
+	}
+	c.writeLocked(captureRecord{Event: captureDisconnect, Source: source, Device: device})
+}
+
+// hidReportParser parses one HID input report for a replayed device; see
+// newHIDReplayParser.
+type hidReportParser func(rawData []byte, dz Deadzones) (GamepadState, bool)
+
+// replayDevice is the per-device replay state.
+type replayDevice struct {
+	info  *joystickInfo   // XInput devices
+	parse hidReportParser // HID devices
+	prev  GamepadState
+}
+
+// ReplayCapture reads a capture written via SetCaptureWriter and feeds each
+// raw input event through the same conversion code the live reader uses,
+// calling fn with the capture timestamp and the resulting state whenever a
+// device's state changes. dz is the stick and trigger deadzone to apply.
+//
+// Devices are replayed independently: active-controller selection and player
+// indices are not reproduced (PlayerIndex is always 0). Generic HID reports
+// are parsed with HidP_* and can only be replayed on Windows; Nintendo and
+// XInput captures replay on every platform.
+func ReplayCapture(rd io.Reader, dz float64, fn func(at time.Duration, s GamepadState)) error {
+	dzs := uniformDeadzones(dz)
+	devices := make(map[string]*replayDevice)
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 0, 64*1024), 4<<20) // preparsed blobs can be several KiB
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec captureRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return fmt.Errorf("capture line %d: %w", line, err)
+		}
+		id := fmt.Sprintf("%s/%d", rec.Source, rec.Device)
+		at := time.Duration(rec.T) * time.Microsecond
+
+		switch rec.Event {
+		case captureConnect:
+			dev, err := newReplayDevice(rec)
+			if err != nil {
+				return fmt.Errorf("capture line %d: %w", line, err)
+			}
+			devices[id] = dev
+
+		case captureDisconnect:
+			delete(devices, id)
+
+		case captureInput:
+			dev := devices[id]
+			if dev == nil {
+				return fmt.Errorf("capture line %d: input for %s before its connect record", line, id)
+			}
+			var s GamepadState
+			switch rec.Source {
+			case "xinput":
+				var xs xinputState
+				if _, err := binary.Decode(rec.Data, binary.LittleEndian, &xs.Gamepad); err != nil {
+					return fmt.Errorf("capture line %d: XInput data: %w", line, err)
+				}
+				s = convertXInputState(&xs, dev.info, dzs)
+			default:
+				var ok bool
+				if s, ok = dev.parse(lastHIDReport(rec.Data, rec.ReportSize), dzs); !ok {
+					continue
+				}
+			}
+			if ComputeDelta(dev.prev, s).IsEmpty() {
+				continue
+			}
+			dev.prev = s
+			fn(at, s)
+
+		default:
+			return fmt.Errorf("capture line %d: unknown event %q", line, rec.Event)
+		}
+	}
+	return sc.Err()
+}
+
+// CaptureStart returns the wall-clock time a capture written via
+// SetCaptureWriter started (its t = 0), from the first connect record that
+// carries it. Captures recorded before connect records had a start time
+// return an error.
+func CaptureStart(rd io.Reader) (time.Time, error) {
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec captureRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return time.Time{}, fmt.Errorf("capture line %d: %w", line, err)
+		}
+		if rec.Event == captureConnect && rec.Start != 0 {
+			return time.UnixMicro(rec.Start), nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("capture has no start time (recorded by an older version)")
+}
+
+// newReplayDevice rebuilds a device's conversion state from its connect record.
+func newReplayDevice(rec captureRecord) (*replayDevice, error) {
+	switch rec.Source {
+	case "xinput":
+		mapping := xboxMapping
+		vidPID := ""
+		if rec.VendorID != 0 || rec.ProductID != 0 {
+			mapping = GetMapping(rec.VendorID, rec.ProductID)
+			vidPID = fmt.Sprintf("VID_%04X&PID_%04X", rec.VendorID, rec.ProductID)
+		}
+		return &replayDevice{info: &joystickInfo{
+			mapping:    mapping,
+			name:       buildControllerName(mapping.Name, vidPID),
+			vidPID:     vidPID,
+			sourceType: "xinput",
+			xinputSlot: uint32(rec.Device),
+			devKey:     deviceKey{VendorID: rec.VendorID, ProductID: rec.ProductID},
+		}}, nil
+	case "hid":
+		parse, err := newHIDReplayParser(rec.VendorID, rec.ProductID, rec.Preparsed)
+		if err != nil {
+			return nil, fmt.Errorf("HID device VID_%04X&PID_%04X: %w", rec.VendorID, rec.ProductID, err)
+		}
+		return &replayDevice{parse: parse}, nil
+	default:
+		return nil, fmt.Errorf("unknown source %q", rec.Source)
+	}
+}
@@ -0,0 +1,203 @@
+package gamepad
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/captures/*.golden from the current replay output")
+
+// replayLine is one line of a .golden file: the state ReplayCapture produced
+// and the capture timestamp it was produced at.
+type replayLine struct {
+	T     int64        `json:"t"`
+	State GamepadState `json:"state"`
+}
+
+// replayFile replays a capture and returns the produced states as .golden lines.
+func replayFile(t *testing.T, path string) []byte {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	err = ReplayCapture(f, 0.05, func(at time.Duration, s GamepadState) {
+		if err := enc.Encode(replayLine{T: at.Microseconds(), State: s}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("ReplayCapture: %v", err)
+	}
+	return out.Bytes()
+}
+
+// TestReplayCaptures replays every capture under testdata/captures and compares
+// the resulting states with the matching .golden file. To turn a bug report
+// into a regression test, drop the user's --capture-raw file in that directory,
+// fix the mapping, and regenerate with: go test ./pkg/gamepad -run TestReplayCaptures -update
+func TestReplayCaptures(t *testing.T) {
+	captures, err := filepath.Glob(filepath.Join("testdata", "captures", "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captures) == 0 {
+		t.Fatal("no captures found under testdata/captures")
+	}
+	for _, path := range captures {
+		name := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		t.Run(name, func(t *testing.T) {
+			got := replayFile(t, path)
+			golden := strings.TrimSuffix(path, ".jsonl") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("replay of %s differs from %s:\ngot:\n%s\nwant:\n%s", path, golden, got, want)
+			}
+		})
+	}
+}
+
+// TestCaptureRoundTrip verifies that records written by the capture hooks
+// replay into the states the live XInput path would produce, and that
+// unchanged XInput packets are not recorded twice.
+func TestCaptureRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewReader()
+	r.SetCaptureWriter(&buf)
+
+	c := r.capture.Load()
+	c.xinputConnect(1, 0x045e, 0x028e)
+	xs := xinputState{PacketNumber: 7}
+	xs.Gamepad.Buttons = xiA | xiDpadUp
+	xs.Gamepad.LeftTrigger = 255
+	c.xinputInput(1, 0x045e, 0x028e, &xs)
+	c.xinputInput(1, 0x045e, 0x028e, &xs) // same packet number: skipped
+	c.disconnect("xinput", 1)
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Fatalf("capture has %d lines, want 3:\n%s", lines, buf.String())
+	}
+
+	if start, err := CaptureStart(bytes.NewReader(buf.Bytes())); err != nil || !start.Equal(c.start.Truncate(time.Microsecond)) {
+		t.Errorf("CaptureStart() = %v, %v; want %v", start, err, c.start)
+	}
+
+	var got []GamepadState
+	if err := ReplayCapture(&buf, 0, func(_ time.Duration, s GamepadState) { got = append(got, s) }); err != nil {
+		t.Fatalf("ReplayCapture: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("replay produced %d states, want 1", len(got))
+	}
+	mapping := GetMapping(0x045e, 0x028e)
+	info := &joystickInfo{mapping: mapping, name: buildControllerName(mapping.Name, "VID_045E&PID_028E")}
+	want := convertXInputState(&xs, info, Deadzones{})
+	if !ComputeDelta(want, got[0]).IsEmpty() || got[0].Name != want.Name {
+		t.Errorf("replayed state = %+v, want %+v", got[0], want)
+	}
+}
+
+// TestStartCapture verifies that a capture started on a running Reader writes
+// a connect record before the first input of a controller that was already
+// connected, skips the removal of one it never recorded, and that nothing is
+// written after StopCapture.
+func TestStartCapture(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewReader()
+	r.StartCapture(&buf)
+	if !r.Capturing() {
+		t.Fatal("Capturing() = false after StartCapture")
+	}
+	c := r.capture.Load()
+	xs := xinputState{PacketNumber: 3}
+	xs.Gamepad.Buttons = xiB
+	c.xinputInput(0, 0x045e, 0x028e, &xs)
+	c.disconnect("xinput", 2)
+	c.disconnect("hid", 0x1234)
+
+	r.StopCapture()
+	if r.Capturing() {
+		t.Error("Capturing() = true after StopCapture")
+	}
+	xs.PacketNumber++
+	c.xinputInput(0, 0x045e, 0x028e, &xs) // an input goroutine still holding c
+	c.disconnect("xinput", 0)
+
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec captureRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("capture line %q: %v", line, err)
+		}
+		events = append(events, rec.Event)
+	}
+	if want := []string{captureConnect, captureInput}; !slices.Equal(events, want) {
+		t.Fatalf("capture events = %v, want %v:\n%s", events, want, buf.String())
+	}
+	var got []GamepadState
+	if err := ReplayCapture(&buf, 0, func(_ time.Duration, s GamepadState) { got = append(got, s) }); err != nil {
+		t.Fatalf("ReplayCapture: %v", err)
+	}
+	if len(got) != 1 || !got[0].Buttons.B {
+		t.Errorf("replay produced %+v, want one state with B held", got)
+	}
+}
+
+// TestReplayCaptureErrors verifies that malformed captures are rejected with
+// the offending line number.
+func TestReplayCaptureErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture string
+		wantErr string
+	}{
+		{"bad json", "{\n", "line 1"},
+		{"input before connect", `{"event":"input","source":"xinput","device":0,"data":"000000000000000000000000"}`, "before its connect record"},
+		{"unknown event", `{"event":"explode","source":"xinput","device":0}`, "unknown event"},
+		{"unknown source", `{"event":"connect","source":"sdl","device":0}`, "unknown source"},
+		{"bad hex", `{"event":"connect","source":"xinput","device":0}` + "\n" + `{"event":"input","source":"xinput","device":0,"data":"zz"}`, "line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ReplayCapture(strings.NewReader(tt.capture), 0, func(time.Duration, GamepadState) {})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReplayCapture() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// FuzzReplayCapture checks that corrupt capture files are rejected with an
+// error rather than a panic (short XInput data, truncated HID reports, ...).
+func FuzzReplayCapture(f *testing.F) {
+	for _, name := range []string{"switch_pro_usb.jsonl", "xbox360_xinput.jsonl"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "captures", name))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte(`{"event":"connect","source":"hid","device":1,"vid":1406,"pid":8201}` + "\n" + `{"event":"input","source":"hid","device":1,"data":"30","report_size":64}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_ = ReplayCapture(bytes.NewReader(data), 0.05, func(time.Duration, GamepadState) {})
+	})
+}
@@ -0,0 +1,172 @@
+package gamepad
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultDeadzone is the stick and trigger deadzone of a new Reader.
+const DefaultDeadzone = 0.05
+
+// Stick deadzone modes (Deadzones.Mode).
+const (
+	DeadzoneAxial  = "axial"  // each axis cut off on its own: cheap, but circles look stepped near the centre
+	DeadzoneRadial = "radial" // the stick's distance from the centre cut off, its position kept
+	DeadzoneScaled = "scaled" // radial, with the rest of the range stretched to start at 0
+)
+
+// DeadzoneModes lists the accepted Deadzones.Mode values.
+var DeadzoneModes = []string{DeadzoneAxial, DeadzoneRadial, DeadzoneScaled}
+
+// deadzoneRaw is the Mode of the Deadzones the Reader parses reports with
+// (see rawSticks): the sticks are read without a deadzone, so that their
+// drift can be measured, and get theirs from applyAllSticks afterwards.
+const deadzoneRaw = "raw"
+
+// Deadzones are the deadzone thresholds of a controller's analog inputs, 0
+// (none) to 1, as a fraction of the full deflection: Stick for the sticks
+// and a flight stick's axes, Trigger for the triggers, pedals, and thrust. A
+// wheel's angle has none. Worn sticks drift and need a bigger Stick value.
+// Mode (one of DeadzoneModes) is how Stick applies to the two sticks; the
+// other axes are always cut off on their own.
+type Deadzones struct {
+	Stick   float64 `json:"stick"`
+	Trigger float64 `json:"trigger"`
+	Mode    string  `json:"mode"`
+}
+
+// clamped returns d with both thresholds clamped to [0, 1] and an unknown
+// Mode replaced by DeadzoneAxial.
+func (d Deadzones) clamped() Deadzones {
+	mode := d.Mode
+	if mode != DeadzoneRadial && mode != DeadzoneScaled {
+		mode = DeadzoneAxial
+	}
+	return Deadzones{Stick: min(max(d.Stick, 0), 1), Trigger: min(max(d.Trigger, 0), 1), Mode: mode}
+}
+
+// of returns the threshold of the axis target (see hidAxisValue). In the
+// radial modes the stick axes get none here; applySticks handles them once
+// both are read.
+func (d Deadzones) of(target string) float64 {
+	switch target {
+	case "wheel":
+		return 0
+	case "left_x", "left_y", "right_x", "right_y":
+		if d.Mode == DeadzoneRadial || d.Mode == DeadzoneScaled || d.Mode == deadzoneRaw {
+			return 0
+		}
+	}
+	if isTriggerTarget(target) {
+		return d.Trigger
+	}
+	return d.Stick
+}
+
+// stick applies the stick deadzone to the raw position of a stick.
+func (d Deadzones) stick(v Vector) Vector {
+	if d.Mode == deadzoneRaw {
+		return v
+	}
+	if d.Mode != DeadzoneRadial && d.Mode != DeadzoneScaled {
+		return Vector{X: applyDeadzone(v.X, d.Stick), Y: applyDeadzone(v.Y, d.Stick)}
+	}
+	r := math.Hypot(v.X, v.Y)
+	if r < d.Stick || r == 0 {
+		return Vector{}
+	}
+	if d.Mode == DeadzoneRadial {
+		return v
+	}
+	// Stretch [Stick, 1] to [0, 1] along the stick's direction. Square
+	// gates reach past 1 in the corners; those stay at the edge.
+	if d.Stick >= 1 {
+		return Vector{}
+	}
+	scale := (min(r, 1) - d.Stick) / (1 - d.Stick) / r
+	return Vector{X: v.X * scale, Y: v.Y * scale}
+}
+
+// applySticks applies the radial modes to sticks read one axis at a time,
+// which of leaves without a deadzone. In DeadzoneAxial mode the axes already
+// have theirs and it does nothing.
+func (d Deadzones) applySticks(s *SticksState) {
+	if d.Mode != DeadzoneRadial && d.Mode != DeadzoneScaled {
+		return
+	}
+	d.applyAllSticks(s)
+}
+
+// applyAllSticks applies the stick deadzone to sticks read with rawSticks, in
+// every mode.
+func (d Deadzones) applyAllSticks(s *SticksState) {
+	s.Left.Position = d.stick(s.Left.Position)
+	s.Right.Position = d.stick(s.Right.Position)
+}
+
+// rawSticks returns d with the sticks left without a deadzone; the other axes
+// keep theirs.
+func (d Deadzones) rawSticks() Deadzones {
+	d.Mode = deadzoneRaw
+	return d
+}
+
+// uniformDeadzones returns axial Deadzones with the same threshold for sticks
+// and triggers, as SetDeadzone and ReplayCapture take it.
+func uniformDeadzones(dz float64) Deadzones {
+	return Deadzones{Stick: dz, Trigger: dz, Mode: DeadzoneAxial}
+}
+
+// SetDeadzones sets the deadzones of controllers without their own (see
+// SetDeviceDeadzones). Values outside [0, 1] are clamped. Must be called
+// before Run.
+func (r *Reader) SetDeadzones(d Deadzones) { r.deadzones = d.clamped() }
+
+// SetDeviceDeadzones sets the deadzones of the controllers with the SDL GUID
+// guid (ControllerInfo.GUID; the VID/PID is what identifies them), in place
+// of the SetDeadzones ones. Values outside [0, 1] are clamped. It may be
+// called while Run is running and applies from the controllers' next input.
+func (r *Reader) SetDeviceDeadzones(guid string, d Deadzones) error {
+	k, err := deadzoneKey(guid)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.deviceDeadzones == nil {
+		r.deviceDeadzones = make(map[deviceKey]Deadzones)
+	}
+	r.deviceDeadzones[k] = d.clamped()
+	return nil
+}
+
+// ClearDeviceDeadzones makes the controllers with the SDL GUID guid use the
+// SetDeadzones deadzones again. It may be called while Run is running.
+func (r *Reader) ClearDeviceDeadzones(guid string) error {
+	k, err := deadzoneKey(guid)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.deviceDeadzones, k)
+	return nil
+}
+
+// deadzoneKey returns the VID/PID of a SetDeviceDeadzones GUID.
+func deadzoneKey(guid string) (deviceKey, error) {
+	vid, pid, ok := parseSDLGUID(guid)
+	if !ok {
+		return deviceKey{}, fmt.Errorf("GUID %q: want the 32 hex characters of a GUID with a VID/PID", guid)
+	}
+	return deviceKey{VendorID: vid, ProductID: pid}, nil
+}
+
+// deadzonesLocked returns the deadzones of the device k. Caller must hold
+// r.mu.
+func (r *Reader) deadzonesLocked(k deviceKey) Deadzones {
+	if d, ok := r.deviceDeadzones[k]; ok {
+		return d
+	}
+	return r.deadzones
+}
@@ -0,0 +1,101 @@
+package gamepad
+
+import (
+	"math"
+	"testing"
+)
+
+// TestDeadzonesOf verifies the threshold each axis target is read with.
+func TestDeadzonesOf(t *testing.T) {
+	d := Deadzones{Stick: 0.2, Trigger: 0.1}
+	for target, want := range map[string]float64{
+		"left_x": 0.2, "right_y": 0.2, "roll": 0.2,
+		"lt": 0.1, "rt": 0.1, "throttle": 0.1, "thrust": 0.1,
+		"wheel": 0,
+	} {
+		if got := d.of(target); got != want {
+			t.Errorf("of(%q) = %g, want %g", target, got, want)
+		}
+	}
+	if got := (Deadzones{Stick: -1, Trigger: 2, Mode: "round"}).clamped(); got != (Deadzones{Stick: 0, Trigger: 1, Mode: DeadzoneAxial}) {
+		t.Errorf("clamped() = %+v, want {0 1 axial}", got)
+	}
+	d.Mode = DeadzoneScaled
+	if got := d.of("left_x"); got != 0 {
+		t.Errorf("scaled of(left_x) = %g, want 0 (applied to the stick)", got)
+	}
+	if got := d.of("roll"); got != 0.2 {
+		t.Errorf("scaled of(roll) = %g, want 0.2", got)
+	}
+	raw := d.rawSticks()
+	if got := raw.stick(Vector{X: 0.1}); raw.of("left_x") != 0 || raw.of("roll") != 0.2 || got != (Vector{X: 0.1}) {
+		t.Errorf("rawSticks: of(left_x) = %g, of(roll) = %g, stick() = %+v; want 0, 0.2, unchanged", raw.of("left_x"), raw.of("roll"), got)
+	}
+}
+
+// TestDeadzonesStick verifies the three stick modes on a diagonal push that
+// is inside the axial deadzone on each axis but outside the radial one.
+func TestDeadzonesStick(t *testing.T) {
+	diag := Vector{X: 0.15, Y: 0.15} // distance 0.212
+	for _, tt := range []struct {
+		mode string
+		in   Vector
+		want Vector
+	}{
+		{DeadzoneAxial, diag, Vector{}},
+		{DeadzoneAxial, Vector{X: 0.5, Y: 0.1}, Vector{X: 0.5}}, // snaps to the axis
+		{DeadzoneRadial, diag, diag},
+		{DeadzoneRadial, Vector{X: 0.1, Y: -0.1}, Vector{}},
+		{DeadzoneRadial, Vector{X: 0.5, Y: 0.1}, Vector{X: 0.5, Y: 0.1}},
+		{DeadzoneScaled, Vector{X: 0.2}, Vector{}},
+		{DeadzoneScaled, Vector{Y: -0.6}, Vector{Y: -0.5}},
+		{DeadzoneScaled, Vector{X: 0.6, Y: 0.8}, Vector{X: 0.6, Y: 0.8}}, // full deflection stays full
+		{DeadzoneScaled, Vector{X: 1, Y: 1}, Vector{X: math.Sqrt2 / 2, Y: math.Sqrt2 / 2}},
+	} {
+		d := Deadzones{Stick: 0.2, Mode: tt.mode}
+		got := d.stick(tt.in)
+		if math.Abs(got.X-tt.want.X) > 1e-9 || math.Abs(got.Y-tt.want.Y) > 1e-9 {
+			t.Errorf("%s stick(%+v) = %+v, want %+v", tt.mode, tt.in, got, tt.want)
+		}
+	}
+	if got := (Deadzones{Stick: 1, Mode: DeadzoneScaled}).stick(Vector{X: 1}); got != (Vector{}) {
+		t.Errorf("scaled stick() with deadzone 1 = %+v, want zero", got)
+	}
+}
+
+// TestReaderDeviceDeadzones verifies that a device's own deadzones replace
+// the Reader's for that VID/PID only, and that clearing them restores the
+// Reader's.
+func TestReaderDeviceDeadzones(t *testing.T) {
+	r := NewReader()
+	r.SetDeadzones(Deadzones{Stick: 0.1, Trigger: 0.02})
+	ps := deviceKey{0x054c, 0x0ce6}
+	xbox := deviceKey{0x045e, 0x028e}
+	r.joysticks[hidKey(0x1000)] = &joystickInfo{mapping: GetMapping(ps.VendorID, ps.ProductID), name: "DualSense", sourceType: "hid", hDevice: 0x1000, devKey: ps}
+	r.joysticks[xinputKey(0)] = &joystickInfo{mapping: xboxMapping, name: "Xbox", sourceType: "xinput", devKey: xbox}
+	r.joystickOrder = []joystickKey{hidKey(0x1000), xinputKey(0)}
+
+	if err := r.SetDeviceDeadzones("030000004C050000E60C000000000000", Deadzones{Stick: 0.25, Trigger: 3}); err != nil {
+		t.Fatalf("SetDeviceDeadzones() error = %v", err)
+	}
+	got := r.Controllers()
+	if want := (Deadzones{Stick: 0.25, Trigger: 1, Mode: DeadzoneAxial}); got[0].Deadzones != want {
+		t.Errorf("DualSense deadzones = %+v, want %+v", got[0].Deadzones, want)
+	}
+	if want := (Deadzones{Stick: 0.1, Trigger: 0.02, Mode: DeadzoneAxial}); got[1].Deadzones != want {
+		t.Errorf("Xbox deadzones = %+v, want the Reader's %+v", got[1].Deadzones, want)
+	}
+
+	if err := r.ClearDeviceDeadzones(sdlGUID(ps.VendorID, ps.ProductID)); err != nil {
+		t.Fatalf("ClearDeviceDeadzones() error = %v", err)
+	}
+	if got := r.Controllers()[0].Deadzones; got != r.deadzones {
+		t.Errorf("DualSense deadzones after clear = %+v, want the Reader's %+v", got, r.deadzones)
+	}
+
+	for _, guid := range []string{"", "xinput", "030000004c050000e60c0000000000", "zz0000004c050000e60c000000000000"} {
+		if err := r.SetDeviceDeadzones(guid, Deadzones{}); err == nil {
+			t.Errorf("SetDeviceDeadzones(%q) error = nil, want an error", guid)
+		}
+	}
+}
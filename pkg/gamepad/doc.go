@@ -0,0 +1,53 @@
+// Package gamepad reads game controllers and exposes their state as a
+// normalized GamepadState stream. It has no dependency on the InputView web
+// server and can be imported by other Go programs.
+//
+// # Stable API
+//
+// The following are covered by semantic versioning:
+//
+//   - State model: GamepadState and its component types, DeltaChanges,
+//     ComputeDelta, ApplyDelta, GamepadState.Validate.
+//   - Reader: NewReader, the Set* configuration methods, Run, Changes, State,
+//     Controllers, ControllerInfo, GetPlayerIndex, SetActiveByPlayerIndex, Inject,
+//     SetRawInputReader, HIDSource, Events, ControllerEvent, PollStats, Rumble
+//     (MaxRumbleDuration, ErrNoController, ErrRumbleUnsupported), SetLEDs (LEDs,
+//     Color, ParseColor, MaxPlayerLEDs, ErrLEDUnsupported), SetRawMode
+//     (RawState, ErrRawUnsupported), ExportSDLMapping (ErrNoSDLMapping),
+//     SetDeviceDeadzones, ClearDeviceDeadzones (Deadzones, DefaultDeadzone,
+//     DeadzoneModes and the Deadzone* modes), SetTriggerCalibration,
+//     LoadTriggerCalibration, SaveTriggerCalibration (TriggerRange), Drift
+//     (DriftReport, StickDrift).
+//   - Mappings: DeviceMapping, AxisMapping, ButtonMapping, DeviceKey, GetMapping,
+//     LoadMappingDir, CustomMappingCount.
+//   - SDL GameControllerDB: LoadSDLDB, SDLMapping, SDLMappingCount, SDLPlatform,
+//     LoadSDLMappingsFromFile, LoadSDLMappingsFromReader.
+//   - Raw input capture: SetCaptureWriter, StartCapture, StopCapture, Capturing,
+//     ReplayCapture. Capture files may
+//     gain fields in later versions; older captures keep replaying.
+//
+// Everything unexported may change at any time.
+//
+// # Usage
+//
+//	r := gamepad.NewReader()
+//	gamepad.LoadSDLDB("") // embedded DB only
+//	go r.Run(ctx)
+//	for s := range r.Changes() {
+//		fmt.Println(s.Buttons.A, s.Sticks.Left.Position)
+//	}
+//
+// On Windows, XInput controllers are polled by Run. Non-XInput HID controllers
+// (PS4/PS5, Switch Pro, generic) additionally need a Raw Input message loop that
+// implements HIDSource; pass it to SetRawInputReader before starting it. Other
+// platforms currently report no controllers.
+package gamepad
+
+// HIDSource is a Raw Input message loop that delivers HID reports and device
+// arrival/removal notifications for a HID usage page/usage pair. Callbacks may
+// be invoked from the source's own goroutine.
+type HIDSource interface {
+	RegisterHIDCallback(usagePage, usage uint16,
+		inputCb func(hDevice uintptr, rawData []byte, reportSize uint32),
+		changeCb func(added bool, hDevice uintptr))
+}
@@ -0,0 +1,138 @@
+package gamepad
+
+import (
+	"log/slog"
+	"math"
+)
+
+const (
+	// driftWindow is the number of resting readings in a row a drift
+	// measurement is taken over.
+	driftWindow = 120
+	// driftRest is how far from the centre a stick may read and still count
+	// as resting; a push past it starts the measurement over.
+	driftRest = 0.35
+)
+
+// StickDrift is how one stick behaves at rest, measured from its raw position
+// (before the deadzone) over the last driftWindow readings in a row it stayed
+// near the centre. Bias is the mean resting position and Noise half the
+// spread of each axis around it. Drifting is set when the resting stick
+// reaches its stick deadzone (or DefaultDeadzone, if that is bigger) on an
+// axis, so that it shows input while nobody touches it: a worn stick, or one
+// that wants a bigger deadzone. All are zero until Measured.
+type StickDrift struct {
+	Bias     Vector `json:"bias"`
+	Noise    Vector `json:"noise"`
+	Drifting bool   `json:"drifting"`
+	Measured bool   `json:"measured"` // the stick has rested for a full measurement since it connected
+}
+
+// DriftReport is the resting behaviour of a controller's two sticks (see
+// Reader.Drift). A Joy-Con measures only the stick it has.
+type DriftReport struct {
+	Left  StickDrift `json:"left"`
+	Right StickDrift `json:"right"`
+}
+
+// driftMeter measures the drift of one stick. last is the last complete
+// measurement; n, sum, lo, and hi accumulate the current one.
+type driftMeter struct {
+	last   StickDrift
+	n      int
+	sum    Vector
+	lo, hi Vector
+}
+
+// add feeds the meter a raw stick position. threshold is the stick deadzone
+// the stick is read with.
+func (m *driftMeter) add(p Vector, threshold float64) {
+	if math.Hypot(p.X, p.Y) > driftRest {
+		m.n = 0
+		return
+	}
+	if m.n == 0 {
+		m.sum, m.lo, m.hi = Vector{}, p, p
+	}
+	m.n++
+	m.sum.X, m.sum.Y = m.sum.X+p.X, m.sum.Y+p.Y
+	m.lo = Vector{X: min(m.lo.X, p.X), Y: min(m.lo.Y, p.Y)}
+	m.hi = Vector{X: max(m.hi.X, p.X), Y: max(m.hi.Y, p.Y)}
+	if m.n < driftWindow {
+		return
+	}
+	n := float64(m.n)
+	threshold = max(threshold, DefaultDeadzone)
+	reach := max(-m.lo.X, m.hi.X, -m.lo.Y, m.hi.Y)
+	m.last = StickDrift{
+		Bias:     Vector{X: m.sum.X / n, Y: m.sum.Y / n},
+		Noise:    Vector{X: (m.hi.X - m.lo.X) / 2, Y: (m.hi.Y - m.lo.Y) / 2},
+		Drifting: reach >= threshold,
+		Measured: true,
+	}
+	m.n = 0
+}
+
+// driftTracker measures the drift of a controller's sticks.
+type driftTracker struct {
+	left, right driftMeter
+}
+
+// report returns the last measurements of both sticks.
+func (t *driftTracker) report() DriftReport {
+	return DriftReport{Left: t.left.last, Right: t.right.last}
+}
+
+// trackDriftLocked feeds the raw sticks of a report from the joystick key to
+// its drift tracker (only the stick of side for a Joy-Con), with threshold its
+// stick deadzone, and returns the ControllerDrift event to post when a stick
+// started or stopped drifting. Wheels and flight sticks have no sticks and
+// are not measured. Caller must hold r.mu.
+func (r *Reader) trackDriftLocked(key joystickKey, info *joystickInfo, sticks SticksState, side joyConSide, threshold float64) *ControllerEvent {
+	if name := info.mapping.Name; name == "wheel" || name == "flightstick" {
+		return nil
+	}
+	t := &info.drift
+	was := t.report()
+	if side != joyConRight {
+		t.left.add(sticks.Left.Position, threshold)
+	}
+	if side != joyConLeft {
+		t.right.add(sticks.Right.Position, threshold)
+	}
+	now := t.report()
+	if now.Left.Drifting == was.Left.Drifting && now.Right.Drifting == was.Right.Drifting {
+		return nil
+	}
+	active := r.hasActive && r.activeKey == key
+	return &ControllerEvent{
+		Type:       ControllerDrift,
+		Controller: r.controllerInfoLocked(info, r.getPlayerIndexLocked(key), active),
+		Drift:      &now,
+	}
+}
+
+// putDrift posts the event trackDriftLocked returned, if any.
+func (r *Reader) putDrift(ev *ControllerEvent) {
+	if ev == nil {
+		return
+	}
+	slog.Info("stick drift changed", "player", ev.Controller.PlayerIndex, "name", ev.Controller.Name,
+		"left", ev.Drift.Left.Drifting, "right", ev.Drift.Right.Drifting)
+	r.events.put(*ev)
+}
+
+// Drift returns the drift report of the controller at the player index
+// (1-based, like SetActiveByPlayerIndex): how far its sticks sit off centre
+// and how much they jitter when left alone. Sticks are measured while the
+// controller is read, which is always for the active one and for every one
+// with SetAllPlayers. ErrNoController if there is no such controller.
+func (r *Reader) Drift(playerIndex int) (DriftReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info := r.joystickLocked(playerIndex)
+	if info == nil {
+		return DriftReport{}, ErrNoController
+	}
+	return info.drift.report(), nil
+}
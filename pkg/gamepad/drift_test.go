@@ -0,0 +1,83 @@
+package gamepad
+
+import (
+	"math"
+	"testing"
+)
+
+// TestDriftMeter verifies the bias, noise, and drifting verdict of a
+// measurement, and that a push away from the centre starts it over.
+func TestDriftMeter(t *testing.T) {
+	var m driftMeter
+	for i := range driftWindow - 1 {
+		m.add(Vector{X: 0.02 + 0.01*float64(i%2), Y: -0.01}, 0.1)
+	}
+	if m.last.Measured {
+		t.Fatal("measured before driftWindow readings")
+	}
+	m.add(Vector{X: 0.5}, 0.1) // pushed: start over
+	for i := range driftWindow {
+		m.add(Vector{X: 0.02 + 0.01*float64(i%2), Y: -0.01}, 0.1)
+	}
+	got := m.last
+	if !got.Measured || got.Drifting {
+		t.Fatalf("last = %+v, want measured and not drifting", got)
+	}
+	if math.Abs(got.Bias.X-0.025) > 1e-9 || math.Abs(got.Bias.Y+0.01) > 1e-9 {
+		t.Errorf("Bias = %+v, want {0.025 -0.01}", got.Bias)
+	}
+	if math.Abs(got.Noise.X-0.005) > 1e-9 || got.Noise.Y != 0 {
+		t.Errorf("Noise = %+v, want {0.005 0}", got.Noise)
+	}
+
+	// Below DefaultDeadzone on an axis, but past a zero deadzone.
+	for range driftWindow {
+		m.add(Vector{Y: -0.06}, 0)
+	}
+	if !m.last.Drifting {
+		t.Errorf("last = %+v, want drifting", m.last)
+	}
+}
+
+// TestTrackDriftEvents verifies that a ControllerDrift event is returned when
+// a stick starts drifting, not again while it keeps drifting, and that a
+// Joy-Con only measures its own stick.
+func TestTrackDriftEvents(t *testing.T) {
+	r := NewReader()
+	info := &joystickInfo{mapping: xboxMapping, name: "Xbox", sourceType: "xinput"}
+	r.joysticks[xinputKey(0)] = info
+	r.joystickOrder = []joystickKey{xinputKey(0)}
+	r.activeKey, r.hasActive = xinputKey(0), true
+
+	drifting := SticksState{Left: StickState{Position: Vector{X: 0.12}}}
+	var events []*ControllerEvent
+	for range 2 * driftWindow {
+		if ev := r.trackDriftLocked(xinputKey(0), info, drifting, joyConNone, 0.1); ev != nil {
+			events = append(events, ev)
+		}
+	}
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1", len(events))
+	}
+	ev := events[0]
+	if ev.Type != ControllerDrift || ev.Drift == nil || !ev.Drift.Left.Drifting || ev.Drift.Right.Drifting {
+		t.Errorf("event = %+v, want a left-stick drift", ev)
+	}
+	if ev.Controller.DeviceID != "xinput-0" || !ev.Controller.Active {
+		t.Errorf("Controller = %+v, want active xinput-0", ev.Controller)
+	}
+	if got, err := r.Drift(1); err != nil || got != *ev.Drift {
+		t.Errorf("Drift(1) = %+v, %v; want %+v", got, err, *ev.Drift)
+	}
+	if _, err := r.Drift(2); err != ErrNoController {
+		t.Errorf("Drift(2) error = %v, want ErrNoController", err)
+	}
+
+	joyCon := &joystickInfo{mapping: xboxMapping, name: "Joy-Con (R)", sourceType: "hid"}
+	for range driftWindow {
+		r.trackDriftLocked(hidKey(1), joyCon, SticksState{Left: StickState{Position: Vector{X: -1, Y: -1}}}, joyConRight, 0.1)
+	}
+	if got := joyCon.drift.report(); got.Left.Measured || !got.Right.Measured {
+		t.Errorf("Joy-Con (R) report = %+v, want only the right stick measured", got)
+	}
+}
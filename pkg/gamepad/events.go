@@ -0,0 +1,128 @@
+package gamepad
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// ControllerEvent types.
+const (
+	ControllerConnected    = "connected"
+	ControllerDisconnected = "disconnected"
+	ControllerSwitched     = "switched" // the active controller changed to another connected one
+	ControllerDrift        = "drift"    // a stick started or stopped drifting (see Reader.Drift)
+)
+
+// Disconnect reasons (ControllerEvent.Reason).
+const (
+	// DisconnectUnplugged: the device went away (XInput slot empty, or a Raw
+	// Input GIDC_REMOVAL notification). Windows reports cable removal,
+	// wireless power-off and link loss the same way.
+	DisconnectUnplugged = "unplugged"
+	// DisconnectReplaced: a HID controller was dropped because an XInput
+	// device with the same VID/PID appeared (XInput emulation such as Steam
+	// Input or BetterJoy started after the controller was registered).
+	DisconnectReplaced = "replaced"
+)
+
+// Switch reasons (ControllerEvent.Reason for ControllerSwitched).
+const (
+	SwitchSelected = "selected" // SetActiveByPlayerIndex (a client's select_player)
+	SwitchPromoted = "promoted" // the active controller disconnected; the first remaining one took over
+)
+
+// eventsBuffer is the capacity of the Events channel. Events are rare; the
+// buffer covers the initial scan before a consumer starts reading.
+const eventsBuffer = 16
+
+// ControllerEvent reports a controller being connected to or disconnected
+// from the Reader, the active controller changing, or a stick of a controller
+// starting or stopping to drift. Controller is the
+// device as Controllers would have listed it at that moment: for
+// ControllerConnected, Active tells whether it became the active controller;
+// for ControllerDisconnected, whether it was; for ControllerSwitched it is the
+// new active controller. Previous, only set for ControllerSwitched, is the
+// formerly active controller as it was listed before the switch (for
+// SwitchPromoted, the one that just disconnected). Drift, only set for
+// ControllerDrift, is the controller's new drift report.
+//
+// Becoming active without a previous controller (the first connect, or a
+// connect after all were gone) is reported by ControllerConnected alone.
+type ControllerEvent struct {
+	Type       string // ControllerConnected, ControllerDisconnected, ControllerSwitched, or ControllerDrift
+	Reason     string // Disconnect* or Switch* constant; empty for ControllerConnected and ControllerDrift
+	Controller ControllerInfo
+	Previous   *ControllerInfo
+	Drift      *DriftReport
+}
+
+// eventQueue is the Reader's connect/disconnect event channel. Like the
+// state mailbox, posting after close is a no-op rather than a panic: Raw
+// Input device notifications can still arrive while Run shuts down.
+type eventQueue struct {
+	mu     sync.Mutex // protects closed and the send/close on ch
+	ch     chan ControllerEvent
+	closed bool
+}
+
+func newEventQueue(n int) *eventQueue {
+	return &eventQueue{ch: make(chan ControllerEvent, n)}
+}
+
+// put posts ev without blocking, dropping it (with a warning) when the
+// buffer is full.
+func (q *eventQueue) put(ev ControllerEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	select {
+	case q.ch <- ev:
+	default:
+		slog.Warn("controller event dropped (consumer not keeping up)", "type", ev.Type, "name", ev.Controller.Name)
+	}
+}
+
+// close closes the channel; later puts are ignored.
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+}
+
+// Events returns the channel on which connect, disconnect, switch, and drift
+// events are emitted. Events are dropped (with a warning) if the consumer falls more than
+// eventsBuffer events behind. The channel is closed when Run returns.
+func (r *Reader) Events() <-chan ControllerEvent {
+	return r.events.ch
+}
+
+// controllerInfoLocked describes a joystick for Controllers and
+// ControllerEvent. Caller must hold r.mu (at least read lock).
+func (r *Reader) controllerInfoLocked(info *joystickInfo, playerIndex int, active bool) ControllerInfo {
+	c := ControllerInfo{
+		PlayerIndex:    playerIndex,
+		DeviceID:       info.deviceID(),
+		Name:           info.name,
+		ControllerType: info.mapping.Name,
+		Source:         info.sourceType,
+		VendorID:       info.devKey.VendorID,
+		ProductID:      info.devKey.ProductID,
+		Active:         active,
+		GUID:           sdlGUID(info.devKey.VendorID, info.devKey.ProductID),
+		Serial:         info.serial,
+		ProductVersion: info.version,
+		Battery:        info.battery,
+		Deadzones:      r.deadzonesLocked(info.devKey),
+	}
+	if info.sourceType == "xinput" {
+		c.Mapping, c.Axes, c.Buttons, c.Hats = "xinput", xinputNumAxes, xinputNumButtons, xinputNumHats
+	} else if dev := r.hidDevices[info.hDevice]; dev != nil {
+		dev.describe(&c)
+	}
+	return c
+}
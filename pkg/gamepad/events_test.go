@@ -0,0 +1,58 @@
+package gamepad
+
+import "testing"
+
+// TestEventQueue verifies that events are delivered in order, dropped when
+// the buffer is full, and ignored after close instead of panicking.
+func TestEventQueue(t *testing.T) {
+	q := newEventQueue(2)
+	for _, name := range []string{"a", "b", "c"} {
+		q.put(ControllerEvent{Type: ControllerConnected, Controller: ControllerInfo{Name: name}})
+	}
+	q.close()
+	q.put(ControllerEvent{Type: ControllerDisconnected}) // after close: no-op
+	q.close()
+
+	var got []string
+	for ev := range q.ch {
+		got = append(got, ev.Controller.Name)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("received %v, want [a b] (c dropped, nothing after close)", got)
+	}
+}
+
+// TestSetActiveSwitchEvent verifies that selecting another controller emits a
+// ControllerSwitched event with both devices, and re-selecting the active one
+// emits nothing.
+func TestSetActiveSwitchEvent(t *testing.T) {
+	r := NewReader()
+	r.joysticks[xinputKey(0)] = &joystickInfo{mapping: xboxMapping, name: "Xbox", sourceType: "xinput"}
+	r.joysticks[xinputKey(1)] = &joystickInfo{mapping: xboxMapping, name: "Xbox 2", sourceType: "xinput", xinputSlot: 1}
+	r.joystickOrder = []joystickKey{xinputKey(0), xinputKey(1)}
+	r.activeKey, r.hasActive = xinputKey(0), true
+
+	if !r.SetActiveByPlayerIndex(2) {
+		t.Fatal("SetActiveByPlayerIndex(2) = false")
+	}
+	r.SetActiveByPlayerIndex(2) // already active: no event
+	r.events.close()
+
+	var got []ControllerEvent
+	for ev := range r.Events() {
+		got = append(got, ev)
+	}
+	if len(got) != 1 {
+		t.Fatalf("events = %+v, want one switch", got)
+	}
+	ev := got[0]
+	if ev.Type != ControllerSwitched || ev.Reason != SwitchSelected {
+		t.Errorf("event = %s/%s, want %s/%s", ev.Type, ev.Reason, ControllerSwitched, SwitchSelected)
+	}
+	if ev.Controller.DeviceID != "xinput-1" || ev.Controller.PlayerIndex != 2 || !ev.Controller.Active {
+		t.Errorf("Controller = %+v, want active P2 xinput-1", ev.Controller)
+	}
+	if ev.Previous == nil || ev.Previous.DeviceID != "xinput-0" || ev.Previous.PlayerIndex != 1 {
+		t.Errorf("Previous = %+v, want P1 xinput-0", ev.Previous)
+	}
+}
@@ -0,0 +1,108 @@
+package gamepad
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Flight stick limits: the extra axes, buttons, and hats FlightState holds.
+// 128 buttons is DirectInput's limit; HOTAS throttles with more are rare.
+const (
+	MaxFlightAxes    = 8
+	MaxFlightButtons = 128
+	MaxFlightHats    = 4
+)
+
+// FlightState represents a flight stick, HOTAS throttle, or rudder
+// (ControllerType "flightstick"), which do not fit the gamepad layout. Roll
+// (-1 left to 1 right), Pitch (-1 pulled back to 1 pushed forward, like a
+// stick's up-positive Y), and Yaw (twist or rudder, -1 left to 1 right) are
+// the stick's axes; Thrust is the throttle, 0 idle to 1 full. Axes holds the
+// device's other axes (sliders, rotaries, mini-sticks), -1 to 1, in the order
+// its mapping gives them. Buttons is a bitmask of up to MaxFlightButtons
+// buttons, 32 per word (see Button), and Hats the first MaxFlightHats hat
+// switches. It is omitted on the wire while everything is centred, idle, and
+// released, so gamepads send nothing.
+type FlightState struct {
+	Roll    float64                       `json:"roll"`
+	Pitch   float64                       `json:"pitch"`
+	Yaw     float64                       `json:"yaw"`
+	Thrust  float64                       `json:"thrust"`
+	Axes    [MaxFlightAxes]float64        `json:"axes"`
+	Buttons [MaxFlightButtons / 32]uint32 `json:"buttons"`
+	Hats    [MaxFlightHats]DpadState      `json:"hats"`
+}
+
+// Button reports whether the 1-based button n is pressed. Clients read bit
+// (n-1)%32 of buttons[(n-1)/32].
+func (f FlightState) Button(n int) bool {
+	if n < 1 || n > MaxFlightButtons {
+		return false
+	}
+	return f.Buttons[(n-1)/32]&(1<<((n-1)%32)) != 0
+}
+
+// press sets the 1-based button n; others are ignored.
+func (f *FlightState) press(n int) {
+	if n >= 1 && n <= MaxFlightButtons {
+		f.Buttons[(n-1)/32] |= 1 << ((n - 1) % 32)
+	}
+}
+
+// flightEqual reports whether two flight stick readings differ by less than
+// analogThreshold on every axis and have the same buttons and hats.
+func flightEqual(a, b FlightState) bool {
+	if !floatEqual(a.Roll, b.Roll) || !floatEqual(a.Pitch, b.Pitch) ||
+		!floatEqual(a.Yaw, b.Yaw) || !floatEqual(a.Thrust, b.Thrust) {
+		return false
+	}
+	for i := range a.Axes {
+		if !floatEqual(a.Axes[i], b.Axes[i]) {
+			return false
+		}
+	}
+	return a.Buttons == b.Buttons && a.Hats == b.Hats
+}
+
+// flightButtonTarget returns the "button<n>" target of the 1-based button n,
+// which flight stick mappings use for buttons HIDButtons does not name.
+func flightButtonTarget(n uint16) string {
+	return "button" + strconv.Itoa(int(n))
+}
+
+// flightIndex parses the 1-based number of a "<prefix><n>" target, such as
+// "button12" or "axis3", for n in [1, limit].
+func flightIndex(target, prefix string, limit int) (int, bool) {
+	s, ok := strings.CutPrefix(target, prefix)
+	if !ok || s == "" || s[0] == '0' {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > limit {
+		return 0, false
+	}
+	return n, true
+}
+
+// isFlightAxisTarget and isFlightButtonTarget report whether target is an
+// "axis<n>" or "button<n>" target, which mapping files accept in addition to
+// axisTargets and buttonTargets.
+func isFlightAxisTarget(target string) bool {
+	_, ok := flightIndex(target, "axis", MaxFlightAxes)
+	return ok
+}
+
+func isFlightButtonTarget(target string) bool {
+	_, ok := flightIndex(target, "button", MaxFlightButtons)
+	return ok
+}
+
+// setFlightHat sets the flight stick's hat i from the 0-based direction index
+// of a HID hat switch (see hatDirTable); values outside 0-7 are centred.
+func setFlightHat(state *GamepadState, i, idx int) {
+	if i >= MaxFlightHats || idx < 0 || idx >= len(hatDirTable) {
+		return
+	}
+	dirs := hatDirTable[idx]
+	state.Flight.Hats[i] = DpadState{Up: dirs[0], Down: dirs[1], Left: dirs[2], Right: dirs[3]}
+}
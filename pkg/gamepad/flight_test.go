@@ -0,0 +1,96 @@
+package gamepad
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestFlightButtons verifies the button bitmask across word boundaries and
+// its wire form.
+func TestFlightButtons(t *testing.T) {
+	var f FlightState
+	for _, n := range []int{1, 32, 33, MaxFlightButtons, 0, MaxFlightButtons + 1} {
+		f.press(n)
+	}
+	for n := 1; n <= MaxFlightButtons; n++ {
+		want := n == 1 || n == 32 || n == 33 || n == MaxFlightButtons
+		if f.Button(n) != want {
+			t.Errorf("Button(%d) = %v, want %v", n, f.Button(n), want)
+		}
+	}
+	if f.Button(0) || f.Button(MaxFlightButtons+1) {
+		t.Error("Button() out of range reported pressed")
+	}
+	b, err := json.Marshal(f.Buttons)
+	if err != nil || string(b) != "[2147483649,1,0,2147483648]" {
+		t.Errorf("buttons JSON = %s, %v", b, err)
+	}
+}
+
+// TestFlightTargets verifies that flight stick mappings pass buttons through
+// by number and fill the flight stick's axes and hats.
+func TestFlightTargets(t *testing.T) {
+	m := &DeviceMapping{Name: "flightstick", HIDButtons: map[uint16]string{3: "button40"}}
+	var s GamepadState
+	for _, usage := range []uint16{1, 3, 17} {
+		applyButton(&s, resolveButtonTarget(m, usage))
+	}
+	if !s.Flight.Button(1) || !s.Flight.Button(40) || !s.Flight.Button(17) || s.Flight.Button(3) || s.Buttons != (ButtonState{}) {
+		t.Errorf("buttons = %v, gamepad %+v; want 1, 17, 40", s.Flight.Buttons, s.Buttons)
+	}
+	if got := resolveButtonTarget(xboxMapping, 17); got != "" {
+		t.Errorf("resolveButtonTarget(xbox, 17) = %q, want none", got)
+	}
+
+	applyAxisToState(&s, "roll", 0.5)
+	applyAxisToState(&s, "pitch", 0.25) // HID Y: pulled back is positive
+	applyAxisToState(&s, "thrust", 0.75)
+	applyAxisToState(&s, "axis3", -1)
+	applyAxisToState(&s, "axis9", 1)
+	setFlightHat(&s, 1, 2) // E
+	setFlightHat(&s, 0, 8) // centred
+	setFlightHat(&s, MaxFlightHats, 0)
+	f := s.Flight
+	if f.Roll != 0.5 || f.Pitch != -0.25 || f.Thrust != 0.75 || f.Axes != [MaxFlightAxes]float64{2: -1} {
+		t.Errorf("axes = %+v", f)
+	}
+	if f.Hats != [MaxFlightHats]DpadState{1: {Right: true}} {
+		t.Errorf("hats = %+v, want hat 2 right", f.Hats)
+	}
+	if s.Sticks != (SticksState{}) || s.Dpad != (DpadState{}) {
+		t.Errorf("flight targets changed the gamepad: %+v, %+v", s.Sticks, s.Dpad)
+	}
+}
+
+// TestFlightMappings verifies that the built-in flight stick mappings only
+// use targets a mapping file accepts, that they win over the SDL database,
+// and that mapping files accept numbered targets within the limits.
+func TestFlightMappings(t *testing.T) {
+	LoadSDLDB("")
+	for k, m := range knownDevices {
+		if m.Name != "flightstick" {
+			continue
+		}
+		for usage, target := range m.HIDAxes {
+			if t2 := strings.TrimSuffix(target, "~"); !slices.Contains(axisTargets, t2) && !isFlightAxisTarget(t2) {
+				t.Errorf("%04x:%04x axis 0x%02x: unknown target %q", k.VendorID, k.ProductID, usage, target)
+			}
+		}
+		if lookupSDLMapping(k.VendorID, k.ProductID) != nil {
+			t.Errorf("lookupSDLMapping(%04x:%04x) = SDL mapping, want the built-in flight stick mapping", k.VendorID, k.ProductID)
+		}
+	}
+
+	keys, m, err := parseMappingFile(strings.NewReader(`{"type": "flightstick", "devices": ["044f:b687"],
+		"hidAxes": {"z": "thrust~", "rz": "yaw", "x": "axis1", "y": "axis2"}, "hidButtons": {"1": "button14"}}`))
+	if err != nil || len(keys) != 1 || m.Name != "flightstick" || m.HIDAxes[hidUsageX] != "axis1" || m.HIDButtons[1] != "button14" {
+		t.Errorf("parseMappingFile(flightstick) = %v, %+v, %v", keys, m, err)
+	}
+	for _, bad := range []string{`"hidAxes": {"x": "axis9"}`, `"hidAxes": {"x": "axis01"}`, `"hidButtons": {"1": "button129"}`, `"hidButtons": {"1": "button0"}`} {
+		if _, _, err := parseMappingFile(strings.NewReader(`{"type": "flightstick", "devices": ["1234:5678"], ` + bad + `}`)); err == nil {
+			t.Errorf("parseMappingFile() accepted %s", bad)
+		}
+	}
+}
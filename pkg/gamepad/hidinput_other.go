@@ -0,0 +1,45 @@
+//go:build !windows
+
+package gamepad
+
+import (
+	"errors"
+	"fmt"
+)
+
+// hidDeviceInfo is a stub type on non-Windows platforms.
+// The HID input path is Windows-only; this keeps the compiler happy
+// when shared code references hidDeviceInfo fields.
+type hidDeviceInfo struct {
+	hDevice     uintptr
+	isXInput    bool
+	isInvalid   bool
+	mapping     *DeviceMapping
+	sdlMap      *SDLMapping // SDL gamecontrollerdb mapping (may be nil)
+	axisOrder   []hidAxisEntry
+	axisMap     map[uint16]string
+	buttonCount uint16
+	name        string
+}
+
+// setRawMode fails on non-Windows platforms (no HID devices are tracked).
+func (dev *hidDeviceInfo) setRawMode(enabled bool) bool { return false }
+
+// sdlBindings fails on non-Windows platforms (no HID devices are tracked).
+func (dev *hidDeviceInfo) sdlBindings() ([]string, bool) { return nil, false }
+
+// describe is a no-op on non-Windows platforms (no HID devices are tracked).
+func (dev *hidDeviceInfo) describe(c *ControllerInfo) {}
+
+// newHIDReplayParser rebuilds a device's report parser for ReplayCapture.
+// Only Nintendo controllers can be replayed here: their custom parser is plain
+// Go, while generic HID reports need the Windows HidP_* API.
+func newHIDReplayParser(vid, pid uint16, preparsed []byte) (hidReportParser, error) {
+	if !isNintendoController(vid) {
+		return nil, errors.New("replaying generic HID reports requires Windows (HidP_* parsing)")
+	}
+	name := fmt.Sprintf("%s (VID_%04X&PID_%04X)", GetMapping(vid, pid).Name, vid, pid)
+	return func(rawData []byte, dz Deadzones) (GamepadState, bool) {
+		return parseSwitchProReport(name, rawData, dz)
+	}, nil
+}
@@ -41,6 +41,16 @@ type hidAxisEntry struct {
 	bitSize    uint16
 }
 
+// logicalRange returns the axis's logical range, or the full range of its
+// bits for descriptors whose logical maximum overflowed to a negative value.
+func (ae *hidAxisEntry) logicalRange() (lMin, lMax int32) {
+	lMin, lMax = ae.logicalMin, ae.logicalMax
+	if lMax < lMin && ae.bitSize > 0 && ae.bitSize < 32 {
+		return 0, (1 << ae.bitSize) - 1
+	}
+	return lMin, lMax
+}
+
 // ---------------------------------------------------------------------------
 // Lookup tables and defaults
 // ---------------------------------------------------------------------------
@@ -154,8 +164,15 @@ func normalizeHIDAxis(raw uint32, logMin, logMax int32, isTrigger bool) float64
 
 // resolveButtonTarget returns the semantic button name for a 1-based HID button
 // usage. Uses the DeviceMapping's HIDButtons table if available, otherwise falls
-// back to defaultButtonOrder.
+// back to defaultButtonOrder. Flight stick mappings pass the buttons
+// HIDButtons does not name through as "button<n>".
 func resolveButtonTarget(mapping *DeviceMapping, buttonUsage uint16) string {
+	if mapping != nil && mapping.Name == "flightstick" {
+		if target := mapping.HIDButtons[buttonUsage]; target != "" {
+			return target
+		}
+		return flightButtonTarget(buttonUsage)
+	}
 	if mapping != nil && len(mapping.HIDButtons) > 0 {
 		return mapping.HIDButtons[buttonUsage]
 	}
@@ -212,6 +229,18 @@ func applyButton(state *GamepadState, target string) {
 		state.Buttons.Touchpad = true
 	case "capture":
 		state.Buttons.Capture = true
+	case "paddle1":
+		state.Extra.P1 = true
+	case "paddle2":
+		state.Extra.P2 = true
+	case "paddle3":
+		state.Extra.P3 = true
+	case "paddle4":
+		state.Extra.P4 = true
+	case "fn1":
+		state.Extra.Fn1 = true
+	case "fn2":
+		state.Extra.Fn2 = true
 	case "ls":
 		state.Sticks.Left.Pressed = true
 	case "rs":
@@ -224,6 +253,17 @@ func applyButton(state *GamepadState, target string) {
 		state.Dpad.Left = true
 	case "dpright":
 		state.Dpad.Right = true
+	case "handbrake":
+		// Preserve an analog handbrake value if an axis already populated it.
+		if state.Wheel.Handbrake == 0 {
+			state.Wheel.Handbrake = 1.0
+		}
+	default:
+		if gear, ok := wheelGear(target); ok {
+			state.Wheel.Gear = gear
+		} else if n, ok := flightIndex(target, "button", MaxFlightButtons); ok {
+			state.Flight.press(n)
+		}
 	}
 }
 
@@ -243,6 +283,10 @@ func applyButton(state *GamepadState, target string) {
 func sdlNameToControllerType(sdlName string) string {
 	lower := strings.ToLower(sdlName)
 	switch {
+	case containsAny(lower, "hit box", "hitbox", "snack box", "leverless"):
+		return "hitbox"
+	case containsAny(lower, "fightstick", "fight stick", "fighting stick", "arcade stick", "arcade pro", "panthera", "qanba"):
+		return "arcade"
 	case strings.Contains(lower, "dualsense") || strings.Contains(lower, "ps5") ||
 		strings.Contains(lower, "dualshock") || strings.Contains(lower, "ps4") ||
 		strings.Contains(lower, "ps3") || strings.Contains(lower, "ps2") ||
@@ -256,6 +300,16 @@ func sdlNameToControllerType(sdlName string) string {
 	}
 }
 
+// containsAny reports whether s contains any of substrs.
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
 // applyAxisToState sets the appropriate GamepadState field for a semantic axis name.
 // HID Y axes are positive-downward; we negate them here to match the XInput
 // convention (positive-upward). The frontend renderer inverts Y again when drawing
@@ -274,7 +328,39 @@ func applyAxisToState(state *GamepadState, target string, v float64) {
 		state.Triggers.LT.Value = v
 	case "rt":
 		state.Triggers.RT.Value = v
+	case "wheel":
+		state.Wheel.Angle = v
+	case "throttle":
+		state.Wheel.Throttle = v
+	case "brake":
+		state.Wheel.Brake = v
+	case "clutch":
+		state.Wheel.Clutch = v
+	case "handbrake":
+		state.Wheel.Handbrake = v
+	case "roll":
+		state.Flight.Roll = v
+	case "pitch":
+		state.Flight.Pitch = -v
+	case "yaw":
+		state.Flight.Yaw = v
+	case "thrust":
+		state.Flight.Thrust = v
+	default:
+		if n, ok := flightIndex(target, "axis", MaxFlightAxes); ok {
+			state.Flight.Axes[n-1] = v
+		}
+	}
+}
+
+// lastHIDReport returns the most recent report from a WM_INPUT payload. If
+// multiple HID reports are batched in a single message (dwCount > 1), only the
+// last one (most recent data) is used.
+func lastHIDReport(rawData []byte, reportSize uint32) []byte {
+	if reportSize > 0 && uint32(len(rawData)) > reportSize {
+		return rawData[uint32(len(rawData))-reportSize:]
 	}
+	return rawData
 }
 
 // ---------------------------------------------------------------------------
@@ -320,7 +406,7 @@ func isNintendoController(vendorID uint16) bool {
 //
 // Handles report ID 0x30 (full mode, 60Hz) and 0x3F (simple HID mode).
 // Returns (state, false) for unknown or too-short reports.
-func parseSwitchProReport(name string, rawData []byte, dz float64) (GamepadState, bool) {
+func parseSwitchProReport(name string, rawData []byte, dz Deadzones) (GamepadState, bool) {
 	state := GamepadState{
 		Connected:      true,
 		ControllerType: "switch_pro",
@@ -353,7 +439,7 @@ func parseSwitchProReport(name string, rawData []byte, dz float64) (GamepadState
 //	Byte 5:    Left buttons    — Down(0x01) Up(0x02) Right(0x04) Left(0x08) SR(0x10) SL(0x20) L(0x40) ZL(0x80)
 //	Bytes 6-8: Left stick      — X = [6] | ([7]&0x0F)<<8; Y = [7]>>4 | [8]<<4
 //	Bytes 9-11: Right stick    — same packing
-func parseSwitchProFull(name string, rawData []byte, dz float64) (GamepadState, bool) {
+func parseSwitchProFull(name string, rawData []byte, dz Deadzones) (GamepadState, bool) {
 	if len(rawData) < 12 {
 		return GamepadState{Connected: true, ControllerType: "switch_pro", Name: name}, false
 	}
@@ -370,7 +456,9 @@ func parseSwitchProFull(name string, rawData []byte, dz float64) (GamepadState,
 	state.Buttons.X = b3&0x02 != 0
 	state.Buttons.B = b3&0x04 != 0
 	state.Buttons.A = b3&0x08 != 0
-	// bits 4-5: SR/SL (Joy-Con only, ignored for Pro Controller)
+	// bits 4-5: SR/SL (Joy-Con (R) rail; always 0 on a Pro Controller)
+	state.Extra.P1 = b3&0x10 != 0   // SR
+	state.Extra.P3 = b3&0x20 != 0   // SL
 	state.Buttons.RB = b3&0x40 != 0 // R
 	if b3&0x80 != 0 {               // ZR (digital)
 		state.Triggers.RT.Value = 1.0
@@ -391,7 +479,9 @@ func parseSwitchProFull(name string, rawData []byte, dz float64) (GamepadState,
 	state.Dpad.Up = b5&0x02 != 0
 	state.Dpad.Right = b5&0x04 != 0
 	state.Dpad.Left = b5&0x08 != 0
-	// bits 4-5: SR/SL (Joy-Con only)
+	// bits 4-5: SR/SL (Joy-Con (L) rail)
+	state.Extra.P4 = b5&0x10 != 0   // SR
+	state.Extra.P2 = b5&0x20 != 0   // SL
 	state.Buttons.LB = b5&0x40 != 0 // L
 	if b5&0x80 != 0 {               // ZL (digital)
 		state.Triggers.LT.Value = 1.0
@@ -408,10 +498,8 @@ func parseSwitchProFull(name string, rawData []byte, dz float64) (GamepadState,
 	// Normalize 12-bit (0-4095, centre ~2048) to [-1.0, 1.0].
 	// Nintendo 0x30 proprietary format: Y increases upward (positive-up),
 	// already matching XInput convention — no negation needed.
-	state.Sticks.Left.Position.X = applyDeadzone(normalize12bit(lx), dz)
-	state.Sticks.Left.Position.Y = applyDeadzone(normalize12bit(ly), dz)
-	state.Sticks.Right.Position.X = applyDeadzone(normalize12bit(rx), dz)
-	state.Sticks.Right.Position.Y = applyDeadzone(normalize12bit(ry), dz)
+	state.Sticks.Left.Position = dz.stick(Vector{X: normalize12bit(lx), Y: normalize12bit(ly)})
+	state.Sticks.Right.Position = dz.stick(Vector{X: normalize12bit(rx), Y: normalize12bit(ry)})
 
 	return state, true
 }
@@ -427,7 +515,7 @@ func parseSwitchProFull(name string, rawData []byte, dz float64) (GamepadState,
 //	Byte 3:  Hat switch (0=N,1=NE,2=E,3=SE,4=S,5=SW,6=W,7=NW,8=centre)
 //	Bytes 4-5: Left stick  (uint16 LE: X, Y)  — 0-65535, centre ~32768
 //	Bytes 6-7: Right stick (uint16 LE: X, Y)  — same range
-func parseSwitchProSimple(name string, rawData []byte, dz float64) (GamepadState, bool) {
+func parseSwitchProSimple(name string, rawData []byte, dz Deadzones) (GamepadState, bool) {
 	if len(rawData) < 8 {
 		return GamepadState{Connected: true, ControllerType: "switch_pro", Name: name}, false
 	}
@@ -484,10 +572,8 @@ func parseSwitchProSimple(name string, rawData []byte, dz float64) (GamepadState
 		rx := uint16(rawData[8]) | (uint16(rawData[9]) << 8)
 		ry := uint16(rawData[10]) | (uint16(rawData[11]) << 8)
 
-		state.Sticks.Left.Position.X = applyDeadzone(normalize16bit(lx), dz)
-		state.Sticks.Left.Position.Y = applyDeadzone(-normalize16bit(ly), dz)
-		state.Sticks.Right.Position.X = applyDeadzone(normalize16bit(rx), dz)
-		state.Sticks.Right.Position.Y = applyDeadzone(-normalize16bit(ry), dz)
+		state.Sticks.Left.Position = dz.stick(Vector{X: normalize16bit(lx), Y: -normalize16bit(ly)})
+		state.Sticks.Right.Position = dz.stick(Vector{X: normalize16bit(rx), Y: -normalize16bit(ry)})
 	}
 
 	return state, true
@@ -1,7 +1,9 @@
 package gamepad
 
 import (
+	"maps"
 	"math"
+	"slices"
 	"testing"
 )
 
@@ -127,6 +129,15 @@ func TestSDLNameToControllerType(t *testing.T) {
 		{"Switch Pro", "Switch Pro Controller", "switch_pro"},
 		{"Nintendo Switch", "Nintendo Switch Pro Controller", "switch_pro"},
 
+		// Arcade sticks and hitboxes — before the PlayStation keywords, which
+		// many sticks in PS4 mode also carry.
+		{"Qanba", "Qanba Obsidian", "arcade"},
+		{"Razer Panthera", "Razer Panthera PS4", "arcade"},
+		{"HORI RAP", "HORI Real Arcade Pro 4", "arcade"},
+		{"Mad Catz", "Mad Catz FightStick TE2+ PS4", "arcade"},
+		{"Hit Box", "Hit Box (PS4 mode)", "hitbox"},
+		{"Snack Box", "Snack Box Micro", "hitbox"},
+
 		// Default fallback — must return lowercase "xbox", not "Xbox".
 		{"Xbox 360", "Microsoft Xbox 360 Wireless Controller", "xbox"},
 		{"Generic", "Generic USB Joystick", "xbox"},
@@ -164,6 +175,35 @@ func toLowerASCII(s string) string {
 	return string(b)
 }
 
+// TestArcadeMappings verifies that the fight stick entries of knownDevices
+// keep the gamepad layout of the mapping they replaced, and that XInput
+// arcade sticks outside the table are recognised by their subtype.
+func TestArcadeMappings(t *testing.T) {
+	for k, m := range knownDevices {
+		switch m.Name {
+		case "arcade", "hitbox":
+		default:
+			continue
+		}
+		base := playstation5Mapping
+		if m.HIDButtons == nil {
+			base = xboxMapping
+		}
+		if !slices.Equal(m.Axes, base.Axes) || !slices.Equal(m.Buttons, base.Buttons) || !maps.Equal(m.HIDButtons, base.HIDButtons) {
+			t.Errorf("%04x:%04x: %s mapping differs from %s", k.VendorID, k.ProductID, m.Name, base.Name)
+		}
+	}
+	if got := xinputMapping(xboxMapping, xinputSubTypeArcadeStick); got != arcadeXboxMapping {
+		t.Errorf("xinputMapping(xbox, arcade stick) = %s, want arcade", got.Name)
+	}
+	if got := xinputMapping(switchProMapping, xinputSubTypeArcadeStick); got != switchProMapping {
+		t.Errorf("xinputMapping(switch_pro, arcade stick) = %s, want switch_pro", got.Name)
+	}
+	if got := xinputMapping(xboxMapping, 0x01); got != xboxMapping { // XINPUT_DEVSUBTYPE_GAMEPAD
+		t.Errorf("xinputMapping(xbox, gamepad) = %s, want xbox", got.Name)
+	}
+}
+
 // TestApplyButton verifies that applyButton sets the correct GamepadState field
 // and that an empty/unknown target is a no-op (does not panic).
 func TestApplyButton(t *testing.T) {
@@ -183,6 +223,17 @@ func TestApplyButton(t *testing.T) {
 		}
 	})
 
+	t.Run("paddle and function targets set Extra", func(t *testing.T) {
+		var state GamepadState
+		for _, target := range []string{"paddle1", "paddle2", "paddle3", "paddle4", "fn1", "fn2"} {
+			applyButton(&state, target)
+		}
+		want := ExtraButtonState{P1: true, P2: true, P3: true, P4: true, Fn1: true, Fn2: true}
+		if state.Extra != want || state.Buttons != (ButtonState{}) {
+			t.Errorf("applyButton(paddles, fn) = Extra %+v, Buttons %+v; want all extra buttons only", state.Extra, state.Buttons)
+		}
+	})
+
 	t.Run("empty target does not panic and leaves state unchanged", func(t *testing.T) {
 		var state GamepadState
 		// Must not panic.
@@ -3,12 +3,13 @@
 package gamepad
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"syscall"
-	"unicode/utf16"
 	"unsafe"
 )
 
@@ -24,6 +25,9 @@ var (
 	procHidPGetButtonCaps = modHid.NewProc("HidP_GetButtonCaps")
 	procHidPGetUsages     = modHid.NewProc("HidP_GetUsages")
 	procHidPGetUsageValue = modHid.NewProc("HidP_GetUsageValue")
+
+	procHidDGetAttributes         = modHid.NewProc("HidD_GetAttributes")
+	procHidDGetSerialNumberString = modHid.NewProc("HidD_GetSerialNumberString")
 )
 
 // GetRawInputDeviceInfoW is also used in rawinput package; we bind it separately
@@ -57,6 +61,18 @@ const (
 // Windows SDK struct layouts
 // ---------------------------------------------------------------------------
 
+// hiddAttributes mirrors HIDD_ATTRIBUTES from hidsdi.h.
+type hiddAttributes struct {
+	Size          uint32
+	VendorID      uint16
+	ProductID     uint16
+	VersionNumber uint16
+}
+
+// hidSerialMaxChars is the buffer size for HidD_GetSerialNumberString; the
+// USB string descriptor limit is 126 UTF-16 characters plus a terminator.
+const hidSerialMaxChars = 127
+
 // hidpCaps mirrors HIDP_CAPS from hidpi.h.
 type hidpCaps struct {
 	Usage                     uint16
@@ -217,6 +233,9 @@ type hidDeviceInfo struct {
 	isXInput  bool // filtered out: device is an XInput virtual HID
 	isInvalid bool // failed to initialise; skip future events
 
+	serial         string // HidD_GetSerialNumberString; empty if not reported
+	productVersion uint16 // HIDD_ATTRIBUTES.VersionNumber (bcdDevice)
+
 	// useCustomParser is true for Nintendo controllers whose USB HID descriptor
 	// defines a fake standard HID layout that doesn't match the actual proprietary
 	// report format. When set, parseHIDReport bypasses HidP_* entirely and uses
@@ -244,6 +263,23 @@ type hidDeviceInfo struct {
 	// valid gamepad data (derived from value caps and button caps during init).
 	// If nil, the device does not use report IDs and all reports are valid.
 	expectedReportIDs map[uint8]struct{}
+
+	// raw is true while the device is read without a mapping (see
+	// Reader.SetRawMode). Set from other goroutines than the one parsing.
+	raw atomic.Bool
+
+	// calib learns the ranges of the trigger axes (see
+	// Reader.SetTriggerCalibration); nil reads them over the logical range.
+	calib *triggerCalibration
+}
+
+// triggerRange returns the range to read the trigger axis usage over, having
+// raw reported: the one calib learned, or the logical range lMin to lMax.
+func (dev *hidDeviceInfo) triggerRange(usage uint16, raw uint32, lMin, lMax int32) (int32, int32) {
+	if dev.calib == nil {
+		return lMin, lMax
+	}
+	return dev.calib.observe(deviceKey{VendorID: dev.vendorID, ProductID: dev.productID}, usage, int32(raw), lMin, lMax)
 }
 
 // ---------------------------------------------------------------------------
@@ -251,10 +287,16 @@ type hidDeviceInfo struct {
 // ---------------------------------------------------------------------------
 
 func isXInputDevice(hDevice uintptr) bool {
+	return strings.Contains(strings.ToUpper(rawInputDeviceName(hDevice)), "IG_")
+}
+
+// rawInputDeviceName returns the device interface path of a Raw Input device
+// (RIDI_DEVICENAME), or "" if it cannot be read.
+func rawInputDeviceName(hDevice uintptr) string {
 	var size uint32
 	procGetRawInputDevInfo.Call(hDevice, ridiDevNameHID, 0, uintptr(unsafe.Pointer(&size)))
 	if size == 0 {
-		return false
+		return ""
 	}
 	buf := make([]uint16, size)
 	ret, _, _ := procGetRawInputDevInfo.Call(
@@ -262,10 +304,72 @@ func isXInputDevice(hDevice uintptr) bool {
 		uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)),
 	)
 	if ret == ^uintptr(0) {
-		return false
+		return ""
+	}
+	return syscall.UTF16ToString(buf)
+}
+
+// readHIDIdentity opens the device interface without read/write access (so it
+// works while other applications hold the device) and reads its serial number
+// string and HIDD_ATTRIBUTES.VersionNumber (bcdDevice). Missing values are
+// returned as zero; many controllers report no serial.
+func readHIDIdentity(hDevice uintptr) (serial string, version uint16) {
+	path := rawInputDeviceName(hDevice)
+	if path == "" {
+		return "", 0
 	}
-	name := string(utf16.Decode(buf))
-	return strings.Contains(strings.ToUpper(name), "IG_")
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return "", 0
+	}
+	h, err := syscall.CreateFile(pathPtr, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE, nil, syscall.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		slog.Debug("hidinput: cannot open device for identity", "path", path, "error", err)
+		return "", 0
+	}
+	defer syscall.CloseHandle(h)
+
+	attrs := hiddAttributes{Size: uint32(unsafe.Sizeof(hiddAttributes{}))}
+	if ok, _, _ := procHidDGetAttributes.Call(uintptr(h), uintptr(unsafe.Pointer(&attrs))); ok != 0 {
+		version = attrs.VersionNumber
+	}
+	var buf [hidSerialMaxChars]uint16
+	if ok, _, _ := procHidDGetSerialNumberString.Call(uintptr(h), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)*2)); ok != 0 {
+		serial = strings.TrimSpace(syscall.UTF16ToString(buf[:]))
+	}
+	return serial, version
+}
+
+// hidOutputReportLength returns the output report length of the device's HID
+// descriptor (0 if unknown).
+func hidOutputReportLength(dev *hidDeviceInfo) int {
+	if dev == nil {
+		return 0
+	}
+	return int(dev.caps.OutputReportByteLength)
+}
+
+// writeHIDOutputReport opens the device interface for writing and sends it
+// one output report, which starts with its report ID.
+func writeHIDOutputReport(hDevice uintptr, report []byte) error {
+	path := rawInputDeviceName(hDevice)
+	if path == "" {
+		return errors.New("device path unavailable")
+	}
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	h, err := syscall.CreateFile(pathPtr, syscall.GENERIC_WRITE, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE, nil, syscall.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return fmt.Errorf("open device for writing: %w", err)
+	}
+	defer syscall.CloseHandle(h)
+	var written uint32
+	if err := syscall.WriteFile(h, report, &written, nil); err != nil {
+		return fmt.Errorf("write output report: %w", err)
+	}
+	return nil
 }
 
 // ---------------------------------------------------------------------------
@@ -300,17 +404,10 @@ func initHIDDevice(hDevice uintptr) *hidDeviceInfo {
 	}
 	dev.vendorID = *(*uint16)(unsafe.Pointer(&infoBuf[8]))
 	dev.productID = *(*uint16)(unsafe.Pointer(&infoBuf[12]))
+	dev.serial, dev.productVersion = readHIDIdentity(hDevice)
 
-	dev.mapping = GetMapping(dev.vendorID, dev.productID)
-	dev.name = fmt.Sprintf("%s (VID_%04X&PID_%04X)", dev.mapping.Name, dev.vendorID, dev.productID)
-
-	// Nintendo controllers (Switch Pro, Joy-Con, etc.) have USB HID descriptors
-	// that describe a fake standard HID layout for report ID 0x30. The actual
-	// data follows a proprietary protocol with completely different byte offsets.
-	// HidP_* parse the fake layout and produce garbage (timer byte as buttons,
-	// button bytes as axes). Use a custom direct-byte parser instead.
-	if isNintendoController(dev.vendorID) {
-		dev.useCustomParser = true
+	dev.setIdentity()
+	if dev.useCustomParser {
 		slog.Info("hidinput: Nintendo device detected, using custom report parser", "device", dev.name)
 		return dev
 	}
@@ -334,13 +431,45 @@ func initHIDDevice(hDevice uintptr) *hidDeviceInfo {
 		return dev
 	}
 
+	dev.initCaps()
+	return dev
+}
+
+// setIdentity resolves the device mapping and display name from the VID/PID
+// and selects the parser.
+func (dev *hidDeviceInfo) setIdentity() {
+	dev.mapping = GetMapping(dev.vendorID, dev.productID)
+	dev.name = fmt.Sprintf("%s (VID_%04X&PID_%04X)", dev.mapping.Name, dev.vendorID, dev.productID)
+
+	// Nintendo controllers (Switch Pro, Joy-Con, etc.) have USB HID descriptors
+	// that describe a fake standard HID layout for report ID 0x30. The actual
+	// data follows a proprietary protocol with completely different byte offsets.
+	// HidP_* parse the fake layout and produce garbage (timer byte as buttons,
+	// button bytes as axes). Use a custom direct-byte parser instead.
+	dev.useCustomParser = isNintendoController(dev.vendorID)
+	dev.raw.Store(dev.mapping.Name == "raw" && !dev.useCustomParser)
+}
+
+// setRawMode switches the device to raw mode or back, and reports whether it
+// has one: the custom Nintendo parser has no raw values to report.
+func (dev *hidDeviceInfo) setRawMode(enabled bool) bool {
+	if dev.useCustomParser {
+		return false
+	}
+	dev.raw.Store(enabled)
+	return true
+}
+
+// initCaps reads the HidP_* capability tables from dev.preparsedData and
+// resolves the axis and button layout. Sets isInvalid if HidP_GetCaps fails.
+func (dev *hidDeviceInfo) initCaps() {
 	ppd := uintptr(unsafe.Pointer(&dev.preparsedData[0]))
 
 	status, _, _ := procHidPGetCaps.Call(ppd, uintptr(unsafe.Pointer(&dev.caps)))
 	if status != hidpStatusSuccess {
 		slog.Warn("hidinput: HidP_GetCaps failed", "status", fmt.Sprintf("0x%08x", status), "device", dev.name)
 		dev.isInvalid = true
-		return dev
+		return
 	}
 
 	if dev.caps.NumberInputValueCaps > 0 {
@@ -405,8 +534,113 @@ func initHIDDevice(hDevice uintptr) *hidDeviceInfo {
 		dev.axisMap = buildAxisMap(dev.mapping)
 		slog.Info("hidinput: initialised device", "device", dev.name, "axes", len(dev.valueCaps), "buttons", dev.buttonCount)
 	}
+}
 
-	return dev
+// controllerType returns the ControllerType of the device's reports: "raw" in
+// raw mode, its mapping's name, or for SDL-mapped devices the type its SDL
+// name suggests.
+// Built-in arcade stick and hitbox mappings keep their type, since the SDL
+// database often names sticks in PS4 mode after the DualShock 4.
+func (dev *hidDeviceInfo) controllerType() string {
+	if dev.raw.Load() {
+		return "raw"
+	}
+	if dev.sdlMap == nil || dev.mapping.Name == "arcade" || dev.mapping.Name == "hitbox" {
+		return dev.mapping.Name
+	}
+	return sdlNameToControllerType(dev.sdlMap.Name)
+}
+
+// describe fills the mapping and capability fields of c.
+func (dev *hidDeviceInfo) describe(c *ControllerInfo) {
+	if dev.useCustomParser {
+		c.Mapping = "nintendo"
+		return
+	}
+	c.Mapping = "builtin"
+	if lookupCustomMapping(dev.vendorID, dev.productID) != nil {
+		c.Mapping = "custom"
+	}
+	if dev.sdlMap != nil {
+		c.Mapping = "sdl_db"
+		c.SDLName = dev.sdlMap.Name
+	}
+	c.ControllerType = dev.controllerType()
+	c.Axes = len(dev.axisOrder)
+	c.Buttons = int(dev.buttonCount)
+	for _, ae := range dev.axisOrder {
+		c.RawAxes = append(c.RawAxes, hidAxisName(ae.usagePage, ae.usage))
+	}
+	c.Hats = dev.hatCount()
+}
+
+// hatCount returns the number of hat switches in the device's value caps.
+func (dev *hidDeviceInfo) hatCount() int {
+	n := 0
+	for i := range dev.valueCaps {
+		vc := &dev.valueCaps[i]
+		usageMax := vc.UsageMax
+		if vc.IsRange == 0 || usageMax < vc.UsageMin {
+			usageMax = vc.UsageMin
+		}
+		if vc.UsagePage == usagePageGenericDesktop && vc.UsageMin <= hidUsageHat && hidUsageHat <= usageMax {
+			n++
+		}
+	}
+	return n
+}
+
+// sdlBindings returns the SDL bindings of the mapping the device is read
+// with (see Reader.ExportSDLMapping): its SDL DB entry's, or those of its
+// legacy mapping (hidSDLBindings). ok is false for the layouts SDL cannot
+// express: the custom Nintendo parser, raw mode, wheels, and flight sticks.
+func (dev *hidDeviceInfo) sdlBindings() (bindings []string, ok bool) {
+	if dev.useCustomParser || dev.raw.Load() {
+		return nil, false
+	}
+	if dev.sdlMap != nil {
+		return sdlMappingBindings(dev.sdlMap), true
+	}
+	switch dev.mapping.Name {
+	case "wheel", "flightstick", "raw":
+		return nil, false
+	}
+	usages := make([]uint16, len(dev.axisOrder))
+	for i, ae := range dev.axisOrder {
+		usages[i] = ae.usage
+	}
+	return hidSDLBindings(dev.mapping, dev.axisMap, usages, int(dev.buttonCount), dev.hatCount() > 0), true
+}
+
+// capabilities returns the device's Capabilities: the known feature flags
+// plus the raw axis/button counts (zero for the custom Nintendo parser).
+func (dev *hidDeviceInfo) capabilities() Capabilities {
+	caps := knownCapabilities(dev.vendorID, dev.productID, false)
+	if !dev.useCustomParser {
+		caps.NumAxes = len(dev.axisOrder)
+		caps.NumButtons = int(dev.buttonCount)
+	}
+	return caps
+}
+
+// newHIDReplayParser rebuilds a device's report parser from the VID/PID and
+// preparsed data recorded in a capture (see ReplayCapture).
+func newHIDReplayParser(vid, pid uint16, preparsed []byte) (hidReportParser, error) {
+	dev := &hidDeviceInfo{vendorID: vid, productID: pid}
+	dev.setIdentity()
+	if !dev.useCustomParser {
+		if len(preparsed) == 0 {
+			return nil, errors.New("no preparsed data recorded")
+		}
+		dev.preparsedData = preparsed
+		dev.initCaps()
+		if dev.isInvalid {
+			return nil, errors.New("recorded preparsed data rejected by HidP_GetCaps")
+		}
+	}
+	return func(rawData []byte, dz Deadzones) (GamepadState, bool) {
+		return parseHIDReport(dev, rawData, dz)
+	}, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -504,7 +738,7 @@ func buildExpectedReportIDs(valueCaps []hidpValueCaps, buttonCaps []hidpButtonCa
 
 // parseHIDReport parses a single HID input report into a GamepadState.
 // Returns (state, false) if the report should be skipped (incompatible report ID).
-func parseHIDReport(dev *hidDeviceInfo, rawData []byte, dz float64) (GamepadState, bool) {
+func parseHIDReport(dev *hidDeviceInfo, rawData []byte, dz Deadzones) (GamepadState, bool) {
 	// Nintendo controllers have USB HID descriptors that lie about the 0x30
 	// report layout. Bypass HidP_* entirely and use the custom direct-byte
 	// parser that reads the actual proprietary format.
@@ -512,13 +746,9 @@ func parseHIDReport(dev *hidDeviceInfo, rawData []byte, dz float64) (GamepadStat
 		return parseSwitchProReport(dev.name, rawData, dz)
 	}
 
-	controllerType := dev.mapping.Name
-	if dev.sdlMap != nil {
-		controllerType = sdlNameToControllerType(dev.sdlMap.Name)
-	}
 	state := GamepadState{
 		Connected:      true,
-		ControllerType: controllerType,
+		ControllerType: dev.controllerType(),
 		Name:           dev.name,
 	}
 
@@ -542,7 +772,15 @@ func parseHIDReport(dev *hidDeviceInfo, rawData []byte, dz float64) (GamepadStat
 	reportPtr := uintptr(unsafe.Pointer(&rawData[0]))
 	reportLen := uint32(len(rawData))
 
-	parseHatSwitch(dev, &state, ppd, reportPtr, reportLen)
+	if dev.raw.Load() {
+		parseHIDReportRaw(dev, &state, ppd, reportPtr, reportLen)
+		return state, true
+	}
+	if dev.mapping.Name == "flightstick" && dev.sdlMap == nil {
+		parseFlightHats(dev, &state, ppd, reportPtr, reportLen)
+	} else {
+		parseHatSwitch(dev, &state, ppd, reportPtr, reportLen)
+	}
 	pressedButtons := collectPressedButtons(dev, ppd, reportPtr, reportLen)
 
 	if dev.sdlMap != nil {
@@ -550,6 +788,7 @@ func parseHIDReport(dev *hidDeviceInfo, rawData []byte, dz float64) (GamepadStat
 	} else {
 		parseHIDReportLegacy(dev, &state, ppd, reportPtr, reportLen, pressedButtons, dz)
 	}
+	dz.applySticks(&state.Sticks)
 
 	return state, true
 }
@@ -597,6 +836,76 @@ func parseHatSwitch(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr uint
 	}
 }
 
+// parseFlightHats reads every hat switch of a flight stick into
+// state.Flight.Hats, in value-cap order.
+func parseFlightHats(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr uintptr, reportLen uint32) {
+	readHats(dev, ppd, reportPtr, reportLen, func(hat, idx int) { setFlightHat(state, hat, idx) })
+}
+
+// readHats calls fn with the 0-based number and direction index (see
+// hatDirTable) of every hat switch of the report, in value-cap order. Hats
+// share one usage, so each is read from its own link collection.
+func readHats(dev *hidDeviceInfo, ppd, reportPtr uintptr, reportLen uint32, fn func(hat, idx int)) {
+	hat := 0
+	for i := range dev.valueCaps {
+		vc := &dev.valueCaps[i]
+		if vc.UsagePage != usagePageGenericDesktop || vc.UsageMin != hidUsageHat {
+			continue
+		}
+		var value uint32
+		status, _, _ := procHidPGetUsageValue.Call(
+			hidpInput,
+			uintptr(vc.UsagePage),
+			uintptr(vc.LinkCollection),
+			uintptr(hidUsageHat),
+			uintptr(unsafe.Pointer(&value)),
+			ppd,
+			reportPtr,
+			uintptr(reportLen),
+		)
+		if status == hidpStatusSuccess {
+			fn(hat, int(value)-int(vc.LogicalMin))
+		}
+		hat++
+	}
+}
+
+// parseHIDReportRaw reads the report without a mapping into state.Raw (see
+// RawState): the axes of dev.axisOrder over their logical range, the pressed
+// buttons by HID number, and the hat switches.
+func parseHIDReportRaw(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr uintptr, reportLen uint32) {
+	raw := &state.Raw
+	raw.NumAxes = min(len(dev.axisOrder), MaxRawAxes)
+	raw.NumButtons = min(int(dev.buttonCount), MaxRawButtons)
+	for i := range raw.NumAxes {
+		ae := &dev.axisOrder[i]
+		var rawVal uint32
+		status, _, _ := procHidPGetUsageValue.Call(
+			hidpInput,
+			uintptr(ae.usagePage),
+			0,
+			uintptr(ae.usage),
+			uintptr(unsafe.Pointer(&rawVal)),
+			ppd,
+			reportPtr,
+			uintptr(reportLen),
+		)
+		if status == hidpStatusSuccess {
+			lMin, lMax := ae.logicalRange()
+			raw.Axes[i] = normalizeHIDAxis(rawVal, lMin, lMax, false)
+		}
+	}
+	for _, u := range collectPressedButtons(dev, ppd, reportPtr, reportLen) {
+		raw.press(int(u))
+	}
+	readHats(dev, ppd, reportPtr, reportLen, func(hat, idx int) {
+		if hat < MaxRawHats {
+			raw.Hats[hat] = rawHat(idx)
+			raw.NumHats = hat + 1
+		}
+	})
+}
+
 // ---------------------------------------------------------------------------
 // collectPressedButtons — get 1-based HID button usages that are pressed
 // ---------------------------------------------------------------------------
@@ -643,7 +952,7 @@ func collectPressedButtons(dev *hidDeviceInfo, ppd, reportPtr uintptr, reportLen
 // parseHIDReportSDL — SDL gamecontrollerdb mapping path
 // ---------------------------------------------------------------------------
 
-func parseHIDReportSDL(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr uintptr, reportLen uint32, pressedButtons []uint16, dz float64) {
+func parseHIDReportSDL(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr uintptr, reportLen uint32, pressedButtons []uint16, dz Deadzones) {
 	sm := dev.sdlMap
 
 	// --- Axes ---
@@ -668,16 +977,14 @@ func parseHIDReportSDL(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr u
 			continue
 		}
 
-		lMin := ae.logicalMin
-		lMax := ae.logicalMax
-		if lMax < lMin && ae.bitSize > 0 && ae.bitSize < 32 {
-			lMax = (1 << ae.bitSize) - 1
-			lMin = 0
-		}
+		lMin, lMax := ae.logicalRange()
 
 		isDpad := ab.Target == "dpup" || ab.Target == "dpdown" ||
 			ab.Target == "dpleft" || ab.Target == "dpright"
-		isTrigger := ab.Target == "lt" || ab.Target == "rt"
+		isTrigger := isTriggerTarget(ab.Target)
+		if isTrigger {
+			lMin, lMax = dev.triggerRange(ae.usage, rawVal, lMin, lMax)
+		}
 
 		var normalized float64
 		if isDpad {
@@ -708,7 +1015,7 @@ func parseHIDReportSDL(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr u
 			continue
 		}
 
-		normalized = applyDeadzone(normalized, dz)
+		normalized = applyDeadzone(normalized, dz.of(ab.Target))
 		applyAxisToState(state, ab.Target, normalized)
 	}
 
@@ -737,7 +1044,7 @@ func parseHIDReportSDL(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr u
 // parseHIDReportLegacy — usage-code-based fallback path
 // ---------------------------------------------------------------------------
 
-func parseHIDReportLegacy(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr uintptr, reportLen uint32, pressedButtons []uint16, dz float64) {
+func parseHIDReportLegacy(dev *hidDeviceInfo, state *GamepadState, ppd, reportPtr uintptr, reportLen uint32, pressedButtons []uint16, dz Deadzones) {
 	for i := range dev.valueCaps {
 		vc := &dev.valueCaps[i]
 		usageMin := vc.UsageMin
@@ -773,9 +1080,10 @@ func parseHIDReportLegacy(dev *hidDeviceInfo, state *GamepadState, ppd, reportPt
 				lMax = (1 << vc.BitSize) - 1
 				lMin = 0
 			}
-			isTrigger := target == "lt" || target == "rt"
-			normalized := normalizeHIDAxis(value, lMin, lMax, isTrigger)
-			normalized = applyDeadzone(normalized, dz)
+			if isTriggerTarget(strings.TrimSuffix(target, "~")) {
+				lMin, lMax = dev.triggerRange(usage, value, lMin, lMax)
+			}
+			target, normalized := hidAxisValue(target, value, lMin, lMax, dz)
 			applyAxisToState(state, target, normalized)
 		}
 	}
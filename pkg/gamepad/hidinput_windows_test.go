@@ -3,6 +3,7 @@
 package gamepad
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -102,3 +103,44 @@ func TestBuildAxisOrderHandlesUsageRanges(t *testing.T) {
 		}
 	}
 }
+
+// TestHIDRawMode verifies that raw mode switches the reported type and that
+// the custom Nintendo parser has none.
+func TestHIDRawMode(t *testing.T) {
+	dev := &hidDeviceInfo{mapping: xboxMapping}
+	if !dev.setRawMode(true) || dev.controllerType() != "raw" {
+		t.Errorf("controllerType() in raw mode = %q, want raw", dev.controllerType())
+	}
+	if !dev.setRawMode(false) || dev.controllerType() != "xbox" {
+		t.Errorf("controllerType() after raw mode = %q, want xbox", dev.controllerType())
+	}
+	if (&hidDeviceInfo{mapping: switchProMapping, useCustomParser: true}).setRawMode(true) {
+		t.Error("setRawMode() accepted a device read by the Nintendo parser")
+	}
+}
+
+// TestHIDSDLBindingsLayouts verifies which devices have an SDL form: SDL DB
+// devices export their entry, gamepads their legacy mapping, and wheels,
+// flight sticks, raw mode, and the Nintendo parser none.
+func TestHIDSDLBindingsLayouts(t *testing.T) {
+	sdl := &SDLMapping{Buttons: []SDLButtonBinding{{ButtonIndex: 0, Target: "a"}}}
+	if b, ok := (&hidDeviceInfo{mapping: xboxMapping, sdlMap: sdl}).sdlBindings(); !ok || !slices.Equal(b, []string{"a:b0"}) {
+		t.Errorf("sdlBindings(SDL DB) = %v, %v; want [a:b0]", b, ok)
+	}
+	gamepad := &hidDeviceInfo{mapping: xboxMapping, axisMap: buildAxisMap(xboxMapping), buttonCount: 1,
+		axisOrder: []hidAxisEntry{{usagePage: usagePageGenericDesktop, usage: hidUsageX}}}
+	if b, ok := gamepad.sdlBindings(); !ok || !slices.Equal(b, []string{"leftx:a0", "x:b0"}) {
+		t.Errorf("sdlBindings(legacy) = %v, %v; want [leftx:a0 x:b0]", b, ok)
+	}
+	gamepad.setRawMode(true)
+	for _, dev := range []*hidDeviceInfo{
+		gamepad,
+		{mapping: switchProMapping, useCustomParser: true},
+		{mapping: flightStickMapping},
+		{mapping: &DeviceMapping{Name: "wheel"}},
+	} {
+		if _, ok := dev.sdlBindings(); ok {
+			t.Errorf("sdlBindings(%s) succeeded, want no SDL form", dev.mapping.Name)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package gamepad
+
+// Joy-Con product IDs. Each Joy-Con is a HID device of its own; a left and a
+// right one are read as one controller, like the Switch does (see
+// Reader.SetJoyConSideways).
+const (
+	joyConLeftPID  = 0x2006
+	joyConRightPID = 0x2007
+)
+
+// joyConPairName is the name of a left and right Joy-Con read together.
+const joyConPairName = "Joy-Con (L/R)"
+
+// joyConSide tells the two Joy-Cons apart.
+type joyConSide int
+
+const (
+	joyConNone joyConSide = iota // not a Joy-Con
+	joyConLeft
+	joyConRight
+)
+
+// joyConSideOf returns which Joy-Con the device k is, if any.
+func joyConSideOf(k deviceKey) joyConSide {
+	if k.VendorID != nintendoVendorID {
+		return joyConNone
+	}
+	switch k.ProductID {
+	case joyConLeftPID:
+		return joyConLeft
+	case joyConRightPID:
+		return joyConRight
+	}
+	return joyConNone
+}
+
+// isJoyConReport reports whether report can be read as a Joy-Con's. Only
+// full-mode (0x30) reports share the Pro Controller layout; the simple-mode
+// (0x3F) reports a Joy-Con sends until a driver switches it to full mode
+// have a per-side layout with the stick as a hat and are not parsed.
+func isJoyConReport(report []byte) bool {
+	return len(report) > 0 && report[0] == switchProReportFull
+}
+
+// joyConHalf returns the fields of s, a Joy-Con report parsed with the Pro
+// Controller layout, that side has: its buttons and stick, and its rail
+// buttons as paddles (see parseSwitchProFull). The other side's stick reads
+// -1 on both axes in such a report (its bytes are zero) and is dropped.
+func joyConHalf(side joyConSide, s GamepadState) GamepadState {
+	out := GamepadState{Connected: s.Connected, ControllerType: s.ControllerType, Name: s.Name}
+	if side == joyConLeft {
+		copyLeftJoyCon(&out, s)
+	} else {
+		copyRightJoyCon(&out, s)
+	}
+	return out
+}
+
+// mergeJoyCons returns the state of a pair from the last states of its left
+// and right Joy-Con, as joyConHalf reads them. Motion is the right one's.
+func mergeJoyCons(left, right GamepadState) GamepadState {
+	out := GamepadState{Connected: true, ControllerType: left.ControllerType, Name: joyConPairName}
+	copyLeftJoyCon(&out, left)
+	copyRightJoyCon(&out, right)
+	return out
+}
+
+func copyLeftJoyCon(dst *GamepadState, src GamepadState) {
+	dst.Dpad = src.Dpad
+	dst.Buttons.LB = src.Buttons.LB
+	dst.Buttons.Back = src.Buttons.Back // Minus
+	dst.Buttons.Capture = src.Buttons.Capture
+	dst.Sticks.Left = src.Sticks.Left
+	dst.Triggers.LT = src.Triggers.LT
+	dst.Extra.P2, dst.Extra.P4 = src.Extra.P2, src.Extra.P4 // SL, SR
+	dst.Motion = src.Motion
+}
+
+func copyRightJoyCon(dst *GamepadState, src GamepadState) {
+	dst.Buttons.A, dst.Buttons.B = src.Buttons.A, src.Buttons.B
+	dst.Buttons.X, dst.Buttons.Y = src.Buttons.X, src.Buttons.Y
+	dst.Buttons.RB = src.Buttons.RB
+	dst.Buttons.Start = src.Buttons.Start // Plus
+	dst.Buttons.Guide = src.Buttons.Guide // Home
+	dst.Sticks.Right = src.Sticks.Right
+	dst.Triggers.RT = src.Triggers.RT
+	dst.Extra.P1, dst.Extra.P3 = src.Extra.P1, src.Extra.P3 // SR, SL
+	dst.Motion = src.Motion
+}
+
+// joyConSideways returns the state of a single Joy-Con held sideways with
+// its rail away from the player, from its report parsed with the Pro
+// Controller layout: the four buttons become the face buttons by position,
+// the stick is the left stick (turned with the controller), SL/SR are LB/RB,
+// and Minus or Plus is Start. L/ZL and R/ZR are out of reach and dropped, as
+// on the Switch. The left Joy-Con is turned a quarter counter-clockwise, the
+// right one a quarter clockwise.
+func joyConSideways(side joyConSide, s GamepadState) GamepadState {
+	out := GamepadState{Connected: s.Connected, ControllerType: s.ControllerType, Name: s.Name, Motion: s.Motion}
+	if side == joyConLeft {
+		out.Buttons.X = s.Dpad.Up
+		out.Buttons.Y = s.Dpad.Right
+		out.Buttons.B = s.Dpad.Down
+		out.Buttons.A = s.Dpad.Left
+		out.Buttons.LB, out.Buttons.RB = s.Extra.P2, s.Extra.P4
+		out.Buttons.Start = s.Buttons.Back
+		out.Buttons.Capture = s.Buttons.Capture
+		p := s.Sticks.Left.Position
+		out.Sticks.Left = StickState{Position: Vector{X: neg(p.Y), Y: p.X}, Pressed: s.Sticks.Left.Pressed}
+		return out
+	}
+	out.Buttons.B = s.Buttons.X
+	out.Buttons.A = s.Buttons.A
+	out.Buttons.X = s.Buttons.B
+	out.Buttons.Y = s.Buttons.Y
+	out.Buttons.LB, out.Buttons.RB = s.Extra.P3, s.Extra.P1
+	out.Buttons.Start = s.Buttons.Start
+	out.Buttons.Guide = s.Buttons.Guide
+	p := s.Sticks.Right.Position
+	out.Sticks.Left = StickState{Position: Vector{X: p.Y, Y: neg(p.X)}, Pressed: s.Sticks.Right.Pressed}
+	return out
+}
+
+// neg negates v without producing -0, which would encode as "-0".
+func neg(v float64) float64 {
+	if v == 0 {
+		return 0
+	}
+	return -v
+}
+
+// SetJoyConSideways makes the Reader show a Joy-Con that has no partner as a
+// controller of its own held sideways (see joyConSideways), for two-player
+// games with one Joy-Con each. By default a lone Joy-Con shows its half of
+// the layout. A left and a right Joy-Con are always read as one controller:
+// the one that connects second is attached to the first, has no player of
+// its own, and goes on alone when its partner disconnects. Must be called
+// before Run.
+func (r *Reader) SetJoyConSideways(enabled bool) { r.joyConSideways = enabled }
+
+// pairJoyConLocked attaches info, a Joy-Con that is not registered yet, to
+// the first registered Joy-Con of the other side without a partner, and
+// returns that one's key. ok is false if info is not a Joy-Con or has no
+// partner to pair with. Caller must hold r.mu.
+func (r *Reader) pairJoyConLocked(key joystickKey, info *joystickInfo) (primary joystickKey, ok bool) {
+	side := joyConSideOf(info.devKey)
+	if side == joyConNone {
+		return 0, false
+	}
+	for _, k := range r.joystickOrder {
+		p := r.joysticks[k]
+		if p == nil || p.partner != nil {
+			continue
+		}
+		if ps := joyConSideOf(p.devKey); ps == joyConNone || ps == side {
+			continue
+		}
+		p.partner, p.partnerKey = info, key
+		p.name = joyConPairName
+		r.joyConAttached[key] = k
+		return k, true
+	}
+	return 0, false
+}
+
+// unpairJoyConLocked detaches the partner of the Joy-Con registered under
+// primary and gives it back its own name. The partner's key is returned so
+// the caller can register it on its own. Caller must hold r.mu.
+func (r *Reader) unpairJoyConLocked(primary joystickKey) (partnerKey joystickKey, partner *joystickInfo) {
+	p := r.joysticks[primary]
+	if p == nil || p.partner == nil {
+		return 0, nil
+	}
+	partnerKey, partner = p.partnerKey, p.partner
+	delete(r.joyConAttached, partnerKey)
+	p.partner, p.partnerKey = nil, 0
+	if dev := r.hidDevices[p.hDevice]; dev != nil {
+		p.name = dev.name
+	}
+	return partnerKey, partner
+}
+
+// joyConStateLocked returns the state to publish for the Joy-Con registered
+// as info after the device from sent a report parsed as s: the pair's merged
+// state, or the lone Joy-Con's half or sideways state. Caller must hold r.mu.
+func (r *Reader) joyConStateLocked(info *joystickInfo, from joystickKey, s GamepadState) GamepadState {
+	side := joyConSideOf(info.devKey)
+	if info.partner == nil {
+		info.half = joyConHalf(side, s)
+		if r.joyConSideways {
+			return joyConSideways(side, s)
+		}
+		return info.half
+	}
+	left, right := info, info.partner
+	if side == joyConRight {
+		left, right = right, left
+	}
+	if from == info.partnerKey {
+		info.partner.half = joyConHalf(joyConSideOf(info.partner.devKey), s)
+	} else {
+		info.half = joyConHalf(side, s)
+	}
+	return mergeJoyCons(left.half, right.half)
+}
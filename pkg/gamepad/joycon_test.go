@@ -0,0 +1,161 @@
+package gamepad
+
+import "testing"
+
+// joyConReport builds a 0x30 full-mode report with the given button bytes
+// (3: right, 4: shared, 5: left) and the stick of side centred (both for
+// joyConNone); the other stick's bytes stay zero, as a single Joy-Con sends
+// them.
+func joyConReport(b3, b4, b5 byte, side joyConSide) []byte {
+	report := make([]byte, 49)
+	report[0] = switchProReportFull
+	report[3], report[4], report[5] = b3, b4, b5
+	centre := []byte{0x00, 0x08, 0x80} // 12-bit 2048, 2048
+	if side != joyConRight {
+		copy(report[6:], centre)
+	}
+	if side != joyConLeft {
+		copy(report[9:], centre)
+	}
+	return report
+}
+
+func parseJoyCon(t *testing.T, report []byte) GamepadState {
+	t.Helper()
+	s, ok := parseSwitchProReport("Joy-Con", report, uniformDeadzones(0.05))
+	if !ok {
+		t.Fatalf("parseSwitchProReport(% x) failed", report[:6])
+	}
+	return s
+}
+
+// TestJoyConSideOf verifies that only the two Joy-Con PIDs are Joy-Cons.
+func TestJoyConSideOf(t *testing.T) {
+	tests := []struct {
+		key  deviceKey
+		want joyConSide
+	}{
+		{deviceKey{nintendoVendorID, joyConLeftPID}, joyConLeft},
+		{deviceKey{nintendoVendorID, joyConRightPID}, joyConRight},
+		{deviceKey{nintendoVendorID, 0x2009}, joyConNone}, // Pro Controller
+		{deviceKey{0x20d6, joyConLeftPID}, joyConNone},
+	}
+	for _, tt := range tests {
+		if got := joyConSideOf(tt.key); got != tt.want {
+			t.Errorf("joyConSideOf(%04x:%04x) = %d, want %d", tt.key.VendorID, tt.key.ProductID, got, tt.want)
+		}
+	}
+	if isJoyConReport([]byte{switchProReportSimple, 0, 0, 8}) || !isJoyConReport(joyConReport(0, 0, 0, joyConLeft)) {
+		t.Error("isJoyConReport() must accept full-mode reports only")
+	}
+}
+
+// TestJoyConRailButtons verifies that SL/SR of both Joy-Cons are read as
+// paddles, upper ones under each hand first.
+func TestJoyConRailButtons(t *testing.T) {
+	s := parseJoyCon(t, joyConReport(0x10|0x20, 0, 0x10|0x20, joyConNone))
+	if want := (ExtraButtonState{P1: true, P2: true, P3: true, P4: true}); s.Extra != want {
+		t.Errorf("Extra = %+v, want %+v", s.Extra, want)
+	}
+	s = parseJoyCon(t, joyConReport(0x10, 0, 0x20, joyConNone)) // right SR, left SL
+	if want := (ExtraButtonState{P1: true, P2: true}); s.Extra != want {
+		t.Errorf("Extra = %+v, want %+v", s.Extra, want)
+	}
+}
+
+// TestJoyConPairMerge verifies that a pair combines each Joy-Con's own half
+// and ignores the zero stick bytes of the side a Joy-Con does not have.
+func TestJoyConPairMerge(t *testing.T) {
+	left := joyConHalf(joyConLeft, parseJoyCon(t, joyConReport(0, 0x01|0x20, 0x02|0x40, joyConLeft))) // Minus, Capture, Up, L
+	right := joyConHalf(joyConRight, parseJoyCon(t, joyConReport(0x08|0x80, 0x02, 0, joyConRight)))   // A, ZR, Plus
+
+	if left.Sticks.Right != (StickState{}) || right.Sticks.Left != (StickState{}) {
+		t.Fatalf("halves kept the missing stick: left %+v, right %+v", left.Sticks, right.Sticks)
+	}
+	s := mergeJoyCons(left, right)
+	want := GamepadState{Connected: true, ControllerType: "switch_pro", Name: joyConPairName}
+	want.Buttons = ButtonState{A: true, LB: true, Back: true, Start: true, Capture: true}
+	want.Dpad.Up = true
+	want.Triggers.RT.Value = 1
+	if s != want {
+		t.Errorf("mergeJoyCons() =\n%+v\nwant\n%+v", s, want)
+	}
+}
+
+// TestJoyConSideways verifies the layout of a single Joy-Con held sideways.
+func TestJoyConSideways(t *testing.T) {
+	// Left: Left (bottom when sideways) → A, SL → LB, Minus → Start.
+	left := parseJoyCon(t, joyConReport(0, 0x01, 0x08|0x20, joyConLeft))
+	left.Sticks.Left.Position = Vector{X: 0.5, Y: 0.25}
+	s := joyConSideways(joyConLeft, left)
+	if s.Buttons != (ButtonState{A: true, LB: true, Start: true}) || s.Dpad != (DpadState{}) {
+		t.Errorf("left sideways buttons = %+v, dpad %+v; want A, LB, Start", s.Buttons, s.Dpad)
+	}
+	if want := (Vector{X: -0.25, Y: 0.5}); s.Sticks.Left.Position != want {
+		t.Errorf("left sideways stick = %+v, want %+v", s.Sticks.Left.Position, want)
+	}
+
+	// Right: X (right when sideways) → B, SR → RB, Home → Guide, R dropped.
+	right := parseJoyCon(t, joyConReport(0x02|0x10|0x40, 0x10, 0, joyConRight))
+	right.Sticks.Right.Position = Vector{X: 0.5, Y: 0.25}
+	s = joyConSideways(joyConRight, right)
+	if s.Buttons != (ButtonState{B: true, RB: true, Guide: true}) || s.Extra != (ExtraButtonState{}) {
+		t.Errorf("right sideways buttons = %+v, extra %+v; want B, RB, Guide", s.Buttons, s.Extra)
+	}
+	if want := (Vector{X: 0.25, Y: -0.5}); s.Sticks.Left.Position != want || s.Sticks.Right != (StickState{}) {
+		t.Errorf("right sideways sticks = %+v, want left %+v", s.Sticks, want)
+	}
+}
+
+// TestJoyConPairing verifies that a Joy-Con is attached to a registered one
+// of the other side, that the pair publishes the merged state, and that
+// unpairing hands the partner back.
+func TestJoyConPairing(t *testing.T) {
+	r := NewReader()
+	joyCon := func(pid uint16, name string) *joystickInfo {
+		return &joystickInfo{mapping: switchProMapping, name: name, sourceType: "hid", devKey: deviceKey{nintendoVendorID, pid}}
+	}
+	leftKey, rightKey, otherKey := hidKey(0x100), hidKey(0x200), hidKey(0x300)
+	left := joyCon(joyConLeftPID, "Joy-Con (L)")
+	r.joysticks[leftKey] = left
+	r.joystickOrder = append(r.joystickOrder, leftKey)
+
+	if _, ok := r.pairJoyConLocked(otherKey, joyCon(joyConLeftPID, "Joy-Con (L)")); ok {
+		t.Fatal("pairJoyConLocked() paired two left Joy-Cons")
+	}
+	if _, ok := r.pairJoyConLocked(otherKey, &joystickInfo{mapping: xboxMapping, devKey: deviceKey{0x045e, 0x028e}}); ok {
+		t.Fatal("pairJoyConLocked() paired a non-Joy-Con")
+	}
+	right := joyCon(joyConRightPID, "Joy-Con (R)")
+	primary, ok := r.pairJoyConLocked(rightKey, right)
+	if !ok || primary != leftKey || left.partner != right || left.name != joyConPairName {
+		t.Fatalf("pairJoyConLocked(right) = %v, %v; partner %p, name %q", primary, ok, left.partner, left.name)
+	}
+	if _, ok := r.pairJoyConLocked(otherKey, joyCon(joyConRightPID, "Joy-Con (R)")); ok {
+		t.Fatal("pairJoyConLocked() attached a second right Joy-Con to a pair")
+	}
+
+	r.joyConStateLocked(left, leftKey, parseJoyCon(t, joyConReport(0, 0, 0x02, joyConLeft)))        // Up
+	s := r.joyConStateLocked(left, rightKey, parseJoyCon(t, joyConReport(0x08, 0, 0, joyConRight))) // A
+	if !s.Buttons.A || !s.Dpad.Up || s.Name != joyConPairName {
+		t.Errorf("pair state = %+v, want A and Up of both halves", s)
+	}
+
+	partnerKey, partner := r.unpairJoyConLocked(leftKey)
+	if partnerKey != rightKey || partner != right || left.partner != nil {
+		t.Fatalf("unpairJoyConLocked() = %v, %p; left partner %p", partnerKey, partner, left.partner)
+	}
+	if _, attached := r.joyConAttached[rightKey]; attached {
+		t.Error("right Joy-Con still attached after unpairing")
+	}
+	s = r.joyConStateLocked(left, leftKey, parseJoyCon(t, joyConReport(0, 0, 0x02, joyConLeft)))
+	if s.Buttons.A || !s.Dpad.Up {
+		t.Errorf("state after unpairing = %+v, want the left half only", s)
+	}
+
+	r.SetJoyConSideways(true)
+	s = r.joyConStateLocked(left, leftKey, parseJoyCon(t, joyConReport(0, 0, 0x02, joyConLeft)))
+	if !s.Buttons.X || s.Dpad.Up {
+		t.Errorf("sideways state = %+v, want Up as X", s)
+	}
+}
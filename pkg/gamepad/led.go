@@ -0,0 +1,121 @@
+package gamepad
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+)
+
+// Color is a lightbar color.
+type Color struct {
+	R, G, B uint8
+}
+
+// ParseColor parses a "#rrggbb" color.
+func ParseColor(s string) (Color, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return Color{}, fmt.Errorf("color %q is not #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("color %q is not #rrggbb", s)
+	}
+	return Color{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
+
+// String returns c as "#rrggbb".
+func (c Color) String() string { return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B) }
+
+// MaxPlayerLEDs is the highest player number the player LEDs can show.
+const MaxPlayerLEDs = 5
+
+// LEDs are the lights Reader.SetLEDs sets on a controller. Nil fields are
+// left as they are.
+type LEDs struct {
+	Color  *Color // lightbar color
+	Player *int   // player number shown on the player LEDs, 0-MaxPlayerLEDs (0 = off)
+}
+
+// ErrLEDUnsupported is returned by Reader.SetLEDs for controllers whose lights
+// cannot be set.
+var ErrLEDUnsupported = errors.New("controller has no settable LEDs (only DualSense controllers do)")
+
+// dualSensePlayerLEDs are the player LED patterns of players 0-5 (bit 0 = the
+// leftmost of the five LEDs), as the PS5 shows them.
+var dualSensePlayerLEDs = [MaxPlayerLEDs + 1]uint8{0x00, 0x04, 0x0A, 0x15, 0x1B, 0x1F}
+
+// DualSense output report layout. USB report 0x02 is 48 bytes; Bluetooth
+// report 0x31 is 78 bytes, with a sequence tag and a CRC-32 footer. Both carry
+// the same block of fields after their header; the offsets below are within
+// that block.
+const (
+	dualSenseUSBOutputID   = 0x02
+	dualSenseUSBOutputLen  = 48
+	dualSenseBTOutputID    = 0x31
+	dualSenseBTOutputLen   = 78
+	dualSenseBTOutputTag   = 0x10
+	dualSenseBTCRCSeed     = 0xA2 // prepended to the report for the CRC
+	dualSenseValidFlag1    = 1    // which of the fields below to apply
+	dualSenseFlagLightbar  = 0x04 // valid_flag1: lightbar color
+	dualSenseFlagPlayerLED = 0x10 // valid_flag1: player LEDs
+	dualSensePlayerLEDsAt  = 43
+	dualSenseLightbarAt    = 44 // red, green, blue
+)
+
+// SetLEDs sets the lightbar color and the player LEDs of the controller of
+// player playerIndex (0 = the active controller), to tell controllers apart
+// in multi-player setups. Only DualSense controllers (USB and Bluetooth) have
+// settable lights; others return ErrLEDUnsupported. The controller keeps the
+// lights until they are set again or it is reconnected.
+func (r *Reader) SetLEDs(playerIndex int, leds LEDs) error {
+	if leds.Player != nil && (*leds.Player < 0 || *leds.Player > MaxPlayerLEDs) {
+		return fmt.Errorf("player LEDs must be in [0, %d], got %d", MaxPlayerLEDs, *leds.Player)
+	}
+	r.mu.Lock()
+	info := r.joystickLocked(playerIndex)
+	if info == nil {
+		r.mu.Unlock()
+		return ErrNoController
+	}
+	if info.sourceType != "hid" || info.devKey.VendorID != sonyVendorID || !dualSensePIDs[info.devKey.ProductID] {
+		r.mu.Unlock()
+		return ErrLEDUnsupported
+	}
+	hDevice := info.hDevice
+	bluetooth := hidOutputReportLength(r.hidDevices[hDevice]) >= dualSenseBTOutputLen
+	r.outputSeq++
+	seq := r.outputSeq
+	r.mu.Unlock()
+	return writeHIDOutputReport(hDevice, dualSenseLEDReport(leds, bluetooth, seq))
+}
+
+// dualSenseLEDReport builds a DualSense output report that sets leds and
+// nothing else. seq is the Bluetooth sequence number (low 4 bits used).
+func dualSenseLEDReport(leds LEDs, bluetooth bool, seq uint8) []byte {
+	report := make([]byte, dualSenseUSBOutputLen)
+	report[0] = dualSenseUSBOutputID
+	common := report[1:]
+	if bluetooth {
+		report = make([]byte, dualSenseBTOutputLen)
+		report[0] = dualSenseBTOutputID
+		report[1] = seq << 4
+		report[2] = dualSenseBTOutputTag
+		common = report[3:]
+	}
+	if leds.Color != nil {
+		common[dualSenseValidFlag1] |= dualSenseFlagLightbar
+		c := leds.Color
+		copy(common[dualSenseLightbarAt:], []byte{c.R, c.G, c.B})
+	}
+	if leds.Player != nil {
+		common[dualSenseValidFlag1] |= dualSenseFlagPlayerLED
+		common[dualSensePlayerLEDsAt] = dualSensePlayerLEDs[*leds.Player]
+	}
+	if bluetooth {
+		crc := crc32.Update(crc32.ChecksumIEEE([]byte{dualSenseBTCRCSeed}), crc32.IEEETable, report[:len(report)-4])
+		binary.LittleEndian.PutUint32(report[len(report)-4:], crc)
+	}
+	return report
+}
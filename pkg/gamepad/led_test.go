@@ -0,0 +1,59 @@
+package gamepad
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	if c, err := ParseColor("#ff8001"); err != nil || c != (Color{R: 0xff, G: 0x80, B: 0x01}) {
+		t.Errorf("ParseColor(#ff8001) = %+v, %v", c, err)
+	}
+	if got := (Color{R: 0xff, G: 0x80, B: 0x01}).String(); got != "#ff8001" {
+		t.Errorf("Color.String() = %q, want #ff8001", got)
+	}
+	for _, s := range []string{"", "ff8001", "#ff80", "#ff80zz", "#+f8001"} {
+		if _, err := ParseColor(s); err == nil {
+			t.Errorf("ParseColor(%q) = nil error, want an error", s)
+		}
+	}
+}
+
+// TestDualSenseLEDReport verifies the USB and Bluetooth report layouts: only
+// the flags of the lights that are set, and a valid Bluetooth CRC.
+func TestDualSenseLEDReport(t *testing.T) {
+	color, player := Color{R: 1, G: 2, B: 3}, 3
+
+	usb := dualSenseLEDReport(LEDs{Color: &color}, false, 0)
+	if len(usb) != 48 || usb[0] != 0x02 || usb[2] != 0x04 || usb[45] != 1 || usb[46] != 2 || usb[47] != 3 || usb[44] != 0 {
+		t.Errorf("USB lightbar report = % x", usb)
+	}
+
+	bt := dualSenseLEDReport(LEDs{Color: &color, Player: &player}, true, 5)
+	if len(bt) != 78 || bt[0] != 0x31 || bt[1] != 0x50 || bt[2] != 0x10 || bt[4] != 0x14 || bt[46] != 0x15 || bt[47] != 1 {
+		t.Errorf("Bluetooth report = % x", bt)
+	}
+	want := crc32.Update(crc32.ChecksumIEEE([]byte{0xA2}), crc32.IEEETable, bt[:74])
+	if got := binary.LittleEndian.Uint32(bt[74:]); got != want {
+		t.Errorf("Bluetooth CRC = %#x, want %#x", got, want)
+	}
+}
+
+func TestReaderSetLEDs(t *testing.T) {
+	r := NewReader()
+	off, tooHigh := 0, 6
+	if err := r.SetLEDs(0, LEDs{Player: &off}); !errors.Is(err, ErrNoController) {
+		t.Errorf("SetLEDs() with nothing connected = %v, want ErrNoController", err)
+	}
+	r.joysticks[xinputKey(0)] = &joystickInfo{mapping: xboxMapping, sourceType: "xinput"}
+	r.joystickOrder = []joystickKey{xinputKey(0)}
+	r.activeKey, r.hasActive = xinputKey(0), true
+	if err := r.SetLEDs(1, LEDs{Player: &off}); !errors.Is(err, ErrLEDUnsupported) {
+		t.Errorf("SetLEDs() on an XInput controller = %v, want ErrLEDUnsupported", err)
+	}
+	if err := r.SetLEDs(1, LEDs{Player: &tooHigh}); err == nil {
+		t.Error("SetLEDs() with player LEDs 6 = nil error, want an error")
+	}
+}
@@ -0,0 +1,137 @@
+package gamepad
+
+import "sync"
+
+// defaultMailboxCapacity is the default maximum number of undelivered states a
+// stateMailbox holds (see Reader.SetChangesBuffer). Consecutive pending states
+// always differ in at least one digital field (analog-only updates are
+// merged), so this bounds the number of buffered button/dpad edges, not the
+// number of raw polls.
+const defaultMailboxCapacity = 16
+
+// stateMailbox is an edge-preserving latest-state mailbox for GamepadState.
+//
+// It replaces a buffered drop-on-full channel. When the consumer falls behind:
+//   - a state that differs from the newest pending state of the same controller
+//     (PlayerIndex and DeviceID; see Reader.SetAllPlayers) only in analog
+//     values (sticks, triggers) overwrites it, so analog streams are collapsed
+//     and the newest value is never dropped;
+//   - a state that changes any digital field (buttons, dpad, stick clicks,
+//     connection/identity) is queued behind the pending ones, so a quick tap
+//     (press + release between two consumer reads) is delivered as two states
+//     instead of vanishing.
+//
+// Only if capacity digital edges are pending at once is the oldest one
+// discarded. The consumer receives through a plain channel (recv), so it can be
+// used in a select alongside other channels.
+type stateMailbox struct {
+	mu      sync.Mutex // serialises producers and guards closed
+	ch      chan GamepadState
+	pending []GamepadState // scratch buffer reused by put; only used under mu
+	closed  bool
+}
+
+// newStateMailbox creates an empty, open mailbox holding up to capacity
+// pending states. capacity < 1 is treated as 1.
+func newStateMailbox(capacity int) *stateMailbox {
+	capacity = max(capacity, 1)
+	return &stateMailbox{
+		ch:      make(chan GamepadState, capacity),
+		pending: make([]GamepadState, 0, capacity),
+	}
+}
+
+// put publishes s. Never blocks; calling put after close is a no-op.
+func (m *stateMailbox) put(s GamepadState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+
+	// Fast path: nothing pending, the consumer is keeping up.
+	if len(m.ch) == 0 {
+		m.ch <- s
+		return
+	}
+
+	// Take the pending states out so the newest one can be inspected or
+	// replaced. The consumer may receive concurrently; whatever it takes
+	// first is simply no longer pending, and FIFO order is preserved.
+	pending := m.pending[:0]
+	for drained := false; !drained; {
+		select {
+		case p := <-m.ch:
+			pending = append(pending, p)
+		default:
+			drained = true
+		}
+	}
+
+	newest := newestOf(pending, s)
+	switch {
+	case newest >= 0 && digitalEqual(pending[newest], s):
+		// Analog-only update: merge into the controller's newest pending state.
+		pending[newest] = s
+	case len(pending) == cap(m.ch):
+		// Every slot holds a distinct digital edge; drop the oldest.
+		copy(pending, pending[1:])
+		pending[len(pending)-1] = s
+	default:
+		pending = append(pending, s)
+	}
+
+	// Cannot block: at most cap(m.ch) states, the channel was just
+	// emptied, and producers are serialised by m.mu.
+	for _, p := range pending {
+		m.ch <- p
+	}
+	m.pending = pending[:0]
+}
+
+// close closes the receive channel. Pending states are still delivered before
+// the consumer observes the close. Safe to call more than once.
+func (m *stateMailbox) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.ch)
+	}
+}
+
+// recv returns the channel on which pending states are delivered.
+func (m *stateMailbox) recv() <-chan GamepadState {
+	return m.ch
+}
+
+// newestOf returns the index of the newest state in pending from the same
+// controller as s, or -1.
+func newestOf(pending []GamepadState, s GamepadState) int {
+	for i := len(pending) - 1; i >= 0; i-- {
+		if pending[i].PlayerIndex == s.PlayerIndex && pending[i].DeviceID == s.DeviceID {
+			return i
+		}
+	}
+	return -1
+}
+
+// digitalEqual reports whether a and b agree on every non-analog field, i.e.
+// the transition a → b changes only stick positions, trigger values, and the
+// wheel's, flight stick's, and raw axes.
+func digitalEqual(a, b GamepadState) bool {
+	return a.Connected == b.Connected &&
+		a.ControllerType == b.ControllerType &&
+		a.Name == b.Name &&
+		a.PlayerIndex == b.PlayerIndex &&
+		a.DeviceID == b.DeviceID &&
+		a.Buttons == b.Buttons &&
+		a.Extra == b.Extra &&
+		a.Dpad == b.Dpad &&
+		a.Wheel.Gear == b.Wheel.Gear &&
+		a.Flight.Buttons == b.Flight.Buttons &&
+		a.Flight.Hats == b.Flight.Hats &&
+		rawDigitalEqual(a.Raw, b.Raw) &&
+		a.Sticks.Left.Pressed == b.Sticks.Left.Pressed &&
+		a.Sticks.Right.Pressed == b.Sticks.Right.Pressed
+}
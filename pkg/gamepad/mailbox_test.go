@@ -0,0 +1,132 @@
+package gamepad
+
+import "testing"
+
+// drainMailbox returns every state currently pending in m.
+func drainMailbox(m *stateMailbox) []GamepadState {
+	var out []GamepadState
+	for {
+		select {
+		case s := <-m.recv():
+			out = append(out, s)
+		default:
+			return out
+		}
+	}
+}
+
+// TestStateMailboxAnalogCoalesced verifies that analog-only updates collapse
+// into the newest pending state rather than queueing or dropping the newest.
+func TestStateMailboxAnalogCoalesced(t *testing.T) {
+	m := newStateMailbox(defaultMailboxCapacity)
+	for i := 1; i <= 5; i++ {
+		var s GamepadState
+		s.Connected = true
+		s.Sticks.Left.Position.X = float64(i) / 10
+		m.put(s)
+	}
+
+	got := drainMailbox(m)
+	// The first put goes straight into the empty mailbox; the remaining four
+	// are analog-only relative to it and merge into one pending state.
+	if len(got) != 1 {
+		t.Fatalf("received %d states, want 1 (analog updates must coalesce)", len(got))
+	}
+	if x := got[0].Sticks.Left.Position.X; x != 0.5 {
+		t.Errorf("received stick X = %v, want 0.5 (newest state)", x)
+	}
+}
+
+// TestStateMailboxTapPreserved verifies that a press and release arriving
+// between two consumer reads are both delivered, even with analog noise
+// interleaved.
+func TestStateMailboxTapPreserved(t *testing.T) {
+	m := newStateMailbox(defaultMailboxCapacity)
+
+	idle := GamepadState{Connected: true}
+	pressed := idle
+	pressed.Buttons.A = true
+	pressedMoved := pressed
+	pressedMoved.Sticks.Left.Position.X = 0.3
+	released := pressedMoved
+	released.Buttons.A = false
+
+	m.put(idle)
+	m.put(pressed)
+	m.put(pressedMoved)
+	m.put(released)
+
+	got := drainMailbox(m)
+	if len(got) != 3 {
+		t.Fatalf("received %d states, want 3 (idle, press, release)", len(got))
+	}
+	if got[0].Buttons.A || !got[1].Buttons.A || got[2].Buttons.A {
+		t.Errorf("button A sequence = %v,%v,%v, want false,true,false",
+			got[0].Buttons.A, got[1].Buttons.A, got[2].Buttons.A)
+	}
+	if x := got[1].Sticks.Left.Position.X; x != 0.3 {
+		t.Errorf("pressed state stick X = %v, want 0.3 (analog merged into edge)", x)
+	}
+}
+
+// TestStateMailboxPlayersInterleaved verifies that with several controllers
+// (Reader.SetAllPlayers) each one's analog updates merge into its own newest
+// pending state, so interleaved streams do not fill the mailbox.
+func TestStateMailboxPlayersInterleaved(t *testing.T) {
+	m := newStateMailbox(defaultMailboxCapacity)
+	m.put(GamepadState{}) // taken by the fast path; the rest queue behind it
+	for i := 1; i <= 20; i++ {
+		for p := 1; p <= 2; p++ {
+			s := GamepadState{Connected: true, PlayerIndex: p}
+			s.Sticks.Left.Position.X = float64(i) / 100
+			m.put(s)
+		}
+	}
+
+	got := drainMailbox(m)
+	if len(got) != 3 {
+		t.Fatalf("received %d states, want 3 (one per controller after the first)", len(got))
+	}
+	for _, s := range got[1:] {
+		if x := s.Sticks.Left.Position.X; x != 0.2 {
+			t.Errorf("player %d stick X = %v, want 0.2 (newest state)", s.PlayerIndex, x)
+		}
+	}
+}
+
+// TestStateMailboxOverflow verifies that when every slot holds a digital edge
+// the oldest is discarded and the newest is kept.
+func TestStateMailboxOverflow(t *testing.T) {
+	m := newStateMailbox(defaultMailboxCapacity)
+	for i := 0; i < defaultMailboxCapacity+3; i++ {
+		m.put(GamepadState{PlayerIndex: i})
+	}
+
+	got := drainMailbox(m)
+	if len(got) != defaultMailboxCapacity {
+		t.Fatalf("received %d states, want %d", len(got), defaultMailboxCapacity)
+	}
+	if first := got[0].PlayerIndex; first != 3 {
+		t.Errorf("oldest kept PlayerIndex = %d, want 3", first)
+	}
+	if last := got[len(got)-1].PlayerIndex; last != defaultMailboxCapacity+2 {
+		t.Errorf("newest kept PlayerIndex = %d, want %d", last, defaultMailboxCapacity+2)
+	}
+}
+
+// TestStateMailboxClose verifies that close is idempotent, that a pending state
+// is still delivered after close, and that put after close does not panic.
+func TestStateMailboxClose(t *testing.T) {
+	m := newStateMailbox(defaultMailboxCapacity)
+	m.put(GamepadState{PlayerIndex: 1})
+	m.close()
+	m.close()
+	m.put(GamepadState{PlayerIndex: 2})
+
+	if s, ok := <-m.recv(); !ok || s.PlayerIndex != 1 {
+		t.Errorf("first receive = (%+v, %v), want pending state 1", s, ok)
+	}
+	if _, ok := <-m.recv(); ok {
+		t.Errorf("receive after drain: channel still open, want closed")
+	}
+}
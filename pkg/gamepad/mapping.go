@@ -75,9 +75,17 @@ type deviceKey struct {
 	ProductID uint16
 }
 
-// GetMapping returns the appropriate mapping for a device identified by vendor/product ID.
+// DeviceKey is the exported name of the vendor/product ID map key returned by
+// LoadSDLMappingsFromFile and LoadSDLMappingsFromReader.
+type DeviceKey = deviceKey
+
+// GetMapping returns the appropriate mapping for a device identified by vendor/product ID:
+// a custom one loaded by LoadMappingDir, else a built-in one.
 // Falls back to generic mapping if no specific mapping is found.
 func GetMapping(vendorID, productID uint16) *DeviceMapping {
+	if m := lookupCustomMapping(vendorID, productID); m != nil {
+		return m
+	}
 	key := deviceKey{VendorID: vendorID, ProductID: productID}
 	if m, ok := knownDevices[key]; ok {
 		return m
@@ -162,6 +162,206 @@ var playstationMapping = newPlayStationMapping("playstation", playStationHIDButt
 
 var playstation5Mapping = newPlayStationMapping("playstation", playStation5HIDButtons, ButtonMapping{Index: 11, Target: "touchpad"})
 
+// Steering wheels. Their HID reports follow no common layout; the mappings
+// below are the DirectInput layouts of each vendor's wheels with the pedals
+// reported as separate axes (the drivers' default). Pedals of Logitech and
+// Thrustmaster wheels read their maximum when released, hence the "~"
+// (inverted) targets. The built-in frontend has no wheel layout; overlays
+// read GamepadState.Wheel. A mapping file (see LoadMappingDir) overrides
+// these for other wheels, pedal sets, and shifters.
+
+// logitechWheelHIDAxes is the layout of the Logitech G25 to G923: X=wheel,
+// Y=throttle, Rz=brake, Slider=clutch.
+var logitechWheelHIDAxes = map[uint16]string{
+	hidUsageX:      "wheel",
+	hidUsageY:      "throttle~",
+	hidUsageRz:     "brake~",
+	hidUsageSlider: "clutch~",
+}
+
+// HID button mapping for the Logitech G29 and G923 (PlayStation version).
+// G29 HID button order: Cross(1) Square(2) Circle(3) Triangle(4) right
+// paddle(5) left paddle(6) R2(7) L2(8) Share(9) Options(10) R3(11) L3(12),
+// then the Driving Force Shifter: gears 1-6 (13-18) and reverse (19), then
+// Plus(20) Minus(21) the dial(22-24) and PS(25).
+var logitechG29HIDButtons = map[uint16]string{
+	1:  "a",  // Cross
+	2:  "x",  // Square
+	3:  "b",  // Circle
+	4:  "y",  // Triangle
+	5:  "rb", // right paddle (shift up)
+	6:  "lb", // left paddle (shift down)
+	7:  "rt", // R2
+	8:  "lt", // L2
+	9:  "back",
+	10: "start",
+	11: "rs",
+	12: "ls",
+	13: "gear1",
+	14: "gear2",
+	15: "gear3",
+	16: "gear4",
+	17: "gear5",
+	18: "gear6",
+	19: "gearr",
+	25: "guide", // PS button
+}
+
+// HID button mapping for the Logitech G920 and G923 (Xbox version).
+// G920 HID button order: A(1) B(2) X(3) Y(4) right paddle(5) left paddle(6)
+// Menu(7) View(8) RSB(9) LSB(10) Xbox(11), then the Driving Force Shifter:
+// gears 1-6 (13-18) and reverse (19).
+var logitechG920HIDButtons = map[uint16]string{
+	1:  "a",
+	2:  "b",
+	3:  "x",
+	4:  "y",
+	5:  "rb", // right paddle (shift up)
+	6:  "lb", // left paddle (shift down)
+	7:  "start",
+	8:  "back",
+	9:  "rs",
+	10: "ls",
+	11: "guide",
+	13: "gear1",
+	14: "gear2",
+	15: "gear3",
+	16: "gear4",
+	17: "gear5",
+	18: "gear6",
+	19: "gearr",
+}
+
+// thrustmasterWheelHIDAxes is the layout of the Thrustmaster T150, T248,
+// T300RS, TMX, and TX: X=wheel, Rz=throttle, Y=brake, Slider=clutch.
+var thrustmasterWheelHIDAxes = map[uint16]string{
+	hidUsageX:      "wheel",
+	hidUsageRz:     "throttle~",
+	hidUsageY:      "brake~",
+	hidUsageSlider: "clutch~",
+}
+
+// fanatecWheelHIDAxes is the layout of Fanatec wheel bases: X=wheel,
+// Y=throttle, Z=brake, Rz=clutch, Slider=handbrake. Their pedals read 0 when
+// released.
+var fanatecWheelHIDAxes = map[uint16]string{
+	hidUsageX:      "wheel",
+	hidUsageY:      "throttle",
+	hidUsageZ:      "brake",
+	hidUsageRz:     "clutch",
+	hidUsageSlider: "handbrake",
+}
+
+// wheelMapping is the device mapping for Logitech wheels without a known
+// button layout, and the base of "wheel" mapping files. Buttons are assigned
+// in the generic order.
+var wheelMapping = &DeviceMapping{
+	Name:    "wheel",
+	HasHat:  true,
+	HIDAxes: logitechWheelHIDAxes,
+}
+
+var logitechG29Mapping = &DeviceMapping{
+	Name:       "wheel",
+	HasHat:     true,
+	HIDAxes:    logitechWheelHIDAxes,
+	HIDButtons: logitechG29HIDButtons,
+}
+
+var logitechG920Mapping = &DeviceMapping{
+	Name:       "wheel",
+	HasHat:     true,
+	HIDAxes:    logitechWheelHIDAxes,
+	HIDButtons: logitechG920HIDButtons,
+}
+
+var thrustmasterWheelMapping = &DeviceMapping{
+	Name:    "wheel",
+	HasHat:  true,
+	HIDAxes: thrustmasterWheelHIDAxes,
+}
+
+var fanatecWheelMapping = &DeviceMapping{
+	Name:    "wheel",
+	HasHat:  true,
+	HIDAxes: fanatecWheelHIDAxes,
+}
+
+// Flight sticks. Buttons and hats are passed through by number (see
+// FlightState); the mappings only name the axes. Throttle levers read their
+// minimum when pushed fully forward, hence the "~" (inverted) targets. HOTAS
+// sets whose stick and throttle are separate USB devices show as two
+// controllers. The built-in frontend has no flight stick layout; overlays
+// read GamepadState.Flight. A mapping file (see LoadMappingDir) maps the axes
+// of other sticks, throttles, and rudder pedals.
+
+// flightStickHIDAxes is the layout of most flight sticks with a throttle
+// wheel or slider: X=roll, Y=pitch, Rz=twist, Slider=thrust, and Z (a
+// throttle or rocker on some) as the first extra axis.
+var flightStickHIDAxes = map[uint16]string{
+	hidUsageX:      "roll",
+	hidUsageY:      "pitch",
+	hidUsageRz:     "yaw",
+	hidUsageSlider: "thrust~",
+	hidUsageZ:      "axis1",
+	hidUsageRx:     "axis2",
+	hidUsageRy:     "axis3",
+	hidUsageDial:   "axis4",
+}
+
+// saitekX52HIDAxes is the layout of the Saitek/Logitech X52 and X52 Pro:
+// X=roll, Y=pitch, Rz=twist, Z=throttle, then the throttle's rotaries (Rx,
+// Ry) and slider.
+var saitekX52HIDAxes = map[uint16]string{
+	hidUsageX:      "roll",
+	hidUsageY:      "pitch",
+	hidUsageRz:     "yaw",
+	hidUsageZ:      "thrust~",
+	hidUsageRx:     "axis1",
+	hidUsageRy:     "axis2",
+	hidUsageSlider: "axis3",
+}
+
+// flightStickMapping is the device mapping for flight sticks, and the base of
+// "flightstick" mapping files.
+var flightStickMapping = &DeviceMapping{
+	Name:    "flightstick",
+	HasHat:  true,
+	HIDAxes: flightStickHIDAxes,
+}
+
+var saitekX52Mapping = &DeviceMapping{
+	Name:    "flightstick",
+	HasHat:  true,
+	HIDAxes: saitekX52HIDAxes,
+}
+
+// Arcade sticks and leverless (hitbox) controllers. They report as gamepads
+// — the lever or the direction buttons as the dpad, the eight buttons as the
+// face buttons, bumpers, and (digital) triggers — and only differ in the
+// "arcade" and "hitbox" frontend layouts: top row X Y RB LB, bottom row A B
+// RT LT, as on Xbox sticks. XInput arcade sticks are also recognised by
+// their XInput subtype (see connectXInput).
+
+// arcadeXboxMapping is the device mapping for XInput arcade sticks.
+var arcadeXboxMapping = &DeviceMapping{
+	Name:    "arcade",
+	Axes:    xboxMapping.Axes,
+	Buttons: xboxMapping.Buttons,
+	HasHat:  true,
+}
+
+// arcadePlayStationMapping is the device mapping for arcade sticks in PS4
+// mode, which share the DualShock 4 report layout.
+var arcadePlayStationMapping = newPlayStationMapping("arcade", playStation5HIDButtons, ButtonMapping{Index: 11, Target: "touchpad"})
+
+// hitboxMapping is arcadePlayStationMapping with the leverless layout.
+var hitboxMapping = newPlayStationMapping("hitbox", playStation5HIDButtons, ButtonMapping{Index: 11, Target: "touchpad"})
+
+// rawMapping is the base of "raw" mapping files, which start a device in raw
+// mode (see Reader.SetRawMode). It maps nothing.
+var rawMapping = &DeviceMapping{Name: "raw"}
+
 // Known vendor/product IDs.
 var knownDevices = map[deviceKey]*DeviceMapping{
 	// Microsoft Xbox controllers
@@ -214,10 +414,7 @@ var knownDevices = map[deviceKey]*DeviceMapping{
 	{0x0e6f, 0x0413}: xboxMapping,
 	{0x0e6f, 0x0501}: xboxMapping,
 	{0x0e6f, 0xf900}: xboxMapping,
-	{0x0f0d, 0x000a}: xboxMapping,
 	{0x0f0d, 0x000c}: xboxMapping,
-	{0x0f0d, 0x000d}: xboxMapping,
-	{0x0f0d, 0x0016}: xboxMapping,
 	{0x0f0d, 0x001b}: xboxMapping,
 	{0x0f0d, 0x008c}: xboxMapping,
 	{0x0f0d, 0x00db}: xboxMapping,
@@ -276,7 +473,6 @@ var knownDevices = map[deviceKey]*DeviceMapping{
 	{0x1bad, 0xfa01}: xboxMapping,
 	{0x1bad, 0xfd00}: xboxMapping,
 	{0x1bad, 0xfd01}: xboxMapping,
-	{0x24c6, 0x5000}: xboxMapping,
 	{0x24c6, 0x5300}: xboxMapping,
 	{0x24c6, 0x5303}: xboxMapping,
 	{0x24c6, 0x530a}: xboxMapping,
@@ -386,14 +582,11 @@ var knownDevices = map[deviceKey]*DeviceMapping{
 	{0x0e6f, 0x02da}: xboxMapping,
 	{0x0e6f, 0x02d6}: xboxMapping,
 	{0x0e6f, 0x02d9}: xboxMapping,
-	{0x0f0d, 0x0063}: xboxMapping,
 	{0x0f0d, 0x0067}: xboxMapping,
-	{0x0f0d, 0x0078}: xboxMapping,
 	{0x0f0d, 0x00c5}: xboxMapping,
 	{0x0f0d, 0x0150}: xboxMapping,
 	{0x10f5, 0x7009}: xboxMapping,
 	{0x10f5, 0x7013}: xboxMapping,
-	{0x1532, 0x0a00}: xboxMapping,
 	{0x1532, 0x0a03}: xboxMapping,
 	{0x1532, 0x0a14}: xboxMapping,
 	{0x1532, 0x0a15}: xboxMapping,
@@ -585,14 +778,10 @@ var knownDevices = map[deviceKey]*DeviceMapping{
 	{0x054c, 0x09cc}: playstation5Mapping,
 	{0x054c, 0x0ba0}: playstation5Mapping,
 	{0x0738, 0x8250}: playstation5Mapping,
-	{0x0738, 0x8384}: playstation5Mapping,
-	{0x0738, 0x8480}: playstation5Mapping,
-	{0x0738, 0x8481}: playstation5Mapping,
 	{0x0c12, 0x0e10}: playstation5Mapping,
 	{0x0c12, 0x0e13}: playstation5Mapping,
 	{0x0c12, 0x0e15}: playstation5Mapping,
 	{0x0c12, 0x0e20}: playstation5Mapping,
-	{0x0c12, 0x0ef6}: playstation5Mapping,
 	{0x0c12, 0x1cf6}: playstation5Mapping,
 	{0x0c12, 0x1e10}: playstation5Mapping,
 	{0x0c12, 0x2e18}: playstation5Mapping,
@@ -603,8 +792,6 @@ var knownDevices = map[deviceKey]*DeviceMapping{
 	{0x0f0d, 0x005e}: playstation5Mapping,
 	{0x0f0d, 0x0066}: playstation5Mapping,
 	{0x0f0d, 0x0084}: playstation5Mapping,
-	{0x0f0d, 0x0087}: playstation5Mapping,
-	{0x0f0d, 0x008a}: playstation5Mapping,
 	{0x0f0d, 0x009c}: playstation5Mapping,
 	{0x0f0d, 0x00a0}: playstation5Mapping,
 	{0x0f0d, 0x00ee}: playstation5Mapping,
@@ -620,18 +807,13 @@ var knownDevices = map[deviceKey]*DeviceMapping{
 	{0x146b, 0x0d10}: playstation5Mapping,
 	{0x146b, 0x0d13}: playstation5Mapping,
 	{0x146b, 0x1103}: playstation5Mapping,
-	{0x1532, 0x0401}: playstation5Mapping,
 	{0x1532, 0x1000}: playstation5Mapping,
 	{0x1532, 0x1004}: playstation5Mapping,
 	{0x1532, 0x1007}: playstation5Mapping,
-	{0x1532, 0x1008}: playstation5Mapping,
 	{0x1532, 0x1009}: playstation5Mapping,
 	{0x1532, 0x100A}: playstation5Mapping,
 	{0x1532, 0x1100}: playstation5Mapping,
 	{0x20d6, 0x792a}: playstation5Mapping,
-	{0x2c22, 0x2000}: playstation5Mapping,
-	{0x2c22, 0x2300}: playstation5Mapping,
-	{0x2c22, 0x2500}: playstation5Mapping,
 	{0x3285, 0x0d16}: playstation5Mapping,
 	{0x3285, 0x0d17}: playstation5Mapping,
 	{0x7545, 0x0104}: playstation5Mapping,
@@ -658,4 +840,58 @@ var knownDevices = map[deviceKey]*DeviceMapping{
 	{0x0f0d, 0x00f6}: switchProMapping,
 	{0x0e6f, 0x0186}: switchProMapping,
 	{0x0e6f, 0x018c}: switchProMapping,
+
+	// Steering wheels
+	{0x046d, 0xc299}: wheelMapping,             // Logitech G25
+	{0x046d, 0xc29a}: wheelMapping,             // Logitech Driving Force GT
+	{0x046d, 0xc29b}: wheelMapping,             // Logitech G27
+	{0x046d, 0xc24f}: logitechG29Mapping,       // Logitech G29
+	{0x046d, 0xc266}: logitechG29Mapping,       // Logitech G923 (PlayStation)
+	{0x046d, 0xc267}: logitechG29Mapping,       // Logitech G923 (PlayStation, PC mode)
+	{0x046d, 0xc262}: logitechG920Mapping,      // Logitech G920
+	{0x046d, 0xc26e}: logitechG920Mapping,      // Logitech G923 (Xbox)
+	{0x044f, 0xb669}: thrustmasterWheelMapping, // Thrustmaster TX
+	{0x044f, 0xb66e}: thrustmasterWheelMapping, // Thrustmaster T300RS
+	{0x044f, 0xb677}: thrustmasterWheelMapping, // Thrustmaster T150
+	{0x044f, 0xb67f}: thrustmasterWheelMapping, // Thrustmaster TMX
+	{0x044f, 0xb696}: thrustmasterWheelMapping, // Thrustmaster T248
+	{0x0eb7, 0x0001}: fanatecWheelMapping,      // Fanatec ClubSport Wheel Base V2
+	{0x0eb7, 0x0004}: fanatecWheelMapping,      // Fanatec ClubSport Wheel Base V2.5
+	{0x0eb7, 0x0005}: fanatecWheelMapping,      // Fanatec CSL Elite Wheel Base
+	{0x0eb7, 0x0006}: fanatecWheelMapping,      // Fanatec Podium Wheel Base DD1
+	{0x0eb7, 0x0007}: fanatecWheelMapping,      // Fanatec Podium Wheel Base DD2
+	{0x0eb7, 0x0020}: fanatecWheelMapping,      // Fanatec CSL DD
+	{0x0eb7, 0x0e03}: fanatecWheelMapping,      // Fanatec CSL Elite Wheel Base (PS4)
+
+	// Flight sticks
+	{0x046d, 0xc215}: flightStickMapping, // Logitech Extreme 3D Pro
+	{0x044f, 0xb10a}: flightStickMapping, // Thrustmaster T.16000M
+	{0x044f, 0xb108}: flightStickMapping, // Thrustmaster T.Flight HOTAS X
+	{0x044f, 0x0402}: flightStickMapping, // Thrustmaster HOTAS Warthog (stick)
+	{0x0738, 0x2221}: flightStickMapping, // Saitek X56 Rhino (stick)
+	{0x06a3, 0x075c}: saitekX52Mapping,   // Saitek X52
+	{0x06a3, 0x0762}: saitekX52Mapping,   // Saitek X52 Pro
+
+	// Arcade sticks (XInput)
+	{0x0738, 0x4758}: arcadeXboxMapping, // Mad Catz Arcade Game Stick
+	{0x0f0d, 0x000a}: arcadeXboxMapping, // HORI DOA4 FightStick
+	{0x0f0d, 0x000d}: arcadeXboxMapping, // HORI Fighting Stick EX2
+	{0x0f0d, 0x0016}: arcadeXboxMapping, // HORI Real Arcade Pro.EX
+	{0x0f0d, 0x0063}: arcadeXboxMapping, // HORI Real Arcade Pro Hayabusa (Xbox One)
+	{0x0f0d, 0x0078}: arcadeXboxMapping, // HORI Real Arcade Pro V Kai (Xbox One)
+	{0x1532, 0x0a00}: arcadeXboxMapping, // Razer Atrox (Xbox One)
+	{0x24c6, 0x5000}: arcadeXboxMapping, // Razer Atrox (Xbox 360)
+
+	// Arcade sticks (PS4 mode)
+	{0x0738, 0x8384}: arcadePlayStationMapping, // Mad Catz FightStick TE S+
+	{0x0738, 0x8480}: arcadePlayStationMapping, // Mad Catz FightStick TE2
+	{0x0738, 0x8481}: arcadePlayStationMapping, // Mad Catz FightStick TE2+
+	{0x0f0d, 0x0087}: arcadePlayStationMapping, // HORI Fighting Stick mini 4
+	{0x0f0d, 0x008a}: arcadePlayStationMapping, // HORI Real Arcade Pro 4
+	{0x1532, 0x0401}: arcadePlayStationMapping, // Razer Panthera
+	{0x1532, 0x1008}: arcadePlayStationMapping, // Razer Panthera Evo
+	{0x2c22, 0x2000}: arcadePlayStationMapping, // Qanba Drone
+	{0x2c22, 0x2300}: arcadePlayStationMapping, // Qanba Obsidian
+	{0x2c22, 0x2500}: arcadePlayStationMapping, // Qanba Dragon
+	{0x0c12, 0x0ef6}: hitboxMapping,            // Hit Box (PS4 mode)
 }
@@ -0,0 +1,200 @@
+package gamepad
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// customMappingsMu protects customMappings from concurrent read/write.
+var customMappingsMu sync.RWMutex
+
+// customMappings holds the device mappings loaded by LoadMappingDir, keyed by
+// (VendorID, ProductID). They take priority over knownDevices and the SDL
+// database. Protected by customMappingsMu.
+var customMappings map[deviceKey]*DeviceMapping
+
+// mappingBases are the built-in mappings a custom mapping file starts from,
+// by its "type" (the ControllerType, which picks the frontend layout).
+var mappingBases = map[string]*DeviceMapping{
+	"xbox":        xboxMapping,
+	"playstation": playstationMapping,
+	"switch_pro":  switchProMapping,
+	"wheel":       wheelMapping,
+	"flightstick": flightStickMapping,
+	"arcade":      arcadePlayStationMapping,
+	"hitbox":      hitboxMapping,
+	"raw":         rawMapping,
+}
+
+// hidAxisNames maps the axis names accepted in "hidAxes" to HID usages.
+var hidAxisNames = map[string]uint16{
+	"x": hidUsageX, "y": hidUsageY, "z": hidUsageZ,
+	"rx": hidUsageRx, "ry": hidUsageRy, "rz": hidUsageRz,
+	"slider": hidUsageSlider, "dial": hidUsageDial,
+}
+
+// axisTargets and buttonTargets are the targets accepted in "hidAxes" and
+// "hidButtons" (see applyAxisToState and applyButton), besides the flight
+// stick's "axis<n>" and "button<n>".
+var (
+	axisTargets = []string{"left_x", "left_y", "right_x", "right_y", "lt", "rt",
+		"wheel", "throttle", "brake", "clutch", "handbrake", "roll", "pitch", "yaw", "thrust"}
+	buttonTargets = []string{"a", "b", "x", "y", "lb", "rb", "lt", "rt", "back", "start", "guide",
+		"touchpad", "capture", "paddle1", "paddle2", "paddle3", "paddle4", "fn1", "fn2",
+		"ls", "rs", "dpup", "dpdown", "dpleft", "dpright",
+		"handbrake", "gear1", "gear2", "gear3", "gear4", "gear5", "gear6", "gear7", "gearr"}
+)
+
+// mappingFile is the JSON form of a custom mapping file:
+//
+//	{
+//	  "type": "playstation",
+//	  "devices": ["2dc8:6001"],
+//	  "hidAxes": {"x": "left_x", "y": "left_y", "z": "right_x", "rz": "right_y"},
+//	  "hidButtons": {"1": "b", "2": "a"}
+//	}
+//
+// devices are "VID:PID" in hex. hidAxes keys are axis names (x y z rx ry rz
+// slider dial) or HID usages such as "0x30", and a target ending in "~" is
+// inverted (a pedal that reads its maximum when released); hidButtons keys
+// are 1-based HID button numbers. Both are optional and replace those of the type's built-in
+// mapping when set.
+type mappingFile struct {
+	Type       string            `json:"type"`
+	Devices    []string          `json:"devices"`
+	HIDAxes    map[string]string `json:"hidAxes"`
+	HIDButtons map[string]string `json:"hidButtons"`
+}
+
+// LoadMappingDir replaces the custom device mappings with those of the *.json
+// files in dir (see mappingFile), so users can add controllers without
+// recompiling. A custom mapping takes priority over the built-in table and
+// the SDL database for its VID/PID. A missing dir loads none. Files that fail
+// to parse are skipped; their errors are joined into the returned error.
+//
+// Call before any gamepads connect (ideally before Reader.Run), like LoadSDLDB.
+func LoadMappingDir(dir string) error {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	merged := make(map[deviceKey]*DeviceMapping)
+	var errs []error
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		keys, m, err := parseMappingFile(f)
+		f.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		for _, k := range keys {
+			merged[k] = m
+		}
+	}
+	if len(merged) > 0 {
+		slog.Info("mappings: loaded custom device mappings", "dir", dir, "files", len(paths)-len(errs), "devices", len(merged))
+	}
+
+	customMappingsMu.Lock()
+	customMappings = merged
+	customMappingsMu.Unlock()
+	return errors.Join(errs...)
+}
+
+// CustomMappingCount returns the number of devices with a mapping loaded by
+// LoadMappingDir (0 before LoadMappingDir is called).
+func CustomMappingCount() int {
+	customMappingsMu.RLock()
+	defer customMappingsMu.RUnlock()
+	return len(customMappings)
+}
+
+// lookupCustomMapping returns the custom mapping for a device's VID/PID, or
+// nil if none was loaded.
+func lookupCustomMapping(vendorID, productID uint16) *DeviceMapping {
+	customMappingsMu.RLock()
+	defer customMappingsMu.RUnlock()
+	return customMappings[deviceKey{VendorID: vendorID, ProductID: productID}]
+}
+
+// parseMappingFile parses and validates a custom mapping file and returns the
+// devices it applies to and their mapping.
+func parseMappingFile(r io.Reader) ([]deviceKey, *DeviceMapping, error) {
+	var mf mappingFile
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&mf); err != nil {
+		return nil, nil, err
+	}
+	base, ok := mappingBases[mf.Type]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown type %q (available: %v)", mf.Type, slices.Sorted(maps.Keys(mappingBases)))
+	}
+	if len(mf.Devices) == 0 {
+		return nil, nil, errors.New("no devices")
+	}
+	keys := make([]deviceKey, len(mf.Devices))
+	for i, d := range mf.Devices {
+		k, err := parseDeviceID(d)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[i] = k
+	}
+
+	m := *base
+	if len(mf.HIDAxes) > 0 {
+		m.HIDAxes = make(map[uint16]string, len(mf.HIDAxes))
+		for name, target := range mf.HIDAxes {
+			usage, ok := hidAxisNames[strings.ToLower(name)]
+			if !ok {
+				u, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(name), "0x"), 16, 16)
+				if err != nil {
+					return nil, nil, fmt.Errorf("hidAxes: unknown axis %q", name)
+				}
+				usage = uint16(u)
+			}
+			if t := strings.TrimSuffix(target, "~"); !slices.Contains(axisTargets, t) && !isFlightAxisTarget(t) {
+				return nil, nil, fmt.Errorf("hidAxes.%s: unknown target %q (available: %v)", name, target, axisTargets)
+			}
+			m.HIDAxes[usage] = target
+		}
+	}
+	if len(mf.HIDButtons) > 0 {
+		m.HIDButtons = make(map[uint16]string, len(mf.HIDButtons))
+		for num, target := range mf.HIDButtons {
+			n, err := strconv.ParseUint(num, 10, 16)
+			if err != nil || n == 0 {
+				return nil, nil, fmt.Errorf("hidButtons: %q is not a 1-based button number", num)
+			}
+			if !slices.Contains(buttonTargets, target) && !isFlightButtonTarget(target) {
+				return nil, nil, fmt.Errorf("hidButtons.%s: unknown target %q (available: %v)", num, target, buttonTargets)
+			}
+			m.HIDButtons[uint16(n)] = target
+		}
+	}
+	return keys, &m, nil
+}
+
+// parseDeviceID parses a "VID:PID" pair in hex, e.g. "054c:0ce6".
+func parseDeviceID(s string) (deviceKey, error) {
+	vid, pid, ok := strings.Cut(s, ":")
+	v, err1 := strconv.ParseUint(strings.TrimPrefix(vid, "0x"), 16, 16)
+	p, err2 := strconv.ParseUint(strings.TrimPrefix(pid, "0x"), 16, 16)
+	if !ok || err1 != nil || err2 != nil {
+		return deviceKey{}, fmt.Errorf("device %q is not VID:PID in hex", s)
+	}
+	return deviceKey{VendorID: uint16(v), ProductID: uint16(p)}, nil
+}
@@ -0,0 +1,61 @@
+package gamepad
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoadMappingDir verifies that custom mapping files replace the built-in
+// mapping and the SDL database for their devices, start from the built-in
+// mapping of their type, and that bad files are reported and skipped.
+func TestLoadMappingDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"sn30.json": `{"type": "switch_pro", "devices": ["2dc8:6001", "0x2DC8:0x6101"],
+			"hidButtons": {"1": "b", "2": "a", "14": "capture"}}`,
+		"ds4.json":     `{"type": "playstation", "devices": ["054c:05c4"], "hidAxes": {"x": "left_x", "0x35": "right_y"}}`,
+		"badtype.json": `{"type": "gamecube", "devices": ["1234:5678"]}`,
+		"badaxis.json": `{"type": "xbox", "devices": ["1234:5679"], "hidAxes": {"x": "left"}}`,
+		"badid.json":   `{"type": "xbox", "devices": ["12345678"]}`,
+		"notes.txt":    `not a mapping`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { LoadMappingDir("") })
+
+	err := LoadMappingDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "badtype.json") || !strings.Contains(err.Error(), "badaxis.json") || !strings.Contains(err.Error(), "badid.json") {
+		t.Errorf("LoadMappingDir() error = %v, want the three bad files", err)
+	}
+	if n := CustomMappingCount(); n != 3 {
+		t.Errorf("CustomMappingCount() = %d, want 3", n)
+	}
+
+	m := GetMapping(0x2dc8, 0x6101)
+	if m.Name != "switch_pro" || m.HIDButtons[1] != "b" || m.HIDButtons[14] != "capture" || m.HIDAxes[hidUsageRz] != "right_y" {
+		t.Errorf("GetMapping(2dc8:6101) = %+v, want Switch Pro axes with the file's buttons", m)
+	}
+	if switchProMapping.HIDButtons[1] != "y" {
+		t.Error("custom mapping modified the built-in Switch Pro mapping")
+	}
+	ds4 := GetMapping(0x054c, 0x05c4)
+	if ds4.Name != "playstation" || len(ds4.HIDAxes) != 2 || ds4.HIDAxes[hidUsageRz] != "right_y" || ds4.HIDButtons[13] != "guide" {
+		t.Errorf("GetMapping(054c:05c4) = %+v, want the file's axes with PlayStation buttons", ds4)
+	}
+
+	LoadSDLDB("")
+	if lookupSDLMapping(0x054c, 0x05c4) != nil {
+		t.Error("lookupSDLMapping(054c:05c4) with a custom mapping: want nil")
+	}
+	if LoadMappingDir(filepath.Join(dir, "missing")) != nil || CustomMappingCount() != 0 {
+		t.Error("LoadMappingDir(missing dir): want no error and no mappings")
+	}
+	if GetMapping(0x054c, 0x05c4) != knownDevices[deviceKey{0x054c, 0x05c4}] {
+		t.Error("GetMapping(054c:05c4) after reload: want the built-in mapping")
+	}
+}
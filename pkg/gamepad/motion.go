@@ -0,0 +1,138 @@
+package gamepad
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Vector3 is a 3D sensor reading.
+type Vector3 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// MotionState is a motion sensor reading, in the SDL sensor API's units and
+// axes, with the controller held in front of you: +X points right, +Y up, +Z
+// toward you. Gyro is the angular velocity around each axis in rad/s (X =
+// pitch, Y = yaw, Z = roll; counter-clockwise positive); Accel is the
+// acceleration in m/s², gravity included, so a controller lying flat reads
+// about +9.8 on Y. The zero value means no reading (omitted on the wire); it
+// is only filled with Reader.SetMotion.
+type MotionState struct {
+	Gyro  Vector3 `json:"gyro"`
+	Accel Vector3 `json:"accel"`
+}
+
+// Motion deltas are only sent for changes above these thresholds, which are
+// above the sensors' noise at rest, so a controller on the desk stays quiet.
+const (
+	gyroThreshold  = 0.02 // rad/s, about 1.1 °/s
+	accelThreshold = 0.1  // m/s², about 0.01 g
+)
+
+// standardGravity converts g to m/s².
+const standardGravity = 9.80665
+
+// Raw sensor resolutions. Factory calibration is not read, so readings can be
+// off by a few percent and drift slightly at rest.
+const (
+	sonyGyroPerDPS      = 16.0   // DualShock 4 / DualSense: LSB per °/s (±2000 °/s)
+	sonyAccelPerG       = 8192.0 // DualShock 4 / DualSense: LSB per g
+	switchGyroDPSPerLSB = 0.070  // Switch Pro: °/s per LSB
+	switchAccelPerG     = 4096.0 // Switch Pro: LSB per g (±8 g)
+)
+
+// motionEqual reports whether two readings differ by less than the motion
+// thresholds on every axis.
+func motionEqual(a, b MotionState) bool {
+	return vectorWithin(a.Gyro, b.Gyro, gyroThreshold) && vectorWithin(a.Accel, b.Accel, accelThreshold)
+}
+
+func vectorWithin(a, b Vector3, threshold float64) bool {
+	return math.Abs(a.X-b.X) < threshold && math.Abs(a.Y-b.Y) < threshold && math.Abs(a.Z-b.Z) < threshold
+}
+
+// hidMotion reads the gyro and accelerometer from a raw HID input report of
+// the controllers whose report layout is known (DualShock 4, DualSense,
+// Switch Pro full mode), like hidBattery. ok is false for other devices and
+// reports.
+func hidMotion(vendorID, productID uint16, report []byte) (MotionState, bool) {
+	switch vendorID {
+	case nintendoVendorID:
+		return switchProMotion(report)
+	case sonyVendorID:
+		return sonyMotion(productID, report)
+	}
+	return MotionState{}, false
+}
+
+// sonyMotion reads the sensor block of a DualShock 4 or DualSense input
+// report: gyro pitch/yaw/roll then accel X/Y/Z, six int16 LE values, at USB
+// byte 13 (DualShock 4, report 0x01) or 16 (DualSense, report 0x01). The
+// Bluetooth full reports 0x11 / 0x31 carry it 2 and 1 bytes later. The axes
+// already match MotionState. The DualShock 4's reduced Bluetooth report 0x01
+// has no sensor data and is too short.
+func sonyMotion(productID uint16, report []byte) (MotionState, bool) {
+	if len(report) == 0 {
+		return MotionState{}, false
+	}
+	var offset int
+	switch {
+	case dualShock4PIDs[productID] && report[0] == 0x01:
+		offset = 13
+	case dualShock4PIDs[productID] && report[0] == 0x11:
+		offset = 15
+	case dualSensePIDs[productID] && report[0] == 0x01:
+		offset = 16
+	case dualSensePIDs[productID] && report[0] == 0x31:
+		offset = 17
+	default:
+		return MotionState{}, false
+	}
+	v, ok := readInt16s(report, offset)
+	if !ok {
+		return MotionState{}, false
+	}
+	gyro := (math.Pi / 180) / sonyGyroPerDPS
+	accel := standardGravity / sonyAccelPerG
+	return MotionState{
+		Gyro:  Vector3{X: v[0] * gyro, Y: v[1] * gyro, Z: v[2] * gyro},
+		Accel: Vector3{X: v[3] * accel, Y: v[4] * accel, Z: v[5] * accel},
+	}, true
+}
+
+// switchProMotion reads the first of the three IMU samples of a Switch Pro
+// 0x30 (full mode) report, bytes 13-24: accel X/Y/Z then gyro X/Y/Z, int16
+// LE, in the controller's own axes (X toward the triggers, Y left, Z up).
+// They are turned into MotionState's as SDL does: (-Y, Z, -X). Simple-mode
+// (0x3F) reports carry no sensor data, and full-mode samples read zero until
+// the IMU is enabled (by Steam or another driver; InputView sends no
+// subcommands).
+func switchProMotion(report []byte) (MotionState, bool) {
+	if len(report) == 0 || report[0] != switchProReportFull {
+		return MotionState{}, false
+	}
+	v, ok := readInt16s(report, 13)
+	if !ok {
+		return MotionState{}, false
+	}
+	accel := standardGravity / switchAccelPerG
+	gyro := switchGyroDPSPerLSB * math.Pi / 180
+	return MotionState{
+		Gyro:  Vector3{X: -v[4] * gyro, Y: v[5] * gyro, Z: -v[3] * gyro},
+		Accel: Vector3{X: -v[1] * accel, Y: v[2] * accel, Z: -v[0] * accel},
+	}, true
+}
+
+// readInt16s reads six little-endian int16 values from report at offset.
+func readInt16s(report []byte, offset int) ([6]float64, bool) {
+	var v [6]float64
+	if len(report) < offset+12 {
+		return v, false
+	}
+	for i := range v {
+		v[i] = float64(int16(binary.LittleEndian.Uint16(report[offset+2*i:])))
+	}
+	return v, true
+}
@@ -0,0 +1,72 @@
+package gamepad
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestHIDMotion(t *testing.T) {
+	// report returns a report of size bytes with the six int16 values v at
+	// offset.
+	report := func(id byte, size, offset int, v ...int16) []byte {
+		r := make([]byte, size)
+		r[0] = id
+		for i, x := range v {
+			binary.LittleEndian.PutUint16(r[offset+2*i:], uint16(x))
+		}
+		return r
+	}
+	const dps = math.Pi / 180
+	// Sony: 16 LSB per °/s, 8192 LSB per g; flat on the desk, turning left at 90 °/s.
+	sony := MotionState{Gyro: Vector3{Y: 90 * dps}, Accel: Vector3{Y: standardGravity}}
+	// Switch Pro: accel X/Y/Z then gyro X/Y/Z in its own axes; 0.070 °/s per LSB,
+	// 4096 LSB per g. Flat (+Z up), turning left (+Z) at 70 °/s.
+	switchPro := MotionState{Gyro: Vector3{Y: 70 * dps}, Accel: Vector3{Y: standardGravity}}
+	tests := []struct {
+		name     string
+		vid, pid uint16
+		report   []byte
+		want     MotionState
+		wantOK   bool
+	}{
+		{"ds4 usb", sonyVendorID, 0x09cc, report(0x01, 64, 13, 0, 1440, 0, 0, 8192, 0), sony, true},
+		{"ds4 bluetooth", sonyVendorID, 0x05c4, report(0x11, 78, 15, 0, 1440, 0, 0, 8192, 0), sony, true},
+		{"ds4 bluetooth reduced", sonyVendorID, 0x05c4, report(0x01, 10, 1), MotionState{}, false},
+		{"dualsense usb", sonyVendorID, 0x0ce6, report(0x01, 64, 16, 0, 1440, 0, 0, 8192, 0), sony, true},
+		{"dualsense bluetooth", sonyVendorID, 0x0ce6, report(0x31, 78, 17, 0, 1440, 0, 0, 8192, 0), sony, true},
+		{"switch pro full", nintendoVendorID, 0x2009, report(0x30, 49, 13, 0, 0, 4096, 0, 0, 1000), switchPro, true},
+		{"switch pro simple mode", nintendoVendorID, 0x2009, report(0x3F, 12, 1), MotionState{}, false},
+		{"dualshock 3", sonyVendorID, 0x0268, report(0x01, 64, 13, 0, 1440, 0, 0, 8192, 0), MotionState{}, false},
+		{"generic hid", 0x1234, 0x5678, report(0x01, 64, 13, 0, 1440, 0, 0, 8192, 0), MotionState{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := hidMotion(tt.vid, tt.pid, tt.report)
+		if !motionClose(got, tt.want) || ok != tt.wantOK {
+			t.Errorf("%s: hidMotion() = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestMotionDelta verifies that sensor noise below the thresholds sends no
+// delta while real movement does.
+func TestMotionDelta(t *testing.T) {
+	rest := GamepadState{Connected: true, Motion: MotionState{Accel: Vector3{Y: standardGravity}}}
+	noise := rest
+	noise.Motion.Gyro.X = 0.01
+	noise.Motion.Accel.Y += 0.05
+	if d := ComputeDelta(rest, noise); !d.IsEmpty() {
+		t.Errorf("ComputeDelta(rest, noise) = %+v, want empty", d)
+	}
+	tilted := rest
+	tilted.Motion.Gyro.X = 0.5
+	if d := ComputeDelta(rest, tilted); d.Motion == nil || *d.Motion != tilted.Motion {
+		t.Errorf("ComputeDelta(rest, tilted).Motion = %v, want %+v", d.Motion, tilted.Motion)
+	}
+}
+
+// motionClose reports whether a and b agree to well within the sensor
+// resolution.
+func motionClose(a, b MotionState) bool {
+	return vectorWithin(a.Gyro, b.Gyro, 1e-9) && vectorWithin(a.Accel, b.Accel, 1e-9)
+}
@@ -0,0 +1,53 @@
+package gamepad
+
+import (
+	"runtime"
+	"time"
+)
+
+// spinWindow is how long before a poll deadline the loop stops sleeping and
+// busy-waits when spin pacing is enabled (see Reader.SetPollSpin). OS timer
+// wake-ups can be late by up to ~1ms, which is a whole period at 1000Hz.
+const spinWindow = time.Millisecond
+
+// pacer schedules fixed-rate poll cycles against absolute deadlines.
+//
+// Sleeping for a fixed delay after each cycle lets the cycle's own run time and
+// every timer wake-up delay accumulate, so the effective rate is always lower
+// than configured and varies with load. pacer instead advances the deadline by
+// exactly one interval per cycle; a late wake-up shortens the next sleep.
+type pacer struct {
+	interval time.Duration
+	deadline time.Time
+}
+
+// next advances the schedule and returns the deadline of the next cycle.
+//
+// The schedule restarts from now when the interval changes (idle ↔ active
+// polling), after reset, or when the loop has fallen more than one interval
+// behind (e.g. after system suspend), so missed cycles are skipped instead of
+// being run back-to-back to catch up.
+func (p *pacer) next(now time.Time, interval time.Duration) time.Time {
+	if interval != p.interval || p.deadline.IsZero() {
+		p.interval = interval
+		p.deadline = now.Add(interval)
+		return p.deadline
+	}
+	p.deadline = p.deadline.Add(interval)
+	if now.Sub(p.deadline) > interval {
+		p.deadline = now
+	}
+	return p.deadline
+}
+
+// reset discards the schedule; the next call to next starts from now.
+func (p *pacer) reset() {
+	p.deadline = time.Time{}
+}
+
+// spinUntil busy-waits (yielding to the scheduler) until deadline.
+func spinUntil(deadline time.Time) {
+	for time.Now().Before(deadline) {
+		runtime.Gosched()
+	}
+}
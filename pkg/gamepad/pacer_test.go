@@ -0,0 +1,73 @@
+package gamepad
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPacerNoDrift verifies that deadlines advance by exactly one interval per
+// cycle regardless of how late each cycle actually ran.
+func TestPacerNoDrift(t *testing.T) {
+	var p pacer
+	start := time.Unix(1000, 0)
+	interval := 2 * time.Millisecond
+
+	first := p.next(start, interval)
+	if want := start.Add(interval); !first.Equal(want) {
+		t.Fatalf("first deadline = %v, want %v", first, want)
+	}
+
+	// Each cycle wakes 300µs late; deadlines must stay on the fixed grid.
+	deadline := first
+	for i := 2; i <= 100; i++ {
+		now := deadline.Add(300 * time.Microsecond)
+		deadline = p.next(now, interval)
+		if want := start.Add(time.Duration(i) * interval); !deadline.Equal(want) {
+			t.Fatalf("cycle %d deadline = %v, want %v (drift %v)", i, deadline, want, deadline.Sub(want))
+		}
+	}
+}
+
+// TestPacerRestart verifies that the schedule restarts from now after an
+// interval change, a reset, or falling more than one interval behind.
+func TestPacerRestart(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(p *pacer, now time.Time)
+		now   time.Duration // offset from base passed to the checked next() call
+		want  time.Duration // expected deadline offset from base
+	}{
+		{
+			name:  "interval change",
+			setup: func(p *pacer, now time.Time) { p.next(now, time.Millisecond) },
+			now:   0,
+			want:  500 * time.Millisecond,
+		},
+		{
+			name: "reset",
+			setup: func(p *pacer, now time.Time) {
+				p.next(now, 500*time.Millisecond)
+				p.reset()
+			},
+			now:  100 * time.Millisecond,
+			want: 600 * time.Millisecond,
+		},
+		{
+			name:  "fell behind",
+			setup: func(p *pacer, now time.Time) { p.next(now, 500*time.Millisecond) },
+			now:   5 * time.Second,
+			want:  5 * time.Second,
+		},
+	}
+	base := time.Unix(1000, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p pacer
+			tt.setup(&p, base)
+			got := p.next(base.Add(tt.now), 500*time.Millisecond)
+			if want := base.Add(tt.want); !got.Equal(want) {
+				t.Errorf("next() = %v, want %v", got.Sub(base), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package gamepad
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Raw mode limits: the axes, buttons, and hats RawState holds.
+const (
+	MaxRawAxes    = 16
+	MaxRawButtons = 128
+	MaxRawHats    = 4
+)
+
+// Raw hat directions. A hat's value is the sum of the pressed ones (0 =
+// centred), as SDL numbers them ("h0.4" is hat 0 down).
+const (
+	RawHatUp    = 1
+	RawHatRight = 2
+	RawHatDown  = 4
+	RawHatLeft  = 8
+)
+
+// RawState is a HID controller read without a mapping (ControllerType
+// "raw"; see Reader.SetRawMode), for devices no mapping fits and for working
+// out the indices of a mapping file. Axes holds the first NumAxes axes in
+// usage order, the order of SDL's "a<n>" indices, each -1 to 1 over its
+// logical range, with no deadzone or inversion; ControllerInfo.RawAxes names
+// them as "hidAxes" keys. Buttons is a bitmask of the HID button numbers of
+// "hidButtons", 32 per word (see Button), and Hats the first NumHats hat
+// switches (RawHat* sums). The gamepad groups stay zero.
+type RawState struct {
+	Axes       [MaxRawAxes]float64        `json:"axes"`
+	Buttons    [MaxRawButtons / 32]uint32 `json:"buttons"`
+	Hats       [MaxRawHats]uint8          `json:"hats"`
+	NumAxes    int                        `json:"numAxes"`
+	NumButtons int                        `json:"numButtons"`
+	NumHats    int                        `json:"numHats"`
+}
+
+// Button reports whether the 1-based HID button n is pressed. Clients read
+// bit (n-1)%32 of buttons[(n-1)/32].
+func (r RawState) Button(n int) bool {
+	if n < 1 || n > MaxRawButtons {
+		return false
+	}
+	return r.Buttons[(n-1)/32]&(1<<((n-1)%32)) != 0
+}
+
+// press sets the 1-based button n; others are ignored.
+func (r *RawState) press(n int) {
+	if n >= 1 && n <= MaxRawButtons {
+		r.Buttons[(n-1)/32] |= 1 << ((n - 1) % 32)
+	}
+}
+
+// rawEqual reports whether two raw readings differ by less than
+// analogThreshold on every axis and have the same buttons, hats, and counts.
+func rawEqual(a, b RawState) bool {
+	for i := range a.Axes {
+		if !floatEqual(a.Axes[i], b.Axes[i]) {
+			return false
+		}
+	}
+	return rawDigitalEqual(a, b)
+}
+
+// rawDigitalEqual reports whether a and b agree on everything but the axes.
+func rawDigitalEqual(a, b RawState) bool {
+	return a.Buttons == b.Buttons && a.Hats == b.Hats &&
+		a.NumAxes == b.NumAxes && a.NumButtons == b.NumButtons && a.NumHats == b.NumHats
+}
+
+// rawHat returns the RawHat* sum of the 0-based direction index of a HID hat
+// switch (see hatDirTable); values outside 0-7 are centred.
+func rawHat(idx int) uint8 {
+	if idx < 0 || idx >= len(hatDirTable) {
+		return 0
+	}
+	var h uint8
+	dirs := hatDirTable[idx]
+	for i, bit := range [4]uint8{RawHatUp, RawHatDown, RawHatLeft, RawHatRight} {
+		if dirs[i] {
+			h |= bit
+		}
+	}
+	return h
+}
+
+// validateRaw checks the ranges of a raw reading for GamepadState.Validate.
+func validateRaw(r RawState) error {
+	for i, v := range r.Axes {
+		if !(v >= -1 && v <= 1) { // also rejects NaN
+			return fmt.Errorf("raw.axes[%d] = %g: want a value in [-1, 1]", i, v)
+		}
+	}
+	for i, h := range r.Hats {
+		if h > RawHatUp|RawHatRight|RawHatDown|RawHatLeft {
+			return fmt.Errorf("raw.hats[%d] = %d: want a value in [0, 15]", i, h)
+		}
+	}
+	if r.NumAxes < 0 || r.NumAxes > MaxRawAxes || r.NumButtons < 0 || r.NumButtons > MaxRawButtons ||
+		r.NumHats < 0 || r.NumHats > MaxRawHats {
+		return fmt.Errorf("raw counts = %d axes, %d buttons, %d hats: want at most %d, %d, %d",
+			r.NumAxes, r.NumButtons, r.NumHats, MaxRawAxes, MaxRawButtons, MaxRawHats)
+	}
+	return nil
+}
+
+// hidAxisName returns the "hidAxes" key of a HID axis: its name (see
+// hidAxisNames) for the Generic Desktop axes, its usage in hex otherwise.
+func hidAxisName(usagePage, usage uint16) string {
+	if usagePage == usagePageGenericDesktop {
+		for name, u := range hidAxisNames {
+			if u == usage {
+				return name
+			}
+		}
+	}
+	return fmt.Sprintf("0x%02x", usage)
+}
+
+// ErrRawUnsupported is returned by Reader.SetRawMode for controllers with a
+// fixed layout.
+var ErrRawUnsupported = errors.New("controller has no raw mode (only generic HID controllers do)")
+
+// SetRawMode switches the controller of player playerIndex (0 = the active
+// controller) to raw mode, or back to its mapping. In raw mode its states
+// carry ControllerType "raw" and the unmapped axes, buttons, and hats in Raw
+// instead of the gamepad groups, from its next report on. A mapping file of
+// type "raw" starts a device in raw mode. XInput controllers and those read
+// by the Nintendo report parser have a fixed layout and return
+// ErrRawUnsupported. The mode lasts until the device is reconnected.
+func (r *Reader) SetRawMode(playerIndex int, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info := r.joystickLocked(playerIndex)
+	if info == nil {
+		return ErrNoController
+	}
+	dev := r.hidDevices[info.hDevice]
+	if info.sourceType != "hid" || dev == nil || !dev.setRawMode(enabled) {
+		return ErrRawUnsupported
+	}
+	return nil
+}
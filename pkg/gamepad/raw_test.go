@@ -0,0 +1,67 @@
+package gamepad
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRawState verifies the raw button bitmask, hat sums, and wire form.
+func TestRawState(t *testing.T) {
+	var r RawState
+	for _, n := range []int{1, 33, MaxRawButtons, 0, MaxRawButtons + 1} {
+		r.press(n)
+	}
+	if !r.Button(1) || !r.Button(33) || !r.Button(MaxRawButtons) || r.Button(2) || r.Button(0) {
+		t.Errorf("buttons = %v, want 1, 33, and %d", r.Buttons, MaxRawButtons)
+	}
+	for idx, want := range map[int]uint8{0: RawHatUp, 1: RawHatUp | RawHatRight, 4: RawHatDown, 7: RawHatUp | RawHatLeft, 8: 0, -1: 0} {
+		if got := rawHat(idx); got != want {
+			t.Errorf("rawHat(%d) = %d, want %d", idx, got, want)
+		}
+	}
+	r.Hats[0] = rawHat(2)
+	b, err := json.Marshal(r)
+	if err != nil || !strings.Contains(string(b), `"buttons":[1,1,0,2147483648],"hats":[2,0,0,0]`) {
+		t.Errorf("RawState JSON = %s, %v", b, err)
+	}
+	if rawDigitalEqual(r, RawState{Buttons: r.Buttons, Hats: r.Hats, NumAxes: 1}) {
+		t.Error("rawDigitalEqual() ignored the axis count")
+	}
+}
+
+// TestHIDAxisName verifies that raw axes are named as mapping files key them.
+func TestHIDAxisName(t *testing.T) {
+	tests := []struct {
+		page, usage uint16
+		want        string
+	}{
+		{usagePageGenericDesktop, hidUsageX, "x"},
+		{usagePageGenericDesktop, hidUsageRz, "rz"},
+		{usagePageGenericDesktop, hidUsageDial, "dial"},
+		{usagePageGenericDesktop, 0x40, "0x40"}, // Vx
+		{0x02, 0xc5, "0xc5"},                    // Simulation Controls: Brake
+	}
+	for _, tt := range tests {
+		if got := hidAxisName(tt.page, tt.usage); got != tt.want {
+			t.Errorf("hidAxisName(0x%02x, 0x%02x) = %q, want %q", tt.page, tt.usage, got, tt.want)
+		}
+	}
+}
+
+func TestReaderSetRawMode(t *testing.T) {
+	r := NewReader()
+	if err := r.SetRawMode(0, true); !errors.Is(err, ErrNoController) {
+		t.Errorf("SetRawMode() with nothing connected = %v, want ErrNoController", err)
+	}
+	r.joysticks[xinputKey(0)] = &joystickInfo{mapping: xboxMapping, sourceType: "xinput"}
+	r.joystickOrder = []joystickKey{xinputKey(0)}
+	r.activeKey, r.hasActive = xinputKey(0), true
+	if err := r.SetRawMode(1, true); !errors.Is(err, ErrRawUnsupported) {
+		t.Errorf("SetRawMode() on an XInput controller = %v, want ErrRawUnsupported", err)
+	}
+	if _, m, err := parseMappingFile(strings.NewReader(`{"type": "raw", "devices": ["1234:5678"]}`)); err != nil || m.Name != "raw" {
+		t.Errorf(`parseMappingFile("raw") = %+v, %v`, m, err)
+	}
+}
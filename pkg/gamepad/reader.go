@@ -0,0 +1,638 @@
+package gamepad
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sdlMappingsMu protects globalSDLMappings from concurrent read/write.
+var sdlMappingsMu sync.RWMutex
+
+// globalSDLMappings holds SDL gamecontrollerdb entries loaded at startup.
+// Keyed by (VendorID, ProductID). Protected by sdlMappingsMu.
+var globalSDLMappings map[deviceKey]*SDLMapping
+
+// sdlPlatformName maps runtime.GOOS values to the platform strings used in
+// gamecontrollerdb.txt entries.
+func sdlPlatformName() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Windows"
+	case "linux":
+		return "Linux"
+	case "darwin":
+		return "Mac OS X"
+	default:
+		return "Windows"
+	}
+}
+
+// SDLPlatform returns the gamecontrollerdb.txt platform name for the running
+// OS (e.g. "Windows"), as used by LoadSDLDB.
+func SDLPlatform() string { return sdlPlatformName() }
+
+// LoadSDLDB initialises the global SDL mapping table.
+//
+// It always loads the embedded gamecontrollerdb.txt as a base. If externalPath
+// is non-empty and the file exists, its entries are merged on top (external
+// entries take priority), allowing users to provide an updated database next to
+// the executable without recompiling.
+//
+// Call before any gamepads connect (ideally before Reader.Run). Safe to call
+// from any goroutine before Run().
+func LoadSDLDB(externalPath string) {
+	platform := sdlPlatformName()
+
+	// Load embedded DB as the base.
+	merged, err := LoadSDLMappingsFromReader(bytes.NewReader(embeddedGameControllerDB), platform)
+	if err != nil {
+		slog.Warn("sdldb: failed to parse embedded DB", "error", err)
+		merged = make(map[deviceKey]*SDLMapping)
+	}
+
+	// Overlay with external file if present.
+	if externalPath != "" {
+		if _, statErr := os.Stat(externalPath); statErr == nil {
+			ext, extErr := LoadSDLMappingsFromFile(externalPath, platform)
+			if extErr != nil {
+				slog.Warn("sdldb: failed to load external db", "path", externalPath, "error", extErr)
+			} else {
+				for k, v := range ext {
+					merged[k] = v
+				}
+				slog.Info("sdldb: merged external db", "path", externalPath, "total", len(merged))
+			}
+		}
+	}
+
+	sdlMappingsMu.Lock()
+	globalSDLMappings = merged
+	sdlMappingsMu.Unlock()
+}
+
+// SDLMappingCount returns the number of SDL mappings loaded by LoadSDLDB for
+// the current platform (0 before LoadSDLDB is called).
+func SDLMappingCount() int {
+	sdlMappingsMu.RLock()
+	defer sdlMappingsMu.RUnlock()
+	return len(globalSDLMappings)
+}
+
+// lookupSDLMapping returns the SDL mapping for a device's VID/PID, or nil if
+// no mapping was loaded, none matches, or the device has a custom mapping
+// (see LoadMappingDir) or is a built-in wheel or flight stick, which win over
+// the SDL database.
+func lookupSDLMapping(vendorID, productID uint16) *SDLMapping {
+	if lookupCustomMapping(vendorID, productID) != nil {
+		return nil
+	}
+	// The SDL database maps wheels and flight sticks as gamepads (pedals
+	// as triggers, a thrust lever as a stick axis); the built-in mappings
+	// keep their axes apart.
+	if m := knownDevices[deviceKey{VendorID: vendorID, ProductID: productID}]; m != nil && (m.Name == "wheel" || m.Name == "flightstick") {
+		return nil
+	}
+	sdlMappingsMu.RLock()
+	m := globalSDLMappings
+	sdlMappingsMu.RUnlock()
+	if m == nil {
+		return nil
+	}
+	return m[deviceKey{VendorID: vendorID, ProductID: productID}]
+}
+
+// joystickKey is a unified key for both XInput slots (0-3) and HID device handles.
+// XInput slots use values 0-3 directly.
+// HID device handles are stored as-is (kernel handles are always >= 4 and aligned,
+// so they never collide with XInput slot values 0-3).
+type joystickKey = uint64
+
+// xinputKey converts an XInput slot index (0-3) to a joystickKey.
+func xinputKey(slot uint32) joystickKey { return joystickKey(slot) }
+
+// hidKey converts a HID device handle to a joystickKey.
+func hidKey(hDevice uintptr) joystickKey { return joystickKey(hDevice) }
+
+// Reader reads gamepad input and emits state changes on a channel.
+// On Windows, it uses XInput for Xbox-compatible controllers and Raw Input HID
+// for all other HID gamepads (PS4/PS5, Switch Pro, generic). On other platforms
+// it is a no-op stub pending future implementation.
+type Reader struct {
+	state         GamepadState
+	prevState     GamepadState
+	joysticks     map[joystickKey]*joystickInfo // key: xinputKey(slot) or hidKey(hDevice)
+	activeKey     joystickKey                   // key of the active controller
+	hasActive     bool
+	joystickOrder []joystickKey // connection order
+	changes       *stateMailbox // latest-state mailbox; see mailbox.go
+	events        *eventQueue   // connect/disconnect events; see Events
+	mu            sync.RWMutex
+
+	// snapshot is the most recently published state. It points to an
+	// immutable copy that is swapped atomically in emitState, so State() never
+	// takes r.mu and HTTP/API readers do not contend with the input goroutines.
+	snapshot atomic.Pointer[GamepadState]
+
+	// deadzones are the deadzones of controllers without their own in
+	// deviceDeadzones (guarded by mu). See SetDeviceDeadzones.
+	deadzones       Deadzones
+	deviceDeadzones map[deviceKey]Deadzones
+
+	// pollDelay is the interval between XInput polling cycles.
+	pollDelay time.Duration
+
+	// pollSpin enables hybrid sleep/spin pacing: sleep until spinWindow before
+	// each poll deadline, then busy-wait. See SetPollSpin.
+	pollSpin bool
+
+	// allPlayers publishes the state of every connected controller, not
+	// only the active one. See SetAllPlayers.
+	allPlayers bool
+
+	// motion reads the gyro and accelerometer into GamepadState.Motion. See
+	// SetMotion.
+	motion bool
+
+	// joyConSideways shows a Joy-Con without a partner held sideways. See
+	// SetJoyConSideways.
+	joyConSideways bool
+
+	// calibrate reads HID triggers over the ranges learned in calibration.
+	// See SetTriggerCalibration.
+	calibrate   bool
+	calibration triggerCalibration
+
+	// joyConAttached maps the key of a Joy-Con attached to a partner (see
+	// pairJoyConLocked) to the key its pair is registered under. Attached
+	// Joy-Cons are not in joysticks or joystickOrder. Guarded by mu.
+	joyConAttached map[joystickKey]joystickKey
+
+	// sleeping makes the polling loop use idlePollDelay even while
+	// controllers are connected. See SetSleeping.
+	sleeping atomic.Bool
+
+	// ignoredXInput is 1 + the XInput slot the polling loop treats as empty
+	// (0 = none); see SetIgnoredXInputSlot.
+	ignoredXInput atomic.Int32
+
+	// pollCycles and pollTime accumulate the XInput polling cycles and the
+	// time spent in them (ns). See PollStats.
+	pollCycles atomic.Uint64
+	pollTime   atomic.Int64
+
+	// rumbles holds the timer that stops each rumbling XInput slot (see
+	// Rumble). Guarded by mu.
+	rumbles map[uint32]*time.Timer
+
+	// outputSeq numbers HID output reports (DualSense Bluetooth sequence
+	// tag; see SetLEDs). Guarded by mu.
+	outputSeq uint8
+
+	// wake interrupts an idle poll wait as soon as a controller is registered
+	// (e.g. a HID device arriving via WM_INPUT_DEVICE_CHANGE), so the loop
+	// returns to pollDelay without waiting out idlePollDelay. Capacity 1.
+	wake chan struct{}
+
+	// capture records raw input events for replay (nil when disabled).
+	// See SetCaptureWriter, StartCapture, and capture.go.
+	capture atomic.Pointer[captureWriter]
+
+	// hidDevices caches per-device HID capability info.
+	// Only accessed under r.mu.
+	hidDevices map[uintptr]*hidDeviceInfo
+
+	// disconnectedHIDs records HID device handles that have been explicitly
+	// disconnected via WM_INPUT_DEVICE_CHANGE (GIDC_REMOVAL). Residual WM_INPUT
+	// events that arrive after disconnection are suppressed using this set,
+	// preventing the fallback path in handleHIDInput from re-registering a
+	// device that has already been removed.
+	// Only accessed under r.mu.
+	disconnectedHIDs map[uintptr]struct{}
+
+	// xinputVIDPIDs tracks VID/PID pairs of currently connected XInput devices.
+	// Used to suppress duplicate HID registrations when XInput emulation software
+	// (e.g. Steam, BetterJoy) creates a virtual XInput device for the same
+	// physical controller — the raw HID interface lacks the "IG_" marker in its
+	// device name, so isXInputDevice() cannot filter it.
+	// Only accessed under r.mu.
+	xinputVIDPIDs map[deviceKey]int
+}
+
+// joystickInfo holds per-device metadata for a connected controller.
+type joystickInfo struct {
+	mapping    *DeviceMapping
+	name       string
+	vidPID     string    // "VID_XXXX&PID_XXXX" for logging; empty if unavailable
+	sourceType string    // "xinput" or "hid"
+	xinputSlot uint32    // XInput slot (0-3); only valid when sourceType=="xinput"
+	hDevice    uintptr   // HID device handle; only valid when sourceType=="hid"
+	devKey     deviceKey // VID/PID pair; zero if unavailable
+	caps       Capabilities
+	serial     string // HID serial number string; empty if unavailable (always for XInput)
+	version    uint16 // product version (USB bcdDevice); zero if unavailable
+
+	// battery is the last power state read from the device (XInput: polled
+	// every batteryPollInterval; HID: parsed from input reports).
+	// batteryPolled is when XInput was last asked. Guarded by Reader.mu.
+	battery       BatteryState
+	batteryPolled time.Time
+
+	// last is the last state published for the device, active or not. Only
+	// kept with SetAllPlayers. Guarded by Reader.mu.
+	last GamepadState
+
+	// partner is the Joy-Con attached to this one, read with it as one
+	// controller, and partnerKey its key (see pairJoyConLocked). half is the
+	// Joy-Con's own part of the last state. Guarded by Reader.mu.
+	partner    *joystickInfo
+	partnerKey joystickKey
+	half       GamepadState
+
+	// drift measures the sticks at rest (see Reader.Drift). Guarded by
+	// Reader.mu.
+	drift driftTracker
+}
+
+// deviceID returns the ControllerInfo.DeviceID of the joystick.
+func (info *joystickInfo) deviceID() string {
+	if info.sourceType == "xinput" {
+		return fmt.Sprintf("xinput-%d", info.xinputSlot)
+	}
+	return fmt.Sprintf("hid-%x", info.hDevice)
+}
+
+// setDeviceFields copies the per-device metadata that the report parsers do
+// not know (device ID, capabilities, serial, product version, battery) onto
+// s. Caller must hold r.mu (at least read lock) for battery.
+func (info *joystickInfo) setDeviceFields(s *GamepadState) {
+	s.DeviceID = info.deviceID()
+	s.Capabilities = info.caps
+	s.Serial = info.serial
+	s.ProductVersion = info.version
+	s.Battery = info.battery
+}
+
+// ControllerInfo describes a connected controller as reported by
+// Reader.Controllers.
+type ControllerInfo struct {
+	PlayerIndex int `json:"playerIndex"` // 1-based, in connection order
+
+	// DeviceID identifies the connection: "xinput-<slot>" or
+	// "hid-<Raw Input handle in hex>". It is unique among connected
+	// controllers and stays the same while the device is connected, unlike
+	// PlayerIndex, which shifts down when an earlier controller disconnects.
+	// It can be reused after a disconnect.
+	DeviceID string `json:"deviceId"`
+
+	Name           string `json:"name"`
+	ControllerType string `json:"controllerType"`
+	Source         string `json:"source"` // "xinput" or "hid"
+	VendorID       uint16 `json:"vendorId,omitempty"`
+	ProductID      uint16 `json:"productId,omitempty"`
+	Active         bool   `json:"active"`
+
+	// Serial is the device's serial number string (HID only; many
+	// controllers report none). ProductVersion is the USB bcdDevice /
+	// XInput version number, which identifies the firmware revision.
+	Serial         string `json:"serial,omitempty"`
+	ProductVersion uint16 `json:"productVersion,omitempty"`
+
+	// Battery is the last power state read from the device (zero if it
+	// reports none).
+	Battery BatteryState `json:"battery,omitzero"`
+
+	// GUID is the SDL GameControllerDB GUID for the device's VID/PID (USB bus
+	// layout), the first field of a gamecontrollerdb.txt line; empty when the
+	// VID/PID is unknown.
+	GUID string `json:"guid,omitempty"`
+
+	// Mapping names the path that turns raw input into GamepadState:
+	// "xinput", "sdl_db" (SDL GameControllerDB entry), "builtin" (VID/PID
+	// table or generic HID defaults), "custom" (a LoadMappingDir file), or
+	// "nintendo" (custom report parser).
+	Mapping string `json:"mapping"`
+
+	// SDLName is the name of the GameControllerDB entry in use when Mapping
+	// is "sdl_db".
+	SDLName string `json:"sdlName,omitempty"`
+
+	// Axes, Buttons, and Hats are the raw input counts the device exposes
+	// (XInput devices report the fixed XINPUT_GAMEPAD layout; zero when the
+	// custom parser bypasses the HID descriptor).
+	Axes    int `json:"axes"`
+	Buttons int `json:"buttons"`
+	Hats    int `json:"hats"`
+
+	// RawAxes names the device's axes in the order of RawState.Axes, as
+	// "hidAxes" keys of a mapping file ("x", "rz", or a usage such as
+	// "0xc5"); HID devices only.
+	RawAxes []string `json:"rawAxes,omitempty"`
+
+	// Deadzones are the deadzones the device is read with: its own (see
+	// SetDeviceDeadzones) or the Reader's.
+	Deadzones Deadzones `json:"deadzones"`
+}
+
+// NewReader creates a new Reader with default deadzone and poll rate.
+func NewReader() *Reader {
+	r := &Reader{
+		joysticks:        make(map[joystickKey]*joystickInfo),
+		hidDevices:       make(map[uintptr]*hidDeviceInfo),
+		disconnectedHIDs: make(map[uintptr]struct{}),
+		xinputVIDPIDs:    make(map[deviceKey]int),
+		joyConAttached:   make(map[joystickKey]joystickKey),
+		changes:          newStateMailbox(defaultMailboxCapacity),
+		events:           newEventQueue(eventsBuffer),
+		deadzones:        uniformDeadzones(DefaultDeadzone),
+		pollDelay:        16 * time.Millisecond,
+		wake:             make(chan struct{}, 1),
+	}
+	r.snapshot.Store(&GamepadState{})
+	return r
+}
+
+// SetDeadzone sets the stick and trigger deadzone threshold (0.0-1.0) of
+// controllers without their own; see SetDeadzones. Values outside [0, 1] are
+// clamped.
+func (r *Reader) SetDeadzone(dz float64) { r.SetDeadzones(uniformDeadzones(dz)) }
+
+// SetPollDelay sets the interval between XInput polling cycles.
+func (r *Reader) SetPollDelay(d time.Duration) { r.pollDelay = d }
+
+// SetPollSpin enables hybrid sleep/spin poll pacing for sub-millisecond
+// accuracy at high poll rates (500–1000Hz). Costs up to spinWindow of busy CPU
+// per cycle while a controller is connected; never used while idle.
+func (r *Reader) SetPollSpin(enabled bool) { r.pollSpin = enabled }
+
+// SetChangesBuffer sets how many undelivered states the changes mailbox holds
+// before the oldest pending button edge is discarded (default 16; analog-only
+// updates are always merged and do not consume slots). Larger values survive
+// longer broadcaster stalls at the cost of delivering a longer backlog of
+// stale edges afterwards. Must be called before Changes() and Run().
+func (r *Reader) SetChangesBuffer(n int) {
+	r.changes = newStateMailbox(n)
+}
+
+// SetAllPlayers makes the Reader publish the state of every connected
+// controller on Changes, each with its own PlayerIndex, so all of them can be
+// shown at once (local multiplayer). By default only the active controller's
+// state is published. The active controller still decides State() and the
+// ControllerSwitched events. Must be called before Run.
+func (r *Reader) SetAllPlayers(enabled bool) { r.allPlayers = enabled }
+
+// SetMotion makes the Reader fill GamepadState.Motion from the gyro and
+// accelerometer of the controllers whose HID reports carry them (DualShock 4,
+// DualSense, Switch Pro in full mode), for gyro-aiming overlays. Off by
+// default: the sensors change with every report, so a held controller sends
+// a delta per report (250-1000 Hz) instead of only on input changes. Must be
+// called before Run.
+func (r *Reader) SetMotion(enabled bool) { r.motion = enabled }
+
+// changedLocked records s as the last state of the controller info (see
+// SetAllPlayers) and reports whether it differs from the previous one.
+// Caller must hold r.mu.
+func (r *Reader) changedLocked(info *joystickInfo, s GamepadState) bool {
+	if ComputeDelta(info.last, s).IsEmpty() && info.last.PlayerIndex == s.PlayerIndex {
+		return false
+	}
+	info.last = s
+	return true
+}
+
+// renumberLocked returns the states to publish with SetAllPlayers after the
+// controller of player removed disconnected: every inactive controller from
+// that index up under its new, lower index, then a disconnected state for
+// the index no controller has any more. nil without SetAllPlayers or
+// controllers. Caller must hold r.mu, with the active controller settled.
+func (r *Reader) renumberLocked(removed int) []GamepadState {
+	if !r.allPlayers || len(r.joystickOrder) == 0 {
+		return nil
+	}
+	var out []GamepadState
+	for i := removed - 1; i < len(r.joystickOrder); i++ {
+		key := r.joystickOrder[i]
+		info := r.joysticks[key]
+		if info == nil || (r.hasActive && key == r.activeKey) {
+			continue
+		}
+		info.last.PlayerIndex = i + 1
+		out = append(out, info.last)
+	}
+	return append(out, GamepadState{PlayerIndex: len(r.joystickOrder) + 1})
+}
+
+// putAll posts states to the changes mailbox, in order.
+func (r *Reader) putAll(states []GamepadState) {
+	for _, s := range states {
+		r.changes.put(s)
+	}
+}
+
+// idlePollDelay is the hot-plug check interval used while no controller is
+// connected. Polling empty XInput slots every pollDelay keeps a tray-resident
+// app measurably busy for nothing; a slow check is enough to notice a new pad.
+const idlePollDelay = 500 * time.Millisecond
+
+// SetSleeping makes Run poll XInput at the slow idle rate (idlePollDelay) even
+// while controllers are connected, for a server nobody is using. Input is
+// still read, only later; HID controllers (Raw Input) are unaffected.
+// SetSleeping(false) returns to the poll rate right away. Safe to call from
+// any goroutine.
+func (r *Reader) SetSleeping(sleeping bool) {
+	r.sleeping.Store(sleeping)
+	if !sleeping {
+		r.signalWake()
+	}
+}
+
+// SetIgnoredXInputSlot makes the Reader treat XInput slot (0-3) as empty: a
+// controller there is disconnected and no longer read. For a virtual
+// controller InputView feeds itself (see internal/vigem), which would
+// otherwise show up as a second copy of the active one. -1 reads every slot
+// again. Safe to call from any goroutine.
+func (r *Reader) SetIgnoredXInputSlot(slot int) {
+	r.ignoredXInput.Store(int32(max(slot, -1) + 1))
+}
+
+// xinputIgnored reports whether XInput slot is ignored (see
+// SetIgnoredXInputSlot).
+func (r *Reader) xinputIgnored(slot uint32) bool {
+	return r.ignoredXInput.Load() == int32(slot)+1
+}
+
+// nextPollDelay returns how long the polling loop should wait before the next
+// cycle: pollDelay while any controller is connected, idlePollDelay otherwise
+// or while sleeping.
+func (r *Reader) nextPollDelay() time.Duration {
+	r.mu.RLock()
+	idle := len(r.joysticks) == 0 || r.sleeping.Load()
+	r.mu.RUnlock()
+	if idle && idlePollDelay > r.pollDelay {
+		return idlePollDelay
+	}
+	return r.pollDelay
+}
+
+// PollStats are the running totals of the XInput polling loop (see
+// Reader.PollStats).
+type PollStats struct {
+	Cycles uint64        // polling cycles run
+	Total  time.Duration // time spent polling in them, excluding the waits between cycles
+}
+
+// PollStats returns the running totals of the XInput polling loop, for
+// monitoring: Total / Cycles is the average cost of a cycle. Both stay 0 where
+// XInput is unavailable (and off Windows). Safe to call from any goroutine.
+func (r *Reader) PollStats() PollStats {
+	return PollStats{Cycles: r.pollCycles.Load(), Total: time.Duration(r.pollTime.Load())}
+}
+
+// recordPoll adds a polling cycle that took d to PollStats.
+func (r *Reader) recordPoll(d time.Duration) {
+	r.pollTime.Add(int64(d))
+	r.pollCycles.Add(1)
+}
+
+// signalWake interrupts an idle poll wait (non-blocking).
+func (r *Reader) signalWake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Changes returns the channel on which state changes are emitted.
+// If the consumer falls behind, intermediate states are collapsed and only the
+// newest pending state is delivered. The channel is closed when Run returns.
+func (r *Reader) Changes() <-chan GamepadState {
+	return r.changes.recv()
+}
+
+// State returns the most recently published state of the active controller.
+// Lock-free; safe to call from any goroutine at any rate.
+func (r *Reader) State() GamepadState {
+	return *r.snapshot.Load()
+}
+
+// Controllers returns the connected controllers in player-index order.
+func (r *Reader) Controllers() []ControllerInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]ControllerInfo, 0, len(r.joystickOrder))
+	for i, key := range r.joystickOrder {
+		info := r.joysticks[key]
+		if info == nil {
+			continue
+		}
+		out = append(out, r.controllerInfoLocked(info, i+1, r.hasActive && r.activeKey == key))
+	}
+	return out
+}
+
+// GetPlayerIndex returns the 1-based player index of the currently active controller.
+func (r *Reader) GetPlayerIndex() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i, key := range r.joystickOrder {
+		if key == r.activeKey {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// SetActiveByPlayerIndex sets the active controller by 1-based player index.
+// Returns true if successful, false if the index is out of range. Switching
+// to a different controller emits a ControllerSwitched event.
+func (r *Reader) SetActiveByPlayerIndex(playerIndex int) bool {
+	r.mu.Lock()
+	if playerIndex < 1 || playerIndex > len(r.joystickOrder) {
+		r.mu.Unlock()
+		return false
+	}
+	newKey := r.joystickOrder[playerIndex-1]
+	info := r.joysticks[newKey]
+	if info == nil {
+		r.mu.Unlock()
+		return false
+	}
+
+	var switched *ControllerEvent
+	if r.hasActive && r.activeKey != newKey {
+		if prevInfo := r.joysticks[r.activeKey]; prevInfo != nil {
+			prev := r.controllerInfoLocked(prevInfo, r.getPlayerIndexLocked(r.activeKey), true)
+			switched = &ControllerEvent{
+				Type:       ControllerSwitched,
+				Reason:     SwitchSelected,
+				Controller: r.controllerInfoLocked(info, playerIndex, true),
+				Previous:   &prev,
+			}
+		}
+	}
+
+	r.activeKey = newKey
+	r.hasActive = true
+	if r.allPlayers {
+		// Take over the controller's own state, not the buttons of the previous one.
+		r.state = info.last
+		r.prevState = info.last
+	}
+	r.state.Connected = true
+	r.state.Name = info.name
+	r.state.ControllerType = info.mapping.Name
+	r.state.PlayerIndex = playerIndex
+	info.setDeviceFields(&r.state)
+	r.mu.Unlock()
+
+	r.emitState()
+	if switched != nil {
+		r.events.put(*switched)
+	}
+	return true
+}
+
+// Inject replaces the current state with s and emits it as if it had been read
+// from a device. Intended for synthetic input (benchmark mode, relays); it does
+// not touch the joystick tracking lists. Safe to call from several goroutines:
+// s itself is published under the lock, so concurrent injectors (one per relayed
+// player) never publish each other's state or reorder the snapshot.
+func (r *Reader) Inject(s GamepadState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = s
+	r.prevState = s
+	r.publish(s)
+}
+
+// emitState publishes the current state. Like Inject it publishes under the
+// write lock, so a poll-loop emit racing an Inject cannot publish the older
+// state last.
+func (r *Reader) emitState() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.publish(r.state)
+}
+
+// publish swaps the lock-free snapshot to s and posts s to the changes mailbox
+// (non-blocking). r.mu must be held for writing.
+func (r *Reader) publish(s GamepadState) {
+	r.snapshot.Store(&s)
+	r.changes.put(s)
+}
+
+// getPlayerIndexLocked returns the 1-based player index for a key.
+// Caller must hold r.mu (at least read lock).
+func (r *Reader) getPlayerIndexLocked(key joystickKey) int {
+	for i, k := range r.joystickOrder {
+		if k == key {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+//go:build !windows
+
+package gamepad
+
+import (
+	"context"
+	"errors"
+)
+
+// Run blocks until ctx is cancelled.
+// Gamepad reading is not yet implemented on non-Windows platforms.
+func (r *Reader) Run(ctx context.Context) {
+	<-ctx.Done()
+	r.changes.close()
+	r.events.close()
+}
+
+// SetRawInputReader is a no-op on non-Windows platforms: there is no Raw Input
+// HID path to register with.
+func (r *Reader) SetRawInputReader(kmReader HIDSource) {}
+
+// hidOutputReportLength is 0 on non-Windows platforms (no HID devices).
+func hidOutputReportLength(dev *hidDeviceInfo) int { return 0 }
+
+// writeHIDOutputReport always fails on non-Windows platforms.
+func writeHIDOutputReport(hDevice uintptr, report []byte) error {
+	return errors.New("writing HID output reports requires Windows")
+}
+
+// xiSetVibration always fails on non-Windows platforms: there is no XInput.
+func xiSetVibration(slot uint32, low, high uint16) bool { return false }
@@ -0,0 +1,228 @@
+package gamepad
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestNextPollDelay verifies that the polling loop backs off to idlePollDelay
+// only while no controller is connected or while sleeping, and never slows a poll rate that is
+// already slower than the idle interval.
+func TestNextPollDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		pollDelay time.Duration
+		connected bool
+		sleeping  bool
+		want      time.Duration
+	}{
+		{"idle uses hot-plug interval", 16 * time.Millisecond, false, false, idlePollDelay},
+		{"connected uses poll delay", 16 * time.Millisecond, true, false, 16 * time.Millisecond},
+		{"idle keeps slower poll delay", 2 * time.Second, false, false, 2 * time.Second},
+		{"sleeping uses hot-plug interval", 16 * time.Millisecond, true, true, idlePollDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader()
+			r.SetPollDelay(tt.pollDelay)
+			r.SetSleeping(tt.sleeping)
+			if tt.connected {
+				r.joysticks[xinputKey(0)] = &joystickInfo{mapping: xboxMapping, name: "Xbox"}
+			}
+			if got := r.nextPollDelay(); got != tt.want {
+				t.Errorf("nextPollDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPollStats verifies that PollStats sums the recorded polling cycles.
+func TestPollStats(t *testing.T) {
+	r := NewReader()
+	if got := r.PollStats(); got != (PollStats{}) {
+		t.Fatalf("PollStats() before polling = %+v, want zero", got)
+	}
+	r.recordPoll(2 * time.Millisecond)
+	r.recordPoll(time.Millisecond)
+	if got, want := r.PollStats(), (PollStats{Cycles: 2, Total: 3 * time.Millisecond}); got != want {
+		t.Errorf("PollStats() = %+v, want %+v", got, want)
+	}
+}
+
+// TestIgnoredXInputSlot verifies that one slot at a time is ignored, and -1
+// ignores none.
+func TestIgnoredXInputSlot(t *testing.T) {
+	r := NewReader()
+	if r.xinputIgnored(0) {
+		t.Error("slot 0 ignored by default")
+	}
+	r.SetIgnoredXInputSlot(1)
+	if !r.xinputIgnored(1) || r.xinputIgnored(0) {
+		t.Error("SetIgnoredXInputSlot(1) did not ignore only slot 1")
+	}
+	r.SetIgnoredXInputSlot(-1)
+	if r.xinputIgnored(1) {
+		t.Error("slot 1 still ignored after SetIgnoredXInputSlot(-1)")
+	}
+}
+
+// TestReaderStateSnapshot verifies that State() reflects the last published
+// state and that later mutations of the caller's copy do not leak into it.
+func TestReaderStateSnapshot(t *testing.T) {
+	r := NewReader()
+	if got := r.State(); got.Connected {
+		t.Fatalf("State() before any input = %+v, want zero state", got)
+	}
+
+	s := GamepadState{Connected: true, Name: "Test Pad", PlayerIndex: 1}
+	s.Buttons.A = true
+	r.Inject(s)
+	s.Buttons.A = false
+
+	got := r.State()
+	if !got.Connected || got.Name != "Test Pad" || !got.Buttons.A {
+		t.Errorf("State() = %+v, want injected state with A pressed", got)
+	}
+}
+
+// TestReaderConcurrentInject verifies that two injectors on different players
+// (as with several relayed instances) each get every one of their states
+// published, in order, and that the snapshot is the last injected state.
+func TestReaderConcurrentInject(t *testing.T) {
+	const n = 500
+	r := NewReader()
+	r.SetChangesBuffer(2 * n) // every state is a button edge; keep them all
+	var wg sync.WaitGroup
+	for _, player := range []int{1, 2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= n; i++ {
+				s := GamepadState{Connected: true, PlayerIndex: player}
+				s.Buttons.A = i%2 == 1
+				s.Sticks.Left.Position.X = float64(i)
+				r.Inject(s)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := map[int][]float64{}
+	for pending := true; pending; {
+		select {
+		case s := <-r.Changes():
+			got[s.PlayerIndex] = append(got[s.PlayerIndex], s.Sticks.Left.Position.X)
+		default:
+			pending = false
+		}
+	}
+	for _, player := range []int{1, 2} {
+		xs := got[player]
+		ok := len(xs) == n
+		for i := 0; ok && i < n; i++ {
+			ok = xs[i] == float64(i+1)
+		}
+		if !ok {
+			t.Errorf("player %d received %d states, want its %d injected states in order", player, len(xs), n)
+		}
+	}
+	r.mu.RLock()
+	want := r.state
+	r.mu.RUnlock()
+	if got := r.State(); got != want {
+		t.Errorf("State() = %+v, want the last injected state %+v", got, want)
+	}
+}
+
+// TestReaderEmitStateInject verifies that poll-loop emits racing an injector
+// never leave the snapshot behind the current state.
+func TestReaderEmitStateInject(t *testing.T) {
+	const n = 500
+	r := NewReader()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= n; i++ {
+			s := GamepadState{Connected: true, PlayerIndex: 1}
+			s.Sticks.Left.Position.X = float64(i)
+			r.Inject(s)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range n {
+			r.emitState()
+		}
+	}()
+	wg.Wait()
+
+	if got := r.State(); got.Sticks.Left.Position.X != n {
+		t.Errorf("State() left stick X = %v, want the last injected %d", got.Sticks.Left.Position.X, n)
+	}
+}
+
+// TestReaderControllers verifies that Controllers lists devices in player
+// order with the active flag and the fixed XInput capability counts.
+func TestReaderControllers(t *testing.T) {
+	r := NewReader()
+	if got := r.Controllers(); len(got) != 0 {
+		t.Fatalf("Controllers() with nothing connected = %+v, want empty", got)
+	}
+
+	ps := GetMapping(0x054c, 0x0ce6)
+	r.joysticks[xinputKey(1)] = &joystickInfo{mapping: xboxMapping, name: "Xbox", sourceType: "xinput", xinputSlot: 1, devKey: deviceKey{VendorID: 0x045e, ProductID: 0x028e}}
+	r.joysticks[hidKey(0x1000)] = &joystickInfo{mapping: ps, name: "DualSense", sourceType: "hid", hDevice: 0x1000}
+	r.joystickOrder = []joystickKey{hidKey(0x1000), xinputKey(1)}
+	r.activeKey, r.hasActive = xinputKey(1), true
+
+	got := r.Controllers()
+	if len(got) != 2 {
+		t.Fatalf("Controllers() returned %d entries, want 2", len(got))
+	}
+	if got[0].PlayerIndex != 1 || got[0].Name != "DualSense" || got[0].Source != "hid" || got[0].Active {
+		t.Errorf("Controllers()[0] = %+v, want inactive P1 DualSense over HID", got[0])
+	}
+	want := ControllerInfo{
+		PlayerIndex: 2, DeviceID: "xinput-1", Name: "Xbox", ControllerType: xboxMapping.Name, Source: "xinput",
+		VendorID: 0x045e, ProductID: 0x028e, Active: true, GUID: "030000005e0400008e02000000000000",
+		Mapping: "xinput", Axes: 6, Buttons: 11, Hats: 1, Deadzones: uniformDeadzones(DefaultDeadzone),
+	}
+	if !reflect.DeepEqual(got[1], want) {
+		t.Errorf("Controllers()[1] = %+v, want %+v", got[1], want)
+	}
+}
+
+// TestReaderRenumberAllPlayers verifies that with SetAllPlayers a disconnect
+// republishes the inactive controllers after it under their new indices and
+// clears the index left over, and that nothing is republished by default.
+func TestReaderRenumberAllPlayers(t *testing.T) {
+	r := NewReader()
+	// Player 1 (hid-10) has just been removed; players 2-4 remain.
+	for i, key := range []joystickKey{hidKey(0x20), xinputKey(1), hidKey(0x30)} {
+		r.joysticks[key] = &joystickInfo{mapping: xboxMapping, last: GamepadState{Connected: true, PlayerIndex: i + 2, DeviceID: fmt.Sprint(key)}}
+		r.joystickOrder = append(r.joystickOrder, key)
+	}
+	r.activeKey, r.hasActive = xinputKey(1), true
+
+	if got := r.renumberLocked(1); got != nil {
+		t.Errorf("renumberLocked() without SetAllPlayers = %+v, want nil", got)
+	}
+	r.SetAllPlayers(true)
+	got := r.renumberLocked(1)
+	if len(got) != 3 {
+		t.Fatalf("renumberLocked() returned %d states, want 3: %+v", len(got), got)
+	}
+	if got[0].PlayerIndex != 1 || got[0].DeviceID != fmt.Sprint(hidKey(0x20)) || !got[0].Connected {
+		t.Errorf("state 0 = %+v, want the first controller as player 1", got[0])
+	}
+	if got[1].PlayerIndex != 3 || got[1].DeviceID != fmt.Sprint(hidKey(0x30)) {
+		t.Errorf("state 1 = %+v, want the third controller as player 3 (the active one is skipped)", got[1])
+	}
+	if got[2].PlayerIndex != 4 || got[2].Connected {
+		t.Errorf("state 2 = %+v, want player 4 disconnected", got[2])
+	}
+}
@@ -7,12 +7,6 @@ import (
 	"fmt"
 	"log/slog"
 	"time"
-
-	"github.com/soar/inputview/internal/rawinput"
-)
-
-const (
-	triggerMax = 255.0 // XINPUT trigger range: 0-255
 )
 
 // HID usage page / usage IDs for gamepad registration (mirrors rawinput constants).
@@ -22,6 +16,11 @@ const (
 	hidUsageIDGamepad   = 0x05
 )
 
+// batteryPollInterval is how often XInputGetBatteryInformation is called for
+// the active XInput controller. Battery levels change slowly; the call is not
+// free, so it is not made every poll cycle.
+const batteryPollInterval = 5 * time.Second
+
 // Run initialises XInput, registers HID callbacks, and runs the polling loop
 // until ctx is cancelled. XInput is thread-safe and does not require LockOSThread.
 func (r *Reader) Run(ctx context.Context) {
@@ -36,37 +35,67 @@ func (r *Reader) Run(ctx context.Context) {
 		// Initial scan for already-connected XInput controllers.
 		for i := uint32(0); i < xinputMaxControllers; i++ {
 			var state xinputState
-			if xiGetStateEx(i, &state) == errorSuccess {
+			if !r.xinputIgnored(i) && xiGetStateEx(i, &state) == errorSuccess {
 				r.connectXInput(i)
 			}
 		}
 	}
 
+	// Poll at pollDelay while controllers are connected; drop to the slow
+	// idlePollDelay hot-plug check when none are, waking early if a HID
+	// device registers in the meantime. Cycles are paced against absolute
+	// deadlines (see pacer) so poll time and timer latency do not drift.
+	var p pacer
+	var deadline time.Time
+	spin := false
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			r.mu.Lock()
+			r.stopRumblesLocked()
 			r.joysticks = make(map[joystickKey]*joystickInfo)
 			r.joystickOrder = nil
 			r.hasActive = false
 			r.state = GamepadState{}
 			r.mu.Unlock()
-			close(r.changes)
+			r.snapshot.Store(&GamepadState{})
+			r.changes.close()
+			r.events.close()
 			return
-		default:
+		case <-timer.C:
+			if spin {
+				spinUntil(deadline)
+			}
+		case <-r.wake:
+			// timer.Reset below discards the pending expiry (Go 1.23+ timer semantics).
+			p.reset()
 		}
 
 		if xinputAvailable {
+			start := time.Now()
 			r.pollAllXInput()
+			r.recordPoll(time.Since(start))
+		}
+
+		now := time.Now()
+		interval := r.nextPollDelay()
+		deadline = p.next(now, interval)
+		sleep := deadline.Sub(now)
+		spin = r.pollSpin && interval == r.pollDelay
+		if spin {
+			sleep -= spinWindow
 		}
-		time.Sleep(r.pollDelay)
+		timer.Reset(max(sleep, 0))
 	}
 }
 
-// SetRawInputReader registers HID gamepad callbacks on the provided rawinput.Reader
-// so that non-XInput gamepads (PS4/PS5/Switch Pro/generic HID) are captured through
-// the same HWND_MESSAGE window. Must be called before kmReader.Run().
-func (r *Reader) SetRawInputReader(kmReader *rawinput.Reader) {
+// SetRawInputReader registers HID gamepad callbacks on the provided HIDSource
+// (in InputView, the rawinput.Reader) so that non-XInput gamepads
+// (PS4/PS5/Switch Pro/generic HID) are captured through the same HWND_MESSAGE
+// window. Must be called before the source's message loop starts.
+func (r *Reader) SetRawInputReader(kmReader HIDSource) {
 	kmReader.RegisterHIDCallback(
 		hidUsagePageGeneric, hidUsageIDJoystick,
 		r.handleHIDInput, r.handleHIDDeviceChange,
@@ -85,19 +114,24 @@ func (r *Reader) SetRawInputReader(kmReader *rawinput.Reader) {
 func (r *Reader) pollAllXInput() {
 	for i := uint32(0); i < xinputMaxControllers; i++ {
 		var state xinputState
-		ret := xiGetStateEx(i, &state)
+		ret := errorDeviceNotConnected
+		if !r.xinputIgnored(i) {
+			ret = xiGetStateEx(i, &state)
+		}
 		key := xinputKey(i)
 
 		r.mu.RLock()
-		_, wasConnected := r.joysticks[key]
+		info, wasConnected := r.joysticks[key]
 		r.mu.RUnlock()
 
 		switch {
 		case ret == errorSuccess && !wasConnected:
 			r.connectXInput(i)
 		case ret != errorSuccess && wasConnected:
-			r.disconnectJoystick(key)
+			r.capture.Load().disconnect("xinput", uint64(i))
+			r.disconnectJoystick(key, DisconnectUnplugged)
 		case ret == errorSuccess && wasConnected:
+			r.capture.Load().xinputInput(i, info.devKey.VendorID, info.devKey.ProductID, &state)
 			r.updateXInputState(i, &state)
 		}
 	}
@@ -105,19 +139,35 @@ func (r *Reader) pollAllXInput() {
 
 // connectXInput handles a newly detected XInput controller at slot i.
 func (r *Reader) connectXInput(userIndex uint32) {
-	vid, pid, hasPID := xiGetCapabilitiesEx(userIndex)
+	vid, pid, version, hasPID := xiGetCapabilitiesEx(userIndex)
+	r.capture.Load().xinputConnect(userIndex, vid, pid)
 	mapping := xboxMapping
 	vidPID := ""
 	if hasPID {
 		mapping = GetMapping(vid, pid)
 		vidPID = fmt.Sprintf("VID_%04X&PID_%04X", vid, pid)
 		// Record the VID/PID so that duplicate HID registrations for the same
-		// physical device can be suppressed (e.g. Switch Pro via Steam XInput).
+		// physical device can be suppressed (e.g. Switch Pro via Steam XInput),
+		// and drop a HID registration that was made before the XInput device
+		// appeared, so the controller is not read twice.
+		dk := deviceKey{VendorID: vid, ProductID: pid}
+		var duplicates []joystickKey
 		r.mu.Lock()
-		r.xinputVIDPIDs[deviceKey{VendorID: vid, ProductID: pid}]++
+		r.xinputVIDPIDs[dk]++
+		for k, ji := range r.joysticks {
+			if ji.sourceType == "hid" && ji.devKey == dk {
+				duplicates = append(duplicates, k)
+			}
+		}
 		r.mu.Unlock()
+		for _, k := range duplicates {
+			r.disconnectJoystick(k, DisconnectReplaced)
+		}
 	}
+	mapping = xinputMapping(mapping, xiSubType(userIndex))
 	name := buildControllerName(mapping.Name, vidPID)
+	caps := knownCapabilities(vid, pid, true)
+	caps.NumAxes, caps.NumButtons = xinputNumAxes, xinputNumButtons
 
 	info := &joystickInfo{
 		mapping:    mapping,
@@ -126,27 +176,50 @@ func (r *Reader) connectXInput(userIndex uint32) {
 		sourceType: "xinput",
 		xinputSlot: userIndex,
 		devKey:     deviceKey{VendorID: vid, ProductID: pid},
+		caps:       caps,
+		version:    version,
 	}
 	key := xinputKey(userIndex)
 	r.registerJoystick(key, info)
 }
 
-// updateXInputState reads the current XInput state for the active controller.
+// updateXInputState reads the current XInput state for the active controller
+// (every controller with SetAllPlayers).
 func (r *Reader) updateXInputState(userIndex uint32, state *xinputState) {
 	key := xinputKey(userIndex)
 	r.mu.RLock()
 	isActive := r.hasActive && r.activeKey == key
 	info := r.joysticks[key]
+	var dz Deadzones
+	if info != nil {
+		dz = r.deadzonesLocked(info.devKey)
+	}
 	r.mu.RUnlock()
 
-	if !isActive || info == nil {
+	if (!isActive && !r.allPlayers) || info == nil {
 		return
 	}
 
-	newState := convertXInputState(state, info, r.deadzone)
-	newState.PlayerIndex = r.GetPlayerIndex()
+	r.pollXInputBattery(userIndex, info)
+	newState := convertXInputState(state, info, dz.rawSticks())
 
 	r.mu.Lock()
+	drift := r.trackDriftLocked(key, info, newState.Sticks, joyConNone, dz.Stick)
+	dz.applyAllSticks(&newState.Sticks)
+	newState.PlayerIndex = r.getPlayerIndexLocked(key)
+	info.setDeviceFields(&newState)
+	if !isActive { // only with allPlayers
+		changed := r.changedLocked(info, newState)
+		r.mu.Unlock()
+		if changed {
+			r.changes.put(newState)
+		}
+		r.putDrift(drift)
+		return
+	}
+	if r.allPlayers {
+		info.last = newState
+	}
 	delta := ComputeDelta(r.prevState, newState)
 	if !delta.IsEmpty() {
 		r.state = newState
@@ -156,61 +229,25 @@ func (r *Reader) updateXInputState(userIndex uint32, state *xinputState) {
 	} else {
 		r.mu.Unlock()
 	}
+	r.putDrift(drift)
 }
 
-// convertXInputState converts a raw xinputState to a GamepadState.
-// dz is the deadzone threshold to apply to analog inputs.
-func convertXInputState(xs *xinputState, info *joystickInfo, dz float64) GamepadState {
-	gp := xs.Gamepad
-	mapping := info.mapping
-
-	state := GamepadState{
-		Connected:      true,
-		ControllerType: mapping.Name,
-		Name:           info.name,
-	}
-
-	// Triggers: uint8 (0-255) → float64 (0.0-1.0)
-	ltRaw := float64(gp.LeftTrigger) / triggerMax
-	rtRaw := float64(gp.RightTrigger) / triggerMax
-	state.Triggers.LT.Value = applyDeadzone(ltRaw, dz)
-	state.Triggers.RT.Value = applyDeadzone(rtRaw, dz)
-
-	// Sticks: int16 → float64 (-1.0 to 1.0)
-	lx := applyDeadzone(normalizeAxis(gp.ThumbLX), dz)
-	ly := applyDeadzone(normalizeAxis(gp.ThumbLY), dz)
-	rx := applyDeadzone(normalizeAxis(gp.ThumbRX), dz)
-	ry := applyDeadzone(normalizeAxis(gp.ThumbRY), dz)
-
-	// XInput Y axes are positive-up. The frontend canvas rendering inverts Y
-	// (knobY = s.y - position.y * maxTravel), so we pass the raw value unchanged.
-	state.Sticks.Left.Position.X = lx
-	state.Sticks.Left.Position.Y = ly
-	state.Sticks.Right.Position.X = rx
-	state.Sticks.Right.Position.Y = ry
-
-	// Buttons
-	btn := gp.Buttons
-	state.Buttons.A = btn&xiA != 0
-	state.Buttons.B = btn&xiB != 0
-	state.Buttons.X = btn&xiX != 0
-	state.Buttons.Y = btn&xiY != 0
-	state.Buttons.LB = btn&xiLeftShoulder != 0
-	state.Buttons.RB = btn&xiRightShoulder != 0
-	state.Buttons.Back = btn&xiBack != 0
-	state.Buttons.Start = btn&xiStart != 0
-	state.Buttons.Guide = btn&xiGuide != 0
-	state.Sticks.Left.Pressed = btn&xiLeftThumb != 0
-	state.Sticks.Right.Pressed = btn&xiRightThumb != 0
-
-	// D-pad
-	state.Dpad.Up = btn&xiDpadUp != 0
-	state.Dpad.Down = btn&xiDpadDown != 0
-	state.Dpad.Left = btn&xiDpadLeft != 0
-	state.Dpad.Right = btn&xiDpadRight != 0
-
-	_ = mapping // Name is used above; axis remapping not needed for XInput
-	return state
+// pollXInputBattery refreshes info.battery if batteryPollInterval has passed
+// since the last query.
+func (r *Reader) pollXInputBattery(userIndex uint32, info *joystickInfo) {
+	r.mu.RLock()
+	due := time.Since(info.batteryPolled) >= batteryPollInterval
+	r.mu.RUnlock()
+	if !due {
+		return
+	}
+	battery, ok := xiGetBattery(userIndex)
+	r.mu.Lock()
+	info.batteryPolled = time.Now()
+	if ok {
+		info.battery = battery
+	}
+	r.mu.Unlock()
 }
 
 // ---------------------------------------------------------------------------
@@ -239,8 +276,11 @@ func (r *Reader) handleHIDInput(hDevice uintptr, rawData []byte, reportSize uint
 
 	// Ensure the device appears in the joystick list (handles cases where
 	// WM_INPUT_DEVICE_CHANGE was not received, e.g. device already connected at startup).
-	key := hidKey(hDevice)
-	if _, exists := r.joysticks[key]; !exists {
+	// A Joy-Con attached to its partner reports for the pair's key instead.
+	from := hidKey(hDevice)
+	key := from
+	_, attached := r.joyConAttached[from]
+	if _, exists := r.joysticks[key]; !exists && !attached {
 		info := &joystickInfo{
 			mapping:    dev.mapping,
 			name:       dev.name,
@@ -248,32 +288,72 @@ func (r *Reader) handleHIDInput(hDevice uintptr, rawData []byte, reportSize uint
 			sourceType: "hid",
 			hDevice:    hDevice,
 			devKey:     deviceKey{VendorID: dev.vendorID, ProductID: dev.productID},
+			caps:       dev.capabilities(),
+			serial:     dev.serial,
+			version:    dev.productVersion,
 		}
 		r.mu.Unlock()
 		r.registerJoystick(key, info)
 		r.mu.Lock()
 	}
+	if primary, ok := r.joyConAttached[from]; ok {
+		key = primary
+	}
 
 	isActive := r.hasActive && r.activeKey == key
+	info := r.joysticks[key]
+	dz := r.deadzonesLocked(deviceKey{VendorID: dev.vendorID, ProductID: dev.productID})
 	r.mu.Unlock()
 
-	if !isActive {
+	r.capture.Load().hidInput(hDevice, dev.vendorID, dev.productID, dev.name, dev.preparsedData, rawData, reportSize)
+	if !isActive && !r.allPlayers {
 		return
 	}
 
-	// If multiple HID reports are batched in a single WM_INPUT message
-	// (dwCount > 1), use only the last report (most recent data).
-	if reportSize > 0 && uint32(len(rawData)) > reportSize {
-		rawData = rawData[uint32(len(rawData))-reportSize:]
-	}
-
-	newState, ok := parseHIDReport(dev, rawData, r.deadzone)
+	report := lastHIDReport(rawData, reportSize)
+	newState, ok := parseHIDReport(dev, report, dz.rawSticks())
 	if !ok {
 		return // incompatible report ID (non-input report); skip
 	}
-	newState.PlayerIndex = r.GetPlayerIndex()
+	side := joyConSideOf(deviceKey{VendorID: dev.vendorID, ProductID: dev.productID})
+	joyCon := side != joyConNone
+	if joyCon && !isJoyConReport(report) {
+		return
+	}
+	battery, hasBattery := hidBattery(dev.vendorID, dev.productID, report)
+	if r.motion {
+		newState.Motion, _ = hidMotion(dev.vendorID, dev.productID, report)
+	}
 
 	r.mu.Lock()
+	var drift *ControllerEvent
+	if info != nil {
+		drift = r.trackDriftLocked(key, info, newState.Sticks, side, dz.Stick)
+	}
+	dz.applyAllSticks(&newState.Sticks)
+	newState.PlayerIndex = r.getPlayerIndexLocked(key)
+	if info != nil {
+		if hasBattery && from == key { // a pair shows the battery of the Joy-Con it is registered as
+			info.battery = battery
+		}
+		if joyCon {
+			newState = r.joyConStateLocked(info, from, newState)
+			newState.PlayerIndex = r.getPlayerIndexLocked(key)
+		}
+		info.setDeviceFields(&newState)
+	}
+	if !isActive { // only with allPlayers
+		changed := info != nil && r.changedLocked(info, newState)
+		r.mu.Unlock()
+		if changed {
+			r.changes.put(newState)
+		}
+		r.putDrift(drift)
+		return
+	}
+	if r.allPlayers && info != nil {
+		info.last = newState
+	}
 	delta := ComputeDelta(r.prevState, newState)
 	if !delta.IsEmpty() {
 		r.state = newState
@@ -283,6 +363,7 @@ func (r *Reader) handleHIDInput(hDevice uintptr, rawData []byte, reportSize uint
 	} else {
 		r.mu.Unlock()
 	}
+	r.putDrift(drift)
 }
 
 // handleHIDDeviceChange is called from the rawinput message loop goroutine when
@@ -306,6 +387,9 @@ func (r *Reader) handleHIDDeviceChange(added bool, hDevice uintptr) {
 			sourceType: "hid",
 			hDevice:    hDevice,
 			devKey:     deviceKey{VendorID: dev.vendorID, ProductID: dev.productID},
+			caps:       dev.capabilities(),
+			serial:     dev.serial,
+			version:    dev.productVersion,
 		}
 		r.mu.Unlock()
 		r.registerJoystick(key, info)
@@ -317,7 +401,8 @@ func (r *Reader) handleHIDDeviceChange(added bool, hDevice uintptr) {
 		// this GIDC_REMOVAL notification do not trigger a spurious re-registration.
 		r.disconnectedHIDs[hDevice] = struct{}{}
 		r.mu.Unlock()
-		r.disconnectJoystick(key)
+		r.capture.Load().disconnect("hid", uint64(hDevice))
+		r.disconnectJoystick(key, DisconnectUnplugged)
 	}
 }
 
@@ -330,6 +415,9 @@ func (r *Reader) getOrInitHIDDevice(hDevice uintptr) *hidDeviceInfo {
 	// Init outside the lock to avoid holding it during potentially slow API calls.
 	r.mu.Unlock()
 	dev := initHIDDevice(hDevice)
+	if dev != nil && r.calibrate {
+		dev.calib = &r.calibration
+	}
 	r.mu.Lock()
 	if existing, ok := r.hidDevices[hDevice]; ok {
 		return existing
@@ -362,6 +450,18 @@ func (r *Reader) getOrInitHIDDevice(hDevice uintptr) *hidDeviceInfo {
 // if no controller is currently active. Thread-safe.
 func (r *Reader) registerJoystick(key joystickKey, info *joystickInfo) {
 	r.mu.Lock()
+	if _, attached := r.joyConAttached[key]; attached {
+		r.mu.Unlock()
+		return
+	}
+	if _, exists := r.joysticks[key]; !exists {
+		if primary, ok := r.pairJoyConLocked(key, info); ok {
+			playerIndex := r.getPlayerIndexLocked(primary)
+			r.mu.Unlock()
+			slog.Info("joy-con paired", "player", playerIndex, "name", info.name)
+			return
+		}
+	}
 	r.joysticks[key] = info
 
 	// Append to order list if not already present.
@@ -390,26 +490,69 @@ func (r *Reader) registerJoystick(key joystickKey, info *joystickInfo) {
 		r.state.Name = info.name
 		r.state.ControllerType = info.mapping.Name
 		r.state.PlayerIndex = playerIndex
+		info.setDeviceFields(&r.state)
 		becameActive = true
 	}
+	var connected *GamepadState // the new controller's first state, with SetAllPlayers
+	if r.allPlayers && !found {
+		s := GamepadState{Connected: true, Name: info.name, ControllerType: info.mapping.Name, PlayerIndex: playerIndex}
+		info.setDeviceFields(&s)
+		if becameActive {
+			s = r.state
+		}
+		info.last = s
+		connected = &s
+	}
+	ev := ControllerEvent{Type: ControllerConnected, Controller: r.controllerInfoLocked(info, playerIndex, becameActive)}
 	r.mu.Unlock()
 
+	r.signalWake()
+	if !found { // a HID arrival notification for a device already registered from its input is not news
+		r.events.put(ev)
+	}
+
 	if becameActive {
 		slog.Info("active controller set", "player", playerIndex, "name", info.name)
 		r.emitState()
+	} else if connected != nil {
+		r.changes.put(*connected)
 	}
 }
 
-// disconnectJoystick removes a joystick from the tracking lists and handles
-// active controller promotion if necessary. Thread-safe.
-func (r *Reader) disconnectJoystick(key joystickKey) {
+// disconnectJoystick removes a joystick from the tracking lists, emits a
+// ControllerDisconnected event with reason (a Disconnect* constant), and
+// handles active controller promotion if necessary. Thread-safe.
+func (r *Reader) disconnectJoystick(key joystickKey, reason string) {
 	r.mu.Lock()
+	if primary, attached := r.joyConAttached[key]; attached {
+		// One Joy-Con of a pair: the other goes on alone as the same player.
+		_, partner := r.unpairJoyConLocked(primary)
+		playerIndex := r.getPlayerIndexLocked(primary)
+		r.mu.Unlock()
+		slog.Info("joy-con unpaired", "player", playerIndex, "name", partner.name, "reason", reason)
+		return
+	}
+	if info := r.joysticks[key]; info != nil && info.partner != nil {
+		// The Joy-Con a pair is registered as: the other one takes its place
+		// as a controller of its own once it is gone.
+		partnerKey, partner := r.unpairJoyConLocked(key)
+		r.mu.Unlock()
+		r.disconnectJoystick(key, reason)
+		r.registerJoystick(partnerKey, partner)
+		return
+	}
 	info, ok := r.joysticks[key]
 	if !ok {
 		r.mu.Unlock()
 		return
 	}
 	playerIndex := r.getPlayerIndexLocked(key)
+	wasActive := r.hasActive && r.activeKey == key
+	ev := ControllerEvent{
+		Type:       ControllerDisconnected,
+		Reason:     reason,
+		Controller: r.controllerInfoLocked(info, playerIndex, wasActive),
+	}
 
 	// Decrement XInput VID/PID tracking count so that HID devices with the
 	// same VID/PID can be registered again after the XInput device is gone.
@@ -430,13 +573,28 @@ func (r *Reader) disconnectJoystick(key joystickKey) {
 	}
 	r.joystickOrder = newOrder
 
-	wasActive := r.hasActive && r.activeKey == key
 	if !wasActive {
 		if info.sourceType == "hid" && info.hDevice != 0 {
 			delete(r.hidDevices, info.hDevice)
 		}
+		// Controllers after the removed one move down a player index; if the
+		// active one did, republish its state so it is routed to its new
+		// index right away instead of on its next input.
+		renumbered := false
+		if r.hasActive {
+			if p := r.getPlayerIndexLocked(r.activeKey); p != r.state.PlayerIndex {
+				r.state.PlayerIndex = p
+				renumbered = true
+			}
+		}
+		others := r.renumberLocked(playerIndex)
 		r.mu.Unlock()
-		slog.Info("gamepad disconnected", "player", playerIndex, "name", info.name, "source", info.sourceType)
+		slog.Info("gamepad disconnected", "player", playerIndex, "name", info.name, "source", info.sourceType, "reason", reason)
+		if renumbered {
+			r.emitState()
+		}
+		r.putAll(others)
+		r.events.put(ev)
 		return
 	}
 
@@ -446,10 +604,12 @@ func (r *Reader) disconnectJoystick(key joystickKey) {
 		if info.sourceType == "hid" && info.hDevice != 0 {
 			delete(r.hidDevices, info.hDevice)
 		}
-		r.state = GamepadState{}
+		// Keep the player index so the disconnect reaches that player's viewers.
+		r.state = GamepadState{PlayerIndex: playerIndex}
 		r.mu.Unlock()
-		slog.Info("gamepad disconnected", "player", playerIndex, "name", info.name, "source", info.sourceType)
+		slog.Info("gamepad disconnected", "player", playerIndex, "name", info.name, "source", info.sourceType, "reason", reason)
 		r.emitState()
+		r.events.put(ev)
 		return
 	}
 
@@ -458,28 +618,31 @@ func (r *Reader) disconnectJoystick(key joystickKey) {
 	nextPlayer := r.getPlayerIndexLocked(nextKey)
 	r.activeKey = nextKey
 	r.hasActive = true
+	if r.allPlayers {
+		r.state = nextInfo.last
+		r.prevState = nextInfo.last
+	}
 	r.state.Connected = true
 	r.state.Name = nextInfo.name
 	r.state.ControllerType = nextInfo.mapping.Name
 	r.state.PlayerIndex = nextPlayer
+	nextInfo.setDeviceFields(&r.state)
+	switched := ControllerEvent{
+		Type:       ControllerSwitched,
+		Reason:     SwitchPromoted,
+		Controller: r.controllerInfoLocked(nextInfo, nextPlayer, true),
+		Previous:   &ev.Controller,
+	}
 	if info.sourceType == "hid" && info.hDevice != 0 {
 		delete(r.hidDevices, info.hDevice)
 	}
+	others := r.renumberLocked(playerIndex)
 	r.mu.Unlock()
 
-	slog.Info("gamepad disconnected", "player", playerIndex, "name", info.name, "source", info.sourceType)
+	slog.Info("gamepad disconnected", "player", playerIndex, "name", info.name, "source", info.sourceType, "reason", reason)
 	slog.Info("active controller promoted", "player", nextPlayer, "name", nextInfo.name)
 	r.emitState()
-}
-
-// ---------------------------------------------------------------------------
-// Helpers
-// ---------------------------------------------------------------------------
-
-// buildControllerName constructs a human-readable controller name.
-func buildControllerName(mappingName, vidPID string) string {
-	if vidPID != "" {
-		return fmt.Sprintf("%s (%s)", mappingName, vidPID)
-	}
-	return mappingName
+	r.putAll(others)
+	r.events.put(ev)
+	r.events.put(switched)
 }
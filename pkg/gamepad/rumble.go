@@ -0,0 +1,92 @@
+package gamepad
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxRumbleDuration caps the duration of a Rumble, so a lost stop command
+// cannot leave a controller vibrating.
+const MaxRumbleDuration = 5 * time.Second
+
+// Errors returned by Reader.Rumble.
+var (
+	ErrNoController      = errors.New("no controller for this player")
+	ErrRumbleUnsupported = errors.New("controller cannot rumble (only XInput controllers can)")
+)
+
+// setXInputVibration sets the motor speeds of an XInput slot and reports
+// whether it succeeded. A variable so tests can record the calls.
+var setXInputVibration = xiSetVibration
+
+// Rumble vibrates the controller of player playerIndex (0 = the active
+// controller) for d, at most MaxRumbleDuration, with the low-frequency (left)
+// and high-frequency (right) motors at the strengths low and high (0-1,
+// clamped). A new rumble replaces the one still running on the controller;
+// low = high = 0 stops it. Only XInput controllers can rumble: no HID rumble
+// reports are written.
+func (r *Reader) Rumble(playerIndex int, low, high float64, d time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info := r.joystickLocked(playerIndex)
+	if info == nil {
+		return ErrNoController
+	}
+	if info.sourceType != "xinput" {
+		return ErrRumbleUnsupported
+	}
+
+	slot := info.xinputSlot
+	if t := r.rumbles[slot]; t != nil {
+		t.Stop()
+		delete(r.rumbles, slot)
+	}
+	if !setXInputVibration(slot, motorSpeed(low), motorSpeed(high)) {
+		return fmt.Errorf("XInputSetState failed for slot %d", slot)
+	}
+	if r.rumbles == nil {
+		r.rumbles = make(map[uint32]*time.Timer)
+	}
+	var t *time.Timer
+	t = time.AfterFunc(min(max(d, 0), MaxRumbleDuration), func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.rumbles[slot] == t { // not replaced or stopped since
+			delete(r.rumbles, slot)
+			setXInputVibration(slot, 0, 0)
+		}
+	})
+	r.rumbles[slot] = t
+	return nil
+}
+
+// joystickLocked returns the controller of player playerIndex (0 = the
+// active controller), or nil if there is none. Caller must hold r.mu.
+func (r *Reader) joystickLocked(playerIndex int) *joystickInfo {
+	switch {
+	case playerIndex == 0 && r.hasActive:
+		return r.joysticks[r.activeKey]
+	case playerIndex > 0 && playerIndex <= len(r.joystickOrder):
+		return r.joysticks[r.joystickOrder[playerIndex-1]]
+	}
+	return nil
+}
+
+// stopRumblesLocked stops the motors of every controller that is still
+// rumbling. Caller must hold r.mu.
+func (r *Reader) stopRumblesLocked() {
+	for slot, t := range r.rumbles {
+		t.Stop()
+		setXInputVibration(slot, 0, 0)
+	}
+	r.rumbles = nil
+}
+
+// motorSpeed converts a strength in [0, 1] (clamped) to an XInput motor speed.
+func motorSpeed(v float64) uint16 {
+	if !(v > 0) { // also NaN
+		return 0
+	}
+	return uint16(min(v, 1)*65535 + 0.5)
+}
@@ -0,0 +1,61 @@
+package gamepad
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestReaderRumble verifies that Rumble drives the motors of the chosen XInput
+// slot, stops them after the duration, and rejects HID and missing players.
+func TestReaderRumble(t *testing.T) {
+	type call struct {
+		slot      uint32
+		low, high uint16
+	}
+	var mu sync.Mutex
+	var calls []call
+	stopped := make(chan struct{}, 1)
+	prev := setXInputVibration
+	setXInputVibration = func(slot uint32, low, high uint16) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call{slot, low, high})
+		if low == 0 && high == 0 {
+			stopped <- struct{}{}
+		}
+		return true
+	}
+	t.Cleanup(func() { setXInputVibration = prev })
+
+	r := NewReader()
+	if err := r.Rumble(0, 1, 1, time.Second); !errors.Is(err, ErrNoController) {
+		t.Errorf("Rumble() with nothing connected = %v, want ErrNoController", err)
+	}
+	r.joysticks[xinputKey(2)] = &joystickInfo{mapping: xboxMapping, sourceType: "xinput", xinputSlot: 2}
+	r.joysticks[hidKey(0x1000)] = &joystickInfo{mapping: xboxMapping, sourceType: "hid", hDevice: 0x1000}
+	r.joystickOrder = []joystickKey{hidKey(0x1000), xinputKey(2)}
+	r.activeKey, r.hasActive = hidKey(0x1000), true
+
+	if err := r.Rumble(0, 1, 1, time.Second); !errors.Is(err, ErrRumbleUnsupported) {
+		t.Errorf("Rumble() on the active HID controller = %v, want ErrRumbleUnsupported", err)
+	}
+	if err := r.Rumble(3, 1, 1, time.Second); !errors.Is(err, ErrNoController) {
+		t.Errorf("Rumble(3) with two players = %v, want ErrNoController", err)
+	}
+	if err := r.Rumble(2, 0.5, 2, 10*time.Millisecond); err != nil {
+		t.Fatalf("Rumble(2) = %v", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("rumble was not stopped after its duration")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []call{{2, 32768, 65535}, {2, 0, 0}}
+	if len(calls) != len(want) || calls[0] != want[0] || calls[1] != want[1] {
+		t.Errorf("vibration calls = %+v, want %+v", calls, want)
+	}
+}
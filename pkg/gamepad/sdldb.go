@@ -34,11 +34,12 @@ package gamepad
 //   leftstick/ls, rightstick/rs,
 //   leftx, lefty, rightx, righty,
 //   dpup, dpdown, dpleft, dpright,
-//   touchpad, misc1, paddle1..4 (ignored beyond touchpad for now)
+//   touchpad, misc1 (→ capture), paddle1..4
 
 import (
 	"bufio"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -146,9 +147,8 @@ func sdlTargetToSemantic(sdlTarget string) (string, bool) {
 		return "touchpad", true
 	case "misc1":
 		return "capture", true
-	// paddle1-4: map to guide/touchpad/capture for now (rarely used)
 	case "paddle1", "paddle2", "paddle3", "paddle4":
-		return "", false // unsupported
+		return sdlTarget, true
 	default:
 		return "", false
 	}
@@ -180,22 +180,63 @@ func parseSDLGUID(guidStr string) (vendorID, productID uint16, ok bool) {
 	return vid, pid, true
 }
 
-// parseMappingFields parses the comma-separated field:value pairs from an SDL mapping string.
-// mappingStr is the raw line from the file (including GUID and name at the front).
-// Returns the parsed SDLMapping or nil on error.
-func parseMappingFields(line string) *SDLMapping {
-	// Format: <guid>,<name>,<field:value>,...,platform:<platform>,
+// sdlGUID builds the GUID SDL uses for a USB device with the given VID/PID
+// (bus 0x0003, no CRC or version) — the inverse of parseSDLGUID. Returns ""
+// when both IDs are zero.
+func sdlGUID(vendorID, productID uint16) string {
+	if vendorID == 0 && productID == 0 {
+		return ""
+	}
+	var raw [16]byte
+	raw[0] = 0x03 // SDL_HARDWARE_BUS_USB
+	raw[4], raw[5] = byte(vendorID), byte(vendorID>>8)
+	raw[8], raw[9] = byte(productID), byte(productID>>8)
+	return hex.EncodeToString(raw[:])
+}
+
+// errNoVIDPID is returned by parseMappingFields for well-formed entries whose
+// GUID carries no VID/PID (e.g. Bluetooth name-hash GUIDs or the special
+// "xinput" entry). Such entries cannot be matched to a device and are skipped
+// without a warning.
+var errNoVIDPID = errors.New("GUID has no VID/PID layout")
+
+// maxSDLIndex bounds button, axis, and hat indices in a binding. Real devices
+// stay far below this; a larger index means a corrupt line, not a big device.
+const maxSDLIndex = 255
+
+// sdlMetaPrefixes are non-binding fields of a mapping line.
+var sdlMetaPrefixes = []string{"platform:", "crc:", "type:", "face:", "hint:", "sdk>=:", "sdk<=:"}
+
+// parseMappingFields parses one gamecontrollerdb.txt line
+// (<guid>,<name>,<field:value>,...,platform:<platform>,) into an SDLMapping.
+// Malformed lines are rejected with a descriptive error naming the offending
+// field, so a typo fails loudly instead of producing a mapping with a missing
+// or misrouted control. Bindings for targets we do not model (misc2-misc6,
+// ...) are syntax-checked and then ignored.
+func parseMappingFields(line string) (*SDLMapping, error) {
 	parts := strings.Split(line, ",")
 	if len(parts) < 3 {
-		return nil
+		return nil, errors.New("want <guid>,<name>,<bindings>,...")
 	}
 
 	guid := strings.TrimSpace(parts[0])
 	name := strings.TrimSpace(parts[1])
+	if guid == "xinput" {
+		return nil, errNoVIDPID
+	}
+	if len(guid) != 32 {
+		return nil, fmt.Errorf("GUID %q: want 32 hex characters, got %d", guid, len(guid))
+	}
+	if _, err := hex.DecodeString(guid); err != nil {
+		return nil, fmt.Errorf("GUID %q: not hexadecimal", guid)
+	}
+	if name == "" {
+		return nil, errors.New("empty controller name")
+	}
 
 	vid, pid, ok := parseSDLGUID(guid)
 	if !ok {
-		return nil
+		return nil, errNoVIDPID
 	}
 
 	m := &SDLMapping{
@@ -208,136 +249,162 @@ func parseMappingFields(line string) *SDLMapping {
 	// Parse field:value pairs starting at parts[2].
 	for i := 2; i < len(parts); i++ {
 		field := strings.TrimSpace(parts[i])
-		if field == "" {
+		if field == "" || isSDLMetaField(field) {
 			continue
 		}
-
-		// Skip meta fields.
-		if strings.HasPrefix(field, "platform:") ||
-			strings.HasPrefix(field, "crc:") ||
-			strings.HasPrefix(field, "type:") ||
-			strings.HasPrefix(field, "face:") ||
-			strings.HasPrefix(field, "hint:") ||
-			strings.HasPrefix(field, "sdk>=:") ||
-			strings.HasPrefix(field, "sdk<=:") {
-			continue
+		if err := m.addBinding(field); err != nil {
+			return nil, fmt.Errorf("field %d %q: %w", i+1, field, err)
 		}
+	}
 
-		// Check for half-axis-from-button prefix: "+rightx:b9" or "-righty:b8"
-		axisSign := 0
-		remaining := field
-		if strings.HasPrefix(field, "+") {
-			axisSign = +1
-			remaining = field[1:]
-		} else if strings.HasPrefix(field, "-") {
-			axisSign = -1
-			remaining = field[1:]
+	return m, nil
+}
+
+// isSDLMetaField reports whether field is a non-binding field (platform, crc, ...).
+func isSDLMetaField(field string) bool {
+	for _, p := range sdlMetaPrefixes {
+		if strings.HasPrefix(field, p) {
+			return true
 		}
+	}
+	return false
+}
 
-		colonIdx := strings.IndexByte(remaining, ':')
-		if colonIdx < 0 {
-			continue
+// addBinding parses one <target>:<source> field and appends it to m.
+func (m *SDLMapping) addBinding(field string) error {
+	// Check for half-axis-from-button prefix: "+rightx:b9" or "-righty:b8"
+	axisSign := 0
+	remaining := field
+	if strings.HasPrefix(field, "+") {
+		axisSign = +1
+		remaining = field[1:]
+	} else if strings.HasPrefix(field, "-") {
+		axisSign = -1
+		remaining = field[1:]
+	}
+
+	colonIdx := strings.IndexByte(remaining, ':')
+	if colonIdx < 0 {
+		return errors.New("missing ':' between target and source")
+	}
+	sdlTarget := remaining[:colonIdx]
+	src := remaining[colonIdx+1:]
+	if sdlTarget == "" {
+		return errors.New("empty target")
+	}
+	if src == "" {
+		return errors.New("empty source")
+	}
+
+	semantic, supported := sdlTargetToSemantic(sdlTarget)
+
+	// Half-axis-from-button: the target name is an axis target, but the source is a button.
+	// Example: "+rightx:b9" — pressing button 9 pushes right_x to +1.
+	if axisSign != 0 && src[0] == 'b' {
+		btnIdx, err := parseSDLIndex(src[1:])
+		if err != nil {
+			return fmt.Errorf("button %w", err)
 		}
-		sdlTarget := remaining[:colonIdx]
-		src := remaining[colonIdx+1:]
-
-		semantic, supported := sdlTargetToSemantic(sdlTarget)
-
-		// Half-axis-from-button: the target name is an axis target, but the source is a button.
-		// Example: "+rightx:b9" — pressing button 9 pushes right_x to +1.
-		if axisSign != 0 && strings.HasPrefix(src, "b") {
-			btnIdx, err := strconv.Atoi(src[1:])
-			if err == nil && supported {
-				m.AxisHalfButtons = append(m.AxisHalfButtons, SDLAxisHalfButton{
-					Target:    semantic,
-					Sign:      axisSign,
-					ButtonIdx: btnIdx,
-				})
-			}
-			continue
+		if supported {
+			m.AxisHalfButtons = append(m.AxisHalfButtons, SDLAxisHalfButton{
+				Target:    semantic,
+				Sign:      axisSign,
+				ButtonIdx: btnIdx,
+			})
 		}
+		return nil
+	}
 
-		if !supported {
-			continue
+	switch {
+	case src[0] == 'b':
+		// Button source
+		idx, err := parseSDLIndex(src[1:])
+		if err != nil {
+			return fmt.Errorf("button %w", err)
 		}
-
-		switch {
-		case strings.HasPrefix(src, "b"):
-			// Button source
-			idx, err := strconv.Atoi(src[1:])
-			if err != nil {
-				continue
-			}
-			// Dpad directions mapped from buttons are stored as buttons with the dpad target name.
-			// The caller handles converting dpup/dpdown/dpleft/dpright button presses to DpadState.
+		// Dpad directions mapped from buttons are stored as buttons with the dpad target name.
+		// The caller handles converting dpup/dpdown/dpleft/dpright button presses to DpadState.
+		if supported {
 			m.Buttons = append(m.Buttons, SDLButtonBinding{
 				ButtonIndex: idx,
 				Target:      semantic,
 			})
+		}
 
-		case strings.HasPrefix(src, "h"):
-			// Hat source: h<n>.<mask>
-			dotIdx := strings.IndexByte(src, '.')
-			if dotIdx < 0 {
-				continue
-			}
-			hatIdx, err1 := strconv.Atoi(src[1:dotIdx])
-			dirMask, err2 := strconv.Atoi(src[dotIdx+1:])
-			if err1 != nil || err2 != nil {
-				continue
-			}
+	case src[0] == 'h':
+		// Hat source: h<n>.<mask>
+		dotIdx := strings.IndexByte(src, '.')
+		if dotIdx < 0 {
+			return errors.New("hat source must be h<index>.<mask>")
+		}
+		hatIdx, err := parseSDLIndex(src[1:dotIdx])
+		if err != nil {
+			return fmt.Errorf("hat %w", err)
+		}
+		dirMask, err := parseSDLIndex(src[dotIdx+1:])
+		if err != nil || (dirMask != 1 && dirMask != 2 && dirMask != 4 && dirMask != 8) {
+			return fmt.Errorf("hat mask %q: want 1, 2, 4, or 8", src[dotIdx+1:])
+		}
+		if supported {
 			m.Hats = append(m.Hats, SDLHatBinding{
 				HatIndex: hatIdx,
 				DirMask:  dirMask,
 				Target:   semantic,
 			})
+		}
 
-		case strings.HasPrefix(src, "+a") || strings.HasPrefix(src, "-a"):
-			// Half-axis source: +a<n> or -a<n>, optional ~ suffix.
-			isPos := src[0] == '+'
-			rest := src[2:] // strip "+a" or "-a"
-			invert := strings.HasSuffix(rest, "~")
-			if invert {
-				rest = rest[:len(rest)-1]
-			}
-			idx, err := strconv.Atoi(rest)
-			if err != nil {
-				continue
-			}
-			binding := SDLAxisBinding{
+	case strings.HasPrefix(src, "+a") || strings.HasPrefix(src, "-a"):
+		// Half-axis source: +a<n> or -a<n>, optional ~ suffix.
+		isPos := src[0] == '+'
+		rest, invert := strings.CutSuffix(src[2:], "~") // strip "+a" or "-a"
+		idx, err := parseSDLIndex(rest)
+		if err != nil {
+			return fmt.Errorf("axis %w", err)
+		}
+		if supported {
+			m.Axes = append(m.Axes, SDLAxisBinding{
 				AxisIndex: idx,
 				Target:    semantic,
 				Invert:    invert,
 				HalfPos:   isPos,
 				HalfNeg:   !isPos,
-			}
-			m.Axes = append(m.Axes, binding)
-
-		case strings.HasPrefix(src, "a"):
-			// Full axis source: a<n> with optional ~ suffix.
-			rest := src[1:]
-			invert := strings.HasSuffix(rest, "~")
-			if invert {
-				rest = rest[:len(rest)-1]
-			}
-			idx, err := strconv.Atoi(rest)
-			if err != nil {
-				continue
-			}
-			// Also handle axisSign from the outer prefix (e.g. "+leftstick:-a2" is unusual but possible)
-			halfPos := axisSign > 0
-			halfNeg := axisSign < 0
+			})
+		}
+
+	case src[0] == 'a':
+		// Full axis source: a<n> with optional ~ suffix.
+		rest, invert := strings.CutSuffix(src[1:], "~")
+		idx, err := parseSDLIndex(rest)
+		if err != nil {
+			return fmt.Errorf("axis %w", err)
+		}
+		// Also handle axisSign from the outer prefix (e.g. "+leftstick:-a2" is unusual but possible)
+		if supported {
 			m.Axes = append(m.Axes, SDLAxisBinding{
 				AxisIndex: idx,
 				Target:    semantic,
 				Invert:    invert,
-				HalfPos:   halfPos,
-				HalfNeg:   halfNeg,
+				HalfPos:   axisSign > 0,
+				HalfNeg:   axisSign < 0,
 			})
 		}
+
+	default:
+		return fmt.Errorf("unknown source %q (want b<n>, a<n>, +a<n>, -a<n>, or h<n>.<mask>)", src)
 	}
+	return nil
+}
 
-	return m
+// parseSDLIndex parses a binding index: plain decimal digits in [0, maxSDLIndex].
+func parseSDLIndex(s string) (int, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" || len(s) > 3 {
+		return 0, fmt.Errorf("index %q: want an integer in 0..%d", s, maxSDLIndex)
+	}
+	n, _ := strconv.Atoi(s)
+	if n > maxSDLIndex {
+		return 0, fmt.Errorf("index %q: want an integer in 0..%d", s, maxSDLIndex)
+	}
+	return n, nil
 }
 
 // LoadSDLMappingsFromFile reads a gamecontrollerdb.txt file and returns all
@@ -375,9 +442,12 @@ func LoadSDLMappingsFromReader(r io.Reader, platform string) (map[deviceKey]*SDL
 			continue
 		}
 
-		m := parseMappingFields(line)
-		if m == nil {
-			slog.Warn("sdldb: failed to parse line", "line", lineNum, "content", fmt.Sprintf("%.80s...", line))
+		m, err := parseMappingFields(line)
+		if errors.Is(err, errNoVIDPID) {
+			continue // not matchable by VID/PID (Bluetooth name GUID, "xinput" entry)
+		}
+		if err != nil {
+			slog.Warn("sdldb: skipping invalid mapping line", "line", lineNum, "error", err)
 			continue
 		}
 		if m.VendorID == 0 && m.ProductID == 0 {
@@ -390,7 +460,7 @@ func LoadSDLMappingsFromReader(r io.Reader, platform string) (map[deviceKey]*SDL
 		loaded++
 	}
 	if err := scanner.Err(); err != nil {
-		return result, err
+		return result, fmt.Errorf("line %d: %w", lineNum+1, err)
 	}
 	slog.Info("sdldb: loaded mappings", "count", loaded, "platform", platform)
 	return result, nil
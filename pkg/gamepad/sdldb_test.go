@@ -42,14 +42,29 @@ func TestParseSDLGUID(t *testing.T) {
 	}
 }
 
+// TestSDLGUIDRoundTrip verifies that sdlGUID produces GUIDs parseSDLGUID
+// accepts, matching the USB entries in gamecontrollerdb.txt.
+func TestSDLGUIDRoundTrip(t *testing.T) {
+	if got := sdlGUID(0x054c, 0x0ce6); got != "030000004c050000e60c000000000000" {
+		t.Errorf("sdlGUID(054c, 0ce6) = %q, want the DualSense USB GUID", got)
+	}
+	if got := sdlGUID(0, 0); got != "" {
+		t.Errorf("sdlGUID(0, 0) = %q, want empty", got)
+	}
+	vid, pid, ok := parseSDLGUID(sdlGUID(0x0f30, 0x010a))
+	if !ok || vid != 0x0f30 || pid != 0x010a {
+		t.Errorf("parseSDLGUID(sdlGUID(0f30, 010a)) = %04x, %04x, %v", vid, pid, ok)
+	}
+}
+
 func TestParseMappingFields_Basic(t *testing.T) {
 	// PS5 DualSense: 030000004c050000e60c000000000000
 	// vid LE16: bytes[4..5]=0x4c,0x05 → 0x054c; pid LE16: bytes[8..9]=0xe6,0x0c → 0x0ce6
 	line := "030000004c050000e60c000000000000,PS5 Controller,a:b1,b:b2,x:b0,y:b3,back:b8,guide:b12,start:b9,leftstick:b10,rightstick:b11,leftshoulder:b4,rightshoulder:b5,dpup:h0.1,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,leftx:a0,lefty:a1,rightx:a2,righty:a5,lefttrigger:a3,righttrigger:a4,platform:Windows,"
 
-	m := parseMappingFields(line)
-	if m == nil {
-		t.Fatal("parseMappingFields returned nil")
+	m, err := parseMappingFields(line)
+	if err != nil {
+		t.Fatalf("parseMappingFields: %v", err)
 	}
 
 	if m.VendorID != 0x054c {
@@ -110,9 +125,9 @@ func TestParseMappingFields_HalfAxis(t *testing.T) {
 	// dpleft:-a0 → dpad left from negative half of axis 0
 	line := "03000000102800000900000000000000,8BitDo SFC30,a:b1,b:b0,back:b10,dpdown:+a1,dpleft:-a0,dpright:+a0,dpup:-a1,leftshoulder:b6,rightshoulder:b7,start:b11,x:b4,y:b3,platform:Windows,"
 
-	m := parseMappingFields(line)
-	if m == nil {
-		t.Fatal("parseMappingFields returned nil")
+	m, err := parseMappingFields(line)
+	if err != nil {
+		t.Fatalf("parseMappingFields: %v", err)
 	}
 
 	// Find dpdown axis binding
@@ -155,9 +170,9 @@ func TestParseMappingFields_N64HalfAxisButton(t *testing.T) {
 	// +rightx:b9 — pressing button 9 sets right_x to +1
 	line := "03000000c82d00000290000000000000,8BitDo N64,+rightx:b9,+righty:b3,-rightx:b4,-righty:b8,a:b0,b:b1,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,leftshoulder:b6,lefttrigger:b10,leftx:a0,lefty:a1,rightshoulder:b7,start:b11,platform:Windows,"
 
-	m := parseMappingFields(line)
-	if m == nil {
-		t.Fatal("parseMappingFields returned nil")
+	m, err := parseMappingFields(line)
+	if err != nil {
+		t.Fatalf("parseMappingFields: %v", err)
 	}
 
 	if len(m.AxisHalfButtons) == 0 {
@@ -197,9 +212,9 @@ func TestParseMappingFields_InvertedAxis(t *testing.T) {
 	// righty:a3~ — axis 3, inverted
 	line := "03000000260900008888000000000000,Cyber Gadget,leftx:a0,lefty:a1,rightx:a2,righty:a3~,start:b7,platform:Windows,"
 
-	m := parseMappingFields(line)
-	if m == nil {
-		t.Fatal("parseMappingFields returned nil")
+	m, err := parseMappingFields(line)
+	if err != nil {
+		t.Fatalf("parseMappingFields: %v", err)
 	}
 
 	var rightyBinding *SDLAxisBinding
@@ -267,3 +282,88 @@ func TestLoadSDLMappingsFromFile_Real(t *testing.T) {
 		t.Logf("8BitDo Pro 2: axes=%d buttons=%d hats=%d", len(entry.Axes), len(entry.Buttons), len(entry.Hats))
 	}
 }
+
+// TestParseMappingFieldsErrors verifies that malformed lines are rejected with
+// an error naming the problem, and that VID/PID-less entries are reported as
+// errNoVIDPID (skipped quietly by the loader).
+func TestParseMappingFieldsErrors(t *testing.T) {
+	const guid = "030000004c050000e60c000000000000"
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string
+	}{
+		{"too few fields", guid + ",Pad", "want <guid>,<name>"},
+		{"short GUID", "030000004c05,Pad,a:b0,", "want 32 hex characters"},
+		{"non-hex GUID", "0300zz004c050000e60c000000000000,Pad,a:b0,", "not hexadecimal"},
+		{"empty name", guid + ", ,a:b0,", "empty controller name"},
+		{"missing colon", guid + ",Pad,ab0,", `field 3 "ab0": missing ':'`},
+		{"empty source", guid + ",Pad,a:,", "empty source"},
+		{"empty target", guid + ",Pad,:b0,", "empty target"},
+		{"bad button index", guid + ",Pad,a:bx,", "button index"},
+		{"negative button index", guid + ",Pad,a:b-1,", "button index"},
+		{"huge axis index", guid + ",Pad,leftx:a9999,", "axis index"},
+		{"hat without mask", guid + ",Pad,dpup:h0,", "h<index>.<mask>"},
+		{"bad hat mask", guid + ",Pad,dpup:h0.3,", "hat mask"},
+		{"unknown source", guid + ",Pad,a:z0,", "unknown source"},
+		{"bad source on unsupported target", guid + ",Pad,paddle1:bq,", "button index"},
+		{"xinput entry", "xinput,XInput Controller,a:b0,", errNoVIDPID.Error()},
+		{"bluetooth name GUID", "05000000504c415953544154494f4e00,PS3,a:b14,", errNoVIDPID.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseMappingFields(tt.line)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseMappingFields() = %+v, %v; want error containing %q", m, err, tt.wantErr)
+			}
+		})
+	}
+
+	// Unsupported targets with valid sources are ignored, not errors.
+	m, err := parseMappingFields(guid + ",Pad,a:b0,misc2:b17,platform:Windows,")
+	if err != nil || len(m.Buttons) != 1 {
+		t.Errorf("parseMappingFields() with unsupported targets = %+v, %v; want 1 button, no error", m, err)
+	}
+
+	// Paddles are kept under their SDL names.
+	m, err = parseMappingFields(guid + ",Pad,a:b0,paddle1:b16,paddle4:b19,platform:Windows,")
+	if err != nil || len(m.Buttons) != 3 || m.Buttons[1].Target != "paddle1" || m.Buttons[2].Target != "paddle4" {
+		t.Errorf("parseMappingFields() with paddles = %+v, %v; want a, paddle1, paddle4", m, err)
+	}
+}
+
+// FuzzParseMappingFields checks that arbitrary lines never panic and that any
+// accepted mapping only contains in-range indices and valid hat masks.
+func FuzzParseMappingFields(f *testing.F) {
+	f.Add("030000004c050000e60c000000000000,PS5 Controller,a:b1,b:b2,leftx:a0,lefty:a1,lefttrigger:a3,dpup:h0.1,platform:Windows,")
+	f.Add("03000000790000000600000000000000,N64,+rightx:b9,-righty:b8,lefty:a1~,righttrigger:-a4,platform:Windows,")
+	f.Add("xinput,XInput Controller,a:b0,platform:Windows,")
+	f.Add(",,,")
+	f.Fuzz(func(t *testing.T, line string) {
+		m, err := parseMappingFields(line)
+		if err != nil {
+			if m != nil {
+				t.Fatalf("parseMappingFields(%q) returned both a mapping and error %v", line, err)
+			}
+			return
+		}
+		for _, b := range m.Buttons {
+			if b.ButtonIndex < 0 || b.ButtonIndex > maxSDLIndex {
+				t.Fatalf("button index %d out of range in %q", b.ButtonIndex, line)
+			}
+		}
+		for _, a := range m.Axes {
+			if a.AxisIndex < 0 || a.AxisIndex > maxSDLIndex {
+				t.Fatalf("axis index %d out of range in %q", a.AxisIndex, line)
+			}
+		}
+		for _, h := range m.Hats {
+			if h.DirMask != 1 && h.DirMask != 2 && h.DirMask != 4 && h.DirMask != 8 {
+				t.Fatalf("hat mask %d invalid in %q", h.DirMask, line)
+			}
+		}
+		if vid, pid, ok := parseSDLGUID(m.GUID); !ok || vid != m.VendorID || pid != m.ProductID {
+			t.Fatalf("mapping VID/PID %04x/%04x does not match GUID %q", m.VendorID, m.ProductID, m.GUID)
+		}
+	})
+}
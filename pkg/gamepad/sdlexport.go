@@ -0,0 +1,182 @@
+package gamepad
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNoSDLMapping is returned by Reader.ExportSDLMapping for controllers
+// whose layout has no gamecontrollerdb form: wheels, flight sticks, raw mode,
+// and the Nintendo report parser.
+var ErrNoSDLMapping = errors.New("controller layout has no SDL mapping (wheels, flight sticks, raw mode, and Nintendo controllers have none)")
+
+// xinputSDLBindings is the fixed XInput layout in SDL's terms, as in SDL's
+// own "xinput" entry.
+var xinputSDLBindings = []string{
+	"a:b0", "b:b1", "x:b2", "y:b3", "leftshoulder:b4", "rightshoulder:b5",
+	"back:b6", "start:b7", "leftstick:b8", "rightstick:b9", "guide:b10",
+	"leftx:a0", "lefty:a1", "lefttrigger:a2", "rightx:a3", "righty:a4", "righttrigger:a5",
+	"dpup:h0.1", "dpright:h0.2", "dpdown:h0.4", "dpleft:h0.8",
+}
+
+// sdlTargetName returns the SDL name of a semantic target, the inverse of
+// sdlTargetToSemantic, or "" for targets SDL has no name for (fn1, the wheel
+// and flight stick targets, ...).
+func sdlTargetName(target string) string {
+	switch target {
+	case "a", "b", "x", "y", "back", "start", "guide", "touchpad",
+		"dpup", "dpdown", "dpleft", "dpright",
+		"paddle1", "paddle2", "paddle3", "paddle4":
+		return target
+	case "lb":
+		return "leftshoulder"
+	case "rb":
+		return "rightshoulder"
+	case "lt":
+		return "lefttrigger"
+	case "rt":
+		return "righttrigger"
+	case "ls":
+		return "leftstick"
+	case "rs":
+		return "rightstick"
+	case "left_x":
+		return "leftx"
+	case "left_y":
+		return "lefty"
+	case "right_x":
+		return "rightx"
+	case "right_y":
+		return "righty"
+	case "capture":
+		return "misc1"
+	}
+	return ""
+}
+
+// sdlMappingBindings re-encodes the bindings of an SDL DB entry, the inverse
+// of addBinding. Bindings for targets we do not model were dropped when the
+// entry was parsed and are not part of the result.
+func sdlMappingBindings(m *SDLMapping) []string {
+	var out []string
+	for _, ab := range m.Axes {
+		src := fmt.Sprintf("a%d", ab.AxisIndex)
+		switch {
+		case ab.HalfPos:
+			src = "+" + src
+		case ab.HalfNeg:
+			src = "-" + src
+		}
+		if ab.Invert {
+			src += "~"
+		}
+		out = append(out, sdlTargetName(ab.Target)+":"+src)
+	}
+	for _, bb := range m.Buttons {
+		out = append(out, fmt.Sprintf("%s:b%d", sdlTargetName(bb.Target), bb.ButtonIndex))
+	}
+	for _, hb := range m.Hats {
+		out = append(out, fmt.Sprintf("%s:h%d.%d", sdlTargetName(hb.Target), hb.HatIndex, hb.DirMask))
+	}
+	for _, hb := range m.AxisHalfButtons {
+		sign := "+"
+		if hb.Sign < 0 {
+			sign = "-"
+		}
+		out = append(out, fmt.Sprintf("%s%s:b%d", sign, sdlTargetName(hb.Target), hb.ButtonIdx))
+	}
+	return out
+}
+
+// hidSDLBindings converts the legacy HID path's mapping of a device into SDL
+// bindings: axisMap (see buildAxisMap) over the usages of its axes in
+// buildAxisOrder order, which is SDL's axis numbering; its buttons 1 to
+// buttons through resolveButtonTarget, SDL button n-1; and the dpad on hat 0
+// if it has one. Axes come first, so a trigger read from an axis keeps it
+// over its digital button; targets SDL has no name for are left out.
+func hidSDLBindings(mapping *DeviceMapping, axisMap map[uint16]string, axisUsages []uint16, buttons int, hasHat bool) []string {
+	var out []string
+	bound := make(map[string]bool)
+	add := func(name, src string) {
+		if name != "" && !bound[name] {
+			bound[name] = true
+			out = append(out, name+":"+src)
+		}
+	}
+	for i, usage := range axisUsages {
+		target, inverted := strings.CutSuffix(axisMap[usage], "~")
+		src := fmt.Sprintf("a%d", i)
+		if inverted {
+			src += "~"
+		}
+		add(sdlTargetName(target), src)
+	}
+	for n := 1; n <= buttons; n++ {
+		add(sdlTargetName(resolveButtonTarget(mapping, uint16(n))), fmt.Sprintf("b%d", n-1))
+	}
+	if hasHat {
+		add("dpup", "h0.1")
+		add("dpright", "h0.2")
+		add("dpdown", "h0.4")
+		add("dpleft", "h0.8")
+	}
+	return out
+}
+
+// sdlMappingLine formats a gamecontrollerdb.txt line for this platform, its
+// bindings sorted as in the DB. Commas in the name, which would end the
+// field, become spaces.
+func sdlMappingLine(guid, name string, bindings []string) string {
+	sorted := append([]string(nil), bindings...)
+	sort.Strings(sorted)
+	fields := append([]string{guid, strings.ReplaceAll(name, ",", " ")}, sorted...)
+	return strings.Join(fields, ",") + ",platform:" + sdlPlatformName() + ","
+}
+
+// sdlExportName returns a controller name without the " (VID_xxxx&PID_xxxx)"
+// suffix the HID path adds, which SDL DB names do not have.
+func sdlExportName(name string) string {
+	if before, _, ok := strings.Cut(name, " (VID_"); ok && before != "" {
+		return before
+	}
+	return name
+}
+
+// ExportSDLMapping returns the mapping the controller of player playerIndex
+// (0 = the active controller) is read with as a gamecontrollerdb.txt line:
+// its SDL GUID and name, its bindings, and this platform. The line can be
+// added to an external gamecontrollerdb.txt (see LoadSDLDB), contributed to
+// SDL_GameControllerDB, or used with other SDL-based tools. XInput
+// controllers export the fixed XInput layout; controllers read from the SDL
+// DB export their entry, less bindings for targets the Reader does not model.
+// Returns ErrNoController or ErrNoSDLMapping.
+func (r *Reader) ExportSDLMapping(playerIndex int) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info := r.joystickLocked(playerIndex)
+	if info == nil {
+		return "", ErrNoController
+	}
+	guid := sdlGUID(info.devKey.VendorID, info.devKey.ProductID)
+	name := sdlExportName(info.name)
+	if info.sourceType == "xinput" {
+		if guid == "" {
+			guid = "xinput"
+		}
+		return sdlMappingLine(guid, name, xinputSDLBindings), nil
+	}
+	dev := r.hidDevices[info.hDevice]
+	if dev == nil || guid == "" {
+		return "", ErrNoSDLMapping
+	}
+	bindings, ok := dev.sdlBindings()
+	if !ok {
+		return "", ErrNoSDLMapping
+	}
+	if dev.sdlMap != nil {
+		name = dev.sdlMap.Name
+	}
+	return sdlMappingLine(guid, name, bindings), nil
+}
@@ -0,0 +1,107 @@
+package gamepad
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// parsedBindings parses an exported line back and returns its bindings, so
+// tests compare what SDL would read rather than field order.
+func parsedBindings(t *testing.T, line string) *SDLMapping {
+	t.Helper()
+	if !strings.HasSuffix(line, ",platform:"+sdlPlatformName()+",") {
+		t.Fatalf("line %q does not end in this platform", line)
+	}
+	m, err := parseMappingFields(line)
+	if err != nil {
+		t.Fatalf("parseMappingFields(%q) error = %v", line, err)
+	}
+	return m
+}
+
+// TestSDLMappingBindings verifies that an SDL DB entry re-encodes to the
+// bindings it was parsed from.
+func TestSDLMappingBindings(t *testing.T) {
+	const line = "030000004c050000cc09000000000000,PS4 Controller,a:b1,b:b2,back:b8,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,guide:b12,leftshoulder:b4,leftstick:b10,lefttrigger:a3,leftx:a0,lefty:a1,misc1:b13,rightshoulder:b5,rightstick:b11,righttrigger:+a4~,rightx:a2,righty:a5,start:b9,x:b0,y:b3,+rightx:b14,platform:Windows,"
+	want, err := parseMappingFields(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := parsedBindings(t, sdlMappingLine(want.GUID, want.Name, sdlMappingBindings(want)))
+	if got.GUID != want.GUID || got.Name != want.Name {
+		t.Errorf("identity = %s %q, want %s %q", got.GUID, got.Name, want.GUID, want.Name)
+	}
+	if !slices.Equal(got.Buttons, want.Buttons) || !slices.Equal(got.Hats, want.Hats) ||
+		!slices.Equal(got.AxisHalfButtons, want.AxisHalfButtons) {
+		t.Errorf("buttons/hats = %+v %+v %+v, want %+v %+v %+v",
+			got.Buttons, got.Hats, got.AxisHalfButtons, want.Buttons, want.Hats, want.AxisHalfButtons)
+	}
+	sortAxes := func(a []SDLAxisBinding) []SDLAxisBinding {
+		return slices.SortedFunc(slices.Values(a), func(x, y SDLAxisBinding) int { return x.AxisIndex - y.AxisIndex })
+	}
+	if !slices.Equal(sortAxes(got.Axes), sortAxes(want.Axes)) {
+		t.Errorf("axes = %+v, want %+v", got.Axes, want.Axes)
+	}
+}
+
+// TestHIDSDLBindings verifies the conversion of a legacy HID mapping: axes by
+// their position in the usage-sorted order, 0-based buttons, the hat as the
+// dpad, inverted axes, and triggers kept on their axis.
+func TestHIDSDLBindings(t *testing.T) {
+	mapping := &DeviceMapping{
+		Name:       "playstation",
+		HIDAxes:    map[uint16]string{hidUsageX: "left_x", hidUsageY: "left_y~", hidUsageZ: "right_x", hidUsageRz: "right_y", hidUsageRx: "lt", hidUsageRy: "rt"},
+		HIDButtons: map[uint16]string{1: "x", 2: "a", 3: "b", 4: "y", 7: "lt", 13: "guide", 14: "fn1"},
+	}
+	usages := []uint16{hidUsageX, hidUsageY, hidUsageZ, hidUsageRx, hidUsageRy, hidUsageRz}
+	line := sdlMappingLine(sdlGUID(0x054c, 0x05c4), "Wireless Controller", hidSDLBindings(mapping, buildAxisMap(mapping), usages, 14, true))
+	want := "030000004c050000c405000000000000,Wireless Controller,a:b1,b:b2,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,guide:b12,lefttrigger:a3,leftx:a0,lefty:a1~,righttrigger:a4,rightx:a2,righty:a5,x:b0,y:b3,platform:" + sdlPlatformName() + ","
+	if line != want {
+		t.Errorf("line =\n%s\nwant\n%s", line, want)
+	}
+	parsedBindings(t, line)
+
+	// Without HIDButtons, buttons follow defaultButtonOrder; no hat, no dpad.
+	line = sdlMappingLine(sdlGUID(0x0079, 0x0006), "USB, Gamepad", hidSDLBindings(&DeviceMapping{Name: "xbox"}, defaultHIDAxes, usages[:2], 2, false))
+	if !strings.HasPrefix(line, "03000000790000000600000000000000,USB  Gamepad,a:b1,leftx:a0,lefty:a1,x:b0,platform:") {
+		t.Errorf("default line = %s", line)
+	}
+}
+
+// TestSDLExportName verifies that the HID path's VID/PID suffix is dropped.
+func TestSDLExportName(t *testing.T) {
+	for name, want := range map[string]string{
+		"DualSense Wireless Controller (VID_054C&PID_0CE6)": "DualSense Wireless Controller",
+		"Xbox Controller":     "Xbox Controller",
+		"(VID_054C&PID_0CE6)": "(VID_054C&PID_0CE6)",
+	} {
+		if got := sdlExportName(name); got != want {
+			t.Errorf("sdlExportName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestReaderExportSDLMapping(t *testing.T) {
+	r := NewReader()
+	if _, err := r.ExportSDLMapping(0); !errors.Is(err, ErrNoController) {
+		t.Errorf("ExportSDLMapping() with nothing connected = %v, want ErrNoController", err)
+	}
+	r.joysticks[xinputKey(0)] = &joystickInfo{mapping: xboxMapping, name: "Xbox Controller", sourceType: "xinput", devKey: deviceKey{0x045e, 0x028e}}
+	r.joysticks[hidKey(0x100)] = &joystickInfo{mapping: switchProMapping, sourceType: "hid", hDevice: 0x100, devKey: deviceKey{nintendoVendorID, 0x2009}}
+	r.joystickOrder = []joystickKey{xinputKey(0), hidKey(0x100)}
+	r.activeKey, r.hasActive = xinputKey(0), true
+
+	line, err := r.ExportSDLMapping(0)
+	if err != nil {
+		t.Fatalf("ExportSDLMapping(xinput) error = %v", err)
+	}
+	m := parsedBindings(t, line)
+	if m.GUID != "030000005e0400008e02000000000000" || len(m.Axes) != 6 || len(m.Buttons) != 11 || len(m.Hats) != 4 {
+		t.Errorf("xinput export = %s", line)
+	}
+	if _, err := r.ExportSDLMapping(2); !errors.Is(err, ErrNoSDLMapping) {
+		t.Errorf("ExportSDLMapping() of an untracked HID device = %v, want ErrNoSDLMapping", err)
+	}
+}
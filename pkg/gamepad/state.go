@@ -0,0 +1,358 @@
+package gamepad
+
+import (
+	"fmt"
+	"math"
+)
+
+// Vector represents a 2D coordinate.
+type Vector struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// StickState represents the state of an analog stick (position and pressed state).
+type StickState struct {
+	Position Vector `json:"position"`
+	Pressed  bool   `json:"pressed"`
+}
+
+// TriggerState represents the state of a trigger (0.0 to 1.0).
+type TriggerState struct {
+	Value float64 `json:"value"`
+}
+
+// ButtonState represents the state of all face and shoulder buttons.
+type ButtonState struct {
+	A        bool `json:"a"`
+	B        bool `json:"b"`
+	X        bool `json:"x"`
+	Y        bool `json:"y"`
+	LB       bool `json:"lb"`
+	RB       bool `json:"rb"`
+	Back     bool `json:"back"`
+	Start    bool `json:"start"`
+	Guide    bool `json:"guide"`
+	Touchpad bool `json:"touchpad"`
+	Capture  bool `json:"capture"`
+}
+
+// ExtraButtonState represents the buttons beyond the standard layout, named
+// as in SDL: the back paddles (P1/P3 under the right hand, upper and lower;
+// P2/P4 under the left: an Xbox Elite's P1-P4, a DualSense Edge's back
+// buttons as P1/P2) and the DualSense Edge function buttons (Fn1 left, Fn2
+// right). It is omitted on the wire while none is pressed, so controllers
+// without them send nothing; new buttons are added here, not to ButtonState.
+type ExtraButtonState struct {
+	P1  bool `json:"p1"`
+	P2  bool `json:"p2"`
+	P3  bool `json:"p3"`
+	P4  bool `json:"p4"`
+	Fn1 bool `json:"fn1"`
+	Fn2 bool `json:"fn2"`
+}
+
+// DpadState represents the state of the directional pad.
+type DpadState struct {
+	Up    bool `json:"up"`
+	Down  bool `json:"down"`
+	Left  bool `json:"left"`
+	Right bool `json:"right"`
+}
+
+// SticksState represents the state of both analog sticks.
+type SticksState struct {
+	Left  StickState `json:"left"`
+	Right StickState `json:"right"`
+}
+
+// TriggersState represents the state of both triggers.
+type TriggersState struct {
+	LT TriggerState `json:"lt"`
+	RT TriggerState `json:"rt"`
+}
+
+// Capabilities describes the hardware of the active controller, so overlays
+// can adapt their layout. The feature flags come from the device's identity
+// (XInput, or the VID/PID of a known controller); HasRumble, HasGyro, and
+// HasTouchpad describe the hardware, not what InputView reads from it, while
+// BatteryReadable means GamepadState.Battery is populated. NumButtons and
+// NumAxes are the raw input counts the device exposes (0 when unknown).
+type Capabilities struct {
+	HasRumble       bool `json:"hasRumble"`
+	HasGyro         bool `json:"hasGyro"`
+	HasTouchpad     bool `json:"hasTouchpad"`
+	NumButtons      int  `json:"numButtons"`
+	NumAxes         int  `json:"numAxes"`
+	BatteryReadable bool `json:"batteryReadable"`
+}
+
+// GamepadState represents the complete state of a connected gamepad.
+type GamepadState struct {
+	Connected      bool             `json:"connected"`
+	ControllerType string           `json:"controllerType"`
+	Name           string           `json:"name"`
+	PlayerIndex    int              `json:"playerIndex"`              // 1-based position in connection order; the routing key for clients
+	DeviceID       string           `json:"deviceId,omitempty"`       // identifies the connected device; see ControllerInfo.DeviceID
+	Serial         string           `json:"serial,omitempty"`         // HID serial number string; omitted if the device reports none
+	ProductVersion uint16           `json:"productVersion,omitempty"` // USB bcdDevice / XInput version (firmware revision)
+	Capabilities   Capabilities     `json:"capabilities,omitzero"`    // omitted while no controller is connected
+	Battery        BatteryState     `json:"battery,omitzero"`         // omitted if the device reports no power state
+	Motion         MotionState      `json:"motion,omitzero"`          // omitted unless Reader.SetMotion is on and the device reports it
+	Buttons        ButtonState      `json:"buttons"`
+	Extra          ExtraButtonState `json:"extra,omitzero"` // paddles and function buttons; omitted while none is pressed
+	Dpad           DpadState        `json:"dpad"`
+	Sticks         SticksState      `json:"sticks"`
+	Triggers       TriggersState    `json:"triggers"`
+	Wheel          WheelState       `json:"wheel,omitzero"`  // steering wheels only; omitted while centred, released, and in neutral
+	Flight         FlightState      `json:"flight,omitzero"` // flight sticks only; omitted while centred, idle, and released
+	Raw            RawState         `json:"raw,omitzero"`    // raw mode only (see Reader.SetRawMode)
+}
+
+// DeltaChanges represents incremental changes to gamepad state for efficient updates.
+// Pointer fields indicate which values have changed (nil = unchanged).
+type DeltaChanges struct {
+	Connected      *bool             `json:"connected,omitempty"`
+	ControllerType *string           `json:"controllerType,omitempty"`
+	Name           *string           `json:"name,omitempty"`
+	DeviceID       *string           `json:"deviceId,omitempty"`
+	Serial         *string           `json:"serial,omitempty"`
+	ProductVersion *uint16           `json:"productVersion,omitempty"`
+	Capabilities   *Capabilities     `json:"capabilities,omitempty"`
+	Battery        *BatteryState     `json:"battery,omitempty"`
+	Motion         *MotionState      `json:"motion,omitempty"`
+	Buttons        *ButtonState      `json:"buttons,omitempty"`
+	Extra          *ExtraButtonState `json:"extra,omitempty"`
+	Dpad           *DpadState        `json:"dpad,omitempty"`
+	Sticks         *SticksState      `json:"sticks,omitempty"`
+	Triggers       *TriggersState    `json:"triggers,omitempty"`
+	Wheel          *WheelState       `json:"wheel,omitempty"`
+	Flight         *FlightState      `json:"flight,omitempty"`
+	Raw            *RawState         `json:"raw,omitempty"`
+}
+
+// IsEmpty returns true if no changes are present.
+func (d *DeltaChanges) IsEmpty() bool {
+	return d.Connected == nil &&
+		d.ControllerType == nil &&
+		d.Name == nil &&
+		d.DeviceID == nil &&
+		d.Serial == nil &&
+		d.ProductVersion == nil &&
+		d.Capabilities == nil &&
+		d.Battery == nil &&
+		d.Motion == nil &&
+		d.Buttons == nil &&
+		d.Extra == nil &&
+		d.Dpad == nil &&
+		d.Sticks == nil &&
+		d.Triggers == nil &&
+		d.Wheel == nil &&
+		d.Flight == nil &&
+		d.Raw == nil
+}
+
+// analogThreshold is the minimum difference between two analog values to be considered different.
+const analogThreshold = 0.01
+
+// floatEqual returns true if two floats are approximately equal within analogThreshold.
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) < analogThreshold
+}
+
+// ComputeDelta calculates the incremental changes between two gamepad states.
+func ComputeDelta(old, new_ GamepadState) *DeltaChanges {
+	d := &DeltaChanges{}
+
+	if old.Connected != new_.Connected {
+		d.Connected = &new_.Connected
+	}
+	if old.ControllerType != new_.ControllerType {
+		d.ControllerType = &new_.ControllerType
+	}
+	if old.Name != new_.Name {
+		d.Name = &new_.Name
+	}
+	if old.DeviceID != new_.DeviceID {
+		d.DeviceID = &new_.DeviceID
+	}
+	if old.Serial != new_.Serial {
+		d.Serial = &new_.Serial
+	}
+	if old.ProductVersion != new_.ProductVersion {
+		d.ProductVersion = &new_.ProductVersion
+	}
+	if old.Capabilities != new_.Capabilities {
+		d.Capabilities = &new_.Capabilities
+	}
+	if old.Battery != new_.Battery {
+		d.Battery = &new_.Battery
+	}
+	if !motionEqual(old.Motion, new_.Motion) {
+		d.Motion = &new_.Motion
+	}
+	if old.Buttons != new_.Buttons {
+		d.Buttons = &new_.Buttons
+	}
+	if old.Extra != new_.Extra {
+		d.Extra = &new_.Extra
+	}
+	if old.Dpad != new_.Dpad {
+		d.Dpad = &new_.Dpad
+	}
+
+	if !floatEqual(old.Sticks.Left.Position.X, new_.Sticks.Left.Position.X) ||
+		!floatEqual(old.Sticks.Left.Position.Y, new_.Sticks.Left.Position.Y) ||
+		old.Sticks.Left.Pressed != new_.Sticks.Left.Pressed ||
+		!floatEqual(old.Sticks.Right.Position.X, new_.Sticks.Right.Position.X) ||
+		!floatEqual(old.Sticks.Right.Position.Y, new_.Sticks.Right.Position.Y) ||
+		old.Sticks.Right.Pressed != new_.Sticks.Right.Pressed {
+		d.Sticks = &new_.Sticks
+	}
+
+	if !floatEqual(old.Triggers.LT.Value, new_.Triggers.LT.Value) ||
+		!floatEqual(old.Triggers.RT.Value, new_.Triggers.RT.Value) {
+		d.Triggers = &new_.Triggers
+	}
+	if !wheelEqual(old.Wheel, new_.Wheel) {
+		d.Wheel = &new_.Wheel
+	}
+	if !flightEqual(old.Flight, new_.Flight) {
+		d.Flight = &new_.Flight
+	}
+	if !rawEqual(old.Raw, new_.Raw) {
+		d.Raw = &new_.Raw
+	}
+
+	return d
+}
+
+// ApplyDelta returns base with every non-nil field of d applied — the inverse
+// of ComputeDelta, matching how clients merge "delta" messages. PlayerIndex is
+// carried over from base (DeltaChanges does not contain it).
+func ApplyDelta(base GamepadState, d *DeltaChanges) GamepadState {
+	if d == nil {
+		return base
+	}
+	if d.Connected != nil {
+		base.Connected = *d.Connected
+	}
+	if d.ControllerType != nil {
+		base.ControllerType = *d.ControllerType
+	}
+	if d.Name != nil {
+		base.Name = *d.Name
+	}
+	if d.DeviceID != nil {
+		base.DeviceID = *d.DeviceID
+	}
+	if d.Serial != nil {
+		base.Serial = *d.Serial
+	}
+	if d.ProductVersion != nil {
+		base.ProductVersion = *d.ProductVersion
+	}
+	if d.Capabilities != nil {
+		base.Capabilities = *d.Capabilities
+	}
+	if d.Battery != nil {
+		base.Battery = *d.Battery
+	}
+	if d.Motion != nil {
+		base.Motion = *d.Motion
+	}
+	if d.Buttons != nil {
+		base.Buttons = *d.Buttons
+	}
+	if d.Extra != nil {
+		base.Extra = *d.Extra
+	}
+	if d.Dpad != nil {
+		base.Dpad = *d.Dpad
+	}
+	if d.Sticks != nil {
+		base.Sticks = *d.Sticks
+	}
+	if d.Triggers != nil {
+		base.Triggers = *d.Triggers
+	}
+	if d.Wheel != nil {
+		base.Wheel = *d.Wheel
+	}
+	if d.Flight != nil {
+		base.Flight = *d.Flight
+	}
+	if d.Raw != nil {
+		base.Raw = *d.Raw
+	}
+	return base
+}
+
+// Validate reports whether s is within the ranges the protocol guarantees:
+// stick axes, the wheel angle, and flight stick and raw axes in [-1, 1],
+// trigger, pedal, and thrust values in [0, 1], a gear in [-1, MaxWheelGear],
+// raw hats and counts within their limits, a non-negative PlayerIndex and
+// capability counts, a battery level in [0, 100], and finite motion
+// readings. Use it on states that come from outside
+// the reader (injected or replayed), where an out-of-range value would
+// otherwise render silently wrong.
+func (s GamepadState) Validate() error {
+	axes := []struct {
+		name  string
+		value float64
+		min   float64
+	}{
+		{"sticks.left.position.x", s.Sticks.Left.Position.X, -1},
+		{"sticks.left.position.y", s.Sticks.Left.Position.Y, -1},
+		{"sticks.right.position.x", s.Sticks.Right.Position.X, -1},
+		{"sticks.right.position.y", s.Sticks.Right.Position.Y, -1},
+		{"triggers.lt.value", s.Triggers.LT.Value, 0},
+		{"triggers.rt.value", s.Triggers.RT.Value, 0},
+		{"wheel.angle", s.Wheel.Angle, -1},
+		{"wheel.throttle", s.Wheel.Throttle, 0},
+		{"wheel.brake", s.Wheel.Brake, 0},
+		{"wheel.clutch", s.Wheel.Clutch, 0},
+		{"wheel.handbrake", s.Wheel.Handbrake, 0},
+		{"flight.roll", s.Flight.Roll, -1},
+		{"flight.pitch", s.Flight.Pitch, -1},
+		{"flight.yaw", s.Flight.Yaw, -1},
+		{"flight.thrust", s.Flight.Thrust, 0},
+	}
+	for _, a := range axes {
+		if !(a.value >= a.min && a.value <= 1) { // also rejects NaN
+			return fmt.Errorf("%s = %g: want a value in [%g, 1]", a.name, a.value, a.min)
+		}
+	}
+	for i, v := range s.Flight.Axes {
+		if !(v >= -1 && v <= 1) {
+			return fmt.Errorf("flight.axes[%d] = %g: want a value in [-1, 1]", i, v)
+		}
+	}
+	if err := validateRaw(s.Raw); err != nil {
+		return err
+	}
+	if s.Wheel.Gear < -1 || s.Wheel.Gear > MaxWheelGear {
+		return fmt.Errorf("wheel.gear = %d: want a value in [-1, %d]", s.Wheel.Gear, MaxWheelGear)
+	}
+	if s.PlayerIndex < 0 {
+		return fmt.Errorf("playerIndex = %d: want >= 0", s.PlayerIndex)
+	}
+	if s.Capabilities.NumButtons < 0 || s.Capabilities.NumAxes < 0 {
+		return fmt.Errorf("capabilities = %d buttons, %d axes: want >= 0", s.Capabilities.NumButtons, s.Capabilities.NumAxes)
+	}
+	if s.Battery.Level < 0 || s.Battery.Level > 100 {
+		return fmt.Errorf("battery.level = %d: want a value in [0, 100]", s.Battery.Level)
+	}
+	motion := []struct {
+		name string
+		v    Vector3
+	}{{"motion.gyro", s.Motion.Gyro}, {"motion.accel", s.Motion.Accel}}
+	for _, m := range motion {
+		for _, c := range []float64{m.v.X, m.v.Y, m.v.Z} {
+			if math.IsNaN(c) || math.IsInf(c, 0) {
+				return fmt.Errorf("%s = %+v: want finite values", m.name, m.v)
+			}
+		}
+	}
+	return nil
+}
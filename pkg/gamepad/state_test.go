@@ -0,0 +1,95 @@
+package gamepad
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestApplyDeltaRoundTrip verifies that ApplyDelta(old, ComputeDelta(old, new))
+// reproduces new for the fields a delta carries.
+func TestApplyDeltaRoundTrip(t *testing.T) {
+	old := GamepadState{Connected: true, ControllerType: "xbox", Name: "Pad", PlayerIndex: 2}
+	next := old
+	next.Name = "Pad 2"
+	next.Capabilities = Capabilities{HasRumble: true, NumButtons: 11, NumAxes: 6}
+	next.Serial = "a0ab51c0ffee"
+	next.ProductVersion = 0x0100
+	next.Buttons.B = true
+	next.Extra.P2 = true
+	next.Dpad.Left = true
+	next.Sticks.Right.Position = Vector{X: -0.5, Y: 0.5}
+	next.Triggers.LT.Value = 0.4
+	next.Motion = MotionState{Gyro: Vector3{X: 0.5}, Accel: Vector3{Y: 9.8}}
+	next.Wheel = WheelState{Angle: -0.25, Throttle: 0.8, Gear: 3}
+	next.Flight = FlightState{Roll: 0.5, Axes: [MaxFlightAxes]float64{1: -0.5}, Buttons: [MaxFlightButtons / 32]uint32{2: 4}}
+	next.Flight.Hats[1].Up = true
+	next.Raw = RawState{Axes: [MaxRawAxes]float64{15: 0.75}, Hats: [MaxRawHats]uint8{RawHatUp | RawHatLeft}, NumAxes: 16, NumHats: 1}
+
+	got := ApplyDelta(old, ComputeDelta(old, next))
+	if got != next {
+		t.Errorf("ApplyDelta round trip = %+v, want %+v", got, next)
+	}
+}
+
+// TestApplyDeltaPartial verifies that nil delta fields leave the base untouched.
+func TestApplyDeltaPartial(t *testing.T) {
+	base := GamepadState{Connected: true, Name: "Pad", PlayerIndex: 1}
+	base.Buttons.A = true
+	base.Sticks.Left.Position.X = 0.7
+
+	pressed := DpadState{Up: true}
+	got := ApplyDelta(base, &DeltaChanges{Dpad: &pressed})
+
+	if !got.Dpad.Up {
+		t.Errorf("Dpad.Up = false, want true (applied)")
+	}
+	if !got.Buttons.A || got.Sticks.Left.Position.X != 0.7 || got.Name != "Pad" || got.PlayerIndex != 1 {
+		t.Errorf("ApplyDelta changed fields absent from the delta: %+v", got)
+	}
+	if ApplyDelta(base, nil) != base {
+		t.Errorf("ApplyDelta(base, nil) != base")
+	}
+}
+
+// TestGamepadStateValidate verifies the protocol range checks.
+func TestGamepadStateValidate(t *testing.T) {
+	valid := GamepadState{Connected: true, PlayerIndex: 1}
+	valid.Sticks.Left.Position = Vector{X: -1, Y: 1}
+	valid.Triggers.RT.Value = 1
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() on boundary values = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(s *GamepadState)
+		wantErr string
+	}{
+		{"negative button count", func(s *GamepadState) { s.Capabilities.NumButtons = -1 }, "capabilities"},
+		{"stick above 1", func(s *GamepadState) { s.Sticks.Right.Position.X = 1.01 }, "sticks.right.position.x"},
+		{"stick below -1", func(s *GamepadState) { s.Sticks.Left.Position.Y = -2 }, "sticks.left.position.y"},
+		{"stick NaN", func(s *GamepadState) { s.Sticks.Left.Position.X = math.NaN() }, "sticks.left.position.x"},
+		{"negative trigger", func(s *GamepadState) { s.Triggers.LT.Value = -0.1 }, "triggers.lt.value"},
+		{"negative player", func(s *GamepadState) { s.PlayerIndex = -1 }, "playerIndex"},
+		{"gyro NaN", func(s *GamepadState) { s.Motion.Gyro.Z = math.NaN() }, "motion.gyro"},
+		{"accel infinite", func(s *GamepadState) { s.Motion.Accel.X = math.Inf(-1) }, "motion.accel"},
+		{"wheel past full lock", func(s *GamepadState) { s.Wheel.Angle = -1.5 }, "wheel.angle"},
+		{"pedal NaN", func(s *GamepadState) { s.Wheel.Brake = math.NaN() }, "wheel.brake"},
+		{"gear above max", func(s *GamepadState) { s.Wheel.Gear = MaxWheelGear + 1 }, "wheel.gear"},
+		{"negative thrust", func(s *GamepadState) { s.Flight.Thrust = -0.5 }, "flight.thrust"},
+		{"flight axis above 1", func(s *GamepadState) { s.Flight.Axes[7] = 1.5 }, "flight.axes[7]"},
+		{"raw axis NaN", func(s *GamepadState) { s.Raw.Axes[3] = math.NaN() }, "raw.axes[3]"},
+		{"raw hat past left", func(s *GamepadState) { s.Raw.Hats[0] = 16 }, "raw.hats[0]"},
+		{"raw axis count above max", func(s *GamepadState) { s.Raw.NumAxes = MaxRawAxes + 1 }, "raw counts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.mutate(&s)
+			if err := s.Validate(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
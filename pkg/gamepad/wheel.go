@@ -0,0 +1,74 @@
+package gamepad
+
+import "strings"
+
+// MaxWheelGear is the highest gear WheelState.Gear reports.
+const MaxWheelGear = 7
+
+// WheelState represents a steering wheel (ControllerType "wheel") with its
+// pedals, handbrake, and shifter. Angle is the wheel's rotation, -1 (full
+// lock left) to 1 (full lock right), as a fraction of the rotation range set
+// in the wheel's driver. Throttle, Brake, Clutch, and Handbrake are 0
+// (released) to 1. Gear is the H-shifter's gear: -1 reverse, 0 neutral, 1 to
+// MaxWheelGear. It is omitted on the wire while the wheel is centred,
+// everything is released, and the shifter is in neutral, so gamepads send
+// nothing. Paddle and sequential shifters are plain buttons.
+type WheelState struct {
+	Angle     float64 `json:"angle"`
+	Throttle  float64 `json:"throttle"`
+	Brake     float64 `json:"brake"`
+	Clutch    float64 `json:"clutch"`
+	Handbrake float64 `json:"handbrake"`
+	Gear      int     `json:"gear"`
+}
+
+// wheelEqual reports whether two wheel readings differ by less than
+// analogThreshold on every axis and have the same gear.
+func wheelEqual(a, b WheelState) bool {
+	return floatEqual(a.Angle, b.Angle) &&
+		floatEqual(a.Throttle, b.Throttle) &&
+		floatEqual(a.Brake, b.Brake) &&
+		floatEqual(a.Clutch, b.Clutch) &&
+		floatEqual(a.Handbrake, b.Handbrake) &&
+		a.Gear == b.Gear
+}
+
+// isTriggerTarget reports whether the axis target is one-sided, read from 0
+// (released) to 1: the triggers, the pedals, and a flight stick's thrust.
+func isTriggerTarget(target string) bool {
+	switch target {
+	case "lt", "rt", "throttle", "brake", "clutch", "handbrake", "thrust":
+		return true
+	}
+	return false
+}
+
+// hidAxisValue reads a raw HID axis value for the HIDAxes target, which may
+// end in "~" for an axis that reads its maximum at rest, like the pedals of
+// most wheels. It returns the target without the suffix and the value to
+// pass to applyAxisToState, with the deadzone of dz for the target. The
+// wheel's angle gets none, so that small corrections show.
+func hidAxisValue(target string, raw uint32, logMin, logMax int32, dz Deadzones) (string, float64) {
+	target, inverted := strings.CutSuffix(target, "~")
+	isTrigger := isTriggerTarget(target)
+	v := normalizeHIDAxis(raw, logMin, logMax, isTrigger)
+	switch {
+	case inverted && isTrigger:
+		v = 1 - v
+	case inverted:
+		v = -v
+	}
+	return target, applyDeadzone(v, dz.of(target))
+}
+
+// wheelGear returns the gear of a "gear1".."gear7" or "gearr" button target.
+func wheelGear(target string) (int, bool) {
+	if target == "gearr" {
+		return -1, true
+	}
+	n, ok := strings.CutPrefix(target, "gear")
+	if !ok || len(n) != 1 || n[0] < '1' || n[0] > '0'+MaxWheelGear {
+		return 0, false
+	}
+	return int(n[0] - '0'), true
+}
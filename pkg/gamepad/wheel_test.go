@@ -0,0 +1,102 @@
+package gamepad
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestHIDAxisValue verifies that pedals are read one-sided, that "~" targets
+// are inverted, and that only the wheel angle skips the deadzone.
+func TestHIDAxisValue(t *testing.T) {
+	tests := []struct {
+		target     string
+		raw        uint32
+		wantTarget string
+		want       float64
+	}{
+		{"throttle~", 1023, "throttle", 0},   // released
+		{"throttle~", 0, "throttle", 1},      // floored
+		{"brake", 1023, "brake", 1},          // not inverted
+		{"clutch~", 1013, "clutch", 0},       // within the deadzone
+		{"wheel", 514, "wheel", 2.5 / 511.5}, // no deadzone
+		{"left_x", 514, "left_x", 0},         // deadzone
+		{"left_x~", 1023, "left_x", -1},      // inverted stick
+		{"handbrake", 512, "handbrake", 512.0 / 1023},
+	}
+	for _, tt := range tests {
+		target, got := hidAxisValue(tt.target, tt.raw, 0, 1023, uniformDeadzones(0.05))
+		if target != tt.wantTarget || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("hidAxisValue(%q, %d) = %q, %g; want %q, %g", tt.target, tt.raw, target, got, tt.wantTarget, tt.want)
+		}
+	}
+}
+
+// TestApplyWheelTargets verifies the wheel's axis and button targets.
+func TestApplyWheelTargets(t *testing.T) {
+	var s GamepadState
+	applyAxisToState(&s, "wheel", -0.5)
+	applyAxisToState(&s, "throttle", 0.75)
+	applyAxisToState(&s, "brake", 0.25)
+	applyButton(&s, "gear4")
+	want := WheelState{Angle: -0.5, Throttle: 0.75, Brake: 0.25, Gear: 4}
+	if s.Wheel != want {
+		t.Errorf("Wheel = %+v, want %+v", s.Wheel, want)
+	}
+
+	applyButton(&s, "gearr")
+	applyButton(&s, "handbrake")
+	if s.Wheel.Gear != -1 || s.Wheel.Handbrake != 1 {
+		t.Errorf("Wheel = %+v, want reverse and the handbrake pulled", s.Wheel)
+	}
+	s.Wheel.Handbrake = 0.4
+	applyButton(&s, "handbrake")
+	if s.Wheel.Handbrake != 0.4 {
+		t.Errorf("handbrake button overwrote the analog value: %g", s.Wheel.Handbrake)
+	}
+
+	for _, target := range []string{"gear0", "gear8", "gear", "gear10"} {
+		if _, ok := wheelGear(target); ok {
+			t.Errorf("wheelGear(%q) accepted", target)
+		}
+	}
+}
+
+// TestWheelMappings verifies that the built-in wheel mappings only use
+// targets a mapping file accepts, and that they win over the SDL database.
+func TestWheelMappings(t *testing.T) {
+	LoadSDLDB("")
+	wheels := 0
+	for k, m := range knownDevices {
+		if m.Name != "wheel" {
+			continue
+		}
+		wheels++
+		for usage, target := range m.HIDAxes {
+			if !slices.Contains(axisTargets, strings.TrimSuffix(target, "~")) {
+				t.Errorf("%04x:%04x axis 0x%02x: unknown target %q", k.VendorID, k.ProductID, usage, target)
+			}
+		}
+		for n, target := range m.HIDButtons {
+			if !slices.Contains(buttonTargets, target) {
+				t.Errorf("%04x:%04x button %d: unknown target %q", k.VendorID, k.ProductID, n, target)
+			}
+		}
+		if lookupSDLMapping(k.VendorID, k.ProductID) != nil {
+			t.Errorf("lookupSDLMapping(%04x:%04x) = SDL mapping, want the built-in wheel mapping", k.VendorID, k.ProductID)
+		}
+	}
+	if wheels == 0 {
+		t.Fatal("no built-in wheel mappings")
+	}
+
+	keys, m, err := parseMappingFile(strings.NewReader(`{"type": "wheel", "devices": ["1234:5678"],
+		"hidAxes": {"x": "wheel", "rz": "throttle~", "ry": "brake~"}, "hidButtons": {"9": "gearr"}}`))
+	if err != nil || len(keys) != 1 || m.Name != "wheel" || m.HIDAxes[hidUsageRz] != "throttle~" || m.HIDButtons[9] != "gearr" {
+		t.Errorf("parseMappingFile(wheel) = %v, %+v, %v", keys, m, err)
+	}
+	if _, _, err := parseMappingFile(strings.NewReader(`{"type": "wheel", "devices": ["1234:5678"], "hidAxes": {"x": "wheel~~"}}`)); err == nil {
+		t.Error("parseMappingFile() accepted target \"wheel~~\"")
+	}
+}
@@ -0,0 +1,122 @@
+package gamepad
+
+// xinput_shared.go — platform-agnostic XInput types and conversion.
+//
+// The XInput DLL bindings live in xinput_windows.go. The struct mirrors and
+// convertXInputState are kept here so recorded XInput states can be replayed
+// (see capture.go) on any platform.
+
+import "fmt"
+
+const (
+	triggerMax = 255.0 // XINPUT trigger range: 0-255
+)
+
+// XInput button bitmasks (XINPUT_GAMEPAD_* constants)
+const (
+	xiDpadUp        uint16 = 0x0001
+	xiDpadDown      uint16 = 0x0002
+	xiDpadLeft      uint16 = 0x0004
+	xiDpadRight     uint16 = 0x0008
+	xiStart         uint16 = 0x0010
+	xiBack          uint16 = 0x0020
+	xiLeftThumb     uint16 = 0x0040
+	xiRightThumb    uint16 = 0x0080
+	xiLeftShoulder  uint16 = 0x0100
+	xiRightShoulder uint16 = 0x0200
+	xiGuide         uint16 = 0x0400 // only via XInputGetStateEx (ordinal 100)
+	xiA             uint16 = 0x1000
+	xiB             uint16 = 0x2000
+	xiX             uint16 = 0x4000
+	xiY             uint16 = 0x8000
+)
+
+// xinputGamepad mirrors XINPUT_GAMEPAD.
+type xinputGamepad struct {
+	Buttons      uint16
+	LeftTrigger  uint8
+	RightTrigger uint8
+	ThumbLX      int16
+	ThumbLY      int16
+	ThumbRX      int16
+	ThumbRY      int16
+}
+
+// xinputState mirrors XINPUT_STATE.
+type xinputState struct {
+	PacketNumber uint32
+	Gamepad      xinputGamepad
+}
+
+// convertXInputState converts a raw xinputState to a GamepadState.
+// dz are the deadzones to apply to analog inputs.
+func convertXInputState(xs *xinputState, info *joystickInfo, dz Deadzones) GamepadState {
+	gp := xs.Gamepad
+	mapping := info.mapping
+
+	state := GamepadState{
+		Connected:      true,
+		ControllerType: mapping.Name,
+		Name:           info.name,
+	}
+
+	// Triggers: uint8 (0-255) → float64 (0.0-1.0)
+	ltRaw := float64(gp.LeftTrigger) / triggerMax
+	rtRaw := float64(gp.RightTrigger) / triggerMax
+	state.Triggers.LT.Value = applyDeadzone(ltRaw, dz.Trigger)
+	state.Triggers.RT.Value = applyDeadzone(rtRaw, dz.Trigger)
+
+	// Sticks: int16 → float64 (-1.0 to 1.0)
+	// XInput Y axes are positive-up. The frontend canvas rendering inverts Y
+	// (knobY = s.y - position.y * maxTravel), so we pass the raw value unchanged.
+	state.Sticks.Left.Position = dz.stick(Vector{X: normalizeAxis(gp.ThumbLX), Y: normalizeAxis(gp.ThumbLY)})
+	state.Sticks.Right.Position = dz.stick(Vector{X: normalizeAxis(gp.ThumbRX), Y: normalizeAxis(gp.ThumbRY)})
+
+	// Buttons
+	btn := gp.Buttons
+	state.Buttons.A = btn&xiA != 0
+	state.Buttons.B = btn&xiB != 0
+	state.Buttons.X = btn&xiX != 0
+	state.Buttons.Y = btn&xiY != 0
+	state.Buttons.LB = btn&xiLeftShoulder != 0
+	state.Buttons.RB = btn&xiRightShoulder != 0
+	state.Buttons.Back = btn&xiBack != 0
+	state.Buttons.Start = btn&xiStart != 0
+	state.Buttons.Guide = btn&xiGuide != 0
+	state.Sticks.Left.Pressed = btn&xiLeftThumb != 0
+	state.Sticks.Right.Pressed = btn&xiRightThumb != 0
+
+	// D-pad
+	state.Dpad.Up = btn&xiDpadUp != 0
+	state.Dpad.Down = btn&xiDpadDown != 0
+	state.Dpad.Left = btn&xiDpadLeft != 0
+	state.Dpad.Right = btn&xiDpadRight != 0
+
+	_ = mapping // Name is used above; axis remapping not needed for XInput
+	return state
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// xinputSubTypeArcadeStick is XINPUT_DEVSUBTYPE_ARCADE_STICK.
+const xinputSubTypeArcadeStick = 0x03
+
+// xinputMapping returns the mapping of an XInput controller whose VID/PID
+// gives mapping: arcadeXboxMapping for an arcade stick that is not in the
+// table (only the generic xboxMapping is overridden), mapping otherwise.
+func xinputMapping(mapping *DeviceMapping, subType uint8) *DeviceMapping {
+	if mapping == xboxMapping && subType == xinputSubTypeArcadeStick {
+		return arcadeXboxMapping
+	}
+	return mapping
+}
+
+// buildControllerName constructs a human-readable controller name.
+func buildControllerName(mappingName, vidPID string) string {
+	if vidPID != "" {
+		return fmt.Sprintf("%s (%s)", mappingName, vidPID)
+	}
+	return mappingName
+}
@@ -25,6 +25,8 @@ var (
 	modXInput                 *syscall.LazyDLL
 	procXInputGetState        *syscall.LazyProc
 	procXInputGetCapabilities *syscall.LazyProc
+	procXInputGetBattery      *syscall.LazyProc // XInputGetBatteryInformation; absent in xinput9_1_0
+	procXInputSetState        *syscall.LazyProc
 
 	// Ordinal-based procs resolved via GetProcAddress(hModule, MAKEINTRESOURCE(ordinal)).
 	// syscall.LazyProc does not support ordinal lookup ("#100" is treated as a literal name),
@@ -37,6 +39,8 @@ func init() {
 	modXInput = loadXInputDLL()
 	procXInputGetState = modXInput.NewProc("XInputGetState")
 	procXInputGetCapabilities = modXInput.NewProc("XInputGetCapabilities")
+	procXInputGetBattery = modXInput.NewProc("XInputGetBatteryInformation")
+	procXInputSetState = modXInput.NewProc("XInputSetState")
 
 	// Resolve ordinal-exported functions manually.
 	// GetProcAddress accepts MAKEINTRESOURCE(ordinal) = uintptr(ordinal) as the proc name
@@ -76,42 +80,6 @@ const (
 	errorDeviceNotConnected uint32 = 1167 // ERROR_DEVICE_NOT_CONNECTED
 )
 
-// XInput button bitmasks (XINPUT_GAMEPAD_* constants)
-const (
-	xiDpadUp        uint16 = 0x0001
-	xiDpadDown      uint16 = 0x0002
-	xiDpadLeft      uint16 = 0x0004
-	xiDpadRight     uint16 = 0x0008
-	xiStart         uint16 = 0x0010
-	xiBack          uint16 = 0x0020
-	xiLeftThumb     uint16 = 0x0040
-	xiRightThumb    uint16 = 0x0080
-	xiLeftShoulder  uint16 = 0x0100
-	xiRightShoulder uint16 = 0x0200
-	xiGuide         uint16 = 0x0400 // only via XInputGetStateEx (ordinal 100)
-	xiA             uint16 = 0x1000
-	xiB             uint16 = 0x2000
-	xiX             uint16 = 0x4000
-	xiY             uint16 = 0x8000
-)
-
-// xinputGamepad mirrors XINPUT_GAMEPAD.
-type xinputGamepad struct {
-	Buttons      uint16
-	LeftTrigger  uint8
-	RightTrigger uint8
-	ThumbLX      int16
-	ThumbLY      int16
-	ThumbRX      int16
-	ThumbRY      int16
-}
-
-// xinputState mirrors XINPUT_STATE.
-type xinputState struct {
-	PacketNumber uint32
-	Gamepad      xinputGamepad
-}
-
 // xinputCapabilities mirrors XINPUT_CAPABILITIES.
 type xinputCapabilities struct {
 	Type      uint8
@@ -153,11 +121,11 @@ func xiGetStateEx(userIndex uint32, state *xinputState) uint32 {
 }
 
 // xiGetCapabilitiesEx calls the undocumented XInputGetCapabilitiesEx (ordinal 108)
-// to obtain VID/PID. Returns (vendorID, productID, true) on success, or (0, 0, false)
-// if the ordinal is unavailable (e.g., on Windows 7 with xinput1_3.dll).
-func xiGetCapabilitiesEx(userIndex uint32) (vendorID, productID uint16, ok bool) {
+// to obtain VID/PID and the product version. Returns ok=false if the ordinal
+// is unavailable (e.g., on Windows 7 with xinput1_3.dll).
+func xiGetCapabilitiesEx(userIndex uint32) (vendorID, productID, version uint16, ok bool) {
 	if addrXInputGetCapabilitiesEx == 0 {
-		return 0, 0, false
+		return 0, 0, 0, false
 	}
 	var capsEx xinputCapabilitiesEx
 	// Signature: DWORD XInputGetCapabilitiesEx(DWORD reserved, DWORD dwUserIndex, DWORD dwFlags, XINPUT_CAPABILITIES_EX* pCapabilities)
@@ -169,7 +137,61 @@ func xiGetCapabilitiesEx(userIndex uint32) (vendorID, productID uint16, ok bool)
 		uintptr(unsafe.Pointer(&capsEx)),
 	)
 	if uint32(ret) != errorSuccess {
-		return 0, 0, false
+		return 0, 0, 0, false
+	}
+	return capsEx.VendorID, capsEx.ProductID, capsEx.VersionNumber, true
+}
+
+// xiSubType returns the device subtype XInputGetCapabilities reports for the
+// controller, or 0 on failure.
+func xiSubType(userIndex uint32) uint8 {
+	var caps xinputCapabilities
+	ret, _, _ := procXInputGetCapabilities.Call(
+		uintptr(userIndex),
+		0, // dwFlags (0 = all devices)
+		uintptr(unsafe.Pointer(&caps)),
+	)
+	if uint32(ret) != errorSuccess {
+		return 0
+	}
+	return caps.SubType
+}
+
+// xinputBatteryInformation mirrors XINPUT_BATTERY_INFORMATION.
+type xinputBatteryInformation struct {
+	BatteryType  uint8
+	BatteryLevel uint8
+}
+
+// batteryDevTypeGamepad is BATTERY_DEVTYPE_GAMEPAD.
+const batteryDevTypeGamepad = 0
+
+// xiGetBattery calls XInputGetBatteryInformation for the gamepad in slot
+// userIndex. ok is false if the function is unavailable or fails.
+func xiGetBattery(userIndex uint32) (BatteryState, bool) {
+	if procXInputGetBattery.Find() != nil {
+		return BatteryState{}, false
+	}
+	var info xinputBatteryInformation
+	ret, _, _ := procXInputGetBattery.Call(uintptr(userIndex), batteryDevTypeGamepad, uintptr(unsafe.Pointer(&info)))
+	if uint32(ret) != errorSuccess {
+		return BatteryState{}, false
+	}
+	return xinputBattery(info.BatteryType, info.BatteryLevel), true
+}
+
+// xinputVibration mirrors XINPUT_VIBRATION.
+type xinputVibration struct {
+	LeftMotorSpeed  uint16 // low-frequency motor
+	RightMotorSpeed uint16 // high-frequency motor
+}
+
+// xiSetVibration calls XInputSetState to set the motor speeds of a slot.
+func xiSetVibration(userIndex uint32, low, high uint16) bool {
+	if procXInputSetState.Find() != nil {
+		return false
 	}
-	return capsEx.VendorID, capsEx.ProductID, true
+	v := xinputVibration{LeftMotorSpeed: low, RightMotorSpeed: high}
+	ret, _, _ := procXInputSetState.Call(uintptr(userIndex), uintptr(unsafe.Pointer(&v)))
+	return uint32(ret) == errorSuccess
 }